@@ -0,0 +1,8 @@
+// Package main provides a binary and large file write guard for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/blobguard"
+
+func main() {
+	blobguard.Main()
+}
@@ -0,0 +1,8 @@
+// Package main provides a protected branch guard for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/branchguard"
+
+func main() {
+	branchguard.Main()
+}
@@ -0,0 +1,8 @@
+// Package main provides a Bash command rewriter for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/commandrewrite"
+
+func main() {
+	commandrewrite.Main()
+}
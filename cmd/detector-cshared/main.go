@@ -0,0 +1,34 @@
+//go:build cgo
+
+// Package main builds the command detector as a C shared library. Build with
+// "make build-cshared", which also writes the header declaring:
+//
+//	char *claudecode_hooks_evaluate(char *rules_json, char *command);
+//	void claudecode_hooks_free(char *result);
+//
+// The result is a JSON string owned by the caller, who must release it with
+// claudecode_hooks_free.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/krmcbride/claudecode-hooks/internal/evaluate"
+)
+
+//export claudecode_hooks_evaluate
+func claudecode_hooks_evaluate(rulesJSON, command *C.char) *C.char { //nolint:revive // C naming for the exported symbol
+	return C.CString(evaluate.EvaluateJSON(C.GoString(rulesJSON), C.GoString(command)))
+}
+
+//export claudecode_hooks_free
+func claudecode_hooks_free(result *C.char) { //nolint:revive // C naming for the exported symbol
+	C.free(unsafe.Pointer(result))
+}
+
+func main() {}
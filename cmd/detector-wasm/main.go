@@ -0,0 +1,25 @@
+//go:build js && wasm
+
+// Package main builds the command detector as a WASM module for JavaScript
+// hosts. It defines a global function:
+//
+//	claudecodeHooksEvaluate(rulesJSON, command) -> resultJSON
+//
+// Build with "make build-wasm" and load it with Go's wasm_exec.js.
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/krmcbride/claudecode-hooks/internal/evaluate"
+)
+
+func main() {
+	js.Global().Set("claudecodeHooksEvaluate", js.FuncOf(func(_ js.Value, args []js.Value) any {
+		if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+			return `{"blocked":true,"error":"usage: claudecodeHooksEvaluate(rulesJSON, command)"}`
+		}
+		return evaluate.EvaluateJSON(args[0].String(), args[1].String())
+	}))
+	select {} // Keep the exported function available
+}
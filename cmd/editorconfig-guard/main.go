@@ -0,0 +1,8 @@
+// Package main provides an .editorconfig whitespace checker for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/editorconfigguard"
+
+func main() {
+	editorconfigguard.Main()
+}
@@ -0,0 +1,8 @@
+// Package main provides a pre-edit file snapshot hook for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/filebackup"
+
+func main() {
+	filebackup.Main()
+}
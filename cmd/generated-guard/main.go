@@ -0,0 +1,8 @@
+// Package main provides a generated file guard for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/generatedguard"
+
+func main() {
+	generatedguard.Main()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+)
+
+func runAudit(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "verify":
+			return runAuditVerify(args[1:], stdout, stderr)
+		case "report":
+			return runAuditReport(args[1:], stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, `USAGE:
+    hooks audit verify [-file path] [-expect-head hash]
+    hooks audit report [-file path] [-since duration] [-top n] [-json]
+
+SUBCOMMANDS:
+    verify    Check the hash chain of an audit log for edited, removed, or reordered records
+    report    Summarize an audit log: top blocked commands, blocks per rule, session, and day
+`)
+	if len(args) == 0 {
+		return 1
+	}
+	if args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		return 0
+	}
+	return 1
+}
+
+func runAuditVerify(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("file", os.Getenv("CLAUDE_HOOKS_AUDIT_LOG"), "Audit log to verify (default $CLAUDE_HOOKS_AUDIT_LOG)")
+	expectHead := fs.String("expect-head", "", "Head hash printed by an earlier verify; fail unless a record still has it")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if *file == "" {
+		fmt.Fprintln(stderr, "Error: no audit log given; use -file or set CLAUDE_HOOKS_AUDIT_LOG")
+		return 1
+	}
+
+	chain, err := audit.VerifyChainFile(*file, *expectHead)
+	if err != nil {
+		fmt.Fprintf(stderr, "FAIL: %v (%d records verified before the break)\n", err, chain.Records)
+		return 1
+	}
+	fmt.Fprintf(stdout, "OK: %d records verified in %s\n", chain.Records, *file)
+	if chain.Head != "" {
+		// The chain cannot show truncation on its own; the head, kept elsewhere, can
+		fmt.Fprintf(stdout, "Head: %s\n", chain.Head)
+	}
+	return 0
+}
+
+func runAuditReport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("audit report", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks audit report [-file path] [-since 168h] [-top 10] [-interval 24h] [-json]
+
+Summarizes an audit log: decisions per hook, the most often blocked commands,
+blocks per rule and per session, blocks over time, and what shadow rules
+would have blocked. Use it to find noisy rules and to show what the hooks
+prevented.
+
+FLAGS:
+`)
+		fs.PrintDefaults()
+	}
+	file := fs.String("file", os.Getenv("CLAUDE_HOOKS_AUDIT_LOG"), "Audit log to summarize (default $CLAUDE_HOOKS_AUDIT_LOG)")
+	since := fs.Duration("since", 0, "Only include records from this long ago onwards (default: all)")
+	top := fs.Int("top", 10, "Number of blocked commands and sessions to list (0 for all)")
+	interval := fs.Duration("interval", 24*time.Hour, "Width of the time series buckets")
+	jsonOutput := fs.Bool("json", false, "Write the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if *file == "" {
+		fmt.Fprintln(stderr, "Error: no audit log given; use -file or set CLAUDE_HOOKS_AUDIT_LOG")
+		return 1
+	}
+	if *interval <= 0 || *top < 0 || *since < 0 {
+		fmt.Fprintln(stderr, "Error: -interval must be positive and -since and -top not negative")
+		return 1
+	}
+
+	records, err := audit.ReadFile(*file)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	opts := audit.ReportOptions{Top: *top, Interval: *interval}
+	if *since > 0 {
+		opts.Since = time.Now().Add(-*since)
+	}
+	report := audit.Summarize(records, opts)
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	writeAuditReport(stdout, *file, report, *interval)
+	return 0
+}
+
+// writeAuditReport prints report as text, one section per summary.
+func writeAuditReport(w io.Writer, file string, report audit.Report, interval time.Duration) {
+	fmt.Fprintf(w, "%d records, %d blocked, in %s\n", report.Records, report.Blocks, file)
+	if report.Records == 0 {
+		return
+	}
+	fmt.Fprintf(w, "From %s to %s\n", report.First.Local().Format(time.DateTime), report.Last.Local().Format(time.DateTime))
+
+	writeCounts(w, "Decisions", report.Decisions)
+	writeCounts(w, "Hooks", report.Hooks)
+	writeCounts(w, "Top blocked commands", report.TopBlocked)
+	writeCounts(w, "Blocks per rule", report.BlocksPerRule)
+
+	if len(report.Sessions) > 0 {
+		fmt.Fprintln(w, "\nSessions (blocks/records):")
+		for _, session := range report.Sessions {
+			fmt.Fprintf(w, "  %6d/%-6d %s  %s\n", session.Blocks, session.Records, session.SessionID, session.Last.Local().Format(time.DateTime))
+		}
+	}
+
+	layout := time.DateOnly
+	if interval%(24*time.Hour) != 0 {
+		layout = time.DateTime
+	}
+	fmt.Fprintln(w, "\nOver time (blocks/records):")
+	for _, bucket := range report.TimeSeries {
+		fmt.Fprintf(w, "  %6d/%-6d %s\n", bucket.Blocks, bucket.Records, bucket.Start.UTC().Format(layout))
+	}
+
+	writeCounts(w, "Shadow rules (would have blocked)", report.ShadowRules)
+}
+
+// writeCounts prints a titled list of counts, or nothing when it is empty.
+func writeCounts(w io.Writer, title string, counts []audit.Count) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, c := range counts {
+		fmt.Fprintf(w, "  %6d  %s\n", c.Count, c.Name)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/evaluate"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+const defaultBenchRules = `{"presets": ["git-push"]}`
+
+// benchTarget evaluates one payload and returns the outcome it signals.
+type benchTarget func(ctx context.Context, payload []byte) (hook.Outcome, error)
+
+// benchReport summarizes a benchmark run.
+type benchReport struct {
+	Target      string               `json:"target"`
+	Concurrency int                  `json:"concurrency"`
+	Requests    int                  `json:"requests"`
+	Errors      int                  `json:"errors"`
+	Elapsed     time.Duration        `json:"elapsed_ns"`
+	PerSecond   float64              `json:"per_second"`
+	P50         time.Duration        `json:"p50_ns"`
+	P95         time.Duration        `json:"p95_ns"`
+	P99         time.Duration        `json:"p99_ns"`
+	Max         time.Duration        `json:"max_ns"`
+	AllocsPerOp uint64               `json:"allocs_per_op"` // In this process, so client side for a daemon
+	BytesPerOp  uint64               `json:"bytes_per_op"`
+	Outcomes    map[hook.Outcome]int `json:"outcomes"`
+	errorSample error                // First error, for the text report
+}
+
+func runBench(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks bench -corpus payloads.jsonl [-concurrency 64] [-repeat 1] [-daemon url | -rules file] [-json]
+
+Replays captured hook payloads, one JSON object per line, and reports
+latency percentiles, throughput, and allocations per payload:
+
+    hooks bench -corpus payloads.jsonl -daemon http://localhost:8799
+        POSTs each payload to a running "hooks serve"
+    hooks bench -corpus payloads.jsonl -rules rules.json
+        Evaluates each payload's command in process, with the rules
+        document the WASM and C hosts take (default: %s)
+
+In-process results are cached by command, as in those hosts, so a corpus
+replayed more than once measures the cache as well as the detector.
+
+FLAGS:
+`, defaultBenchRules)
+		fs.PrintDefaults()
+	}
+	corpusFile := fs.String("corpus", "", "Payloads to replay, one JSON object per line")
+	concurrency := fs.Int("concurrency", runtime.GOMAXPROCS(0), "Payloads evaluated at once")
+	repeat := fs.Int("repeat", 1, "Times to replay the corpus")
+	daemonURL := fs.String("daemon", "", "URL of a hooks serve daemon to benchmark, e.g. http://localhost:8799")
+	rulesFile := fs.String("rules", "", "Rules document (JSON) for the in-process engine")
+	timeout := fs.Duration("timeout", defaultServeTimeout, "Per-payload timeout")
+	jsonOutput := fs.Bool("json", false, "Print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if *corpusFile == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 1
+	}
+	if *concurrency <= 0 || *repeat <= 0 || *timeout <= 0 {
+		fmt.Fprintf(stderr, "Error: -concurrency, -repeat, and -timeout must be positive\n")
+		return 1
+	}
+	if *daemonURL != "" && *rulesFile != "" {
+		fmt.Fprintf(stderr, "Error: -rules applies to the in-process engine; the daemon uses its own flags\n")
+		return 1
+	}
+	payloads, err := readBenchCorpus(*corpusFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var target benchTarget
+	name := "in-process engine"
+	if *daemonURL != "" {
+		name = *daemonURL
+		// Keep a connection per worker, so the run measures evaluations
+		// rather than connection setup
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = *concurrency
+		target = daemonTarget(&http.Client{Transport: transport}, strings.TrimSuffix(*daemonURL, "/")+"/evaluate")
+	} else {
+		rules := defaultBenchRules
+		if *rulesFile != "" {
+			data, err := os.ReadFile(*rulesFile) // #nosec G304 - path from command line flag
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				return 1
+			}
+			rules = string(data)
+		}
+		if result := evaluate.Evaluate(rules, ""); result.Error != "" {
+			fmt.Fprintf(stderr, "Error: %s\n", result.Error)
+			return 1
+		}
+		target = engineTarget(rules)
+	}
+
+	report := bench(context.Background(), target, payloads, *concurrency, *repeat, *timeout)
+	report.Target = name
+	if *jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	} else {
+		printBenchReport(stdout, report)
+	}
+	if report.Errors > 0 {
+		return 1
+	}
+	return 0
+}
+
+// readBenchCorpus reads the payloads in a JSON Lines file, skipping blank
+// lines. Every payload must be valid JSON.
+func readBenchCorpus(path string) ([][]byte, error) {
+	f, err := os.Open(path) // #nosec G304 - path from command line flag
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck // Read only
+
+	var payloads [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxPayloadSize)
+	for line := 1; scanner.Scan(); line++ {
+		payload := bytes.TrimSpace(scanner.Bytes())
+		if len(payload) == 0 {
+			continue
+		}
+		if !json.Valid(payload) {
+			return nil, fmt.Errorf("%s:%d: payload is not valid JSON", path, line)
+		}
+		payloads = append(payloads, bytes.Clone(payload))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if len(payloads) == 0 {
+		return nil, fmt.Errorf("%s has no payloads", path)
+	}
+	return payloads, nil
+}
+
+// engineTarget evaluates the command of Bash payloads with the in-process
+// engine. Other payloads have nothing to evaluate and are allowed.
+func engineTarget(rules string) benchTarget {
+	return func(_ context.Context, payload []byte) (hook.Outcome, error) {
+		var input struct {
+			ToolInput struct {
+				Command string `json:"command"`
+			} `json:"tool_input"`
+		}
+		if err := json.Unmarshal(payload, &input); err != nil {
+			return "", err
+		}
+		if input.ToolInput.Command == "" {
+			return hook.OutcomeAllow, nil
+		}
+		if evaluate.Evaluate(rules, input.ToolInput.Command).Blocked {
+			return hook.OutcomeBlock, nil
+		}
+		return hook.OutcomeAllow, nil
+	}
+}
+
+// daemonTarget posts payloads to a hooks serve /evaluate endpoint.
+func daemonTarget(client *http.Client, url string) benchTarget {
+	return func(ctx context.Context, payload []byte) (hook.Outcome, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = resp.Body.Close() }() //nolint:errcheck // Response already read
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+		var e evaluation
+		if err := json.Unmarshal(body, &e); err != nil {
+			return "", fmt.Errorf("decoding response: %w", err)
+		}
+		return e.Outcome, nil
+	}
+}
+
+// bench replays the payloads repeat times through target, concurrency at a
+// time, and measures each evaluation.
+func bench(ctx context.Context, target benchTarget, payloads [][]byte, concurrency, repeat int, timeout time.Duration) benchReport {
+	total := len(payloads) * repeat
+	latencies := make([]time.Duration, total)
+	outcomes := make([]hook.Outcome, total)
+	errs := make([]error, total)
+	next := make(chan int)
+	go func() {
+		defer close(next)
+		for i := range total {
+			next <- i
+		}
+	}()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range min(concurrency, total) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				evalCtx, cancel := context.WithTimeout(ctx, timeout)
+				began := time.Now()
+				outcomes[i], errs[i] = target(evalCtx, payloads[i%len(payloads)])
+				latencies[i] = time.Since(began)
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	report := benchReport{
+		Concurrency: concurrency,
+		Requests:    total,
+		Elapsed:     elapsed,
+		PerSecond:   float64(total) / elapsed.Seconds(),
+		AllocsPerOp: (after.Mallocs - before.Mallocs) / uint64(total),
+		BytesPerOp:  (after.TotalAlloc - before.TotalAlloc) / uint64(total),
+		Outcomes:    map[hook.Outcome]int{},
+	}
+	for i, err := range errs {
+		if err != nil {
+			report.Errors++
+			if report.errorSample == nil {
+				report.errorSample = err
+			}
+			continue
+		}
+		report.Outcomes[outcomes[i]]++
+	}
+	slices.Sort(latencies)
+	report.P50 = percentile(latencies, 50)
+	report.P95 = percentile(latencies, 95)
+	report.P99 = percentile(latencies, 99)
+	report.Max = latencies[total-1]
+	return report
+}
+
+// percentile returns the p-th percentile of sorted, by the nearest rank.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
+
+func printBenchReport(w io.Writer, r benchReport) {
+	fmt.Fprintf(w, "Replayed %d payloads against %s, %d at a time, in %s (%.0f/s)\n",
+		r.Requests, r.Target, r.Concurrency, r.Elapsed.Round(time.Millisecond), r.PerSecond)
+	fmt.Fprintf(w, "Latency:     p50 %s  p95 %s  p99 %s  max %s\n", r.P50, r.P95, r.P99, r.Max)
+	fmt.Fprintf(w, "Allocations: %d allocs/op  %d B/op\n", r.AllocsPerOp, r.BytesPerOp)
+	names := make([]string, 0, len(r.Outcomes))
+	for outcome, n := range r.Outcomes {
+		names = append(names, fmt.Sprintf("%s %d", outcome, n))
+	}
+	slices.Sort(names)
+	fmt.Fprintf(w, "Outcomes:    %s\n", strings.Join(names, ", "))
+	if r.Errors > 0 {
+		fmt.Fprintf(w, "Errors:      %d (first: %v)\n", r.Errors, r.errorSample)
+	}
+}
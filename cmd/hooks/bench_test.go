@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i + 1)
+	}
+	for _, tt := range []struct{ p, want int }{{50, 50}, {95, 95}, {99, 99}, {100, 100}, {0, 1}} {
+		if got := percentile(sorted, tt.p); got != time.Duration(tt.want) {
+			t.Errorf("percentile(1..100, %d) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile([]time.Duration{7}, 95); got != 7 {
+		t.Errorf("percentile([7], 95) = %d, want 7", got)
+	}
+}
+
+func TestBench_Engine(t *testing.T) {
+	payloads := [][]byte{
+		[]byte(`{"tool_name":"Bash","tool_input":{"command":"git push"}}`),
+		[]byte(`{"tool_name":"Bash","tool_input":{"command":"git status"}}`),
+		[]byte(`{"tool_name":"Read","tool_input":{"file_path":"main.go"}}`),
+	}
+	report := bench(context.Background(), engineTarget(defaultBenchRules), payloads, 4, 3, time.Second)
+	if report.Requests != 9 || report.Errors != 0 {
+		t.Errorf("bench() = %d requests, %d errors, want 9, 0", report.Requests, report.Errors)
+	}
+	if report.Outcomes[hook.OutcomeBlock] != 3 || report.Outcomes[hook.OutcomeAllow] != 6 {
+		t.Errorf("bench() outcomes = %v, want 3 block, 6 allow", report.Outcomes)
+	}
+	if report.P50 > report.P95 || report.P95 > report.Max {
+		t.Errorf("bench() latencies out of order: p50 %s, p95 %s, max %s", report.P50, report.P95, report.Max)
+	}
+}
+
+func TestBench_Daemon(t *testing.T) {
+	s := &server{hook: "bash-block", timeout: time.Second, run: func(_ context.Context, _ string, _ []string, payload []byte) (hookRun, error) {
+		if bytes.Contains(payload, []byte("git push")) {
+			return hookRun{ExitCode: 2, Stderr: []byte("blocked")}, nil
+		}
+		return hookRun{}, nil
+	}}
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	payloads := [][]byte{
+		[]byte(`{"tool_input":{"command":"git push"}}`),
+		[]byte(`{"tool_input":{"command":"ls"}}`),
+		[]byte(`{"tool_input":`),
+	}
+	report := bench(context.Background(), daemonTarget(ts.Client(), ts.URL+"/evaluate"), payloads, 2, 2, time.Second)
+	if report.Outcomes[hook.OutcomeBlock] != 2 || report.Outcomes[hook.OutcomeAllow] != 2 {
+		t.Errorf("bench() outcomes = %v, want 2 block, 2 allow", report.Outcomes)
+	}
+	if report.Errors != 2 || !strings.Contains(report.errorSample.Error(), "400") {
+		t.Errorf("bench() = %d errors (%v), want 2 for the invalid payload", report.Errors, report.errorSample)
+	}
+}
+
+func TestRunBench(t *testing.T) {
+	dir := t.TempDir()
+	corpus := filepath.Join(dir, "payloads.jsonl")
+	data := `{"tool_name":"Bash","tool_input":{"command":"git push origin main"}}` + "\n\n" +
+		`{"tool_name":"Bash","tool_input":{"command":"make test"}}` + "\n"
+	if err := os.WriteFile(corpus, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bench", "-corpus", corpus, "-concurrency", "2"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("hooks bench = %d, stderr: %s", code, stderr.String())
+	}
+	for _, want := range []string{"Replayed 2 payloads against in-process engine", "p95", "allocs/op", "allow 1, block 1"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("hooks bench output missing %q:\n%s", want, stdout.String())
+		}
+	}
+
+	stderr.Reset()
+	if code := run([]string{"bench", "-corpus", corpus, "-rules", corpus}, &stdout, &stderr); code != 1 {
+		t.Errorf("hooks bench with invalid rules = %d, want 1", code)
+	}
+	if err := os.WriteFile(corpus, []byte("not json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if code := run([]string{"bench", "-corpus", corpus}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), ":1: payload is not valid JSON") {
+		t.Errorf("hooks bench with invalid corpus = %d (%s), want 1", code, stderr.String())
+	}
+}
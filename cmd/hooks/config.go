@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+)
+
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "validate":
+			return runConfigValidate(args[1:], stdout, stderr)
+		case "resolve":
+			return runConfigResolve(args[1:], stdout, stderr)
+		case "schema":
+			_, _ = stdout.Write(config.PolicySchema) //nolint:errcheck // Nothing to report to
+			return 0
+		}
+	}
+	fmt.Fprintf(stderr, `USAGE:
+    hooks config validate [file ...]
+    hooks config resolve [flags] [file]
+    hooks config schema
+
+SUBCOMMANDS:
+    validate  Check policy files against the schema and the rule checks hooks apply when loading them
+    resolve   Print the effective policy with extends, include, and policy layers merged
+    schema    Print the JSON Schema of policy files, e.g. for editor completion
+`)
+	if len(args) == 0 {
+		return 1
+	}
+	if args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		return 0
+	}
+	return 1
+}
+
+func runConfigValidate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks config validate [file ...]
+
+Checks each policy file (default: %s in the current directory) and
+reports every problem with its line and column, such as unknown keys (with a
+suggestion for likely typos), values of the wrong type, and invalid rules.
+Exits 1 if any file has problems.
+`, config.ProjectFileName)
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{config.ProjectFileName}
+	}
+	code := 0
+	for _, file := range files {
+		if !validatePolicyFile(file, stdout, stderr) {
+			code = 1
+		}
+	}
+	return code
+}
+
+// validatePolicyFile reports the problems in one policy file and whether it
+// has none.
+func validatePolicyFile(file string, stdout, stderr io.Writer) bool {
+	data, err := os.ReadFile(file) // #nosec G304 - path from command line
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return false
+	}
+	schemaErrs, err := config.ValidateSchema(data)
+	if err != nil {
+		fmt.Fprintf(stdout, "%s: %v\n", file, err)
+		return false
+	}
+	for _, e := range schemaErrs {
+		location := e.Message
+		if e.Path != "" {
+			location = e.Path + ": " + e.Message
+		}
+		fmt.Fprintf(stdout, "%s:%d:%d: %s\n", file, e.Line, e.Column, location)
+	}
+	if len(schemaErrs) > 0 {
+		return false
+	}
+	if _, err := config.LoadPolicy(file); err != nil {
+		fmt.Fprintln(stdout, err)
+		return false
+	}
+	fmt.Fprintf(stdout, "OK: %s\n", file)
+	return true
+}
+
+func runConfigResolve(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("config resolve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks config resolve [flags] [file]
+
+Prints the effective policy as YAML. With a file, that file is resolved:
+the files and presets it extends or includes are merged into it. Without
+one, every policy layer a hook run in the current directory would load is
+resolved and merged: the system and user policies, -rules, and %s.
+
+FLAGS:
+`, config.ProjectFileName)
+		fs.PrintDefaults()
+	}
+	settings := config.RegisterFlags(fs, config.FailClosed)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	var policy *config.Policy
+	switch fs.NArg() {
+	case 0:
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		layers, err := config.LoadLayers(context.Background(), settings, cwd)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		policy = config.Merge(layers)
+	case 1:
+		var err error
+		if policy, err = config.LoadPolicy(fs.Arg(0)); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	default:
+		fs.Usage()
+		return 1
+	}
+
+	encoder := yaml.NewEncoder(stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(policy); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+func runDescribe(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("describe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks describe [hook ...]
+
+Prints the -describe manifest of each bundled hook (all of them by default)
+as a JSON array: the events and tools it handles, its flags and their
+environment variables, the policy sections it reads, and the version.
+`)
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		names = hookNames()
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: locating hooks binary: %v\n", err)
+		return 1
+	}
+	manifests, err := describeHooks(names, func(name string) ([]byte, error) {
+		return exec.Command(executable, name, "-describe").Output() // #nosec G204 - runs this binary
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifests); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// describeHooks collects the manifest of each named hook. describe runs a
+// hook with -describe in a child process, since every hook parses the
+// process-wide flags and exits when done.
+func describeHooks(names []string, describe func(name string) ([]byte, error)) ([]json.RawMessage, error) {
+	manifests := make([]json.RawMessage, 0, len(names))
+	for _, name := range names {
+		if _, ok := hookMains[name]; !ok {
+			return nil, fmt.Errorf("unknown hook %q", name)
+		}
+		out, err := describe(name)
+		if err != nil {
+			return nil, fmt.Errorf("describing %s: %w", name, err)
+		}
+		if !json.Valid(out) {
+			return nil, fmt.Errorf("describing %s: invalid manifest", name)
+		}
+		manifests = append(manifests, out)
+	}
+	return manifests, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/grant"
+)
+
+// grantTerminal returns the terminal hooks grant reads its confirmation from:
+// stdin, when it is an interactive terminal rather than a pipe, as it is in
+// Claude Code's Bash tool.
+var grantTerminal = func() (io.Reader, bool) {
+	info, err := os.Stdin.Stat()
+	return os.Stdin, err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func runGrant(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("grant", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks grant [-ttl duration] [-dir path] [-key path] [-audit-log path] "COMMAND"
+
+Allows COMMAND to run once through bash-block -allow-once, within the TTL.
+The command must match exactly, apart from whitespace. Grants are signed
+with the key file, which is created on first use. hooks grant must be run
+in an interactive terminal, where it asks you to confirm by typing "yes".
+
+FLAGS:
+`)
+		fs.PrintDefaults()
+	}
+	ttl := fs.Duration("ttl", grant.DefaultTTL, "How long the grant stays usable")
+	dir := fs.String("dir", grant.DefaultDir(), "Grant directory (must match bash-block -grant-dir)")
+	key := fs.String("key", grant.DefaultKeyPath(), "Grant key file (must match bash-block -grant-key)")
+	auditLog := fs.String("audit-log", os.Getenv("CLAUDE_HOOKS_AUDIT_LOG"), "Audit log to record the grant in (default $CLAUDE_HOOKS_AUDIT_LOG)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+	command := strings.Join(fs.Args(), " ")
+
+	// Only a human at a terminal may grant: Claude runs commands without one
+	terminal, ok := grantTerminal()
+	if !ok {
+		fmt.Fprintln(stderr, "Error: hooks grant must be run in an interactive terminal")
+		return 1
+	}
+	fmt.Fprintf(stderr, "Allow this command to run once within %s?\n\n    %s\n\nType yes to confirm: ", *ttl, command)
+	answer, err := bufio.NewReader(terminal).ReadString('\n')
+	if strings.TrimSpace(answer) != "yes" {
+		if err != nil && !errors.Is(err, io.EOF) {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintln(stderr, "Not granted")
+		return 1
+	}
+
+	token, expires, err := grant.NewStore(*dir, *key).Grant(command, *ttl)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	record := audit.Record{
+		Hook:     "hooks",
+		Decision: audit.DecisionGrant,
+		Reason:   "allow once until " + expires.Format(time.RFC3339) + " (grant " + token[:12] + ")",
+		Command:  command,
+	}
+	if err := audit.NewLogger(*auditLog).Log(record); err != nil {
+		fmt.Fprintf(stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+
+	fmt.Fprintf(stdout, "Granted once until %s: %s (grant %s)\n", expires.Local().Format("15:04:05"), command, token[:12])
+	return 0
+}
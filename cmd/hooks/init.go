@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+func runInit(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks init [-dir dir] [-prefix prefix] [-settings path] [-merge] [hook ...]
+    hooks init [flags] hook -- [hook flags]
+
+Prints, or merges into settings.json, the entries that register each hook
+(default: %s) under its events, with the matchers the hook itself reports
+through -emit-config for the given hook flags, e.g.
+
+    hooks init -merge file-format -- -cmd "gofmt -w" -ext .go
+
+Entries run the shims "hooks install" creates.
+
+FLAGS:
+`, strings.Join(defaultInitHooks(), ", "))
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", filepath.Join(claudeConfigDir(), "hooks"), "Hooks directory the shims were installed to")
+	prefix := fs.String("prefix", defaultPrefix, "Prefix of the per-hook shim names")
+	settingsPath := fs.String("settings", filepath.Join(claudeConfigDir(), "settings.json"), "settings.json to merge hook entries into")
+	merge := fs.Bool("merge", false, "Merge the hook entries into -settings instead of printing them")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	names, hookFlags := cutArgs(fs.Args(), "--")
+	if len(names) == 0 {
+		names = defaultInitHooks()
+	}
+	if len(hookFlags) > 0 && len(names) != 1 {
+		fmt.Fprintf(stderr, "Error: hook flags after -- need exactly one hook\n")
+		return 1
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: locating hooks binary: %v\n", err)
+		return 1
+	}
+
+	inst := &installer{dir: *dir, prefix: *prefix}
+	snippet, err := initSnippet(names, hookFlags, inst.shimCommand, func(name string, flags []string) ([]byte, error) {
+		return exec.Command(executable, append([]string{name, "-emit-config"}, flags...)...).Output() // #nosec G204 - runs this binary
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if !*merge {
+		data, err := json.MarshalIndent(snippet, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Add to %s (or rerun with -merge):\n%s\n", *settingsPath, data)
+		return 0
+	}
+	if err := mergeSettingsFile(*settingsPath, snippet); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Merged hook entries into %s\n", *settingsPath)
+	return 0
+}
+
+// defaultInitHooks are the hooks install suggests, in order.
+func defaultInitHooks() []string {
+	var names []string
+	for _, h := range suggestedHooks {
+		if !slices.Contains(names, h.hook) {
+			names = append(names, h.hook)
+		}
+	}
+	return names
+}
+
+// cutArgs splits args around the first sep.
+func cutArgs(args []string, sep string) (before, after []string) {
+	if i := slices.Index(args, sep); i >= 0 {
+		return args[:i], args[i+1:]
+	}
+	return args, nil
+}
+
+// initSnippet collects the settings.json entries each named hook emits for
+// flags and points their commands at the hook's shim. emit runs a hook with
+// -emit-config in a child process, as describeHooks does with -describe.
+func initSnippet(names, flags []string, shimCommand func(name string) string, emit func(name string, flags []string) ([]byte, error)) (map[string]any, error) {
+	snippet := map[string]any{}
+	for _, name := range names {
+		if _, ok := hookMains[name]; !ok {
+			return nil, fmt.Errorf("unknown hook %q (available: %s)", name, strings.Join(hookNames(), ", "))
+		}
+		out, err := emit(name, flags)
+		if err != nil {
+			return nil, fmt.Errorf("emitting config of %s: %w", name, err)
+		}
+		var entries map[string]any
+		if err := json.Unmarshal(out, &entries); err != nil {
+			return nil, fmt.Errorf("emitting config of %s: %w", name, err)
+		}
+		events, _ := entries["hooks"].(map[string]any)
+		for _, value := range events {
+			list, _ := value.([]any)
+			for _, entry := range list {
+				m, _ := entry.(map[string]any)
+				hooks, _ := m["hooks"].([]any)
+				for _, h := range hooks {
+					command, _ := h.(map[string]any)
+					line, _ := command["command"].(string)
+					if rest, ok := strings.CutPrefix(line, name); ok {
+						command["command"] = shimCommand(name) + rest
+					}
+				}
+			}
+		}
+		mergeSettings(snippet, entries)
+	}
+	return snippet, nil
+}
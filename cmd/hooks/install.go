@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultPrefix = "krmcbride-"
+
+// settingsHook is a hook entry suggested for settings.json.
+type settingsHook struct {
+	event   string
+	matcher string
+	hook    string
+}
+
+// suggestedHooks are the hooks added to settings.json by install. Their rules
+// come from the policy files, so no flags are needed.
+var suggestedHooks = []settingsHook{
+	{event: "PreToolUse", matcher: "Bash", hook: "bash-block"},
+	{event: "PostToolUse", matcher: "Edit|MultiEdit|Write", hook: "file-format"},
+}
+
+// installer copies the hooks binary into place and links a shim per hook.
+type installer struct {
+	executable string // Binary to install
+	dir        string // Hooks directory, e.g. ~/.claude/hooks
+	prefix     string // Shim name prefix
+	stdout     io.Writer
+}
+
+// claudeConfigDir mirrors the Makefile's USER_HOOK_DIR: $CLAUDE_CONFIG_DIR or ~/.claude.
+func claudeConfigDir() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".claude"
+	}
+	return filepath.Join(home, ".claude")
+}
+
+func runInstall(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", filepath.Join(claudeConfigDir(), "hooks"), "Hooks directory; the binary goes into <dir>/bin")
+	prefix := fs.String("prefix", defaultPrefix, "Prefix of the per-hook shim names")
+	settingsPath := fs.String("settings", filepath.Join(claudeConfigDir(), "settings.json"), "settings.json to merge hook entries into")
+	merge := fs.Bool("merge", false, "Merge the hook entries into -settings instead of printing them")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: locating the running binary: %v\n", err)
+		return 1
+	}
+
+	inst := &installer{executable: executable, dir: *dir, prefix: *prefix, stdout: stdout}
+	if err := inst.install(); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	snippet := inst.settingsSnippet()
+	if !*merge {
+		data, err := json.MarshalIndent(snippet, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "\nAdd to %s (or rerun with -merge):\n%s\n", *settingsPath, data)
+		return 0
+	}
+	if err := mergeSettingsFile(*settingsPath, snippet); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Merged hook entries into %s\n", *settingsPath)
+	return 0
+}
+
+// binaryPath is where the hooks binary is installed.
+func (i *installer) binaryPath() string {
+	return filepath.Join(i.dir, "bin", i.prefix+"hooks")
+}
+
+// install copies the binary to <dir>/bin and points a shim per hook at it.
+// Shims replace the per-hook binaries installed by "make install-user".
+func (i *installer) install() error {
+	target := i.binaryPath()
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(target), err)
+	}
+	if i.executable != target {
+		data, err := os.ReadFile(i.executable)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", i.executable, err)
+		}
+		if err := replaceExecutable(target, data); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(i.stdout, "Installed %s\n", target)
+
+	// Relative links keep working if the Claude config directory moves
+	relTarget := filepath.Join("bin", filepath.Base(target))
+	for _, name := range hookNames() {
+		shim := filepath.Join(i.dir, i.prefix+name)
+		if err := os.Remove(shim); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("replacing %s: %w", shim, err)
+		}
+		if err := os.Symlink(relTarget, shim); err != nil {
+			return fmt.Errorf("creating shim %s: %w", shim, err)
+		}
+		fmt.Fprintf(i.stdout, "Linked %s -> %s\n", shim, relTarget)
+	}
+	return nil
+}
+
+// shimCommand is the settings.json command for a hook. Paths under the home
+// directory use $HOME so the same settings work across machines.
+func (i *installer) shimCommand(name string) string {
+	path := filepath.Join(i.dir, i.prefix+name)
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return "$HOME/" + filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+// settingsSnippet returns the settings.json hooks section for suggestedHooks.
+func (i *installer) settingsSnippet() map[string]any {
+	events := map[string]any{}
+	for _, h := range suggestedHooks {
+		entries, _ := events[h.event].([]any)
+		events[h.event] = append(entries, map[string]any{
+			"matcher": h.matcher,
+			"hooks": []any{
+				map[string]any{"type": "command", "command": i.shimCommand(h.hook)},
+			},
+		})
+	}
+	return map[string]any{"hooks": events}
+}
+
+// mergeSettingsFile merges snippet into the settings file at path, keeping a
+// .bak copy of the original.
+func mergeSettingsFile(path string, snippet map[string]any) error {
+	settings := map[string]any{}
+	original, err := os.ReadFile(path) // #nosec G304 - path is user-provided
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(original, &settings); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if err := os.WriteFile(path+".bak", original, 0o600); err != nil {
+			return fmt.Errorf("backing up %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	mergeSettings(settings, snippet)
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// mergeSettings adds the snippet's hook entries to settings. Entries whose
+// command is already configured for the event are skipped, so install can be
+// rerun safely.
+func mergeSettings(settings, snippet map[string]any) {
+	hooks, _ := settings["hooks"].(map[string]any)
+	if hooks == nil {
+		hooks = map[string]any{}
+		settings["hooks"] = hooks
+	}
+	snippetHooks, _ := snippet["hooks"].(map[string]any)
+
+	for event, value := range snippetHooks {
+		existing, _ := hooks[event].([]any)
+		entries, _ := value.([]any)
+		for _, entry := range entries {
+			if !hasCommand(existing, entryCommand(entry)) {
+				existing = append(existing, entry)
+			}
+		}
+		hooks[event] = existing
+	}
+}
+
+// entryCommand returns the command of a snippet entry.
+func entryCommand(entry any) string {
+	m, _ := entry.(map[string]any)
+	hooks, _ := m["hooks"].([]any)
+	if len(hooks) == 0 {
+		return ""
+	}
+	hook, _ := hooks[0].(map[string]any)
+	command, _ := hook["command"].(string)
+	return command
+}
+
+// hasCommand reports whether any entry already runs the same hook executable,
+// regardless of its directory or flags.
+func hasCommand(entries []any, command string) bool {
+	want := executableName(command)
+	for _, entry := range entries {
+		m, _ := entry.(map[string]any)
+		hooks, _ := m["hooks"].([]any)
+		for _, h := range hooks {
+			hook, _ := h.(map[string]any)
+			if existing, _ := hook["command"].(string); want != "" && executableName(existing) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// executableName returns the base name of the executable a hook command runs.
+func executableName(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return filepath.Base(fields[0])
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHookName(t *testing.T) {
+	tests := []struct {
+		arg0   string
+		want   string
+		wantOK bool
+	}{
+		{"/home/u/.claude/hooks/krmcbride-bash-block", "bash-block", true},
+		{"bash-block", "bash-block", true},
+		{`C:\hooks\krmcbride-file-format.exe`, "file-format", true},
+		{"/usr/local/bin/krmcbride-hooks", "", false},
+		{"hooks", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg0, func(t *testing.T) {
+			got, ok := hookName(tt.arg0)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("hookName(%q) = %q, %v, want %q, %v", tt.arg0, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestInstaller_Install(t *testing.T) {
+	executable := filepath.Join(t.TempDir(), "hooks")
+	if err := os.WriteFile(executable, []byte("binary"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "hooks")
+
+	inst := &installer{executable: executable, dir: dir, prefix: defaultPrefix, stdout: &bytes.Buffer{}}
+	// Installing twice replaces existing shims
+	for range 2 {
+		if err := inst.install(); err != nil {
+			t.Fatalf("install() error: %v", err)
+		}
+	}
+
+	for _, name := range hookNames() {
+		shim := filepath.Join(dir, defaultPrefix+name)
+		data, err := os.ReadFile(shim) // #nosec G304 - test file
+		if err != nil {
+			t.Fatalf("reading shim %s: %v", shim, err)
+		}
+		if string(data) != "binary" {
+			t.Errorf("shim %s does not resolve to the installed binary", shim)
+		}
+	}
+}
+
+func TestMergeSettings(t *testing.T) {
+	inst := &installer{dir: "/opt/claude/hooks", prefix: defaultPrefix}
+	var settings map[string]any
+	existing := `{
+  "model": "opus",
+  "hooks": {
+    "PreToolUse": [
+      {"matcher": "Bash", "hooks": [{"type": "command", "command": "/old/path/krmcbride-bash-block -cmd='git push'"}]}
+    ],
+    "Stop": [
+      {"matcher": "", "hooks": [{"type": "command", "command": "notify-send done"}]}
+    ]
+  }
+}`
+	if err := json.Unmarshal([]byte(existing), &settings); err != nil {
+		t.Fatal(err)
+	}
+
+	mergeSettings(settings, inst.settingsSnippet())
+	mergeSettings(settings, inst.settingsSnippet()) // Idempotent
+
+	if settings["model"] != "opus" {
+		t.Error("mergeSettings() dropped unrelated settings")
+	}
+	hooks := settings["hooks"].(map[string]any)
+	if got := len(hooks["PreToolUse"].([]any)); got != 1 {
+		t.Errorf("PreToolUse has %d entries, want 1 (bash-block already configured)", got)
+	}
+	if got := len(hooks["PostToolUse"].([]any)); got != 1 {
+		t.Errorf("PostToolUse has %d entries, want 1", got)
+	}
+	if got := len(hooks["Stop"].([]any)); got != 1 {
+		t.Errorf("Stop has %d entries, want 1", got)
+	}
+	if got := entryCommand(hooks["PostToolUse"].([]any)[0]); got != "/opt/claude/hooks/krmcbride-file-format" {
+		t.Errorf("file-format command = %q", got)
+	}
+}
+
+func TestMergeSettingsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(path, []byte(`{"model": "opus"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	inst := &installer{dir: "/opt/claude/hooks", prefix: defaultPrefix}
+	if err := mergeSettingsFile(path, inst.settingsSnippet()); err != nil {
+		t.Fatalf("mergeSettingsFile() error: %v", err)
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Errorf("mergeSettingsFile() should keep a backup: %v", err)
+	}
+	data, err := os.ReadFile(path) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatalf("merged settings are not valid JSON: %v", err)
+	}
+	if _, ok := settings["hooks"]; !ok || settings["model"] != "opus" {
+		t.Errorf("merged settings = %v", settings)
+	}
+}
@@ -0,0 +1,168 @@
+// Package main provides the hooks command, a companion CLI for inspecting and
+// managing the claudecode-hooks installation. The hooks binary also bundles
+// every hook, so a single binary can be installed and invoked under each
+// hook's name through shims (see "hooks install").
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/bashblock"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/blobguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/branchguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/commandrewrite"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/editorconfigguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/filebackup"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/fileformat"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/generatedguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/hooklogger"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/injectionguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/ownerguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/pkginstallguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/ratelimiter"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/readonlyguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/remoteguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/sandboxguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/secretguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/selfprotect"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/sessionsummary"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/subagentguard"
+	"github.com/krmcbride/claudecode-hooks/internal/hooks/usageguard"
+)
+
+// command is a hooks subcommand. It receives the arguments after its name and
+// returns the process exit code.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+func commands() []command {
+	return []command{
+		{name: "audit", summary: "Inspect the decision audit log", run: runAudit},
+		{name: "bench", summary: "Measure evaluation latency over captured payloads", run: runBench},
+		{name: "config", summary: "Validate policy files", run: runConfig},
+		{name: "describe", summary: "Print the JSON manifest of each bundled hook", run: runDescribe},
+		{name: "grant", summary: "Allow a blocked command to run once", run: runGrant},
+		{name: "init", summary: "Print or merge settings.json entries generated by each hook", run: runInit},
+		{name: "install", summary: "Install this binary and shims for every hook", run: runInstall},
+		{name: "normalize", summary: "Print the canonical form rules match a command in", run: runNormalize},
+		{name: "pre-commit", summary: "Apply the rules in git pre-commit and pre-push hooks", run: runPreCommit},
+		{name: "register", summary: "Register a project's hook flags for hooks serve -registry", run: runRegister},
+		{name: "restore", summary: "Restore a file from the snapshots file-backup took", run: runRestore},
+		{name: "scan", summary: "Report blocked commands in scripts, Makefiles, and workflows", run: runScan},
+		{name: "serve", summary: "Serve a hook over HTTP for remote evaluation", run: runServe},
+		{name: "version", summary: "Print build metadata", run: runVersion},
+		{name: "self-update", summary: "Download and install the latest release", run: runSelfUpdate},
+	}
+}
+
+// hookMains are the hooks bundled into this binary. Each runs when the binary
+// is invoked under the hook's name (optionally prefixed, e.g. through the
+// krmcbride-bash-block shim) or as "hooks <hook> [flags]".
+var hookMains = map[string]func(){
+	"bash-block":         bashblock.Main,
+	"blob-guard":         blobguard.Main,
+	"branch-guard":       branchguard.Main,
+	"command-rewrite":    commandrewrite.Main,
+	"editorconfig-guard": editorconfigguard.Main,
+	"file-backup":        filebackup.Main,
+	"file-format":        fileformat.Main,
+	"generated-guard":    generatedguard.Main,
+	"hook-logger":        hooklogger.Main,
+	"injection-guard":    injectionguard.Main,
+	"owner-guard":        ownerguard.Main,
+	"pkg-install-guard":  pkginstallguard.Main,
+	"rate-limit":         ratelimiter.Main,
+	"readonly-guard":     readonlyguard.Main,
+	"remote-guard":       remoteguard.Main,
+	"sandbox-guard":      sandboxguard.Main,
+	"secret-guard":       secretguard.Main,
+	"self-protect":       selfprotect.Main,
+	"session-summary":    sessionsummary.Main,
+	"subagent-guard":     subagentguard.Main,
+	"usage-guard":        usageguard.Main,
+}
+
+func main() {
+	if name, ok := hookName(os.Args[0]); ok {
+		os.Args[0] = name
+		hookMains[name]()
+		return
+	}
+	if len(os.Args) > 1 {
+		if hookMain, ok := hookMains[os.Args[1]]; ok {
+			os.Args = os.Args[1:]
+			hookMain()
+			return
+		}
+	}
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// hookName maps the name the binary was invoked as to a bundled hook.
+func hookName(arg0 string) (string, bool) {
+	base := strings.TrimSuffix(filepath.Base(arg0), ".exe")
+	for name := range hookMains {
+		if base == name || strings.HasSuffix(base, "-"+name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// hookNames returns the bundled hook names in a stable order.
+func hookNames() []string {
+	names := make([]string, 0, len(hookMains))
+	for name := range hookMains {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		showUsage(stderr)
+		if len(args) == 0 {
+			return 1
+		}
+		return 0
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "Error: unknown command %q\n\n", args[0])
+	showUsage(stderr)
+	return 1
+}
+
+func showUsage(w io.Writer) {
+	fmt.Fprintf(w, `hooks - Manage claudecode-hooks
+
+USAGE:
+    hooks <command> [arguments]
+    hooks <hook> [flags]
+
+COMMANDS:
+`)
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "    %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, `
+HOOKS:
+    %s
+
+Run "hooks <command> -help" or "hooks <hook> -help" for details.
+`, strings.Join(hookNames(), ", "))
+}
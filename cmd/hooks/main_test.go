@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/grant"
+)
+
+func TestRun_UnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bogus"}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown command "bogus"`) {
+		t.Errorf("stderr = %q, want unknown command error", stderr.String())
+	}
+}
+
+func TestRunAuditVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger := audit.NewLogger(path)
+	for _, decision := range []string{audit.DecisionAllow, audit.DecisionBlock} {
+		if err := logger.Log(audit.Record{Hook: "bash-block", Decision: decision}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"audit", "verify", "-file", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "OK: 2 records verified") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Replace(data, []byte(`"decision":"block"`), []byte(`"decision":"allow"`), 1)
+	if err := os.WriteFile(path, tampered, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"audit", "verify", "-file", path}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() on tampered log = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "line 2") {
+		t.Errorf("stderr = %q, want failure at line 2", stderr.String())
+	}
+}
+
+func TestRunAuditReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger := audit.NewLogger(path)
+	records := []audit.Record{
+		{Hook: "bash-block", SessionID: "s1", Decision: audit.DecisionAllow, Command: "terraform destroy", ShadowRules: []string{"terraform destroy"}},
+		{Hook: "bash-block", SessionID: "s1", Decision: audit.DecisionBlock, Command: "git push", Rules: []string{"git-push"}},
+		{Hook: "bash-block", SessionID: "s2", Decision: audit.DecisionBlock, Command: "git push", Rules: []string{"git-push"}},
+		{Hook: "self-protect", SessionID: "s2", Decision: audit.DecisionBlock, FilePath: ".claude/settings.json"},
+	}
+	for _, record := range records {
+		if err := logger.Log(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"audit", "report", "-file", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	for _, want := range []string{
+		"4 records, 3 blocked",
+		"Top blocked commands:\n       2  git push\n       1  .claude/settings.json\n",
+		"Blocks per rule:\n       2  git-push\n       1  self-protect\n",
+		"Shadow rules (would have blocked):\n       1  terraform destroy\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+		}
+	}
+
+	stdout.Reset()
+	if code := run([]string{"audit", "report", "-file", path, "-json", "-top", "1"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() -json = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	var report audit.Report
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, stdout.String())
+	}
+	if report.Blocks != 3 || len(report.TopBlocked) != 1 || len(report.Sessions) != 1 || report.Sessions[0].SessionID != "s2" {
+		t.Errorf("report = %+v, want 3 blocks and the top command and session", report)
+	}
+
+	if code := run([]string{"audit", "report", "-file", path, "-interval", "0s"}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() with -interval 0s = %d, want 1", code)
+	}
+}
+
+// setGrantTerminal makes hooks grant read its confirmation from input, as if
+// typed at an interactive terminal when interactive is true.
+func setGrantTerminal(t *testing.T, input string, interactive bool) {
+	t.Helper()
+	saved := grantTerminal
+	grantTerminal = func() (io.Reader, bool) { return strings.NewReader(input), interactive }
+	t.Cleanup(func() { grantTerminal = saved })
+}
+
+func TestRunGrant(t *testing.T) {
+	dir := t.TempDir()
+	auditPath := filepath.Join(dir, "audit.jsonl")
+	keyPath := filepath.Join(dir, "grant.key")
+	setGrantTerminal(t, "yes\n", true)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"grant", "-dir", dir, "-key", keyPath, "-audit-log", auditPath, "git push origin main"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Granted once") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+
+	token, ok, err := grant.NewStore(dir, keyPath).Consume("git push origin main")
+	if err != nil || !ok {
+		t.Fatalf("Consume() = %v, %v, want the granted command allowed", ok, err)
+	}
+	data, err := os.ReadFile(auditPath) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"decision":"grant"`) || !strings.Contains(string(data), token[:12]) {
+		t.Errorf("audit log = %s, want a grant record", data)
+	}
+
+	if code := run([]string{"grant", "-dir", dir}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() without a command = %d, want 1", code)
+	}
+}
+
+func TestRunGrant_Refused(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		interactive bool
+	}{
+		{"not a terminal", "yes\n", false},
+		{"not confirmed", "no\n", true},
+		{"no answer", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			keyPath := filepath.Join(dir, "grant.key")
+			setGrantTerminal(t, tt.input, tt.interactive)
+
+			var stdout, stderr bytes.Buffer
+			if code := run([]string{"grant", "-dir", dir, "-key", keyPath, "git push"}, &stdout, &stderr); code != 1 {
+				t.Errorf("run() = %d, want 1 (stderr: %s)", code, stderr.String())
+			}
+			if _, ok, err := grant.NewStore(dir, keyPath).Consume("git push"); ok || err != nil {
+				t.Errorf("Consume() = %v, %v, want no grant", ok, err)
+			}
+		})
+	}
+}
+
+func TestRunNormalize(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"normalize", `env -i HOME=/tmp  command g"i"'t'   push`}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "git push" {
+		t.Errorf("stdout = %q, want %q", got, "git push")
+	}
+
+	if code := run([]string{"normalize", "-dialect", "fish", "ls"}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() with an unknown dialect = %d, want 1", code)
+	}
+	if code := run([]string{"normalize", "if"}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() with a parse error = %d, want 1", code)
+	}
+}
+
+func TestDescribeHooks(t *testing.T) {
+	describe := func(name string) ([]byte, error) {
+		return []byte(`{"hook": "` + name + `"}`), nil
+	}
+	manifests, err := describeHooks([]string{"bash-block", "file-format"}, describe)
+	if err != nil {
+		t.Fatalf("describeHooks() error: %v", err)
+	}
+	if len(manifests) != 2 || string(manifests[1]) != `{"hook": "file-format"}` {
+		t.Errorf("describeHooks() = %s", manifests)
+	}
+
+	if _, err := describeHooks([]string{"no-such-hook"}, describe); err == nil {
+		t.Error("describeHooks() should reject unknown hooks")
+	}
+	if _, err := describeHooks([]string{"bash-block"}, func(string) ([]byte, error) { return []byte("usage: ..."), nil }); err == nil {
+		t.Error("describeHooks() should reject output that is not JSON")
+	}
+}
+
+func TestRunConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(valid, []byte("rules:\n  - command: git\n    patterns: [push]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(invalid, []byte("rules:\n  - comand: git\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "validate", valid}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stdout: %s, stderr: %s)", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "OK: "+valid) {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"config", "validate", valid, invalid}, &stdout, &stderr); code != 1 {
+		t.Errorf("run() with an invalid file = %d, want 1", code)
+	}
+	want := invalid + `:2:5: rules[0]: unknown key "comand"; did you mean "command"?`
+	if !strings.Contains(stdout.String(), want) {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunConfigResolve(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "baseline.yaml"), []byte("rules:\n  - name: no-push\n    command: git\n    patterns: [push]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(path, []byte("extends: [baseline.yaml, preset:git-push]\nrules:\n  - name: no-push\n    command: git\n    patterns: [push --force]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "resolve", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	want := "presets:\n  - git-push\nrules:\n  - name: no-push\n    command: git\n    patterns:\n      - push --force\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestInitSnippet(t *testing.T) {
+	emitted := map[string]string{
+		"bash-block":  `{"hooks": {"PreToolUse": [{"matcher": "Bash", "hooks": [{"type": "command", "command": "bash-block -preset git-push"}]}]}}`,
+		"file-format": `{"hooks": {"PostToolUse": [{"matcher": "Edit|MultiEdit|Write", "hooks": [{"type": "command", "command": "file-format"}]}]}}`,
+	}
+	var gotFlags []string
+	emit := func(name string, flags []string) ([]byte, error) {
+		gotFlags = flags
+		return []byte(emitted[name]), nil
+	}
+	shim := func(name string) string { return "$HOME/.claude/hooks/krmcbride-" + name }
+
+	snippet, err := initSnippet([]string{"bash-block", "file-format"}, []string{"-preset", "git-push"}, shim, emit)
+	if err != nil {
+		t.Fatalf("initSnippet() error: %v", err)
+	}
+	if !slices.Equal(gotFlags, []string{"-preset", "git-push"}) {
+		t.Errorf("emit flags = %q", gotFlags)
+	}
+	data, err := json.Marshal(snippet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"hooks":{"PostToolUse":[{"hooks":[{"command":"$HOME/.claude/hooks/krmcbride-file-format","type":"command"}],"matcher":"Edit|MultiEdit|Write"}],` +
+		`"PreToolUse":[{"hooks":[{"command":"$HOME/.claude/hooks/krmcbride-bash-block -preset git-push","type":"command"}],"matcher":"Bash"}]}}`
+	if string(data) != want {
+		t.Errorf("initSnippet() = %s, want %s", data, want)
+	}
+
+	if _, err := initSnippet([]string{"no-such-hook"}, nil, shim, emit); err == nil {
+		t.Error("initSnippet() of an unknown hook succeeded")
+	}
+}
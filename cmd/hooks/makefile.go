@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxMakeDepth bounds the expansion of variables that refer to variables.
+const maxMakeDepth = 10
+
+var (
+	// makeAssignment matches a variable assignment outside a recipe, such as
+	// "GOTESTSUM = $(LOCALBIN)/gotestsum" or "export GOOS := linux".
+	makeAssignment = regexp.MustCompile(`^(?:(?:export|override)\s+)*([A-Za-z_][A-Za-z0-9_.-]*)\s*(\?=|::=|:=|\+=|!=|=)\s*(.*)$`)
+	// makeDefine matches the first line of a multi-line variable.
+	makeDefine = regexp.MustCompile(`^(?:(?:export|override)\s+)*define\s+([A-Za-z_][A-Za-z0-9_.-]*)\s*(?:\?=|::=|:=|=)?\s*$`)
+	// shellIdentifier matches names that can stay variables in shell.
+	shellIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// makeVars are the variables defined in a set of Makefiles. A variable
+// defined twice with different values, or appended to, is left out, since
+// which value a recipe sees depends on the include order.
+type makeVars map[string]string
+
+// parseMakeVars adds the variables data defines to vars. Names in conflicts
+// have no certain value.
+func parseMakeVars(data []byte, vars makeVars, conflicts map[string]bool) {
+	define := func(name, value string) {
+		if existing, ok := vars[name]; ok && existing != value {
+			conflicts[name] = true
+		}
+		vars[name] = value
+	}
+
+	var defining string
+	var body []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if defining != "" {
+			if strings.TrimSpace(line) == "endef" {
+				define(defining, strings.Join(body, "\n"))
+				defining, body = "", nil
+			} else {
+				body = append(body, line)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			continue // Recipe
+		}
+		if match := makeDefine.FindStringSubmatch(line); match != nil {
+			defining = match[1]
+			continue
+		}
+		match := makeAssignment.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if name, op := match[1], match[2]; op == "+=" || op == "!=" {
+			conflicts[name] = true
+		} else {
+			define(name, strings.TrimSpace(stripMakeComment(match[3])))
+		}
+	}
+}
+
+// stripMakeComment removes a trailing # comment from a variable value.
+func stripMakeComment(value string) string {
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' {
+			i++
+		} else if value[i] == '#' {
+			return value[:i]
+		}
+	}
+	return value
+}
+
+// expandMake expands make syntax in text the way make does before handing a
+// recipe to the shell. Variables with a known value are substituted, other
+// variables become shell variables, $(foreach) and $(call) are unrolled,
+// $(shell cmd) becomes $(cmd), and other functions become a dynamic
+// ${MAKE_FUNCTION}. dir is the Makefile's directory, for $(CURDIR).
+func expandMake(text string, vars makeVars, dir string) string {
+	return expandMakeDepth(text, vars, dir, 0)
+}
+
+func expandMakeDepth(text string, vars makeVars, dir string, depth int) string {
+	if depth > maxMakeDepth {
+		return "${MAKE_VARIABLE}"
+	}
+	var out strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '$' || i+1 == len(text) {
+			out.WriteByte(text[i])
+			continue
+		}
+		open := text[i+1]
+		if open == '$' {
+			out.WriteByte('$')
+			i++
+			continue
+		}
+		if open != '(' && open != '{' {
+			out.WriteByte('$') // Automatic variables such as $@ stay as they are
+			continue
+		}
+		end := matchingParen(text, i+1)
+		if end < 0 {
+			out.WriteString(text[i:])
+			break
+		}
+		out.WriteString(expandMakeReference(text[i+2:end], vars, dir, depth))
+		i = end
+	}
+	return out.String()
+}
+
+// expandMakeReference expands the inside of one $(...) reference.
+func expandMakeReference(inner string, vars makeVars, dir string, depth int) string {
+	function, args, isFunction := strings.Cut(inner, " ")
+	if !isFunction {
+		name := expandMakeDepth(inner, vars, dir, depth+1)
+		if value, ok := vars[name]; ok {
+			return expandMakeDepth(value, vars, dir, depth+1)
+		}
+		if name == "CURDIR" {
+			return dir
+		}
+		if shellIdentifier.MatchString(name) {
+			return "${" + name + "}"
+		}
+		return "${MAKE_VARIABLE}"
+	}
+
+	switch function {
+	case "foreach":
+		parts := splitMakeArgs(args, 3)
+		if len(parts) != 3 {
+			break
+		}
+		loopVars := maps.Clone(vars)
+		delete(loopVars, strings.TrimSpace(parts[0]))
+		return expandMakeDepth(parts[2], loopVars, dir, depth+1)
+	case "call":
+		parts := splitMakeArgs(args, -1)
+		name := strings.TrimSpace(expandMakeDepth(parts[0], vars, dir, depth+1))
+		body, ok := vars[name]
+		if !ok {
+			break
+		}
+		callVars := maps.Clone(vars)
+		for n, arg := range parts[1:] {
+			callVars[strconv.Itoa(n+1)] = expandMakeDepth(arg, vars, dir, depth+1)
+		}
+		return expandMakeDepth(body, callVars, dir, depth+1)
+	case "shell":
+		command := strings.TrimSpace(expandMakeDepth(args, vars, dir, depth+1))
+		if command == "pwd" {
+			return dir
+		}
+		return "$(" + command + ")"
+	}
+	return "${MAKE_FUNCTION}"
+}
+
+// matchingParen returns the index of the parenthesis or brace closing the
+// one at open, or -1.
+func matchingParen(text string, open int) int {
+	opening, closing := text[open], byte(')')
+	if opening == '{' {
+		closing = '}'
+	}
+	nesting := 0
+	for i := open; i < len(text); i++ {
+		switch text[i] {
+		case opening:
+			nesting++
+		case closing:
+			nesting--
+			if nesting == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitMakeArgs splits function arguments on commas outside nested
+// references, into at most n parts (all of them if n < 0).
+func splitMakeArgs(args string, n int) []string {
+	var parts []string
+	nesting, start := 0, 0
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case '(', '{':
+			nesting++
+		case ')', '}':
+			nesting--
+		case ',':
+			if nesting == 0 && (n < 0 || len(parts) < n-1) {
+				parts = append(parts, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, args[start:])
+}
+
+// isMakefile reports whether file is a Makefile by name.
+func isMakefile(file string) bool {
+	name := filepath.Base(file)
+	return name == "Makefile" || name == "makefile" || name == "GNUmakefile" || filepath.Ext(name) == ".mk"
+}
+
+// makefileSnippets returns each recipe line, joined with its backslash
+// continuations and expanded with vars, without its @, -, and + prefixes.
+// Lines of define blocks are templates rather than recipes and are skipped;
+// they are scanned where $(call) expands them.
+func makefileSnippets(data []byte, vars makeVars, dir string) []snippet {
+	var snippets []snippet
+	var current *snippet
+	inDefine := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if current == nil {
+			if inDefine {
+				inDefine = strings.TrimSpace(line) != "endef"
+				continue
+			}
+			if makeDefine.MatchString(line) {
+				inDefine = true
+				continue
+			}
+			recipe, ok := strings.CutPrefix(line, "\t")
+			if !ok {
+				continue
+			}
+			current = &snippet{line: lineNumber, source: recipe}
+		} else {
+			current.source += "\n" + strings.TrimPrefix(line, "\t")
+		}
+		if !strings.HasSuffix(line, "\\") {
+			snippets = append(snippets, *current)
+			current = nil
+		}
+	}
+	if current != nil {
+		snippets = append(snippets, *current)
+	}
+	for i := range snippets {
+		source := strings.TrimLeft(snippets[i].source, "@-+ \t")
+		snippets[i].source = strings.TrimLeft(expandMake(source, vars, dir), "@-+ \t")
+	}
+	return snippets
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+func runNormalize(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("normalize", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks normalize [-dialect name] "COMMAND"
+
+Prints the canonical form rules are matched against: wrappers such as
+command, exec, and env -i are removed, quoted pieces of a word are joined,
+and whitespace is collapsed.
+
+FLAGS:
+`)
+		fs.PrintDefaults()
+	}
+	dialect := fs.String("dialect", "bash", "Shell dialect: "+strings.Join(shellparse.Dialects, ", "))
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+	lang, err := shellparse.ParseDialect(*dialect)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	normalized, err := shellparse.Normalize(strings.Join(fs.Args(), " "), lang)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, normalized)
+	return 0
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+)
+
+// Git hook stages accepted by pre-commit -stage.
+const (
+	stagePreCommit = "pre-commit" // Check the staged files
+	stagePrePush   = "pre-push"   // Check the refs being pushed
+)
+
+const (
+	policyMaxRecursion = 10
+	gitTimeout         = 10 * time.Second
+)
+
+// listFlag collects a repeatable flag.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// refUpdate is one ref a push updates, as git passes it to pre-push hooks.
+type refUpdate struct {
+	localRef, localSHA   string
+	remoteRef, remoteSHA string
+}
+
+func runPreCommit(args []string, _, stderr io.Writer) int {
+	fs := flag.NewFlagSet("pre-commit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks pre-commit [-stage pre-commit|pre-push] [-cmd spec] [-preset name] [-rules file] [ARGS...]
+
+Applies the bash-block policy to git commits and pushes, so humans are held
+to the rules Claude is.
+
+    pre-commit  Staged changes to protected_paths or redirects paths are refused.
+                ARGS are the files to check (default: every staged file).
+    pre-push    Each pushed ref is checked as the equivalent git push command,
+                e.g. "git push --force origin main". ARGS are the remote name
+                and URL git passes to pre-push hooks.
+
+Works as .git/hooks/pre-commit and pre-push, or from the pre-commit framework.
+
+FLAGS:
+`)
+		fs.PrintDefaults()
+	}
+	stage := fs.String("stage", stagePreCommit, "Git hook stage: pre-commit or pre-push")
+	var commands, presetNames listFlag
+	fs.Var(&commands, "cmd", "Command and optional patterns to block, as for bash-block (can be specified multiple times)")
+	fs.Var(&presetNames, "preset", "Built-in rule preset to enable (can be specified multiple times)")
+	settings := config.RegisterFlags(fs, config.FailClosed)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if *stage != stagePreCommit && *stage != stagePrePush {
+		fmt.Fprintf(stderr, "Error: invalid stage '%s'. Must be '%s' or '%s'\n", *stage, stagePreCommit, stagePrePush)
+		return 1
+	}
+
+	fail := func(message string, err error) int {
+		fmt.Fprintf(stderr, "Error: %s: %v\n", message, err)
+		if settings.FailMode == config.FailOpen {
+			return 0
+		}
+		return 1
+	}
+	root, err := git(".", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return fail("finding the repository", err)
+	}
+	root = strings.TrimSpace(root)
+	commandDetector, policy, err := policyDetector(settings, root, presetNames, commands)
+	if err != nil {
+		return fail("loading rules", err)
+	}
+
+	var blocked []string
+	if *stage == stagePreCommit {
+		files := fs.Args()
+		if len(files) == 0 {
+			if files, err = stagedFiles(root); err != nil {
+				return fail("listing staged files", err)
+			}
+		}
+		blocked = protectedChanges(commandDetector, policy, root, files)
+	} else {
+		remote := fs.Arg(0)
+		if remote == "" {
+			remote = os.Getenv("PRE_COMMIT_REMOTE_NAME")
+		}
+		updates, err := pushUpdates(os.Stdin)
+		if err != nil {
+			return fail("reading pushed refs", err)
+		}
+		blocked = blockedPushes(commandDetector, root, remote, updates)
+	}
+
+	if len(blocked) == 0 {
+		return 0
+	}
+	fmt.Fprintf(stderr, "🚫 BLOCKED by %s:\n", *stage)
+	for _, issue := range blocked {
+		fmt.Fprintf(stderr, "Issue: %s\n", issue)
+	}
+	return 1
+}
+
+// policyDetector builds a detector from the presets, -cmd specs, and the
+// policy layers that apply to root, as bash-block would for a command run
+// there. Redirect rules resolve against root.
+func policyDetector(settings *config.Settings, root string, presetNames, commands []string) (*detector.CommandDetector, *config.Policy, error) {
+	layers, err := config.LoadLayers(context.Background(), settings, root)
+	if err != nil {
+		return nil, nil, err
+	}
+	policy := config.Merge(layers)
+
+	now := time.Now()
+	var rules []detector.CommandRule
+	var redirects []string
+	for _, name := range presetNames {
+		preset, ok := detector.LookupPreset(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown preset '%s'", name)
+		}
+		rules = append(rules, preset.Rules...)
+		redirects = append(redirects, preset.Redirects...)
+	}
+	for _, spec := range commands {
+		if rule, ok := detector.ParseCommandSpec(spec); ok {
+			rules = append(rules, rule)
+		}
+	}
+	rules = append(rules, policy.CommandRules(now)...)
+	commandDetector := detector.NewCommandDetector(rules, policyMaxRecursion)
+	commandDetector.SetProtectedRedirects(root, append(redirects, policy.RedirectPatterns(now)...))
+	return commandDetector, policy, nil
+}
+
+// protectedChanges returns an issue per file whose change the policy forbids.
+func protectedChanges(commandDetector *detector.CommandDetector, policy *config.Policy, root string, files []string) []string {
+	var issues []string
+	for _, file := range files {
+		if policy.IsProtectedPath(root, file) || commandDetector.ProtectsPath(file) {
+			issues = append(issues, "Change to protected path: "+file)
+		}
+	}
+	return issues
+}
+
+// blockedPushes checks each ref update as the git push command that would
+// make it, and returns the issues of those the rules block.
+func blockedPushes(commandDetector *detector.CommandDetector, root, remote string, updates []refUpdate) []string {
+	var issues []string
+	for _, update := range updates {
+		command := pushCommand(remote, update, isForcePush(root, update))
+		if commandDetector.ShouldBlockShellExpr(command) {
+			issues = append(issues, command+": "+strings.Join(commandDetector.GetIssues(), "; "))
+		}
+	}
+	return issues
+}
+
+// pushUpdates reads the ref updates git writes to a pre-push hook's stdin.
+// Under the pre-commit framework, which passes them in PRE_COMMIT_*
+// variables instead, stdin is not read.
+func pushUpdates(stdin io.Reader) ([]refUpdate, error) {
+	if remoteRef := os.Getenv("PRE_COMMIT_REMOTE_BRANCH"); remoteRef != "" {
+		return []refUpdate{{
+			localRef:  os.Getenv("PRE_COMMIT_LOCAL_BRANCH"),
+			localSHA:  os.Getenv("PRE_COMMIT_TO_REF"),
+			remoteRef: remoteRef,
+			remoteSHA: os.Getenv("PRE_COMMIT_FROM_REF"),
+		}}, nil
+	}
+	var updates []refUpdate
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("malformed ref update %q", scanner.Text())
+		}
+		updates = append(updates, refUpdate{localRef: fields[0], localSHA: fields[1], remoteRef: fields[2], remoteSHA: fields[3]})
+	}
+	return updates, scanner.Err()
+}
+
+// pushCommand is the git push command equivalent to update, such as
+// "git push origin main", "git push --force origin feature:main", or
+// "git push origin --delete main".
+func pushCommand(remote string, update refUpdate, force bool) string {
+	args := []string{"git", "push"}
+	if force {
+		args = append(args, "--force")
+	}
+	if remote != "" {
+		args = append(args, quoteArg(remote))
+	}
+	dst := shortRef(update.remoteRef)
+	switch src := shortRef(update.localRef); {
+	case isZeroSHA(update.localSHA):
+		args = append(args, "--delete", quoteArg(dst))
+	case src == dst || src == "":
+		args = append(args, quoteArg(dst))
+	default:
+		args = append(args, quoteArg(src+":"+dst))
+	}
+	return strings.Join(args, " ")
+}
+
+// isForcePush reports whether update rewrites history on the remote: the
+// remote ref exists and its commit is not an ancestor of the pushed one.
+func isForcePush(root string, update refUpdate) bool {
+	if isZeroSHA(update.remoteSHA) || isZeroSHA(update.localSHA) || update.remoteSHA == "" {
+		return false
+	}
+	_, err := git(root, "merge-base", "--is-ancestor", update.remoteSHA, update.localSHA)
+	return err != nil
+}
+
+// stagedFiles lists the files the next commit adds, changes, or deletes,
+// relative to root.
+func stagedFiles(root string) ([]string, error) {
+	output, err := git(root, "diff", "--cached", "--name-only", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range strings.Split(output, "\x00") {
+		if file != "" {
+			files = append(files, filepath.FromSlash(file))
+		}
+	}
+	return files, nil
+}
+
+// git runs a git command in dir and returns its stdout.
+func git(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(output), nil
+}
+
+// shortRef strips the refs/heads/ or refs/tags/ prefix from a ref.
+func shortRef(ref string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if short, ok := strings.CutPrefix(ref, prefix); ok {
+			return short
+		}
+	}
+	return ref
+}
+
+// isZeroSHA reports whether sha is git's all-zero object name, which marks a
+// ref that does not exist.
+func isZeroSHA(sha string) bool {
+	return sha != "" && strings.Trim(sha, "0") == ""
+}
+
+// quoteArg quotes arg for the shell when it needs quoting.
+func quoteArg(arg string) string {
+	quoted, err := syntax.Quote(arg, syntax.LangBash)
+	if err != nil {
+		return arg
+	}
+	return quoted
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPushCommand(t *testing.T) {
+	const sha = "1111111111111111111111111111111111111111"
+	const zero = "0000000000000000000000000000000000000000"
+
+	tests := []struct {
+		name   string
+		remote string
+		update refUpdate
+		force  bool
+		want   string
+	}{
+		{"same branch", "origin", refUpdate{"refs/heads/main", sha, "refs/heads/main", sha}, false, "git push origin main"},
+		{"renamed branch", "origin", refUpdate{"refs/heads/feature", sha, "refs/heads/main", zero}, false, "git push origin feature:main"},
+		{"force", "origin", refUpdate{"refs/heads/main", sha, "refs/heads/main", sha}, true, "git push --force origin main"},
+		{"delete", "origin", refUpdate{"(delete)", zero, "refs/heads/old", sha}, false, "git push origin --delete old"},
+		{"tag", "upstream", refUpdate{"refs/tags/v1.0.0", sha, "refs/tags/v1.0.0", zero}, false, "git push upstream v1.0.0"},
+		{"quoted remote", "my remote", refUpdate{"refs/heads/main", sha, "refs/heads/main", zero}, false, "git push 'my remote' main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pushCommand(tt.remote, tt.update, tt.force); got != tt.want {
+				t.Errorf("pushCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushUpdates(t *testing.T) {
+	stdin := "refs/heads/main abc refs/heads/main def\n\nrefs/heads/x 123 refs/heads/y 000\n"
+	got, err := pushUpdates(strings.NewReader(stdin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []refUpdate{{"refs/heads/main", "abc", "refs/heads/main", "def"}, {"refs/heads/x", "123", "refs/heads/y", "000"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("pushUpdates() = %v, want %v", got, want)
+	}
+
+	if _, err := pushUpdates(strings.NewReader("refs/heads/main abc\n")); err == nil {
+		t.Error("pushUpdates() with a malformed line succeeded, want error")
+	}
+
+	t.Setenv("PRE_COMMIT_REMOTE_BRANCH", "refs/heads/main")
+	t.Setenv("PRE_COMMIT_LOCAL_BRANCH", "refs/heads/feature")
+	t.Setenv("PRE_COMMIT_FROM_REF", "def")
+	t.Setenv("PRE_COMMIT_TO_REF", "abc")
+	got, err = pushUpdates(strings.NewReader(""))
+	if err != nil || len(got) != 1 || got[0] != (refUpdate{"refs/heads/feature", "abc", "refs/heads/main", "def"}) {
+		t.Errorf("pushUpdates() under pre-commit = %v, %v", got, err)
+	}
+}
+
+func TestRunPreCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // No user policy
+	dir := t.TempDir()
+	gitIn := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitIn("init", "-q", "-b", "main")
+	write(".claudehooks.yaml", "protected_paths: [.env]\nrules:\n  - redirects: [secrets/**]\n  - command: git\n    patterns: [push --force]\n")
+	write("README.md", "one\n")
+	gitIn("add", ".")
+	gitIn("commit", "-q", "-m", "first")
+	first := gitIn("rev-parse", "HEAD")
+	t.Chdir(dir)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"pre-commit"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("pre-commit with nothing staged = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+
+	write(".env", "TOKEN=x\n")
+	write("secrets/key", "x\n")
+	write("main.go", "package main\n")
+	gitIn("add", ".")
+	stderr.Reset()
+	if code := run([]string{"pre-commit"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("pre-commit with protected files staged = %d, want 1", code)
+	}
+	for _, want := range []string{"Change to protected path: .env", "Change to protected path: secrets/key"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr = %q, want %q", stderr.String(), want)
+		}
+	}
+	if strings.Contains(stderr.String(), "main.go") {
+		t.Errorf("stderr = %q, want main.go allowed", stderr.String())
+	}
+	stderr.Reset()
+	if code := run([]string{"pre-commit", "main.go"}, &stdout, &stderr); code != 0 {
+		t.Errorf("pre-commit main.go = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+
+	// Rewrite history so pushing over the first commit needs --force
+	gitIn("reset", "-q", "HEAD")
+	write("README.md", "two\n")
+	gitIn("commit", "-q", "--amend", "-a", "-m", "amended")
+	amended := gitIn("rev-parse", "HEAD")
+	push := func(remoteSHA string) (int, string) {
+		t.Helper()
+		stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stdin.WriteString("refs/heads/main " + amended + " refs/heads/main " + remoteSHA + "\n"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stdin.Seek(0, 0); err != nil {
+			t.Fatal(err)
+		}
+		oldStdin := os.Stdin
+		os.Stdin = stdin
+		defer func() { os.Stdin = oldStdin }()
+		var stderr bytes.Buffer
+		code := run([]string{"pre-commit", "-stage", "pre-push", "origin", "git@example.com:repo.git"}, &stdout, &stderr)
+		return code, stderr.String()
+	}
+	if code, out := push(first); code != 1 || !strings.Contains(out, "git push --force origin main") {
+		t.Errorf("force push = %d (%s), want blocked", code, out)
+	}
+	if code, out := push(strings.Repeat("0", 40)); code != 0 {
+		t.Errorf("push of a new branch = %d (%s), want 0", code, out)
+	}
+}
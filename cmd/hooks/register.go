@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/krmcbride/claudecode-hooks/internal/instances"
+)
+
+func runRegister(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("register", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks register [-registry dir] [-project dir] [-- hook flags]
+    hooks register [-registry dir] [-project dir] -remove
+    hooks register [-registry dir] -list
+
+Registers a project with the registry "hooks serve -registry" routes
+payloads by: a payload whose cwd is inside the project is evaluated with the
+hook flags after "--", from the project directory, so one server serves
+several projects with isolated rules. Registering a project again replaces
+its flags. The innermost registered project containing the cwd wins.
+
+    hooks register -project ~/src/app -- -policy .claude/policy.yaml
+
+FLAGS:
+`)
+		fs.PrintDefaults()
+	}
+	dir := fs.String("registry", instances.DefaultDir(), "Registry directory (must match hooks serve -registry)")
+	project := fs.String("project", ".", "Project directory to register or remove")
+	list := fs.Bool("list", false, "List the registered projects instead of registering one")
+	remove := fs.Bool("remove", false, "Unregister the project instead of registering it")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if *list && *remove || (*list || *remove) && fs.NArg() > 0 {
+		fs.Usage()
+		return 1
+	}
+	registry := instances.NewRegistry(*dir)
+
+	switch {
+	case *list:
+		registered, err := registry.List()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		if len(registered) == 0 {
+			fmt.Fprintf(stdout, "No projects registered in %s\n", *dir)
+			return 0
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "PROJECT\tFLAGS")
+		for _, instance := range registered {
+			fmt.Fprintf(tw, "%s\t%s\n", instance.Project, strings.Join(instance.Flags, " "))
+		}
+		if err := tw.Flush(); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	case *remove:
+		removed, err := registry.Remove(*project)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		if !removed {
+			fmt.Fprintf(stderr, "Error: %s is not registered in %s\n", absPath(*project), *dir)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Unregistered %s\n", absPath(*project))
+	default:
+		instance, err := registry.Register(*project, fs.Args())
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Registered %s with flags: %s\n", instance.Project, strings.Join(instance.Flags, " "))
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/krmcbride/claudecode-hooks/internal/backup"
+)
+
+func runRestore(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks restore [-dir path] -list [FILE]
+    hooks restore [-dir path] [-to path] FILE | SNAPSHOT
+
+Restores a file from the snapshots file-backup takes before edits: the latest
+snapshot of FILE, or the snapshot with the given ID (or a unique prefix of
+it). The current content is snapshotted first, so a restore can be undone.
+
+FLAGS:
+`)
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", "", "Snapshot directory (default: the nearest .claude/backups, as file-backup -dir)")
+	list := fs.Bool("list", false, "List snapshots, of FILE or of every file, instead of restoring")
+	to := fs.String("to", "", "Write the snapshot to this path instead of the original file")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 1 || (fs.NArg() == 0 && !*list) {
+		fs.Usage()
+		return 1
+	}
+
+	if *dir == "" {
+		found, err := findBackupDir()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		*dir = found
+	}
+	store := backup.NewStore(*dir)
+
+	if *list {
+		var path string
+		if fs.NArg() == 1 {
+			path = absPath(fs.Arg(0))
+		}
+		snapshots, err := store.List(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		if len(snapshots) == 0 {
+			fmt.Fprintf(stdout, "No snapshots in %s\n", *dir)
+			return 0
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTIME\tTOOL\tSIZE\tFILE")
+		for _, snapshot := range snapshots {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", snapshot.ID, snapshot.Time.Local().Format("2006-01-02 15:04:05"), snapshot.Tool, snapshot.Size, snapshot.Path)
+		}
+		if err := tw.Flush(); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	snapshot, err := findSnapshot(store, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	target := snapshot.Path
+	if *to != "" {
+		target = absPath(*to)
+	}
+	current, saved, err := store.Save(target, "", "restore")
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: backing up %s before restoring: %v\n", target, err)
+		return 1
+	}
+	if err := store.Restore(snapshot, target); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Restored %s from snapshot %s (%s)\n", target, snapshot.ID, snapshot.Time.Local().Format("2006-01-02 15:04:05"))
+	if saved {
+		fmt.Fprintf(stdout, "The replaced content is snapshot %s\n", current.ID)
+	}
+	return 0
+}
+
+// findSnapshot returns the snapshot an argument names: a snapshot ID or
+// prefix, or else a file whose latest snapshot is wanted.
+func findSnapshot(store *backup.Store, arg string) (backup.Snapshot, error) {
+	if snapshot, err := store.Find(arg); err == nil {
+		return snapshot, nil
+	}
+	path := absPath(arg)
+	snapshots, err := store.List(path)
+	if err != nil {
+		return backup.Snapshot{}, err
+	}
+	if len(snapshots) == 0 {
+		return backup.Snapshot{}, fmt.Errorf("no snapshot with ID %q or of file %s in %s", arg, path, store.Dir())
+	}
+	return snapshots[len(snapshots)-1], nil
+}
+
+// findBackupDir returns the snapshot directory of the current project: the
+// one in $CLAUDE_PROJECT_DIR, or the nearest above the working directory.
+func findBackupDir() (string, error) {
+	if root := os.Getenv("CLAUDE_PROJECT_DIR"); root != "" {
+		return backup.DefaultDir(root), nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	dir, ok := backup.FindDir(cwd)
+	if !ok {
+		return "", fmt.Errorf("no .claude/backups directory found above %s; use -dir", cwd)
+	}
+	return dir, nil
+}
+
+// absPath makes path absolute against the working directory, as snapshots
+// record absolute paths.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krmcbride/claudecode-hooks/internal/backup"
+)
+
+func TestRunRestore(t *testing.T) {
+	root := t.TempDir()
+	dir := backup.DefaultDir(root)
+	path := filepath.Join(root, "main.go")
+	store := backup.NewStore(dir)
+	var first backup.Snapshot
+	for i, content := range []string{"v1", "v2", "broken"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			break
+		}
+		snapshot, _, err := store.Save(path, "", "Edit")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = snapshot
+		}
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantContent string
+	}{
+		{"latest snapshot of a file", []string{"-dir", dir, path}, "v2"},
+		{"snapshot by ID", []string{"-dir", dir, first.ID}, "v1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runRestore(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("runRestore() = %d, stderr: %s", code, stderr.String())
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.wantContent { // #nosec G304 - test file
+				t.Errorf("restored content = %q, want %q", data, tt.wantContent)
+			}
+		})
+	}
+
+	// Each restore snapshotted the content it replaced (broken, then v2), so restores can be undone
+	var stdout, stderr bytes.Buffer
+	if code := runRestore([]string{"-dir", dir, "-list", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runRestore(-list) = %d, stderr: %s", code, stderr.String())
+	}
+	if lines := strings.Count(stdout.String(), "\n"); lines != 5 {
+		t.Errorf("runRestore(-list) printed %d lines, want a header and 4 snapshots:\n%s", lines, stdout.String())
+	}
+
+	if code := runRestore([]string{"-dir", dir, filepath.Join(root, "other.go")}, &stdout, &stderr); code != 1 {
+		t.Errorf("runRestore() of a file without snapshots = %d, want 1", code)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/version"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolURI      = "https://github.com/krmcbride/claudecode-hooks"
+)
+
+// genericRuleID identifies findings no named rule explains, such as dynamic
+// commands or writes to protected paths.
+const genericRuleID = "bash-block"
+
+// nonSlug matches runs of characters not allowed in a SARIF rule ID.
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// sarifLog is the subset of SARIF 2.1.0 hooks scan writes, enough for GitHub
+// code scanning and other dashboards that accept SARIF uploads.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifReport converts scan findings to SARIF with one result per matched
+// rule. File paths are made relative to base so uploads line up with the
+// repository checkout.
+func sarifReport(findings []finding, base string) sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "claudecode-hooks",
+			Version:        version.Version,
+			InformationURI: toolURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndex := make(map[string]int)
+	index := func(name string) (string, int) {
+		id := sarifRuleID(name)
+		if i, ok := ruleIndex[id]; ok {
+			return id, i
+		}
+		description := "Command blocked by rule " + name
+		if name == "" {
+			description = "Command bash-block would block"
+		}
+		ruleIndex[id] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               id,
+			Name:             id,
+			ShortDescription: sarifMessage{Text: description},
+			HelpURI:          toolURI + "#bash-block",
+		})
+		return id, ruleIndex[id]
+	}
+
+	for _, f := range findings {
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: sarifURI(f.File, base)},
+			Region:           sarifRegion{StartLine: f.Line},
+		}}
+		message := sarifMessage{Text: f.Command + ": " + strings.Join(f.Issues, "; ")}
+		rules := f.Rules
+		if len(rules) == 0 {
+			rules = []string{""}
+		}
+		for _, rule := range rules {
+			id, i := index(rule)
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    id,
+				RuleIndex: i,
+				Level:     "error",
+				Message:   message,
+				Locations: []sarifLocation{location},
+			})
+		}
+	}
+	return sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+}
+
+// sarifRuleID derives a stable rule ID from a rule name, e.g. "git-push"
+// from "git push", or genericRuleID for findings without a rule.
+func sarifRuleID(name string) string {
+	id := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if id == "" {
+		return genericRuleID
+	}
+	return id
+}
+
+// sarifURI returns file relative to base with forward slashes, or file as
+// given when it lies outside base.
+func sarifURI(file, base string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		if rel, err := filepath.Rel(base, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return filepath.ToSlash(file)
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// scanSkipDirs are directories scan never descends into.
+var scanSkipDirs = []string{".git", "node_modules", "vendor", ".venv", "venv", "__pycache__"}
+
+// scanShells are shebang interpreters whose scripts scan parses.
+var scanShells = []string{"sh", "bash", "dash", "ksh", "mksh"}
+
+// workflowExpression matches a GitHub Actions expression, ${{ ... }}.
+var workflowExpression = regexp.MustCompile(`\$\{\{[^}]*\}\}`)
+
+// snippet is shell source found in a file, starting at line.
+type snippet struct {
+	line   int
+	source string
+}
+
+// finding is a command the rules would block.
+type finding struct {
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	Command string   `json:"command"`
+	Issues  []string `json:"issues"`
+	Rules   []string `json:"rules,omitempty"` // Rules that matched
+}
+
+func runScan(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks scan [-cmd spec] [-preset name] [-rules file] [-format text|json|sarif] [PATH...]
+
+Reports commands in shell scripts, Makefile recipes, and GitHub Actions
+run: steps that bash-block would block, so automation can be linted with the
+same rules. Exits 1 when any are found. PATH defaults to the current directory.
+SARIF output can be uploaded to GitHub code scanning; its rule IDs derive from
+the names of the rules that matched.
+
+FLAGS:
+`)
+		flags.PrintDefaults()
+	}
+	var commands, presetNames listFlag
+	flags.Var(&commands, "cmd", "Command and optional patterns to block, as for bash-block (can be specified multiple times)")
+	flags.Var(&presetNames, "preset", "Built-in rule preset to enable (can be specified multiple times)")
+	format := flags.String("format", "text", "Output format: text, json, or sarif")
+	settings := config.RegisterFlags(flags, config.FailClosed)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(stderr, "Error: invalid format '%s'. Must be 'text', 'json', or 'sarif'\n", *format)
+		return 1
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	root, err := filepath.Abs(paths[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		root = filepath.Dir(root)
+	}
+	commandDetector, _, err := policyDetector(settings, root, presetNames, commands)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: loading rules: %v\n", err)
+		return 1
+	}
+
+	findings := []finding{}
+	for _, path := range paths {
+		found, err := scanPath(commandDetector, path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		findings = append(findings, found...)
+	}
+
+	var report any = findings
+	switch *format {
+	case "text":
+		for _, f := range findings {
+			fmt.Fprintf(stdout, "%s:%d: %s\n", f.File, f.Line, f.Command)
+			for _, issue := range f.Issues {
+				fmt.Fprintf(stdout, "    Issue: %s\n", issue)
+			}
+		}
+		if len(findings) > 0 {
+			fmt.Fprintf(stdout, "%d blocked commands found\n", len(findings))
+		}
+		report = nil
+	case "sarif":
+		base, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		report = sarifReport(findings, base)
+	}
+	if report != nil {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// scanPath scans a file, or every scannable file below a directory.
+// Variables defined in any Makefile found are expanded in every recipe,
+// since Makefiles usually include each other, and make is assumed to run
+// in the scanned directory.
+func scanPath(commandDetector *detector.CommandDetector, path string) ([]finding, error) {
+	var files []string
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if file != path && slices.Contains(scanSkipDirs, entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	makeDir, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 1 && files[0] == path {
+		makeDir = filepath.Dir(makeDir)
+	}
+	vars := makeVars{}
+	conflicts := make(map[string]bool)
+	for _, file := range files {
+		if !isMakefile(file) {
+			continue
+		}
+		data, err := os.ReadFile(file) // #nosec G304 - scanning user-selected paths
+		if err != nil {
+			return nil, err
+		}
+		parseMakeVars(data, vars, conflicts)
+	}
+	for name := range conflicts {
+		delete(vars, name)
+	}
+
+	var findings []finding
+	for _, file := range files {
+		snippets, err := fileSnippets(file, vars, makeDir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, s := range snippets {
+			findings = append(findings, scanSnippet(commandDetector, file, s)...)
+		}
+	}
+	return findings, nil
+}
+
+// fileSnippets extracts the shell source of a script, Makefile, or workflow.
+// Other files yield nothing. Recipes are expanded as if make ran in makeDir.
+func fileSnippets(file string, vars makeVars, makeDir string) ([]snippet, error) {
+	name := filepath.Base(file)
+	ext := filepath.Ext(name)
+	isWorkflow := (ext == ".yml" || ext == ".yaml") &&
+		(filepath.Base(filepath.Dir(file)) == "workflows" || strings.TrimSuffix(name, ext) == "action")
+	isScript := ext == ".sh" || ext == ".bash"
+	if !isWorkflow && !isMakefile(file) && !isScript && (ext != "" || !hasShellShebang(file)) {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(file) // #nosec G304 - scanning user-selected paths
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case isWorkflow:
+		return workflowSnippets(data)
+	case isMakefile(file):
+		return makefileSnippets(data, vars, makeDir), nil
+	}
+	return []snippet{{line: 1, source: string(data)}}, nil
+}
+
+// hasShellShebang reports whether file starts with a shell interpreter
+// line, such as #!/bin/sh or #!/usr/bin/env bash.
+func hasShellShebang(file string) bool {
+	f, err := os.Open(file) // #nosec G304 - scanning user-selected paths
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+	line, _ := bufio.NewReader(io.LimitReader(f, 256)).ReadString('\n')
+	interpreter, ok := strings.CutPrefix(strings.TrimSpace(line), "#!")
+	if !ok {
+		return false
+	}
+	fields := strings.Fields(interpreter)
+	if len(fields) > 1 && filepath.Base(fields[0]) == "env" {
+		fields = fields[1:]
+	}
+	return len(fields) > 0 && slices.Contains(scanShells, filepath.Base(fields[0]))
+}
+
+// workflowSnippets returns the run: scripts of a GitHub Actions workflow or
+// composite action, skipping steps run by a non-POSIX shell.
+// Expressions such as ${{ inputs.target }} become a shell variable.
+func workflowSnippets(data []byte) ([]snippet, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	var snippets []snippet
+	var visit func(node *yaml.Node)
+	visit = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			var run *yaml.Node
+			shell := ""
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				switch key, value := node.Content[i].Value, node.Content[i+1]; key {
+				case "run":
+					run = value
+				case "shell":
+					shell = value.Value
+				}
+			}
+			if run != nil && run.Kind == yaml.ScalarNode && isPOSIXShell(shell) {
+				line := run.Line
+				if run.Style == yaml.LiteralStyle || run.Style == yaml.FoldedStyle {
+					line++ // The script starts below the | or > indicator
+				}
+				snippets = append(snippets, snippet{line: line, source: workflowExpression.ReplaceAllString(run.Value, "$${GITHUB_EXPRESSION}")})
+			}
+		}
+		for _, child := range node.Content {
+			visit(child)
+		}
+	}
+	visit(&document)
+	return snippets, nil
+}
+
+// scanSnippet checks each top-level statement of s, so a finding points at
+// the line of the command rather than the start of the script.
+func scanSnippet(commandDetector *detector.CommandDetector, file string, s snippet) []finding {
+	statements := []snippet{s}
+	if node, err := shellparse.Parse(s.source); err == nil {
+		if script, ok := node.(*syntax.File); ok {
+			statements = statements[:0]
+			for _, stmt := range script.Stmts {
+				statements = append(statements, snippet{
+					line:   s.line + int(stmt.Pos().Line()) - 1,
+					source: shellparse.Print(stmt),
+				})
+			}
+		}
+	}
+
+	var findings []finding
+	for _, statement := range statements {
+		if !commandDetector.ShouldBlockShellExpr(statement.source) {
+			continue
+		}
+		command, _, _ := strings.Cut(strings.TrimSpace(statement.source), "\n")
+		f := finding{File: file, Line: statement.line, Command: command, Issues: commandDetector.GetIssues()}
+		for _, rule := range commandDetector.MatchedRules() {
+			if name := rule.String(); !slices.Contains(f.Rules, name) {
+				f.Rules = append(f.Rules, name)
+			}
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// isPOSIXShell reports whether a workflow step's shell: runs its script with
+// a POSIX shell. Steps without one use bash.
+func isPOSIXShell(shell string) bool {
+	fields := strings.Fields(shell)
+	return len(fields) == 0 || slices.Contains(scanShells, filepath.Base(fields[0]))
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestExpandMake(t *testing.T) {
+	vars := makeVars{
+		"BIN":      "$(LOCALBIN)/tool",
+		"LOCALBIN": "$(shell pwd)/bin",
+		"HOOKS":    "a b",
+		"install":  "cp $(1) $(2)",
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"$(BIN) run", "/repo/bin/tool run"},
+		{"echo $$HOME $${PATH}", "echo $HOME ${PATH}"},
+		{"$(UNKNOWN) push", "${UNKNOWN} push"},
+		{"$(weird.name) push", "${MAKE_VARIABLE} push"},
+		{"$(foreach hook,$(HOOKS),echo $(hook);)", "echo ${hook};"},
+		{"$(call install,x,$(BIN))", "cp x /repo/bin/tool"},
+		{"$(shell git rev-parse HEAD)", "$(git rev-parse HEAD)"},
+		{"$(word 1,$(HOOKS))", "${MAKE_FUNCTION}"},
+		{"cd $(CURDIR) && touch $@", "cd /repo && touch $@"},
+	}
+	for _, tt := range tests {
+		if got := expandMake(tt.text, vars, "/repo"); got != tt.want {
+			t.Errorf("expandMake(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseMakeVars(t *testing.T) {
+	vars := makeVars{}
+	conflicts := make(map[string]bool)
+	parseMakeVars([]byte("A := one # comment\nexport B ?= two\nC = x\nC += y\ndefine D\nline1\nline2\nendef\nrule:\n\tE := not a variable\n"), vars, conflicts)
+	parseMakeVars([]byte("A := one\nB = changed\n"), vars, conflicts)
+
+	if vars["A"] != "one" || vars["D"] != "line1\nline2" {
+		t.Errorf("parseMakeVars() = %v", vars)
+	}
+	if !conflicts["B"] || !conflicts["C"] || conflicts["A"] {
+		t.Errorf("conflicts = %v, want B and C", conflicts)
+	}
+	if _, ok := vars["E"]; ok {
+		t.Errorf("parseMakeVars() read a recipe line as a variable")
+	}
+}
+
+func TestRunScan(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // No user policy
+	dir := t.TempDir()
+	files := map[string]string{
+		"deploy.sh":                    "#!/bin/bash\nset -e\n\ngit push origin main\necho done\n",
+		"bin/release":                  "#!/usr/bin/env sh\ngit push --tags\n",
+		"bin/notes":                    "git push in a text file\n",
+		"Makefile":                     "GIT ?= git\n\n.PHONY: release\nrelease:\n\t@echo releasing\n\t$(GIT) push \\\n\t\torigin main\n",
+		".github/workflows/ci.yml":     "jobs:\n  ci:\n    steps:\n      - run: go test ./...\n      - run: |\n          make build\n          git push ${{ github.ref }}\n      - shell: pwsh\n        run: git push\n",
+		"node_modules/x/install.sh":    "git push\n",
+		".github/actions/x/action.yml": "runs:\n  using: composite\n  steps:\n    - run: git push\n      shell: bash\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"scan", "-cmd", "git push", "-format", "json", dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("scan = %d, want 1 (stderr: %s)", code, stderr.String())
+	}
+	var findings []finding
+	if err := json.Unmarshal(stdout.Bytes(), &findings); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, f := range findings {
+		rel, _ := filepath.Rel(dir, f.File)
+		got[filepath.ToSlash(rel)+":"+strings.Fields(f.Command)[0]+":"+strconv.Itoa(f.Line)] = true
+	}
+	want := []string{
+		"deploy.sh:git:4",
+		"bin/release:git:2",
+		"Makefile:git:6",
+		".github/workflows/ci.yml:git:7",
+		".github/actions/x/action.yml:git:4",
+	}
+	for _, key := range want {
+		if !got[key] {
+			t.Errorf("findings = %v, want %s", got, key)
+		}
+	}
+	if len(findings) != len(want) {
+		t.Errorf("got %d findings, want %d: %v", len(findings), len(want), got)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"scan", "-cmd", "kubectl", dir}, &stdout, &stderr); code != 0 {
+		t.Errorf("scan without matches = %d, want 0 (stdout: %s)", code, stdout.String())
+	}
+}
+
+func TestSarifReport(t *testing.T) {
+	base := t.TempDir()
+	findings := []finding{
+		{File: filepath.Join(base, "deploy.sh"), Line: 4, Command: "git push", Issues: []string{"Blocked git pattern detected"}, Rules: []string{"git push"}},
+		{File: filepath.Join(base, "ci", "release.sh"), Line: 2, Command: "git push --tags", Issues: []string{"Blocked git pattern detected"}, Rules: []string{"git push"}},
+		{File: filepath.Join(base, "Makefile"), Line: 9, Command: "$(TOOL) run", Issues: []string{"Command uses dynamic substitution - unable to verify safety"}},
+	}
+	report := sarifReport(findings, base)
+
+	if report.Version != "2.1.0" || len(report.Runs) != 1 {
+		t.Fatalf("sarifReport() = %+v", report)
+	}
+	run := report.Runs[0]
+	var ruleIDs []string
+	for _, rule := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	if want := []string{"git-push", "bash-block"}; !slices.Equal(ruleIDs, want) {
+		t.Errorf("rule IDs = %v, want %v", ruleIDs, want)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("got %d results, want 3", len(run.Results))
+	}
+	second := run.Results[1]
+	if second.RuleID != "git-push" || second.RuleIndex != 0 ||
+		second.Locations[0].PhysicalLocation.ArtifactLocation.URI != "ci/release.sh" ||
+		second.Locations[0].PhysicalLocation.Region.StartLine != 2 {
+		t.Errorf("result = %+v", second)
+	}
+	if run.Results[2].RuleID != "bash-block" || run.Results[2].RuleIndex != 1 {
+		t.Errorf("result without a rule = %+v, want bash-block", run.Results[2])
+	}
+}
+
+func TestSarifRuleID(t *testing.T) {
+	tests := map[string]string{
+		"git push":              "git-push",
+		"git-push":              "git-push",
+		"kubectl delete, --all": "kubectl-delete-all",
+		"No Force Pushes!":      "no-force-pushes",
+		"":                      "bash-block",
+	}
+	for name, want := range tests {
+		if got := sarifRuleID(name); got != want {
+			t.Errorf("sarifRuleID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/version"
+)
+
+const (
+	defaultReleaseRepo = "krmcbride/claudecode-hooks"
+	githubAPI          = "https://api.github.com"
+	checksumsAsset     = "checksums.txt"
+	downloadTimeout    = 5 * time.Minute
+	maxAssetSize       = 200 << 20
+)
+
+// release is the subset of the GitHub release API response we use.
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func (r *release) assetURL(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL, true
+		}
+	}
+	return "", false
+}
+
+// updater downloads releases and replaces the running binary.
+type updater struct {
+	client     *http.Client
+	apiBase    string
+	repo       string
+	publicKey  string // Optional cosign public key for checksums.txt.sig
+	executable string // Binary to replace
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+// releaseAssetName is the binary asset name for the current platform.
+func releaseAssetName() string {
+	name := fmt.Sprintf("krmcbride-hooks_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func runSelfUpdate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("self-update", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	tag := fs.String("version", "", "Release tag to install (default: latest)")
+	repo := fs.String("repo", defaultReleaseRepo, "GitHub repository to download releases from")
+	publicKey := fs.String("pubkey", os.Getenv("CLAUDE_HOOKS_RELEASE_PUBKEY"), "Cosign public key; checksums.txt must carry a valid signature (default $CLAUDE_HOOKS_RELEASE_PUBKEY)")
+	force := fs.Bool("force", false, "Reinstall even if already up to date")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: locating the running binary: %v\n", err)
+		return 1
+	}
+
+	u := &updater{
+		client:     &http.Client{Timeout: downloadTimeout},
+		apiBase:    githubAPI,
+		repo:       *repo,
+		publicKey:  *publicKey,
+		executable: executable,
+		stdout:     stdout,
+		stderr:     stderr,
+	}
+	if err := u.update(context.Background(), *tag, *force); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// update installs the release tagged tag (or the latest release).
+func (u *updater) update(ctx context.Context, tag string, force bool) error {
+	rel, err := u.fetchRelease(ctx, tag)
+	if err != nil {
+		return err
+	}
+	current := version.Get().Version
+	if rel.TagName == current && !force {
+		fmt.Fprintf(u.stdout, "Already up to date (%s)\n", current)
+		return nil
+	}
+
+	assetName := releaseAssetName()
+	binaryURL, ok := rel.assetURL(assetName)
+	if !ok {
+		return fmt.Errorf("release %s has no binary for this platform (%s)", rel.TagName, assetName)
+	}
+	checksumsURL, ok := rel.assetURL(checksumsAsset)
+	if !ok {
+		return fmt.Errorf("release %s has no %s", rel.TagName, checksumsAsset)
+	}
+
+	checksums, err := u.download(ctx, checksumsURL)
+	if err != nil {
+		return err
+	}
+	if err := u.verifyChecksumsSignature(ctx, rel, checksums); err != nil {
+		return err
+	}
+	want, err := findChecksum(checksums, assetName)
+	if err != nil {
+		return err
+	}
+
+	binary, err := u.download(ctx, binaryURL)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", assetName, got, want)
+	}
+
+	if err := replaceExecutable(u.executable, binary); err != nil {
+		return err
+	}
+	fmt.Fprintf(u.stdout, "Updated %s from %s to %s\n", u.executable, current, rel.TagName)
+	return nil
+}
+
+// verifyChecksumsSignature checks checksums.txt.sig when a public key is configured.
+func (u *updater) verifyChecksumsSignature(ctx context.Context, rel *release, checksums []byte) error {
+	if u.publicKey == "" {
+		fmt.Fprintln(u.stderr, "Warning: no -pubkey given; verifying checksums without a signature")
+		return nil
+	}
+	sigURL, ok := rel.assetURL(checksumsAsset + ".sig")
+	if !ok {
+		return fmt.Errorf("release %s has no %s.sig", rel.TagName, checksumsAsset)
+	}
+	sig, err := u.download(ctx, sigURL)
+	if err != nil {
+		return err
+	}
+	if err := config.VerifyBlobSignature(checksums, sig, u.publicKey); err != nil {
+		return fmt.Errorf("verifying %s: %w", checksumsAsset, err)
+	}
+	return nil
+}
+
+func (u *updater) fetchRelease(ctx context.Context, tag string) (*release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", u.apiBase, u.repo)
+	if tag != "" {
+		url = fmt.Sprintf("%s/repos/%s/releases/tags/%s", u.apiBase, u.repo, tag)
+	}
+	data, err := u.download(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	var rel release
+	if err := json.Unmarshal(data, &rel); err != nil {
+		return nil, fmt.Errorf("decoding release: %w", err)
+	}
+	return &rel, nil
+}
+
+func (u *updater) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck // Response fully read
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize))
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// findChecksum looks up an asset in a sha256sum-style checksums file.
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no entry for %s", checksumsAsset, name)
+}
+
+// replaceExecutable atomically swaps the binary at path for data.
+func replaceExecutable(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".hooks-update-*")
+	if err != nil {
+		return fmt.Errorf("writing update: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer func() { _ = os.Remove(tmpName) }() //nolint:errcheck // Already renamed on success
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close() //nolint:errcheck // Already failing
+		return fmt.Errorf("writing update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing update: %w", err)
+	}
+	if err := os.Chmod(tmpName, 0o755); err != nil { // #nosec G302 - executable binary
+		return fmt.Errorf("writing update: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("replacing %s: %w", path, err)
+	}
+	return nil
+}
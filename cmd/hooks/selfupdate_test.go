@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// releaseServer serves a fake GitHub release containing binary for the current platform.
+func releaseServer(t *testing.T, binary []byte, checksums, signature string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	mux.HandleFunc("/repos/example/hooks/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		rel := map[string]any{
+			"tag_name": "v9.9.9",
+			"assets": []map[string]string{
+				{"name": releaseAssetName(), "browser_download_url": server.URL + "/download/binary"},
+				{"name": checksumsAsset, "browser_download_url": server.URL + "/download/checksums"},
+				{"name": checksumsAsset + ".sig", "browser_download_url": server.URL + "/download/sig"},
+			},
+		}
+		_ = json.NewEncoder(w).Encode(rel) //nolint:errcheck // Test server
+	})
+	mux.HandleFunc("/download/binary", func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write(binary) })               //nolint:errcheck // Test server
+	mux.HandleFunc("/download/checksums", func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte(checksums)) }) //nolint:errcheck // Test server
+	mux.HandleFunc("/download/sig", func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte(signature)) })       //nolint:errcheck // Test server
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestUpdater(t *testing.T, server *httptest.Server, publicKey string) (*updater, string) {
+	t.Helper()
+	executable := filepath.Join(t.TempDir(), "krmcbride-hooks")
+	if err := os.WriteFile(executable, []byte("old binary"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &updater{
+		client:     server.Client(),
+		apiBase:    server.URL,
+		repo:       "example/hooks",
+		publicKey:  publicKey,
+		executable: executable,
+		stdout:     &bytes.Buffer{},
+		stderr:     &bytes.Buffer{},
+	}, executable
+}
+
+func checksumLine(binary []byte) string {
+	sum := sha256.Sum256(binary)
+	return hex.EncodeToString(sum[:]) + "  " + releaseAssetName() + "\n"
+}
+
+func TestUpdater_Update(t *testing.T) {
+	binary := []byte("new binary")
+	checksums := "0000  other_asset\n" + checksumLine(binary)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPath := filepath.Join(t.TempDir(), "cosign.pub")
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(checksums)))
+
+	server := releaseServer(t, binary, checksums, signature)
+	u, executable := newTestUpdater(t, server, pubPath)
+	if err := u.update(context.Background(), "", false); err != nil {
+		t.Fatalf("update() error: %v", err)
+	}
+
+	got, err := os.ReadFile(executable) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, binary) {
+		t.Errorf("executable = %q, want %q", got, binary)
+	}
+	info, err := os.Stat(executable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("updated binary is not executable: %v", info.Mode())
+	}
+}
+
+func TestUpdater_UpdateRejectsTampering(t *testing.T) {
+	binary := []byte("new binary")
+
+	t.Run("Checksum mismatch", func(t *testing.T) {
+		server := releaseServer(t, []byte("tampered binary"), checksumLine(binary), "")
+		u, executable := newTestUpdater(t, server, "")
+		err := u.update(context.Background(), "", false)
+		if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Fatalf("update() error = %v, want checksum mismatch", err)
+		}
+		got, err := os.ReadFile(executable) // #nosec G304 - test file
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "old binary" {
+			t.Errorf("executable was replaced despite the checksum mismatch")
+		}
+	})
+
+	t.Run("Invalid signature", func(t *testing.T) {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubPath := filepath.Join(t.TempDir(), "cosign.pub")
+		if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		bogus := base64.StdEncoding.EncodeToString(make([]byte, ed25519.SignatureSize))
+
+		server := releaseServer(t, binary, checksumLine(binary), bogus)
+		u, _ := newTestUpdater(t, server, pubPath)
+		if err := u.update(context.Background(), "", false); err == nil {
+			t.Fatal("update() should reject an invalid signature")
+		}
+	})
+}
+
+func TestRunVersion(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"version", "-json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run(version) = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	var info map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		t.Fatalf("version -json output is not JSON: %v", err)
+	}
+	if info["version"] == "" || info["platform"] == "" {
+		t.Errorf("version -json = %v, want version and platform", info)
+	}
+}
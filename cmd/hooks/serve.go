@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/instances"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+const (
+	defaultServeTimeout = 5 * time.Second
+	maxPayloadSize      = 1 << 20
+	shutdownTimeout     = 10 * time.Second
+)
+
+// hookRun is the result of running a hook on one payload.
+type hookRun struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+}
+
+// evaluation is the POST /evaluate response: the hook's exit code and output
+// as Claude Code would receive them, plus the outcome they signal.
+type evaluation struct {
+	Outcome  hook.Outcome    `json:"outcome"`
+	ExitCode int             `json:"exit_code"`
+	Reason   string          `json:"reason,omitempty"`  // Stderr, or the reason in the JSON output
+	Output   json.RawMessage `json:"output,omitempty"`  // JSON response the hook wrote to stdout
+	Project  string          `json:"project,omitempty"` // Registered project whose flags evaluated the payload
+}
+
+// server answers evaluation requests by running one bundled hook per request.
+type server struct {
+	hook    string
+	timeout time.Duration
+	flags   []string // Hook flags for payloads outside every registered project
+
+	// registry, if set, routes payloads by cwd to the flags of the project
+	// they come from. It is read per request, so registering a project takes
+	// effect without a restart.
+	registry *instances.Registry
+
+	// run evaluates a payload with the hook flags, from dir ("" for the
+	// server's working directory).
+	run func(ctx context.Context, dir string, flags []string, payload []byte) (hookRun, error)
+}
+
+func runServe(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, `USAGE:
+    hooks serve -http addr [-hook name] [-timeout 5s] [-registry dir] [TLS flags] [-- hook flags]
+
+Serves a hook over HTTP so policy servers and remote agents can consult it:
+
+    POST /evaluate   Hook payload in, decision out
+    GET  /healthz    Liveness check
+
+Each request runs the hook with the flags after "--", e.g.
+    hooks serve -http :8799 -- -preset git-push -reason-format json
+The hook loads its policy files on every request, so edits take effect
+without a restart.
+
+With -registry, one server serves many projects with isolated rules: a
+payload whose cwd is inside a project registered with "hooks register" runs
+with that project's flags, from the project directory, instead.
+
+FLAGS:
+`)
+		fs.PrintDefaults()
+	}
+	addr := fs.String("http", "", "Address to listen on, e.g. :8799")
+	hookName := fs.String("hook", "bash-block", "Bundled hook to evaluate payloads with")
+	timeout := fs.Duration("timeout", defaultServeTimeout, "Per-request evaluation timeout")
+	certFile := fs.String("tls-cert", "", "Server certificate (PEM); enables HTTPS")
+	keyFile := fs.String("tls-key", "", "Server private key (PEM)")
+	clientCA := fs.String("client-ca", "", "CA bundle (PEM) that client certificates must chain to; enables mTLS")
+	registryDir := fs.String("registry", "", "Project registry to route payloads by cwd, e.g. "+instances.DefaultDir()+" (see hooks register)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if *addr == "" {
+		fs.Usage()
+		return 1
+	}
+	if _, ok := hookMains[*hookName]; !ok {
+		fmt.Fprintf(stderr, "Error: unknown hook %q (available: %s)\n", *hookName, strings.Join(hookNames(), ", "))
+		return 1
+	}
+	if *timeout <= 0 {
+		fmt.Fprintf(stderr, "Error: -timeout must be positive\n")
+		return 1
+	}
+	tlsConfig, err := serverTLSConfig(*certFile, *keyFile, *clientCA)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: locating hooks binary: %v\n", err)
+		return 1
+	}
+
+	s := &server{hook: *hookName, timeout: *timeout, flags: fs.Args(), run: execHook(executable, *hookName)}
+	if *registryDir != "" {
+		s.registry = instances.NewRegistry(*registryDir)
+	}
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           s.handler(),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx) //nolint:errcheck // Shutting down anyway
+	}()
+
+	fmt.Fprintf(stdout, "Serving %s on %s\n", *hookName, *addr)
+	if tlsConfig != nil {
+		err = httpServer.ListenAndServeTLS(*certFile, *keyFile)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// serverTLSConfig returns the TLS settings for the flags, or nil for plain
+// HTTP. A client CA requires every client to present a certificate it signed.
+func serverTLSConfig(certFile, keyFile, clientCA string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		if clientCA != "" {
+			return nil, errors.New("-client-ca requires -tls-cert and -tls-key")
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("-tls-cert and -tls-key must be set together")
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if clientCA != "" {
+		pem, err := os.ReadFile(clientCA) // #nosec G304 - path from command line flag
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCA)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// execHook runs the hook as a child process of this binary, as Claude Code
+// would, so each evaluation starts from clean state.
+func execHook(executable, name string) func(context.Context, string, []string, []byte) (hookRun, error) {
+	return func(ctx context.Context, dir string, flags []string, payload []byte) (hookRun, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, executable, append([]string{name}, flags...)...) // #nosec G204 - runs this binary
+		cmd.Dir = dir
+		cmd.Stdin = bytes.NewReader(payload)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if ctx.Err() != nil {
+			return hookRun{}, ctx.Err()
+		}
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			return hookRun{}, err
+		}
+		return hookRun{ExitCode: cmd.ProcessState.ExitCode(), Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}, nil
+	}
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok\n") //nolint:errcheck // Client went away
+	})
+	mux.HandleFunc("POST /evaluate", s.evaluate)
+	return mux
+}
+
+func (s *server) evaluate(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "reading payload: "+err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !json.Valid(payload) {
+		http.Error(w, "payload is not valid JSON", http.StatusBadRequest)
+		return
+	}
+
+	instance, err := s.route(payload)
+	if err != nil {
+		http.Error(w, "routing payload: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	result, err := s.run(ctx, instance.Project, instance.Flags, payload)
+	if errors.Is(err, context.DeadlineExceeded) {
+		http.Error(w, fmt.Sprintf("%s did not finish within %s", s.hook, s.timeout), http.StatusGatewayTimeout)
+		return
+	}
+	if err != nil {
+		http.Error(w, "running "+s.hook+": "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	e := decide(result)
+	e.Project = instance.Project
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(e) //nolint:errcheck // Client went away
+}
+
+// route returns the registered project a payload comes from, by its cwd, or
+// an instance with no project and the server's own flags.
+func (s *server) route(payload []byte) (instances.Instance, error) {
+	fallback := instances.Instance{Flags: s.flags}
+	if s.registry == nil {
+		return fallback, nil
+	}
+	var input struct {
+		Cwd string `json:"cwd"`
+	}
+	if json.Unmarshal(payload, &input) != nil || input.Cwd == "" {
+		return fallback, nil
+	}
+	instance, ok, err := s.registry.Lookup(input.Cwd)
+	if err != nil || !ok {
+		return fallback, err
+	}
+	return instance, nil
+}
+
+// decide maps a hook's exit code and output to the outcome they signal
+// under the hook protocol.
+func decide(result hookRun) evaluation {
+	e := evaluation{ExitCode: result.ExitCode, Reason: strings.TrimSpace(string(result.Stderr))}
+	switch hook.ExitCode(result.ExitCode) {
+	case hook.ExitSuccess:
+		e.Outcome = hook.OutcomeAllow
+	case hook.ExitBlock:
+		e.Outcome = hook.OutcomeBlock
+		return e
+	default:
+		e.Outcome = hook.OutcomeError
+		return e
+	}
+
+	output := bytes.TrimSpace(result.Stdout)
+	if len(output) == 0 || !json.Valid(output) {
+		return e
+	}
+	e.Output = output
+	var response struct {
+		hook.PostToolUseResponse
+		HookSpecificOutput struct {
+			hook.PreToolUseOutput
+			AdditionalContext    string          `json:"additionalContext"`
+			UpdatedMCPToolOutput json.RawMessage `json:"updatedMCPToolOutput"`
+		} `json:"hookSpecificOutput"`
+	}
+	if json.Unmarshal(output, &response) != nil {
+		return e
+	}
+	if decision := response.HookSpecificOutput.PermissionDecision; decision != "" {
+		e.Outcome = hook.Outcome(decision)
+		e.Reason = response.HookSpecificOutput.PermissionDecisionReason
+	} else if response.Decision == string(hook.OutcomeBlock) {
+		e.Outcome = hook.OutcomeBlock
+		e.Reason = response.Reason
+	} else if len(response.HookSpecificOutput.UpdatedMCPToolOutput) > 0 {
+		e.Outcome = hook.OutcomeUpdate
+	} else if context := response.HookSpecificOutput.AdditionalContext; context != "" {
+		e.Outcome = hook.OutcomeContext
+		e.Reason = context
+	}
+	return e
+}
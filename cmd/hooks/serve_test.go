@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/instances"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      hookRun
+		wantOutcome hook.Outcome
+		wantReason  string
+	}{
+		{"allow", hookRun{ExitCode: 0}, hook.OutcomeAllow, ""},
+		{"block", hookRun{ExitCode: 2, Stderr: []byte("🚫 BLOCKED: git push\n")}, hook.OutcomeBlock, "🚫 BLOCKED: git push"},
+		{"error", hookRun{ExitCode: 1, Stderr: []byte("Error: bad flag\n")}, hook.OutcomeError, "Error: bad flag"},
+		{"ask", hookRun{Stdout: []byte(`{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"ask","permissionDecisionReason":"unlisted"}}`)},
+			hook.OutcomeAsk, "unlisted"},
+		{"deny", hookRun{Stdout: []byte(`{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"deny","permissionDecisionReason":"{}"}}`)},
+			hook.OutcomeDeny, "{}"},
+		{"post tool use block", hookRun{Stdout: []byte(`{"decision":"block","reason":"format failed"}`)}, hook.OutcomeBlock, "format failed"},
+		{"post tool use update", hookRun{Stdout: []byte(`{"hookSpecificOutput":{"hookEventName":"PostToolUse","updatedMCPToolOutput":[{"type":"text","text":"[REDACTED jwt]"}]}}`)},
+			hook.OutcomeUpdate, ""},
+		{"post tool use context", hookRun{Stdout: []byte(`{"hookSpecificOutput":{"hookEventName":"PostToolUse","additionalContext":"untrusted page"}}`)},
+			hook.OutcomeContext, "untrusted page"},
+		{"non-JSON stdout", hookRun{Stdout: []byte("context\n")}, hook.OutcomeAllow, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decide(tt.result)
+			if got.Outcome != tt.wantOutcome || got.Reason != tt.wantReason {
+				t.Errorf("decide() = %q (%q), want %q (%q)", got.Outcome, got.Reason, tt.wantOutcome, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestServer(t *testing.T) {
+	s := &server{hook: "bash-block", timeout: 50 * time.Millisecond, run: func(ctx context.Context, _ string, _ []string, payload []byte) (hookRun, error) {
+		if bytes.Contains(payload, []byte("sleep")) {
+			<-ctx.Done()
+			return hookRun{}, ctx.Err()
+		}
+		if bytes.Contains(payload, []byte("git push")) {
+			return hookRun{ExitCode: 2, Stderr: []byte("blocked")}, nil
+		}
+		return hookRun{}, nil
+	}}
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /healthz = %d, want 200", resp.StatusCode)
+	}
+
+	tests := []struct {
+		name        string
+		payload     string
+		wantStatus  int
+		wantOutcome hook.Outcome
+	}{
+		{"allowed", `{"tool_input":{"command":"ls"}}`, http.StatusOK, hook.OutcomeAllow},
+		{"blocked", `{"tool_input":{"command":"git push"}}`, http.StatusOK, hook.OutcomeBlock},
+		{"invalid JSON", `{"tool_input":`, http.StatusBadRequest, ""},
+		{"timeout", `{"tool_input":{"command":"sleep"}}`, http.StatusGatewayTimeout, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+"/evaluate", "application/json", strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("POST /evaluate = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantOutcome == "" {
+				return
+			}
+			var got evaluation
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Outcome != tt.wantOutcome {
+				t.Errorf("outcome = %q, want %q", got.Outcome, tt.wantOutcome)
+			}
+		})
+	}
+}
+
+func TestServer_Registry(t *testing.T) {
+	registry := instances.NewRegistry(t.TempDir())
+	app := t.TempDir()
+	nested := filepath.Join(app, "services", "api")
+	if err := os.MkdirAll(nested, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for project, flags := range map[string][]string{app: {"-preset", "git-push"}, nested: {"-preset", "git-config"}} {
+		if _, err := registry.Register(project, flags); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var gotDir string
+	var gotFlags []string
+	s := &server{hook: "bash-block", timeout: time.Second, flags: []string{"-cmd", "ls"}, registry: registry,
+		run: func(_ context.Context, dir string, flags []string, _ []byte) (hookRun, error) {
+			gotDir, gotFlags = dir, flags
+			return hookRun{}, nil
+		}}
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	tests := []struct {
+		name      string
+		cwd       string
+		wantDir   string
+		wantFlags []string
+	}{
+		{"project", app, app, []string{"-preset", "git-push"}},
+		{"below a project", filepath.Join(app, "docs"), app, []string{"-preset", "git-push"}},
+		{"innermost project wins", filepath.Join(nested, "cmd"), nested, []string{"-preset", "git-config"}},
+		{"unregistered", "/elsewhere", "", []string{"-cmd", "ls"}},
+		{"no cwd", "", "", []string{"-cmd", "ls"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, _ := json.Marshal(map[string]string{"cwd": tt.cwd}) //nolint:errcheck // Cannot fail
+			resp, err := http.Post(ts.URL+"/evaluate", "application/json", bytes.NewReader(payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			var got evaluation
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if gotDir != tt.wantDir || !slices.Equal(gotFlags, tt.wantFlags) || got.Project != tt.wantDir {
+				t.Errorf("ran in %q with %q (project %q), want %q with %q", gotDir, gotFlags, got.Project, tt.wantDir, tt.wantFlags)
+			}
+		})
+	}
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	tests := []struct {
+		name                      string
+		certFile, keyFile, caFile string
+		wantErr                   bool
+	}{
+		{"plain HTTP", "", "", "", false},
+		{"client CA without server cert", "", "", "ca.pem", true},
+		{"cert without key", "cert.pem", "", "", true},
+		{"missing client CA file", "cert.pem", "key.pem", "/nonexistent/ca.pem", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := serverTLSConfig(tt.certFile, tt.keyFile, tt.caFile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("serverTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
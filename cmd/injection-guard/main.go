@@ -0,0 +1,8 @@
+// Package main provides a prompt-injection scanner for web content in Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/injectionguard"
+
+func main() {
+	injectionguard.Main()
+}
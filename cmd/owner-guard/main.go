@@ -0,0 +1,8 @@
+// Package main provides a CODEOWNERS-based code ownership guard for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/ownerguard"
+
+func main() {
+	ownerguard.Main()
+}
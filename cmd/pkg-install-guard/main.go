@@ -0,0 +1,8 @@
+// Package main provides a package install guard for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/pkginstallguard"
+
+func main() {
+	pkginstallguard.Main()
+}
@@ -0,0 +1,8 @@
+// Package main provides a rate limiter for risky-but-allowed tool calls in Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/ratelimiter"
+
+func main() {
+	ratelimiter.Main()
+}
@@ -0,0 +1,8 @@
+// Package main provides a read-only mode guard for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/readonlyguard"
+
+func main() {
+	readonlyguard.Main()
+}
@@ -0,0 +1,8 @@
+// Package main provides a git remote allowlist for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/remoteguard"
+
+func main() {
+	remoteguard.Main()
+}
@@ -0,0 +1,8 @@
+// Package main provides a project root confinement guard for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/sandboxguard"
+
+func main() {
+	sandboxguard.Main()
+}
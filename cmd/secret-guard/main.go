@@ -0,0 +1,8 @@
+// Package main provides secret redaction for tool output in Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/secretguard"
+
+func main() {
+	secretguard.Main()
+}
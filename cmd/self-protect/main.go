@@ -0,0 +1,8 @@
+// Package main provides a hook configuration guard for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/selfprotect"
+
+func main() {
+	selfprotect.Main()
+}
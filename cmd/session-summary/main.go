@@ -0,0 +1,8 @@
+// Package main provides a Claude Code hook that summarizes each session as markdown
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/sessionsummary"
+
+func main() {
+	sessionsummary.Main()
+}
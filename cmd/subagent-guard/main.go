@@ -0,0 +1,8 @@
+// Package main provides a subagent (Task tool) guard for Claude Code hooks
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/subagentguard"
+
+func main() {
+	subagentguard.Main()
+}
@@ -0,0 +1,8 @@
+// Package main provides token and tool call budgets for Claude Code sessions
+package main
+
+import "github.com/krmcbride/claudecode-hooks/internal/hooks/usageguard"
+
+func main() {
+	usageguard.Main()
+}
@@ -3,3 +3,5 @@ module github.com/krmcbride/claudecode-hooks
 go 1.24.3
 
 require mvdan.cc/sh/v3 v3.12.0
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,189 @@
+// Package audit provides an append-only, hash-chained JSONL log of hook decisions.
+//
+// Every record carries the hash of the record before it (prev_hash) and its own
+// hash, forming a chain: editing, deleting, or reordering a record breaks the
+// chain from that point on, which Verify detects. A record's hash is the hex
+// SHA-256 of its JSON encoding without the trailing "hash" field, so the chain
+// can be checked with standard tools as well. The hashes are unkeyed, so
+// truncating the log or rewriting its tail is only detected against a head
+// recorded elsewhere (VerifyChain).
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+)
+
+// Decisions recorded in the audit log.
+const (
+	DecisionAllow   = "allow"
+	DecisionBlock   = "block"
+	DecisionGrant   = "grant"   // A human allowed a command once (hooks grant)
+	DecisionWarn    = "warn"    // Allowed with a warning to the user
+	DecisionRewrite = "rewrite" // Run with input the hook changed, e.g. a safer command
+	DecisionRedact  = "redact"  // Tool output given to Claude with secrets removed
+)
+
+// Record is a single audited hook decision.
+type Record struct {
+	Time        time.Time `json:"time"`
+	Hook        string    `json:"hook"`
+	Event       string    `json:"event,omitempty"`
+	SessionID   string    `json:"session_id,omitempty"`
+	ToolName    string    `json:"tool_name,omitempty"`
+	Decision    string    `json:"decision"`
+	Reason      string    `json:"reason,omitempty"`
+	ErrorKind   string    `json:"error_kind,omitempty"` // Why a tool call could not be judged, e.g. config or parse
+	Issues      []string  `json:"issues,omitempty"`
+	Rules       []string  `json:"rules,omitempty"`        // Rules that blocked the command, asked or warned about it, or rewrote it
+	RuleIndexes []int     `json:"rule_indexes,omitempty"` // Positions of the blocking rules among the rules evaluated
+	ShadowRules []string  `json:"shadow_rules,omitempty"` // Shadow rules that would have blocked
+	Command     string    `json:"command,omitempty"`
+	Rewritten   string    `json:"rewritten,omitempty"` // The command that runs instead of Command
+	FilePath    string    `json:"file_path,omitempty"`
+	PrevHash    string    `json:"prev_hash"`
+	Hash        string    `json:"hash,omitempty"`
+}
+
+// Logger appends records to a JSONL file. A Logger with an empty path discards records.
+type Logger struct {
+	path string
+}
+
+// NewLogger creates a Logger writing to path. An empty path disables logging.
+func NewLogger(path string) *Logger {
+	return &Logger{path: path}
+}
+
+// Enabled reports whether records are written anywhere.
+func (l *Logger) Enabled() bool {
+	return l != nil && l.path != ""
+}
+
+// Log appends a record, filling in the timestamp if it is not set and chaining
+// it to the last record in the file. Concurrent hook processes are serialized
+// with a lock file so the chain stays linear.
+func (l *Logger) Log(record Record) error {
+	return l.LogContext(context.Background(), record)
+}
+
+// LogContext is Log that gives up waiting for the lock, with ctx's error,
+// when ctx is done. A record is either written whole or not at all.
+func (l *Logger) LogContext(ctx context.Context, record Record) error {
+	if !l.Enabled() {
+		return nil
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o750); err != nil {
+		return fmt.Errorf("creating audit log directory: %w", err)
+	}
+	unlock, err := utils.LockFileContext(ctx, l.path+".lock")
+	if err != nil {
+		return fmt.Errorf("locking audit log: %w", err)
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+
+	prevHash, err := lastHash(f)
+	if err != nil {
+		_ = f.Close() //nolint:errcheck // Already failing
+		return err
+	}
+	record.PrevHash = prevHash
+
+	line, err := encodeChained(record)
+	if err != nil {
+		_ = f.Close() //nolint:errcheck // Already failing
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close() //nolint:errcheck // Already failing
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing audit log: %w", err)
+	}
+	return nil
+}
+
+// encodeChained marshals the record and appends its hash as the final field.
+func encodeChained(record Record) ([]byte, error) {
+	record.Hash = ""
+	body, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("encoding audit record: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+
+	line := make([]byte, 0, len(body)+len(hash)+10)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, `,"hash":"`...)
+	line = append(line, hash...)
+	line = append(line, `"}`...)
+	return line, nil
+}
+
+// lastHash returns the hash of the last record in the file, or "" for an empty file.
+func lastHash(f *os.File) (string, error) {
+	line, err := lastLine(f)
+	if err != nil || len(line) == 0 {
+		return "", err
+	}
+	var record Record
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", fmt.Errorf("reading last audit record: %w", err)
+	}
+	return record.Hash, nil
+}
+
+// lastLine reads the final non-empty line of f without loading the whole file.
+func lastLine(f *os.File) ([]byte, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+
+	const chunkSize = 64 * 1024
+	var tail []byte
+	for offset := info.Size(); offset > 0; {
+		size := int64(chunkSize)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+
+		chunk := make([]byte, size)
+		if _, err := f.ReadAt(chunk, offset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reading audit log: %w", err)
+		}
+		tail = append(chunk, tail...)
+
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		if offset == 0 {
+			return trimmed, nil
+		}
+	}
+	return nil, nil
+}
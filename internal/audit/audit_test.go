@@ -0,0 +1,259 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogger_Disabled(t *testing.T) {
+	logger := NewLogger("")
+	if logger.Enabled() {
+		t.Error("Logger without path should be disabled")
+	}
+	if err := logger.Log(Record{Decision: DecisionAllow}); err != nil {
+		t.Errorf("Log() on disabled logger returned error: %v", err)
+	}
+}
+
+func TestLogger_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "audit.jsonl")
+	logger := NewLogger(path)
+
+	records := []Record{
+		{Hook: "bash-block", Decision: DecisionBlock, Command: "git push", Issues: []string{"Blocked git pattern detected"}},
+		{Hook: "bash-block", Decision: DecisionAllow, Command: "git status"},
+	}
+	for _, record := range records {
+		if err := logger.Log(record); err != nil {
+			t.Fatalf("Log() error: %v", err)
+		}
+	}
+
+	f, err := os.Open(path) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var got []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid JSONL line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, record)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d records, want 2", len(got))
+	}
+	if got[0].Command != "git push" || got[1].Decision != DecisionAllow {
+		t.Errorf("unexpected records: %+v", got)
+	}
+	if got[0].Time.IsZero() {
+		t.Error("Log() should fill in the timestamp")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("audit log permissions = %o, want 600", info.Mode().Perm())
+	}
+}
+
+func TestLogger_LogContext_Canceled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger := NewLogger(path)
+	if err := logger.Log(Record{Hook: "bash-block", Decision: DecisionAllow, Command: "git status"}); err != nil {
+		t.Fatal(err)
+	}
+	// Another process holds the lock
+	if err := os.WriteFile(path+".lock", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := logger.LogContext(ctx, Record{Hook: "bash-block", Decision: DecisionBlock, Command: "git push"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("LogContext() = %v, want context.Canceled", err)
+	}
+	data, err := os.ReadFile(path) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("audit log has %d records after a canceled LogContext(), want 1", lines)
+	}
+}
+
+func TestLogger_HashChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger := NewLogger(path)
+
+	for _, command := range []string{"git push", "git status", "ls"} {
+		if err := logger.Log(Record{Hook: "bash-block", Decision: DecisionAllow, Command: command}); err != nil {
+			t.Fatalf("Log() error: %v", err)
+		}
+	}
+
+	count, err := VerifyFile(path)
+	if err != nil {
+		t.Fatalf("VerifyFile() error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("VerifyFile() = %d, want 3", count)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(lines []string) []string
+		wantLine int
+	}{
+		{
+			name: "edited record",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"decision":"block"`, `"decision":"allow"`, 1)
+				return lines
+			},
+			wantLine: 2,
+		},
+		{
+			name: "deleted record",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			wantLine: 2,
+		},
+		{
+			name: "reordered records",
+			tamper: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			wantLine: 2,
+		},
+		{
+			name: "truncated head",
+			tamper: func(lines []string) []string {
+				return lines[1:]
+			},
+			wantLine: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			logger := NewLogger(path)
+			for _, decision := range []string{DecisionAllow, DecisionBlock, DecisionAllow} {
+				if err := logger.Log(Record{Hook: "bash-block", Decision: decision}); err != nil {
+					t.Fatalf("Log() error: %v", err)
+				}
+			}
+
+			data, err := os.ReadFile(path) // #nosec G304 - test file
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+
+			_, err = Verify(strings.NewReader(strings.Join(lines, "\n")))
+			var verifyErr *VerifyError
+			if !errors.As(err, &verifyErr) {
+				t.Fatalf("Verify() error = %v, want *VerifyError", err)
+			}
+			if verifyErr.Line != tt.wantLine {
+				t.Errorf("Verify() line = %d, want %d", verifyErr.Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestVerifyChain_ExpectHead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger := NewLogger(path)
+	for _, decision := range []string{DecisionAllow, DecisionBlock} {
+		if err := logger.Log(Record{Hook: "bash-block", Decision: decision}); err != nil {
+			t.Fatalf("Log() error: %v", err)
+		}
+	}
+	chain, err := VerifyChainFile(path, "")
+	if err != nil || chain.Records != 2 || chain.Head == "" {
+		t.Fatalf("VerifyChainFile() = %+v, %v", chain, err)
+	}
+	head := chain.Head
+
+	// Records appended after the head was recorded still verify
+	if err := logger.Log(Record{Hook: "bash-block", Decision: DecisionAllow}); err != nil {
+		t.Fatalf("Log() error: %v", err)
+	}
+	if _, err := VerifyChainFile(path, head); err != nil {
+		t.Errorf("VerifyChainFile() after an append error: %v", err)
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	// Cutting the block off the end leaves a valid chain that lacks the head
+	truncated := strings.Join(lines[:1], "\n")
+	if _, err := Verify(strings.NewReader(truncated)); err != nil {
+		t.Fatalf("Verify() of a truncated log error: %v, want the unanchored chain to verify", err)
+	}
+	var verifyErr *VerifyError
+	if _, err := VerifyChain(strings.NewReader(truncated), head); !errors.As(err, &verifyErr) {
+		t.Errorf("VerifyChain() of a truncated log error = %v, want *VerifyError", err)
+	}
+
+	// So does a log rewritten from the first record onwards
+	rewritten := filepath.Join(t.TempDir(), "audit.jsonl")
+	rewriter := NewLogger(rewritten)
+	for _, decision := range []string{DecisionAllow, DecisionAllow} {
+		if err := rewriter.Log(Record{Hook: "bash-block", Decision: decision}); err != nil {
+			t.Fatalf("Log() error: %v", err)
+		}
+	}
+	if _, err := VerifyChainFile(rewritten, head); !errors.As(err, &verifyErr) {
+		t.Errorf("VerifyChainFile() of a rewritten log error = %v, want *VerifyError", err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger := NewLogger(path)
+	records := []Record{
+		{Hook: "bash-block", Decision: DecisionAllow, Command: "terraform destroy", ShadowRules: []string{"terraform destroy"}},
+		{Hook: "bash-block", Decision: DecisionBlock, Command: "git push"},
+	}
+	for _, record := range records {
+		if err := logger.Log(record); err != nil {
+			t.Fatalf("Log() error: %v", err)
+		}
+	}
+
+	got, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	if len(got) != 2 || got[0].ShadowRules[0] != "terraform destroy" || got[1].Decision != DecisionBlock {
+		t.Errorf("ReadFile() = %+v, want the logged records", got)
+	}
+
+	if _, err := Read(strings.NewReader("{\"hook\":\"bash-block\"}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Read() error = %v, want an error for line 2", err)
+	}
+}
@@ -0,0 +1,45 @@
+// Package audit - reading records
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ReadFile reads every record of the audit log at path.
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path) // #nosec G304 - path is user-provided
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck // Read-only file
+	return Read(f)
+}
+
+// Read decodes the records of an audit log. It does not check the hash
+// chain; use Verify for that.
+func Read(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var records []Record
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("audit log line %d: invalid JSON: %w", lineNum, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	return records, nil
+}
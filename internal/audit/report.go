@@ -0,0 +1,181 @@
+// Package audit - log summaries
+package audit
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Count is how often a name, such as a command or rule, occurs in a log.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// SessionSummary counts the decisions of one Claude Code session.
+type SessionSummary struct {
+	SessionID string    `json:"session_id"`
+	Records   int       `json:"records"`
+	Blocks    int       `json:"blocks"`
+	First     time.Time `json:"first"`
+	Last      time.Time `json:"last"`
+}
+
+// Bucket counts the decisions in the interval starting at Start.
+type Bucket struct {
+	Start   time.Time `json:"start"`
+	Records int       `json:"records"`
+	Blocks  int       `json:"blocks"`
+}
+
+// Report summarizes an audit log. Counts are sorted with the largest first,
+// sessions by blocks, and the time series by time.
+type Report struct {
+	Records       int              `json:"records"`
+	Blocks        int              `json:"blocks"`
+	First         time.Time        `json:"first,omitzero"`
+	Last          time.Time        `json:"last,omitzero"`
+	Decisions     []Count          `json:"decisions"`
+	Hooks         []Count          `json:"hooks"`
+	TopBlocked    []Count          `json:"top_blocked_commands"`
+	BlocksPerRule []Count          `json:"blocks_per_rule"`
+	Sessions      []SessionSummary `json:"sessions"`
+	TimeSeries    []Bucket         `json:"time_series"`
+	ShadowRules   []Count          `json:"shadow_rules"` // Commands each shadow rule would have blocked
+}
+
+// ReportOptions select and shape what Summarize reports.
+type ReportOptions struct {
+	Since    time.Time     // Ignore records before Since, unless zero
+	Top      int           // Limit on blocked commands and sessions listed; 0 lists all
+	Interval time.Duration // Width of the time series buckets; 0 means a day
+}
+
+// Summarize aggregates records into a Report. Blocks by hooks other than
+// bash-block, and bash-block blocks no named rule explains (such as dynamic
+// commands), are counted under the hook's name in BlocksPerRule.
+func Summarize(records []Record, opts ReportOptions) Report {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	report := Report{}
+	decisions := make(map[string]int)
+	hooks := make(map[string]int)
+	blockedCommands := make(map[string]int)
+	rules := make(map[string]int)
+	shadow := make(map[string]int)
+	sessions := make(map[string]*SessionSummary)
+	buckets := make(map[time.Time]*Bucket)
+	for _, record := range records {
+		if record.Time.Before(opts.Since) {
+			continue
+		}
+		blocked := record.Decision == DecisionBlock
+		report.Records++
+		if report.First.IsZero() || record.Time.Before(report.First) {
+			report.First = record.Time
+		}
+		if record.Time.After(report.Last) {
+			report.Last = record.Time
+		}
+		decisions[record.Decision]++
+		hooks[record.Hook]++
+		for _, rule := range record.ShadowRules {
+			shadow[rule]++
+		}
+
+		start := record.Time.Truncate(interval)
+		bucket, ok := buckets[start]
+		if !ok {
+			bucket = &Bucket{Start: start}
+			buckets[start] = bucket
+		}
+		bucket.Records++
+
+		var session *SessionSummary
+		if record.SessionID != "" {
+			if session, ok = sessions[record.SessionID]; !ok {
+				session = &SessionSummary{SessionID: record.SessionID, First: record.Time}
+				sessions[record.SessionID] = session
+			}
+			session.Records++
+			if record.Time.Before(session.First) {
+				session.First = record.Time
+			}
+			if record.Time.After(session.Last) {
+				session.Last = record.Time
+			}
+		}
+
+		if !blocked {
+			continue
+		}
+		report.Blocks++
+		bucket.Blocks++
+		if session != nil {
+			session.Blocks++
+		}
+		if command := blockedTarget(record); command != "" {
+			blockedCommands[command]++
+		}
+		if len(record.Rules) == 0 {
+			rules[record.Hook]++
+		}
+		for _, rule := range record.Rules {
+			rules[rule]++
+		}
+	}
+
+	report.Decisions = sortedCounts(decisions, 0)
+	report.Hooks = sortedCounts(hooks, 0)
+	report.TopBlocked = sortedCounts(blockedCommands, opts.Top)
+	report.BlocksPerRule = sortedCounts(rules, 0)
+	report.ShadowRules = sortedCounts(shadow, 0)
+
+	report.Sessions = []SessionSummary{}
+	for _, session := range sessions {
+		report.Sessions = append(report.Sessions, *session)
+	}
+	slices.SortFunc(report.Sessions, func(a, b SessionSummary) int {
+		return cmp.Or(cmp.Compare(b.Blocks, a.Blocks), cmp.Compare(b.Records, a.Records), cmp.Compare(a.SessionID, b.SessionID))
+	})
+	if opts.Top > 0 && len(report.Sessions) > opts.Top {
+		report.Sessions = report.Sessions[:opts.Top]
+	}
+
+	report.TimeSeries = []Bucket{}
+	for _, bucket := range buckets {
+		report.TimeSeries = append(report.TimeSeries, *bucket)
+	}
+	slices.SortFunc(report.TimeSeries, func(a, b Bucket) int {
+		return a.Start.Compare(b.Start)
+	})
+	return report
+}
+
+// blockedTarget is what a record blocked: the command, or the file for file hooks.
+func blockedTarget(record Record) string {
+	if record.Command != "" {
+		return record.Command
+	}
+	return record.FilePath
+}
+
+// sortedCounts returns counts sorted by count, largest first, then by name,
+// keeping the first top (all if top is 0).
+func sortedCounts(counts map[string]int, top int) []Count {
+	sorted := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, Count{Name: name, Count: count})
+	}
+	slices.SortFunc(sorted, func(a, b Count) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Name, b.Name))
+	})
+	if top > 0 && len(sorted) > top {
+		sorted = sorted[:top]
+	}
+	return sorted
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Time: day.Add(-time.Hour), Hook: "bash-block", SessionID: "old", Decision: DecisionBlock, Command: "rm -rf /"},
+		{Time: day.Add(9 * time.Hour), Hook: "bash-block", SessionID: "a", Decision: DecisionBlock, Command: "git push", Rules: []string{"git-push"}},
+		{Time: day.Add(10 * time.Hour), Hook: "bash-block", SessionID: "a", Decision: DecisionAllow, Command: "terraform destroy", ShadowRules: []string{"no-tf"}},
+		{Time: day.Add(30 * time.Hour), Hook: "bash-block", SessionID: "b", Decision: DecisionBlock, Command: "git push", Rules: []string{"git-push"}},
+		{Time: day.Add(31 * time.Hour), Hook: "bash-block", SessionID: "b", Decision: DecisionBlock, Command: "eval $X"},
+		{Time: day.Add(32 * time.Hour), Hook: "self-protect", SessionID: "b", Decision: DecisionBlock, FilePath: ".claude/settings.json"},
+	}
+
+	report := Summarize(records, ReportOptions{Since: day})
+
+	if report.Records != 5 || report.Blocks != 4 {
+		t.Errorf("Summarize() counted %d records and %d blocks, want 5 and 4", report.Records, report.Blocks)
+	}
+	if !report.First.Equal(day.Add(9*time.Hour)) || !report.Last.Equal(day.Add(32*time.Hour)) {
+		t.Errorf("Summarize() range = %v to %v", report.First, report.Last)
+	}
+	wantTop := []Count{{"git push", 2}, {".claude/settings.json", 1}, {"eval $X", 1}}
+	if !reflect.DeepEqual(report.TopBlocked, wantTop) {
+		t.Errorf("TopBlocked = %v, want %v", report.TopBlocked, wantTop)
+	}
+	wantRules := []Count{{"git-push", 2}, {"bash-block", 1}, {"self-protect", 1}}
+	if !reflect.DeepEqual(report.BlocksPerRule, wantRules) {
+		t.Errorf("BlocksPerRule = %v, want %v", report.BlocksPerRule, wantRules)
+	}
+	if want := []Count{{"no-tf", 1}}; !reflect.DeepEqual(report.ShadowRules, want) {
+		t.Errorf("ShadowRules = %v, want %v", report.ShadowRules, want)
+	}
+	wantSessions := []SessionSummary{
+		{SessionID: "b", Records: 3, Blocks: 3, First: day.Add(30 * time.Hour), Last: day.Add(32 * time.Hour)},
+		{SessionID: "a", Records: 2, Blocks: 1, First: day.Add(9 * time.Hour), Last: day.Add(10 * time.Hour)},
+	}
+	if !reflect.DeepEqual(report.Sessions, wantSessions) {
+		t.Errorf("Sessions = %+v, want %+v", report.Sessions, wantSessions)
+	}
+	wantSeries := []Bucket{{Start: day, Records: 2, Blocks: 1}, {Start: day.Add(24 * time.Hour), Records: 3, Blocks: 3}}
+	if !reflect.DeepEqual(report.TimeSeries, wantSeries) {
+		t.Errorf("TimeSeries = %+v, want %+v", report.TimeSeries, wantSeries)
+	}
+
+	limited := Summarize(records, ReportOptions{Top: 1, Interval: time.Hour})
+	if len(limited.TopBlocked) != 1 || len(limited.Sessions) != 1 || len(limited.TimeSeries) != 6 {
+		t.Errorf("Summarize() with Top 1 and hourly buckets = %+v", limited)
+	}
+}
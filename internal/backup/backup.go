@@ -0,0 +1,278 @@
+// Package backup keeps snapshots of files taken before Claude Code changes
+// them, so a bad edit can be undone even outside version control.
+//
+// A snapshot is a copy of the file named by the snapshot's ID, plus a JSON
+// sidecar recording where it came from. IDs start with the UTC time the
+// snapshot was taken, so they sort chronologically.
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Retention defaults.
+const (
+	DefaultKeep        = 10                 // Snapshots kept per file
+	DefaultMaxAge      = 7 * 24 * time.Hour // Snapshots older than this are removed
+	DefaultMaxFileSize = 10 << 20           // Larger files are not backed up
+	idTimeFormat       = "20060102T150405.000000000Z"
+)
+
+// Snapshot describes one backed-up copy of a file.
+type Snapshot struct {
+	ID        string      `json:"id"`
+	Path      string      `json:"path"` // Absolute path of the original file
+	Time      time.Time   `json:"time"`
+	Size      int64       `json:"size"`
+	Mode      fs.FileMode `json:"mode"`
+	SHA256    string      `json:"sha256"`
+	SessionID string      `json:"session_id,omitempty"`
+	Tool      string      `json:"tool,omitempty"` // Tool about to change the file, e.g. Edit
+}
+
+// Store keeps snapshots in a directory.
+type Store struct {
+	Keep        int           // Snapshots kept per file; 0 keeps all
+	MaxAge      time.Duration // Age after which snapshots are removed; 0 keeps them
+	MaxFileSize int64         // Files larger than this are skipped; 0 backs up any size
+
+	dir string
+	now func() time.Time
+}
+
+// NewStore creates a Store keeping snapshots in dir with the default retention.
+func NewStore(dir string) *Store {
+	return &Store{Keep: DefaultKeep, MaxAge: DefaultMaxAge, MaxFileSize: DefaultMaxFileSize, dir: dir, now: time.Now}
+}
+
+// Dir returns the directory the store keeps snapshots in.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// DefaultDir returns the snapshot directory of a project: .claude/backups
+// under its root.
+func DefaultDir(root string) string {
+	return filepath.Join(root, ".claude", "backups")
+}
+
+// FindDir returns the snapshot directory of the project containing dir: the
+// nearest .claude/backups found by walking up from it.
+func FindDir(dir string) (string, bool) {
+	for {
+		candidate := DefaultDir(dir)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Save takes a snapshot of the file at path, an absolute path, and applies
+// the retention limits. It reports false, with no error, when there is
+// nothing to back up: the file does not exist yet, is not a regular file, is
+// larger than MaxFileSize, lies within the store, or is unchanged since its
+// last snapshot.
+func (s *Store) Save(path, sessionID, tool string) (Snapshot, bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	if !info.Mode().IsRegular() || (s.MaxFileSize > 0 && info.Size() > s.MaxFileSize) || s.contains(path) {
+		return Snapshot{}, false, nil
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - path is the file about to be edited
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	snapshots, err := s.List(path)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	if len(snapshots) > 0 && snapshots[len(snapshots)-1].SHA256 == digest {
+		return snapshots[len(snapshots)-1], false, nil
+	}
+
+	if err := s.init(); err != nil {
+		return Snapshot{}, false, err
+	}
+	now := s.now().UTC()
+	pathSum := sha256.Sum256([]byte(path))
+	snapshot := Snapshot{
+		ID:        now.Format(idTimeFormat) + "-" + hex.EncodeToString(pathSum[:4]),
+		Path:      path,
+		Time:      now,
+		Size:      info.Size(),
+		Mode:      info.Mode().Perm(),
+		SHA256:    digest,
+		SessionID: sessionID,
+		Tool:      tool,
+	}
+	if err := writeFile(filepath.Join(s.dir, snapshot.ID), data, 0o600); err != nil {
+		return Snapshot{}, false, fmt.Errorf("writing snapshot: %w", err)
+	}
+	meta, err := json.Marshal(snapshot)
+	if err != nil {
+		return Snapshot{}, false, fmt.Errorf("encoding snapshot: %w", err)
+	}
+	// The sidecar is written last: a snapshot without one is incomplete and ignored
+	if err := writeFile(filepath.Join(s.dir, snapshot.ID+".json"), meta, 0o600); err != nil {
+		return Snapshot{}, false, fmt.Errorf("writing snapshot: %w", err)
+	}
+	s.prune()
+	return snapshot, true, nil
+}
+
+// List returns the snapshots of the file at path, or of every file when path
+// is empty, oldest first.
+func (s *Store) List(path string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading backup directory: %w", err)
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name())) // #nosec G304 - within the backup directory
+		if err != nil {
+			continue
+		}
+		var snapshot Snapshot
+		if json.Unmarshal(data, &snapshot) != nil || snapshot.ID+".json" != entry.Name() {
+			continue // Not a snapshot sidecar
+		}
+		if path == "" || snapshot.Path == path {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	slices.SortFunc(snapshots, func(a, b Snapshot) int { return strings.Compare(a.ID, b.ID) })
+	return snapshots, nil
+}
+
+// Find returns the snapshot whose ID is id or starts with it.
+func (s *Store) Find(id string) (Snapshot, error) {
+	snapshots, err := s.List("")
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var matches []Snapshot
+	for _, snapshot := range snapshots {
+		if snapshot.ID == id {
+			return snapshot, nil
+		}
+		if id != "" && strings.HasPrefix(snapshot.ID, id) {
+			matches = append(matches, snapshot)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Snapshot{}, fmt.Errorf("no snapshot %q in %s", id, s.dir)
+	case 1:
+		return matches[0], nil
+	}
+	return Snapshot{}, fmt.Errorf("snapshot %q is ambiguous: %d snapshots match", id, len(matches))
+}
+
+// Restore writes the contents of a snapshot to the path it was taken from, or
+// to to when it is not empty, replacing the file.
+func (s *Store) Restore(snapshot Snapshot, to string) error {
+	if to == "" {
+		to = snapshot.Path
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, snapshot.ID)) // #nosec G304 - within the backup directory
+	if err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o750); err != nil {
+		return err
+	}
+	return writeFile(to, data, snapshot.Mode)
+}
+
+// init creates the snapshot directory, keeping it out of version control.
+func (s *Store) init() error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+	ignore := filepath.Join(s.dir, ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, os.ErrNotExist) {
+		return os.WriteFile(ignore, []byte("*\n"), 0o600)
+	}
+	return nil
+}
+
+// contains reports whether path lies within the snapshot directory, so
+// snapshots are never themselves backed up.
+func (s *Store) contains(path string) bool {
+	dir, err := filepath.Abs(s.dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// prune removes the snapshots beyond Keep for each file and those older than
+// MaxAge. Errors are ignored; a leftover snapshot only costs disk space.
+func (s *Store) prune() {
+	snapshots, err := s.List("")
+	if err != nil {
+		return
+	}
+	now := s.now()
+	kept := make(map[string]int)
+	// Newest first, so Keep counts the most recent snapshots of each file
+	for _, snapshot := range slices.Backward(snapshots) {
+		kept[snapshot.Path]++
+		if (s.Keep > 0 && kept[snapshot.Path] > s.Keep) || (s.MaxAge > 0 && now.Sub(snapshot.Time) > s.MaxAge) {
+			_ = os.Remove(filepath.Join(s.dir, snapshot.ID+".json")) //nolint:errcheck // Best-effort cleanup
+			_ = os.Remove(filepath.Join(s.dir, snapshot.ID))         //nolint:errcheck // Best-effort cleanup
+		}
+	}
+}
+
+// writeFile writes data to path through a temporary file in the same
+// directory, so readers never see a partial file.
+func writeFile(path string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // Already renamed on success
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close() //nolint:errcheck // The write error is reported
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,164 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a store in a temporary directory whose clock advances
+// a second on every call, and a file to back up.
+func newTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	root := t.TempDir()
+	store := NewStore(DefaultDir(root))
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	path := filepath.Join(root, "main.go")
+	writeTestFile(t, path, "v1")
+	return store, path
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore_SaveAndRestore(t *testing.T) {
+	store, path := newTestStore(t)
+
+	first, saved, err := store.Save(path, "session-1", "Edit")
+	if err != nil || !saved {
+		t.Fatalf("Save() = %v, %v, want a snapshot", saved, err)
+	}
+	if first.Path != path || first.Size != 2 || first.Tool != "Edit" || first.SessionID != "session-1" {
+		t.Errorf("Save() = %+v", first)
+	}
+	if _, saved, err := store.Save(path, "session-1", "Edit"); err != nil || saved {
+		t.Errorf("Save() of unchanged content = %v, %v, want no new snapshot", saved, err)
+	}
+
+	writeTestFile(t, path, "v2")
+	if _, saved, err := store.Save(path, "session-1", "Write"); err != nil || !saved {
+		t.Fatalf("Save() of changed content = %v, %v, want a snapshot", saved, err)
+	}
+	snapshots, err := store.List(path)
+	if err != nil || len(snapshots) != 2 || snapshots[0].ID != first.ID {
+		t.Fatalf("List() = %+v, %v, want both snapshots, oldest first", snapshots, err)
+	}
+
+	writeTestFile(t, path, "broken")
+	if err := store.Restore(first, ""); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "v1" { // #nosec G304 - test file
+		t.Errorf("restored content = %q, want v1", data)
+	}
+
+	// The backup directory keeps itself out of version control
+	if data, err := os.ReadFile(filepath.Join(store.Dir(), ".gitignore")); err != nil || string(data) != "*\n" {
+		t.Errorf(".gitignore = %q, %v", data, err)
+	}
+}
+
+func TestStore_SaveSkips(t *testing.T) {
+	store, path := newTestStore(t)
+	store.MaxFileSize = 4
+
+	large := filepath.Join(filepath.Dir(path), "large.bin")
+	writeTestFile(t, large, "too large")
+	if err := os.MkdirAll(store.Dir(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	inside := filepath.Join(store.Dir(), "notes.txt")
+	writeTestFile(t, inside, "x")
+
+	for _, skipped := range []string{filepath.Join(filepath.Dir(path), "new.go"), large, inside, filepath.Dir(path)} {
+		if _, saved, err := store.Save(skipped, "", "Write"); err != nil || saved {
+			t.Errorf("Save(%s) = %v, %v, want it skipped", skipped, saved, err)
+		}
+	}
+}
+
+func TestStore_Retention(t *testing.T) {
+	store, path := newTestStore(t)
+	store.Keep = 2
+	other := filepath.Join(filepath.Dir(path), "other.go")
+	writeTestFile(t, other, "other")
+	if _, _, err := store.Save(other, "", "Edit"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []string{"a", "b", "c"} {
+		writeTestFile(t, path, content)
+		if _, _, err := store.Save(path, "", "Edit"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshots, err := store.List(path)
+	if err != nil || len(snapshots) != 2 || snapshots[0].SHA256 == snapshots[1].SHA256 {
+		t.Fatalf("List() = %+v, %v, want the 2 newest snapshots", snapshots, err)
+	}
+	if snapshots, _ := store.List(other); len(snapshots) != 1 {
+		t.Errorf("Keep applies per file, but other.go has %d snapshots", len(snapshots))
+	}
+
+	// Ages out everything but the snapshot just taken
+	store.MaxAge = time.Second
+	writeTestFile(t, path, "d")
+	if _, _, err := store.Save(path, "", "Edit"); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := store.List(""); len(all) != 1 {
+		t.Errorf("List() after MaxAge = %d snapshots, want 1", len(all))
+	}
+}
+
+func TestStore_Find(t *testing.T) {
+	store, path := newTestStore(t)
+	first, _, err := store.Save(path, "", "Edit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, path, "v2")
+	if _, _, err := store.Save(path, "", "Edit"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := store.Find(first.ID); err != nil || got.ID != first.ID {
+		t.Errorf("Find(%s) = %v, %v", first.ID, got.ID, err)
+	}
+	if got, err := store.Find(first.ID[:len("20250101T120001")]); err != nil || got.ID != first.ID {
+		t.Errorf("Find() of a unique prefix = %v, %v", got.ID, err)
+	}
+	if _, err := store.Find("20250101"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Find() of a shared prefix error = %v, want ambiguous", err)
+	}
+	if _, err := store.Find("nope"); err == nil {
+		t.Error("Find() of an unknown ID should fail")
+	}
+}
+
+func TestFindDir(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "src", "pkg")
+	if err := os.MkdirAll(nested, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := FindDir(nested); ok {
+		t.Error("FindDir() found a directory before one exists")
+	}
+	if err := os.MkdirAll(DefaultDir(root), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if dir, ok := FindDir(nested); !ok || dir != DefaultDir(root) {
+		t.Errorf("FindDir() = %s, %v, want %s", dir, ok, DefaultDir(root))
+	}
+}
@@ -0,0 +1,572 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+func (l *listFlag) Repeatable() bool   { return true }
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		hook, flag, want string
+	}{
+		{"", "rules", "CLAUDE_HOOKS_RULES"},
+		{"", "fail-mode", "CLAUDE_HOOKS_FAIL_MODE"},
+		{"bash-block", "max-recursion", "CLAUDE_HOOKS_BASH_BLOCK_MAX_RECURSION"},
+	}
+	for _, tt := range tests {
+		if got := EnvName(tt.hook, tt.flag); got != tt.want {
+			t.Errorf("EnvName(%q, %q) = %q, want %q", tt.hook, tt.flag, got, tt.want)
+		}
+	}
+}
+
+func TestBindEnv_Precedence(t *testing.T) {
+	t.Setenv("CLAUDE_HOOKS_RULES", "/etc/machine.yaml")
+	t.Setenv("CLAUDE_HOOKS_AUDIT_LOG", "/var/log/machine.jsonl")
+	t.Setenv("CLAUDE_HOOKS_BASH_BLOCK_AUDIT_LOG", "/var/log/bash-block.jsonl")
+	t.Setenv("CLAUDE_HOOKS_FAIL_MODE", "open")
+	t.Setenv("CLAUDE_HOOKS_LOG_LEVEL", "debug")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	settings := RegisterFlags(fs, FailClosed)
+	if err := BindEnv(fs, "bash-block"); err != nil {
+		t.Fatalf("BindEnv() error: %v", err)
+	}
+	if err := fs.Parse([]string{"-rules", "/project/rules.yaml"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Settings{
+		RulesFile: "/project/rules.yaml",       // Flag wins over environment
+		FailMode:  FailOpen,                    // Machine-wide environment
+		LogLevel:  "debug",                     // Machine-wide environment
+		AuditLog:  "/var/log/bash-block.jsonl", // Hook-specific wins over machine-wide
+		Discover:  true,
+	}
+	if !reflect.DeepEqual(*settings, want) {
+		t.Errorf("settings = %+v, want %+v", *settings, want)
+	}
+}
+
+func TestBindEnv_Repeatable(t *testing.T) {
+	t.Setenv("CLAUDE_HOOKS_CMD", "git push; kubectl delete ;")
+
+	var commands listFlag
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&commands, "cmd", "")
+	if err := BindEnv(fs, "bash-block"); err != nil {
+		t.Fatalf("BindEnv() error: %v", err)
+	}
+	if err := fs.Parse([]string{"-cmd", "aws"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := listFlag{"git push", "kubectl delete", "aws"}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %v, want %v", commands, want)
+	}
+}
+
+func TestBindEnv_InvalidValue(t *testing.T) {
+	t.Setenv("CLAUDE_HOOKS_FAIL_MODE", "sideways")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs, FailClosed)
+	err := BindEnv(fs, "bash-block")
+	if err == nil || !strings.Contains(err.Error(), "CLAUDE_HOOKS_BASH_BLOCK_FAIL_MODE") {
+		t.Errorf("BindEnv() error = %v, want error naming the variable", err)
+	}
+	if !errors.Is(err, hook.ErrConfig) {
+		t.Errorf("BindEnv() error = %v, want it to match hook.ErrConfig", err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	var commands listFlag
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&commands, "cmd", "Command to block")
+	fs.Bool("describe", false, "Print a JSON manifest and exit")
+	fs.Int("max-recursion", 10, "Maximum analysis depth")
+
+	manifest := Describe(fs, Manifest{Hook: "bash-block", Events: []string{"PreToolUse"}, Tools: []string{"Bash"}})
+	if manifest.Version == "" || manifest.ProtocolVersion == 0 {
+		t.Errorf("Describe() version = %q, protocol %d, want both set", manifest.Version, manifest.ProtocolVersion)
+	}
+	want := []FlagInfo{
+		{Name: "cmd", Usage: "Command to block", Repeatable: true, Env: []string{"CLAUDE_HOOKS_BASH_BLOCK_CMD", "CLAUDE_HOOKS_CMD"}},
+		{Name: "describe", Usage: "Print a JSON manifest and exit", Default: "false", Boolean: true},
+		{Name: "max-recursion", Usage: "Maximum analysis depth", Default: "10", Env: []string{"CLAUDE_HOOKS_BASH_BLOCK_MAX_RECURSION", "CLAUDE_HOOKS_MAX_RECURSION"}},
+	}
+	if !reflect.DeepEqual(manifest.Flags, want) {
+		t.Errorf("Describe() flags = %+v, want %+v", manifest.Flags, want)
+	}
+
+	var out bytes.Buffer
+	if err := PrintManifest(&out, fs, Manifest{Hook: "hook-logger", Events: []string{"Stop"}}); err != nil {
+		t.Fatalf("PrintManifest() error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"events": [
+    "Stop"
+  ]`) || strings.Contains(out.String(), `"tools"`) {
+		t.Errorf("PrintManifest() = %s", out.String())
+	}
+}
+
+func TestSettingsEntries(t *testing.T) {
+	manifest := Manifest{
+		Hook:       "file-format",
+		Events:     []string{"PostToolUse", "PreToolUse", "Stop"},
+		Tools:      []string{"Edit", "MultiEdit", "Write"},
+		EventTools: map[string][]string{"PreToolUse": {"Write"}},
+	}
+	got := SettingsEntries(manifest, []string{"-cmd", "gofmt -w", "-ext", ".go", "-block-message", "don't"})
+	command := `file-format -cmd 'gofmt -w' -ext .go -block-message 'don'\''t'`
+	entry := func(matcher string) []any {
+		e := map[string]any{"hooks": []any{map[string]any{"type": "command", "command": command}}}
+		if matcher != "" {
+			e["matcher"] = matcher
+		}
+		return []any{e}
+	}
+	want := map[string]any{"hooks": map[string]any{
+		"PostToolUse": entry("Edit|MultiEdit|Write"),
+		"PreToolUse":  entry("Write"),
+		"Stop":        entry(""),
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SettingsEntries() = %v, want %v", got, want)
+	}
+}
+
+func TestNewLogger_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(&buf, "error")
+	logger.Warn("hidden")
+	logger.Error("shown")
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("unexpected log output: %q", buf.String())
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	content := `
+rules:
+  - name: no-push
+    command: git
+    patterns: [push]
+    suggest: ask the human to push
+  - command: kubectl
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy() error: %v", err)
+	}
+
+	want := []detector.CommandRule{
+		{Name: "no-push", BlockedCommand: "git", BlockedPatterns: []string{"push"}, Suggest: "ask the human to push"},
+		{BlockedCommand: "kubectl", BlockedPatterns: []string{"*"}},
+	}
+	if got := policy.CommandRules(time.Now()); !reflect.DeepEqual(got, want) {
+		t.Errorf("CommandRules() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Malformed YAML", "rules: [\n"},
+		{"Missing command", "rules:\n  - patterns: [push]\n"},
+		{"Missing allow_after command", "rules:\n  - command: git\n    allow_after:\n      within: 5\n"},
+		{"Negative allow_after window", "rules:\n  - command: git\n    allow_after:\n      command: go test\n      within: -1\n"},
+		{"Package rule without names", "packages:\n  deny:\n    - ecosystem: npm\n"},
+		{"Unknown package ecosystem", "packages:\n  allow:\n    - ecosystem: maven\n      names: [junit]\n"},
+		{"Unknown system package manager", "packages:\n  critical:\n    - ecosystem: npm\n      names: [git]\n"},
+		{"Unknown ownership action", "ownership:\n  action: warn\n"},
+		{"Invalid block message", "block_message: \"{{.Rule\"\n"},
+		{"Invalid rule message", "rules:\n  - command: git\n    message: \"{{if .Rule}}\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePolicy([]byte(tt.content)); !errors.Is(err, hook.ErrConfig) {
+				t.Errorf("ParsePolicy() error = %v, want hook.ErrConfig", err)
+			}
+		})
+	}
+}
+
+func TestPolicy_RedirectPatterns(t *testing.T) {
+	policy, err := ParsePolicy([]byte("rules:\n  - command: git\n    patterns: [push]\n  - name: no-rc\n    redirects: [~/.bashrc, /etc/**]\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+	if rules := policy.CommandRules(time.Now()); len(rules) != 1 || rules[0].BlockedCommand != "git" {
+		t.Errorf("CommandRules() = %+v, want only the git rule", rules)
+	}
+	want := []string{"~/.bashrc", "/etc/**"}
+	if got := policy.RedirectPatterns(time.Now()); !reflect.DeepEqual(got, want) {
+		t.Errorf("RedirectPatterns() = %v, want %v", got, want)
+	}
+}
+
+func TestPolicy_SSHHostPatterns(t *testing.T) {
+	policy, err := ParsePolicy([]byte("rules:\n  - command: git\n    patterns: [push]\n  - name: no-prod-shell\n    ssh_hosts: [prod-*, bastion.example.com]\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+	if rules := policy.CommandRules(time.Now()); len(rules) != 1 || rules[0].BlockedCommand != "git" {
+		t.Errorf("CommandRules() = %+v, want only the git rule", rules)
+	}
+	want := []string{"prod-*", "bastion.example.com"}
+	if got := policy.SSHHostPatterns(time.Now()); !reflect.DeepEqual(got, want) {
+		t.Errorf("SSHHostPatterns() = %v, want %v", got, want)
+	}
+
+	if _, err := ParsePolicy([]byte("rules:\n  - ssh_hosts: [prod-*]\n    enforce: false\n")); err == nil {
+		t.Error("ParsePolicy() of a shadow ssh_hosts rule should fail")
+	}
+}
+
+func TestParsePolicy_JSON(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{"rules": [{"command": "git", "patterns": ["push"]}]}`))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+	if len(policy.Rules) != 1 || policy.Rules[0].Command != "git" {
+		t.Errorf("unexpected policy: %+v", policy)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, err := Discover(nested); err != nil || path != "" {
+		t.Errorf("Discover() without policy = %q, %v; want empty", path, err)
+	}
+
+	policyPath := filepath.Join(root, ProjectFileName)
+	if err := os.WriteFile(policyPath, []byte("rules: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := Discover(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != policyPath {
+		t.Errorf("Discover() = %q, want %q", path, policyPath)
+	}
+
+	if path, err := Discover(""); err != nil || path != "" {
+		t.Errorf("Discover(\"\") = %q, %v; want empty", path, err)
+	}
+}
+
+func TestPolicy_ProjectSections(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`
+formatters:
+  - command: goimports -w {FILEPATH}
+    extensions: [.go]
+protected_paths:
+  - .env
+  - secrets/**
+notifications:
+  webhook: https://hooks.example.com/claude
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+
+	if len(policy.Formatters) != 1 || policy.Formatters[0].Extensions[0] != ".go" {
+		t.Errorf("unexpected formatters: %+v", policy.Formatters)
+	}
+	if policy.Notifications.Webhook != "https://hooks.example.com/claude" {
+		t.Errorf("unexpected notifications: %+v", policy.Notifications)
+	}
+
+	root := "/project"
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{".env", true},
+		{"/project/.env", true},
+		{"secrets/prod/key.pem", true},
+		{"/project/secrets", true},
+		{"src/main.go", false},
+		{"/other/.env", false},
+	}
+	for _, tt := range tests {
+		if got := policy.IsProtectedPath(root, tt.path); got != tt.want {
+			t.Errorf("IsProtectedPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	// Windows paths from Claude Code on Windows match in any case or separator
+	windowsRoot := `C:\Project`
+	windowsTests := []struct {
+		path string
+		want bool
+	}{
+		{`.env`, true},
+		{`c:\project\.env`, true},
+		{`C:/Project/Secrets/prod/key.pem`, true},
+		{`secrets\prod\key.pem`, true},
+		{`src\main.go`, false},
+		{`D:\Project\.env`, false},
+	}
+	for _, tt := range windowsTests {
+		if got := policy.IsProtectedPath(windowsRoot, tt.path); got != tt.want {
+			t.Errorf("IsProtectedPath(%q, %q) = %v, want %v", windowsRoot, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParsePolicy_InvalidFormatter(t *testing.T) {
+	if _, err := ParsePolicy([]byte("formatters:\n  - command: gofmt -w\n")); err == nil {
+		t.Error("ParsePolicy() should require formatter extensions")
+	}
+	if _, err := ParsePolicy([]byte("formatters:\n  - command: gofmt -w\n    extensions: [.go]\n    verify_timeout: soon\n")); err == nil || !strings.Contains(err.Error(), "verify_timeout") {
+		t.Errorf("ParsePolicy() error = %v, want an invalid verify_timeout", err)
+	}
+}
+
+func TestParsePolicy_Rewrites(t *testing.T) {
+	policy, err := ParsePolicy([]byte("rewrites:\n  - from: rm -rf\n    to: trash\n  - from: kubectl delete\n    add_flag: --dry-run=client\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+	if len(policy.Rewrites) != 2 || policy.Rewrites[1].AddFlag != "--dry-run=client" {
+		t.Errorf("unexpected rewrites: %+v", policy.Rewrites)
+	}
+
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr string
+	}{
+		{"Missing from", "rewrites:\n  - to: trash\n", "from"},
+		{"Neither to nor add_flag", "rewrites:\n  - from: rm -rf\n", "exactly one of to and add_flag"},
+		{"Both to and add_flag", "rewrites:\n  - from: rm -rf\n    to: trash\n    add_flag: -i\n", "exactly one of to and add_flag"},
+		{"Not a flag", "rewrites:\n  - from: rsync\n    add_flag: dry-run\n", "not a single flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePolicy([]byte(tt.policy)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePolicy() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicy_CommandRulesAllowAfter(t *testing.T) {
+	policy, err := ParsePolicy([]byte("rules:\n  - command: git\n    patterns: [push]\n    allow_after:\n      command: go test\n      within: 10\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+	rules := policy.CommandRules(time.Now())
+	if len(rules) != 1 || rules[0].AllowAfter == nil {
+		t.Fatalf("CommandRules() = %+v, want one rule with AllowAfter", rules)
+	}
+	if got := *rules[0].AllowAfter; got.Command != "go test" || got.Within != 10 {
+		t.Errorf("AllowAfter = %+v, want {go test 10}", got)
+	}
+}
+
+func TestPolicy_ShadowRules(t *testing.T) {
+	policy, err := ParsePolicy([]byte("rules:\n  - command: git\n    patterns: [push]\n  - name: trial\n    command: terraform\n    patterns: [destroy]\n    enforce: false\n  - command: kubectl\n    enforce: true\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+	var enforced []string
+	for _, rule := range policy.CommandRules(time.Now()) {
+		enforced = append(enforced, rule.BlockedCommand)
+	}
+	if want := []string{"git", "kubectl"}; !reflect.DeepEqual(enforced, want) {
+		t.Errorf("CommandRules() commands = %v, want %v", enforced, want)
+	}
+	shadow := policy.ShadowRules(time.Now())
+	if len(shadow) != 1 || shadow[0].Name != "trial" || shadow[0].BlockedCommand != "terraform" {
+		t.Errorf("ShadowRules() = %+v, want the terraform rule", shadow)
+	}
+
+	if _, err := ParsePolicy([]byte("rules:\n  - redirects: [.env]\n    enforce: false\n")); err == nil {
+		t.Error("ParsePolicy() accepted enforce: false on a redirect rule")
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"Valid", "rules:\n  - command: git\n    patterns: [push]\n", nil},
+		{"Empty", "", nil},
+		{"Typo", "rules:\n  - comand: git\n", []string{`line 2, column 5: rules[0]: unknown key "comand"; did you mean "command"?`}},
+		{"Unknown section", "formaters: []\n", []string{`line 1, column 1: unknown key "formaters"; did you mean "formatters"?`}},
+		{"No suggestion", "rules:\n  - command: git\n    colour: red\n", []string{`line 3, column 5: rules[0]: unknown key "colour"`}},
+		{"Scalar for list", "rules:\n  - command: git\n    patterns: push\n", []string{`line 3, column 15: rules[0].patterns: expected a list, got "push"; write [push] for a single item`}},
+		{"Wrong type", "formatters:\n  - command: gofmt\n    extensions: [.go]\n    block: maybe\n", []string{`line 4, column 12: formatters[0].block: expected true or false, got "maybe"`}},
+		{"Missing required", "packages:\n  deny:\n    - reason: no\n", []string{`line 3, column 7: packages.deny[0]: missing required key "names"`}},
+		{"Below minimum", "rules:\n  - command: git\n    allow_after: {command: go test, within: -1}\n", []string{`line 3, column 45: rules[0].allow_after.within: must be at least 0`}},
+		{"JSON", `{"rules": [{"command": "git", "enforce": "yes"}]}`, []string{`line 1, column 42: rules[0].enforce: expected true or false, got "yes"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := ValidateSchema([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("ValidateSchema() error: %v", err)
+			}
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateSchema() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePolicy_SchemaErrors(t *testing.T) {
+	_, err := ParsePolicy([]byte("rules:\n  - command: git\n    patern: [push]\n"))
+	var schemaErrs SchemaErrors
+	if !errors.As(err, &schemaErrs) || len(schemaErrs) != 1 {
+		t.Fatalf("ParsePolicy() error = %v, want one schema error", err)
+	}
+	if !strings.Contains(err.Error(), `did you mean "patterns"?`) {
+		t.Errorf("error = %q, want a suggestion", err)
+	}
+}
+
+func TestRegisterFlags_Groups(t *testing.T) {
+	t.Setenv("CLAUDE_HOOKS_DISABLE_GROUP", "cloud;filesystem")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	settings := RegisterFlags(fs, FailClosed)
+	if err := BindEnv(fs, "bash-block"); err != nil {
+		t.Fatalf("BindEnv() error: %v", err)
+	}
+	if err := fs.Parse([]string{"-disable-group", "git,docker", "-enable-group", "experimental"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cloud", "filesystem", "git", "docker"}; !reflect.DeepEqual(settings.DisableGroups, want) {
+		t.Errorf("DisableGroups = %v, want %v", settings.DisableGroups, want)
+	}
+	if want := []string{"experimental"}; !reflect.DeepEqual(settings.EnableGroups, want) {
+		t.Errorf("EnableGroups = %v, want %v", settings.EnableGroups, want)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    hook.Verbosity
+		wantErr bool
+	}{
+		{nil, hook.VerbosityNormal, false},
+		{[]string{"-quiet"}, hook.VerbosityQuiet, false},
+		{[]string{"-verbose"}, hook.VerbosityVerbose, false},
+		{[]string{"-quiet", "-verbose"}, hook.VerbosityQuiet, true},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		settings := RegisterFlags(fs, FailClosed)
+		RegisterOutputFlags(fs, settings)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		err := settings.ValidateOutput()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateOutput() with %v error = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+		if got := settings.Output(); got.Verbosity != tt.want || got.MaxIssues != hook.DefaultMaxIssues || got.WarnOnly {
+			t.Errorf("Output() with %v = %+v, want verbosity %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("rules: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, policyErr := LoadPolicy(policyPath)
+	_, inputErr := hook.DecodePreToolUseInput(strings.NewReader("not json"), hook.InputOptions{})
+	_, parseErr := detector.NewCommandDetector(nil, 10).AnalyzeSegments("echo 'unterminated")
+
+	tests := []struct {
+		err         error
+		want        string
+		wantMessage string
+		wantExit    hook.ExitCode
+	}{
+		{policyErr, ErrorKindConfig, "Failed (the hook configuration is invalid; ask the user to fix it)", hook.ExitNonBlockingError},
+		{inputErr, ErrorKindInputSchema, "Failed", hook.ExitSuccess},
+		{parseErr, ErrorKindParse, "Failed", hook.ExitSuccess},
+		{fmt.Errorf("checking: %w", detector.ErrTimeout), ErrorKindTimeout, "Failed", hook.ExitSuccess},
+		{os.ErrPermission, ErrorKindInternal, "Failed", hook.ExitSuccess},
+	}
+	for _, tt := range tests {
+		if got := ErrorKind(tt.err); got != tt.want {
+			t.Errorf("ErrorKind(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+		if got := FailureMessage("Failed", tt.err); got != tt.wantMessage {
+			t.Errorf("FailureMessage(%v) = %q, want %q", tt.err, got, tt.wantMessage)
+		}
+		if got := FailOpenExitCode(tt.err); got != tt.wantExit {
+			t.Errorf("FailOpenExitCode(%v) = %d, want %d", tt.err, got, tt.wantExit)
+		}
+	}
+}
+
+func TestOutput_WarnOnly(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	settings := RegisterFlags(fs, FailClosed)
+	RegisterOutputFlags(fs, settings)
+	if got := settings.BlockDecision(); got != audit.DecisionBlock {
+		t.Errorf("BlockDecision() = %q, want %q", got, audit.DecisionBlock)
+	}
+	if err := fs.Parse([]string{"-warn-only"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.ValidateOutput(); err != nil {
+		t.Fatalf("ValidateOutput() error: %v", err)
+	}
+	if !settings.Output().WarnOnly {
+		t.Error("Output().WarnOnly = false, want true")
+	}
+	if got := settings.BlockDecision(); got != audit.DecisionWarn {
+		t.Errorf("BlockDecision() = %q, want %q", got, audit.DecisionWarn)
+	}
+}
@@ -0,0 +1,151 @@
+// Package config - self-describing hook manifests
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/version"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// Manifest is the -describe output of a hook binary. It tells installers and
+// other tooling how to register the hook and configure it without parsing
+// its help text.
+type Manifest struct {
+	Hook            string   `json:"hook"`
+	Version         string   `json:"version"`
+	ProtocolVersion int      `json:"protocol_version"`
+	Events          []string `json:"events"`          // Hook events handled, e.g. PreToolUse
+	Tools           []string `json:"tools,omitempty"` // Tools matched for tool events, e.g. Bash; empty for all tools
+	// EventTools are the tools matched for an event when they differ from
+	// Tools, e.g. only Write for a PreToolUse check of a PostToolUse hook.
+	EventTools map[string][]string `json:"event_tools,omitempty"`
+	Flags      []FlagInfo          `json:"flags"`
+	// PolicySections are the policy file sections the hook reads, such as
+	// "rules" or "packages" (see Policy).
+	PolicySections []string `json:"policy_sections,omitempty"`
+}
+
+// FlagInfo describes a command-line flag and the environment variables that
+// set it.
+type FlagInfo struct {
+	Name       string `json:"name"`
+	Usage      string `json:"usage"`
+	Default    string `json:"default,omitempty"`
+	Boolean    bool   `json:"boolean,omitempty"`
+	Repeatable bool   `json:"repeatable,omitempty"`
+	// Env are the environment variables for the flag, the hook-specific one
+	// first; empty for flags that cannot be set from the environment.
+	Env []string `json:"env,omitempty"`
+}
+
+// Describe completes m with the build version, the protocol version, and the
+// flags defined on fs.
+func Describe(fs *flag.FlagSet, m Manifest) Manifest {
+	m.Version = version.Get().Version
+	m.ProtocolVersion = hook.ProtocolVersion
+	m.Flags = []FlagInfo{}
+	fs.VisitAll(func(f *flag.Flag) {
+		info := FlagInfo{Name: f.Name, Usage: f.Usage, Default: f.DefValue}
+		if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok {
+			info.Boolean = bf.IsBoolFlag()
+		}
+		if rv, ok := f.Value.(RepeatableValue); ok {
+			info.Repeatable = rv.Repeatable()
+		}
+		if !skipEnv(f.Name) {
+			info.Env = []string{EnvName(m.Hook, f.Name), EnvName("", f.Name)}
+		}
+		m.Flags = append(m.Flags, info)
+	})
+	return m
+}
+
+// PrintManifest writes the completed manifest as indented JSON.
+func PrintManifest(w io.Writer, fs *flag.FlagSet, m Manifest) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Describe(fs, m))
+}
+
+// ExitWithManifest prints the manifest and exits when -describe was given.
+// Call it right after parsing flags.
+func ExitWithManifest(describe bool, fs *flag.FlagSet, m Manifest) {
+	if !describe {
+		return
+	}
+	if err := PrintManifest(os.Stdout, fs, m); err != nil {
+		hook.NonBlockingError("Error encoding manifest: " + err.Error())
+	}
+	hook.Exit(hook.ExitSuccess)
+}
+
+// toolEvents are the hook events whose settings.json entries take a matcher.
+var toolEvents = []string{hook.EventPreToolUse, hook.EventPostToolUse}
+
+// SettingsEntries returns the settings.json "hooks" section that registers
+// m's hook for each of its events, run with args. Tool events match exactly
+// the tools the hook checks, so none of them, such as MultiEdit, is missed.
+func SettingsEntries(m Manifest, args []string) map[string]any {
+	command := strings.Join(append([]string{m.Hook}, quoteArgs(args)...), " ")
+	events := map[string]any{}
+	for _, event := range m.Events {
+		entry := map[string]any{
+			"hooks": []any{map[string]any{"type": "command", "command": command}},
+		}
+		tools, ok := m.EventTools[event]
+		if !ok {
+			tools = m.Tools
+		}
+		if slices.Contains(toolEvents, event) && len(tools) > 0 {
+			entry["matcher"] = strings.Join(tools, "|")
+		}
+		events[event] = []any{entry}
+	}
+	return map[string]any{"hooks": events}
+}
+
+// ExitWithSettings prints the settings.json entries for the hook as run, with
+// the command-line flags other than -emit-config, and exits when
+// -emit-config was given. Call it right after ExitWithManifest.
+func ExitWithSettings(emitConfig bool, m Manifest) {
+	if !emitConfig {
+		return
+	}
+	var args []string
+	for _, arg := range os.Args[1:] {
+		if name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "="); strings.HasPrefix(arg, "-") && name == "emit-config" {
+			continue
+		}
+		args = append(args, arg)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(SettingsEntries(m, args)); err != nil {
+		hook.NonBlockingError("Error encoding settings: " + err.Error())
+	}
+	hook.Exit(hook.ExitSuccess)
+}
+
+// safeArg matches arguments that need no quoting in a shell command.
+var safeArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// quoteArgs single-quotes the arguments a shell would otherwise split or
+// expand.
+func quoteArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if safeArg.MatchString(arg) {
+			quoted[i] = arg
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return quoted
+}
@@ -0,0 +1,55 @@
+// Package config - project-local policy discovery
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ProjectFileName is the project-local policy file discovered by every hook.
+const ProjectFileName = ".claudehooks.yaml"
+
+// Discover walks up from dir looking for a .claudehooks.yaml file and returns
+// its path, or "" if none exists between dir and the filesystem root.
+// dir is normally the cwd from the hook payload.
+func Discover(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		candidate := filepath.Join(dir, ProjectFileName)
+		info, err := os.Stat(candidate)
+		switch {
+		case err == nil && !info.IsDir():
+			return candidate, nil
+		case err != nil && !errors.Is(err, os.ErrNotExist):
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectPolicy discovers and loads the project policy for dir. It returns
+// a nil policy and an empty path when no project policy exists.
+func LoadProjectPolicy(dir string) (policy *Policy, path string, err error) {
+	path, err = Discover(dir)
+	if err != nil || path == "" {
+		return nil, "", err
+	}
+	policy, err = LoadPolicy(path)
+	if err != nil {
+		return nil, path, err
+	}
+	return policy, path, nil
+}
@@ -0,0 +1,78 @@
+// Package config - environment variable binding for flags
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvPrefix is the prefix of every environment variable read by the hooks.
+const EnvPrefix = "CLAUDE_HOOKS_"
+
+// RepeatableValue is implemented by flag values that may be given multiple
+// times (like bash-block's -cmd). Their environment variables hold several
+// values separated by semicolons.
+type RepeatableValue interface {
+	flag.Value
+	Repeatable() bool
+}
+
+// EnvName returns the environment variable for a flag. With an empty hook name
+// it is the machine-wide form (CLAUDE_HOOKS_MAX_RECURSION); otherwise the
+// hook-specific form (CLAUDE_HOOKS_BASH_BLOCK_MAX_RECURSION).
+func EnvName(hook, flagName string) string {
+	name := EnvPrefix
+	if hook != "" {
+		name += envSegment(hook) + "_"
+	}
+	return name + envSegment(flagName)
+}
+
+// envSegment upper-cases a name and replaces dashes with underscores.
+func envSegment(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// skipEnv reports whether a flag only prints information and exits, so it
+// must not be set from the environment.
+func skipEnv(flagName string) bool {
+	return flagName == "help" || flagName == "print-protocol" || flagName == "describe" || flagName == "emit-config"
+}
+
+// BindEnv applies CLAUDE_HOOKS_* environment variables to every flag defined
+// on fs. It must be called after the flags are defined and before fs.Parse so
+// that command-line flags still take precedence. For each flag the
+// hook-specific variable wins over the machine-wide one.
+func BindEnv(fs *flag.FlagSet, hook string) error {
+	var bindErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		if bindErr != nil || skipEnv(f.Name) {
+			return
+		}
+
+		value, ok := os.LookupEnv(EnvName(hook, f.Name))
+		if !ok {
+			value, ok = os.LookupEnv(EnvName("", f.Name))
+		}
+		if !ok || value == "" {
+			return
+		}
+
+		values := []string{value}
+		if rv, isRepeatable := f.Value.(RepeatableValue); isRepeatable && rv.Repeatable() {
+			values = strings.Split(value, ";")
+		}
+		for _, v := range values {
+			if strings.TrimSpace(v) == "" {
+				continue
+			}
+			if err := fs.Set(f.Name, strings.TrimSpace(v)); err != nil {
+				bindErr = configError(fmt.Errorf("invalid value for %s: %w", EnvName(hook, f.Name), err))
+				return
+			}
+		}
+	})
+	return bindErr
+}
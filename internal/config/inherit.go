@@ -0,0 +1,139 @@
+// Package config - policy inheritance with extends and include
+package config
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+)
+
+// PresetPrefix marks a built-in bash-block preset in extends, e.g.
+// "preset:git-push".
+const PresetPrefix = "preset:"
+
+// loadPolicyFile reads a policy file and resolves its extends and include
+// references. chain holds the absolute paths of the files that led here, for
+// cycle detection.
+func loadPolicyFile(path string, chain []string) (*Policy, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(chain, abs) {
+		return nil, fmt.Errorf("policy inheritance cycle: %s", strings.Join(append(chain, abs), " -> "))
+	}
+	data, err := readPolicyFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := parsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	policy, err = resolveInheritance(policy, filepath.Dir(abs), append(chain, abs))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// resolveInheritance returns policy with its extends and include references
+// applied. Relative file references are resolved against dir; when dir is
+// empty (a remote policy) only preset references are allowed.
+//
+// Included files are combined with the policy as if their contents were
+// written in it, so their rules are all enforced. Extended files and presets
+// are a base the policy builds on: a policy rule with the same name as a base
+// rule replaces it, and the policy's formatters replace the base ones.
+// Everything else is additive, as with Merge.
+func resolveInheritance(policy *Policy, dir string, chain []string) (*Policy, error) {
+	if len(policy.Extends) == 0 && len(policy.Include) == 0 {
+		return policy, nil
+	}
+
+	base := &Policy{}
+	for _, ref := range policy.Extends {
+		parent, err := loadReference(ref, dir, chain)
+		if err != nil {
+			return nil, fmt.Errorf("extends %s: %w", ref, err)
+		}
+		base = inherit(base, parent)
+	}
+
+	own := *policy
+	own.Extends, own.Include = nil, nil
+	layers := make([]Layer, 0, len(policy.Include)+1)
+	for _, ref := range policy.Include {
+		if strings.HasPrefix(ref, PresetPrefix) {
+			return nil, fmt.Errorf("include %s: presets can only be extended", ref)
+		}
+		included, err := loadReference(ref, dir, chain)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", ref, err)
+		}
+		layers = append(layers, Layer{Source: ref, Policy: included})
+	}
+	layers = append(layers, Layer{Policy: &own})
+	return inherit(base, Merge(layers)), nil
+}
+
+// loadReference loads the policy an extends or include entry refers to.
+func loadReference(ref, dir string, chain []string) (*Policy, error) {
+	if name, ok := strings.CutPrefix(ref, PresetPrefix); ok {
+		if _, found := detector.LookupPreset(name); !found {
+			return nil, fmt.Errorf("unknown preset %q", name)
+		}
+		return &Policy{Presets: []string{name}}, nil
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("only %sNAME references are allowed in remote policies", PresetPrefix)
+	}
+	if !filepath.IsAbs(ref) {
+		ref = filepath.Join(dir, ref)
+	}
+	return loadPolicyFile(ref, chain)
+}
+
+// inherit applies child on top of base: the child rules named like a base
+// rule replace every base rule of that name, the child severities replace the
+// base ones, and the rest is merged as with Merge.
+func inherit(base, child *Policy) *Policy {
+	named := func(name string) func(Rule) bool {
+		return func(r Rule) bool { return name != "" && r.Name == name }
+	}
+	inherited := *base
+	inherited.Rules = nil
+	overriding := *child
+	overriding.Rules = nil
+	for _, rule := range base.Rules {
+		switch {
+		case !slices.ContainsFunc(child.Rules, named(rule.Name)):
+			inherited.Rules = append(inherited.Rules, rule)
+		case !slices.ContainsFunc(inherited.Rules, named(rule.Name)):
+			// Overridden in place by every child rule of the same name
+			for _, override := range child.Rules {
+				if override.Name == rule.Name {
+					inherited.Rules = append(inherited.Rules, override)
+				}
+			}
+		}
+	}
+	for _, rule := range child.Rules {
+		if !slices.ContainsFunc(base.Rules, named(rule.Name)) {
+			overriding.Rules = append(overriding.Rules, rule)
+		}
+	}
+	merged := Merge([]Layer{{Policy: &inherited}, {Policy: &overriding}})
+	if len(base.Severities) > 0 || len(child.Severities) > 0 {
+		merged.Severities = maps.Clone(base.Severities)
+		if merged.Severities == nil {
+			merged.Severities = map[string]string{}
+		}
+		maps.Copy(merged.Severities, child.Severities)
+	}
+	return merged
+}
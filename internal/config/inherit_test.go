@@ -0,0 +1,128 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPolicy_Inheritance(t *testing.T) {
+	dir := t.TempDir()
+	writePolicyFile(t, filepath.Join(dir, "org", "baseline.yaml"), `rules:
+  - name: no-push
+    command: git
+    patterns: [push]
+  - name: no-kubectl
+    command: kubectl
+protected_paths: [.env]
+formatters:
+  - command: gofmt -w
+    extensions: [.go]
+`)
+	writePolicyFile(t, filepath.Join(dir, "team.yaml"), "rules:\n  - name: no-push\n    command: git\n    patterns: [push --tags]\n")
+	path := filepath.Join(dir, ProjectFileName)
+	writePolicyFile(t, path, `extends: [org/baseline.yaml, preset:git-push]
+include: [team.yaml]
+rules:
+  - name: no-push
+    command: git
+    patterns: [push --force]
+protected_paths: [secrets/**]
+`)
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy() error: %v", err)
+	}
+	if len(policy.Extends) != 0 || len(policy.Include) != 0 {
+		t.Errorf("Extends = %v, Include = %v, want them resolved", policy.Extends, policy.Include)
+	}
+	if !reflect.DeepEqual(policy.Presets, []string{"git-push"}) {
+		t.Errorf("Presets = %v", policy.Presets)
+	}
+	var got []string
+	for _, rule := range policy.Rules {
+		got = append(got, rule.Name+": "+strings.Join(rule.Patterns, ","))
+	}
+	// The extending file overrides the baseline rule, and the included rule of
+	// the same name is enforced alongside it
+	want := []string{"no-push: push --tags", "no-push: push --force", "no-kubectl: "}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Rules = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(policy.ProtectedPaths, []string{".env", "secrets/**"}) {
+		t.Errorf("ProtectedPaths = %v", policy.ProtectedPaths)
+	}
+	if len(policy.Formatters) != 1 {
+		t.Errorf("Formatters = %v, want the baseline formatter", policy.Formatters)
+	}
+	if rules := policy.CommandRules(time.Now()); len(rules) <= len(policy.Rules) {
+		t.Errorf("CommandRules() = %d rules, want the git-push preset rules too", len(rules))
+	}
+}
+
+func TestLoadPolicy_InheritanceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			"Cycle",
+			map[string]string{"a.yaml": "extends: [b.yaml]\n", "b.yaml": "include: [a.yaml]\n"},
+			"policy inheritance cycle",
+		},
+		{
+			"Self",
+			map[string]string{"a.yaml": "extends: [a.yaml]\n"},
+			"policy inheritance cycle",
+		},
+		{
+			"Missing file",
+			map[string]string{"a.yaml": "extends: [missing.yaml]\n"},
+			"reading policy file",
+		},
+		{
+			"Unknown preset",
+			map[string]string{"a.yaml": "extends: [preset:bogus]\n"},
+			`unknown preset "bogus"`,
+		},
+		{
+			"Included preset",
+			map[string]string{"a.yaml": "include: [preset:git-push]\n"},
+			"presets can only be extended",
+		},
+		{
+			"Invalid base",
+			map[string]string{"a.yaml": "extends: [b.yaml]\n", "b.yaml": "rules:\n  - name: x\n"},
+			"command, redirects, or ssh_hosts is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writePolicyFile(t, filepath.Join(dir, name), content)
+			}
+			_, err := LoadPolicy(filepath.Join(dir, "a.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadPolicy() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePolicy_Extends(t *testing.T) {
+	policy, err := ParsePolicy([]byte("extends: [preset:shell-rc]\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+	if len(policy.RedirectPatterns(time.Now())) == 0 {
+		t.Error("RedirectPatterns() should include the shell-rc preset redirects")
+	}
+	if _, err := ParsePolicy([]byte("extends: [baseline.yaml]\n")); err == nil {
+		t.Error("ParsePolicy() should reject file references without a file to resolve them against")
+	}
+}
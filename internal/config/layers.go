@@ -0,0 +1,204 @@
+// Package config - layered policy resolution
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Scope identifies where a policy layer was loaded from.
+type Scope string
+
+// Policy layers, from most general to most specific.
+const (
+	ScopeSystem  Scope = "system"  // Machine-wide baseline, e.g. managed by an organization
+	ScopeUser    Scope = "user"    // Personal additions for every project
+	ScopeRules   Scope = "rules"   // The -rules file or URL
+	ScopeProject Scope = "project" // The discovered .claudehooks.yaml
+)
+
+// PolicyFileName is the policy file looked up in the system and user config directories.
+const PolicyFileName = "policy.yaml"
+
+// SystemConfigDir holds the machine-wide policy. It is a variable so tests and
+// packagers can point it elsewhere.
+var SystemConfigDir = "/etc/claudecode-hooks"
+
+// Layer is a policy together with the scope and location it was loaded from.
+type Layer struct {
+	Scope  Scope
+	Source string // File path or URL
+	Policy *Policy
+}
+
+// UserConfigDir returns the per-user policy directory (~/.config/claudecode-hooks
+// on Linux, honoring XDG_CONFIG_HOME).
+func UserConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "claudecode-hooks"), nil
+}
+
+// LoadLayers loads every policy layer that exists for a hook invocation:
+// the system policy, the user policy, the -rules source, and, when discovery is
+// enabled, the project policy found from cwd. Layers are returned from most
+// general to most specific; missing files are skipped. Each layer's rules in
+// groups disabled by the layer itself are left out, and, except in the system
+// layer, so are those in groups disabled by -disable-group.
+func LoadLayers(ctx context.Context, settings *Settings, cwd string) ([]Layer, error) {
+	var layers []Layer
+
+	system, err := loadOptionalLayer(ScopeSystem, filepath.Join(SystemConfigDir, PolicyFileName))
+	if err != nil {
+		return nil, err
+	}
+	layers = appendLayer(layers, system)
+
+	if userDir, err := UserConfigDir(); err == nil {
+		user, err := loadOptionalLayer(ScopeUser, filepath.Join(userDir, PolicyFileName))
+		if err != nil {
+			return nil, err
+		}
+		layers = appendLayer(layers, user)
+	}
+
+	if settings.RulesFile != "" {
+		policy, err := LoadPolicySource(ctx, settings.RulesFile, settings.RemoteOptions())
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, Layer{Scope: ScopeRules, Source: settings.RulesFile, Policy: policy})
+	}
+
+	if settings.Discover {
+		policy, path, err := LoadProjectPolicy(cwd)
+		if err != nil {
+			return nil, err
+		}
+		layers = appendLayer(layers, Layer{Scope: ScopeProject, Source: path, Policy: policy})
+	}
+
+	for i, layer := range layers {
+		if layer.Scope == ScopeSystem {
+			// -enable-group and -disable-group can come from the agent's
+			// environment, so they never change the machine-wide baseline
+			layers[i].Policy = layer.Policy.WithGroups(nil, nil)
+			continue
+		}
+		layers[i].Policy = layer.Policy.WithGroups(settings.EnableGroups, settings.DisableGroups)
+	}
+	return layers, nil
+}
+
+// Merge combines layers into the effective policy. Merging never lets a more
+// specific layer weaken a more general one:
+//
+//   - rules are additive: every layer's rules are enforced, and a project rule
+//     with the same name as a system rule adds to it rather than replacing it
+//   - presets are additive, as are disabled_groups, though LoadLayers already
+//     applied each layer's own
+//   - protected_paths and generated_paths are additive
+//   - notification webhooks are additive, so every layer's webhook is notified
+//   - package deny lists are additive, and ask_new is on if any layer turns it
+//     on; a deny always wins over an allow
+//   - package allow lists are additive up to the most general layer that turns
+//     ask_new on: more specific layers cannot exempt packages from its question
+//   - rewrites are additive, the most general layer's first, so a project
+//     rewrite cannot take precedence over a system one
+//   - the ownership allow list comes from the most general layer that defines
+//     one, so a project cannot allow edits of owners the system layer left
+//     out, and the ownership action and unowned values are the strictest any
+//     layer gives
+//   - formatters come from the most specific layer that defines any, since
+//     formatting is a preference rather than a safeguard, and so does
+//     block_message
+//   - each severity maps to the strongest decision any layer gives it
+func Merge(layers []Layer) *Policy {
+	merged := &Policy{}
+	policies := make([]*Policy, 0, len(layers))
+	for _, layer := range layers {
+		policy := layer.Policy
+		policies = append(policies, policy)
+		merged.Rules = append(merged.Rules, policy.Rules...)
+		for _, name := range policy.Presets {
+			if !slices.Contains(merged.Presets, name) {
+				merged.Presets = append(merged.Presets, name)
+			}
+		}
+		for _, group := range policy.DisabledGroups {
+			if !slices.Contains(merged.DisabledGroups, group) {
+				merged.DisabledGroups = append(merged.DisabledGroups, group)
+			}
+		}
+		for _, path := range policy.ProtectedPaths {
+			if !slices.Contains(merged.ProtectedPaths, path) {
+				merged.ProtectedPaths = append(merged.ProtectedPaths, path)
+			}
+		}
+		for _, path := range policy.GeneratedPaths {
+			if !slices.Contains(merged.GeneratedPaths, path) {
+				merged.GeneratedPaths = append(merged.GeneratedPaths, path)
+			}
+		}
+		for _, url := range policy.Notifications.URLs() {
+			if !slices.Contains(merged.Notifications.Webhooks, url) {
+				merged.Notifications.Webhooks = append(merged.Notifications.Webhooks, url)
+			}
+		}
+		merged.Packages.Deny = append(merged.Packages.Deny, policy.Packages.Deny...)
+		if !merged.Packages.AskNew {
+			merged.Packages.Allow = append(merged.Packages.Allow, policy.Packages.Allow...)
+		}
+		merged.Packages.AskNew = merged.Packages.AskNew || policy.Packages.AskNew
+		merged.Packages.Critical = append(merged.Packages.Critical, policy.Packages.Critical...)
+		merged.Rewrites = append(merged.Rewrites, policy.Rewrites...)
+		if len(merged.Ownership.Allow) == 0 {
+			merged.Ownership.Allow = policy.Ownership.Allow
+		}
+		merged.Ownership.Action = stricterAction(merged.Ownership.Action, policy.Ownership.Action)
+		merged.Ownership.Unowned = stricterAction(merged.Ownership.Unowned, policy.Ownership.Unowned)
+		if len(policy.Formatters) > 0 {
+			merged.Formatters = policy.Formatters
+		}
+		if policy.BlockMessage != "" {
+			merged.BlockMessage = policy.BlockMessage
+		}
+	}
+	merged.Severities = mergeSeverities(policies)
+	return merged
+}
+
+// loadOptionalLayer loads a policy file if it exists.
+func loadOptionalLayer(scope Scope, path string) (Layer, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return Layer{}, nil
+	}
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		return Layer{}, fmt.Errorf("%s policy: %w", scope, err)
+	}
+	return Layer{Scope: scope, Source: path, Policy: policy}, nil
+}
+
+// appendLayer appends layer unless it is empty (no policy was found).
+func appendLayer(layers []Layer, layer Layer) []Layer {
+	if layer.Policy == nil {
+		return layers
+	}
+	return append(layers, layer)
+}
+
+// stricterAction returns the stricter of two OwnershipActions values; an
+// empty value is unset.
+func stricterAction(a, b string) string {
+	if slices.Index(OwnershipActions, b) > slices.Index(OwnershipActions, a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writePolicyFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	defaultSystemDir := SystemConfigDir
+	SystemConfigDir = t.TempDir()
+	t.Cleanup(func() { SystemConfigDir = defaultSystemDir })
+	userConfig := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userConfig)
+	projectDir := t.TempDir()
+
+	writePolicyFile(t, filepath.Join(SystemConfigDir, PolicyFileName), "rules:\n  - command: terraform\n")
+	writePolicyFile(t, filepath.Join(userConfig, "claudecode-hooks", PolicyFileName), "rules:\n  - command: kubectl\n")
+	writePolicyFile(t, filepath.Join(projectDir, ProjectFileName), "rules:\n  - command: git\n")
+
+	layers, err := LoadLayers(context.Background(), &Settings{Discover: true}, projectDir)
+	if err != nil {
+		t.Fatalf("LoadLayers() error: %v", err)
+	}
+	var scopes []Scope
+	for _, layer := range layers {
+		scopes = append(scopes, layer.Scope)
+	}
+	if want := []Scope{ScopeSystem, ScopeUser, ScopeProject}; !reflect.DeepEqual(scopes, want) {
+		t.Errorf("LoadLayers() scopes = %v, want %v", scopes, want)
+	}
+
+	// A broken system policy is an error rather than silently skipped
+	writePolicyFile(t, filepath.Join(SystemConfigDir, PolicyFileName), "rules: [\n")
+	if _, err := LoadLayers(context.Background(), &Settings{}, projectDir); err == nil {
+		t.Error("LoadLayers() should fail for an invalid system policy")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	system := &Policy{
+		Rules:          []Rule{{Name: "no-destroy", Command: "terraform", Patterns: []string{"destroy"}}},
+		ProtectedPaths: []string{".env"},
+		Formatters:     []Formatter{{Command: "gofmt -w", Extensions: []string{".go"}}},
+		Notifications:  Notifications{Webhook: "https://security.example.com/hook"},
+		Packages:       Packages{Deny: []PackageRule{{Names: []string{"crossenv"}}}, AskNew: true},
+		Rewrites:       []Rewrite{{From: "rm -rf", To: "trash"}},
+		Ownership:      Ownership{Allow: []string{"@org/platform"}, Action: "deny"},
+	}
+	project := &Policy{
+		// Same name as the system rule: added alongside it, not replacing it
+		Rules:          []Rule{{Name: "no-destroy", Command: "terraform", Patterns: []string{"plan"}}},
+		ProtectedPaths: []string{".env", "secrets/**"},
+		GeneratedPaths: []string{"*.pb.go"},
+		Formatters:     []Formatter{{Command: "goimports -w", Extensions: []string{".go"}}},
+		Notifications:  Notifications{Webhook: "https://team.example.com/hook"},
+		Packages:       Packages{Deny: []PackageRule{{Ecosystem: "npm", Names: []string{"@internal/*"}}}},
+		Rewrites:       []Rewrite{{From: "rm -rf", To: "rm -rf"}},
+		Ownership:      Ownership{Allow: []string{"@org/payments"}, Action: "ask", Unowned: "ask"},
+	}
+
+	merged := Merge([]Layer{{Scope: ScopeSystem, Policy: system}, {Scope: ScopeProject, Policy: project}})
+
+	if len(merged.Rules) != 2 || merged.Rules[0].Patterns[0] != "destroy" {
+		t.Errorf("Merge() rules = %+v, want system and project rules", merged.Rules)
+	}
+	if want := []string{".env", "secrets/**"}; !reflect.DeepEqual(merged.ProtectedPaths, want) {
+		t.Errorf("Merge() protected paths = %v, want %v", merged.ProtectedPaths, want)
+	}
+	if want := []string{"*.pb.go"}; !reflect.DeepEqual(merged.GeneratedPaths, want) {
+		t.Errorf("Merge() generated paths = %v, want %v", merged.GeneratedPaths, want)
+	}
+	if want := []string{"https://security.example.com/hook", "https://team.example.com/hook"}; !reflect.DeepEqual(merged.Notifications.URLs(), want) {
+		t.Errorf("Merge() webhooks = %v, want %v", merged.Notifications.URLs(), want)
+	}
+	if len(merged.Formatters) != 1 || merged.Formatters[0].Command != "goimports -w" {
+		t.Errorf("Merge() formatters = %+v, want the project formatter", merged.Formatters)
+	}
+	if len(merged.Packages.Deny) != 2 || !merged.Packages.AskNew {
+		t.Errorf("Merge() packages = %+v, want both deny lists and ask_new from the system layer", merged.Packages)
+	}
+	if len(merged.Rewrites) != 2 || merged.Rewrites[0].To != "trash" {
+		t.Errorf("Merge() rewrites = %+v, want the system rewrite first", merged.Rewrites)
+	}
+	// The project cannot widen the owners the system layer allows
+	if want := (Ownership{Allow: []string{"@org/platform"}, Action: "deny", Unowned: "ask"}); !reflect.DeepEqual(merged.Ownership, want) {
+		t.Errorf("Merge() ownership = %+v, want %+v", merged.Ownership, want)
+	}
+}
+
+func TestMerge_PackageAllow(t *testing.T) {
+	allow := func(name string) []PackageRule { return []PackageRule{{Names: []string{name}}} }
+	tests := []struct {
+		name   string
+		layers []*Policy
+		want   []PackageRule
+	}{
+		{
+			"Project allow cannot silence system ask_new",
+			[]*Policy{{Packages: Packages{AskNew: true, Allow: allow("requests")}}, {Packages: Packages{Allow: allow("*")}}},
+			allow("requests"),
+		},
+		{
+			"User allow kept when the project turns ask_new on",
+			[]*Policy{{Packages: Packages{Allow: allow("left-pad")}}, {Packages: Packages{AskNew: true, Allow: allow("lodash")}}},
+			[]PackageRule{allow("left-pad")[0], allow("lodash")[0]},
+		},
+		{
+			"No ask_new",
+			[]*Policy{{Packages: Packages{Allow: allow("left-pad")}}, {Packages: Packages{Allow: allow("lodash")}}},
+			[]PackageRule{allow("left-pad")[0], allow("lodash")[0]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var layers []Layer
+			for _, policy := range tt.layers {
+				layers = append(layers, Layer{Policy: policy})
+			}
+			if got := Merge(layers).Packages.Allow; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() packages allow = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge_OwnershipAllow(t *testing.T) {
+	user := &Policy{Ownership: Ownership{Action: "ask"}}
+	project := &Policy{Ownership: Ownership{Allow: []string{"@org/payments"}}}
+	merged := Merge([]Layer{{Scope: ScopeUser, Policy: user}, {Scope: ScopeProject, Policy: project}})
+	if want := []string{"@org/payments"}; !reflect.DeepEqual(merged.Ownership.Allow, want) {
+		t.Errorf("Merge() ownership allow = %v, want the project's %v when no broader layer sets one", merged.Ownership.Allow, want)
+	}
+}
+
+func TestLoadLayers_Groups(t *testing.T) {
+	defaultSystemDir := SystemConfigDir
+	SystemConfigDir = t.TempDir()
+	t.Cleanup(func() { SystemConfigDir = defaultSystemDir })
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	projectDir := t.TempDir()
+
+	writePolicyFile(t, filepath.Join(SystemConfigDir, PolicyFileName), "rules:\n  - group: cloud\n    command: terraform\n")
+	writePolicyFile(t, filepath.Join(projectDir, ProjectFileName), `disabled_groups: [experimental, cloud]
+rules:
+  - group: git
+    command: git
+  - group: experimental
+    command: docker
+  - command: kubectl
+`)
+
+	tests := []struct {
+		name            string
+		enable, disable []string
+		wantCommands    []string
+	}{
+		{"Defaults", nil, nil, []string{"terraform", "git", "kubectl"}},
+		{"Disable group", nil, []string{"git"}, []string{"terraform", "kubectl"}},
+		{"System groups cannot be disabled", nil, []string{"cloud"}, []string{"terraform", "git", "kubectl"}},
+		{"Enable group", []string{"experimental"}, nil, []string{"terraform", "git", "docker", "kubectl"}},
+		{"Disable wins", []string{"git"}, []string{"git"}, []string{"terraform", "kubectl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &Settings{Discover: true, EnableGroups: tt.enable, DisableGroups: tt.disable}
+			layers, err := LoadLayers(context.Background(), settings, projectDir)
+			if err != nil {
+				t.Fatalf("LoadLayers() error: %v", err)
+			}
+			var got []string
+			for _, rule := range Merge(layers).Rules {
+				got = append(got, rule.Command)
+			}
+			if !reflect.DeepEqual(got, tt.wantCommands) {
+				t.Errorf("rules = %v, want %v", got, tt.wantCommands)
+			}
+		})
+	}
+}
@@ -0,0 +1,478 @@
+// Package config - policy file loading
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+)
+
+// Policy is the contents of a policy file. YAML and JSON are both accepted.
+// The same format is used for -rules files and for the project-local
+// .claudehooks.yaml, so a repository can check in one file consumed by every hook.
+//
+// Example:
+//
+//	disabled_groups: [cloud]  # off unless -enable-group cloud
+//	severities:          # see DefaultSeverityDecisions
+//	  high: deny
+//	rules:
+//	  - name: no-push
+//	    group: git         # toggled with -disable-group git
+//	    command: git
+//	    patterns: [push]
+//	    message: "{{.Rule}}: see https://wiki.example.com/git"  # see hook.MessageData
+//	  - command: kubectl   # no patterns blocks every kubectl command
+//	  - command: git
+//	    patterns: [push]
+//	    allow_after:       # unless tests passed in the last 10 tool calls
+//	      command: go test
+//	      within: 10
+//	  - command: kubectl
+//	    patterns: [apply]
+//	    schedule:          # only outside business hours (see Schedule)
+//	      timezone: Europe/Berlin
+//	      enforce_outside:
+//	        - days: [mon-fri]
+//	          hours: "09:00-17:00"
+//	  - name: no-docker-prune
+//	    command: docker
+//	    patterns: [system prune]
+//	    severity: medium   # allowed with a warning
+//	  - name: no-terraform-destroy
+//	    command: terraform
+//	    patterns: [destroy]
+//	    enforce: false     # shadow mode: audit would-block matches only
+//	  - name: no-shell-rc
+//	    redirects: [~/.bashrc, ~/.zshrc, /etc/**]  # block > and >> to these
+//	  - name: no-prod-shell
+//	    ssh_hosts: [prod-*]  # block ssh prod-db 'cmd', allow ssh prod-db
+//	formatters:
+//	  - command: goimports -w {FILEPATH}
+//	    extensions: [.go]
+//	protected_paths:
+//	  - .env
+//	  - secrets/**
+//	notifications:
+//	  webhook: https://hooks.example.com/claude
+//	packages:
+//	  deny:
+//	    - ecosystem: npm
+//	      names: ["@internal/*"]
+//	      reason: internal packages must come from the private registry
+//	  ask_new: true
+type Policy struct {
+	// Extends lists policy files (relative to this one) and built-in presets
+	// ("preset:NAME") this policy builds on and may override.
+	Extends []string `yaml:"extends,omitempty" json:"extends,omitempty"`
+	// Include lists policy files whose contents are added to this policy.
+	Include []string `yaml:"include,omitempty" json:"include,omitempty"`
+	// Presets are built-in bash-block presets enforced with the rules, as
+	// with -preset. Extending "preset:NAME" adds NAME here.
+	Presets []string `yaml:"presets,omitempty" json:"presets,omitempty"`
+	// DisabledGroups are rule groups that are off unless enabled with
+	// -enable-group.
+	DisabledGroups []string `yaml:"disabled_groups,omitempty" json:"disabled_groups,omitempty"`
+	// Severities maps rule severities to decisions (deny, ask, warn, or log),
+	// overriding DefaultSeverityDecisions.
+	Severities map[string]string `yaml:"severities,omitempty" json:"severities,omitempty"`
+	// BlockMessage is a text/template for block messages (see
+	// hook.MessageData), e.g. to link an internal runbook or translate them.
+	// Rules can override it with their own message.
+	BlockMessage   string        `yaml:"block_message,omitempty" json:"block_message,omitempty"`
+	Rules          []Rule        `yaml:"rules" json:"rules"`
+	Formatters     []Formatter   `yaml:"formatters,omitempty" json:"formatters,omitempty"`
+	ProtectedPaths []string      `yaml:"protected_paths,omitempty" json:"protected_paths,omitempty"`
+	GeneratedPaths []string      `yaml:"generated_paths,omitempty" json:"generated_paths,omitempty"`
+	Notifications  Notifications `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+	Packages       Packages      `yaml:"packages,omitempty" json:"packages,omitempty"`
+	Rewrites       []Rewrite     `yaml:"rewrites,omitempty" json:"rewrites,omitempty"`
+	Ownership      Ownership     `yaml:"ownership,omitempty" json:"ownership,omitempty"`
+}
+
+// Packages configures pkg-install-guard.
+type Packages struct {
+	Deny   []PackageRule `yaml:"deny,omitempty" json:"deny,omitempty"`       // Never install these
+	Allow  []PackageRule `yaml:"allow,omitempty" json:"allow,omitempty"`     // Never ask about these (deny still wins)
+	AskNew bool          `yaml:"ask_new,omitempty" json:"ask_new,omitempty"` // Ask before adding packages missing from the lockfile
+	// Critical are system packages that brew, apt, yum/dnf, and snap must
+	// not remove. Their ecosystem is one of SystemPackageManagers.
+	Critical []PackageRule `yaml:"critical,omitempty" json:"critical,omitempty"`
+}
+
+// PackageRule matches packages by name pattern, optionally within one ecosystem.
+type PackageRule struct {
+	Ecosystem string   `yaml:"ecosystem,omitempty" json:"ecosystem,omitempty"` // npm, pypi, cargo, or go (brew, apt, yum, or snap for critical); empty matches all
+	Names     []string `yaml:"names" json:"names"`                             // Glob patterns, e.g. "@internal/*"
+	Reason    string   `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// Formatter configures file-format for a set of file extensions.
+type Formatter struct {
+	Command    string   `yaml:"command" json:"command"`                     // Format command with optional {FILEPATH} placeholder
+	Extensions []string `yaml:"extensions" json:"extensions"`               // Extensions to format, e.g. [.go]
+	Block      bool     `yaml:"block,omitempty" json:"block,omitempty"`     // Block on formatting failures
+	Timeout    string   `yaml:"timeout,omitempty" json:"timeout,omitempty"` // Format command timeout, e.g. 30s
+	Stdin      bool     `yaml:"stdin,omitempty" json:"stdin,omitempty"`     // Command formats stdin to stdout, so it can format Write content before it is written
+
+	// Verify runs after formatting, e.g. "go build ./...", and blocks on failure
+	Verify        string `yaml:"verify,omitempty" json:"verify,omitempty"`
+	VerifyTimeout string `yaml:"verify_timeout,omitempty" json:"verify_timeout,omitempty"` // e.g. 2m
+}
+
+// Rewrite configures a command-rewrite rule: commands starting with From are
+// rewritten to start with To, or get AddFlag added.
+type Rewrite struct {
+	From    string `yaml:"from" json:"from"`                             // Command words and options to match, e.g. "rm -rf"
+	To      string `yaml:"to,omitempty" json:"to,omitempty"`             // Replacement, e.g. "trash"
+	AddFlag string `yaml:"add_flag,omitempty" json:"add_flag,omitempty"` // Flag to add instead, e.g. --dry-run=client
+}
+
+// Ownership configures owner-guard.
+type Ownership struct {
+	Allow   []string `yaml:"allow,omitempty" json:"allow,omitempty"`     // CODEOWNERS owners whose files may be edited, e.g. "@org/payments"
+	Action  string   `yaml:"action,omitempty" json:"action,omitempty"`   // ask or deny, for files only other owners own
+	Unowned string   `yaml:"unowned,omitempty" json:"unowned,omitempty"` // allow, ask, or deny, for files no CODEOWNERS rule owns
+}
+
+// OwnershipActions are the valid ownership unowned values, weakest first;
+// action is ask or deny.
+var OwnershipActions = []string{"allow", "ask", "deny"}
+
+// Notifications configures where hooks report blocked tool calls.
+type Notifications struct {
+	Webhook  string   `yaml:"webhook,omitempty" json:"webhook,omitempty"`   // URL that receives a JSON POST per block
+	Webhooks []string `yaml:"webhooks,omitempty" json:"webhooks,omitempty"` // Additional webhook URLs
+}
+
+// URLs returns every configured webhook URL.
+func (n Notifications) URLs() []string {
+	var urls []string
+	for _, url := range append([]string{n.Webhook}, n.Webhooks...) {
+		if url != "" && !slices.Contains(urls, url) {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// PackageEcosystems are the package ecosystems understood by pkg-install-guard.
+var PackageEcosystems = []string{"npm", "pypi", "cargo", "go"}
+
+// SystemPackageManagers are the system package managers whose removals
+// pkg-install-guard checks. yum also covers dnf.
+var SystemPackageManagers = []string{"brew", "apt", "yum", "snap"}
+
+// Rule is a single command rule in a policy file.
+type Rule struct {
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Group names the area the rule belongs to, such as git, cloud, or
+	// filesystem, so related rules can be turned off together with
+	// -disable-group.
+	Group      string      `yaml:"group,omitempty" json:"group,omitempty"`
+	Command    string      `yaml:"command,omitempty" json:"command,omitempty"`
+	Patterns   []string    `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+	AllowAfter *AllowAfter `yaml:"allow_after,omitempty" json:"allow_after,omitempty"`
+	Schedule   *Schedule   `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	Suggest    string      `yaml:"suggest,omitempty" json:"suggest,omitempty"` // Safer alternative shown when blocked
+	// Description says what the rule guards against, shown in -verbose block output
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Message     string `yaml:"message,omitempty" json:"message,omitempty"` // Block message template, overriding block_message
+
+	// Severity (critical, high, medium, or low) decides what happens when the
+	// command rule matches, through the policy's severities. Rules without
+	// one block.
+	Severity string `yaml:"severity,omitempty" json:"severity,omitempty"`
+
+	// Enforce set to false runs a command rule in shadow mode: commands it
+	// matches are audited, logged, and counted as would-block but allowed, so
+	// a new rule can be trialled before it is enforced.
+	Enforce *bool `yaml:"enforce,omitempty" json:"enforce,omitempty"`
+
+	// Redirects blocks output redirection (>, >>, &>) by any command to
+	// paths matching these patterns. A rule needs a command, redirects, or
+	// ssh_hosts, and may combine them.
+	Redirects []string `yaml:"redirects,omitempty" json:"redirects,omitempty"`
+
+	// SSHHosts blocks ssh commands that run an inline command on hosts
+	// matching these patterns, such as ssh prod-db 'psql ...'. Interactive
+	// sessions stay allowed.
+	SSHHosts []string `yaml:"ssh_hosts,omitempty" json:"ssh_hosts,omitempty"`
+}
+
+// AllowAfter lifts a rule when the session transcript shows a matching command
+// succeeded within the last Within tool calls.
+type AllowAfter struct {
+	Command string `yaml:"command" json:"command"`                   // e.g. "go test"
+	Within  int    `yaml:"within,omitempty" json:"within,omitempty"` // 0 considers the whole session
+}
+
+// WithGroups returns the policy without the rules of its disabled groups: the
+// groups in disable, and the groups in DisabledGroups that are not in enable.
+func (p *Policy) WithGroups(enable, disable []string) *Policy {
+	disabled := func(group string) bool {
+		return group != "" && (slices.Contains(disable, group) ||
+			(slices.Contains(p.DisabledGroups, group) && !slices.Contains(enable, group)))
+	}
+	if !slices.ContainsFunc(p.Rules, func(rule Rule) bool { return disabled(rule.Group) }) {
+		return p
+	}
+	filtered := *p
+	filtered.Rules = nil
+	for _, rule := range p.Rules {
+		if !disabled(rule.Group) {
+			filtered.Rules = append(filtered.Rules, rule)
+		}
+	}
+	return &filtered
+}
+
+// Shadow reports whether the rule runs in shadow mode (enforce: false).
+func (r Rule) Shadow() bool {
+	return r.Enforce != nil && !*r.Enforce
+}
+
+// IsProtectedPath reports whether path matches one of the policy's protected
+// path patterns. Relative patterns and paths are resolved against root (the
+// directory containing the policy file). Patterns use pathmatch.Match syntax,
+// so a trailing "/**" also protects everything below a directory, and
+// Windows paths match regardless of case or separator.
+func (p *Policy) IsProtectedPath(root, path string) bool {
+	path = pathmatch.Join(root, path)
+	for _, pattern := range p.ProtectedPaths {
+		if pathmatch.Match(pathmatch.Join(root, pattern), path) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadPolicy reads and validates a policy file, and resolves the files and
+// presets it extends or includes. Inheritance cycles are an error.
+func LoadPolicy(path string) (*Policy, error) {
+	policy, err := loadPolicyFile(path, nil)
+	return policy, configError(err)
+}
+
+// readPolicyFile reads the contents of a policy file.
+func readPolicyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is user-configured
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file: %w", err)
+	}
+	return data, nil
+}
+
+// ParsePolicy decodes and validates policy file contents. Contents that do
+// not match PolicySchema, such as a misspelled key, are rejected with
+// SchemaErrors rather than silently ignored. Without a file to resolve them
+// against, only preset references may be extended.
+func ParsePolicy(data []byte) (*Policy, error) {
+	policy, err := parsePolicy(data)
+	if err != nil {
+		return nil, configError(err)
+	}
+	policy, err = resolveInheritance(policy, "", nil)
+	return policy, configError(err)
+}
+
+// parsePolicy decodes and validates policy file contents, leaving extends
+// and include unresolved.
+func parsePolicy(data []byte) (*Policy, error) {
+	schemaErrs, err := ValidateSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if len(schemaErrs) > 0 {
+		return nil, fmt.Errorf("invalid policy:\n%w", schemaErrs)
+	}
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate reports rules that cannot be turned into detector rules.
+func (p *Policy) Validate() error {
+	errs := p.validateSeverities()
+	if _, err := hook.ParseMessageTemplate(p.BlockMessage); err != nil {
+		errs = append(errs, fmt.Errorf("block_message: %w", err))
+	}
+	for _, name := range p.Presets {
+		if _, ok := detector.LookupPreset(name); !ok {
+			errs = append(errs, fmt.Errorf("unknown preset %q", name))
+		}
+	}
+	for i, rule := range p.Rules {
+		if strings.TrimSpace(rule.Command) == "" && len(rule.Redirects) == 0 && len(rule.SSHHosts) == 0 {
+			errs = append(errs, fmt.Errorf("rule %d (%s): command, redirects, or ssh_hosts is required", i+1, rule.Name))
+		}
+		if _, err := hook.ParseMessageTemplate(rule.Message); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d (%s): message: %w", i+1, rule.Name, err))
+		}
+		if rule.Shadow() && (len(rule.Redirects) > 0 || len(rule.SSHHosts) > 0) {
+			errs = append(errs, fmt.Errorf("rule %d (%s): enforce: false applies to command rules only, not redirects or ssh_hosts", i+1, rule.Name))
+		}
+		if rule.AllowAfter != nil {
+			if strings.TrimSpace(rule.AllowAfter.Command) == "" {
+				errs = append(errs, fmt.Errorf("rule %d (%s): allow_after.command is required", i+1, rule.Name))
+			}
+			if rule.AllowAfter.Within < 0 {
+				errs = append(errs, fmt.Errorf("rule %d (%s): allow_after.within must not be negative", i+1, rule.Name))
+			}
+		}
+		if rule.Schedule != nil {
+			if err := rule.Schedule.validate(); err != nil {
+				errs = append(errs, fmt.Errorf("rule %d (%s): schedule: %w", i+1, rule.Name, err))
+			}
+		}
+	}
+	for i, rule := range slices.Concat(p.Packages.Deny, p.Packages.Allow) {
+		if len(rule.Names) == 0 {
+			errs = append(errs, fmt.Errorf("package rule %d: at least one name is required", i+1))
+		}
+		if rule.Ecosystem != "" && !slices.Contains(PackageEcosystems, rule.Ecosystem) {
+			errs = append(errs, fmt.Errorf("package rule %d: unknown ecosystem %q (want one of %s)", i+1, rule.Ecosystem, strings.Join(PackageEcosystems, ", ")))
+		}
+	}
+	for i, rule := range p.Packages.Critical {
+		if len(rule.Names) == 0 {
+			errs = append(errs, fmt.Errorf("critical package rule %d: at least one name is required", i+1))
+		}
+		if rule.Ecosystem != "" && !slices.Contains(SystemPackageManagers, rule.Ecosystem) {
+			errs = append(errs, fmt.Errorf("critical package rule %d: unknown package manager %q (want one of %s)", i+1, rule.Ecosystem, strings.Join(SystemPackageManagers, ", ")))
+		}
+	}
+	for i, formatter := range p.Formatters {
+		if strings.TrimSpace(formatter.Command) == "" {
+			errs = append(errs, fmt.Errorf("formatter %d: command is required", i+1))
+		}
+		if len(formatter.Extensions) == 0 {
+			errs = append(errs, fmt.Errorf("formatter %d: at least one extension is required", i+1))
+		}
+		for _, timeout := range []struct{ key, value string }{{"timeout", formatter.Timeout}, {"verify_timeout", formatter.VerifyTimeout}} {
+			if timeout.value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(timeout.value); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("formatter %d: %s: invalid duration %q", i+1, timeout.key, timeout.value))
+			}
+		}
+	}
+	if action := p.Ownership.Action; action != "" && action != "ask" && action != "deny" {
+		errs = append(errs, fmt.Errorf("ownership: action: unknown value %q (want ask or deny)", action))
+	}
+	if unowned := p.Ownership.Unowned; unowned != "" && !slices.Contains(OwnershipActions, unowned) {
+		errs = append(errs, fmt.Errorf("ownership: unowned: unknown value %q (want one of %s)", unowned, strings.Join(OwnershipActions, ", ")))
+	}
+	for i, rewrite := range p.Rewrites {
+		switch {
+		case strings.TrimSpace(rewrite.From) == "":
+			errs = append(errs, fmt.Errorf("rewrite %d: from is required", i+1))
+		case (strings.TrimSpace(rewrite.To) == "") == (rewrite.AddFlag == ""):
+			errs = append(errs, fmt.Errorf("rewrite %d (%s): exactly one of to and add_flag is required", i+1, rewrite.From))
+		case rewrite.AddFlag != "" && (!strings.HasPrefix(rewrite.AddFlag, "-") || strings.ContainsAny(rewrite.AddFlag, " \t")):
+			errs = append(errs, fmt.Errorf("rewrite %d (%s): add_flag %q is not a single flag", i+1, rewrite.From, rewrite.AddFlag))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CommandRules converts the policy presets and the rules enforced at now into
+// detector rules. A rule without patterns blocks every use of its command.
+// Rules whose schedule cannot be evaluated are enforced. Rules whose severity
+// maps to another decision than deny are left out; see DecisionRules.
+func (p *Policy) CommandRules(now time.Time) []detector.CommandRule {
+	return p.DecisionRules(now, DecisionDeny)
+}
+
+// ShadowRules converts the rules active at now that are only logged, shadow
+// rules (enforce: false) and rules whose severity maps to log, into detector
+// rules, for reporting the commands they would block.
+func (p *Policy) ShadowRules(now time.Time) []detector.CommandRule {
+	return p.DecisionRules(now, DecisionLog)
+}
+
+// DecisionRules converts the command rules active at now whose decision (see
+// Decision) is decision into detector rules. Presets are denied.
+func (p *Policy) DecisionRules(now time.Time, decision string) []detector.CommandRule {
+	var rules []detector.CommandRule
+	if decision == DecisionDeny {
+		for _, name := range p.Presets {
+			if preset, ok := detector.LookupPreset(name); ok {
+				rules = append(rules, preset.Rules...)
+			}
+		}
+	}
+	for _, rule := range p.Rules {
+		if enforced, err := rule.Schedule.Enforced(now); err == nil && !enforced {
+			continue
+		}
+		if rule.Command == "" || p.Decision(rule) != decision {
+			continue // Redirect or ssh_hosts rule, or another decision
+		}
+		patterns := rule.Patterns
+		if len(patterns) == 0 {
+			patterns = []string{"*"}
+		}
+		commandRule := detector.CommandRule{
+			Name:            rule.Name,
+			BlockedCommand:  rule.Command,
+			BlockedPatterns: patterns,
+			Suggest:         rule.Suggest,
+			Description:     rule.Description,
+			Message:         rule.Message,
+		}
+		if rule.AllowAfter != nil {
+			commandRule.AllowAfter = &detector.AllowAfter{
+				Command: rule.AllowAfter.Command,
+				Within:  rule.AllowAfter.Within,
+			}
+		}
+		rules = append(rules, commandRule)
+	}
+	return rules
+}
+
+// RedirectPatterns returns the redirect patterns of the presets and of the
+// rules enforced at now.
+func (p *Policy) RedirectPatterns(now time.Time) []string {
+	var patterns []string
+	for _, name := range p.Presets {
+		if preset, ok := detector.LookupPreset(name); ok {
+			patterns = append(patterns, preset.Redirects...)
+		}
+	}
+	for _, rule := range p.Rules {
+		if enforced, err := rule.Schedule.Enforced(now); (err == nil && !enforced) || rule.Shadow() {
+			continue
+		}
+		patterns = append(patterns, rule.Redirects...)
+	}
+	return patterns
+}
+
+// SSHHostPatterns returns the ssh_hosts patterns of the rules enforced at now.
+func (p *Policy) SSHHostPatterns(now time.Time) []string {
+	var patterns []string
+	for _, rule := range p.Rules {
+		if enforced, err := rule.Schedule.Enforced(now); (err == nil && !enforced) || rule.Shadow() {
+			continue
+		}
+		patterns = append(patterns, rule.SSHHosts...)
+	}
+	return patterns
+}
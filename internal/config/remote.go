@@ -0,0 +1,417 @@
+// Package config - remote policy fetching and verification
+package config
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	fetchTimeout       = 10 * time.Second
+	maxPolicySize      = 4 << 20 // 4 MiB
+	defaultCacheMaxAge = time.Hour
+	ociManifestTypes   = "application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// RemoteOptions controls fetching and verifying policies from https:// and oci:// sources.
+type RemoteOptions struct {
+	SHA256        string        // Expected hex SHA-256 of the policy file (optional pin)
+	PublicKeyPath string        // PEM public key for cosign-style blob signature verification
+	Signature     string        // Signature location; defaults to <url>.sig for https sources
+	CacheDir      string        // Cache directory; defaults to the user cache dir
+	MaxAge        time.Duration // How long a cached policy is used before refetching
+	Client        *http.Client
+}
+
+// IsRemoteSource reports whether source is an https:// or oci:// policy location.
+func IsRemoteSource(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "oci://")
+}
+
+// LoadPolicySource loads a policy from a local path or a remote source.
+func LoadPolicySource(ctx context.Context, source string, opts RemoteOptions) (*Policy, error) {
+	if !IsRemoteSource(source) {
+		return LoadPolicy(source)
+	}
+	data, err := FetchPolicy(ctx, source, opts)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return policy, nil
+}
+
+// FetchPolicy returns the verified contents of a remote policy. A cached copy
+// younger than MaxAge is used without network access; if fetching fails, an
+// older cached copy is used as a fallback. Cached copies are re-verified on
+// every read so tampering with the cache is detected.
+func FetchPolicy(ctx context.Context, source string, opts RemoteOptions) ([]byte, error) {
+	if opts.MaxAge == 0 {
+		opts.MaxAge = defaultCacheMaxAge
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: fetchTimeout}
+	}
+	cachePath, err := cachePathFor(source, opts.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, statErr := os.Stat(cachePath); statErr == nil && time.Since(info.ModTime()) < opts.MaxAge {
+		if data, readErr := readVerifiedCache(cachePath, opts); readErr == nil {
+			return data, nil
+		}
+	}
+
+	data, sig, fetchErr := fetchAndVerify(ctx, source, opts)
+	if fetchErr != nil {
+		if cached, readErr := readVerifiedCache(cachePath, opts); readErr == nil {
+			return cached, nil
+		}
+		return nil, fetchErr
+	}
+
+	if err := writeCache(cachePath, data, sig); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// fetchAndVerify downloads the policy (and its signature when a key is configured) and verifies both.
+func fetchAndVerify(ctx context.Context, source string, opts RemoteOptions) (data, sig []byte, err error) {
+	if strings.HasPrefix(source, "oci://") {
+		data, err = fetchOCI(ctx, opts.Client, strings.TrimPrefix(source, "oci://"))
+	} else {
+		data, err = fetchHTTPS(ctx, opts.Client, source, "")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.PublicKeyPath != "" {
+		sigSource := opts.Signature
+		if sigSource == "" {
+			if strings.HasPrefix(source, "oci://") {
+				return nil, nil, errors.New("signature location is required to verify oci:// policies")
+			}
+			sigSource = source + ".sig"
+		}
+		sig, err = readSignature(ctx, opts.Client, sigSource)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := verifyPolicy(data, sig, opts); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return data, sig, nil
+}
+
+// verifyPolicy checks the checksum pin and signature configured in opts.
+func verifyPolicy(data, sig []byte, opts RemoteOptions) error {
+	if opts.SHA256 != "" {
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), opts.SHA256) {
+			return errors.New("policy checksum does not match the pinned SHA-256")
+		}
+	}
+	if opts.PublicKeyPath != "" {
+		if err := VerifyBlobSignature(data, sig, opts.PublicKeyPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyBlobSignature verifies a base64 signature over data as produced by
+// `cosign sign-blob --key`: ECDSA or RSA over the SHA-256 digest, or Ed25519
+// over the raw data.
+func VerifyBlobSignature(data, sig []byte, publicKeyPath string) error {
+	keyPEM, err := os.ReadFile(publicKeyPath) // #nosec G304 - path is user-configured
+	if err != nil {
+		return fmt.Errorf("reading public key: %w", err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return errors.New("public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("parsing public key: %w", err)
+	}
+
+	rawSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+
+	digest := sha256.Sum256(data)
+	valid := false
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(k, digest[:], rawSig)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, data, rawSig)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], rawSig) == nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	if !valid {
+		return errors.New("policy signature verification failed")
+	}
+	return nil
+}
+
+// readSignature loads a signature from an https:// URL or a local file.
+func readSignature(ctx context.Context, client *http.Client, source string) ([]byte, error) {
+	if strings.HasPrefix(source, "https://") {
+		return fetchHTTPS(ctx, client, source, "")
+	}
+	sig, err := os.ReadFile(source) // #nosec G304 - path is user-configured
+	if err != nil {
+		return nil, fmt.Errorf("reading signature: %w", err)
+	}
+	return sig, nil
+}
+
+// fetchHTTPS performs a size-limited GET, optionally with a bearer token.
+func fetchHTTPS(ctx context.Context, client *http.Client, url, token string) ([]byte, error) {
+	resp, err := doGet(ctx, client, url, token, "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck // Body fully read below
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return readLimited(resp.Body)
+}
+
+func doGet(ctx context.Context, client *http.Client, url, token, accept string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the request context when the body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPolicySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if len(data) > maxPolicySize {
+		return nil, errors.New("policy exceeds maximum size")
+	}
+	return data, nil
+}
+
+// fetchOCI downloads the first layer of an OCI artifact reference such as
+// ghcr.io/org/policies:v1 (or @sha256:...), using anonymous bearer tokens
+// when the registry requests them. The layer digest is always verified.
+func fetchOCI(ctx context.Context, client *http.Client, ref string) ([]byte, error) {
+	registry, repo, reference, err := parseOCIReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	base := "https://" + registry + "/v2/" + repo
+
+	manifestBody, token, err := getOCI(ctx, client, base+"/manifests/"+reference, ociManifestTypes, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Layers []struct {
+			Digest string `json:"digest"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(manifestBody, &manifest); err != nil {
+		return nil, fmt.Errorf("decoding OCI manifest: %w", err)
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, errors.New("OCI artifact has no layers")
+	}
+
+	digest := manifest.Layers[0].Digest
+	blob, _, err := getOCI(ctx, client, base+"/blobs/"+digest, "", token)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(blob)
+	if digest != "sha256:"+hex.EncodeToString(sum[:]) {
+		return nil, errors.New("OCI layer digest mismatch")
+	}
+	return blob, nil
+}
+
+// getOCI GETs a registry URL, negotiating an anonymous bearer token on 401.
+func getOCI(ctx context.Context, client *http.Client, url, accept, token string) ([]byte, string, error) {
+	resp, err := doGet(ctx, client, url, token, accept)
+	if err != nil {
+		return nil, token, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close() //nolint:errcheck // Retrying with a token
+		token, err = fetchRegistryToken(ctx, client, challenge)
+		if err != nil {
+			return nil, "", err
+		}
+		resp, err = doGet(ctx, client, url, token, accept)
+		if err != nil {
+			return nil, token, err
+		}
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck // Body fully read below
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, token, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	body, err := readLimited(resp.Body)
+	return body, token, err
+}
+
+// fetchRegistryToken requests an anonymous token described by a Bearer challenge.
+func fetchRegistryToken(ctx context.Context, client *http.Client, challenge string) (string, error) {
+	params, ok := strings.CutPrefix(challenge, "Bearer ")
+	if !ok {
+		return "", errors.New("registry requires unsupported authentication")
+	}
+
+	values := make(map[string]string)
+	for _, part := range strings.Split(params, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if found {
+			values[key] = strings.Trim(value, `"`)
+		}
+	}
+	if values["realm"] == "" {
+		return "", errors.New("registry auth challenge has no realm")
+	}
+
+	url := values["realm"] + "?service=" + values["service"] + "&scope=" + values["scope"]
+	body, err := fetchHTTPS(ctx, client, url, "")
+	if err != nil {
+		return "", err
+	}
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("decoding registry token: %w", err)
+	}
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+	return tokenResp.AccessToken, nil
+}
+
+// parseOCIReference splits registry/repo:tag or registry/repo@digest.
+func parseOCIReference(ref string) (registry, repo, reference string, err error) {
+	registry, rest, found := strings.Cut(ref, "/")
+	if !found || rest == "" {
+		return "", "", "", fmt.Errorf("invalid OCI reference %q", ref)
+	}
+	if name, digest, ok := strings.Cut(rest, "@"); ok {
+		return registry, name, digest, nil
+	}
+	if i := strings.LastIndex(rest, ":"); i > 0 {
+		return registry, rest[:i], rest[i+1:], nil
+	}
+	return registry, rest, "latest", nil
+}
+
+// cachePathFor returns the cache file for a source.
+func cachePathFor(source, cacheDir string) (string, error) {
+	if cacheDir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("locating cache directory: %w", err)
+		}
+		cacheDir = filepath.Join(userCache, "claudecode-hooks", "policies")
+	}
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:16])+".yaml"), nil
+}
+
+func readVerifiedCache(path string, opts RemoteOptions) ([]byte, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path derived from cache dir
+	if err != nil {
+		return nil, err
+	}
+	var sig []byte
+	if opts.PublicKeyPath != "" {
+		if sig, err = os.ReadFile(path + ".sig"); err != nil { // #nosec G304 - path derived from cache dir
+			return nil, err
+		}
+	}
+	if err := verifyPolicy(data, sig, opts); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func writeCache(path string, data, sig []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating policy cache: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing policy cache: %w", err)
+	}
+	if sig != nil {
+		if err := os.WriteFile(path+".sig", sig, 0o600); err != nil {
+			return fmt.Errorf("writing policy cache: %w", err)
+		}
+	}
+	return nil
+}
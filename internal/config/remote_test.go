@@ -0,0 +1,210 @@
+package config
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const remotePolicy = "rules:\n  - command: git\n    patterns: [push]\n"
+
+// signingKey creates an ECDSA key, writes its public key PEM, and returns a
+// cosign-style signature of remotePolicy.
+func signingKey(t *testing.T) (pubPath, signature string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPath = filepath.Join(t.TempDir(), "cosign.pub")
+	if err := os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256([]byte(remotePolicy))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pubPath, base64.StdEncoding.EncodeToString(sig)
+}
+
+func policyServer(t *testing.T, signature string) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/policy.yaml":
+			fmt.Fprint(w, remotePolicy)
+		case "/policy.yaml.sig":
+			fmt.Fprint(w, signature)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestLoadPolicySource_HTTPSWithSignature(t *testing.T) {
+	pubPath, signature := signingKey(t)
+	server, _ := policyServer(t, signature)
+
+	sum := sha256.Sum256([]byte(remotePolicy))
+	opts := RemoteOptions{
+		SHA256:        hex.EncodeToString(sum[:]),
+		PublicKeyPath: pubPath,
+		CacheDir:      t.TempDir(),
+		Client:        server.Client(),
+	}
+	policy, err := LoadPolicySource(context.Background(), server.URL+"/policy.yaml", opts)
+	if err != nil {
+		t.Fatalf("LoadPolicySource() error: %v", err)
+	}
+	if len(policy.Rules) != 1 || policy.Rules[0].Command != "git" {
+		t.Errorf("unexpected policy: %+v", policy)
+	}
+}
+
+func TestFetchPolicy_VerificationFailures(t *testing.T) {
+	pubPath, _ := signingKey(t)
+	_, otherSignature := signingKey(t)
+	server, _ := policyServer(t, otherSignature)
+
+	tests := []struct {
+		name string
+		opts RemoteOptions
+		want string
+	}{
+		{
+			name: "Checksum mismatch",
+			opts: RemoteOptions{SHA256: strings.Repeat("0", 64)},
+			want: "checksum",
+		},
+		{
+			name: "Signature from a different key",
+			opts: RemoteOptions{PublicKeyPath: pubPath},
+			want: "signature",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.CacheDir = t.TempDir()
+			tt.opts.Client = server.Client()
+			_, err := FetchPolicy(context.Background(), server.URL+"/policy.yaml", tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("FetchPolicy() error = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchPolicy_Cache(t *testing.T) {
+	server, requests := policyServer(t, "")
+	source := server.URL + "/policy.yaml"
+	opts := RemoteOptions{CacheDir: t.TempDir(), Client: server.Client()}
+
+	if _, err := FetchPolicy(context.Background(), source, opts); err != nil {
+		t.Fatalf("FetchPolicy() error: %v", err)
+	}
+	if _, err := FetchPolicy(context.Background(), source, opts); err != nil {
+		t.Fatalf("FetchPolicy() error: %v", err)
+	}
+	if *requests != 1 {
+		t.Errorf("fresh cache should avoid refetching, got %d requests", *requests)
+	}
+
+	// Stale cache is refetched, and used as a fallback when the server is gone
+	cachePath, err := cachePathFor(source, opts.CacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cachePath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	data, err := FetchPolicy(context.Background(), source, opts)
+	if err != nil {
+		t.Fatalf("FetchPolicy() should fall back to the cache: %v", err)
+	}
+	if string(data) != remotePolicy {
+		t.Errorf("cached policy = %q", data)
+	}
+}
+
+func TestFetchPolicy_OCI(t *testing.T) {
+	sum := sha256.Sum256([]byte(remotePolicy))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var serverURL string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			fmt.Fprint(w, `{"token": "anon"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anon" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+serverURL+`/token",service="test",scope="repository:org/policies:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/org/policies/manifests/v1":
+			fmt.Fprintf(w, `{"layers": [{"digest": %q}]}`, digest)
+		case "/v2/org/policies/blobs/" + digest:
+			fmt.Fprint(w, remotePolicy)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	source := "oci://" + strings.TrimPrefix(server.URL, "https://") + "/org/policies:v1"
+	data, err := FetchPolicy(context.Background(), source, RemoteOptions{CacheDir: t.TempDir(), Client: server.Client()})
+	if err != nil {
+		t.Fatalf("FetchPolicy() error: %v", err)
+	}
+	if string(data) != remotePolicy {
+		t.Errorf("OCI policy = %q", data)
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	tests := []struct {
+		ref, registry, repo, reference string
+	}{
+		{"ghcr.io/org/policies:v1", "ghcr.io", "org/policies", "v1"},
+		{"ghcr.io/org/policies", "ghcr.io", "org/policies", "latest"},
+		{"localhost:5000/policies@sha256:abc", "localhost:5000", "policies", "sha256:abc"},
+	}
+	for _, tt := range tests {
+		registry, repo, reference, err := parseOCIReference(tt.ref)
+		if err != nil || registry != tt.registry || repo != tt.repo || reference != tt.reference {
+			t.Errorf("parseOCIReference(%q) = %q, %q, %q, %v", tt.ref, registry, repo, reference, err)
+		}
+	}
+	if _, _, _, err := parseOCIReference("policies"); err == nil {
+		t.Error("parseOCIReference() should reject references without a registry")
+	}
+}
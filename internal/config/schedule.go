@@ -0,0 +1,220 @@
+// Package config - time-window rule scheduling
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schedule limits when a rule is enforced. A rule is enforced while the current
+// time falls inside any EnforceDuring window (e.g. a release freeze) or outside
+// all EnforceOutside windows (e.g. outside business hours). A schedule with no
+// windows is always enforced.
+//
+// Example:
+//
+//	schedule:
+//	  timezone: America/New_York
+//	  enforce_outside:          # only allow pushes during business hours
+//	    - days: [mon-fri]
+//	      hours: "09:00-17:00"
+//	  enforce_during:           # and never during the holiday freeze
+//	    - from: 2025-12-20
+//	      to: 2026-01-02
+type Schedule struct {
+	Timezone       string   `yaml:"timezone,omitempty" json:"timezone,omitempty"` // IANA name; defaults to local time
+	EnforceDuring  []Window `yaml:"enforce_during,omitempty" json:"enforce_during,omitempty"`
+	EnforceOutside []Window `yaml:"enforce_outside,omitempty" json:"enforce_outside,omitempty"`
+}
+
+// Window is a recurring day/hour window, an absolute date range, or both.
+// Empty fields match any time.
+type Window struct {
+	Days  []string `yaml:"days,omitempty" json:"days,omitempty"`   // mon..sun, ranges such as mon-fri
+	Hours string   `yaml:"hours,omitempty" json:"hours,omitempty"` // "09:00-17:00"; may wrap past midnight
+	From  string   `yaml:"from,omitempty" json:"from,omitempty"`   // 2006-01-02 or RFC 3339
+	To    string   `yaml:"to,omitempty" json:"to,omitempty"`       // Inclusive date or RFC 3339 time
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Enforced reports whether a rule with this schedule applies at now.
+func (s *Schedule) Enforced(now time.Time) (bool, error) {
+	if s == nil {
+		return true, nil
+	}
+	loc, err := s.location()
+	if err != nil {
+		return false, err
+	}
+	now = now.In(loc)
+
+	for _, window := range s.EnforceDuring {
+		inside, err := window.contains(now, loc)
+		if err != nil || inside {
+			return inside, err
+		}
+	}
+	if len(s.EnforceOutside) == 0 {
+		return len(s.EnforceDuring) == 0, nil
+	}
+	for _, window := range s.EnforceOutside {
+		inside, err := window.contains(now, loc)
+		if err != nil || inside {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// validate checks the timezone and every window without evaluating them.
+func (s *Schedule) validate() error {
+	loc, err := s.location()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, window := range slices.Concat(s.EnforceDuring, s.EnforceOutside) {
+		if _, err := window.contains(time.Now(), loc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Schedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+	}
+	return loc, nil
+}
+
+// contains reports whether t (already in loc) falls inside the window.
+func (w Window) contains(t time.Time, loc *time.Location) (bool, error) {
+	inRange, err := w.inDateRange(t, loc)
+	if err != nil || !inRange {
+		return false, err
+	}
+	onDay, err := w.onDay(t)
+	if err != nil || !onDay {
+		return false, err
+	}
+	return w.inHours(t)
+}
+
+func (w Window) inDateRange(t time.Time, loc *time.Location) (bool, error) {
+	if w.From != "" {
+		from, _, err := parseScheduleTime(w.From, loc)
+		if err != nil {
+			return false, err
+		}
+		if t.Before(from) {
+			return false, nil
+		}
+	}
+	if w.To != "" {
+		to, dateOnly, err := parseScheduleTime(w.To, loc)
+		if err != nil {
+			return false, err
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1) // Dates are inclusive
+		}
+		if !t.Before(to) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (w Window) onDay(t time.Time) (bool, error) {
+	if len(w.Days) == 0 {
+		return true, nil
+	}
+	for _, spec := range w.Days {
+		first, last, err := parseDayRange(spec)
+		if err != nil {
+			return false, err
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			if day == t.Weekday() {
+				return true, nil
+			}
+			if day == last {
+				break
+			}
+		}
+	}
+	return false, nil
+}
+
+func (w Window) inHours(t time.Time) (bool, error) {
+	if w.Hours == "" {
+		return true, nil
+	}
+	startText, endText, ok := strings.Cut(w.Hours, "-")
+	if !ok {
+		return false, fmt.Errorf("invalid hours %q: want HH:MM-HH:MM", w.Hours)
+	}
+	start, err := parseClock(startText)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseClock(endText)
+	if err != nil {
+		return false, err
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return minute >= start && minute < end, nil
+	}
+	return minute >= start || minute < end, nil // Wraps past midnight
+}
+
+// parseScheduleTime parses a date or RFC 3339 time and reports whether it was a date.
+func parseScheduleTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date %q: want YYYY-MM-DD or RFC 3339", value)
+	}
+	return t, false, nil
+}
+
+func parseDayRange(spec string) (time.Weekday, time.Weekday, error) {
+	firstText, lastText, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), "-")
+	first, ok := weekdays[firstText]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid day %q: want mon..sun or a range like mon-fri", spec)
+	}
+	if !isRange {
+		return first, first, nil
+	}
+	last, ok := weekdays[lastText]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid day %q: want mon..sun or a range like mon-fri", spec)
+	}
+	return first, last, nil
+}
+
+// parseClock parses HH:MM into minutes after midnight.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: want HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Enforced(t *testing.T) {
+	businessHours := []Window{{Days: []string{"mon-fri"}, Hours: "09:00-17:00"}}
+	freeze := []Window{{From: "2025-12-20", To: "2026-01-02"}}
+
+	tests := []struct {
+		name     string
+		schedule *Schedule
+		now      string
+		want     bool
+	}{
+		{"nil schedule", nil, "2025-06-04T12:00:00Z", true},
+		{"no windows", &Schedule{}, "2025-06-04T12:00:00Z", true},
+		{"inside business hours", &Schedule{EnforceOutside: businessHours}, "2025-06-04T12:00:00Z", false},
+		{"after business hours", &Schedule{EnforceOutside: businessHours}, "2025-06-04T17:00:00Z", true},
+		{"weekend", &Schedule{EnforceOutside: businessHours}, "2025-06-07T12:00:00Z", true},
+		{"timezone shifts hours", &Schedule{Timezone: "America/New_York", EnforceOutside: businessHours}, "2025-06-04T12:00:00Z", true},
+		{"timezone inside hours", &Schedule{Timezone: "America/New_York", EnforceOutside: businessHours}, "2025-06-04T14:00:00Z", false},
+		{"during freeze", &Schedule{EnforceDuring: freeze}, "2025-12-24T12:00:00Z", true},
+		{"last day of freeze is inclusive", &Schedule{EnforceDuring: freeze}, "2026-01-02T23:59:00Z", true},
+		{"after freeze", &Schedule{EnforceDuring: freeze}, "2026-01-03T00:00:00Z", false},
+		{"freeze overrides business hours", &Schedule{EnforceOutside: businessHours, EnforceDuring: freeze}, "2025-12-23T12:00:00Z", true},
+		{"overnight window", &Schedule{EnforceDuring: []Window{{Hours: "22:00-06:00"}}}, "2025-06-04T03:00:00Z", true},
+		{"outside overnight window", &Schedule{EnforceDuring: []Window{{Hours: "22:00-06:00"}}}, "2025-06-04T12:00:00Z", false},
+		{"wrapping day range", &Schedule{EnforceDuring: []Window{{Days: []string{"fri-mon"}}}}, "2025-06-08T12:00:00Z", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.schedule.Enforced(now)
+			if err != nil {
+				t.Fatalf("Enforced() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Enforced(%s) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePolicy_InvalidSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+	}{
+		{"timezone", "timezone: Mars/Olympus"},
+		{"day", "enforce_during: [{days: [someday]}]"},
+		{"hours", "enforce_during: [{hours: \"9-5\"}]"},
+		{"date", "enforce_during: [{from: tomorrow}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "rules:\n  - command: git\n    schedule: {" + tt.schedule + "}\n"
+			if _, err := ParsePolicy([]byte(content)); err == nil {
+				t.Error("ParsePolicy() should reject an invalid schedule")
+			}
+		})
+	}
+}
+
+func TestPolicy_CommandRulesSchedule(t *testing.T) {
+	policy, err := ParsePolicy([]byte("rules:\n  - command: git\n  - command: kubectl\n    schedule:\n      enforce_during:\n        - days: [sat-sun]\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+	weekday := time.Date(2025, 6, 4, 12, 0, 0, 0, time.Local)
+	if rules := policy.CommandRules(weekday); len(rules) != 1 || rules[0].BlockedCommand != "git" {
+		t.Errorf("CommandRules(weekday) = %+v, want only git", rules)
+	}
+	weekend := time.Date(2025, 6, 7, 12, 0, 0, 0, time.Local)
+	if rules := policy.CommandRules(weekend); len(rules) != 2 {
+		t.Errorf("CommandRules(weekend) = %+v, want git and kubectl", rules)
+	}
+}
@@ -0,0 +1,238 @@
+// Package config - policy file schema validation
+package config
+
+import (
+	_ "embed" // Embeds the policy schema
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicySchema is the JSON Schema of policy files. Editors can use it for
+// completion, and ParsePolicy checks every policy file against it.
+//
+//go:embed policy.schema.json
+var PolicySchema []byte
+
+// schema is the subset of JSON Schema that PolicySchema uses.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	Minimum              *float64           `json:"minimum"`
+	Enum                 []string           `json:"enum"`
+	Defs                 map[string]*schema `json:"$defs"`
+}
+
+// policySchema is PolicySchema decoded.
+var policySchema = func() *schema {
+	var s schema
+	if err := json.Unmarshal(PolicySchema, &s); err != nil {
+		panic("invalid embedded policy schema: " + err.Error())
+	}
+	return &s
+}()
+
+// SchemaError is a policy file problem found by schema validation, at its
+// position in the file.
+type SchemaError struct {
+	Line    int
+	Column  int
+	Path    string // Location in the policy, e.g. rules[2].allow_after; empty for the top level
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// SchemaErrors are all the schema violations in a policy file.
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// ValidateSchema checks policy file contents (YAML or JSON) against
+// PolicySchema. It returns the violations found, or an error if the contents
+// cannot be parsed at all.
+func ValidateSchema(data []byte) (SchemaErrors, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil // Empty file
+	}
+	v := &validator{root: policySchema}
+	v.validate(doc.Content[0], policySchema, "")
+	return v.errs, nil
+}
+
+// validator collects the violations of one document.
+type validator struct {
+	root *schema
+	errs SchemaErrors
+}
+
+func (v *validator) report(node *yaml.Node, path, format string, args ...any) {
+	v.errs = append(v.errs, SchemaError{Line: node.Line, Column: node.Column, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// resolve follows a "#/$defs/name" reference.
+func (v *validator) resolve(s *schema) *schema {
+	if name, ok := strings.CutPrefix(s.Ref, "#/$defs/"); ok {
+		if def, found := v.root.Defs[name]; found {
+			return def
+		}
+	}
+	return s
+}
+
+func (v *validator) validate(node *yaml.Node, s *schema, path string) {
+	s = v.resolve(s)
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return // Same as leaving the key out
+	}
+
+	switch s.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			v.report(node, path, "expected a mapping of keys to values, got %s", describeNode(node))
+			return
+		}
+		v.validateObject(node, s, path)
+	case "array":
+		if node.Kind == yaml.ScalarNode {
+			v.report(node, path, "expected a list, got %s; write [%s] for a single item", describeNode(node), node.Value)
+			return
+		}
+		if node.Kind != yaml.SequenceNode {
+			v.report(node, path, "expected a list, got %s", describeNode(node))
+			return
+		}
+		for i, item := range node.Content {
+			v.validate(item, s.Items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case "string":
+		if node.Kind != yaml.ScalarNode {
+			v.report(node, path, "expected a string, got %s", describeNode(node))
+			return
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, node.Value) {
+			v.report(node, path, "expected one of %s, got %q", strings.Join(s.Enum, ", "), node.Value)
+		}
+	case "boolean":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			v.report(node, path, "expected true or false, got %s", describeNode(node))
+		}
+	case "integer":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			v.report(node, path, "expected a whole number, got %s", describeNode(node))
+			return
+		}
+		if n, err := strconv.ParseFloat(node.Value, 64); err == nil && s.Minimum != nil && n < *s.Minimum {
+			v.report(node, path, "must be at least %v", *s.Minimum)
+		}
+	}
+}
+
+func (v *validator) validateObject(node *yaml.Node, s *schema, path string) {
+	var keys []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keys = append(keys, key.Value)
+		property, known := s.Properties[key.Value]
+		if !known {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				v.report(key, path, "unknown key %q%s", key.Value, suggestKey(key.Value, s.Properties))
+			}
+			continue
+		}
+		v.validate(value, property, joinPath(path, key.Value))
+	}
+	for _, name := range s.Required {
+		if !slices.Contains(keys, name) {
+			v.report(node, path, "missing required key %q", name)
+		}
+	}
+}
+
+// joinPath appends a key to a policy location.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// describeNode names the kind of value a node holds, for error messages.
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	switch node.Tag {
+	case "!!bool":
+		return "the boolean " + node.Value
+	case "!!int", "!!float":
+		return "the number " + node.Value
+	}
+	return strconv.Quote(node.Value)
+}
+
+// suggestKey returns a "did you mean" hint for a misspelled key, or "" when
+// no known key is close.
+func suggestKey(key string, properties map[string]*schema) string {
+	best, bestDistance := "", len(key)/2+1
+	for _, name := range slices.Sorted(maps.Keys(properties)) {
+		if d := editDistance(strings.ToLower(key), name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean %q?", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,233 @@
+// Package config provides shared configuration for Claude Code hooks: common
+// settings bound to flags and CLAUDE_HOOKS_* environment variables, and the
+// policy file format that defines command rules.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// FailMode controls what a hook does when it cannot do its job, for example
+// when the hook input or the rules file cannot be parsed.
+type FailMode string
+
+const (
+	// FailClosed blocks the tool call on internal errors (secure default for blockers).
+	FailClosed FailMode = "closed"
+	// FailOpen allows the tool call on internal errors.
+	FailOpen FailMode = "open"
+)
+
+// Set implements flag.Value.
+func (m *FailMode) Set(value string) error {
+	switch FailMode(strings.ToLower(value)) {
+	case FailClosed:
+		*m = FailClosed
+	case FailOpen:
+		*m = FailOpen
+	default:
+		return fmt.Errorf("invalid fail mode %q (want %q or %q)", value, FailClosed, FailOpen)
+	}
+	return nil
+}
+
+// String implements flag.Value.
+func (m *FailMode) String() string {
+	return string(*m)
+}
+
+// Settings holds the options shared by every hook binary.
+type Settings struct {
+	RulesFile string   // Policy file or https:// / oci:// location (-rules, CLAUDE_HOOKS_RULES)
+	FailMode  FailMode // Behavior on internal errors (-fail-mode, CLAUDE_HOOKS_FAIL_MODE)
+	LogLevel  string   // debug, info, warn, or error (-log-level, CLAUDE_HOOKS_LOG_LEVEL)
+	AuditLog  string   // JSONL decision log path (-audit-log, CLAUDE_HOOKS_AUDIT_LOG)
+	Discover  bool     // Look for .claudehooks.yaml above the payload cwd (-discover, CLAUDE_HOOKS_DISCOVER)
+
+	// Reject payloads that do not match the expected schema (-strict-input, CLAUDE_HOOKS_STRICT_INPUT)
+	StrictInput bool
+
+	// Rule groups (see Rule.Group) to turn on although a policy lists them in
+	// disabled_groups (-enable-group, CLAUDE_HOOKS_ENABLE_GROUP)
+	EnableGroups []string
+	// Rule groups to turn off in every policy layer (-disable-group, CLAUDE_HOOKS_DISABLE_GROUP)
+	DisableGroups []string
+
+	// Block output detail for hooks that call RegisterOutputFlags
+	Quiet   bool // Single-line block reasons (-quiet, CLAUDE_HOOKS_QUIET)
+	Verbose bool // Every issue, the matched commands, and rule descriptions (-verbose, CLAUDE_HOOKS_VERBOSE)
+	// Warn the user instead of blocking, for advisory use (-warn-only, CLAUDE_HOOKS_WARN_ONLY)
+	WarnOnly bool
+
+	// Verification of remote policies
+	RulesSHA256    string // Pinned SHA-256 of the remote policy (-rules-sha256)
+	RulesPublicKey string // PEM public key for signature verification (-rules-pubkey)
+	RulesSignature string // Signature file or URL, default <url>.sig (-rules-signature)
+}
+
+// RegisterFlags registers the common flags on fs and returns the Settings they
+// populate. defaultFailMode is the hook's fail mode when none is configured.
+func RegisterFlags(fs *flag.FlagSet, defaultFailMode FailMode) *Settings {
+	settings := &Settings{FailMode: defaultFailMode, LogLevel: "warn", Discover: true}
+	fs.StringVar(&settings.RulesFile, "rules", "", "Policy file with command rules")
+	fs.Var(&settings.FailMode, "fail-mode", "Behavior on internal errors: closed (block) or open (allow)")
+	fs.StringVar(&settings.LogLevel, "log-level", settings.LogLevel, "Log level: debug, info, warn, or error")
+	fs.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every decision to this file")
+	fs.BoolVar(&settings.Discover, "discover", settings.Discover, "Load "+ProjectFileName+" found above the working directory")
+	fs.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	fs.Var((*nameList)(&settings.EnableGroups), "enable-group", "Policy rule group to enable although disabled_groups lists it (can be specified multiple times)")
+	fs.Var((*nameList)(&settings.DisableGroups), "disable-group", "Policy rule group to disable, e.g. cloud (can be specified multiple times)")
+	fs.StringVar(&settings.RulesSHA256, "rules-sha256", "", "Expected SHA-256 of a remote -rules policy")
+	fs.StringVar(&settings.RulesPublicKey, "rules-pubkey", "", "PEM public key to verify the remote -rules policy signature")
+	fs.StringVar(&settings.RulesSignature, "rules-signature", "", "Signature file or URL for the remote -rules policy (default <url>.sig)")
+	return settings
+}
+
+// RegisterOutputFlags registers -quiet and -verbose, which tune how much a
+// blocking hook tells Claude, and -warn-only on fs. Check them with
+// ValidateOutput after parsing and block through Output.
+func RegisterOutputFlags(fs *flag.FlagSet, settings *Settings) {
+	fs.BoolVar(&settings.Quiet, "quiet", false, "Give a single-line block reason to keep Claude's context small")
+	fs.BoolVar(&settings.Verbose, "verbose", false, "List every issue, the matched commands, and rule descriptions when blocking")
+	fs.BoolVar(&settings.WarnOnly, "warn-only", false, "Allow blocked tool calls and show the block reason to the user as a warning")
+}
+
+// ValidateOutput reports output flags that cannot be combined.
+func (s *Settings) ValidateOutput() error {
+	if s.Quiet && s.Verbose {
+		return configError(errors.New("-quiet and -verbose cannot be combined"))
+	}
+	return nil
+}
+
+// Output returns how the hook writes blocks: the verbosity -quiet and
+// -verbose select, and -warn-only.
+func (s *Settings) Output() hook.Output {
+	output := hook.DefaultOutput()
+	output.WarnOnly = s.WarnOnly
+	switch {
+	case s.Quiet:
+		output.Verbosity = hook.VerbosityQuiet
+	case s.Verbose:
+		output.Verbosity = hook.VerbosityVerbose
+	}
+	return output
+}
+
+// BlockDecision is the audit decision for a tool call the hook blocks:
+// audit.DecisionWarn with -warn-only, else audit.DecisionBlock.
+func (s *Settings) BlockDecision() string {
+	if s.WarnOnly {
+		return audit.DecisionWarn
+	}
+	return audit.DecisionBlock
+}
+
+// Error kinds, recorded as the error_kind of the audit record of a tool call
+// a hook could not judge.
+const (
+	ErrorKindConfig      = "config"       // hook.ErrConfig: the hook is misconfigured
+	ErrorKindInputSchema = "input_schema" // hook.ErrInputSchema: the payload is malformed
+	ErrorKindParse       = "parse"        // detector.ErrParse: the command does not parse
+	ErrorKindTimeout     = "timeout"      // detector.ErrTimeout: analysis ran out of time
+	ErrorKindInternal    = "internal"     // Anything else, e.g. an unreadable file
+)
+
+// ErrorKind classifies an error that stopped a hook from judging a tool call.
+// Only parse and timeout errors concern the tool call itself; the others
+// mean the hook or its setup is at fault.
+func ErrorKind(err error) string {
+	switch {
+	case errors.Is(err, hook.ErrConfig):
+		return ErrorKindConfig
+	case errors.Is(err, hook.ErrInputSchema):
+		return ErrorKindInputSchema
+	case errors.Is(err, detector.ErrParse):
+		return ErrorKindParse
+	case errors.Is(err, detector.ErrTimeout):
+		return ErrorKindTimeout
+	}
+	return ErrorKindInternal
+}
+
+// FailureMessage returns message, the block message for err, with a note for
+// configuration errors, so Claude asks the user to fix the hook instead of
+// retrying the tool call in another form.
+func FailureMessage(message string, err error) string {
+	if errors.Is(err, hook.ErrConfig) {
+		return message + " (the hook configuration is invalid; ask the user to fix it)"
+	}
+	return message
+}
+
+// FailOpenExitCode is the exit code of a hook that allows a tool call it
+// could not judge, fail mode being open. Configuration errors exit with
+// hook.ExitNonBlockingError, so the warning reaches the user who can fix them;
+// other errors exit with hook.ExitSuccess.
+func FailOpenExitCode(err error) hook.ExitCode {
+	if errors.Is(err, hook.ErrConfig) {
+		return hook.ExitNonBlockingError
+	}
+	return hook.ExitSuccess
+}
+
+// configError marks err as a hook.ErrConfig.
+func configError(err error) error {
+	return hook.MarkError(err, hook.ErrConfig)
+}
+
+// nameList is a repeatable flag of names. Each value may also hold several
+// comma-separated names.
+type nameList []string
+
+func (l *nameList) String() string { return strings.Join(*l, ",") }
+
+func (l *nameList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*l = append(*l, name)
+		}
+	}
+	return nil
+}
+
+func (l *nameList) Repeatable() bool { return true }
+
+// RemoteOptions returns the verification options for a remote -rules policy.
+func (s *Settings) RemoteOptions() RemoteOptions {
+	return RemoteOptions{
+		SHA256:        s.RulesSHA256,
+		PublicKeyPath: s.RulesPublicKey,
+		Signature:     s.RulesSignature,
+	}
+}
+
+// InputOptions returns the payload validation options: strict when
+// -strict-input is set, with schema reports going to the logger.
+func (s *Settings) InputOptions() hook.InputOptions {
+	return hook.InputOptions{Strict: s.StrictInput, Logger: s.Logger()}
+}
+
+// Logger returns a stderr logger honoring the configured log level.
+func (s *Settings) Logger() *slog.Logger {
+	return newLogger(os.Stderr, s.LogLevel)
+}
+
+// newLogger creates a text logger at the named level, defaulting to warn.
+func newLogger(w io.Writer, level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelWarn
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: lvl}))
+}
@@ -0,0 +1,111 @@
+// Package config - rule severities and the decisions they map to
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Rule severities, from most to least severe.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+// Severities lists the rule severities from most to least severe.
+var Severities = []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+
+// Decisions a rule severity can map to, from strongest to weakest.
+const (
+	DecisionDeny = "deny" // Block the command
+	DecisionAsk  = "ask"  // Ask the user to confirm the command
+	DecisionWarn = "warn" // Allow the command, warn the user, and log it
+	DecisionLog  = "log"  // Allow the command and only log it, like a shadow rule
+)
+
+// Decisions lists the decisions from strongest to weakest.
+var Decisions = []string{DecisionDeny, DecisionAsk, DecisionWarn, DecisionLog}
+
+// DefaultSeverityDecisions maps each severity to its decision when a policy
+// does not set one under severities.
+var DefaultSeverityDecisions = map[string]string{
+	SeverityCritical: DecisionDeny,
+	SeverityHigh:     DecisionAsk,
+	SeverityMedium:   DecisionWarn,
+	SeverityLow:      DecisionLog,
+}
+
+// Decision returns what happens when rule matches: the decision its severity
+// maps to, DecisionLog for a shadow rule (enforce: false), and DecisionDeny
+// for a rule without a severity.
+func (p *Policy) Decision(rule Rule) string {
+	switch {
+	case rule.Shadow():
+		return DecisionLog
+	case rule.Severity == "":
+		return DecisionDeny
+	}
+	if decision, ok := p.Severities[rule.Severity]; ok {
+		return decision
+	}
+	return DefaultSeverityDecisions[rule.Severity]
+}
+
+// severityDecision returns the decision p maps severity to.
+func (p *Policy) severityDecision(severity string) string {
+	return p.Decision(Rule{Severity: severity})
+}
+
+// strongerDecision returns the stronger of two decisions.
+func strongerDecision(a, b string) string {
+	if slices.Index(Decisions, b) < slices.Index(Decisions, a) {
+		return b
+	}
+	return a
+}
+
+// validateSeverities reports unknown severities and decisions.
+func (p *Policy) validateSeverities() []error {
+	var errs []error
+	for _, severity := range slices.Sorted(maps.Keys(p.Severities)) {
+		if !slices.Contains(Severities, severity) {
+			errs = append(errs, fmt.Errorf("severities: unknown severity %q (want one of %s)", severity, strings.Join(Severities, ", ")))
+		}
+		if decision := p.Severities[severity]; !slices.Contains(Decisions, decision) {
+			errs = append(errs, fmt.Errorf("severities: %s: unknown decision %q (want one of %s)", severity, decision, strings.Join(Decisions, ", ")))
+		}
+	}
+	for i, rule := range p.Rules {
+		if rule.Severity == "" {
+			continue
+		}
+		if !slices.Contains(Severities, rule.Severity) {
+			errs = append(errs, fmt.Errorf("rule %d (%s): unknown severity %q (want one of %s)", i+1, rule.Name, rule.Severity, strings.Join(Severities, ", ")))
+		}
+		if len(rule.Redirects) > 0 || len(rule.SSHHosts) > 0 {
+			errs = append(errs, fmt.Errorf("rule %d (%s): severity applies to command rules only, not redirects or ssh_hosts", i+1, rule.Name))
+		}
+	}
+	return errs
+}
+
+// mergeSeverities returns the severity mapping of merged layers: each
+// severity maps to the strongest decision of any layer, so a more specific
+// layer cannot weaken a more general one. It is nil when no layer sets one.
+func mergeSeverities(policies []*Policy) map[string]string {
+	if !slices.ContainsFunc(policies, func(p *Policy) bool { return len(p.Severities) > 0 }) {
+		return nil
+	}
+	merged := make(map[string]string, len(Severities))
+	for _, severity := range Severities {
+		merged[severity] = DecisionLog
+		for _, policy := range policies {
+			merged[severity] = strongerDecision(merged[severity], policy.severityDecision(severity))
+		}
+	}
+	return merged
+}
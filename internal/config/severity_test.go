@@ -0,0 +1,99 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPolicy_DecisionRules(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`severities:
+  medium: ask
+rules:
+  - name: plain
+    command: rm
+  - name: critical
+    command: git
+    severity: critical
+  - name: high
+    command: kubectl
+    severity: high
+  - name: medium
+    command: docker
+    severity: medium
+  - name: low
+    command: curl
+    severity: low
+  - name: shadow
+    command: terraform
+    enforce: false
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+
+	names := func(decision string) []string {
+		var names []string
+		for _, rule := range policy.DecisionRules(time.Now(), decision) {
+			names = append(names, rule.Name)
+		}
+		return names
+	}
+	want := map[string][]string{
+		DecisionDeny: {"plain", "critical"},
+		DecisionAsk:  {"high", "medium"}, // medium is remapped from warn
+		DecisionWarn: nil,
+		DecisionLog:  {"low", "shadow"},
+	}
+	for decision, wantNames := range want {
+		if got := names(decision); !reflect.DeepEqual(got, wantNames) {
+			t.Errorf("DecisionRules(%s) = %v, want %v", decision, got, wantNames)
+		}
+	}
+	if got := len(policy.CommandRules(time.Now())); got != 2 {
+		t.Errorf("CommandRules() = %d rules, want the 2 denied", got)
+	}
+}
+
+func TestParsePolicy_InvalidSeverity(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Unknown severity", "rules:\n  - command: git\n    severity: urgent\n"},
+		{"Unknown decision", "severities:\n  high: block\n"},
+		{"Severity on redirects", "rules:\n  - redirects: [~/.bashrc]\n    severity: low\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePolicy([]byte(tt.content)); err == nil {
+				t.Error("ParsePolicy() should fail")
+			}
+		})
+	}
+}
+
+func TestMerge_Severities(t *testing.T) {
+	system := &Policy{Severities: map[string]string{SeverityHigh: DecisionDeny}}
+	project := &Policy{Severities: map[string]string{SeverityHigh: DecisionLog, SeverityLow: DecisionWarn}}
+
+	got := Merge([]Layer{{Policy: system}, {Policy: project}}).Severities
+	want := map[string]string{
+		SeverityCritical: DecisionDeny,
+		SeverityHigh:     DecisionDeny, // The project cannot weaken it
+		SeverityMedium:   DecisionWarn,
+		SeverityLow:      DecisionWarn, // The project can strengthen it
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge().Severities = %v, want %v", got, want)
+	}
+	if got := Merge([]Layer{{Policy: &Policy{}}}).Severities; got != nil {
+		t.Errorf("Merge().Severities = %v without severities, want nil", got)
+	}
+
+	// A policy extending another may weaken what it inherits
+	inherited := inherit(system, project).Severities
+	if inherited[SeverityHigh] != DecisionLog {
+		t.Errorf("inherit().Severities = %v, want the child mapping", inherited)
+	}
+}
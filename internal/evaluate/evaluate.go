@@ -0,0 +1,151 @@
+// Package evaluate runs the command detector behind a JSON-in, JSON-out API
+// for hosts that embed it as a WASM module or C shared library.
+package evaluate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/resultcache"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// defaultMaxRecursion matches bash-block's default analysis depth.
+const defaultMaxRecursion = 10
+
+// resultCacheSize bounds the results kept for commands evaluated again under
+// the same rules, as when Claude retries a blocked command.
+const resultCacheSize = 1024
+
+// results caches Evaluate results by rules and command. Hosts embedding the
+// detector are long-lived, so an in-memory cache serves every call.
+var results = resultcache.NewLRU[Result](resultCacheSize)
+
+// Config is the rules document passed to Evaluate. Rules use the policy file
+// format, e.g. {"rules": [{"command": "git", "patterns": ["push"]}]}.
+type Config struct {
+	Rules        []config.Rule `json:"rules"`
+	Presets      []string      `json:"presets,omitempty"`       // Built-in presets, e.g. git-push
+	Dialect      string        `json:"dialect,omitempty"`       // bash (default), posix, mksh, or bats
+	MaxRecursion int           `json:"max_recursion,omitempty"` // Default: 10
+	Cwd          string        `json:"cwd,omitempty"`           // Resolves relative redirect rules
+}
+
+// Result is the decision for one command.
+type Result struct {
+	Blocked      bool     `json:"blocked"`
+	Issues       []string `json:"issues,omitempty"`
+	Rules        []string `json:"rules,omitempty"`        // Rules that matched
+	RuleIndexes  []int    `json:"rule_indexes,omitempty"` // Positions of the matched rules among the rules evaluated: the presets' rules, then the active Config.Rules
+	Alternatives []string `json:"alternatives,omitempty"` // Suggested safe alternatives
+	Segments     []string `json:"segments,omitempty"`     // Blocked segments of a compound command
+	Error        string   `json:"error,omitempty"`        // Invalid rules; the command is blocked
+}
+
+// Evaluate decides whether command should be blocked under the rules in
+// rulesJSON. Invalid rules block the command and set Result.Error. Results
+// are cached, so the returned slices must not be modified.
+func Evaluate(rulesJSON, command string) Result {
+	commandDetector, rulesHash, err := newDetector(rulesJSON)
+	if err != nil {
+		return Result{Blocked: true, Error: err.Error()}
+	}
+	key := resultcache.Key(rulesHash, command)
+	if result, ok := results.Get(key); ok {
+		return result
+	}
+	result := evaluate(commandDetector, command)
+	results.Put(key, result)
+	return result
+}
+
+// evaluate runs the detector on command.
+func evaluate(commandDetector *detector.CommandDetector, command string) Result {
+	evaluation := commandDetector.Evaluate(command)
+	result := Result{Blocked: evaluation.Blocked}
+	if !result.Blocked {
+		return result
+	}
+	result.Issues = evaluation.Issues
+	result.RuleIndexes = evaluation.RuleIndexes
+	for _, rule := range evaluation.Rules {
+		result.Rules = append(result.Rules, rule.String())
+		if rule.Suggest != "" && !slices.Contains(result.Alternatives, rule.Suggest) {
+			result.Alternatives = append(result.Alternatives, rule.Suggest)
+		}
+	}
+	if segments, err := commandDetector.AnalyzeSegments(command); err == nil && len(segments) > 1 {
+		for _, segment := range segments {
+			if segment.Blocked {
+				result.Segments = append(result.Segments, segment.Command)
+			}
+		}
+	}
+	return result
+}
+
+// EvaluateJSON is Evaluate with the result encoded as JSON.
+func EvaluateJSON(rulesJSON, command string) string {
+	data, err := json.Marshal(Evaluate(rulesJSON, command))
+	if err != nil {
+		return `{"blocked":true,"error":"encoding result failed"}`
+	}
+	return string(data)
+}
+
+// newDetector builds a detector from the rules document, and returns it with
+// a hash of the rules in force and the detector settings.
+func newDetector(rulesJSON string) (*detector.CommandDetector, string, error) {
+	var cfg Config
+	decoder := json.NewDecoder(bytes.NewReader([]byte(rulesJSON)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, "", fmt.Errorf("invalid rules: %w", err)
+	}
+	policy := &config.Policy{Rules: cfg.Rules}
+	if err := policy.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	var rules []detector.CommandRule
+	var redirects []string
+	for _, name := range cfg.Presets {
+		preset, ok := detector.LookupPreset(name)
+		if !ok {
+			return nil, "", fmt.Errorf("unknown preset '%s'", name)
+		}
+		rules = append(rules, preset.Rules...)
+		redirects = append(redirects, preset.Redirects...)
+	}
+	now := time.Now()
+	rules = append(rules, policy.CommandRules(now)...)
+
+	maxRecursion := cfg.MaxRecursion
+	if maxRecursion <= 0 {
+		maxRecursion = defaultMaxRecursion
+	}
+	commandDetector := detector.NewCommandDetector(rules, maxRecursion)
+	if cfg.Dialect != "" {
+		lang, err := shellparse.ParseDialect(cfg.Dialect)
+		if err != nil {
+			return nil, "", err
+		}
+		commandDetector.SetDialect(lang)
+	}
+	redirects = append(redirects, policy.RedirectPatterns(now)...)
+	commandDetector.SetProtectedRedirects(cfg.Cwd, redirects)
+	sshHosts := policy.SSHHostPatterns(now)
+	commandDetector.SetProtectedHosts(sshHosts)
+
+	// Schedules decide which rules are in force, so hash those rather than rulesJSON
+	rulesHash, err := resultcache.RulesHash(rules, cfg.Presets, cfg.Dialect, maxRecursion, cfg.Cwd, redirects, sshHosts)
+	if err != nil {
+		return nil, "", err
+	}
+	return commandDetector, rulesHash, nil
+}
@@ -0,0 +1,107 @@
+package evaluate
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	const gitPush = `{"rules": [{"command": "git", "patterns": ["push"], "suggest": "open a pull request"}]}`
+
+	tests := []struct {
+		name      string
+		rulesJSON string
+		command   string
+		want      Result
+	}{
+		{"allowed", gitPush, "git status", Result{}},
+		{"blocked", gitPush, "git push origin main", Result{
+			Blocked:      true,
+			Issues:       []string{"Blocked git pattern detected"},
+			Rules:        []string{"git push"},
+			RuleIndexes:  []int{0},
+			Alternatives: []string{"open a pull request"},
+		}},
+		{"blocked segment", gitPush, "ls && git push", Result{
+			Blocked:      true,
+			Issues:       []string{"Blocked git pattern detected"},
+			Rules:        []string{"git push"},
+			RuleIndexes:  []int{0},
+			Alternatives: []string{"open a pull request"},
+			Segments:     []string{"git push"},
+		}},
+		{"preset", `{"rules": [], "presets": ["git-push"]}`, "git push", Result{
+			Blocked:      true,
+			Issues:       []string{"Blocked git push"},
+			Rules:        []string{"git-push"},
+			RuleIndexes:  []int{0},
+			Alternatives: []string{"use `git push --dry-run` to check the push, or ask the human to push"},
+		}},
+		{"rule order", `{"rules": [{"command": "git", "patterns": ["push"]}, {"command": "git", "patterns": ["reset --hard"]}]}`, "git reset --hard && git push", Result{
+			Blocked:     true,
+			Issues:      []string{"Blocked git pattern detected"},
+			Rules:       []string{"git reset --hard"},
+			RuleIndexes: []int{1},
+			Segments:    []string{"git reset --hard", "git push"},
+		}},
+		{"no rules", `{"rules": []}`, "rm -rf /", Result{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(tt.rulesJSON, tt.command)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate(%q) = %+v, want %+v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate_InvalidRulesBlock(t *testing.T) {
+	tests := []struct {
+		name      string
+		rulesJSON string
+		wantError string
+	}{
+		{"malformed JSON", `{"rules": [`, "invalid rules"},
+		{"unknown field", `{"rule": []}`, "invalid rules"},
+		{"unknown preset", `{"rules": [], "presets": ["nope"]}`, "unknown preset 'nope'"},
+		{"unknown dialect", `{"rules": [], "dialect": "zsh"}`, "zsh"},
+		{"rule without command", `{"rules": [{"patterns": ["push"]}]}`, "command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(tt.rulesJSON, "ls")
+			if !got.Blocked || !strings.Contains(got.Error, tt.wantError) {
+				t.Errorf("Evaluate() = %+v, want blocked with error containing %q", got, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestEvaluateJSON(t *testing.T) {
+	var got Result
+	if err := json.Unmarshal([]byte(EvaluateJSON(`{"rules": [{"command": "rm"}]}`, "rm -rf x")), &got); err != nil {
+		t.Fatalf("EvaluateJSON() returned invalid JSON: %v", err)
+	}
+	if !got.Blocked || len(got.Issues) == 0 {
+		t.Errorf("EvaluateJSON() = %+v, want blocked with issues", got)
+	}
+}
+
+func TestEvaluate_CachesResults(t *testing.T) {
+	const rulesJSON = `{"rules": [{"command": "terraform", "patterns": ["destroy"]}]}`
+	first := Evaluate(rulesJSON, "terraform destroy -auto-approve")
+	cached := results.Len()
+	if again := Evaluate(rulesJSON, "terraform destroy -auto-approve"); !reflect.DeepEqual(again, first) || results.Len() != cached {
+		t.Errorf("Evaluate() again = %+v with %d cached results, want %+v from the cache (%d)", again, results.Len(), first, cached)
+	}
+
+	// The same command under other rules is evaluated again
+	if got := Evaluate(`{"rules": [{"command": "terraform", "patterns": ["apply"]}]}`, "terraform destroy -auto-approve"); got.Blocked {
+		t.Errorf("Evaluate() under other rules = %+v, want allowed", got)
+	}
+}
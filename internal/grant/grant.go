@@ -0,0 +1,241 @@
+// Package grant records one-time approvals that let a human allow a single run
+// of a command a hook would otherwise block.
+//
+// A grant is a small JSON file named after the SHA-256 of the command, so the
+// grant directory holds no command text. Checking a grant consumes it: it
+// allows the next matching command only, and only before it expires.
+//
+// Each grant carries an HMAC made with a per-user key kept outside the grant
+// directory, so a grant file written by anything that cannot read the key,
+// such as Claude with self-protect guarding the key, allows nothing.
+package grant
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+)
+
+// DefaultTTL is how long a grant stays usable when no TTL is given.
+const DefaultTTL = 5 * time.Minute
+
+// record is the on-disk state of one grant.
+type record struct {
+	Expires time.Time `json:"expires"`
+	MAC     string    `json:"mac"` // HMAC-SHA256 of the token and expiry
+}
+
+// Store persists grants in a directory.
+type Store struct {
+	dir     string
+	keyPath string
+	now     func() time.Time
+}
+
+// NewStore creates a Store keeping grant files in dir, authenticated with
+// the key at keyPath. Grant creates the key when it does not exist yet.
+func NewStore(dir, keyPath string) *Store {
+	return &Store{dir: dir, keyPath: keyPath, now: time.Now}
+}
+
+// DefaultKeyPath returns the default grant key file, in the user config
+// directory next to the user policy.
+func DefaultKeyPath() string {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "claudecode-hooks", "grant.key")
+}
+
+// DefaultDir returns the default grant directory under the user cache directory.
+func DefaultDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "claudecode-hooks", "grants")
+}
+
+// Token identifies the grant for command: the hex SHA-256 of the command with
+// runs of whitespace collapsed, so "git push  origin" and "git push origin"
+// share a grant.
+func Token(command string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(command), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Grant allows command to run once within ttl and returns the grant's token
+// and expiry. Granting a command again replaces its earlier grant.
+func (s *Store) Grant(command string, ttl time.Duration) (string, time.Time, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", time.Time{}, errors.New("no command to grant")
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", time.Time{}, fmt.Errorf("creating grant directory: %w", err)
+	}
+
+	key, err := s.key(true)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token := Token(command)
+	now := s.now()
+	expires := now.Add(ttl).UTC()
+	data, err := json.Marshal(record{Expires: expires, MAC: mac(key, token, expires)})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("encoding grant: %w", err)
+	}
+
+	path := s.path(token)
+	unlock, err := utils.LockFile(path + ".lock")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer unlock()
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", time.Time{}, fmt.Errorf("writing grant: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", time.Time{}, fmt.Errorf("writing grant: %w", err)
+	}
+	s.cleanup(now)
+	return token, expires, nil
+}
+
+// Consume reports whether an unexpired, authentic grant exists for command,
+// removing it so it cannot be used again. It returns the grant's token when
+// one was used. Without a key no grant was ever made, so none is authentic.
+func (s *Store) Consume(command string) (string, bool, error) {
+	token := Token(command)
+	path := s.path(token)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+
+	unlock, err := utils.LockFile(path + ".lock")
+	if err != nil {
+		return "", false, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path) // #nosec G304 - path is derived from the grant directory
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil // Consumed by a concurrent hook
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("reading grant: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return "", false, fmt.Errorf("consuming grant: %w", err)
+	}
+
+	var grant record
+	if err := json.Unmarshal(data, &grant); err != nil {
+		return "", false, nil // A corrupt grant allows nothing
+	}
+	if !s.now().Before(grant.Expires) {
+		return "", false, nil
+	}
+	key, err := s.key(false)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if !hmac.Equal([]byte(grant.MAC), []byte(mac(key, token, grant.Expires))) {
+		return "", false, nil // Not written by hooks grant
+	}
+	return token, true, nil
+}
+
+// key reads the grant key, creating it first when create is set and it does
+// not exist yet.
+func (s *Store) key(create bool) ([]byte, error) {
+	data, err := os.ReadFile(s.keyPath) // #nosec G304 - path is the configured key file
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) < 32 {
+			return nil, fmt.Errorf("grant key %s is invalid", s.keyPath)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, fmt.Errorf("reading grant key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("creating grant key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.keyPath), 0o700); err != nil {
+		return nil, fmt.Errorf("creating grant key: %w", err)
+	}
+	// O_EXCL, so two hooks grant runs cannot each write their own key
+	f, err := os.OpenFile(s.keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) // #nosec G304 - path is the configured key file
+	if errors.Is(err, os.ErrExist) {
+		return s.key(false)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating grant key: %w", err)
+	}
+	_, err = f.WriteString(hex.EncodeToString(key) + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating grant key: %w", err)
+	}
+	return key, nil
+}
+
+// mac authenticates a grant: the hex HMAC-SHA256 of its token and expiry.
+func mac(key []byte, token string, expires time.Time) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(token + "\n" + expires.UTC().Format(time.RFC3339Nano)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// path maps a token to its grant file.
+func (s *Store) path(token string) string {
+	return filepath.Join(s.dir, token+".json")
+}
+
+// cleanup removes expired grants. Errors are ignored; an expired grant allows
+// nothing and only costs disk space.
+func (s *Store) cleanup(now time.Time) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		data, err := os.ReadFile(path) // #nosec G304 - path is within the grant directory
+		if err != nil {
+			continue
+		}
+		var grant record
+		if json.Unmarshal(data, &grant) != nil || !now.Before(grant.Expires) {
+			_ = os.Remove(path) //nolint:errcheck // Best-effort cleanup
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package grant
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	dir := t.TempDir()
+	return NewStore(filepath.Join(dir, "grants"), filepath.Join(dir, "config", "grant.key"))
+}
+
+func TestStore_GrantAndConsume(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	token, expires, err := store.Grant("git push origin main", time.Minute)
+	if err != nil {
+		t.Fatalf("Grant() error: %v", err)
+	}
+	if token != Token("git push origin main") || !expires.Equal(now.Add(time.Minute)) {
+		t.Errorf("Grant() = %s, %v", token, expires)
+	}
+
+	// The grant file is named after the hash and holds no command text
+	data, err := os.ReadFile(filepath.Join(store.dir, token+".json")) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), `{"expires":"2025-01-01T12:01:00Z","mac":"`) {
+		t.Errorf("grant file = %s", data)
+	}
+	if info, err := os.Stat(store.keyPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("grant key = %v, %v, want a file only the user can read", info, err)
+	}
+
+	if _, ok, err := store.Consume("git push origin dev"); err != nil || ok {
+		t.Errorf("Consume() for another command = %v, %v, want false", ok, err)
+	}
+	if got, ok, err := store.Consume("git  push origin main"); err != nil || !ok || got != token {
+		t.Errorf("Consume() = %s, %v, %v, want %s, true", got, ok, err, token)
+	}
+	if _, ok, err := store.Consume("git push origin main"); err != nil || ok {
+		t.Errorf("Consume() after use = %v, %v, want false", ok, err)
+	}
+}
+
+func TestStore_Expiry(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	if _, _, err := store.Grant("git push", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	if _, ok, err := store.Consume("git push"); err != nil || ok {
+		t.Errorf("Consume() after expiry = %v, %v, want false", ok, err)
+	}
+}
+
+func TestStore_GrantEmpty(t *testing.T) {
+	if _, _, err := newTestStore(t).Grant("  ", time.Minute); err == nil {
+		t.Error("Grant() of an empty command should fail")
+	}
+}
+
+func TestStore_Forged(t *testing.T) {
+	store := newTestStore(t)
+	forged := `{"expires":"2999-01-01T00:00:00Z"}`
+	plant := func(command, content string) {
+		t.Helper()
+		if err := os.MkdirAll(store.dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(store.dir, Token(command)+".json"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without a key no grant was ever made
+	plant("rm -rf /", forged)
+	if _, ok, err := store.Consume("rm -rf /"); err != nil || ok {
+		t.Errorf("Consume() of a planted grant without a key = %v, %v, want false", ok, err)
+	}
+
+	// With a key, a grant without a valid MAC, or one copied from another
+	// command, allows nothing
+	if _, _, err := store.Grant("git status", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	genuine, err := os.ReadFile(filepath.Join(store.dir, Token("git status")+".json")) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{forged, `{"expires":"2999-01-01T00:00:00Z","mac":"00"}`, string(genuine)} {
+		plant("rm -rf /", content)
+		if _, ok, err := store.Consume("rm -rf /"); err != nil || ok {
+			t.Errorf("Consume() of planted grant %s = %v, %v, want false", content, ok, err)
+		}
+	}
+}
@@ -0,0 +1,1164 @@
+// Package bashblock implements the bash-block hook, a bash command safety validator for Claude Code hooks
+package bashblock
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/grant"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/internal/metrics"
+	"github.com/krmcbride/claudecode-hooks/internal/notify"
+	"github.com/krmcbride/claudecode-hooks/internal/resultcache"
+	"github.com/krmcbride/claudecode-hooks/internal/tracing"
+	"github.com/krmcbride/claudecode-hooks/internal/transcript"
+	"github.com/krmcbride/claudecode-hooks/internal/version"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+const defaultMaxRecursion = 10
+
+// defaultDocsURL documents bash-block rules for JSON block reasons.
+const defaultDocsURL = "https://github.com/krmcbride/claudecode-hooks#bash-block"
+
+// Modes accepted by -default for commands no rule blocks.
+const (
+	defaultAllow = "allow" // Run anything not blocked
+	defaultDeny  = "deny"  // Block anything not allowlisted with -allow
+	defaultAsk   = "ask"   // Ask the user about anything not allowlisted
+)
+
+// Block reason formats accepted by -reason-format.
+const (
+	reasonText = "text" // Exit 2 with the reason on stderr
+	reasonJSON = "json" // Deny with a JSON reason in hookSpecificOutput
+)
+
+// gitConfigTimeout bounds reading git aliases so a slow repository never
+// stalls the hook.
+const gitConfigTimeout = 2 * time.Second
+
+// cmdFlag allows multiple -cmd flags to be specified
+type cmdFlag []string
+
+func (c *cmdFlag) String() string {
+	return strings.Join(*c, ", ")
+}
+
+func (c *cmdFlag) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+// Repeatable marks -cmd as a list flag for CLAUDE_HOOKS_CMD environment binding.
+func (c *cmdFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var commands cmdFlag
+	flag.Var(&commands, "cmd", "Command and optional patterns to block (can be specified multiple times)")
+	var presetNames cmdFlag
+	flag.Var(&presetNames, "preset", "Built-in rule preset to enable (can be specified multiple times)")
+	var allowSpecs cmdFlag
+	flag.Var(&allowSpecs, "allow", "Command and optional patterns to allow in -default deny or ask mode (can be specified multiple times)")
+	defaultMode := flag.String("default", defaultAllow, "Decision for commands no rule blocks: allow, deny, or ask")
+	askPrefix := flag.Bool("ask-prefix", false, "In -default ask mode, offer the allowed prefix of a compound command")
+	var disabledObfuscation cmdFlag
+	flag.Var(&disabledObfuscation, "disable-obfuscation", "Obfuscation detector to disable (can be specified multiple times)")
+	obfuscationThreshold := flag.Float64("obfuscation-threshold", detector.DefaultObfuscationThreshold, "Confidence (0-1) at which obfuscation findings block")
+
+	maxRecur := flag.String("max-recursion", strconv.Itoa(defaultMaxRecursion), "Max recursion depth")
+	dialect := flag.String("dialect", "bash", "Shell language commands are parsed as: bash, posix, mksh, or bats")
+	resolveVars := flag.Bool("resolve-vars", false, "Resolve variables assigned earlier in a command, as in GIT=git; $GIT push")
+	var envNames cmdFlag
+	flag.Var(&envNames, "env", "Environment variable to resolve in commands, e.g. HOME (can be specified multiple times; implies -resolve-vars)")
+	foreignSyntax := flag.Bool("foreign-syntax", false, "Translate common zsh and fish syntax; block what still does not parse only if it may run a blocked command")
+	reasonFormat := flag.String("reason-format", reasonText, "Block reason format: text or json")
+	docsURL := flag.String("docs-url", defaultDocsURL, "Documentation link included in JSON block reasons")
+	shadowWarn := flag.Bool("shadow-warn", false, "Show the user a warning when a shadow rule (enforce: false) matches, rather than only logging it")
+	blockMessage := flag.String("block-message", "", "Block message template, overriding the policy's block_message, e.g. '{{.Rule}} blocked: see https://wiki.example.com'")
+	allowOnce := flag.Bool("allow-once", false, "Allow a blocked command once when a human granted it with hooks grant")
+	grantDir := flag.String("grant-dir", grant.DefaultDir(), "Directory of hooks grant approvals")
+	grantKey := flag.String("grant-key", grant.DefaultKeyPath(), "Key file hooks grant signs approvals with")
+	useCache := flag.Bool("cache", false, "Cache blocks on disk so a retried command is not evaluated again")
+	cacheDir := flag.String("cache-dir", resultcache.DefaultDir(), "Directory of cached blocks")
+	maxIssues := flag.Int("max-issues", hook.DefaultMaxIssues, "Max issues listed in the block message (0 for no limit)")
+	nowFlag := flag.String("now", "", "Evaluate rule schedules at this RFC 3339 time (for testing)")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+	settings := config.RegisterFlags(flag.CommandLine, config.FailClosed)
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "bash-block"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "bash-block", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:           "bash-block",
+		Events:         []string{hook.EventPreToolUse},
+		Tools:          []string{"Bash"},
+		PolicySections: []string{"rules", "notifications"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	// Show help if requested. Without rules on the command line we still need
+	// the hook payload to discover a project policy, so only show usage when
+	// stdin is a terminal.
+	noRuleFlags := len(commands) == 0 && len(presetNames) == 0 && len(allowSpecs) == 0 && settings.RulesFile == ""
+	if *showHelp || (noRuleFlags && hookutil.StdinIsTerminal()) {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	// Parse max recursion
+	maxRecursion, err := strconv.Atoi(*maxRecur)
+	if err != nil || maxRecursion <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid max-recursion '%s'. Must be a positive integer\n", *maxRecur)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *defaultMode != defaultAllow && *defaultMode != defaultDeny && *defaultMode != defaultAsk {
+		fmt.Fprintf(os.Stderr, "Error: invalid default '%s'. Must be '%s', '%s', or '%s'\n", *defaultMode, defaultAllow, defaultDeny, defaultAsk)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	lang, err := shellparse.ParseDialect(*dialect)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	allowlist := detector.NewAllowlist(parseAllowRules(allowSpecs), maxRecursion)
+	allowlist.SetDialect(lang)
+	env := resolveEnv(envNames, *resolveVars)
+	allowlist.SetEnvironment(env)
+
+	if *reasonFormat != reasonText && *reasonFormat != reasonJSON {
+		fmt.Fprintf(os.Stderr, "Error: invalid reason-format '%s'. Must be '%s' or '%s'\n", *reasonFormat, reasonText, reasonJSON)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if *maxIssues < 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid max-issues '%d'. Must be zero or a positive integer\n", *maxIssues)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	output := settings.Output()
+	output.MaxIssues = *maxIssues
+	if _, err := hook.ParseMessageTemplate(*blockMessage); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid block-message: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	now, err := parseNow(*nowFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	presets, err := presetRules(presetNames)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	recorder := metrics.FromEnv("bash-block")
+	tracer := tracing.FromEnv("bash-block")
+	root := tracer.Start("bash-block", nil)
+	start := time.Now()
+
+	// Read PreToolUse hook input
+	readSpan := tracer.Start("read_input", root)
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	readSpan.End()
+	if err != nil {
+		readSpan.SetError()
+		recorder.ParseFailure()
+		recorder.Evaluation(time.Since(start), settings.FailMode == config.FailClosed)
+		flushTelemetry(recorder, tracer)
+		hookutil.FailInternal(settings, auditLog, "bash-block", "Failed to parse hook input", err)
+		return
+	}
+	root.SetAttribute("hook.tool_name", input.ToolName)
+	if tracer.RecordCommands() {
+		root.SetAttribute("hook.command", input.ToolInput.Command)
+	}
+
+	// Load command rules from -cmd flags and the system, user, -rules, and project policies
+	policy, err := loadPolicy(settings, input.Cwd)
+	if err != nil {
+		flushTelemetry(recorder, tracer)
+		hookutil.FailInternal(settings, auditLog, "bash-block", "Failed to load rules", err)
+		return
+	}
+	rules := append(presets, buildRules(commands, policy, now)...)
+	rules = dropUnconfiguredPresets(logger, rules, input.Cwd)
+	if *allowOnce {
+		// Claude must not be able to grant itself an exception
+		rules = append(rules, grantRules()...)
+	}
+	redirects := append(presetRedirects(presetNames), policy.RedirectPatterns(now)...)
+	if *allowOnce {
+		// Nor write grants or replace the key they are signed with
+		redirects = append(redirects, filepath.Join(*grantDir, "**"), *grantKey)
+	}
+	sshHosts := policy.SSHHostPatterns(now)
+	shadowRules := policy.ShadowRules(now)
+	askRules := policy.DecisionRules(now, config.DecisionAsk)
+	warnRules := policy.DecisionRules(now, config.DecisionWarn)
+	if len(rules) == 0 && len(redirects) == 0 && len(sshHosts) == 0 && len(shadowRules) == 0 &&
+		len(askRules) == 0 && len(warnRules) == 0 && *defaultMode == defaultAllow {
+		fmt.Fprintf(os.Stderr, "Error: no valid command rules specified\n")
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	// Create detector with configuration
+	commandDetector := detector.NewCommandDetector(rules, maxRecursion)
+	commandDetector.SetDialect(lang)
+	commandDetector.SetForeignSyntax(*foreignSyntax)
+	commandDetector.SetEnvironment(env)
+	commandDetector.SetObfuscationThreshold(*obfuscationThreshold)
+	for _, name := range disabledObfuscation {
+		if !commandDetector.DisableObfuscationDetector(name) {
+			fmt.Fprintf(os.Stderr, "Error: unknown obfuscation detector '%s' (want one of %s)\n", name, obfuscationDetectorNames())
+			hook.Exit(hook.ExitNonBlockingError)
+		}
+	}
+	if tracer.Enabled() {
+		commandDetector.SetStageObserver(tracer.StageObserver(root))
+	}
+	loadTranscriptContext(logger, commandDetector, rules, input.TranscriptPath)
+	aliases := loadGitAliases(logger, commandDetector, rules, input.Cwd)
+	commandDetector.SetProtectedRedirects(input.Cwd, redirects)
+	commandDetector.SetProtectedHosts(sshHosts)
+
+	// Shadow rules are being trialled: what they would block is recorded, never
+	// enforced. Rules whose severity maps to ask or warn get their own detectors
+	// too, so a block is never hidden behind them.
+	ruleDetector := func(rules []detector.CommandRule) *detector.CommandDetector {
+		if len(rules) == 0 {
+			return nil
+		}
+		ruleDetector := detector.NewCommandDetector(rules, maxRecursion)
+		ruleDetector.SetDialect(lang)
+		ruleDetector.SetForeignSyntax(*foreignSyntax)
+		ruleDetector.SetEnvironment(env)
+		return ruleDetector
+	}
+	shadowDetector := ruleDetector(shadowRules)
+	askDetector, warnDetector := ruleDetector(askRules), ruleDetector(warnRules)
+
+	// Check if expression should be blocked, unless it was blocked before
+	command := input.ToolInput.Command
+	var cache *resultcache.Disk[evaluation]
+	var cacheKey string
+	if *useCache {
+		cacheKey, err = blockCacheKey(rules, shadowRules, command,
+			version.Get(), *dialect, maxRecursion, *foreignSyntax, env, *obfuscationThreshold, disabledObfuscation,
+			input.Cwd, redirects, sshHosts, aliases, *defaultMode, allowSpecs)
+		if err != nil {
+			logger.Warn("not caching blocks", "error", err)
+		} else if cacheKey != "" {
+			cache = resultcache.NewDisk[evaluation](*cacheDir, resultcache.DefaultDiskEntries)
+		}
+	}
+	result, cached := cachedBlock(cache, cacheKey)
+	if cached {
+		logger.Debug("using cached block", "command", command)
+	} else {
+		result = evaluateCommand(commandDetector, shadowDetector, allowlist, *defaultMode, command)
+		if cache != nil && result.Blocked {
+			if err := cache.Put(cacheKey, result); err != nil {
+				logger.Warn("failed to cache block", "error", err)
+			}
+		}
+	}
+	if result.ParseFailed {
+		recorder.ParseFailure()
+	}
+	if len(result.ShadowRules) > 0 {
+		recorder.ShadowMatch()
+		logger.Info("shadow rule would block", "rules", result.ShadowRules, "command", command)
+	}
+	blocked, issues, unlisted := result.Blocked, result.Issues, result.Unlisted
+
+	// A human may have allowed this exact command once with "hooks grant"
+	var reason string
+	if blocked && *allowOnce {
+		if token, ok := consumeGrant(logger, *grantDir, *grantKey, command); ok {
+			blocked = false
+			reason = "Allowed once by grant " + token[:12]
+		}
+	}
+	asked := !blocked && reason == "" && len(unlisted) > 0
+	if asked {
+		reason = "Asked: command not in allowlist"
+		issues = unlisted
+	}
+
+	// Rules whose severity maps to ask or warn apply when nothing else decided
+	var severityRules []string
+	warned := false
+	if !blocked && reason == "" {
+		if matched, matchedIssues := severityMatches(askDetector, command); len(matched) > 0 {
+			asked, reason, issues, severityRules = true, "Asked: rule severity", matchedIssues, matched
+		} else if matched, matchedIssues := severityMatches(warnDetector, command); len(matched) > 0 {
+			warned, reason, issues, severityRules = true, "Warned: rule severity", matchedIssues, matched
+			logger.Warn("rule severity warning", "rules", matched, "command", command)
+		} else if *shadowWarn && len(result.ShadowRules) > 0 {
+			warned, reason, severityRules = true, "Warned: shadow rule", result.ShadowRules
+			issues = []string{"Shadow rule would block: " + command}
+		}
+	}
+	recorder.Evaluation(time.Since(start), blocked)
+
+	decisionSpan := tracer.Start("decision", root)
+	decision := audit.DecisionAllow
+	blockingRules := severityRules
+	var blockingIndexes []int
+	switch {
+	case blocked:
+		decision = settings.BlockDecision()
+		blockingRules, blockingIndexes = result.Rules, result.RuleIndexes
+	case warned:
+		decision = audit.DecisionWarn
+	}
+	decisionSpan.SetAttribute("hook.decision", decision)
+	decisionSpan.SetAttribute("hook.issue_count", len(issues))
+	root.SetAttribute("hook.decision", decision)
+	decisionSpan.End()
+	flushTelemetry(recorder, tracer)
+
+	logger.Debug("evaluated command", "decision", decision, "issues", issues)
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:        "bash-block",
+		Event:       hook.EventPreToolUse,
+		SessionID:   input.SessionID,
+		ToolName:    input.ToolName,
+		Decision:    decision,
+		Reason:      reason,
+		Issues:      issues,
+		Rules:       blockingRules,
+		RuleIndexes: blockingIndexes,
+		ShadowRules: result.ShadowRules,
+		Command:     command,
+	})
+
+	if blocked {
+		notifyBlock(logger, policy, notify.Event{
+			Hook:      "bash-block",
+			Decision:  decision,
+			SessionID: input.SessionID,
+			ToolName:  input.ToolName,
+			Cwd:       input.Cwd,
+			Reason:    "Blocked command detected!",
+			Issues:    issues,
+		})
+		block := blockReason(result, issues, *docsURL)
+		block.Segments = result.Segments
+		if text := messageTemplate(result.Messages, *blockMessage, policy.BlockMessage); text != "" {
+			message, err := hook.RenderMessage(text, "bash-block", command, block)
+			switch {
+			case err != nil:
+				logger.Warn("using the default block message", "error", err)
+			case *reasonFormat == reasonJSON:
+				block.Message = message
+			default:
+				output.BlockPreToolUseMessage(message)
+				return
+			}
+		}
+		if *reasonFormat == reasonJSON {
+			output.DenyPreToolUse(block)
+			return
+		}
+		output.BlockPreToolUseReason(block)
+		return
+	}
+	source := "bash-block"
+	if len(severityRules) > 0 {
+		source += " (" + strings.Join(severityRules, ", ") + ")"
+	}
+	if warned {
+		hook.WarnPreToolUse("⚠️ " + source + ": " + strings.Join(hook.SummarizeIssues(issues, output.MaxIssues), "; "))
+		return
+	}
+	if asked {
+		askReason := source + ": " + strings.Join(hook.SummarizeIssues(issues, output.MaxIssues), "; ")
+		if *askPrefix && len(unlisted) > 0 {
+			if prefix := detector.AllowedPrefix(analyzeSegments(commandDetector, allowlist, true, command)); prefix != "" {
+				askReason += ". The allowed prefix can run on its own: " + prefix
+			}
+		}
+		output.DecidePreToolUse(hook.PermissionAsk, askReason)
+		return
+	}
+
+	// Allow execution if no issues found
+	hook.AllowPreToolUse()
+}
+
+// loadPolicy loads the system and user policies, the -rules source, and, when
+// discovery is enabled, the project .claudehooks.yaml found by walking up from
+// cwd, and merges them into the effective policy.
+func loadPolicy(settings *config.Settings, cwd string) (*config.Policy, error) {
+	layers, err := config.LoadLayers(context.Background(), settings, cwd)
+	if err != nil {
+		return nil, err
+	}
+	return config.Merge(layers), nil
+}
+
+// buildRules combines rules from -cmd flags with the policy rules whose
+// schedule is active at now.
+func buildRules(commands []string, policy *config.Policy, now time.Time) []detector.CommandRule {
+	return append(parseCommandRules(commands), policy.CommandRules(now)...)
+}
+
+// evaluation is what the rules, the allowlist, and the shadow rules decided
+// about a command, before grants are applied. With -cache, blocks are kept
+// on disk and reused when Claude retries the command.
+type evaluation struct {
+	Blocked      bool     `json:"blocked"`
+	ParseFailed  bool     `json:"parse_failed,omitempty"`
+	Issues       []string `json:"issues,omitempty"`
+	Rules        []string `json:"rules,omitempty"`        // Names of the rules that blocked
+	RuleIndexes  []int    `json:"rule_indexes,omitempty"` // Positions of the rules that blocked, in the evaluated rules
+	Alternatives []string `json:"alternatives,omitempty"` // Suggestions of the rules that blocked
+	Unlisted     []string `json:"unlisted,omitempty"`     // Commands -default deny or ask applies to
+	Segments     []string `json:"segments,omitempty"`     // Blocked segments of a compound command
+	ShadowRules  []string `json:"shadow_rules,omitempty"` // Shadow rules that would have blocked
+	Messages     []string `json:"messages,omitempty"`     // Message templates of the rules that blocked
+	Matched      []string `json:"matched,omitempty"`      // Commands the rules matched, for -verbose
+	Descriptions []string `json:"descriptions,omitempty"` // "rule: description" of the rules that blocked, for -verbose
+}
+
+// evaluateCommand checks command against the rules, the shadow rules when
+// shadowDetector is set, and, outside -default allow mode, the allowlist.
+func evaluateCommand(commandDetector, shadowDetector *detector.CommandDetector, allowlist *detector.Allowlist, defaultMode, command string) evaluation {
+	result := evaluation{Blocked: commandDetector.ShouldBlockShellExpr(command)}
+	result.ParseFailed = commandDetector.ParseFailed()
+	result.Issues = commandDetector.GetIssues()
+	if result.Blocked {
+		result.Rules = ruleNames(commandDetector)
+		result.RuleIndexes = commandDetector.MatchedRuleIndexes()
+		for _, rule := range commandDetector.MatchedRules() {
+			if rule.Suggest != "" && !slices.Contains(result.Alternatives, rule.Suggest) {
+				result.Alternatives = append(result.Alternatives, rule.Suggest)
+			}
+			if rule.Message != "" && !slices.Contains(result.Messages, rule.Message) {
+				result.Messages = append(result.Messages, rule.Message)
+			}
+			if description := ruleDescription(rule); description != "" && !slices.Contains(result.Descriptions, description) {
+				result.Descriptions = append(result.Descriptions, description)
+			}
+		}
+		result.Matched = commandDetector.MatchedCommands()
+	}
+	if shadowDetector != nil {
+		result.ShadowRules = shadowMatches(shadowDetector, command)
+	}
+
+	// In default-deny mode every command must also be allowlisted
+	if !result.Blocked && defaultMode != defaultAllow {
+		result.Unlisted = unlistedCommands(allowlist, command)
+		if defaultMode == defaultDeny && len(result.Unlisted) > 0 {
+			result.Blocked = true
+			result.Issues = append(result.Issues, result.Unlisted...)
+		}
+	}
+	if result.Blocked {
+		result.Segments = blockedSegments(analyzeSegments(commandDetector, allowlist, defaultMode != defaultAllow, command))
+	}
+	return result
+}
+
+// cachedBlock returns the cached block for key. Only blocks are cached, so
+// any other entry, such as one planted in the cache directory to allow a
+// command, is a miss and the command is evaluated again.
+func cachedBlock(cache *resultcache.Disk[evaluation], key string) (evaluation, bool) {
+	if cache == nil {
+		return evaluation{}, false
+	}
+	result, ok := cache.Get(key)
+	if !ok || !result.Blocked {
+		return evaluation{}, false
+	}
+	return result, true
+}
+
+// blockCacheKey returns the key of command's cached block under the rules and
+// settings, everything else that affects evaluation. It returns "" when the
+// rules include conditional ones, which depend on the session as well.
+func blockCacheKey(rules, shadowRules []detector.CommandRule, command string, settings ...any) (string, error) {
+	if slices.ContainsFunc(rules, func(rule detector.CommandRule) bool { return rule.AllowAfter != nil }) {
+		return "", nil
+	}
+	shadowHash, err := resultcache.RulesHash(shadowRules)
+	if err != nil {
+		return "", err
+	}
+	rulesHash, err := resultcache.RulesHash(rules, append(settings, shadowHash)...)
+	if err != nil {
+		return "", err
+	}
+	return resultcache.Key(rulesHash, command), nil
+}
+
+// shadowMatches returns the names of the rules of shadowDetector that match
+// command, without duplicates.
+func shadowMatches(shadowDetector *detector.CommandDetector, command string) []string {
+	if !shadowDetector.ShouldBlockShellExpr(command) {
+		return nil
+	}
+	return ruleNames(shadowDetector)
+}
+
+// severityMatches evaluates command against the rules of severityDetector,
+// if any, and returns the names of the rules that match, without duplicates,
+// and the issues they report.
+func severityMatches(severityDetector *detector.CommandDetector, command string) (rules, issues []string) {
+	if severityDetector == nil || !severityDetector.ShouldBlockShellExpr(command) {
+		return nil, nil
+	}
+	return ruleNames(severityDetector), severityDetector.GetIssues()
+}
+
+// ruleNames returns the names of the rules that matched in the last
+// evaluation of commandDetector, without duplicates.
+func ruleNames(commandDetector *detector.CommandDetector) []string {
+	var names []string
+	for _, rule := range commandDetector.MatchedRules() {
+		if name := rule.String(); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// presetRules returns the rules of the named built-in presets.
+func presetRules(names []string) ([]detector.CommandRule, error) {
+	var rules []detector.CommandRule
+	for _, name := range names {
+		preset, ok := detector.LookupPreset(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown preset '%s' (see -help for the list)", name)
+		}
+		rules = append(rules, preset.Rules...)
+	}
+	return rules, nil
+}
+
+// presetRedirects returns the protected redirect targets of the named
+// built-in presets. Unknown names are reported by presetRules.
+func presetRedirects(names []string) []string {
+	var redirects []string
+	for _, name := range names {
+		if preset, ok := detector.LookupPreset(name); ok {
+			redirects = append(redirects, preset.Redirects...)
+		}
+	}
+	return redirects
+}
+
+// messageTemplate returns the block message template to use: the message of
+// the first blocking rule that has one, else -block-message, else the policy
+// block_message. It returns "" for the default message.
+func messageTemplate(ruleMessages []string, flagMessage, policyMessage string) string {
+	if len(ruleMessages) > 0 {
+		return ruleMessages[0]
+	}
+	if flagMessage != "" {
+		return flagMessage
+	}
+	return policyMessage
+}
+
+// blockReason describes a block: the issues found and the detector rules that
+// matched.
+func blockReason(result evaluation, issues []string, docsURL string) hook.BlockReason {
+	return hook.BlockReason{
+		Message:      "Blocked command detected!",
+		Rules:        result.Rules,
+		RuleIndexes:  result.RuleIndexes,
+		Issues:       issues,
+		Alternatives: result.Alternatives,
+		Docs:         docsURL,
+		Matched:      result.Matched,
+		Descriptions: result.Descriptions,
+	}
+}
+
+// ruleDescription returns "name: description" for a rule with a description.
+func ruleDescription(rule detector.CommandRule) string {
+	switch {
+	case rule.Description == "":
+		return ""
+	case rule.Name == "":
+		return rule.Description
+	}
+	return rule.Name + ": " + rule.Description
+}
+
+// analyzeSegments decides each top-level segment of a compound command on its
+// own, against the rules and, when checkAllowlist is set, the allowlist. It
+// returns nil for a single command or one that cannot be parsed.
+func analyzeSegments(commandDetector *detector.CommandDetector, allowlist *detector.Allowlist, checkAllowlist bool, command string) []detector.SegmentResult {
+	results, err := commandDetector.AnalyzeSegments(command)
+	if err != nil || len(results) < 2 {
+		return nil
+	}
+	if checkAllowlist {
+		for i, result := range results {
+			if unlisted := unlistedCommands(allowlist, result.Command); !result.Blocked && len(unlisted) > 0 {
+				results[i].Blocked = true
+				results[i].Issues = unlisted
+			}
+		}
+	}
+	return results
+}
+
+// blockedSegments returns the commands of the blocked segments.
+func blockedSegments(results []detector.SegmentResult) []string {
+	var segments []string
+	for _, result := range results {
+		if result.Blocked {
+			segments = append(segments, result.Command)
+		}
+	}
+	return segments
+}
+
+// resolveEnv snapshots the -env variables for resolving commands. It returns
+// nil, leaving every variable dynamic, unless resolution is enabled.
+func resolveEnv(names []string, resolveVars bool) map[string]string {
+	if len(names) == 0 && !resolveVars {
+		return nil
+	}
+	env := make(map[string]string, len(names))
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			env[name] = value
+		}
+	}
+	return env
+}
+
+// parseAllowRules parses -allow specs, which use the -cmd format.
+func parseAllowRules(specs []string) []detector.AllowRule {
+	var rules []detector.AllowRule
+	for _, spec := range specs {
+		if rule, ok := detector.ParseAllowSpec(spec); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// unlistedCommands returns the commands -default deny or ask applies to. A
+// command that cannot be parsed is unlisted as a whole.
+func unlistedCommands(allowlist *detector.Allowlist, command string) []string {
+	unlisted, err := allowlist.Unlisted(command)
+	if err != nil {
+		return []string{"Unable to parse command to check the allowlist: " + err.Error()}
+	}
+	return unlisted
+}
+
+// grantRules block "hooks grant" so that only a human, outside Claude Code,
+// can create grants. The hooks binary may be installed under a prefix, as in
+// krmcbride-hooks.
+func grantRules() []detector.CommandRule {
+	match := func(inv detector.Invocation) string {
+		if inv.Subcommand(0) == "grant" {
+			return "Grants must be created by a human, outside Claude Code"
+		}
+		return ""
+	}
+	suggest := "ask the human to run hooks grant in their own terminal"
+	return []detector.CommandRule{
+		{Name: "allow-once", BlockedCommand: "hooks", Match: match, Suggest: suggest},
+		{Name: "allow-once", BlockedCommand: "krmcbride-hooks", Match: match, Suggest: suggest},
+	}
+}
+
+// consumeGrant uses up an authentic grant for command, if one exists. Errors
+// are logged and leave the command blocked.
+func consumeGrant(logger *slog.Logger, dir, keyPath, command string) (string, bool) {
+	token, ok, err := grant.NewStore(dir, keyPath).Consume(command)
+	if err != nil {
+		logger.Warn("failed to check grants", "error", err)
+		return "", false
+	}
+	return token, ok
+}
+
+// parseNow parses the -now override, defaulting to the current time.
+func parseNow(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	now, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -now '%s': want an RFC 3339 time such as 2025-01-31T18:30:00-05:00", value)
+	}
+	return now, nil
+}
+
+// loadTranscriptContext gives the detector the session history when a rule
+// depends on it. Without context, conditional rules stay enforced.
+func loadTranscriptContext(logger *slog.Logger, commandDetector *detector.CommandDetector, rules []detector.CommandRule, transcriptPath string) {
+	needed := slices.ContainsFunc(rules, func(rule detector.CommandRule) bool {
+		return rule.AllowAfter != nil
+	})
+	if !needed || transcriptPath == "" {
+		return
+	}
+	recent, err := recentCommands(transcriptPath)
+	if err != nil {
+		logger.Warn("failed to read transcript", "error", err)
+		return
+	}
+	commandDetector.SetRecentCommands(recent)
+}
+
+// loadGitAliases gives the detector the git aliases configured for cwd, so a
+// flag-aware git rule also matches "git p" when alias.p is push, and returns
+// them. Without git, aliases are simply not expanded.
+func loadGitAliases(logger *slog.Logger, commandDetector *detector.CommandDetector, rules []detector.CommandRule, cwd string) map[string]string {
+	needed := slices.ContainsFunc(rules, func(rule detector.CommandRule) bool {
+		return rule.BlockedCommand == "git" && rule.Match != nil
+	})
+	if !needed {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), gitConfigTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "config", "--get-regexp", `^alias\.`)
+	cmd.Dir = cwd
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		// Exit status 1 only means no aliases are configured
+		logger.Debug("failed to read git aliases", "error", err)
+		return nil
+	}
+	aliases := parseGitAliases(string(output))
+	commandDetector.SetAliases("git", aliases)
+	return aliases
+}
+
+// dropUnconfiguredPresets removes the rules of presets that only apply to
+// repositories with certain git config, such as git-signing, when the
+// repository at cwd does not have it.
+func dropUnconfiguredPresets(logger *slog.Logger, rules []detector.CommandRule, cwd string) []detector.CommandRule {
+	for _, preset := range detector.Presets() {
+		fromPreset := func(rule detector.CommandRule) bool {
+			return rule.Name == preset.Name && rule.Match != nil
+		}
+		if len(preset.RequiresGitConfig) == 0 || !slices.ContainsFunc(rules, fromPreset) {
+			continue
+		}
+		if !gitConfigEnabled(logger, cwd, preset.RequiresGitConfig) {
+			logger.Debug("preset does not apply to this repository", "preset", preset.Name, "requires", preset.RequiresGitConfig)
+			rules = slices.DeleteFunc(rules, fromPreset)
+		}
+	}
+	return rules
+}
+
+// gitConfigEnabled reports whether one of the boolean git config variables
+// is true for cwd. Without git, or outside a repository with none of them
+// set globally, none is.
+func gitConfigEnabled(logger *slog.Logger, cwd string, keys []string) bool {
+	for _, key := range keys {
+		ctx, cancel := context.WithTimeout(context.Background(), gitConfigTimeout)
+		cmd := exec.CommandContext(ctx, "git", "config", "--type=bool", "--get", key)
+		cmd.Dir = cwd
+		output, err := cmd.Output()
+		cancel()
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+			// Exit status 1 only means the variable is not set
+			logger.Debug("failed to read git config", "key", key, "error", err)
+		}
+		if strings.TrimSpace(string(output)) == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGitAliases parses "git config --get-regexp ^alias\." output, one
+// "alias.NAME expansion" per line.
+func parseGitAliases(output string) map[string]string {
+	aliases := make(map[string]string)
+	for line := range strings.Lines(output) {
+		key, expansion, _ := strings.Cut(strings.TrimRight(line, "\n"), " ")
+		if name, ok := strings.CutPrefix(key, "alias."); ok && name != "" {
+			aliases[name] = expansion
+		}
+	}
+	return aliases
+}
+
+// recentCommands reads the session transcript into detector context.
+func recentCommands(transcriptPath string) ([]detector.RecentCommand, error) {
+	calls, err := transcript.ReadToolCalls(transcriptPath)
+	if err != nil {
+		return nil, err
+	}
+	recent := make([]detector.RecentCommand, 0, len(calls))
+	for _, call := range calls {
+		recent = append(recent, detector.RecentCommand{
+			Command:   call.Command(),
+			Succeeded: call.Succeeded(),
+		})
+	}
+	return recent, nil
+}
+
+// notifyBlock sends the block event to every webhook configured in the policy.
+// Failures are logged but never affect the decision.
+func notifyBlock(logger *slog.Logger, policy *config.Policy, event notify.Event) {
+	for _, url := range policy.Notifications.URLs() {
+		if err := notify.NewWebhook(url).Send(context.Background(), event); err != nil {
+			logger.Warn("failed to send notification", "error", err)
+		}
+	}
+}
+
+// flushTelemetry emits recorded metrics and spans. Failures are reported but
+// never affect the decision.
+func flushTelemetry(recorder *metrics.Recorder, tracer *tracing.Tracer) {
+	if err := recorder.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to emit metrics: %v\n", err)
+	}
+	if err := tracer.Flush(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to export traces: %v\n", err)
+	}
+}
+
+// parseCommandRules parses -cmd flag values into CommandRule structs
+func parseCommandRules(commands []string) []detector.CommandRule {
+	var rules []detector.CommandRule
+	for _, cmd := range commands {
+		if rule, ok := detector.ParseCommandSpec(cmd); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `bash-block: Bash command blocker for Claude Code hooks
+
+Provides an additional layer of safety on top of Claude Code's built-in deny permissions.
+Blocks commands including through variables, subshells, eval, obfuscation, etc.
+
+USAGE:
+    bash-block -cmd COMMAND_SPEC [-cmd COMMAND_SPEC ...] [OPTIONS]
+    bash-block -preset NAME [-preset NAME ...] [OPTIONS]
+    bash-block -rules POLICY_FILE [OPTIONS]
+    bash-block -default deny -allow COMMAND_SPEC [-allow COMMAND_SPEC ...] [OPTIONS]
+
+RULES (from -cmd, -preset, -rules, and policy files; all sources are combined):
+    Policy files are loaded from /etc/claudecode-hooks/policy.yaml (system),
+    ~/.config/claudecode-hooks/policy.yaml (user), and a discovered
+    .claudehooks.yaml (project). Rules from every layer are enforced, so a
+    project policy can add rules but never remove system or user rules.
+
+    -cmd string
+            Command and optional patterns to block (can be specified multiple times)
+            Format: "command [pattern1] [pattern2] ..."
+            
+            Examples:
+              -cmd git                    Block all git commands
+              -cmd "git push"             Block only git push
+              -cmd "git push pull"        Block git push and git pull
+              -cmd "aws delete-*"         Block aws delete-* commands
+              -cmd kubectl                Block all kubectl commands
+
+    -preset string
+            Built-in, flag-aware rule set (can be specified multiple times).
+            Preset rules parse the command's flags and expand its aliases
+            (for git, from git config alias.*), so "git -C dir push" and
+            "git p" with alias.p=push are both caught:
+%s
+    -rules string
+            YAML or JSON policy file with additional rules:
+              rules:
+                - command: git
+                  patterns: [push]
+                  allow_after:        # optional: lift the rule when the
+                    command: go test  # transcript shows this command
+                    within: 10        # succeeded in the last 10 tool calls
+                - redirects: [~/.bashrc, /etc/**]  # block > and >> to these paths
+                - ssh_hosts: [prod-*]  # block inline ssh commands to these hosts
+            May also be an https:// URL or oci://registry/repo:tag artifact.
+            Rules may carry a schedule to enforce them only in certain time windows:
+                  schedule:
+                    timezone: America/New_York
+                    enforce_outside: [{days: [mon-fri], hours: "09:00-17:00"}]
+                    enforce_during: [{from: 2025-12-20, to: 2026-01-02}]
+            Remote policies are cached for an hour and used offline.
+
+    -rules-sha256 string
+            Pin the remote policy to this SHA-256 checksum
+
+    -rules-pubkey string
+            PEM public key; the remote policy must carry a valid cosign
+            sign-blob signature (fetched from <url>.sig)
+
+    -rules-signature string
+            Signature file or URL (required for oci:// with -rules-pubkey)
+
+DEFAULT-DENY MODE:
+    -default string
+            Decision for commands that no rule blocks: allow, deny (block
+            anything not allowlisted), or ask (ask the user about anything not
+            allowlisted) (default: allow). Every command of a compound
+            command, pipeline, or substitution must be allowlisted, as must
+            the commands in scripts run by an allowlisted sh -c or eval.
+
+    -allow string
+            Command and optional patterns to allow in deny or ask mode (can
+            be specified multiple times). Same format as -cmd; patterns match
+            the leading arguments, so "git status log" allows "git status -s"
+            and "git log", but not "git push". Allowlists are only read from
+            flags, so a project policy cannot widen them.
+
+    -ask-prefix
+            In ask mode, when only later segments of a compound command
+            (git status && git push) are unlisted, tell the user which
+            leading segments could run on their own
+
+OPTIONAL:
+    -max-recursion int
+            Maximum recursion depth for command analysis (default: %d)
+    
+    -dialect string
+            Shell language commands are parsed as: bash, posix (or sh), mksh,
+            or bats (default: bash). A command that does not parse is
+            blocked, so pick the dialect of the shell that runs it
+
+    -resolve-vars
+            Resolve variables assigned earlier in a command, so
+            "GIT=git; $GIT push" is checked as git push rather than blocked
+            as a dynamic command. A value is only used when it is certain:
+            assigned once, at the top level, to a static value, in a command
+            without eval, read, declare, or export
+
+    -env string
+            Environment variable to resolve from the hook's environment,
+            e.g. HOME or EDITOR, so "$HOME/bin/git push" is checked as git
+            push (can be specified multiple times; implies -resolve-vars).
+            Variables the command sets, and shell-managed ones such as PWD,
+            are never taken from the environment
+
+    -foreign-syntax
+            Handle zsh and fish syntax the bash parser rejects, such as
+            "cd (dirname x)" or "ls **/*.go(.)": translate it into bash and
+            analyze that. A command that still does not parse (fish
+            "if ...; end") is only blocked if its words may run a blocked
+            command, a shell, or eval; otherwise it is allowed
+
+    -reason-format string
+            How a block is reported: text (exit 2 with the reason on stderr)
+            or json (a deny decision whose reason is a JSON object with the
+            matched rules, issues, suggested alternatives, and a docs link)
+            (default: text)
+
+    -docs-url string
+            Documentation link included in JSON block reasons
+            (default: %s)
+
+    -block-message string
+            Go text/template for block messages, e.g. to link a runbook or
+            translate them. Fields: {{.Command}}, {{.Rule}}, {{.Suggestion}},
+            {{.Issues}}, {{.Alternatives}}, {{.Docs}}. A rule's own message
+            wins, and this flag wins over the policy's block_message
+
+    -shadow-warn
+            Show the user a warning when a shadow rule (enforce: false)
+            matches, rather than only logging it
+
+    -allow-once
+            Allow a blocked command once when a human has granted it with
+            "hooks grant COMMAND" (the grant is used up and audited). Running
+            hooks grant from Claude Code, and redirections into the grant
+            directory or key, are blocked. Only grants signed with the key
+            count, so run self-protect too to keep Claude from reading it.
+
+    -grant-dir string
+            Directory of hooks grant approvals (default: %s)
+
+    -grant-key string
+            Key file hooks grant signs approvals with (default: %s)
+
+    -cache
+            Cache blocks on disk, so a command Claude retries after a block
+            is not parsed and evaluated again. Only blocks are cached, and
+            never under conditional (allow_after) rules
+
+    -cache-dir string
+            Directory of cached blocks (default: %s)
+
+    -max-issues int
+            Maximum number of issues listed when a command is blocked; the
+            rest are summarized as "...and N more" (0 for no limit) (default: %d)
+
+    -disable-obfuscation string
+            Disable a content obfuscation detector (can be specified multiple
+            times): hex, reverse, or substitution
+
+    -obfuscation-threshold float
+            Confidence from 0 to 1 at which an obfuscation finding blocks;
+            weaker findings are only reported as warnings (default: 0.5)
+
+    -now string
+            Evaluate rule schedules at this RFC 3339 time instead of the
+            current time (for testing schedules)
+
+    -fail-mode string
+            Behavior when input or rules cannot be parsed: closed (block) or
+            open (allow) (default: closed)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every decision to this file
+
+    -discover
+            Load .claudehooks.yaml found by walking up from the payload cwd
+            (default: true; use -discover=false to disable)
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_BASH_BLOCK_<FLAG> to target only this hook. Dashes become
+    underscores (CLAUDE_HOOKS_FAIL_MODE, CLAUDE_HOOKS_RULES). Separate multiple
+    -cmd values with semicolons: CLAUDE_HOOKS_CMD="git push;kubectl delete".
+    Command-line flags take precedence over the environment, except -cmd
+    where environment and command-line rules are combined.
+
+    CLAUDE_HOOKS_METRICS_TEXTFILE
+            Update a Prometheus textfile with evaluation, block, and latency metrics
+
+    CLAUDE_HOOKS_STATSD_ADDR
+            Send the same metrics to a StatsD server (host:port) over UDP
+
+    CLAUDE_HOOKS_OTLP_ENDPOINT
+            Export OpenTelemetry spans to an OTLP/HTTP traces URL
+            (e.g. http://localhost:4318/v1/traces)
+
+    CLAUDE_HOOKS_OTLP_HEADERS
+            Extra OTLP request headers as key=value pairs separated by commas
+
+    CLAUDE_HOOKS_TRACE_COMMANDS
+            Set to true to attach the full command text to spans (off by default)
+
+EXAMPLES:
+    # Block all git commands
+    bash-block -cmd git
+    
+    # Block only git push
+    bash-block -cmd "git push"
+
+    # Block git push, including through global flags and aliases
+    bash-block -preset git-push
+    
+    # Block multiple specific commands
+    bash-block -cmd "git push" -cmd "aws delete-bucket terminate-instances"
+    
+    # Block all aws and kubectl commands
+    bash-block -cmd aws -cmd kubectl
+    
+    # Complex example with multiple rules
+    bash-block -cmd "git push force-push" \
+               -cmd "aws delete-* terminate-*" \
+               -cmd "kubectl delete"
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "preToolUse": [
+      {
+        "command": "/path/to/bash-block",
+        "args": ["-cmd", "git push", "-cmd", "aws delete-*"]
+      }
+    ]
+  }
+}
+
+`, presetUsage(), defaultMaxRecursion, defaultDocsURL, grant.DefaultDir(), grant.DefaultKeyPath(), resultcache.DefaultDir(), hook.DefaultMaxIssues)
+}
+
+// obfuscationDetectorNames lists the built-in obfuscation detectors.
+func obfuscationDetectorNames() string {
+	var names []string
+	for _, obfuscationDetector := range detector.DefaultObfuscationDetectors() {
+		names = append(names, obfuscationDetector.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// presetUsage lists the built-in presets for showUsage.
+func presetUsage() string {
+	var b strings.Builder
+	for _, preset := range detector.Presets() {
+		fmt.Fprintf(&b, "              %-29s %s\n", preset.Name, preset.Description)
+	}
+	return b.String()
+}
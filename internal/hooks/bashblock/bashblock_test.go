@@ -0,0 +1,479 @@
+package bashblock
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/resultcache"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+func TestParseCommandRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		want     []detector.CommandRule
+	}{
+		{
+			name:     "Single command blocks all",
+			commands: []string{"git"},
+			want: []detector.CommandRule{
+				{
+					BlockedCommand:  "git",
+					BlockedPatterns: []string{"*"},
+				},
+			},
+		},
+		{
+			name:     "Command with single pattern",
+			commands: []string{"git push"},
+			want: []detector.CommandRule{
+				{
+					BlockedCommand:  "git",
+					BlockedPatterns: []string{"push"},
+				},
+			},
+		},
+		{
+			name:     "Command with multiple patterns",
+			commands: []string{"git push pull force-push"},
+			want: []detector.CommandRule{
+				{
+					BlockedCommand:  "git",
+					BlockedPatterns: []string{"push", "pull", "force-push"},
+				},
+			},
+		},
+		{
+			name:     "Command with wildcard patterns",
+			commands: []string{"aws delete-* terminate-*"},
+			want: []detector.CommandRule{
+				{
+					BlockedCommand:  "aws",
+					BlockedPatterns: []string{"delete-*", "terminate-*"},
+				},
+			},
+		},
+		{
+			name: "Multiple commands",
+			commands: []string{
+				"git push",
+				"aws delete-*",
+				"kubectl",
+			},
+			want: []detector.CommandRule{
+				{
+					BlockedCommand:  "git",
+					BlockedPatterns: []string{"push"},
+				},
+				{
+					BlockedCommand:  "aws",
+					BlockedPatterns: []string{"delete-*"},
+				},
+				{
+					BlockedCommand:  "kubectl",
+					BlockedPatterns: []string{"*"},
+				},
+			},
+		},
+		{
+			name:     "Empty command ignored",
+			commands: []string{"", "git push", ""},
+			want: []detector.CommandRule{
+				{
+					BlockedCommand:  "git",
+					BlockedPatterns: []string{"push"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCommandRules(tt.commands)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCommandRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPolicyAndBuildRules(t *testing.T) {
+	systemDir := t.TempDir()
+	defaultSystemDir := config.SystemConfigDir
+	config.SystemConfigDir = systemDir
+	t.Cleanup(func() { config.SystemConfigDir = defaultSystemDir })
+	if err := os.WriteFile(filepath.Join(systemDir, config.PolicyFileName), []byte("rules:\n  - command: terraform\n    patterns: [destroy]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir()) // No user policy
+
+	projectDir := t.TempDir()
+	nestedDir := filepath.Join(projectDir, "sub", "dir")
+	if err := os.MkdirAll(nestedDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	projectPolicy := "rules:\n  - command: aws\n    patterns: [delete-*]\n"
+	if err := os.WriteFile(filepath.Join(projectDir, config.ProjectFileName), []byte(projectPolicy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(rulesFile, []byte("rules:\n  - command: kubectl\n    patterns: [delete]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := &config.Settings{RulesFile: rulesFile, Discover: true}
+	policy, err := loadPolicy(settings, nestedDir)
+	if err != nil {
+		t.Fatalf("loadPolicy() error: %v", err)
+	}
+
+	want := []detector.CommandRule{
+		{BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+		{BlockedCommand: "terraform", BlockedPatterns: []string{"destroy"}},
+		{BlockedCommand: "kubectl", BlockedPatterns: []string{"delete"}},
+		{BlockedCommand: "aws", BlockedPatterns: []string{"delete-*"}},
+	}
+	if got := buildRules([]string{"git push"}, policy, time.Now()); !reflect.DeepEqual(got, want) {
+		t.Errorf("buildRules() = %+v, want %+v", got, want)
+	}
+
+	// Discovery disabled ignores the project policy
+	settings.Discover = false
+	policy, err = loadPolicy(settings, nestedDir)
+	if err != nil {
+		t.Fatalf("loadPolicy() error: %v", err)
+	}
+	if len(policy.Rules) != 2 {
+		t.Errorf("loadPolicy() with discovery disabled returned %d rules, want 2", len(policy.Rules))
+	}
+
+	settings.RulesFile = filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := loadPolicy(settings, nestedDir); err == nil {
+		t.Error("loadPolicy() should fail for a missing policy file")
+	}
+}
+
+func TestPresetRules(t *testing.T) {
+	rules, err := presetRules([]string{"git-push"})
+	if err != nil {
+		t.Fatalf("presetRules() error: %v", err)
+	}
+	if len(rules) != 1 || rules[0].BlockedCommand != "git" || rules[0].Match == nil {
+		t.Errorf("presetRules(git-push) = %+v, want one flag-aware git rule", rules)
+	}
+
+	if _, err := presetRules([]string{"git-push", "nope"}); err == nil {
+		t.Error("presetRules() with an unknown preset should fail")
+	}
+}
+
+func TestPresetRedirects(t *testing.T) {
+	if redirects := presetRedirects([]string{"git-push"}); len(redirects) != 0 {
+		t.Errorf("presetRedirects(git-push) = %q, want none", redirects)
+	}
+	if redirects := presetRedirects([]string{"git-push", "shell-rc"}); !slices.Contains(redirects, "~/.bashrc") {
+		t.Errorf("presetRedirects(shell-rc) = %q, want it to contain ~/.bashrc", redirects)
+	}
+}
+
+func TestDropUnconfiguredPresets(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	// Keep the user's global config, which may sign commits, out of the test
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := t.TempDir()
+	if err := exec.Command("git", "init", "--quiet", repo).Run(); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := presetRules([]string{"git-push", "git-signing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.DiscardHandler)
+
+	names := func(rules []detector.CommandRule) []string {
+		var names []string
+		for _, rule := range rules {
+			names = append(names, rule.Name)
+		}
+		return names
+	}
+	if got := names(dropUnconfiguredPresets(logger, slices.Clone(rules), repo)); !reflect.DeepEqual(got, []string{"git-push"}) {
+		t.Errorf("dropUnconfiguredPresets() without signing = %q, want only git-push", got)
+	}
+	if err := exec.Command("git", "-C", repo, "config", "tag.gpgSign", "yes").Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(dropUnconfiguredPresets(logger, slices.Clone(rules), repo)); !reflect.DeepEqual(got, []string{"git-push", "git-signing"}) {
+		t.Errorf("dropUnconfiguredPresets() with tag.gpgSign = %q, want both presets", got)
+	}
+}
+
+func TestShadowMatches(t *testing.T) {
+	shadowDetector := detector.NewCommandDetector([]detector.CommandRule{
+		{BlockedCommand: "terraform", BlockedPatterns: []string{"destroy"}},
+		{Name: "no-curl", BlockedCommand: "curl", BlockedPatterns: []string{"*"}},
+	}, 10)
+
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"terraform plan", nil},
+		{"terraform destroy", []string{"terraform destroy"}},
+		{"curl example.com && curl example.org", []string{"no-curl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := shadowMatches(shadowDetector, tt.command); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shadowMatches(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverityMatches(t *testing.T) {
+	if rules, issues := severityMatches(nil, "git push"); rules != nil || issues != nil {
+		t.Errorf("severityMatches(nil) = %v, %v, want nothing", rules, issues)
+	}
+	askDetector := detector.NewCommandDetector([]detector.CommandRule{
+		{Name: "no-apply", BlockedCommand: "kubectl", BlockedPatterns: []string{"apply"}},
+	}, 10)
+	if rules, _ := severityMatches(askDetector, "kubectl get pods"); rules != nil {
+		t.Errorf("severityMatches() = %v for an unmatched command", rules)
+	}
+	rules, issues := severityMatches(askDetector, "kubectl apply -f deploy.yaml")
+	if !reflect.DeepEqual(rules, []string{"no-apply"}) || len(issues) == 0 {
+		t.Errorf("severityMatches() = %v, %v, want no-apply and its issues", rules, issues)
+	}
+}
+
+func TestMessageTemplate(t *testing.T) {
+	tests := []struct {
+		name                 string
+		rules                []string
+		flagText, policyText string
+		want                 string
+	}{
+		{"Default", nil, "", "", ""},
+		{"Policy", nil, "", "policy", "policy"},
+		{"Flag over policy", nil, "flag", "policy", "flag"},
+		{"Rule over flag", []string{"rule", "other rule"}, "flag", "policy", "rule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageTemplate(tt.rules, tt.flagText, tt.policyText); got != tt.want {
+				t.Errorf("messageTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGitAliases(t *testing.T) {
+	output := "alias.p push\nalias.lg log --graph --oneline\nalias.ship !git push origin HEAD\n"
+	want := map[string]string{
+		"p":    "push",
+		"lg":   "log --graph --oneline",
+		"ship": "!git push origin HEAD",
+	}
+	if got := parseGitAliases(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGitAliases() = %v, want %v", got, want)
+	}
+	if got := parseGitAliases(""); len(got) != 0 {
+		t.Errorf("parseGitAliases(\"\") = %v, want empty", got)
+	}
+}
+
+func TestBlockReason(t *testing.T) {
+	rules, err := presetRules([]string{"git-push"})
+	if err != nil {
+		t.Fatalf("presetRules() error: %v", err)
+	}
+	commandDetector := detector.NewCommandDetector(rules, 10)
+	result := evaluateCommand(commandDetector, nil, nil, defaultAllow, "git -C repo push origin main")
+	if !result.Blocked {
+		t.Fatal("expected git push to be blocked")
+	}
+
+	want := hook.BlockReason{
+		Message:     "Blocked command detected!",
+		Rules:       []string{"git-push"},
+		RuleIndexes: []int{0},
+		Issues:      []string{"Blocked git push"},
+		Alternatives: []string{
+			"use `git push --dry-run` to check the push, or ask the human to push",
+		},
+		Docs:         defaultDocsURL,
+		Matched:      []string{"git -C repo push origin main"},
+		Descriptions: []string{"git-push: git push, including after global flags and through aliases"},
+	}
+	if got := blockReason(result, result.Issues, defaultDocsURL); !reflect.DeepEqual(got, want) {
+		t.Errorf("blockReason() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEvaluateCommand(t *testing.T) {
+	commandDetector := detector.NewCommandDetector(parseCommandRules([]string{"git push"}), 10)
+	shadowDetector := detector.NewCommandDetector([]detector.CommandRule{
+		{Name: "no-curl", BlockedCommand: "curl", BlockedPatterns: []string{"*"}},
+	}, 10)
+	allowlist := detector.NewAllowlist(parseAllowRules([]string{"git status", "curl"}), 10)
+
+	tests := []struct {
+		name        string
+		defaultMode string
+		command     string
+		want        evaluation
+	}{
+		{"allowed", defaultAllow, "git status", evaluation{}},
+		{"rule blocks", defaultAllow, "git status && git push", evaluation{
+			Blocked:     true,
+			Issues:      []string{"Blocked git pattern detected"},
+			Rules:       []string{"git push"},
+			RuleIndexes: []int{0},
+			Segments:    []string{"git push"},
+			Matched:     []string{"git push"},
+		}},
+		{"shadow rule", defaultAllow, "curl example.com", evaluation{ShadowRules: []string{"no-curl"}}},
+		{"unlisted in deny mode", defaultDeny, "make deploy", evaluation{
+			Blocked:  true,
+			Issues:   []string{"Command not in allowlist: make deploy"},
+			Unlisted: []string{"Command not in allowlist: make deploy"},
+		}},
+		{"unlisted in ask mode", defaultAsk, "make deploy", evaluation{Unlisted: []string{"Command not in allowlist: make deploy"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateCommand(commandDetector, shadowDetector, allowlist, tt.defaultMode, tt.command)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evaluateCommand(%q) = %+v, want %+v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockCacheKey(t *testing.T) {
+	rules := parseCommandRules([]string{"git push"})
+	key, err := blockCacheKey(rules, nil, "git push", "bash")
+	if err != nil || key == "" {
+		t.Fatalf("blockCacheKey() = %q, %v, want a key", key, err)
+	}
+	others := map[string]func() (string, error){
+		"command":  func() (string, error) { return blockCacheKey(rules, nil, "git push -f", "bash") },
+		"settings": func() (string, error) { return blockCacheKey(rules, nil, "git push", "posix") },
+		"shadow rules": func() (string, error) {
+			return blockCacheKey(rules, parseCommandRules([]string{"curl"}), "git push", "bash")
+		},
+	}
+	for name, other := range others {
+		if got, err := other(); err != nil || got == key {
+			t.Errorf("blockCacheKey() with different %s = %q, %v, want a different key", name, got, err)
+		}
+	}
+
+	conditional := append(rules, detector.CommandRule{BlockedCommand: "git", AllowAfter: &detector.AllowAfter{Command: "go test"}})
+	if got, err := blockCacheKey(conditional, nil, "git push", "bash"); err != nil || got != "" {
+		t.Errorf("blockCacheKey() with conditional rules = %q, %v, want no key", got, err)
+	}
+}
+
+func TestCachedBlock(t *testing.T) {
+	cache := resultcache.NewDisk[evaluation](t.TempDir(), 10)
+	if _, ok := cachedBlock(cache, "missing"); ok {
+		t.Error("cachedBlock() of a missing key = hit, want miss")
+	}
+
+	// An entry that is not a block would allow the command without evaluating it
+	if err := cache.Put("planted", evaluation{Issues: []string{"allowed"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := cachedBlock(cache, "planted"); ok {
+		t.Errorf("cachedBlock() of a non-block entry = %+v, hit, want miss", got)
+	}
+
+	block := evaluation{Blocked: true, Issues: []string{"Blocked git pattern detected"}, Rules: []string{"git push"}}
+	if err := cache.Put("block", block); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := cachedBlock(cache, "block"); !ok || !reflect.DeepEqual(got, block) {
+		t.Errorf("cachedBlock() = %+v, %v, want %+v, hit", got, ok, block)
+	}
+	if _, ok := cachedBlock(nil, "block"); ok {
+		t.Error("cachedBlock() without a cache = hit, want miss")
+	}
+}
+
+func TestGrantRules(t *testing.T) {
+	commandDetector := detector.NewCommandDetector(grantRules(), 10)
+	tests := []struct {
+		command   string
+		wantBlock bool
+	}{
+		{`hooks grant "git push origin main"`, true},
+		{`/usr/local/bin/krmcbride-hooks grant -ttl 1h "git push"`, true},
+		{`sh -c 'hooks grant git push'`, true},
+		{"hooks audit verify", false},
+		{"hooks version", false},
+	}
+	for _, tt := range tests {
+		if got := commandDetector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+			t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v", tt.command, got, tt.wantBlock)
+		}
+	}
+}
+
+func TestAnalyzeSegments(t *testing.T) {
+	commandDetector := detector.NewCommandDetector(parseCommandRules([]string{"git push"}), 10)
+	allowlist := detector.NewAllowlist(parseAllowRules([]string{"git status log", "ls"}), 10)
+
+	tests := []struct {
+		name           string
+		command        string
+		checkAllowlist bool
+		wantBlocked    []string
+		wantPrefix     string
+	}{
+		{"rule blocks a segment", "git status && git push", false, []string{"git push"}, "git status"},
+		{"unlisted segment", "ls; make deploy && git log", true, []string{"make deploy"}, "ls"},
+		{"unlisted ignored without allowlist", "ls; make deploy", false, nil, ""},
+		{"rule and unlisted segments", "git push || rm -rf x", true, []string{"git push", "rm -rf x"}, ""},
+		{"single command", "git push", false, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := analyzeSegments(commandDetector, allowlist, tt.checkAllowlist, tt.command)
+			if got := blockedSegments(results); !reflect.DeepEqual(got, tt.wantBlocked) {
+				t.Errorf("blockedSegments() = %q, want %q", got, tt.wantBlocked)
+			}
+			if got := detector.AllowedPrefix(results); got != tt.wantPrefix {
+				t.Errorf("AllowedPrefix() = %q, want %q", got, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	t.Setenv("BASH_BLOCK_TEST_EDITOR", "vim")
+	if env := resolveEnv(nil, false); env != nil {
+		t.Errorf("resolveEnv() without -env or -resolve-vars = %v, want nil", env)
+	}
+	if env := resolveEnv(nil, true); env == nil || len(env) != 0 {
+		t.Errorf("resolveEnv() with -resolve-vars = %v, want empty", env)
+	}
+	env := resolveEnv([]string{"BASH_BLOCK_TEST_EDITOR", "BASH_BLOCK_TEST_UNSET"}, false)
+	if want := map[string]string{"BASH_BLOCK_TEST_EDITOR": "vim"}; !reflect.DeepEqual(env, want) {
+		t.Errorf("resolveEnv() = %v, want %v", env, want)
+	}
+}
@@ -0,0 +1,292 @@
+// Package blobguard - binary, base64, and oversized content detection
+package blobguard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// sniffSize is how much of a file or content is inspected for binary data,
+// as git does.
+const sniffSize = 8000
+
+// Lines between minWrapWidth and maxWrapWidth long continue a base64 run onto
+// the next line, since encoders wrap their output at 64 or 76 characters.
+const (
+	minWrapWidth = 60
+	maxWrapWidth = 1024
+)
+
+// Limits configures which content is blocked.
+type Limits struct {
+	MaxSize     int64 // Largest content or created file in bytes; 0 disables the check
+	MaxBase64   int   // Longest base64 run in bytes; 0 disables the check
+	AllowBinary bool  // Allow binary content and overwriting binary files
+}
+
+// CheckContent returns the reasons content should not be written: it is
+// larger than the limit, binary, or carries a long base64 blob.
+func (l Limits) CheckContent(content string) []string {
+	var issues []string
+	if l.MaxSize > 0 && int64(len(content)) > l.MaxSize {
+		issues = append(issues, fmt.Sprintf("content is %s, over the %s limit", FormatSize(int64(len(content))), FormatSize(l.MaxSize)))
+	}
+	if !l.AllowBinary && IsBinary([]byte(content)) {
+		issues = append(issues, "content is binary")
+	}
+	if run := LongestBase64Run(content); l.MaxBase64 > 0 && run > l.MaxBase64 {
+		issues = append(issues, fmt.Sprintf("content has a %s base64 blob, over the %s limit", FormatSize(int64(run)), FormatSize(int64(l.MaxBase64))))
+	}
+	return issues
+}
+
+// IsBinary reports whether data looks binary: its start holds a NUL byte or
+// is not valid UTF-8.
+func IsBinary(data []byte) bool {
+	if len(data) > sniffSize {
+		data = data[:sniffSize]
+		// Don't count a multi-byte character cut at the end as invalid
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	return bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)
+}
+
+// IsBinaryFile reports whether the file at path exists and looks binary.
+func IsBinaryFile(path string) (bool, error) {
+	f, err := os.Open(path) // #nosec G304 - path of the file Claude is writing
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+	data := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, data)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, err
+	}
+	return IsBinary(data[:n]), nil
+}
+
+// LongestBase64Run returns the length of the longest run of base64 text in
+// s, following lines wrapped at the same width. Runs without both upper and
+// lower case letters and digits, such as long identifiers or hex digests, are
+// not counted.
+func LongestBase64Run(s string) int {
+	longest, run, line, width := 0, 0, 0, 0
+	var upper, lower, digit bool
+	end := func() {
+		if upper && lower && digit && run > longest {
+			longest = run
+		}
+		run, line, width = 0, 0, 0
+		upper, lower, digit = false, false, false
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case 'A' <= c && c <= 'Z':
+			upper = true
+		case 'a' <= c && c <= 'z':
+			lower = true
+		case '0' <= c && c <= '9':
+			digit = true
+		case c == '+' || c == '/' || c == '=' || c == '-' || c == '_':
+		case c == '\r' && line > 0:
+			continue
+		case c == '\n' && line >= minWrapWidth && line <= maxWrapWidth && (width == 0 || line == width):
+			width, line = line, 0
+			continue
+		default:
+			end()
+			continue
+		}
+		run++
+		line++
+	}
+	end()
+	return longest
+}
+
+// Creation is a file a Bash command creates with a size known from its
+// arguments.
+type Creation struct {
+	Path    string
+	Size    int64 // Bytes; -1 if the command writes without bound
+	Command string
+}
+
+// FileCreations returns the files command creates whose size its arguments
+// tell: dd of= with bs= and count=, fallocate -l, truncate -s, and mkfile.
+func FileCreations(command string) ([]Creation, error) {
+	node, err := shellparse.Parse(command)
+	if err != nil {
+		return nil, err
+	}
+	var creations []Creation
+	shellparse.VisitCommands(node, func(cmd shellparse.Command) bool {
+		if !cmd.Static {
+			return true
+		}
+		var size int64
+		var files []string
+		var ok bool
+		switch path.Base(cmd.Name) {
+		case "dd":
+			size, files, ok = ddCreation(cmd.Args)
+		case "fallocate":
+			inv := detector.ArgSpec{ValueFlags: []string{"-l", "--length", "-o", "--offset"}}.Parse(cmd.Args)
+			length, _ := inv.Flag("-l", "--length")
+			size, ok = parseSizeOK(length)
+			files = inv.Positionals
+		case "truncate":
+			inv := detector.ArgSpec{ValueFlags: []string{"-s", "--size", "-r", "--reference"}}.Parse(cmd.Args)
+			value, _ := inv.Flag("-s", "--size")
+			size, ok = parseSizeOK(strings.TrimLeft(value, "+<>/%"))
+			files = inv.Positionals
+		case "mkfile":
+			inv := detector.ArgSpec{}.Parse(cmd.Args)
+			if len(inv.Positionals) > 1 {
+				size, ok = parseSizeOK(inv.Positionals[0])
+				files = inv.Positionals[1:]
+			}
+		}
+		if !ok {
+			return true
+		}
+		for _, file := range files {
+			creations = append(creations, Creation{Path: file, Size: size, Command: cmd.Source})
+		}
+		return true
+	})
+	return creations, nil
+}
+
+// ddCreation returns the size dd writes to its of= file: bs= (or obs=) times
+// count=. Copying from an endless device such as /dev/zero without a count is
+// unbounded.
+func ddCreation(args []string) (int64, []string, bool) {
+	operands := map[string]string{}
+	for _, arg := range args {
+		if key, value, ok := strings.Cut(arg, "="); ok {
+			operands[key] = value
+		}
+	}
+	output := operands["of"]
+	if output == "" || strings.HasPrefix(output, "/dev/") {
+		return 0, nil, false
+	}
+	count, hasCount := operands["count"]
+	if !hasCount {
+		switch operands["if"] {
+		case "/dev/zero", "/dev/urandom", "/dev/random":
+			return -1, []string{output}, true
+		}
+		return 0, nil, false
+	}
+	blockSize := "512"
+	for _, key := range []string{"bs", "obs"} {
+		if value, ok := operands[key]; ok {
+			blockSize = value
+			break
+		}
+	}
+	blocks, err := ParseSize(count)
+	if err != nil {
+		return 0, nil, false
+	}
+	bs, err := ParseSize(blockSize)
+	if err != nil {
+		return 0, nil, false
+	}
+	return blocks * bs, []string{output}, true
+}
+
+// parseSizeOK is ParseSize reporting success instead of an error.
+func parseSizeOK(s string) (int64, bool) {
+	size, err := ParseSize(s)
+	return size, err == nil
+}
+
+// sizeSuffixes are the multipliers of size suffixes as coreutils and dd
+// accept them: K, M, G, and T (and KiB, ...) are powers of 1024, KB, MB, ...
+// powers of 1000, and dd's b, w, and c are 512, 2, and 1 bytes.
+var sizeSuffixes = map[string]int64{
+	"": 1, "c": 1, "w": 2, "b": 512,
+	"k": 1 << 10, "K": 1 << 10, "KiB": 1 << 10, "KB": 1000, "kB": 1000,
+	"M": 1 << 20, "MiB": 1 << 20, "MB": 1000 * 1000, "m": 1 << 20,
+	"G": 1 << 30, "GiB": 1 << 30, "GB": 1000 * 1000 * 1000, "g": 1 << 30,
+	"T": 1 << 40, "TiB": 1 << 40, "TB": 1000 * 1000 * 1000 * 1000, "t": 1 << 40,
+}
+
+// ParseSize parses a size such as 512, 10M, 1GiB, or 4KB into bytes.
+func ParseSize(s string) (int64, error) {
+	i := 0
+	for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	multiplier, ok := sizeSuffixes[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown suffix %q", s, s[i:])
+	}
+	if n > 0 && multiplier > (1<<62)/n {
+		return 1 << 62, nil
+	}
+	return n * multiplier, nil
+}
+
+// FormatSize formats a byte count for messages, e.g. 1.5 MiB.
+func FormatSize(n int64) string {
+	switch {
+	case n < 0:
+		return "unbounded"
+	case n < 1<<10:
+		return fmt.Sprintf("%d B", n)
+	case n < 1<<20:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	case n < 1<<30:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	default:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	}
+}
+
+// matchAny reports whether path matches one of patterns. A pattern without a
+// slash, such as *.png, matches the file name in any directory; others are
+// resolved against root and use pathmatch.Match syntax, so assets/** matches
+// everything below assets.
+func matchAny(patterns []string, root, file string) bool {
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, `/\`) {
+			if pathmatch.Match(pattern, pathmatch.Base(file)) {
+				return true
+			}
+			continue
+		}
+		if pathmatch.Match(pathmatch.Join(root, pattern), pathmatch.Join(root, file)) {
+			return true
+		}
+	}
+	return false
+}
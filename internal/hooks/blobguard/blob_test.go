@@ -0,0 +1,113 @@
+package blobguard
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckContent(t *testing.T) {
+	blob := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("\x00\x01binary payload\xff", 2000)))
+	wrapped := ""
+	for rest := blob; rest != ""; {
+		n := min(76, len(rest))
+		wrapped += rest[:n] + "\n"
+		rest = rest[n:]
+	}
+	limits := Limits{MaxSize: 64 << 10, MaxBase64: 16 << 10}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"source code", "package main\n\nfunc main() {}\n", nil},
+		{"too large", strings.Repeat("x", 65<<10), []string{"content is 65.0 KiB, over the 64.0 KiB limit"}},
+		{"binary", "PNG\x00\x00\x1a", []string{"content is binary"}},
+		{"invalid UTF-8", "caf\xe9", []string{"content is binary"}},
+		{"base64 blob", `const logo = "data:image/png;base64,` + blob + `";`, []string{"content has a 44.3 KiB base64 blob, over the 16.0 KiB limit"}},
+		{"wrapped base64", "-----BEGIN DATA-----\n" + wrapped + "-----END DATA-----\n", []string{"content has a 44.3 KiB base64 blob, over the 16.0 KiB limit"}},
+		{"long identifiers and hex", strings.Repeat("abcdef0123456789", 2000) + "\n" + strings.Repeat("SOME_CONSTANT_NAME_", 1000), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := limits.CheckContent(tt.content)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("CheckContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBinaryFile(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "logo.png")
+	if err := os.WriteFile(image, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	text := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(text, []byte(strings.Repeat("héllo ", 2000)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		path string
+		want bool
+	}{{image, true}, {text, false}, {filepath.Join(dir, "missing"), false}} {
+		if got, err := IsBinaryFile(tt.path); err != nil || got != tt.want {
+			t.Errorf("IsBinaryFile(%s) = %v, %v, want %v", filepath.Base(tt.path), got, err, tt.want)
+		}
+	}
+}
+
+func TestFileCreations(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []Creation
+	}{
+		{"dd if=/dev/urandom of=data.bin bs=1M count=100", []Creation{{"data.bin", 100 << 20, "dd if=/dev/urandom of=data.bin bs=1M count=100"}}},
+		{"dd if=/dev/zero of=disk.img", []Creation{{"disk.img", -1, "dd if=/dev/zero of=disk.img"}}},
+		{"dd if=in.img of=out.img", nil},
+		{"dd if=/dev/zero of=/dev/null bs=1G count=1", nil},
+		{"fallocate -l 2G big.img", []Creation{{"big.img", 2 << 30, "fallocate -l 2G big.img"}}},
+		{"truncate -s 10MB a.log b.log", []Creation{{"a.log", 10_000_000, "truncate -s 10MB a.log b.log"}, {"b.log", 10_000_000, "truncate -s 10MB a.log b.log"}}},
+		{"cd tmp && mkfile 1g swap", []Creation{{"swap", 1 << 30, "mkfile 1g swap"}}},
+		{"fallocate -l $SIZE big.img", nil},
+		{"echo hi > out.txt", nil},
+	}
+	for _, tt := range tests {
+		got, err := FileCreations(tt.command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("FileCreations(%q) = %+v, want %+v", tt.command, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("FileCreations(%q)[%d] = %+v, want %+v", tt.command, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"512", 512}, {"4K", 4096}, {"4KB", 4000}, {"1MiB", 1 << 20}, {"2b", 1024}, {"1g", 1 << 30},
+	}
+	for _, tt := range tests {
+		if got, err := ParseSize(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "M", "10X", "-1"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) succeeded, want an error", in)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+// Package blobguard implements the blob-guard hook, which blocks writes of oversized, binary, and base64 blob content
+package blobguard
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// Default limits
+const (
+	DefaultMaxKB       = 1024
+	DefaultMaxBase64KB = 16
+)
+
+// listFlag allows multiple -allow flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var allow listFlag
+	maxKB := flag.Int64("max-kb", DefaultMaxKB, "Largest Write content, or file a Bash command creates, in KiB; 0 disables the check")
+	maxBase64KB := flag.Int("max-base64-kb", DefaultMaxBase64KB, "Longest base64 blob in Write content, in KiB; 0 disables the check")
+	allowBinary := flag.Bool("allow-binary", false, "Allow binary Write content and overwriting binary files")
+	flag.Var(&allow, "allow", "Glob of files exempt from the checks, e.g. *.svg or testdata/** (can be specified multiple times)")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailOpen}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input or the command cannot be parsed, or a file cannot be read: open (allow) or closed (block)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every blocked write to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "blob-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "blob-guard", hook.EventPreToolUse, hook.EventPostToolUse)
+	manifest := config.Manifest{
+		Hook:   "blob-guard",
+		Events: []string{hook.EventPreToolUse, hook.EventPostToolUse},
+		Tools:  []string{"Write", "Bash"},
+		// After a Bash command, the files it created are checked
+		EventTools: map[string][]string{hook.EventPostToolUse: {"Bash"}},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+	limits := Limits{MaxSize: *maxKB << 10, MaxBase64: *maxBase64KB << 10, AllowBinary: *allowBinary}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err == nil && hook.EventName(data) == hook.EventPostToolUse {
+		checkAfterBash(logger, auditLog, settings, data, limits, allow)
+		return
+	}
+	var input *hook.PreToolUseInput
+	if err == nil {
+		input, err = hook.DecodePreToolUseInput(bytes.NewReader(data), settings.InputOptions())
+	}
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "blob-guard", "Failed to parse hook input", err)
+		return
+	}
+	root := projectRoot(input.Cwd)
+
+	var issues []string
+	switch input.ToolName {
+	case "Write":
+		target := resolve(input.Cwd, input.ToolInput.FilePath)
+		if target == "" || matchAny(allow, root, target) {
+			break
+		}
+		for _, issue := range limits.CheckContent(input.ToolInput.Content) {
+			issues = append(issues, fmt.Sprintf("Write of %s: %s", input.ToolInput.FilePath, issue))
+		}
+		if !limits.AllowBinary {
+			binary, err := IsBinaryFile(target)
+			if err != nil {
+				hookutil.FailInternal(settings, auditLog, "blob-guard", "Failed to read "+target, err)
+				return
+			}
+			if binary {
+				issues = append(issues, fmt.Sprintf("Write of %s overwrites a binary file", input.ToolInput.FilePath))
+			}
+		}
+	case "Bash":
+		creations, err := FileCreations(input.ToolInput.Command)
+		if err != nil {
+			hookutil.FailInternal(settings, auditLog, "blob-guard", "Failed to parse command", err)
+			return
+		}
+		for _, creation := range creations {
+			if matchAny(allow, root, resolve(input.Cwd, creation.Path)) {
+				continue
+			}
+			if creation.Size < 0 || (limits.MaxSize > 0 && creation.Size > limits.MaxSize) {
+				issues = append(issues, fmt.Sprintf("%s creates %s of %s, over the %s limit", creation.Command, creation.Path, FormatSize(creation.Size), FormatSize(limits.MaxSize)))
+			}
+		}
+	}
+	logger.Debug("checked tool call", "tool", input.ToolName, "issues", issues)
+	if len(issues) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "blob-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  settings.BlockDecision(),
+		Issues:    issues,
+		Command:   input.ToolInput.Command,
+		FilePath:  input.ToolInput.FilePath,
+	})
+	settings.Output().BlockPreToolUse("Large or binary file write! Generate such files with their build step, reference them by path, or ask the user to add them.", issues)
+}
+
+// checkAfterBash reports the files a Bash command wrote that exceed the size
+// limit, so Claude can remove them before they are committed.
+func checkAfterBash(logger *slog.Logger, auditLog *audit.Logger, settings *config.Settings, data []byte, limits Limits, allow []string) {
+	input, err := hook.DecodePostToolUseInput(bytes.NewReader(data), settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternalPostToolUse(settings, auditLog, "blob-guard", "Failed to parse hook input", err, "")
+		return
+	}
+	if input.ToolName != "Bash" || limits.MaxSize <= 0 {
+		hook.AllowPostToolUse()
+		return
+	}
+	written, err := detector.WrittenPaths(input.ToolInput.Command)
+	if err != nil {
+		hook.AllowPostToolUse() // The command already ran; nothing to report
+		return
+	}
+
+	root := projectRoot(input.Cwd)
+	var issues []string
+	for _, file := range written {
+		target := resolve(input.Cwd, file)
+		if matchAny(allow, root, target) {
+			continue
+		}
+		info, err := os.Stat(target)
+		if err != nil || !info.Mode().IsRegular() || info.Size() <= limits.MaxSize {
+			continue
+		}
+		issues = append(issues, fmt.Sprintf("%s is %s, over the %s limit", file, FormatSize(info.Size()), FormatSize(limits.MaxSize)))
+	}
+	logger.Debug("checked written files", "files", len(written), "issues", issues)
+	if len(issues) == 0 {
+		hook.AllowPostToolUse()
+		return
+	}
+
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "blob-guard",
+		Event:     hook.EventPostToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  settings.BlockDecision(),
+		Issues:    issues,
+		Command:   input.ToolInput.Command,
+	})
+	hook.BlockPostToolUse("The command wrote large files: " + strings.Join(issues, "; ") + ". Delete them, or move them out of the repository, unless the user asked for them.")
+}
+
+// projectRoot returns the root allow globs are relative to:
+// $CLAUDE_PROJECT_DIR, or the working directory.
+func projectRoot(cwd string) string {
+	if root := os.Getenv("CLAUDE_PROJECT_DIR"); root != "" {
+		return root
+	}
+	return cwd
+}
+
+// resolve makes path absolute against cwd.
+func resolve(cwd, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(cwd, path)
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `blob-guard: Binary and large file write guard for Claude Code hooks
+
+Keeps Claude from dumping megabytes into the repository or overwriting assets:
+
+    PreToolUse Write    Blocks content over -max-kb, binary content, content
+                        with a base64 blob over -max-base64-kb, and Writes
+                        that would overwrite an existing binary file
+    PreToolUse Bash     Blocks dd, fallocate, truncate, and mkfile commands
+                        whose arguments create a file over -max-kb, and dd
+                        from /dev/zero or /dev/urandom without a count
+    PostToolUse Bash    Reports files the command wrote (by redirection, tee,
+                        cp, mv, and the like) that ended up over -max-kb, so
+                        Claude removes them before they are committed
+
+USAGE:
+    blob-guard [-max-kb 1024] [-max-base64-kb 16] [-allow GLOB ...] [OPTIONS]
+
+OPTIONAL:
+    -max-kb int
+            Largest Write content, or file a Bash command creates, in KiB;
+            0 disables the check (default: 1024)
+
+    -max-base64-kb int
+            Longest base64 blob in Write content, in KiB; 0 disables the
+            check (default: 16)
+
+    -allow-binary
+            Allow binary Write content and overwriting binary files
+
+    -allow string
+            Glob of files exempt from the checks (can be specified multiple
+            times). A glob without a slash, such as *.svg, matches the file
+            name in any directory; others are relative to the project root,
+            e.g. testdata/**
+
+    -fail-mode string
+            Behavior when input or the command cannot be parsed, or a file
+            cannot be read: open (allow) or closed (block) (default: open)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every blocked write to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_BLOB_GUARD_<FLAG> to target only this hook. Separate
+    multiple -allow values with semicolons.
+
+EXAMPLES:
+    # 256 KiB limit, but let Claude write SVGs and test fixtures
+    blob-guard -max-kb 256 -allow "*.svg" -allow "testdata/**"
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Write|Bash",
+        "hooks": [{"type": "command", "command": "/path/to/blob-guard"}]
+      }
+    ],
+    "PostToolUse": [
+      {
+        "matcher": "Bash",
+        "hooks": [{"type": "command", "command": "/path/to/blob-guard"}]
+      }
+    ]
+  }
+}
+
+`)
+}
@@ -0,0 +1,335 @@
+// Package branchguard implements the branch-guard hook, which keeps file edits off protected git branches
+package branchguard
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+const defaultMaxRecursion = 10
+
+// defaultProtected are the branches protected when no -protect flag is given.
+var defaultProtected = []string{"main", "master"}
+
+// DefaultBranchTemplate names the feature branch suggested in block messages.
+const DefaultBranchTemplate = "claude/{session}"
+
+// listFlag allows multiple -protect flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var protectFlags listFlag
+	flag.Var(&protectFlags, "protect", "Protected branch glob, e.g. release/* (can be specified multiple times; default: main and master)")
+	createBranch := flag.String("create-branch", "", "Create and switch to this branch instead of blocking, e.g. claude/{session}")
+	allowInProgress := flag.Bool("allow-in-progress", false, "Allow edits while a rebase, merge, cherry-pick, or revert is in progress")
+	approve := flag.Bool("approve", false, "Run commits rewritten by -create-branch without a permission prompt")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailOpen}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input cannot be parsed or git fails: open (allow) or closed (block)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every blocked edit and created branch to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "branch-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "branch-guard", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:   "branch-guard",
+		Events: []string{hook.EventPreToolUse},
+		Tools:  []string{"Edit", "MultiEdit", "Write", "NotebookEdit", "Bash"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	protected := []string(protectFlags)
+	if len(protected) == 0 {
+		protected = defaultProtected
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "branch-guard", "Failed to parse hook input", err)
+		return
+	}
+
+	// Edits are checked in the repository of the edited file, commands in
+	// the one of the working directory
+	target := input.Cwd
+	switch input.ToolName {
+	case "Edit", "MultiEdit", "Write":
+		target = input.ToolInput.FilePath
+	case "NotebookEdit":
+		target = input.ToolInput.NotebookPath
+	case "Bash":
+		if *createBranch == "" || !isCommit(input.ToolInput.Command) {
+			hook.AllowPreToolUse()
+			return
+		}
+	default:
+		hook.AllowPreToolUse()
+		return
+	}
+	if target == "" {
+		hook.AllowPreToolUse()
+		return
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(input.Cwd, target)
+	}
+
+	state, inRepo, err := Inspect(target)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "branch-guard", "Failed to inspect the git repository", err)
+		return
+	}
+	logger.Debug("inspected repository", "file", target, "in_repo", inRepo, "branch", state.Branch, "operation", state.Operation)
+	if !inRepo {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	record := audit.Record{
+		Hook:      "branch-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Command:   input.ToolInput.Command,
+		FilePath:  input.ToolInput.FilePath,
+	}
+
+	if input.ToolName != "Bash" && state.Operation != "" && !*allowInProgress {
+		record.Decision = settings.BlockDecision()
+		record.Reason = "A " + state.Operation + " is in progress"
+		hookutil.WriteAudit(auditLog, record)
+		settings.Output().BlockPreToolUse(fmt.Sprintf("A %s is in progress in %s! Ask the user to finish or abort it (git %s --continue or --abort) before editing files.", state.Operation, state.Root, state.Operation),
+			[]string{fmt.Sprintf("%s of %s during a %s", input.ToolName, target, state.Operation)})
+		return
+	}
+	if !state.Protected(protected) {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	if *createBranch == "" {
+		branch := branchName(DefaultBranchTemplate, input.SessionID, time.Now())
+		record.Decision = settings.BlockDecision()
+		record.Reason = "Edit on protected branch " + state.Branch
+		hookutil.WriteAudit(auditLog, record)
+		settings.Output().BlockPreToolUse(fmt.Sprintf("On protected branch %s! Create a feature branch first, e.g. git switch -c %s", state.Branch, branch),
+			[]string{fmt.Sprintf("%s of %s on branch %s", input.ToolName, target, state.Branch)})
+		return
+	}
+
+	branch := branchName(*createBranch, input.SessionID, time.Now())
+	if input.ToolName == "Bash" {
+		// Switch in the command itself, so the commit lands on the new branch
+		rewritten := "git switch --create " + shellQuote(branch) + " && " + input.ToolInput.Command
+		record.Decision = audit.DecisionRewrite
+		record.Rewritten = rewritten
+		record.Reason = "Commit on protected branch " + state.Branch
+		hookutil.WriteAudit(auditLog, record)
+		decision := hook.PermissionAsk
+		if *approve {
+			decision = hook.PermissionAllow
+		}
+		hook.UpdatePreToolUseInput(decision, input.UpdatedToolInput(map[string]any{"command": rewritten}),
+			fmt.Sprintf("branch-guard: %s is protected, committing on new branch %s", state.Branch, branch))
+		return
+	}
+
+	// File tools have no command to rewrite, so the hook switches branches itself
+	if err := state.CreateBranch(branch); err != nil {
+		record.Decision = settings.BlockDecision()
+		record.Reason = "Failed to create branch " + branch
+		record.Issues = []string{err.Error()}
+		hookutil.WriteAudit(auditLog, record)
+		settings.Output().BlockPreToolUse(fmt.Sprintf("On protected branch %s, and creating branch %s failed! Create a feature branch first.", state.Branch, branch), []string{err.Error()})
+		return
+	}
+	record.Decision = audit.DecisionAllow
+	record.Reason = "Switched from protected branch " + state.Branch + " to new branch " + branch
+	hookutil.WriteAudit(auditLog, record)
+	fmt.Fprintf(os.Stderr, "branch-guard: switched from protected branch %s to new branch %s\n", state.Branch, branch)
+	hook.AllowPreToolUse()
+}
+
+// gitCommitRule matches git commit, including after global flags such as -C.
+var gitCommitRule = detector.CommandRule{
+	BlockedCommand: "git",
+	Args:           detector.ArgSpec{ValueFlags: []string{"-C", "-c", "--git-dir", "--work-tree", "--namespace", "--config-env"}},
+	Match: func(inv detector.Invocation) string {
+		if inv.Subcommand(0) == "commit" {
+			return "git commit"
+		}
+		return ""
+	},
+}
+
+// isCommit reports whether a shell expression runs git commit.
+func isCommit(command string) bool {
+	return detector.NewCommandDetector([]detector.CommandRule{gitCommitRule}, defaultMaxRecursion).ShouldBlockShellExpr(command)
+}
+
+// branchName expands the {session} (the first 8 characters of the session
+// ID) and {date} (YYYYMMDD) placeholders of a branch name template.
+func branchName(template, sessionID string, now time.Time) string {
+	session := sessionID
+	if len(session) > 8 {
+		session = session[:8]
+	}
+	if session == "" {
+		session = now.Format("150405")
+	}
+	return strings.NewReplacer("{session}", session, "{date}", now.Format("20060102")).Replace(template)
+}
+
+// shellQuote quotes a branch name for the shell unless it is plain.
+func shellQuote(s string) string {
+	if quoted, err := syntax.Quote(s, syntax.LangBash); err == nil {
+		return quoted
+	}
+	return s // Only fails on control characters, which git rejects in names
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `branch-guard: Protected branch guard for Claude Code hooks
+
+Blocks Edit/MultiEdit/Write/NotebookEdit calls on files in a git repository
+whose checked-out branch is protected (main and master by default), nudging
+Claude to create a feature branch first. Edits are also blocked while a
+rebase, merge, cherry-pick, or revert is stopped partway, e.g. on a conflict.
+Each file is checked in its own repository or worktree; files outside git
+are not checked.
+
+With -create-branch, a protected branch is left instead of blocked: before a
+file edit the hook runs git switch --create itself, since file tools have no
+command to change, and a Bash git commit is rewritten to run
+git switch --create BRANCH first (shown to the user for approval unless
+-approve is given). Uncommitted changes carry over to the new branch.
+
+USAGE:
+    branch-guard [-protect GLOB ...] [-create-branch NAME] [OPTIONS]
+
+OPTIONAL:
+    -protect string
+            Protected branch glob, e.g. main or release/* (can be specified
+            multiple times; default: main and master)
+
+    -create-branch string
+            Create and switch to this branch instead of blocking. {session}
+            is replaced with the first 8 characters of the session ID and
+            {date} with YYYYMMDD, e.g. claude/{session}
+
+    -approve
+            Run commits rewritten by -create-branch without a permission prompt
+
+    -allow-in-progress
+            Allow edits while a rebase, merge, cherry-pick, or revert is in
+            progress, e.g. so Claude can resolve conflicts
+
+    -fail-mode string
+            Behavior when input cannot be parsed or git fails: open (allow)
+            or closed (block) (default: open)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every blocked edit and created branch to
+            this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_BRANCH_GUARD_<FLAG> to target only this hook.
+
+EXAMPLES:
+    branch-guard -protect main -protect 'release/*'
+    branch-guard -create-branch 'claude/{date}-{session}'
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Edit|MultiEdit|Write|NotebookEdit|Bash",
+        "hooks": [{"type": "command", "command": "/path/to/branch-guard"}]
+      }
+    ]
+  }
+}
+
+`)
+}
@@ -0,0 +1,101 @@
+package branchguard
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestRepo creates a git repository with one commit on main.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "initial"},
+	} {
+		if _, err := git(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestInspect(t *testing.T) {
+	repo := newTestRepo(t)
+
+	state, inRepo, err := Inspect(filepath.Join(repo, "new", "dir", "file.go"))
+	if err != nil || !inRepo {
+		t.Fatalf("Inspect() = %v, %v, want a repository", inRepo, err)
+	}
+	if state.Root != repo || state.Branch != "main" || state.Operation != "" {
+		t.Errorf("Inspect() = %+v", state)
+	}
+	if !state.Protected(defaultProtected) || state.Protected([]string{"release/*"}) {
+		t.Errorf("Protected() mismatch for branch %s", state.Branch)
+	}
+
+	if err := os.WriteFile(filepath.Join(state.GitDir, "MERGE_HEAD"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if state, _, _ := Inspect(repo); state.Operation != "merge" {
+		t.Errorf("Inspect() operation = %q, want merge", state.Operation)
+	}
+	if err := os.Remove(filepath.Join(state.GitDir, "MERGE_HEAD")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := state.CreateBranch("claude/test"); err != nil {
+		t.Fatal(err)
+	}
+	if state, _, _ := Inspect(repo); state.Branch != "claude/test" || state.Protected(defaultProtected) {
+		t.Errorf("Inspect() after CreateBranch = %+v", state)
+	}
+
+	if _, inRepo, err := Inspect(t.TempDir()); err != nil || inRepo {
+		t.Errorf("Inspect() outside a repository = %v, %v, want false", inRepo, err)
+	}
+}
+
+func TestIsCommit(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"git commit -m 'fix'", true},
+		{"git add . && git commit -am wip", true},
+		{"git -C sub commit", true},
+		{"git status", false},
+		{"git log --grep commit", false},
+	}
+	for _, tt := range tests {
+		if got := isCommit(tt.command); got != tt.want {
+			t.Errorf("isCommit(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestBranchName(t *testing.T) {
+	now := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		template, session, want string
+	}{
+		{"claude/{session}", "0b7d5e2c-1f4a-4e55-9d0a-1c2b3d4e5f60", "claude/0b7d5e2c"},
+		{"claude/{date}-{session}", "abc", "claude/20250304-abc"},
+		{"claude/{session}", "", "claude/050607"},
+		{"feature/agent", "abc", "feature/agent"},
+	}
+	for _, tt := range tests {
+		if got := branchName(tt.template, tt.session, now); got != tt.want {
+			t.Errorf("branchName(%q, %q) = %q, want %q", tt.template, tt.session, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Package branchguard - git repository state
+package branchguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitTimeout bounds each git command the hook runs.
+const gitTimeout = 5 * time.Second
+
+// operationFiles map the files git keeps in its directory while an operation
+// is stopped partway, e.g. on a conflict, to the operation.
+var operationFiles = []struct{ file, operation string }{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+}
+
+// RepoState is the state of the git repository, or worktree, holding a file.
+type RepoState struct {
+	Root      string // Top-level directory of the worktree
+	GitDir    string // The worktree's git directory
+	Branch    string // Checked-out branch; empty when HEAD is detached
+	Operation string // Operation in progress, e.g. rebase or merge; empty if none
+}
+
+// Inspect returns the state of the repository holding path, which need not
+// exist yet, and false if it is not in a git repository.
+func Inspect(path string) (RepoState, bool, error) {
+	dir := existingDir(path)
+	output, err := git(dir, "rev-parse", "--show-toplevel", "--absolute-git-dir")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return RepoState{}, false, nil // Not a repository, or a bare one
+		}
+		return RepoState{}, false, err
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		return RepoState{}, false, fmt.Errorf("git rev-parse: unexpected output %q", output)
+	}
+	state := RepoState{Root: lines[0], GitDir: lines[1]}
+
+	// Fails with exit status 1 when HEAD is detached
+	if branch, err := git(dir, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		state.Branch = strings.TrimSpace(branch)
+	}
+	for _, op := range operationFiles {
+		if _, err := os.Stat(filepath.Join(state.GitDir, op.file)); err == nil {
+			state.Operation = op.operation
+			break
+		}
+	}
+	return state, true, nil
+}
+
+// Protected reports whether the checked-out branch matches one of the
+// protected branch patterns, such as main or release/*.
+func (s RepoState) Protected(patterns []string) bool {
+	if s.Branch == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, s.Branch); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateBranch creates branch at HEAD and switches to it. Uncommitted changes
+// carry over to the new branch.
+func (s RepoState) CreateBranch(branch string) error {
+	_, err := git(s.Root, "switch", "--create", branch)
+	return err
+}
+
+// existingDir returns the nearest existing directory containing path, so new
+// files in new directories are attributed to the right repository.
+func existingDir(path string) string {
+	dir := path
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// git runs a git command in dir and returns its stdout. Errors from git itself
+// wrap an *exec.ExitError.
+func git(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("git %s: %w", args[0], ctx.Err())
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s: %w", args[0], strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,384 @@
+// Package commandrewrite implements the command-rewrite hook, which rewrites Bash commands before they run
+package commandrewrite
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// defaultMaxRecursion is how deep rewritten commands are checked, as in bash-block.
+const defaultMaxRecursion = 10
+
+// listFlag allows multiple -rewrite, -add-flag, and -preset flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var rewriteFlags, addFlagFlags, presetFlags listFlag
+	flag.Var(&rewriteFlags, "rewrite", "Rewrite rule \"FROM => TO\", e.g. \"pip install => uv pip install\" (can be specified multiple times)")
+	flag.Var(&addFlagFlags, "add-flag", "Flag rule \"COMMAND += FLAG\", e.g. \"kubectl apply += --dry-run=client\" (can be specified multiple times)")
+	flag.Var(&presetFlags, "preset", "Built-in rules: "+strings.Join(presetNames(), ", ")+" (can be specified multiple times)")
+	approve := flag.Bool("approve", false, "Run rewritten commands without a permission prompt, unless a -rules or project policy rule applied")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := config.RegisterFlags(flag.CommandLine, config.FailOpen)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "command-rewrite"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "command-rewrite", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:           "command-rewrite",
+		Events:         []string{hook.EventPreToolUse},
+		Tools:          []string{"Bash"},
+		PolicySections: []string{"rewrites"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+
+	flagRules, err := buildRules(rewriteFlags, addFlagFlags, presetFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		failInternal(settings, auditLog, "Failed to parse hook input", err)
+		return
+	}
+	if input.ToolName != "Bash" {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	layers, err := config.LoadLayers(context.Background(), settings, input.Cwd)
+	if err != nil {
+		failInternal(settings, auditLog, "Failed to load rewrites", err)
+		return
+	}
+	policy := config.Merge(layers)
+	policyRules, err := layerRules(layers)
+	if err != nil {
+		failInternal(settings, auditLog, "Failed to load rewrites", err)
+		return
+	}
+
+	// The hook's own flags win over policy files, which a project can add to
+	command := input.ToolInput.Command
+	rewritten, applied, err := Rewrite(command, slices.Concat(flagRules, policyRules))
+	if err != nil {
+		failInternal(settings, auditLog, "Failed to parse command", err)
+		return
+	}
+	logger.Debug("rewrote command", "command", command, "rewritten", rewritten, "rules", applied)
+	if len(applied) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	// bash-block checks the command Claude wrote, never the rewritten one
+	if issues := blockedIssues(policy, input.Cwd, rewritten, time.Now()); len(issues) > 0 {
+		hookutil.WriteAudit(auditLog, audit.Record{
+			Hook:      "command-rewrite",
+			Event:     hook.EventPreToolUse,
+			SessionID: input.SessionID,
+			ToolName:  input.ToolName,
+			Decision:  audit.DecisionBlock,
+			Reason:    "Rewritten command is blocked",
+			Issues:    issues,
+			Rules:     ruleNames(applied),
+			Command:   command,
+			Rewritten: rewritten,
+		})
+		hook.BlockPreToolUse("Rewritten command is blocked: "+rewritten, issues)
+		return
+	}
+
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "command-rewrite",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  audit.DecisionRewrite,
+		Rules:     ruleNames(applied),
+		Command:   command,
+		Rewritten: rewritten,
+	})
+	hook.UpdatePreToolUseInput(decision(*approve, applied), input.UpdatedToolInput(map[string]any{"command": rewritten}), "Rewritten by command-rewrite: "+rewritten)
+}
+
+// blockedIssues checks a rewritten command against the bash-block rules of
+// the policy files: command rules, protected redirection targets, and
+// protected SSH hosts. It returns the issues when they block the command.
+func blockedIssues(policy *config.Policy, cwd, command string, now time.Time) []string {
+	commandDetector := detector.NewCommandDetector(policy.CommandRules(now), defaultMaxRecursion)
+	commandDetector.SetProtectedRedirects(cwd, policy.RedirectPatterns(now))
+	commandDetector.SetProtectedHosts(policy.SSHHostPatterns(now))
+	if !commandDetector.ShouldBlockShellExpr(command) {
+		return nil
+	}
+	return commandDetector.GetIssues()
+}
+
+// decision returns the permission decision for a rewritten command: allow
+// with -approve when every applied rule is trusted, otherwise ask, so the
+// user sees what a project's rewrites make of the command.
+func decision(approve bool, applied []Rule) string {
+	if approve && !slices.ContainsFunc(applied, func(rule Rule) bool { return !rule.Trusted }) {
+		return hook.PermissionAllow
+	}
+	return hook.PermissionAsk
+}
+
+// buildRules returns the rules of the -rewrite, -add-flag, and -preset flags,
+// in that order, so a rule given on the command line wins over a preset. They
+// are all trusted.
+func buildRules(rewrites, addFlags, presetList []string) ([]Rule, error) {
+	var rules []Rule
+	for _, spec := range rewrites {
+		rule, err := ParseRewrite(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	for _, spec := range addFlags {
+		rule, err := ParseAddFlag(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	for _, name := range presetList {
+		preset, ok := presets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q", name)
+		}
+		rules = append(rules, preset...)
+	}
+	for i := range rules {
+		rules[i].Trusted = true
+	}
+	return rules, nil
+}
+
+// layerRules returns the rewrites of the policy layers, the most general
+// layer's first. Only the system and user layers' rules are trusted: the
+// -rules source and the project .claudehooks.yaml may be written by Claude.
+func layerRules(layers []config.Layer) ([]Rule, error) {
+	var rules []Rule
+	for _, layer := range layers {
+		scoped, err := policyRules(layer.Policy.Rewrites)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", layer.Source, err)
+		}
+		for i := range scoped {
+			scoped[i].Trusted = layer.Scope == config.ScopeSystem || layer.Scope == config.ScopeUser
+		}
+		rules = append(rules, scoped...)
+	}
+	return rules, nil
+}
+
+// policyRules returns the rules of the rewrites policy section.
+func policyRules(rewrites []config.Rewrite) ([]Rule, error) {
+	rules := make([]Rule, 0, len(rewrites))
+	for _, rewrite := range rewrites {
+		var rule Rule
+		var err error
+		if rewrite.AddFlag != "" {
+			rule, err = NewFlagRule(rewrite.From, rewrite.AddFlag)
+		} else {
+			rule, err = NewRewriteRule(rewrite.From, rewrite.To)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ruleNames returns the rules as given on the command line, for the audit log.
+func ruleNames(rules []Rule) []string {
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = rule.String()
+	}
+	return names
+}
+
+// failInternal handles an error that prevents rewriting according to the fail
+// mode: fail open runs the command unchanged, fail closed blocks it.
+func failInternal(settings *config.Settings, auditLog *audit.Logger, message string, err error) {
+	decision := audit.DecisionBlock
+	if settings.FailMode == config.FailOpen {
+		decision = audit.DecisionAllow
+	}
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "command-rewrite",
+		Event:     hook.EventPreToolUse,
+		Decision:  decision,
+		Reason:    message,
+		ErrorKind: config.ErrorKind(err),
+		Issues:    []string{err.Error()},
+	})
+
+	if settings.FailMode == config.FailOpen {
+		fmt.Fprintf(os.Stderr, "Warning: %s (running it unchanged, fail mode is open): %v\n", message, err)
+		hook.Exit(config.FailOpenExitCode(err))
+		return
+	}
+	hook.BlockPreToolUse(config.FailureMessage(message, err), []string{err.Error()})
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `command-rewrite: Bash command rewriting for Claude Code hooks
+
+Rewrites Bash commands before they run, through the updatedInput of a
+PreToolUse hook: substitutes a safer command, as in rm -rf => trash, or adds
+a flag, as in kubectl delete += --dry-run=client. Every command of a list or
+pipeline is rewritten, after wrappers such as env and command. The rest of the
+command line is kept as written.
+
+The rewritten command is shown to the user for approval, since it may not be
+what they allowed; -approve runs it without a prompt when every rule that
+applied comes from these flags or the system or user policy. bash-block only sees
+the command as Claude wrote it, so a rewritten command the bash-block rules
+of the policy files would block is blocked here. Every rewrite is recorded
+in the -audit-log. Commands run by another shell, as in
+bash -c '...', are not rewritten.
+
+USAGE:
+    command-rewrite [-rewrite "FROM => TO" ...] [-add-flag "COMMAND += FLAG" ...]
+                    [-preset NAME ...] [OPTIONS]
+
+RULES:
+    Rules come from these flags and the rewrites section of policy files,
+    which are tried after them. The first rule matching a command applies.
+
+    -rewrite string
+            Replace the leading words of a command, e.g.
+            "pip install => uv pip install" (can be specified multiple
+            times). Options at the end of FROM must be the command's only
+            leading options, in any order or combination: "rm -rf" matches
+            rm -rf, rm -fr, and rm -r -f, but not rm -rfi.
+
+    -add-flag string
+            Add a flag after the leading words of a command unless it is
+            already given, e.g. "kubectl apply += --dry-run=client" (can be
+            specified multiple times)
+
+    -preset string
+            Built-in rules (can be specified multiple times):
+              uv       - pip install, pip3 install, and python -m pip install
+                         => uv pip install
+              dry-run  - --dry-run for kubectl apply/delete (=client),
+                         helm install/upgrade, and rsync
+              safe     - rm -r/-rf => trash, git push --force/-f =>
+                         git push --force-with-lease, terraform apply =>
+                         terraform plan, terraform destroy =>
+                         terraform plan -destroy, and
+                         kubectl delete += --dry-run=client
+
+OPTIONAL:
+    -approve
+            Run rewritten commands without a permission prompt, unless a
+            rule from the -rules source or the project .claudehooks.yaml
+            applied
+
+    -rules string
+            Policy file or URL whose rewrites are used
+
+    -discover
+            Load .claudehooks.yaml found by walking up from the payload cwd
+            (default: true; use -discover=false to disable)
+
+    -fail-mode string
+            Behavior when input, the command, or policy files cannot be
+            parsed: open (run it unchanged) or closed (block) (default: open)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -audit-log string
+            Append a JSONL record of every rewritten command to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_COMMAND_REWRITE_<FLAG> to target only this hook.
+
+EXAMPLES:
+    command-rewrite -preset uv
+    command-rewrite -preset safe -audit-log ~/.claude/audit.jsonl
+    command-rewrite -preset dry-run -add-flag "npm install += --ignore-scripts"
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Bash",
+        "hooks": [{"type": "command", "command": "/path/to/command-rewrite -preset safe"}]
+      }
+    ]
+  }
+}
+
+`)
+}
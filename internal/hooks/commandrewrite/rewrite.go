@@ -0,0 +1,239 @@
+// Package commandrewrite - rules that rewrite commands
+package commandrewrite
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// Rule rewrites the commands that start with Prefix: it replaces Prefix with
+// Replace, or adds AddFlag after it unless the command already has the flag.
+type Rule struct {
+	Prefix []string // Command words to match, e.g. [pip install]
+	// Options the command must have right after Prefix, and no others, in
+	// any order or combination: [-rf] matches rm -rf, rm -fr, and rm -r -f.
+	// They are replaced along with Prefix.
+	Options []string
+	Replace []string // Words to replace Prefix with, e.g. [uv pip install]
+	AddFlag string   // Flag to add after Prefix, e.g. --dry-run=client
+
+	// Trusted rules come from the hook's flags or the system or user policy,
+	// not from files in the project, so -approve may run their rewrites
+	// without a prompt.
+	Trusted bool
+}
+
+// String returns the rule as given on the command line.
+func (r Rule) String() string {
+	from := strings.Join(slices.Concat(r.Prefix, r.Options), " ")
+	if r.AddFlag != "" {
+		return from + " += " + r.AddFlag
+	}
+	return from + " => " + strings.Join(r.Replace, " ")
+}
+
+// presets are the built-in rule sets of -preset.
+var presets = map[string][]Rule{
+	// Install Python packages with uv instead of pip
+	"uv": {
+		{Prefix: []string{"pip", "install"}, Replace: []string{"uv", "pip", "install"}},
+		{Prefix: []string{"pip3", "install"}, Replace: []string{"uv", "pip", "install"}},
+		{Prefix: []string{"python", "-m", "pip", "install"}, Replace: []string{"uv", "pip", "install"}},
+		{Prefix: []string{"python3", "-m", "pip", "install"}, Replace: []string{"uv", "pip", "install"}},
+	},
+	// Preview changes to clusters and remote hosts instead of making them
+	"dry-run": {
+		{Prefix: []string{"kubectl", "apply"}, AddFlag: "--dry-run=client"},
+		{Prefix: []string{"kubectl", "delete"}, AddFlag: "--dry-run=client"},
+		{Prefix: []string{"helm", "install"}, AddFlag: "--dry-run"},
+		{Prefix: []string{"helm", "upgrade"}, AddFlag: "--dry-run"},
+		{Prefix: []string{"rsync"}, AddFlag: "--dry-run"},
+	},
+	// Substitute recoverable or read-only equivalents for destructive commands
+	"safe": {
+		{Prefix: []string{"rm"}, Options: []string{"-rf"}, Replace: []string{"trash"}},
+		{Prefix: []string{"rm"}, Options: []string{"-Rf"}, Replace: []string{"trash"}},
+		{Prefix: []string{"rm"}, Options: []string{"-r"}, Replace: []string{"trash"}},
+		{Prefix: []string{"rm"}, Options: []string{"-R"}, Replace: []string{"trash"}},
+		{Prefix: []string{"git", "push"}, Options: []string{"--force"}, Replace: []string{"git", "push", "--force-with-lease"}},
+		{Prefix: []string{"git", "push"}, Options: []string{"-f"}, Replace: []string{"git", "push", "--force-with-lease"}},
+		{Prefix: []string{"terraform", "apply"}, Replace: []string{"terraform", "plan"}},
+		{Prefix: []string{"terraform", "destroy"}, Replace: []string{"terraform", "plan", "-destroy"}},
+		{Prefix: []string{"kubectl", "delete"}, AddFlag: "--dry-run=client"},
+	},
+}
+
+// presetNames returns the names of the built-in presets, sorted.
+func presetNames() []string {
+	return slices.Sorted(maps.Keys(presets))
+}
+
+// ParseRewrite parses a -rewrite rule, "FROM => TO", e.g.
+// "pip install => uv pip install" or "rm -rf => trash".
+func ParseRewrite(spec string) (Rule, error) {
+	from, to, found := strings.Cut(spec, "=>")
+	if !found {
+		return Rule{}, fmt.Errorf("invalid rewrite %q (want \"FROM => TO\")", spec)
+	}
+	return NewRewriteRule(from, to)
+}
+
+// ParseAddFlag parses an -add-flag rule, "COMMAND += FLAG", e.g.
+// "kubectl apply += --dry-run=client".
+func ParseAddFlag(spec string) (Rule, error) {
+	command, flag, found := strings.Cut(spec, "+=")
+	if !found {
+		return Rule{}, fmt.Errorf("invalid flag rule %q (want \"COMMAND += -FLAG\")", spec)
+	}
+	return NewFlagRule(command, flag)
+}
+
+// NewRewriteRule returns a rule replacing the command words from with to.
+// Options at the end of from, as in "rm -rf", become the rule's Options.
+func NewRewriteRule(from, to string) (Rule, error) {
+	words := strings.Fields(from)
+	split := len(words)
+	for split > 1 && isOption(words[split-1]) {
+		split--
+	}
+	rule := Rule{Prefix: words[:split], Replace: strings.Fields(to)}
+	if split < len(words) {
+		rule.Options = words[split:]
+	}
+	if len(rule.Prefix) == 0 || len(rule.Replace) == 0 {
+		return Rule{}, fmt.Errorf("invalid rewrite %q => %q: both sides need a command", strings.TrimSpace(from), strings.TrimSpace(to))
+	}
+	return rule, nil
+}
+
+// NewFlagRule returns a rule adding flag to the commands starting with the
+// words of command.
+func NewFlagRule(command, flag string) (Rule, error) {
+	rule := Rule{Prefix: strings.Fields(command), AddFlag: strings.TrimSpace(flag)}
+	if len(rule.Prefix) == 0 || !isOption(rule.AddFlag) || strings.ContainsAny(rule.AddFlag, " \t") {
+		return Rule{}, fmt.Errorf("invalid flag rule %q += %q: want a command and a single flag", strings.TrimSpace(command), rule.AddFlag)
+	}
+	return rule, nil
+}
+
+// isOption reports whether a command word is an option such as -f or --force.
+func isOption(word string) bool {
+	return len(word) > 1 && strings.HasPrefix(word, "-") && word != "--"
+}
+
+// optionSet returns the single options of option words, splitting combined
+// short options: -rf and -r -f both give {-r, -f}.
+func optionSet(words []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range words {
+		if strings.HasPrefix(word, "--") {
+			set[word] = true
+			continue
+		}
+		for _, r := range word[1:] {
+			set["-"+string(r)] = true
+		}
+	}
+	return set
+}
+
+// edit replaces source[start:end] with text.
+type edit struct {
+	start, end uint
+	text       string
+}
+
+// Rewrite applies the first matching rule to every command in command, such
+// as each side of a && list, and returns the result with the rules that
+// applied. Commands are matched after wrappers such as env and command (see
+// shellparse.NormalizeCall), by their static words; the rest of the command
+// line is kept as written. Commands in strings run by another shell, as in
+// bash -c '...', are not rewritten.
+func Rewrite(command string, rules []Rule) (string, []Rule, error) {
+	node, err := shellparse.Parse(command)
+	if err != nil {
+		return command, nil, err
+	}
+
+	var edits []edit
+	var applied []Rule
+	for _, call := range shellparse.CallExprs(node) {
+		words := shellparse.NormalizeCall(call).Args
+		for _, rule := range rules {
+			e, ok := rule.edit(words)
+			if !ok {
+				continue
+			}
+			edits = append(edits, e)
+			if !slices.ContainsFunc(applied, func(r Rule) bool { return r.String() == rule.String() }) {
+				applied = append(applied, rule)
+			}
+			break
+		}
+	}
+	if len(edits) == 0 {
+		return command, nil, nil
+	}
+
+	// Apply from the end so earlier offsets stay valid
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	rewritten := command
+	for _, e := range edits {
+		rewritten = rewritten[:e.start] + e.text + rewritten[e.end:]
+	}
+	return rewritten, applied, nil
+}
+
+// edit returns the edit the rule makes to a command with the given words, or
+// false if the command does not start with its prefix and options, or already
+// has its flag.
+func (r Rule) edit(words []*syntax.Word) (edit, bool) {
+	if len(words) < len(r.Prefix) {
+		return edit{}, false
+	}
+	for i, want := range r.Prefix {
+		value, isStatic := shellparse.StaticWord(words[i])
+		if i == 0 {
+			value = path.Base(value) // /usr/bin/pip runs pip
+		}
+		if !isStatic || value != want {
+			return edit{}, false
+		}
+	}
+
+	last := words[len(r.Prefix)-1]
+	if r.AddFlag == "" {
+		if len(r.Options) > 0 {
+			var options []string
+			for _, word := range words[len(r.Prefix):] {
+				value, isStatic := shellparse.StaticWord(word)
+				if !isStatic || !isOption(value) {
+					break
+				}
+				options = append(options, value)
+				last = word
+			}
+			if !maps.Equal(optionSet(options), optionSet(r.Options)) {
+				return edit{}, false
+			}
+		}
+		return edit{start: words[0].Pos().Offset(), end: last.End().Offset(), text: strings.Join(r.Replace, " ")}, true
+	}
+	name, _, _ := strings.Cut(r.AddFlag, "=")
+	for _, word := range words[len(r.Prefix):] {
+		value, _ := shellparse.StaticWord(word)
+		if value == r.AddFlag || value == name || strings.HasPrefix(value, name+"=") {
+			return edit{}, false
+		}
+	}
+	end := last.End().Offset()
+	return edit{start: end, end: end, text: " " + r.AddFlag}, true
+}
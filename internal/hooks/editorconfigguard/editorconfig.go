@@ -0,0 +1,326 @@
+// Package editorconfigguard - .editorconfig parsing
+package editorconfigguard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Properties are the .editorconfig properties that apply to a file, keyed by
+// lowercase name. Values of the properties the hook checks are lowercased.
+type Properties map[string]string
+
+// Section is a [glob] section of an .editorconfig file.
+type Section struct {
+	Glob       string
+	Properties Properties
+	re         *regexp.Regexp
+	ranges     []numberRange
+}
+
+// numberRange is a {num1..num2} glob, matched by the capture group of the
+// same index.
+type numberRange struct{ low, high int }
+
+// Editorconfig is a parsed .editorconfig file.
+type Editorconfig struct {
+	Path     string
+	Root     bool // Set by root = true; files above it are not read
+	Sections []Section
+}
+
+// lowercaseValues are the properties whose values are case-insensitive.
+var lowercaseValues = map[string]bool{
+	"indent_style": true, "indent_size": true, "tab_width": true, "end_of_line": true,
+	"charset": true, "trim_trailing_whitespace": true, "insert_final_newline": true, "root": true,
+}
+
+// Resolve returns the properties that apply to file: the sections of every
+// .editorconfig from its directory up to the first one with root = true,
+// where nearer files and later sections take precedence. A property set to
+// "unset" is removed.
+func Resolve(file string) (Properties, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	var configs []*Editorconfig
+	for dir := filepath.Dir(abs); ; {
+		config, err := LoadEditorconfig(filepath.Join(dir, ".editorconfig"))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			configs = append(configs, config)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || (config != nil && config.Root) {
+			break
+		}
+		dir = parent
+	}
+
+	properties := Properties{}
+	for i := len(configs) - 1; i >= 0; i-- {
+		configs[i].apply(abs, properties)
+	}
+	for name, value := range properties {
+		if value == "unset" {
+			delete(properties, name)
+		}
+	}
+	return properties, nil
+}
+
+// apply sets the properties of the sections matching file in properties.
+func (e *Editorconfig) apply(file string, properties Properties) {
+	rel, err := filepath.Rel(filepath.Dir(e.Path), file)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	for _, section := range e.Sections {
+		if section.Match(rel) {
+			for name, value := range section.Properties {
+				properties[name] = value
+			}
+		}
+	}
+}
+
+// LoadEditorconfig reads and parses an .editorconfig file.
+func LoadEditorconfig(file string) (*Editorconfig, error) {
+	f, err := os.Open(file) // #nosec G304 - .editorconfig of the edited file's directories
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	config, err := ParseEditorconfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	config.Path = abs
+	return config, nil
+}
+
+// ParseEditorconfig parses .editorconfig content. Comments start with # or ;,
+// and properties before the first section apply to the file itself, where
+// only root is meaningful.
+func ParseEditorconfig(r io.Reader) (*Editorconfig, error) {
+	config := &Editorconfig{}
+	var section *Section
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || text[0] == '#' || text[0] == ';':
+		case text[0] == '[' && text[len(text)-1] == ']':
+			glob := text[1 : len(text)-1]
+			re, ranges, err := compileGlob(glob)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("line %d: invalid glob %q: %w", line, glob, err))
+				section = nil
+				continue
+			}
+			config.Sections = append(config.Sections, Section{Glob: glob, Properties: Properties{}, re: re, ranges: ranges})
+			section = &config.Sections[len(config.Sections)-1]
+		default:
+			name, value, ok := strings.Cut(text, "=")
+			if !ok {
+				errs = append(errs, fmt.Errorf("line %d: expected [glob] or name = value", line))
+				continue
+			}
+			name = strings.ToLower(strings.TrimSpace(name))
+			value = strings.TrimSpace(value)
+			if lowercaseValues[name] {
+				value = strings.ToLower(value)
+			}
+			if section != nil {
+				section.Properties[name] = value
+			} else if name == "root" {
+				config.Root = value == "true"
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return config, errors.Join(errs...)
+}
+
+// Match reports whether the section applies to rel, a slash-separated path
+// relative to the directory of its .editorconfig.
+func (s *Section) Match(rel string) bool {
+	match := s.re.FindStringSubmatch(rel)
+	if match == nil {
+		return false
+	}
+	for i, r := range s.ranges {
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil || n < r.low || n > r.high {
+			return false
+		}
+	}
+	return true
+}
+
+// compileGlob translates an .editorconfig glob into a regular expression
+// matching slash-separated paths relative to the .editorconfig directory:
+//
+//   - a glob without a slash matches files in any directory; otherwise it is
+//     anchored at the directory, and a leading slash is dropped
+//   - * and ? do not cross slashes, and ** matches any string
+//   - [name] and [!name] match a character in or not in name
+//   - {s1,s2} matches any of the strings, and {num1..num2} an integer in the
+//     range
+func compileGlob(glob string) (*regexp.Regexp, []numberRange, error) {
+	anchored := strings.Contains(glob, "/")
+	glob = strings.TrimPrefix(glob, "/")
+	if glob == "" {
+		return nil, nil, errors.New("empty glob")
+	}
+	var ranges []numberRange
+	expr, err := translateGlob(glob, &ranges)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	return re, ranges, err
+}
+
+// numberRangePattern matches the inside of a {num1..num2} glob.
+var numberRangePattern = regexp.MustCompile(`^([+-]?\d+)\.\.([+-]?\d+)$`)
+
+// translateGlob translates glob into a regular expression, recording the
+// {num1..num2} ranges it captures in ranges.
+func translateGlob(glob string, ranges *[]numberRange) (string, error) {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				expr.WriteString(".*")
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			negate := strings.HasPrefix(class, "!")
+			class = strings.TrimPrefix(class, "!")
+			expr.WriteString("[")
+			if negate {
+				expr.WriteString("^")
+			}
+			expr.WriteString(strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '{':
+			end := matchingBrace(glob, i)
+			if end < 0 {
+				expr.WriteString(`\{`)
+				continue
+			}
+			inner := glob[i+1 : end]
+			i = end
+			if m := numberRangePattern.FindStringSubmatch(inner); m != nil {
+				low, _ := strconv.Atoi(m[1])
+				high, _ := strconv.Atoi(m[2])
+				*ranges = append(*ranges, numberRange{min(low, high), max(low, high)})
+				expr.WriteString(`([+-]?\d+)`)
+				continue
+			}
+			alternatives := splitAlternatives(inner)
+			if len(alternatives) < 2 {
+				expr.WriteString(regexp.QuoteMeta("{" + inner + "}"))
+				continue
+			}
+			expr.WriteString("(?:")
+			for j, alternative := range alternatives {
+				if j > 0 {
+					expr.WriteString("|")
+				}
+				translated, err := translateGlob(alternative, ranges)
+				if err != nil {
+					return "", err
+				}
+				expr.WriteString(translated)
+			}
+			expr.WriteString(")")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String(), nil
+}
+
+// matchingBrace returns the index of the } closing the { at open, or -1.
+func matchingBrace(glob string, open int) int {
+	depth := 0
+	for i := open; i < len(glob); i++ {
+		switch glob[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitAlternatives splits the inside of a {s1,s2} glob at its top-level
+// commas.
+func splitAlternatives(inner string) []string {
+	var alternatives []string
+	depth, start := 0, 0
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alternatives = append(alternatives, inner[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(alternatives, inner[start:])
+}
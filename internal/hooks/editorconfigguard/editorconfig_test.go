@@ -0,0 +1,163 @@
+package editorconfigguard
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+func TestSectionMatch(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{"*", "main.go", true},
+		{"*", "cmd/app/main.go", true},
+		{"*.go", "cmd/app/main.go", true},
+		{"*.go", "main.go.orig", false},
+		{"*.{js,ts}", "web/app.ts", true},
+		{"*.{js,ts}", "web/app.tsx", false},
+		{"{package.json,*.yml}", "ci/build.yml", true},
+		{"Makefile", "sub/Makefile", true},
+		{"/Makefile", "sub/Makefile", false},
+		{"lib/*.js", "lib/a.js", true},
+		{"lib/*.js", "lib/sub/a.js", false},
+		{"lib/*.js", "src/lib/a.js", false},
+		{"lib/**.js", "lib/sub/a.js", true},
+		{"**/vendor/**", "a/vendor/b/c.go", true},
+		{"file[0-9].txt", "file7.txt", true},
+		{"file[!0-9].txt", "file7.txt", false},
+		{"?.md", "a.md", true},
+		{"?.md", "ab.md", false},
+		{"test{1..10}.txt", "test5.txt", true},
+		{"test{1..10}.txt", "test11.txt", false},
+		{"{single}.txt", "{single}.txt", true},
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+	}
+	for _, tt := range tests {
+		config, err := ParseEditorconfig(strings.NewReader("[" + tt.glob + "]\nindent_style = tab\n"))
+		if err != nil {
+			t.Fatalf("ParseEditorconfig(%q) error: %v", tt.glob, err)
+		}
+		if got := config.Sections[0].Match(tt.path); got != tt.want {
+			t.Errorf("[%s] Match(%q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".editorconfig", "root = true\n\n[*]\nindent_style = space\nindent_size = 2\ntrim_trailing_whitespace = true\n\n[*.go]\nindent_style = TAB\n\n[Makefile]\nindent_style = tab\n")
+	write("docs/.editorconfig", "; docs keep Markdown line breaks\n[*.md]\ntrim_trailing_whitespace = false\nindent_size = unset\n")
+	// Above the root: never read
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), ".editorconfig"), []byte("[*]\ncharset = latin1\n"), 0o600); err == nil {
+		t.Cleanup(func() { _ = os.Remove(filepath.Join(filepath.Dir(dir), ".editorconfig")) })
+	}
+
+	tests := []struct {
+		file string
+		want Properties
+	}{
+		{"main.go", Properties{"indent_style": "tab", "indent_size": "2", "trim_trailing_whitespace": "true"}},
+		{"web/app.js", Properties{"indent_style": "space", "indent_size": "2", "trim_trailing_whitespace": "true"}},
+		{"docs/guide.md", Properties{"indent_style": "space", "trim_trailing_whitespace": "false"}},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatalf("Resolve(%s) error: %v", tt.file, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Resolve(%s) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	spaces := Properties{"indent_style": "space", "trim_trailing_whitespace": "true", "insert_final_newline": "true"}
+	tabs := Properties{"indent_style": "tab", "indent_size": "4", "insert_final_newline": "false"}
+
+	tests := []struct {
+		name       string
+		properties Properties
+		content    string
+		want       []string
+	}{
+		{"clean spaces", spaces, "def f():\n    return 1\n", nil},
+		{"tab indent", spaces, "def f():\n\treturn 1\n", []string{"line 2: indented with a tab, but indent_style is space"}},
+		{"trailing whitespace", spaces, "a = 1 \r\nb = 2\r\n\t\n", []string{"line 1: trailing whitespace", "line 3: trailing whitespace"}},
+		{"missing final newline", spaces, "a = 1\nb = 2", []string{"line 2: no newline at end of file, but insert_final_newline is true"}},
+		{"empty file", spaces, "", nil},
+		{"clean tabs", tabs, "func f() {\n\treturn\n}", nil},
+		{"alignment after tabs", tabs, "/*\n * comment\n */\n\tx :=  1 // aligned\n\t  y", nil},
+		{"space indent", tabs, "func f() {\n    return\n}", []string{"line 2: indented with 4 or more spaces, but indent_style is tab"}},
+		{"unwanted final newline", tabs, "x\n", []string{"line 1: newline at end of file, but insert_final_newline is false"}},
+		{"no properties", Properties{}, "\tx  \n    y", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range tt.properties.Check(tt.content) {
+				got = append(got, v.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewViolations(t *testing.T) {
+	properties := Properties{"indent_style": "space", "trim_trailing_whitespace": "true", "insert_final_newline": "true"}
+	before := "legacy:\n\tkeep: 1 \nother: 2"
+	after := "legacy:\n\tkeep: 1 \nadded:\n\tnew: 3\nother: 2"
+
+	var got []string
+	for _, v := range properties.NewViolations(before, after) {
+		got = append(got, v.String())
+	}
+	want := []string{"line 4: indented with a tab, but indent_style is space"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewViolations() = %q, want %q", got, want)
+	}
+}
+
+func TestApplyEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edits   []hook.Edit
+		want    string
+		wantErr bool
+	}{
+		{"first occurrence", "a a a", []hook.Edit{{OldString: "a", NewString: "b"}}, "b a a", false},
+		{"replace all", "a a a", []hook.Edit{{OldString: "a", NewString: "b", ReplaceAll: true}}, "b b b", false},
+		{"sequential", "x = 1", []hook.Edit{{OldString: "x", NewString: "y"}, {OldString: "y = 1", NewString: "y = 2"}}, "y = 2", false},
+		{"new file", "", []hook.Edit{{NewString: "hello\n"}}, "hello\n", false},
+		{"not found", "abc", []hook.Edit{{OldString: "xyz", NewString: "b"}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyEdits(tt.content, tt.edits)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ApplyEdits() = %q, %v, want %q (error %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Package editorconfigguard implements the editorconfig-guard hook, which blocks edits whose whitespace does not follow .editorconfig
+package editorconfigguard
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// DefaultMaxIssues is how many violations a block message lists by default.
+const DefaultMaxIssues = 20
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	existing := flag.Bool("existing", false, "Also report violations the file already had, not only the ones the edit introduces")
+	maxIssues := flag.Int("max-issues", DefaultMaxIssues, "Most violations listed in a block message; 0 lists all")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailOpen}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input or an .editorconfig cannot be parsed, or the file cannot be read: open (allow) or closed (block)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every blocked edit to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "editorconfig-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "editorconfig-guard", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:   "editorconfig-guard",
+		Events: []string{hook.EventPreToolUse},
+		Tools:  []string{"Edit", "MultiEdit", "Write"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "editorconfig-guard", "Failed to parse hook input", err)
+		return
+	}
+	target := input.ToolInput.FilePath
+	if target == "" || (input.ToolName != "Edit" && input.ToolName != "MultiEdit" && input.ToolName != "Write") {
+		hook.AllowPreToolUse()
+		return
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(input.Cwd, target)
+	}
+
+	properties, err := Resolve(target)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "editorconfig-guard", "Failed to read .editorconfig", err)
+		return
+	}
+	if len(properties) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	original, err := os.ReadFile(target) // #nosec G304 - the file Claude is editing
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		hookutil.FailInternal(settings, auditLog, "editorconfig-guard", "Failed to read "+target, err)
+		return
+	}
+	before := string(original)
+	var after string
+	switch input.ToolName {
+	case "Write":
+		after = input.ToolInput.Content
+	case "Edit":
+		after, err = ApplyEdits(before, []hook.Edit{{OldString: input.ToolInput.OldString, NewString: input.ToolInput.NewString, ReplaceAll: input.ToolInput.ReplaceAll}})
+	case "MultiEdit":
+		after, err = ApplyEdits(before, input.ToolInput.Edits)
+	}
+	if err != nil || strings.IndexByte(after, 0) >= 0 {
+		// The edit fails on its own, or the file is binary
+		hook.AllowPreToolUse()
+		return
+	}
+
+	var violations []Violation
+	if *existing {
+		violations = properties.Check(after)
+	} else {
+		violations = properties.NewViolations(before, after)
+	}
+	logger.Debug("checked file", "file", target, "properties", properties, "violations", len(violations))
+	if len(violations) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	issues := formatIssues(input.ToolInput.FilePath, violations, *maxIssues)
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "editorconfig-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  settings.BlockDecision(),
+		Issues:    issues,
+		FilePath:  input.ToolInput.FilePath,
+	})
+	settings.Output().BlockPreToolUse(fmt.Sprintf("Whitespace does not follow .editorconfig (%s)! Fix these lines and retry.", describeProperties(properties)), issues)
+}
+
+// formatIssues lists violations as file:line references, at most limit of
+// them unless limit is 0.
+func formatIssues(file string, violations []Violation, limit int) []string {
+	issues := make([]string, 0, len(violations))
+	for i, v := range violations {
+		if limit > 0 && i == limit {
+			issues = append(issues, fmt.Sprintf("... and %d more", len(violations)-limit))
+			break
+		}
+		issues = append(issues, fmt.Sprintf("%s:%d: %s", file, v.Line, v.Message))
+	}
+	return issues
+}
+
+// describeProperties summarizes the checked properties for the block
+// message, e.g. "indent_style = tab, trim_trailing_whitespace = true".
+func describeProperties(properties Properties) string {
+	var parts []string
+	for _, name := range []string{"indent_style", "indent_size", "trim_trailing_whitespace", "insert_final_newline"} {
+		if value, ok := properties[name]; ok {
+			parts = append(parts, name+" = "+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `editorconfig-guard: .editorconfig whitespace checks for Claude Code hooks
+
+Blocks Edit, MultiEdit, and Write calls whose result does not follow the
+.editorconfig of the file, listing each offending line, so Claude fixes its
+whitespace without a formatter installed. Checked properties:
+
+    indent_style                Lines indented with tabs when it is space, or
+                                with indent_size (or tab_width, default 4) or
+                                more spaces when it is tab; fewer spaces after
+                                the tabs are alignment
+    trim_trailing_whitespace    Spaces or tabs at the end of a line when true
+    insert_final_newline        A missing newline at the end of the file when
+                                true, or one that is there when false
+
+The .editorconfig files from the file's directory up to the first with
+root = true are read, as editors do. Only violations the edit introduces are
+reported, so a file that already breaks the rules can still be edited.
+
+USAGE:
+    editorconfig-guard [-existing] [-max-issues 20] [OPTIONS]
+
+OPTIONAL:
+    -existing
+            Also report violations the file already had, not only the ones
+            the edit introduces
+
+    -max-issues int
+            Most violations listed in a block message; 0 lists all (default: 20)
+
+    -fail-mode string
+            Behavior when input or an .editorconfig cannot be parsed, or the
+            file cannot be read: open (allow) or closed (block) (default: open)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every blocked edit to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_EDITORCONFIG_GUARD_<FLAG> to target only this hook.
+
+EXAMPLES:
+    # Hold every edited file to .editorconfig, not only the edited lines
+    editorconfig-guard -existing
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Edit|MultiEdit|Write",
+        "hooks": [{"type": "command", "command": "/path/to/editorconfig-guard"}]
+      }
+    ]
+  }
+}
+
+`)
+}
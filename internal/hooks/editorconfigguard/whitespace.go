@@ -0,0 +1,125 @@
+// Package editorconfigguard - whitespace checks
+package editorconfigguard
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// defaultTabWidth is the width of a tab when neither indent_size nor
+// tab_width says, for telling space indentation from alignment.
+const defaultTabWidth = 4
+
+// Violation is a line that does not follow an .editorconfig property.
+type Violation struct {
+	Line     int    // 1-based line number
+	Property string // The property it violates, e.g. indent_style
+	Message  string
+	text     string // The line, for telling new violations from existing ones
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("line %d: %s", v.Line, v.Message)
+}
+
+// Check returns the lines of content that do not follow the indent_style,
+// trim_trailing_whitespace, and insert_final_newline properties.
+func (p Properties) Check(content string) []Violation {
+	indentStyle := p["indent_style"]
+	trim := p["trim_trailing_whitespace"] == "true"
+	width := p.tabWidth()
+
+	var violations []Violation
+	lines := strings.Split(content, "\n")
+	if strings.HasSuffix(content, "\n") {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		body := strings.TrimLeft(line, " \t")
+		indent := line[:len(line)-len(body)]
+		if trim && strings.TrimRight(line, " \t") != line {
+			violations = append(violations, Violation{i + 1, "trim_trailing_whitespace", "trailing whitespace", line})
+		}
+		if body == "" {
+			continue
+		}
+		switch {
+		case indentStyle == "space" && strings.Contains(indent, "\t"):
+			violations = append(violations, Violation{i + 1, "indent_style", "indented with a tab, but indent_style is space", line})
+		case indentStyle == "tab" && strings.Contains(indent, strings.Repeat(" ", width)):
+			violations = append(violations, Violation{i + 1, "indent_style", fmt.Sprintf("indented with %d or more spaces, but indent_style is tab", width), line})
+		}
+	}
+
+	switch p["insert_final_newline"] {
+	case "true":
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			violations = append(violations, Violation{len(lines), "insert_final_newline", "no newline at end of file, but insert_final_newline is true", ""})
+		}
+	case "false":
+		if strings.HasSuffix(content, "\n") {
+			violations = append(violations, Violation{len(lines), "insert_final_newline", "newline at end of file, but insert_final_newline is false", ""})
+		}
+	}
+	return violations
+}
+
+// tabWidth returns the number of spaces that make an indentation level:
+// indent_size, or tab_width when indent_size is tab or unset.
+func (p Properties) tabWidth() int {
+	for _, name := range []string{"indent_size", "tab_width"} {
+		if width, err := strconv.Atoi(p[name]); err == nil && width > 0 {
+			return width
+		}
+	}
+	return defaultTabWidth
+}
+
+// NewViolations returns the violations of after, the content of a file once
+// edited, that before, its content until then, does not have as well: the
+// ones the edit introduces. A violation exists in both if a line with the same
+// text violates the same property.
+func (p Properties) NewViolations(before, after string) []Violation {
+	existing := map[[2]string]int{}
+	for _, v := range p.Check(before) {
+		existing[[2]string{v.Property, v.text}]++
+	}
+	var violations []Violation
+	for _, v := range p.Check(after) {
+		key := [2]string{v.Property, v.text}
+		if existing[key] > 0 {
+			existing[key]--
+			continue
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+// errNotFound reports an edit whose old_string is not in the file; the tool
+// call fails on its own, so there is nothing to check.
+var errNotFound = errors.New("old_string not found")
+
+// ApplyEdits returns content with edits applied as the Edit and MultiEdit
+// tools do: each replaces the first occurrence of its old_string, or every
+// one with replace_all. An empty old_string on empty content creates the file.
+func ApplyEdits(content string, edits []hook.Edit) (string, error) {
+	for _, edit := range edits {
+		switch {
+		case edit.OldString == "" && content == "":
+			content = edit.NewString
+		case edit.OldString == "" || !strings.Contains(content, edit.OldString):
+			return "", errNotFound
+		case edit.ReplaceAll:
+			content = strings.ReplaceAll(content, edit.OldString, edit.NewString)
+		default:
+			content = strings.Replace(content, edit.OldString, edit.NewString, 1)
+		}
+	}
+	return content, nil
+}
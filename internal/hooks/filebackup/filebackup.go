@@ -0,0 +1,196 @@
+// Package filebackup implements the file-backup hook, which snapshots files before Claude Code edits them
+package filebackup
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/backup"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	dir := flag.String("dir", "", "Snapshot directory (default: .claude/backups in the project root)")
+	keep := flag.Int("keep", backup.DefaultKeep, "Snapshots kept per file; 0 keeps all")
+	maxAge := flag.Duration("max-age", backup.DefaultMaxAge, "Remove snapshots older than this, e.g. 72h; 0 keeps them")
+	maxFileMB := flag.Int64("max-file-mb", backup.DefaultMaxFileSize>>20, "Skip files larger than this many MiB; 0 backs up any size")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailOpen}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when a file cannot be backed up: open (allow the edit) or closed (block it)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "file-backup"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "file-backup", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:   "file-backup",
+		Events: []string{hook.EventPreToolUse},
+		Tools:  []string{"Edit", "MultiEdit", "Write", "NotebookEdit"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		failInternal(settings, "Failed to parse hook input", err)
+		return
+	}
+
+	target := targetPath(input)
+	if target == "" {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	storeDir := *dir
+	if storeDir == "" {
+		root := os.Getenv("CLAUDE_PROJECT_DIR")
+		if root == "" {
+			root = input.Cwd
+		}
+		storeDir = backup.DefaultDir(root)
+	}
+	store := backup.NewStore(storeDir)
+	store.Keep = *keep
+	store.MaxAge = *maxAge
+	store.MaxFileSize = *maxFileMB << 20
+
+	start := time.Now()
+	snapshot, saved, err := store.Save(target, input.SessionID, input.ToolName)
+	if err != nil {
+		failInternal(settings, "Failed to back up "+target, err)
+		return
+	}
+	logger.Debug("backed up file", "file", target, "snapshot", snapshot.ID, "saved", saved, "duration", time.Since(start))
+	hook.AllowPreToolUse()
+}
+
+// targetPath returns the absolute path of the file a tool call is about to
+// change, resolving a relative path against the working directory, or "" for
+// a tool that changes no file.
+func targetPath(input *hook.PreToolUseInput) string {
+	var target string
+	switch input.ToolName {
+	case "Edit", "MultiEdit", "Write":
+		target = input.ToolInput.FilePath
+	case "NotebookEdit":
+		target = input.ToolInput.NotebookPath
+	}
+	if target == "" {
+		return ""
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(input.Cwd, target)
+	}
+	return filepath.Clean(target)
+}
+
+// failInternal handles an error that prevents the backup according to the fail
+// mode: fail open allows the edit, fail closed blocks it.
+func failInternal(settings *config.Settings, message string, err error) {
+	if settings.FailMode == config.FailOpen {
+		fmt.Fprintf(os.Stderr, "Warning: %s (allowing, fail mode is open): %v\n", message, err)
+		hook.Exit(config.FailOpenExitCode(err))
+		return
+	}
+	hook.BlockPreToolUse(config.FailureMessage(message, err), []string{err.Error()})
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `file-backup: Snapshots before edits for Claude Code hooks
+
+Copies the file an Edit, MultiEdit, Write, or NotebookEdit call is about to
+change into a snapshot directory, so a bad edit can be undone with
+"hooks restore" even outside version control. New files have nothing to back
+up, and a file unchanged since its last snapshot is not copied again.
+
+Snapshots go to .claude/backups in the project root ($CLAUDE_PROJECT_DIR, or
+the working directory), which keeps itself out of git with a .gitignore.
+
+USAGE:
+    file-backup [OPTIONS]
+
+OPTIONAL:
+    -dir string
+            Snapshot directory (default: .claude/backups in the project root)
+
+    -keep int
+            Snapshots kept per file; 0 keeps all (default: 10)
+
+    -max-age duration
+            Remove snapshots older than this, e.g. 72h; 0 keeps them
+            (default: 168h)
+
+    -max-file-mb int
+            Skip files larger than this many MiB; 0 backs up any size
+            (default: 10)
+
+    -fail-mode string
+            Behavior when a file cannot be backed up: open (allow the edit)
+            or closed (block it) (default: open)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_FILE_BACKUP_<FLAG> to target only this hook.
+
+EXAMPLES:
+    file-backup -keep 20 -max-age 72h
+
+    # Recover a file
+    hooks restore -list src/main.go
+    hooks restore src/main.go
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Edit|MultiEdit|Write|NotebookEdit",
+        "hooks": [{"type": "command", "command": "/path/to/file-backup"}]
+      }
+    ]
+  }
+}
+
+`)
+}
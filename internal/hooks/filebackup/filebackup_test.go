@@ -0,0 +1,148 @@
+package filebackup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krmcbride/claudecode-hooks/internal/backup"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// TestMain runs the hook instead of the tests when runHook re-executes the
+// test binary, so the whole flow, exit code included, can be checked.
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv("FILE_BACKUP_TEST_ARGS"); ok {
+		os.Args = append([]string{"file-backup"}, strings.Fields(args)...)
+		Main()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runHook runs the hook with args on a PreToolUse payload for tool, with
+// toolInput as its tool_input, and returns the exit code and stderr.
+func runHook(t *testing.T, project, cwd, tool string, toolInput map[string]any, args ...string) (int, string) {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{
+		"session_id":      "session-1",
+		"transcript_path": filepath.Join(project, "transcript.jsonl"),
+		"cwd":             cwd,
+		"hook_event_name": hook.EventPreToolUse,
+		"tool_name":       tool,
+		"tool_input":      toolInput,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0]) // #nosec G204 - the test binary itself
+	cmd.Env = append(os.Environ(), "FILE_BACKUP_TEST_ARGS="+strings.Join(args, " "), "CLAUDE_PROJECT_DIR="+project)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), stderr.String()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0, stderr.String()
+}
+
+func TestTargetPath(t *testing.T) {
+	tests := []struct {
+		tool, filePath, notebookPath string
+		want                         string
+	}{
+		{"Edit", "/repo/main.go", "", "/repo/main.go"},
+		{"MultiEdit", "/repo/main.go", "", "/repo/main.go"},
+		{"Write", "src/../main.go", "", "/work/main.go"},
+		{"NotebookEdit", "", "analysis.ipynb", "/work/analysis.ipynb"},
+		{"NotebookEdit", "/repo/ignored.go", "", ""},
+		{"Bash", "/repo/main.go", "", ""},
+		{"Write", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool+" "+tt.filePath+tt.notebookPath, func(t *testing.T) {
+			input := &hook.PreToolUseInput{Cwd: "/work", ToolName: tt.tool}
+			input.ToolInput.FilePath = tt.filePath
+			input.ToolInput.NotebookPath = tt.notebookPath
+			if got := targetPath(input); got != filepath.FromSlash(tt.want) {
+				t.Errorf("targetPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMain_BacksUpTarget(t *testing.T) {
+	project := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(project, "src"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"main.go": "package main", "src/util.go": "package src", "lib.go": "package lib", "notes.ipynb": "{}"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(project, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		cwd       string
+		tool      string
+		toolInput map[string]any
+		want      string // File backed up, relative to the project
+	}{
+		{"Edit absolute path", project, "Edit", map[string]any{"file_path": filepath.Join(project, "main.go"), "old_string": "main", "new_string": "app"}, "main.go"},
+		{"Write relative to cwd", filepath.Join(project, "src"), "Write", map[string]any{"file_path": "util.go", "content": "package util"}, "src/util.go"},
+		{"MultiEdit", project, "MultiEdit", map[string]any{"file_path": "lib.go", "edits": []any{}}, "lib.go"},
+		{"NotebookEdit", project, "NotebookEdit", map[string]any{"notebook_path": "notes.ipynb", "new_source": "print(1)"}, "notes.ipynb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stderr := runHook(t, project, tt.cwd, tt.tool, tt.toolInput)
+			if code != int(hook.ExitSuccess) {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", code, hook.ExitSuccess, stderr)
+			}
+			snapshots, err := backup.NewStore(backup.DefaultDir(project)).List(filepath.Join(project, tt.want))
+			if err != nil || len(snapshots) != 1 || snapshots[0].Tool != tt.tool {
+				t.Errorf("snapshots of %s = %+v, %v, want one from %s", tt.want, snapshots, err, tt.tool)
+			}
+		})
+	}
+
+	// A new file has nothing to back up
+	if code, stderr := runHook(t, project, project, "Write", map[string]any{"file_path": "new.go", "content": "package main"}); code != int(hook.ExitSuccess) {
+		t.Errorf("exit code for a new file = %d, want %d (stderr: %s)", code, hook.ExitSuccess, stderr)
+	}
+	if snapshots, err := backup.NewStore(backup.DefaultDir(project)).List(filepath.Join(project, "new.go")); err != nil || len(snapshots) != 0 {
+		t.Errorf("snapshots of a new file = %+v, %v, want none", snapshots, err)
+	}
+}
+
+func TestMain_FailMode(t *testing.T) {
+	project := t.TempDir()
+	target := filepath.Join(project, "main.go")
+	if err := os.WriteFile(target, []byte("package main"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A snapshot directory below a regular file cannot be created
+	dir := filepath.Join(target, "backups")
+	toolInput := map[string]any{"file_path": target, "content": "package app"}
+
+	code, stderr := runHook(t, project, project, "Write", toolInput, "-dir", dir)
+	if code != int(hook.ExitSuccess) || !strings.Contains(stderr, "fail mode is open") {
+		t.Errorf("fail open: exit code = %d, stderr = %q, want the edit allowed with a warning", code, stderr)
+	}
+	code, stderr = runHook(t, project, project, "Write", toolInput, "-dir", dir, "-fail-mode", "closed")
+	if code != int(hook.ExitBlock) || !strings.Contains(stderr, "Failed to back up "+target) {
+		t.Errorf("fail closed: exit code = %d, stderr = %q, want the edit blocked", code, stderr)
+	}
+}
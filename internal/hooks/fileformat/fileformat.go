@@ -0,0 +1,297 @@
+// Package fileformat implements the file-format hook, which formats files after Claude Code edits them,
+// or the content of a Write before it is written.
+package fileformat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/internal/metrics"
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var (
+		formatCommand  = flag.String("cmd", "", "Format command to run (required)")
+		extensionsFlag = flag.String("ext", "", "Comma-separated file extensions to process (required)")
+		blockOnFailure = flag.Bool("block", false, "Block on formatting failures")
+		formatTimeout  = flag.Duration("timeout", DefaultTimeout, "Timeout of the format command")
+		verifyCommand  = flag.String("verify", "", "Command run after formatting, e.g. \"go build ./...\"; blocks if it fails")
+		verifyTimeout  = flag.Duration("verify-timeout", DefaultVerifyTimeout, "Timeout of the -verify command")
+		stdinFormat    = flag.Bool("stdin", false, "The -cmd formats stdin to stdout, e.g. gofmt; such formatters also format Write content before it is written (PreToolUse)")
+		approve        = flag.Bool("approve", false, "Run Writes with formatted content without a permission prompt (PreToolUse)")
+		bashWrites     = flag.Bool("bash-writes", false, "Also format files written by Bash commands, e.g. cat > main.go <<EOF, tee, or sed -i")
+		projectOnly    = flag.Bool("project-only", false, "Skip files that resolve outside the project root ($CLAUDE_PROJECT_DIR or the working directory), following symlinks and ../")
+		showHelp       = flag.Bool("help", false, "Show help message")
+		printProtocol  = flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+		describe       = flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+		emitConfig     = flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+	)
+	settings := config.RegisterFlags(flag.CommandLine, config.FailOpen)
+
+	// Environment variables (CLAUDE_HOOKS_FILE_FORMAT_CMD, CLAUDE_HOOKS_EXT, ...)
+	// provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "file-format"); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "file-format", hook.EventPostToolUse, hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:           "file-format",
+		Events:         []string{hook.EventPostToolUse, hook.EventPreToolUse},
+		Tools:          []string{"Edit", "MultiEdit", "Write", "Bash"},
+		PolicySections: []string{"formatters"},
+		// Write content is formatted before it is written; Bash writes only
+		// with -bash-writes
+		EventTools: map[string][]string{hook.EventPreToolUse: {"Write"}},
+	}
+	if !*bashWrites {
+		manifest.EventTools[hook.EventPostToolUse] = []string{"Edit", "MultiEdit", "Write"}
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+
+	// Show help if requested
+	if *showHelp {
+		flag.Usage()
+		hook.Exit(hook.ExitSuccess)
+	}
+
+	// Validate required flags. Without -cmd the formatters come from the
+	// policy files, and the project policy needs a hook payload to be found.
+	if *formatCommand == "" && hookutil.StdinIsTerminal() {
+		log.Fatal("Error: -cmd flag is required")
+	}
+	if *formatCommand != "" && *extensionsFlag == "" {
+		log.Fatal("Error: -ext flag is required")
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+	recorder := metrics.FromEnv("file-format")
+	start := time.Now()
+
+	// setup creates the formatters from flags or the policy files
+	setup := func(cwd string) ([]*FileFormatter, error) {
+		formatters, err := loadFormatters(*formatCommand, *extensionsFlag, *blockOnFailure, settings, cwd)
+		if err != nil {
+			return nil, err
+		}
+		if *formatCommand != "" {
+			formatters[0].Timeout = *formatTimeout
+			formatters[0].Verify = *verifyCommand
+			formatters[0].VerifyTimeout = *verifyTimeout
+			formatters[0].Stdin = *stdinFormat
+		}
+		if *projectOnly {
+			root := os.Getenv("CLAUDE_PROJECT_DIR")
+			if root == "" {
+				root = cwd
+			}
+			for _, formatter := range formatters {
+				formatter.Root = root
+			}
+		}
+		for _, formatter := range formatters {
+			formatter.BashWrites = *bashWrites
+		}
+		return formatters, nil
+	}
+
+	// Read input
+	data, err := io.ReadAll(os.Stdin)
+	if err == nil && hook.EventName(data) == hook.EventPreToolUse {
+		formatBeforeWrite(logger, auditLog, settings, data, setup, *approve)
+		return
+	}
+	var input *hook.PostToolUseInput
+	if err == nil {
+		input, err = hook.DecodePostToolUseInput(bytes.NewReader(data), settings.InputOptions())
+	}
+	if err != nil {
+		logger.Error("failed to decode JSON", "error", err)
+		recorder.ParseFailure()
+		flushMetrics(logger, recorder)
+		if settings.FailMode == config.FailClosed {
+			writeAudit(logger, auditLog, audit.Record{Decision: audit.DecisionBlock, Reason: "Failed to parse hook input", ErrorKind: config.ErrorKind(err)})
+			hook.BlockPostToolUse("Failed to parse hook input: " + err.Error())
+		}
+		hook.Exit(config.FailOpenExitCode(err))
+	}
+
+	// Create formatters and process input
+	formatters, err := setup(input.Cwd)
+	if err != nil {
+		logger.Error("failed to load policy", "error", err)
+		if settings.FailMode == config.FailClosed {
+			writeAudit(logger, auditLog, audit.Record{Decision: audit.DecisionBlock, Reason: "Failed to load policy", ErrorKind: config.ErrorKind(err)})
+			hook.BlockPostToolUse(config.FailureMessage("Failed to load policy", err) + ": " + err.Error())
+		}
+		hook.Exit(config.FailOpenExitCode(err))
+	}
+
+	err = processInput(formatters, input)
+	recorder.Evaluation(time.Since(start), err != nil)
+	flushMetrics(logger, recorder)
+
+	record := audit.Record{
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  audit.DecisionAllow,
+		FilePath:  input.ToolInput.FilePath,
+	}
+	if err != nil {
+		record.Decision = audit.DecisionBlock
+		record.Reason = blockReason(err)
+	}
+	writeAudit(logger, auditLog, record)
+
+	if err != nil {
+		hook.BlockPostToolUse(blockReason(err))
+	}
+
+	hook.AllowPostToolUse()
+}
+
+// formatBeforeWrite handles a PreToolUse payload: it formats the content of a
+// Write with the Stdin formatters and, if that changed it, has the Write run
+// with the formatted content. approve skips the permission prompt for it.
+func formatBeforeWrite(logger *slog.Logger, auditLog *audit.Logger, settings *config.Settings, data []byte, setup func(cwd string) ([]*FileFormatter, error), approve bool) {
+	input, err := hook.DecodePreToolUseInput(bytes.NewReader(data), settings.InputOptions())
+	var formatters []*FileFormatter
+	if err == nil {
+		formatters, err = setup(input.Cwd)
+	}
+	if err != nil {
+		logger.Error("failed to prepare formatting", "error", err)
+		if settings.FailMode == config.FailClosed {
+			writeAudit(logger, auditLog, audit.Record{Event: hook.EventPreToolUse, Decision: audit.DecisionBlock, Reason: "Failed to prepare formatting"})
+			hook.BlockPreToolUse("Failed to prepare formatting", []string{err.Error()})
+		}
+		hook.AllowPreToolUse()
+		return
+	}
+
+	original := input.ToolInput.Content
+	for _, formatter := range formatters {
+		content, ok, err := formatter.FormatContent(input)
+		switch {
+		case !ok:
+			continue
+		case err != nil && formatter.BlockOnFail:
+			writeAudit(logger, auditLog, audit.Record{
+				Event:     hook.EventPreToolUse,
+				SessionID: input.SessionID,
+				ToolName:  input.ToolName,
+				Decision:  audit.DecisionBlock,
+				Reason:    "File formatting failed",
+				Issues:    []string{err.Error()},
+				FilePath:  input.ToolInput.FilePath,
+			})
+			hook.BlockPreToolUse("File formatting failed", []string{err.Error()})
+			return
+		case err != nil:
+			logger.Warn("formatting failed, writing the content as is", "file", input.ToolInput.FilePath, "error", err)
+			continue
+		}
+		input.ToolInput.Content = content
+	}
+	if input.ToolInput.Content == original {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	writeAudit(logger, auditLog, audit.Record{
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  audit.DecisionAllow,
+		Reason:    "Formatted before write",
+		FilePath:  input.ToolInput.FilePath,
+	})
+	decision := hook.PermissionAsk
+	if approve {
+		decision = hook.PermissionAllow
+	}
+	hook.UpdatePreToolUseInput(decision, input.UpdatedToolInput(map[string]any{"content": input.ToolInput.Content}), "Formatted by file-format")
+}
+
+// loadFormatters returns the formatter configured by flags or, when no -cmd is
+// given, the formatters of the most specific policy layer that defines any
+// (project, -rules, user, then system).
+func loadFormatters(command, extensions string, blockOnFailure bool, settings *config.Settings, cwd string) ([]*FileFormatter, error) {
+	if command != "" {
+		return []*FileFormatter{NewFileFormatter(command, utils.ParseCommaSeparated(extensions), blockOnFailure)}, nil
+	}
+
+	layers, err := config.LoadLayers(context.Background(), settings, cwd)
+	if err != nil {
+		return nil, err
+	}
+	policy := config.Merge(layers)
+
+	formatters := make([]*FileFormatter, 0, len(policy.Formatters))
+	for _, f := range policy.Formatters {
+		formatter := NewFileFormatter(f.Command, f.Extensions, f.Block)
+		// Durations were checked when the policy was loaded
+		formatter.Timeout, _ = time.ParseDuration(f.Timeout)
+		formatter.Stdin = f.Stdin
+		formatter.Verify = f.Verify
+		formatter.VerifyTimeout, _ = time.ParseDuration(f.VerifyTimeout)
+		formatters = append(formatters, formatter)
+	}
+	return formatters, nil
+}
+
+// blockReason explains a failed run: the output of a failed verification,
+// so Claude can fix what the formatter broke, or that formatting failed.
+func blockReason(err error) string {
+	var verifyErr *VerifyError
+	if errors.As(err, &verifyErr) {
+		return "File formatted, but " + verifyErr.Error()
+	}
+	return "File formatting failed"
+}
+
+// processInput runs every formatter and returns an error if any of them failed
+// with blocking enabled.
+func processInput(formatters []*FileFormatter, input *hook.PostToolUseInput) error {
+	var errs []error
+	for _, formatter := range formatters {
+		if err := formatter.ProcessInput(input); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeAudit appends a decision to the audit log. Failures are logged but never affect the outcome.
+func writeAudit(logger *slog.Logger, auditLog *audit.Logger, record audit.Record) {
+	record.Hook = "file-format"
+	if record.Event == "" {
+		record.Event = hook.EventPostToolUse
+	}
+	if err := auditLog.Log(record); err != nil {
+		logger.Warn("failed to write audit log", "error", err)
+	}
+}
+
+// flushMetrics emits recorded metrics. Failures are logged but never affect the outcome.
+func flushMetrics(logger *slog.Logger, recorder *metrics.Recorder) {
+	if err := recorder.Flush(); err != nil {
+		logger.Warn("failed to emit metrics", "error", err)
+	}
+}
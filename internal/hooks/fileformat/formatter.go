@@ -0,0 +1,344 @@
+// Package fileformat - file formatter
+package fileformat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+)
+
+// Default timeouts of the format and verification commands
+const (
+	DefaultTimeout       = 30 * time.Second
+	DefaultVerifyTimeout = 2 * time.Minute
+)
+
+// maxVerifyOutput is how much of a failed verification's output is shown
+const maxVerifyOutput = 4000
+
+// FileFormatter handles file formatting operations
+type FileFormatter struct {
+	Command     string
+	Extensions  []string
+	BlockOnFail bool
+	Timeout     time.Duration // Format command timeout, DefaultTimeout if zero
+
+	// Verify, if set, is run in the working directory after a file is
+	// formatted, e.g. "go build ./...", and a failure blocks whether or not
+	// BlockOnFail is set. It may use the {FILEPATH} placeholder.
+	Verify        string
+	VerifyTimeout time.Duration // DefaultVerifyTimeout if zero
+
+	// Root, if set, confines formatting to files that resolve below it,
+	// following symlinks and ../ traversal
+	Root string
+
+	// BashWrites also formats the files a Bash command wrote, as far as they
+	// can be told from the command (see detector.WrittenPaths)
+	BashWrites bool
+
+	// Stdin means Command formats its standard input to its standard output,
+	// as gofmt or prettier --stdin-filepath {FILEPATH} do, instead of a file.
+	// Only such formatters can format Write content before it is written.
+	Stdin bool
+}
+
+// VerifyError reports a verification command that failed after formatting.
+type VerifyError struct {
+	Command string
+	Output  string // Combined output, truncated to its last part
+	Err     error
+}
+
+func (e *VerifyError) Error() string {
+	message := fmt.Sprintf("verification %q failed: %v", e.Command, e.Err)
+	if e.Output != "" {
+		message += "\n" + e.Output
+	}
+	return message
+}
+
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// NewFileFormatter creates a new FileFormatter instance
+func NewFileFormatter(command string, extensions []string, blockOnFail bool) *FileFormatter {
+	return &FileFormatter{
+		Command:     command,
+		Extensions:  extensions,
+		BlockOnFail: blockOnFail,
+	}
+}
+
+// ProcessInput processes PostToolUse input and formats files
+func (f *FileFormatter) ProcessInput(input *hook.PostToolUseInput) error {
+	return f.ProcessInputContext(context.Background(), input)
+}
+
+// ProcessInputContext is ProcessInput whose format and verification commands
+// are also killed when ctx is done.
+func (f *FileFormatter) ProcessInputContext(ctx context.Context, input *hook.PostToolUseInput) error {
+	if !f.shouldProcessInput(input) {
+		return nil
+	}
+
+	filesToFormat := f.getFilesToFormat(input)
+	if len(filesToFormat) == 0 {
+		return nil
+	}
+
+	formatFailed := f.formatFiles(ctx, filesToFormat)
+	if formatFailed && f.BlockOnFail {
+		return errors.New("file formatting failed")
+	}
+	if formatFailed || f.Verify == "" {
+		return nil
+	}
+
+	return f.verify(ctx, filesToFormat[0], input.Cwd)
+}
+
+// shouldProcessInput checks if we should process this input
+func (f *FileFormatter) shouldProcessInput(input *hook.PostToolUseInput) bool {
+	// PostToolUse hooks only run after successful operations, so we don't need to check success
+	if input.ToolName == "Bash" {
+		return f.BashWrites
+	}
+	return input.ToolName == "Edit" || input.ToolName == "MultiEdit" || input.ToolName == "Write"
+}
+
+// getFilesToFormat returns the edited file, or the files a Bash command
+// wrote, if they should be formatted
+func (f *FileFormatter) getFilesToFormat(input *hook.PostToolUseInput) []string {
+	if input.ToolName == "Bash" {
+		return f.getBashFilesToFormat(input)
+	}
+
+	// Get the file path from tool_input
+	filePath := input.ToolInput.FilePath
+	if filePath == "" || !f.shouldFormat(input.Cwd, filePath) {
+		return nil
+	}
+
+	return []string{filePath}
+}
+
+// getBashFilesToFormat returns the files the Bash command wrote that should
+// be formatted. Relative paths are resolved against the working directory,
+// and paths that do not name a regular file, say because the command failed
+// before writing it, are skipped.
+func (f *FileFormatter) getBashFilesToFormat(input *hook.PostToolUseInput) []string {
+	written, err := detector.WrittenPaths(input.ToolInput.Command)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, filePath := range written {
+		if !filepath.IsAbs(filePath) && input.Cwd != "" {
+			filePath = filepath.Join(input.Cwd, filePath)
+		}
+		if info, err := os.Stat(filePath); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if f.shouldFormat(input.Cwd, filePath) {
+			files = append(files, filePath)
+		}
+	}
+	return files
+}
+
+// shouldFormat checks if the file has an allowed extension and, with Root
+// set, stays within the project root
+func (f *FileFormatter) shouldFormat(cwd, filePath string) bool {
+	// Check if the file extension is allowed
+	if !f.isAllowedExtension(filePath) {
+		return false
+	}
+
+	// Refuse files that escape the project root, e.g. through a symlink
+	return f.Root == "" || utils.WithinRoot(f.Root, cwd, filePath)
+}
+
+// isAllowedExtension checks if the file extension is allowed. Windows paths
+// match case-insensitively, so Main.GO is formatted like main.go.
+func (f *FileFormatter) isAllowedExtension(filePath string) bool {
+	return pathmatch.HasExt(filePath, f.Extensions...)
+}
+
+// formatFiles formats each file and returns whether any failed
+func (f *FileFormatter) formatFiles(ctx context.Context, filesToFormat []string) bool {
+	formatFailed := false
+	for _, filePath := range filesToFormat {
+		if err := f.formatFile(ctx, filePath); err != nil {
+			formatFailed = true
+		}
+	}
+	return formatFailed
+}
+
+// FormatContent returns the Write tool's content formatted, for a PreToolUse
+// hook that formats a file before it is written. It returns ok false when the
+// formatter does not apply: it is not a Stdin formatter, the tool is not
+// Write, or the file is not one it formats.
+func (f *FileFormatter) FormatContent(input *hook.PreToolUseInput) (content string, ok bool, err error) {
+	return f.FormatContentContext(context.Background(), input)
+}
+
+// FormatContentContext is FormatContent whose format command is also killed
+// when ctx is done.
+func (f *FileFormatter) FormatContentContext(ctx context.Context, input *hook.PreToolUseInput) (content string, ok bool, err error) {
+	filePath := input.ToolInput.FilePath
+	if !f.Stdin || input.ToolName != "Write" || filePath == "" || !f.shouldFormat(input.Cwd, filePath) {
+		return "", false, nil
+	}
+
+	content, err = f.formatStdin(ctx, filePath, input.Cwd, input.ToolInput.Content)
+	return content, true, err
+}
+
+// formatStdin pipes content through a Stdin formatter run in dir and
+// returns its output.
+func (f *FileFormatter) formatStdin(ctx context.Context, filePath, dir, content string) (string, error) {
+	parts := commandArgs(f.Command, filePath)
+	if len(parts) == 0 {
+		return content, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeoutOrDefault(f.Timeout, DefaultTimeout))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...) // #nosec G204 - command is user-configured
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(content)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+// commandArgs splits a command template into its arguments and then replaces
+// {FILEPATH} in each, so a path with spaces stays one argument and a file
+// named "x -o /etc/y" cannot add options.
+func commandArgs(template, filePath string) []string {
+	parts := strings.Fields(template)
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(part, "{FILEPATH}", filePath)
+	}
+	return parts
+}
+
+// formatFile runs the format command on a single file
+func (f *FileFormatter) formatFile(ctx context.Context, filePath string) error {
+	if f.Stdin {
+		return f.formatFileStdin(ctx, filePath)
+	}
+
+	// Replace {FILEPATH} placeholder with actual file path
+	// This allows flexible command templates like:
+	// - "gofmt -w {FILEPATH}"
+	// - "make fmt-file FILE={FILEPATH}"
+	// - "prettier --write {FILEPATH} --config .prettierrc"
+	parts := commandArgs(f.Command, filePath)
+	if len(parts) == 0 {
+		return nil
+	}
+
+	baseCommand := parts[0]
+	args := parts[1:]
+
+	// If no placeholder was found, use legacy behavior
+	// This maintains backwards compatibility for commands without placeholders
+	if !strings.Contains(f.Command, "{FILEPATH}") {
+		// If the last argument ends with =, concatenate the filepath without a space
+		// This handles legacy cases like "make fmt-file FILE="
+		if len(args) > 0 && strings.HasSuffix(args[len(args)-1], "=") {
+			args[len(args)-1] = args[len(args)-1] + filePath
+		} else {
+			// Default: append filepath as last argument
+			args = append(args, filePath)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeoutOrDefault(f.Timeout, DefaultTimeout))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, baseCommand, args...) // #nosec G204 - command is user-configured
+	_, err := cmd.CombinedOutput()
+	return err
+}
+
+// formatFileStdin formats a file with a Stdin formatter, rewriting it only
+// when the formatter changed it.
+func (f *FileFormatter) formatFileStdin(ctx context.Context, filePath string) error {
+	content, err := os.ReadFile(filePath) // #nosec G304 - the file Claude just edited
+	if err != nil {
+		return err
+	}
+	formatted, err := f.formatStdin(ctx, filePath, "", string(content))
+	if err != nil || formatted == string(content) {
+		return err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, []byte(formatted), info.Mode().Perm())
+}
+
+// verify runs the verification command in dir, returning a *VerifyError if
+// it fails or times out.
+func (f *FileFormatter) verify(ctx context.Context, filePath, dir string) error {
+	parts := commandArgs(f.Verify, filePath)
+	if len(parts) == 0 {
+		return nil
+	}
+
+	timeout := timeoutOrDefault(f.VerifyTimeout, DefaultVerifyTimeout)
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...) // #nosec G204 - command is user-configured
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	switch {
+	case parent.Err() != nil:
+		err = parent.Err()
+	case ctx.Err() != nil:
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	text := strings.TrimSpace(string(output))
+	if len(text) > maxVerifyOutput {
+		text = "..." + text[len(text)-maxVerifyOutput:]
+	}
+	return &VerifyError{Command: f.Verify, Output: text, Err: err}
+}
+
+// timeoutOrDefault returns timeout, or fallback when it is not positive.
+func timeoutOrDefault(timeout, fallback time.Duration) time.Duration {
+	if timeout <= 0 {
+		return fallback
+	}
+	return timeout
+}
@@ -0,0 +1,562 @@
+package fileformat
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+func TestParseCommaSeparatedForExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "Single extension",
+			input:    ".go",
+			expected: []string{".go"},
+		},
+		{
+			name:     "Multiple extensions",
+			input:    ".go,.js,.py",
+			expected: []string{".go", ".js", ".py"},
+		},
+		{
+			name:     "Extensions with spaces",
+			input:    ".go, .js , .py",
+			expected: []string{".go", ".js", ".py"},
+		},
+		{
+			name:     "Empty string",
+			input:    "",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := utils.ParseCommaSeparated(tt.input)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseCommaSeparated(%s) = %v, want %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewFileFormatter(t *testing.T) {
+	command := "./bin/golangci-lint fmt"
+	extensions := []string{".go", ".js"}
+	blockOnFail := true
+
+	formatter := NewFileFormatter(command, extensions, blockOnFail)
+
+	if formatter.Command != command {
+		t.Errorf("Command = %s, want %s", formatter.Command, command)
+	}
+	if !reflect.DeepEqual(formatter.Extensions, extensions) {
+		t.Errorf("Extensions = %v, want %v", formatter.Extensions, extensions)
+	}
+	if formatter.BlockOnFail != blockOnFail {
+		t.Errorf("BlockOnFail = %v, want %v", formatter.BlockOnFail, blockOnFail)
+	}
+}
+
+func TestFileFormatter_shouldProcessInput(t *testing.T) {
+	formatter := NewFileFormatter("echo test", []string{".go"}, false)
+
+	tests := []struct {
+		name     string
+		input    *hook.PostToolUseInput
+		expected bool
+	}{
+		{
+			name: "Edit tool",
+			input: &hook.PostToolUseInput{
+				ToolName: "Edit",
+			},
+			expected: true,
+		},
+		{
+			name: "MultiEdit tool",
+			input: &hook.PostToolUseInput{
+				ToolName: "MultiEdit",
+			},
+			expected: true,
+		},
+		{
+			name: "Write tool",
+			input: &hook.PostToolUseInput{
+				ToolName: "Write",
+			},
+			expected: true,
+		},
+		{
+			name: "Wrong tool - Read",
+			input: &hook.PostToolUseInput{
+				ToolName: "Read",
+			},
+			expected: false,
+		},
+		{
+			name: "Wrong tool - Bash",
+			input: &hook.PostToolUseInput{
+				ToolName: "Bash",
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatter.shouldProcessInput(tt.input)
+			if result != tt.expected {
+				t.Errorf("shouldProcessInput() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+
+	formatter.BashWrites = true
+	if !formatter.shouldProcessInput(&hook.PostToolUseInput{ToolName: "Bash"}) {
+		t.Error("shouldProcessInput() = false for Bash with BashWrites, want true")
+	}
+}
+
+func TestFileFormatter_getFilesToFormat(t *testing.T) {
+	formatter := NewFileFormatter("echo test", []string{".go", ".js"}, false)
+
+	tests := []struct {
+		name     string
+		input    *hook.PostToolUseInput
+		expected []string
+	}{
+		{
+			name: "Edit with Go file",
+			input: &hook.PostToolUseInput{
+				ToolName: "Edit",
+				ToolInput: struct {
+					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
+				}{
+					FilePath: "main.go",
+				},
+			},
+			expected: []string{"main.go"},
+		},
+		{
+			name: "MultiEdit with Go file",
+			input: &hook.PostToolUseInput{
+				ToolName: "MultiEdit",
+				ToolInput: struct {
+					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
+				}{
+					FilePath: "utils.go",
+				},
+			},
+			expected: []string{"utils.go"},
+		},
+		{
+			name: "Write with JS file",
+			input: &hook.PostToolUseInput{
+				ToolName: "Write",
+				ToolInput: struct {
+					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
+				}{
+					FilePath: "app.js",
+				},
+			},
+			expected: []string{"app.js"},
+		},
+		{
+			name: "Edit with wrong extension",
+			input: &hook.PostToolUseInput{
+				ToolName: "Edit",
+				ToolInput: struct {
+					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
+				}{
+					FilePath: "README.md",
+				},
+			},
+			expected: nil, // Filtered out due to extension
+		},
+		{
+			name: "Edit with empty file path",
+			input: &hook.PostToolUseInput{
+				ToolName: "Edit",
+				ToolInput: struct {
+					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
+				}{
+					FilePath: "",
+				},
+			},
+			expected: nil, // Empty file path
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatter.getFilesToFormat(tt.input)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("getFilesToFormat() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFileFormatter_getFilesToFormat_Bash(t *testing.T) {
+	project := t.TempDir()
+	for _, name := range []string{"main.go", "util.go", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(project, name), []byte("package main\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	formatter := NewFileFormatter("echo test", []string{".go"}, false)
+	formatter.BashWrites = true
+
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		{"cat > main.go <<'EOF'\npackage main\nEOF", []string{filepath.Join(project, "main.go")}},
+		{"sed -i 's/a/b/' main.go notes.txt " + filepath.Join(project, "util.go"), []string{filepath.Join(project, "main.go"), filepath.Join(project, "util.go")}},
+		{"echo x | tee missing.go", nil},
+		{"go test ./...", nil},
+		{"echo 'unterminated", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			input := &hook.PostToolUseInput{ToolName: "Bash", Cwd: project}
+			input.ToolInput.Command = tt.command
+			if result := formatter.getFilesToFormat(input); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("getFilesToFormat(%q) = %v, want %v", tt.command, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFileFormatter_getFilesToFormat_Root(t *testing.T) {
+	base := t.TempDir()
+	project := filepath.Join(base, "project")
+	if err := os.MkdirAll(project, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(base, filepath.Join(project, "up")); err != nil {
+		t.Fatal(err)
+	}
+	formatter := NewFileFormatter("echo test", []string{".go"}, false)
+	formatter.Root = project
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"main.go", []string{"main.go"}},
+		{filepath.Join(project, "cmd", "main.go"), []string{filepath.Join(project, "cmd", "main.go")}},
+		{"../other/main.go", nil},
+		{"up/other/main.go", nil},
+		{"/tmp/main.go", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			input := &hook.PostToolUseInput{ToolName: "Edit", Cwd: project}
+			input.ToolInput.FilePath = tt.path
+			if result := formatter.getFilesToFormat(input); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("getFilesToFormat(%q) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFileFormatter_isAllowedExtension(t *testing.T) {
+	formatter := NewFileFormatter("echo test", []string{".go", ".js", ".py"}, false)
+
+	tests := []struct {
+		name     string
+		filePath string
+		expected bool
+	}{
+		{
+			name:     "Go file allowed",
+			filePath: "main.go",
+			expected: true,
+		},
+		{
+			name:     "JavaScript file allowed",
+			filePath: "script.js",
+			expected: true,
+		},
+		{
+			name:     "Python file allowed",
+			filePath: "script.py",
+			expected: true,
+		},
+		{
+			name:     "Text file not allowed",
+			filePath: "README.txt",
+			expected: false,
+		},
+		{
+			name:     "No extension",
+			filePath: "Dockerfile",
+			expected: false,
+		},
+		{
+			name:     "Case sensitive extension",
+			filePath: "Main.GO",
+			expected: runtime.GOOS == "windows",
+		},
+		{
+			name:     "Windows path extension ignores case",
+			filePath: `C:\src\Main.GO`,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatter.isAllowedExtension(tt.filePath)
+			if result != tt.expected {
+				t.Errorf("isAllowedExtension(%s) = %v, want %v", tt.filePath, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFileFormatter_formatFile_placeholder(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		filePath    string
+		expectError bool
+	}{
+		{
+			name:        "Command with {FILEPATH} placeholder",
+			command:     "echo Formatting: {FILEPATH}",
+			filePath:    "test.go",
+			expectError: false,
+		},
+		{
+			name:        "Command without placeholder appends filepath",
+			command:     "echo",
+			filePath:    "test.go",
+			expectError: false,
+		},
+		{
+			name:        "Command ending with = concatenates filepath",
+			command:     "echo FILE=",
+			filePath:    "test.go",
+			expectError: false,
+		},
+		{
+			name:        "Empty command",
+			command:     "",
+			filePath:    "test.go",
+			expectError: false, // Empty command returns nil
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := NewFileFormatter(tt.command, []string{".go"}, false)
+			err := formatter.formatFile(context.Background(), tt.filePath)
+
+			if tt.expectError && err == nil {
+				t.Errorf("formatFile() expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("formatFile() expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	tests := []struct {
+		template, filePath string
+		want               []string
+	}{
+		{"gofmt -w {FILEPATH}", "main.go", []string{"gofmt", "-w", "main.go"}},
+		{"gofmt -w {FILEPATH}", "my dir/main.go", []string{"gofmt", "-w", "my dir/main.go"}},
+		{"gofmt -w {FILEPATH}", "x -o /etc/y", []string{"gofmt", "-w", "x -o /etc/y"}},
+		{"make fmt-file FILE={FILEPATH}", "a b.go", []string{"make", "fmt-file", "FILE=a b.go"}},
+		{"", "main.go", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template+" "+tt.filePath, func(t *testing.T) {
+			if got := commandArgs(tt.template, tt.filePath); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commandArgs(%q, %q) = %q, want %q", tt.template, tt.filePath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileFormatter_formatFile(t *testing.T) {
+	formatter := NewFileFormatter("echo test", []string{".go"}, false)
+
+	tests := []struct {
+		name        string
+		command     string
+		expectError bool
+	}{
+		{
+			name:        "Empty command",
+			command:     "",
+			expectError: false,
+		},
+		{
+			name:        "Valid command",
+			command:     "echo",
+			expectError: false, // echo should work now
+		},
+		{
+			name:        "Command that will fail",
+			command:     "nonexistent-command-12345",
+			expectError: true, // Will fail because command doesn't exist
+		},
+	}
+
+	// Create a temporary file for testing
+	tempFile := filepath.Join(t.TempDir(), "test.go")
+	if err := os.WriteFile(tempFile, []byte("package main"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Update formatter command for this test
+			formatter.Command = tt.command
+			err := formatter.formatFile(context.Background(), tempFile)
+
+			if tt.expectError && err == nil {
+				t.Errorf("formatFile() expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("formatFile() expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadFormatters(t *testing.T) {
+	defaultSystemDir := config.SystemConfigDir
+	config.SystemConfigDir = t.TempDir()
+	t.Cleanup(func() { config.SystemConfigDir = defaultSystemDir })
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	if err := os.MkdirAll(filepath.Join(userDir, "claudecode-hooks"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	userPolicy := "formatters:\n  - command: shfmt -w\n    extensions: [.sh]\n"
+	if err := os.WriteFile(filepath.Join(userDir, "claudecode-hooks", config.PolicyFileName), []byte(userPolicy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	projectDir := t.TempDir()
+	policy := "formatters:\n  - command: gofmt -w\n    extensions: [.go]\n    verify: go build ./...\n    verify_timeout: 1m\n  - command: prettier --write\n    extensions: [.ts, .js]\n    block: true\n"
+	if err := os.WriteFile(filepath.Join(projectDir, ".claudehooks.yaml"), []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Flags take precedence", func(t *testing.T) {
+		formatters, err := loadFormatters("goimports -w", ".go", true, &config.Settings{Discover: true}, projectDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(formatters) != 1 || formatters[0].Command != "goimports -w" || !formatters[0].BlockOnFail {
+			t.Errorf("unexpected formatters: %+v", formatters)
+		}
+	})
+
+	t.Run("Project policy", func(t *testing.T) {
+		formatters, err := loadFormatters("", "", false, &config.Settings{Discover: true}, filepath.Join(projectDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(formatters) != 2 {
+			t.Fatalf("got %d formatters, want 2", len(formatters))
+		}
+		if formatters[0].Verify != "go build ./..." || formatters[0].VerifyTimeout != time.Minute {
+			t.Errorf("unexpected first formatter: %+v", formatters[0])
+		}
+		if !reflect.DeepEqual(formatters[1].Extensions, []string{".ts", ".js"}) || !formatters[1].BlockOnFail {
+			t.Errorf("unexpected second formatter: %+v", formatters[1])
+		}
+	})
+
+	t.Run("Discovery disabled falls back to user policy", func(t *testing.T) {
+		formatters, err := loadFormatters("", "", false, &config.Settings{}, projectDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(formatters) != 1 || formatters[0].Command != "shfmt -w" {
+			t.Errorf("loadFormatters() = %+v, want the user policy formatter", formatters)
+		}
+	})
+}
+
+func TestFileFormatter_FormatContent(t *testing.T) {
+	write := func(tool, filePath, content string) *hook.PreToolUseInput {
+		input := &hook.PreToolUseInput{ToolName: tool}
+		input.ToolInput.FilePath = filePath
+		input.ToolInput.Content = content
+		return input
+	}
+	upper := NewFileFormatter("tr a-z A-Z", []string{".txt"}, false)
+	upper.Stdin = true
+	failing := NewFileFormatter("ls /nonexistent-dir", []string{".txt"}, false)
+	failing.Stdin = true
+
+	tests := []struct {
+		name      string
+		formatter *FileFormatter
+		input     *hook.PreToolUseInput
+		want      string
+		wantOK    bool
+		wantErr   bool
+	}{
+		{"formats stdin", upper, write("Write", "notes.txt", "hello\n"), "HELLO\n", true, false},
+		{"other extension", upper, write("Write", "main.go", "hello\n"), "", false, false},
+		{"not a Write", upper, write("Edit", "notes.txt", ""), "", false, false},
+		{"file formatter", NewFileFormatter("tr a-z A-Z", []string{".txt"}, false), write("Write", "notes.txt", "hello\n"), "", false, false},
+		{"formatter fails", failing, write("Write", "notes.txt", "hello\n"), "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := tt.formatter.FormatContent(tt.input)
+			if got != tt.want || ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Errorf("FormatContent() = %q, %v, %v, want %q, %v, error %v", got, ok, err, tt.want, tt.wantOK, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileFormatter_formatFile_stdin(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(filePath, []byte("hello\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	formatter := NewFileFormatter("tr a-z A-Z", []string{".txt"}, true)
+	formatter.Stdin = true
+	if err := formatter.formatFile(context.Background(), filePath); err != nil {
+		t.Fatalf("formatFile() error: %v", err)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "HELLO\n" {
+		t.Errorf("formatted file = %q, want %q", content, "HELLO\n")
+	}
+}
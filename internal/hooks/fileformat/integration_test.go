@@ -1,9 +1,13 @@
-package main
+package fileformat
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/krmcbride/claudecode-hooks/pkg/hook"
 )
@@ -40,6 +44,7 @@ func TestFileFormatter_ProcessInput_Integration(t *testing.T) {
 				ToolName: "Edit",
 				ToolInput: struct {
 					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
 				}{
 					FilePath: testGoFile,
 				},
@@ -54,6 +59,7 @@ func TestFileFormatter_ProcessInput_Integration(t *testing.T) {
 				ToolName: "MultiEdit",
 				ToolInput: struct {
 					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
 				}{
 					FilePath: testJsFile,
 				},
@@ -68,6 +74,7 @@ func TestFileFormatter_ProcessInput_Integration(t *testing.T) {
 				ToolName: "Write",
 				ToolInput: struct {
 					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
 				}{
 					FilePath: testGoFile,
 				},
@@ -82,6 +89,7 @@ func TestFileFormatter_ProcessInput_Integration(t *testing.T) {
 				ToolName: "Edit",
 				ToolInput: struct {
 					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
 				}{
 					FilePath: testTxtFile,
 				},
@@ -96,6 +104,7 @@ func TestFileFormatter_ProcessInput_Integration(t *testing.T) {
 				ToolName: "Read",
 				ToolInput: struct {
 					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
 				}{
 					FilePath: testGoFile,
 				},
@@ -110,6 +119,7 @@ func TestFileFormatter_ProcessInput_Integration(t *testing.T) {
 				ToolName: "Edit",
 				ToolInput: struct {
 					FilePath string `json:"file_path"`
+					Command  string `json:"command"`
 				}{
 					FilePath: testGoFile,
 				},
@@ -140,6 +150,7 @@ func TestFileFormatter_ProcessInput_EmptyFilePath(t *testing.T) {
 		ToolName: "Edit",
 		ToolInput: struct {
 			FilePath string `json:"file_path"`
+			Command  string `json:"command"`
 		}{
 			FilePath: "",
 		},
@@ -165,6 +176,7 @@ func TestFileFormatter_ProcessInput_PlaceholderExpansion(t *testing.T) {
 		ToolName: "Edit",
 		ToolInput: struct {
 			FilePath string `json:"file_path"`
+			Command  string `json:"command"`
 		}{
 			FilePath: testFile,
 		},
@@ -175,3 +187,78 @@ func TestFileFormatter_ProcessInput_PlaceholderExpansion(t *testing.T) {
 		t.Errorf("ProcessInput() with placeholder should not error, got %v", err)
 	}
 }
+
+func TestFileFormatter_ProcessInput_Verify(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "test.go")
+	if err := os.WriteFile(testFile, []byte("package main"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	input := &hook.PostToolUseInput{Cwd: tempDir, ToolName: "Edit"}
+	input.ToolInput.FilePath = testFile
+
+	tests := []struct {
+		name          string
+		command       string
+		verify        string
+		verifyTimeout time.Duration
+		wantErr       string // Substring of the *VerifyError, empty for success
+	}{
+		{"verification passes", "true", "test -f {FILEPATH}", 0, ""},
+		{"verification runs in the working directory", "true", "test -f test.go", 0, ""},
+		{"verification fails", "true", "ls missing-file", 0, "missing-file"},
+		{"verification times out", "true", "sleep 5", 50 * time.Millisecond, "timed out after 50ms"},
+		{"skipped when formatting fails", "false", "false", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter := NewFileFormatter(tt.command, []string{".go"}, false)
+			formatter.Verify = tt.verify
+			formatter.VerifyTimeout = tt.verifyTimeout
+
+			err := formatter.ProcessInput(input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ProcessInput() error = %v, want nil", err)
+				}
+				return
+			}
+			var verifyErr *VerifyError
+			if !errors.As(err, &verifyErr) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ProcessInput() error = %v, want a verification error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileFormatter_ProcessInputContext_Cancel(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "test.go")
+	if err := os.WriteFile(testFile, []byte("package main"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	input := &hook.PostToolUseInput{Cwd: tempDir, ToolName: "Edit"}
+	input.ToolInput.FilePath = testFile
+
+	formatter := NewFileFormatter("true", []string{".go"}, false)
+	formatter.Verify = "sleep 5"
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := formatter.ProcessInputContext(ctx, input)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ProcessInputContext() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("ProcessInputContext() took %s, want the verification killed", elapsed)
+	}
+
+	// A formatter killed by a done context fails like any other
+	formatter = NewFileFormatter("sleep 5", []string{".go"}, true)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := formatter.ProcessInputContext(ctx, input); err == nil {
+		t.Error("ProcessInputContext() with a canceled context succeeded, want the formatting failure")
+	}
+}
@@ -0,0 +1,134 @@
+// Package generatedguard - generated file detection
+package generatedguard
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+)
+
+// builtinPatterns name the output of common code generators: protobuf and
+// gRPC, go generate tools such as stringer and controller-gen, and Dart's
+// build_runner.
+var builtinPatterns = []string{
+	"*.pb.go", "*.pb.gw.go", "*_grpc.pb.go", "*_gen.go", "*.gen.go", "zz_generated*.go",
+	"*_pb2.py", "*_pb2.pyi", "*_pb2_grpc.py", "*.pb.h", "*.pb.cc",
+	"*_pb.js", "*_pb.d.ts", "*.g.dart", "*.freezed.dart",
+}
+
+// maxHeaderLines bounds how far into a file the leading comments are searched
+// for a generated marker.
+const maxHeaderLines = 50
+
+// markers match the comments generators put at the top of their output, such
+// as Go's "Code generated by stringer; DO NOT EDIT." or "@generated".
+var markers = []*regexp.Regexp{
+	regexp.MustCompile(`\bDO NOT EDIT\b`),
+	regexp.MustCompile(`@generated\b`),
+	regexp.MustCompile(`(?i)\b(auto-?generated|automatically generated)\b`),
+}
+
+// generatorPattern extracts the generator from a marker line, such as
+// protoc-gen-go from "Code generated by protoc-gen-go. DO NOT EDIT.".
+var generatorPattern = regexp.MustCompile(`(?i)\bgenerated by (?:the )?"?([^\s.;,"]+(?: [a-z]+ compiler)?)`)
+
+// sourcePattern extracts the generator input protoc and similar tools name in
+// the header, as in "// source: api/v1/user.proto".
+var sourcePattern = regexp.MustCompile(`^(?://|#|\*|--)\s*source:\s*(\S+)`)
+
+// commentPrefixes start the comment lines of a file header.
+var commentPrefixes = []string{"//", "#", "/*", "*", "--", ";", "<!--", "%", "'", `"""`, "{{/*"}
+
+// Marker describes why a file is considered generated.
+type Marker struct {
+	Line      string // The header line carrying the marker
+	Generator string // The generator, when the marker names it
+	Source    string // The generator input, when the header names it
+}
+
+// FindMarker reads the leading comments of the file at path, up to the first
+// line of code, and returns the generated marker they carry. It returns false
+// if the file has none or does not exist.
+func FindMarker(path string) (Marker, bool, error) {
+	f, err := os.Open(path) // #nosec G304 - path of the file Claude is editing
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Marker{}, false, nil
+		}
+		return Marker{}, false, err
+	}
+	defer func() { _ = f.Close() }()
+	return ScanMarker(f)
+}
+
+// ScanMarker is FindMarker for file content.
+func ScanMarker(r io.Reader) (Marker, bool, error) {
+	var marker Marker
+	found := false
+	scanner := bufio.NewScanner(r)
+	for i := 0; i < maxHeaderLines && scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i == 0 && strings.HasPrefix(line, "#!") {
+			continue
+		}
+		if line != "" && !isComment(line) {
+			break
+		}
+		if match := sourcePattern.FindStringSubmatch(line); match != nil && marker.Source == "" {
+			marker.Source = match[1]
+		}
+		if found {
+			continue
+		}
+		for _, re := range markers {
+			if re.MatchString(line) {
+				marker.Line, found = line, true
+				if match := generatorPattern.FindStringSubmatch(line); match != nil {
+					marker.Generator = match[1]
+				}
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return Marker{}, false, err
+	}
+	return marker, found, nil
+}
+
+// isComment reports whether a trimmed line looks like a comment in one of the
+// common languages.
+func isComment(line string) bool {
+	for _, prefix := range commentPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPattern returns the first pattern matching path. A pattern without a
+// slash, such as *.pb.go, matches the file name in any directory; others are
+// resolved against root and use pathmatch.Match syntax, so api/gen/** matches
+// everything below api/gen.
+func MatchPattern(patterns []string, root, path string) (string, bool) {
+	base := pathmatch.Base(path)
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, `/\`) {
+			if pathmatch.Match(pattern, base) {
+				return pattern, true
+			}
+			continue
+		}
+		if pathmatch.Match(pathmatch.Join(root, pattern), pathmatch.Join(root, path)) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,130 @@
+package generatedguard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanMarker(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		wantFound     bool
+		wantGenerator string
+		wantSource    string
+	}{
+		{
+			name:          "go protobuf",
+			content:       "// Code generated by protoc-gen-go. DO NOT EDIT.\n// versions:\n// \tprotoc v4.25.1\n// source: api/v1/user.proto\n\npackage v1\n",
+			wantFound:     true,
+			wantGenerator: "protoc-gen-go",
+			wantSource:    "api/v1/user.proto",
+		},
+		{
+			name:          "after build constraints",
+			content:       "//go:build linux\n\n// Code generated by \"stringer -type=Mode\"; DO NOT EDIT.\n\npackage mode\n",
+			wantFound:     true,
+			wantGenerator: "stringer",
+		},
+		{
+			name:          "python protobuf",
+			content:       "# -*- coding: utf-8 -*-\n# Generated by the protocol buffer compiler.  DO NOT EDIT!\n# source: user.proto\nimport sys\n",
+			wantFound:     true,
+			wantGenerator: "protocol buffer compiler",
+			wantSource:    "user.proto",
+		},
+		{
+			name:      "block comment",
+			content:   "/**\n * @generated SignedSource<<abc>>\n */\nexport const x = 1;\n",
+			wantFound: true,
+		},
+		{
+			name:          "shebang",
+			content:       "#!/bin/sh\n# This file is auto-generated by make; changes are lost.\necho hi\n",
+			wantFound:     true,
+			wantGenerator: "make",
+		},
+		{
+			name:    "marker after code",
+			content: "package main\n\n// DO NOT EDIT below this line without review\nfunc main() {}\n",
+		},
+		{
+			name:    "hand-written",
+			content: "// Package main is hand-written.\npackage main\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marker, found, err := ScanMarker(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found != tt.wantFound || marker.Generator != tt.wantGenerator || marker.Source != tt.wantSource {
+				t.Errorf("ScanMarker() = %+v, %v, want found %v, generator %q, source %q", marker, found, tt.wantFound, tt.wantGenerator, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "project")
+	patterns := []string{"*.pb.go", "api/gen/**"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(root, "internal", "user", "user.pb.go"), "*.pb.go"},
+		{filepath.Join(root, "api", "gen", "client", "client.go"), "api/gen/**"},
+		{filepath.Join(root, "api", "spec.yaml"), ""},
+		{filepath.Join(root, "main.go"), ""},
+	}
+	for _, tt := range tests {
+		got, ok := MatchPattern(patterns, root, tt.path)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("MatchPattern(%s) = %q, %v, want %q", tt.path, got, ok, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	marked := write("mode_string.go", "// Code generated by \"stringer -type=Mode\"; DO NOT EDIT.\n\npackage mode\n")
+	plain := write("mode.go", "package mode\n")
+	mock := write("mock_gen.go", "package mode\n")
+
+	tests := []struct {
+		name    string
+		path    string
+		allow   []string
+		markers bool
+		want    string
+	}{
+		{"marked", marked, nil, true, "mode_string.go, which is marked generated"},
+		{"markers disabled", marked, nil, false, ""},
+		{"allowed", marked, []string{"*_string.go"}, true, ""},
+		{"builtin pattern", mock, nil, true, "mock_gen.go, which matches the generated file pattern *_gen.go"},
+		{"new file matching a pattern", filepath.Join(root, "new.pb.go"), nil, true, "new.pb.go, which matches"},
+		{"hand-written", plain, nil, true, ""},
+		{"new file", filepath.Join(root, "new.go"), nil, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := check(tt.path, root, builtinPatterns, tt.allow, tt.markers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(got, tt.want) || (tt.want == "") != (got == "") {
+				t.Errorf("check() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
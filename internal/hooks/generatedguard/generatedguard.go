@@ -0,0 +1,274 @@
+// Package generatedguard implements the generated-guard hook, which blocks manual edits of generated files
+package generatedguard
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// listFlag allows multiple -pattern and -allow flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var patterns, allow listFlag
+	flag.Var(&patterns, "pattern", "Glob of generated files, e.g. *.pb.go or api/gen/** (can be specified multiple times)")
+	flag.Var(&allow, "allow", "Glob of files that may be edited even if generated (can be specified multiple times)")
+	useBuiltin := flag.Bool("builtin-patterns", true, "Treat the built-in globs of common generator output (*.pb.go, *_gen.go, ...) as generated")
+	checkMarkers := flag.Bool("markers", true, "Treat files whose header comments say DO NOT EDIT, @generated, or auto-generated as generated")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+	settings := config.RegisterFlags(flag.CommandLine, config.FailClosed)
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "generated-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "generated-guard", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:           "generated-guard",
+		Events:         []string{hook.EventPreToolUse},
+		Tools:          []string{"Edit", "MultiEdit", "Write", "NotebookEdit"},
+		PolicySections: []string{"generated_paths"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "generated-guard", "Failed to parse hook input", err)
+		return
+	}
+
+	var target string
+	switch input.ToolName {
+	case "Edit", "MultiEdit", "Write":
+		target = input.ToolInput.FilePath
+	case "NotebookEdit":
+		target = input.ToolInput.NotebookPath
+	}
+	if target == "" {
+		hook.AllowPreToolUse()
+		return
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(input.Cwd, target)
+	}
+	root := os.Getenv("CLAUDE_PROJECT_DIR")
+	if root == "" {
+		root = input.Cwd
+	}
+
+	// Combine -pattern with the generated_paths of every policy layer
+	layers, err := config.LoadLayers(context.Background(), settings, input.Cwd)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "generated-guard", "Failed to load rules", err)
+		return
+	}
+	globs := append(config.Merge(layers).GeneratedPaths, patterns...)
+	if *useBuiltin {
+		globs = append(globs, builtinPatterns...)
+	}
+
+	issue, err := check(target, root, globs, allow, *checkMarkers)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "generated-guard", "Failed to read "+target, err)
+		return
+	}
+	logger.Debug("checked file", "file", target, "issue", issue)
+	if issue == "" {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	issues := []string{fmt.Sprintf("%s of %s", input.ToolName, issue)}
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "generated-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  settings.BlockDecision(),
+		Issues:    issues,
+		FilePath:  target,
+	})
+	settings.Output().BlockPreToolUse("Generated file! Change the generator's inputs and regenerate instead of editing its output.", issues)
+}
+
+// check returns why target must not be edited by hand, or "" if it may be.
+// Files matching allow are never generated; otherwise a file is generated if
+// it matches one of globs or, with markers, its header carries a generated
+// marker.
+func check(target, root string, globs, allow []string, markers bool) (string, error) {
+	rel := target
+	if r, err := filepath.Rel(root, target); err == nil && !strings.HasPrefix(r, "..") {
+		rel = r
+	}
+	if _, ok := MatchPattern(allow, root, target); ok {
+		return "", nil
+	}
+	if pattern, ok := MatchPattern(globs, root, target); ok {
+		return fmt.Sprintf("%s, which matches the generated file pattern %s", rel, pattern), nil
+	}
+	if !markers {
+		return "", nil
+	}
+	marker, found, err := FindMarker(target)
+	if err != nil || !found {
+		return "", err
+	}
+	issue := fmt.Sprintf("%s, which is marked generated (%s)", rel, marker.Line)
+	switch {
+	case marker.Source != "" && marker.Generator != "":
+		issue += fmt.Sprintf("; edit %s and rerun %s", marker.Source, marker.Generator)
+	case marker.Source != "":
+		issue += fmt.Sprintf("; edit %s and regenerate", marker.Source)
+	case marker.Generator != "":
+		issue += fmt.Sprintf("; rerun %s after changing its inputs", marker.Generator)
+	}
+	return issue, nil
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `generated-guard: Generated file protection for Claude Code hooks
+
+Blocks Edit, MultiEdit, Write, and NotebookEdit calls on generated files, and
+tells Claude to change the generator's inputs (a .proto file, a go:generate
+source, an OpenAPI spec) and regenerate instead. Hand edits of generated code
+are lost the next time the generator runs.
+
+A file is generated if it matches a generated file glob, or if the comments
+at the top of it, before the first line of code, say "DO NOT EDIT",
+"@generated", or "auto-generated", as Go's "Code generated ... DO NOT EDIT."
+convention and most generators do. When the header names the generator or
+its input (e.g. "// source: api/v1/user.proto"), the block message does too.
+
+USAGE:
+    generated-guard [-pattern GLOB ...] [-allow GLOB ...] [OPTIONS]
+
+OPTIONAL:
+    -pattern string
+            Glob of generated files (can be specified multiple times). A glob
+            without a slash, such as *.pb.go, matches the file name in any
+            directory; others are relative to the project root, e.g. api/gen/**
+
+    -allow string
+            Glob of files that may be edited even if generated, such as
+            generator templates (can be specified multiple times)
+
+    -builtin-patterns
+            Treat the built-in globs of common generator output as generated:
+            *.pb.go, *_grpc.pb.go, *_gen.go, *.gen.go, zz_generated*.go,
+            *_pb2.py, *.pb.h, *.pb.cc, *_pb.js, *.g.dart, ... (default: true)
+
+    -markers
+            Treat files whose header comments carry a generated marker as
+            generated (default: true)
+
+    -rules string
+            YAML or JSON policy file; its generated_paths globs are added to -pattern:
+              generated_paths:
+                - "*.pb.go"
+                - api/gen/**
+
+    -fail-mode string
+            Behavior when input or rules cannot be parsed, or the file cannot
+            be read: closed (block) or open (allow) (default: closed)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every blocked edit to this file
+
+    -discover
+            Load .claudehooks.yaml found by walking up from the payload cwd
+            (default: true; use -discover=false to disable)
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_GENERATED_GUARD_<FLAG> to target only this hook. Separate
+    multiple -pattern or -allow values with semicolons.
+
+EXAMPLES:
+    # Also protect the OpenAPI client, but let Claude edit the mocks
+    generated-guard -pattern "internal/client/**" -allow "internal/mocks/*_gen.go"
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Edit|MultiEdit|Write|NotebookEdit",
+        "hooks": [{"type": "command", "command": "/path/to/generated-guard"}]
+      }
+    ]
+  }
+}
+
+`)
+}
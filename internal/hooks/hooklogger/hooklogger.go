@@ -0,0 +1,108 @@
+// Package hooklogger implements the hook-logger hook for debugging Claude Code hook payloads.
+package hooklogger
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	silent := flag.Bool("silent", false, "Suppress stdout output (for logging only)")
+	logFile := flag.String("log", "", "Log file path (if not specified, outputs to stdout)")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	// Environment variables (CLAUDE_HOOKS_HOOK_LOGGER_LOG, ...) provide defaults
+	if err := config.BindEnv(flag.CommandLine, "hook-logger"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitSuccess) // Don't block the operation
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "hook-logger", hook.Events...)
+	manifest := config.Manifest{
+		Hook:   "hook-logger",
+		Events: hook.Events,
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+
+	// Read JSON input from stdin
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		hook.NonBlockingError(fmt.Sprintf("Error reading input: %v", err))
+	}
+
+	// Parse JSON to pretty print it
+	var data any
+	err = json.Unmarshal(input, &data)
+	if err != nil {
+		if !*silent {
+			fmt.Fprintf(os.Stderr, "Error parsing JSON: %v\n", err)
+			// Output raw input
+			fmt.Printf("HOOK_PAYLOAD_RAW: %s\n", string(input))
+		}
+		hook.Exit(hook.ExitSuccess) // Don't block the operation
+	}
+
+	// Pretty print the JSON
+	prettyJSON, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		if !*silent {
+			fmt.Fprintf(os.Stderr, "Error formatting JSON: %v\n", err)
+			fmt.Printf("HOOK_PAYLOAD_RAW: %s\n", string(input))
+		}
+		hook.Exit(hook.ExitSuccess)
+	}
+
+	// Format output
+	output := fmt.Sprintf("=== HOOK PAYLOAD ===\n%s\n===================\n", string(prettyJSON))
+
+	// Output to log file or stdout
+	if *logFile != "" {
+		// Ensure directory exists
+		dir := filepath.Dir(*logFile)
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			if !*silent {
+				fmt.Fprintf(os.Stderr, "Error creating log directory: %v\n", err)
+			}
+			hook.Exit(hook.ExitSuccess)
+		}
+
+		// Append to log file
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			if !*silent {
+				fmt.Fprintf(os.Stderr, "Error opening log file: %v\n", err)
+			}
+			hook.Exit(hook.ExitSuccess)
+		}
+		defer func() {
+			if err := f.Close(); err != nil && !*silent {
+				fmt.Fprintf(os.Stderr, "Error closing log file: %v\n", err)
+			}
+		}()
+
+		if _, err := f.WriteString(output); err != nil {
+			if !*silent {
+				fmt.Fprintf(os.Stderr, "Error writing to log file: %v\n", err)
+			}
+			hook.Exit(hook.ExitSuccess)
+		}
+	} else if !*silent {
+		// Output to stdout only if not silent
+		fmt.Print(output)
+	}
+
+	// Always exit 0 to not block operations
+	hook.Exit(hook.ExitSuccess)
+}
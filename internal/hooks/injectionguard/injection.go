@@ -0,0 +1,156 @@
+// Package injectionguard - prompt-injection markers in fetched content
+package injectionguard
+
+import (
+	"encoding/base64"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMinBase64 is the shortest base64 run, in characters, that is decoded
+// to look for encoded instructions.
+const DefaultMinBase64 = 64
+
+// Kinds of prompt-injection markers.
+const (
+	KindInstruction = "instruction" // Text addressed to the model, e.g. "ignore previous instructions"
+	KindRoleMarker  = "role-marker" // Chat template tokens that fake a turn, e.g. <|im_start|>
+	KindHiddenHTML  = "hidden-html" // Instructions in comments or elements a reader does not see
+	KindHiddenText  = "hidden-text" // Invisible Unicode, such as tag characters
+	KindEncoded     = "encoded"     // A base64 block that decodes to text
+)
+
+// Finding is a prompt-injection marker in fetched content.
+type Finding struct {
+	Kind    string
+	Line    int    // 1-based line the marker starts on
+	Excerpt string // The marker, or what it hides, shortened
+}
+
+var (
+	instructionPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\s+(?:all\s+|any\s+)?(?:of\s+)?(?:the\s+|your\s+)?(?:previous|prior|above|earlier|preceding|system)\s+(?:instructions|prompts?|directions|rules|messages)`),
+		regexp.MustCompile(`(?i)\bnew\s+(?:system\s+)?instructions\s*:`),
+		regexp.MustCompile(`(?i)\b(?:reveal|print|output|repeat)\s+(?:your|the)\s+(?:system\s+prompt|instructions)`),
+		regexp.MustCompile(`(?i)\b(?:without\s+(?:telling|informing|notifying)|(?:do\s+not|don't)\s+(?:tell|inform|notify))\s+the\s+user`),
+		regexp.MustCompile(`(?i)\bif\s+you\s+are\s+an?\s+(?:AI|LLM|large\s+language\s+model|language\s+model|assistant|agent|coding\s+agent)\b`),
+	}
+	roleMarker = regexp.MustCompile(`(?i)<\|(?:im_start|im_end|system|user|assistant|endoftext)\|>|\[/?INST\]|<</?SYS>>|</?(?:system|system-reminder)>|\n\n(?:Human|Assistant):`)
+
+	htmlComment  = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+	addressed    = regexp.MustCompile(`(?i)\b(?:instructions?|assistant|claude|LLM|AI\s+agent|language\s+model|system\s+prompt|ignore|you\s+must)\b`)
+	hiddenStyled = regexp.MustCompile(`(?is)<\w+[^>]*\bstyle\s*=\s*["'][^"']*(?:display\s*:\s*none|visibility\s*:\s*hidden|font-size\s*:\s*0|opacity\s*:\s*0)[^"']*["'][^>]*>([^<]{20,})`)
+	zeroWidth    = regexp.MustCompile("[\u200B\u200C\u200D\u2060\uFEFF]{4,}")
+
+	base64Run = regexp.MustCompile(`[A-Za-z0-9+/_-]{16,}={0,2}`)
+)
+
+// Scan returns the prompt-injection markers in text, in order. Base64 runs
+// of at least minBase64 characters are decoded and reported when they hold
+// text; 0 disables the check.
+func Scan(text string, minBase64 int) []Finding {
+	var findings []Finding
+	add := func(kind string, start int, excerpt string) {
+		findings = append(findings, Finding{Kind: kind, Line: strings.Count(text[:start], "\n") + 1, Excerpt: shorten(excerpt)})
+	}
+
+	for _, pattern := range instructionPatterns {
+		for _, loc := range pattern.FindAllStringIndex(text, -1) {
+			add(KindInstruction, loc[0], sentence(text, loc[0]))
+		}
+	}
+	for _, loc := range roleMarker.FindAllStringIndex(text, -1) {
+		start := loc[0] + len(text[loc[0]:loc[1]]) - len(strings.TrimLeft(text[loc[0]:loc[1]], "\n"))
+		add(KindRoleMarker, start, text[start:loc[1]])
+	}
+	for _, loc := range htmlComment.FindAllStringSubmatchIndex(text, -1) {
+		if body := text[loc[2]:loc[3]]; addressed.MatchString(body) {
+			add(KindHiddenHTML, loc[0], body)
+		}
+	}
+	for _, loc := range hiddenStyled.FindAllStringSubmatchIndex(text, -1) {
+		add(KindHiddenHTML, loc[0], text[loc[2]:loc[3]])
+	}
+	if hidden, start := tagText(text); hidden != "" {
+		add(KindHiddenText, start, hidden)
+	}
+	for _, loc := range zeroWidth.FindAllStringIndex(text, -1) {
+		add(KindHiddenText, loc[0], "run of zero-width characters")
+	}
+	if minBase64 > 0 {
+		for _, loc := range base64Run.FindAllStringIndex(text, -1) {
+			if loc[1]-loc[0] < minBase64 {
+				continue
+			}
+			if decoded, ok := decodeText(text[loc[0]:loc[1]]); ok {
+				add(KindEncoded, loc[0], decoded)
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int { return a.Line - b.Line })
+	return findings
+}
+
+// tagText returns what Unicode tag characters (U+E0020 to U+E007E), which
+// mirror ASCII but render as nothing, spell out in text, and where the
+// first one is.
+func tagText(text string) (string, int) {
+	var hidden strings.Builder
+	first := -1
+	for i, r := range text {
+		if r >= 0xE0020 && r <= 0xE007E {
+			if first < 0 {
+				first = i
+			}
+			hidden.WriteRune(r - 0xE0000)
+		}
+	}
+	return hidden.String(), first
+}
+
+// decodeText decodes a base64 run and reports whether it is text, as
+// instructions would be, rather than binary data such as an image.
+func decodeText(run string) (string, bool) {
+	var data []byte
+	var err error
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if data, err = encoding.DecodeString(run); err == nil {
+			break
+		}
+	}
+	if err != nil || !utf8.Valid(data) {
+		return "", false
+	}
+	text := string(data)
+	printable := 0
+	for _, r := range text {
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			printable++
+		}
+	}
+	letters := strings.IndexFunc(text, unicode.IsLetter) >= 0
+	return text, letters && printable*10 >= utf8.RuneCountInString(text)*9
+}
+
+// sentence returns the text from start to the end of its sentence or line.
+func sentence(text string, start int) string {
+	rest := text[start:]
+	if end := strings.IndexAny(rest, ".!?\n"); end >= 0 {
+		rest = rest[:end+1]
+	}
+	return rest
+}
+
+// shorten collapses whitespace in s and cuts it to 80 characters.
+func shorten(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= 80 {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:79]) + "…"
+}
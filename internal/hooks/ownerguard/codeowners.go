@@ -0,0 +1,234 @@
+// Package ownerguard - CODEOWNERS parsing
+package ownerguard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeownersLocations are where GitHub and GitLab look for a CODEOWNERS file,
+// relative to the repository root, in order of precedence.
+var codeownersLocations = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+	filepath.Join(".gitlab", "CODEOWNERS"),
+}
+
+// OwnerRule is one CODEOWNERS line: a pattern and the owners of the files it
+// matches. A rule without owners leaves its files unowned.
+type OwnerRule struct {
+	Pattern string
+	Owners  []string
+	Line    int
+	re      *regexp.Regexp
+}
+
+// Codeowners is a parsed CODEOWNERS file.
+type Codeowners struct {
+	Path  string // The CODEOWNERS file
+	Root  string // Directory its patterns are relative to
+	Rules []OwnerRule
+}
+
+// FindCodeowners returns the path of the CODEOWNERS file governing path: the
+// first of the standard locations found in path's directory or the nearest
+// directory above it.
+func FindCodeowners(path string) (string, bool) {
+	dir := filepath.Dir(filepath.Clean(path))
+	for {
+		for _, location := range codeownersLocations {
+			candidate := filepath.Join(dir, location)
+			if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+				return candidate, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// LoadCodeowners reads and parses a CODEOWNERS file. Its patterns are relative
+// to the repository root: the directory containing the file, or its parent
+// for a file in .github, docs, or .gitlab.
+func LoadCodeowners(file string) (*Codeowners, error) {
+	f, err := os.Open(file) // #nosec G304 - CODEOWNERS path from the project or -codeowners
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	rules, err := ParseCodeowners(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Dir(abs)
+	switch filepath.Base(root) {
+	case ".github", "docs", ".gitlab":
+		root = filepath.Dir(root)
+	}
+	return &Codeowners{Path: file, Root: root, Rules: rules}, nil
+}
+
+// ParseCodeowners parses CODEOWNERS rules. Blank lines, comments, and GitLab
+// section headers such as [Docs] are skipped.
+func ParseCodeowners(r io.Reader) ([]OwnerRule, error) {
+	var rules []OwnerRule
+	var errs []error
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := codeownersFields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "[") || strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+		re, err := compilePattern(fields[0])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: invalid pattern %q: %w", line, fields[0], err))
+			continue
+		}
+		rules = append(rules, OwnerRule{Pattern: fields[0], Owners: fields[1:], Line: line, re: re})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, errors.Join(errs...)
+}
+
+// codeownersFields splits a CODEOWNERS line into its pattern and owners,
+// dropping a trailing comment. A backslash escapes a space or # in the
+// pattern.
+func codeownersFields(line string) []string {
+	var fields []string
+	var field strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line) && (line[i+1] == ' ' || line[i+1] == '#'):
+			i++
+			field.WriteByte(line[i])
+		case c == '#' && field.Len() == 0:
+			i = len(line)
+		case c == ' ' || c == '\t':
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteByte(c)
+		}
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// compilePattern translates a CODEOWNERS pattern, which follows gitignore
+// rules, into a regular expression matching slash-separated paths relative
+// to the repository root:
+//
+//   - a pattern with a leading or inner slash is anchored at the root;
+//     otherwise it matches at any depth
+//   - * and ? do not cross slashes, and ** matches any number of directories
+//   - a pattern matching a directory matches everything below it, except
+//     that a trailing /* matches only the directory's direct children
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	directChildren := strings.HasSuffix(pattern, "/*") && !strings.HasSuffix(pattern, "/**")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+	if trimmed == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(trimmed); i++ {
+		switch c := trimmed[i]; c {
+		case '*':
+			if i+1 < len(trimmed) && trimmed[i+1] == '*' {
+				i++
+				if i+1 < len(trimmed) && trimmed[i+1] == '/' {
+					i++
+					expr.WriteString("(?:.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(trimmed[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := trimmed[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if !directChildren {
+		expr.WriteString("(?:/.*)?")
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// Owners returns the rule governing a path relative to the repository root:
+// the last rule whose pattern matches it. It returns false if no rule does.
+func (c *Codeowners) Owners(rel string) (OwnerRule, bool) {
+	rel = path.Clean(filepath.ToSlash(rel))
+	for i := len(c.Rules) - 1; i >= 0; i-- {
+		if c.Rules[i].re.MatchString(rel) {
+			return c.Rules[i], true
+		}
+	}
+	return OwnerRule{}, false
+}
+
+// Rel returns file relative to the repository root, and false if it is
+// outside the root.
+func (c *Codeowners) Rel(file string) (string, bool) {
+	rel, err := filepath.Rel(c.Root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// OwnerAllowed reports whether owner matches one of the allowed owners.
+// Matching ignores case, as GitHub handles do, and allowed entries may be
+// globs such as "@org/platform-*".
+func OwnerAllowed(owner string, allowed []string) bool {
+	owner = strings.ToLower(owner)
+	for _, pattern := range allowed {
+		if matched, err := path.Match(strings.ToLower(pattern), owner); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
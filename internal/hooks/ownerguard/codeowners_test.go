@@ -0,0 +1,104 @@
+package ownerguard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+const testCodeowners = `# Default owners
+*                       @org/platform
+
+[Payments]
+/services/payments/     @org/payments @alice
+*.sql                   @org/dba
+docs/*                  @org/docs
+**/generated/**         @org/codegen
+/services/legacy/
+/third\ party/          @org/vendor # vendored code
+`
+
+func TestCodeownersOwners(t *testing.T) {
+	rules, err := ParseCodeowners(strings.NewReader(testCodeowners))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owners := &Codeowners{Rules: rules}
+
+	tests := []struct {
+		path       string
+		wantOwners string
+		wantLine   int
+	}{
+		{"main.go", "@org/platform", 2},
+		{"services/payments/api/handler.go", "@org/payments @alice", 5},
+		{"services/payments/schema.sql", "@org/dba", 6},
+		{"db/migrations/001.sql", "@org/dba", 6},
+		{"docs/index.md", "@org/docs", 7},
+		{"docs/api/index.md", "@org/platform", 2},
+		{"services/orders/generated/client.go", "@org/codegen", 8},
+		{"services/legacy/main.go", "", 9},
+		{"third party/lib.c", "@org/vendor", 10},
+	}
+	for _, tt := range tests {
+		rule, ok := owners.Owners(tt.path)
+		if !ok || strings.Join(rule.Owners, " ") != tt.wantOwners || rule.Line != tt.wantLine {
+			t.Errorf("Owners(%q) = %v (line %d), %v, want %q (line %d)", tt.path, rule.Owners, rule.Line, ok, tt.wantOwners, tt.wantLine)
+		}
+	}
+}
+
+func TestParseCodeowners_InvalidPattern(t *testing.T) {
+	if _, err := ParseCodeowners(strings.NewReader("/ @org/platform\n")); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("ParseCodeowners() error = %v, want an error for line 1", err)
+	}
+}
+
+func TestGuardCheck(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".github"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte(testCodeowners), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, ok := FindCodeowners(filepath.Join(root, "services", "payments", "new", "file.go"))
+	if !ok || file != filepath.Join(root, ".github", "CODEOWNERS") {
+		t.Fatalf("FindCodeowners() = %q, %v", file, ok)
+	}
+
+	tests := []struct {
+		name      string
+		ownership config.Ownership
+		path      string
+		want      string
+	}{
+		{"allowed team", config.Ownership{Allow: []string{"@org/payments"}}, "services/payments/api.go", ""},
+		{"allowed user, any case", config.Ownership{Allow: []string{"@Alice"}}, "services/payments/api.go", ""},
+		{"allowed glob", config.Ownership{Allow: []string{"@org/pay*"}}, "services/payments/api.go", ""},
+		{"other team", config.Ownership{Allow: []string{"@org/payments"}}, "services/orders/api.go", hook.PermissionDeny},
+		{"other team, ask", config.Ownership{Allow: []string{"@org/payments"}, Action: "ask"}, "services/orders/api.go", hook.PermissionAsk},
+		{"unowned", config.Ownership{Allow: []string{"@org/payments"}}, "services/legacy/main.go", ""},
+		{"unowned, deny", config.Ownership{Allow: []string{"@org/payments"}, Unowned: "deny"}, "services/legacy/main.go", hook.PermissionDeny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, issue, err := NewGuard("", tt.ownership).Check(filepath.Join(root, tt.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || (got != "") != (issue != "") {
+				t.Errorf("Check(%s) = %q, %q, want %q", tt.path, got, issue, tt.want)
+			}
+		})
+	}
+
+	// Files outside any repository with a CODEOWNERS file are not checked
+	if got, _, err := NewGuard("", config.Ownership{}).Check(filepath.Join(t.TempDir(), "main.go")); err != nil || got != "" {
+		t.Errorf("Check() without CODEOWNERS = %q, %v, want allow", got, err)
+	}
+}
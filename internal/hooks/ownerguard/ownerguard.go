@@ -0,0 +1,368 @@
+// Package ownerguard implements the owner-guard hook, which keeps edits to files that CODEOWNERS assigns to the allowed owners
+package ownerguard
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// listFlag allows multiple -allow flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var allow listFlag
+	flag.Var(&allow, "allow", "CODEOWNERS owner whose files may be edited, e.g. @org/payments (can be specified multiple times)")
+	codeowners := flag.String("codeowners", "", "CODEOWNERS file to use (default: found above each edited file)")
+	action := flag.String("action", "", "What to do with edits of files other owners own: ask or deny (default: deny)")
+	unowned := flag.String("unowned", "", "What to do with edits of files no CODEOWNERS rule owns: allow, ask, or deny (default: allow)")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+	settings := config.RegisterFlags(flag.CommandLine, config.FailClosed)
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "owner-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "owner-guard", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:           "owner-guard",
+		Events:         []string{hook.EventPreToolUse},
+		Tools:          []string{"Bash", "Edit", "MultiEdit", "Write", "NotebookEdit"},
+		PolicySections: []string{"ownership"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if *action != "" && *action != hook.PermissionAsk && *action != hook.PermissionDeny {
+		fmt.Fprintf(os.Stderr, "Error: invalid action '%s'. Must be ask or deny\n", *action)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if *unowned != "" && !slices.Contains(config.OwnershipActions, *unowned) {
+		fmt.Fprintf(os.Stderr, "Error: invalid unowned action '%s'. Must be allow, ask, or deny\n", *unowned)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "owner-guard", "Failed to parse hook input", err)
+		return
+	}
+
+	var files []string
+	switch input.ToolName {
+	case "Bash":
+		files, err = detector.WrittenPaths(input.ToolInput.Command)
+		if err != nil {
+			hookutil.FailInternal(settings, auditLog, "owner-guard", "Failed to parse command", err)
+			return
+		}
+	case "Edit", "MultiEdit", "Write":
+		files = []string{input.ToolInput.FilePath}
+	case "NotebookEdit":
+		files = []string{input.ToolInput.NotebookPath}
+	}
+	files = slices.DeleteFunc(files, func(file string) bool { return file == "" })
+	if len(files) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	// Combine -allow, -action, and -unowned with the ownership section of every policy layer
+	layers, err := config.LoadLayers(context.Background(), settings, input.Cwd)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "owner-guard", "Failed to load rules", err)
+		return
+	}
+	ownership := config.Merge(layers).Ownership
+	ownership.Allow = append(ownership.Allow, allow...)
+	ownership.Action = stricterAction(ownership.Action, *action)
+	ownership.Unowned = stricterAction(ownership.Unowned, *unowned)
+	if len(ownership.Allow) == 0 {
+		hookutil.FailInternal(settings, auditLog, "owner-guard", "No allowed owners configured", fmt.Errorf("set -allow or the ownership.allow policy setting"))
+		return
+	}
+
+	guard := NewGuard(*codeowners, ownership)
+	decision := ""
+	var issues []string
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(input.Cwd, file)
+		}
+		fileDecision, issue, err := guard.Check(filepath.Clean(file))
+		if err != nil {
+			hookutil.FailInternal(settings, auditLog, "owner-guard", "Failed to load CODEOWNERS", err)
+			return
+		}
+		logger.Debug("checked file ownership", "file", file, "decision", fileDecision, "issue", issue)
+		if fileDecision == "" {
+			continue
+		}
+		issues = append(issues, fmt.Sprintf("%s of %s", input.ToolName, issue))
+		decision = stricterDecision(decision, fileDecision)
+	}
+	if decision == "" {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	auditDecision := audit.DecisionAllow
+	if decision == hook.PermissionDeny {
+		auditDecision = settings.BlockDecision()
+	}
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "owner-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  auditDecision,
+		Issues:    issues,
+		Command:   input.ToolInput.Command,
+		FilePath:  input.ToolInput.FilePath,
+	})
+
+	if decision == hook.PermissionDeny {
+		settings.Output().BlockPreToolUse("File owned by another team! Ask the user before changing code outside your ownership.", issues)
+		return
+	}
+	settings.Output().DecidePreToolUse(hook.PermissionAsk, "Edit outside your code ownership: "+strings.Join(issues, "; "))
+}
+
+// Guard decides edits by the owners CODEOWNERS assigns to the edited files.
+type Guard struct {
+	codeowners string // Explicit CODEOWNERS file; empty finds one per file
+	ownership  config.Ownership
+	loaded     map[string]*Codeowners
+}
+
+// NewGuard returns a Guard using the given CODEOWNERS file, or the one found
+// above each file when codeowners is empty.
+func NewGuard(codeowners string, ownership config.Ownership) *Guard {
+	return &Guard{codeowners: codeowners, ownership: ownership, loaded: map[string]*Codeowners{}}
+}
+
+// Check returns the decision for an edit of file: "" to allow,
+// hook.PermissionAsk, or hook.PermissionDeny, with an issue describing the
+// file's ownership when it is not allowed. Files without a CODEOWNERS file,
+// or outside its repository, are allowed.
+func (g *Guard) Check(file string) (string, string, error) {
+	path := g.codeowners
+	if path == "" {
+		found, ok := FindCodeowners(file)
+		if !ok {
+			return "", "", nil
+		}
+		path = found
+	}
+	owners, ok := g.loaded[path]
+	if !ok {
+		var err error
+		if owners, err = LoadCodeowners(path); err != nil {
+			return "", "", err
+		}
+		g.loaded[path] = owners
+	}
+
+	rel, ok := owners.Rel(file)
+	if !ok {
+		return "", "", nil
+	}
+	rule, ok := owners.Owners(rel)
+	if !ok || len(rule.Owners) == 0 {
+		decision := permission(g.ownership.Unowned, "allow")
+		if decision == "" {
+			return "", "", nil
+		}
+		return decision, fmt.Sprintf("%s, which no CODEOWNERS rule owns", rel), nil
+	}
+	for _, owner := range rule.Owners {
+		if OwnerAllowed(owner, g.ownership.Allow) {
+			return "", "", nil
+		}
+	}
+	return permission(g.ownership.Action, "deny"), fmt.Sprintf("%s, owned by %s (%s line %d: %s)", rel, strings.Join(rule.Owners, " "), filepath.Base(owners.Path), rule.Line, rule.Pattern), nil
+}
+
+// permission converts an ownership action, or the default when it is unset,
+// to a hook permission decision; allow becomes "".
+func permission(action, defaultAction string) string {
+	if action == "" {
+		action = defaultAction
+	}
+	if action == "allow" {
+		return ""
+	}
+	return action
+}
+
+// stricterAction returns the stricter of two config.OwnershipActions values;
+// an empty value is unset.
+func stricterAction(a, b string) string {
+	if slices.Index(config.OwnershipActions, b) > slices.Index(config.OwnershipActions, a) {
+		return b
+	}
+	return a
+}
+
+// stricterDecision returns the stricter of two permission decisions: deny
+// wins over ask, which wins over allow ("").
+func stricterDecision(a, b string) string {
+	if a == hook.PermissionDeny || b == "" {
+		return a
+	}
+	return b
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `owner-guard: Code ownership guard for Claude Code hooks
+
+Keeps Claude's edits to the parts of a monorepo it is meant to work on. Each
+file an Edit, MultiEdit, Write, or NotebookEdit call changes, or a Bash
+command writes by redirection, tee, cp, mv, and the like, is looked up in the
+CODEOWNERS file of its repository (.github/CODEOWNERS, CODEOWNERS,
+docs/CODEOWNERS, or .gitlab/CODEOWNERS). The last matching rule decides, as
+on GitHub: the edit is allowed if any of the file's owners is allowed, and
+denied, or confirmed with the user, otherwise.
+
+USAGE:
+    owner-guard -allow OWNER [-allow OWNER ...] [-action ask|deny] [-unowned allow|ask|deny] [OPTIONS]
+
+OWNERSHIP (from flags and the ownership section of policy files; combined):
+    -allow string
+            CODEOWNERS owner whose files may be edited, e.g. @org/payments or
+            dev@example.com (can be specified multiple times). Globs such as
+            "@org/payments-*" match several teams; case is ignored.
+
+    -action string
+            What to do with edits of files other owners own: ask or deny
+            (default: deny)
+
+    -unowned string
+            What to do with edits of files no CODEOWNERS rule owns: allow,
+            ask, or deny (default: allow)
+
+    -rules string
+            YAML or JSON policy file with an ownership section:
+              ownership:
+                allow: ["@org/payments", "@org/payments-*"]
+                action: ask
+                unowned: ask
+            The most general layer with an allow list sets the allowed
+            owners, and the strictest action and unowned values win.
+
+OPTIONAL:
+    -codeowners string
+            CODEOWNERS file to use (default: found above each edited file).
+            Its patterns are relative to its directory, or to the parent of
+            a .github, docs, or .gitlab directory.
+
+    -fail-mode string
+            Behavior when input, the command, rules, or CODEOWNERS cannot be
+            parsed, or no owners are allowed: closed (block) or open (allow)
+            (default: closed)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every denied or confirmed edit to this file
+
+    -discover
+            Load .claudehooks.yaml found by walking up from the payload cwd
+            (default: true; use -discover=false to disable)
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_OWNER_GUARD_<FLAG> to target only this hook. Separate
+    multiple -allow values with semicolons, e.g. to scope a session:
+
+        CLAUDE_HOOKS_OWNER_GUARD_ALLOW="@org/payments;@org/checkout" claude
+
+EXAMPLES:
+    # Only touch the payments team's code
+    owner-guard -allow @org/payments
+
+    # Ask, rather than deny, for other teams' and unowned files
+    owner-guard -allow @org/payments -action ask -unowned ask
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Bash|Edit|MultiEdit|Write|NotebookEdit",
+        "hooks": [{"type": "command", "command": "/path/to/owner-guard -allow @org/payments"}]
+      }
+    ]
+  }
+}
+
+`)
+}
@@ -0,0 +1,190 @@
+// Package pkginstallguard - install command parsing
+package pkginstallguard
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// Install is a package that a command would add or install.
+type Install struct {
+	Ecosystem string // npm, pypi, cargo, or go
+	Name      string // Normalized package name; empty when the argument is dynamic
+	Spec      string // The argument as written, e.g. "left-pad@1.3.0"
+}
+
+// installer describes how a package manager command adds packages.
+type installer struct {
+	ecosystem   string
+	subcommands []string // Subcommands that install packages
+	valueFlags  []string // Flags whose value is the next argument
+}
+
+var (
+	npmInstaller = installer{
+		ecosystem:   "npm",
+		subcommands: []string{"install", "i", "in", "add"},
+		valueFlags:  []string{"--registry", "--prefix", "--tag", "-w", "--workspace", "--cache"},
+	}
+	pipInstaller = installer{
+		ecosystem:   "pypi",
+		subcommands: []string{"install"},
+		valueFlags: []string{
+			"-r", "--requirement", "-c", "--constraint", "-e", "--editable",
+			"-i", "--index-url", "--extra-index-url", "-f", "--find-links",
+			"-t", "--target", "--prefix", "--root", "--python",
+		},
+	}
+	pyprojectInstaller = installer{
+		ecosystem:   "pypi",
+		subcommands: []string{"add"},
+		valueFlags:  []string{"-G", "--group", "--optional", "--source", "--index", "--python"},
+	}
+	cargoInstaller = installer{
+		ecosystem:   "cargo",
+		subcommands: []string{"add", "install"},
+		valueFlags: []string{
+			"--vers", "--version", "--git", "--branch", "--tag", "--rev", "--path",
+			"--registry", "--index", "--features", "-F", "--rename", "-p", "--package",
+			"--target", "--root", "--manifest-path",
+		},
+	}
+	goInstaller = installer{
+		ecosystem:   "go",
+		subcommands: []string{"get", "install"},
+		valueFlags:  []string{"-C", "-modfile", "-tags"},
+	}
+)
+
+// installers maps package manager commands to how they install packages.
+var installers = map[string]installer{
+	"npm":    npmInstaller,
+	"pnpm":   npmInstaller,
+	"yarn":   npmInstaller,
+	"bun":    npmInstaller,
+	"pip":    pipInstaller,
+	"pip3":   pipInstaller,
+	"poetry": pyprojectInstaller,
+	"cargo":  cargoInstaller,
+	"go":     goInstaller,
+}
+
+// FindInstalls returns every package the shell expression would install.
+func FindInstalls(shellExpr string) ([]Install, error) {
+	ast, err := shellparse.Parse(shellExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	var installs []Install
+	for _, call := range shellparse.CallExprs(ast) {
+		if len(call.Args) == 0 {
+			continue
+		}
+		args := make([]string, 0, len(call.Args))
+		static := make([]bool, 0, len(call.Args))
+		for _, word := range call.Args {
+			arg, isStatic := shellparse.StaticWord(word)
+			args = append(args, arg)
+			static = append(static, isStatic)
+		}
+		if !static[0] {
+			continue
+		}
+		installs = append(installs, callInstalls(args, static)...)
+	}
+	return installs, nil
+}
+
+// callInstalls returns the packages installed by a single command call.
+func callInstalls(args []string, static []bool) []Install {
+	cmd := filepath.Base(args[0])
+	offset := 1
+
+	// python -m pip install ..., uv pip install ..., uv add ...
+	switch {
+	case strings.HasPrefix(cmd, "python") && len(args) > 2 && args[1] == "-m" && strings.HasPrefix(args[2], "pip"):
+		cmd, offset = "pip", 3
+	case cmd == "uv" && len(args) > 1 && args[1] == "pip":
+		cmd, offset = "pip", 2
+	case cmd == "uv":
+		cmd = "poetry"
+	}
+
+	inst, ok := installers[cmd]
+	if !ok || len(args) <= offset || !static[offset] || !slices.Contains(inst.subcommands, args[offset]) {
+		return nil
+	}
+
+	var installs []Install
+	for i := offset + 1; i < len(args); i++ {
+		arg := args[i]
+		if static[i] && strings.HasPrefix(arg, "-") {
+			if slices.Contains(inst.valueFlags, arg) {
+				i++ // Skip the flag value, e.g. -r requirements.txt
+			}
+			continue
+		}
+		if !static[i] {
+			installs = append(installs, Install{Ecosystem: inst.ecosystem, Spec: arg})
+			continue
+		}
+		if name := packageName(inst.ecosystem, arg); name != "" {
+			installs = append(installs, Install{Ecosystem: inst.ecosystem, Name: name, Spec: arg})
+		}
+	}
+	return installs
+}
+
+// packageName extracts the registry package name from an install argument,
+// dropping version constraints and extras. Local paths, archives, and URLs
+// are not registry packages and yield "".
+func packageName(ecosystem, spec string) string {
+	if isLocalSpec(spec) {
+		return ""
+	}
+	var name string
+	switch ecosystem {
+	case "npm":
+		// @scope/name@1.0.0 or name@^1
+		name = spec
+		if at := strings.LastIndex(spec, "@"); at > 0 {
+			name = spec[:at]
+		}
+	case "pypi":
+		if strings.HasSuffix(spec, ".whl") || strings.HasSuffix(spec, ".tar.gz") {
+			return ""
+		}
+		name = spec
+		if end := strings.IndexAny(spec, "[=<>!~;@ "); end >= 0 {
+			name = spec[:end]
+		}
+	case "cargo", "go":
+		name, _, _ = strings.Cut(spec, "@")
+	}
+	return normalizeName(ecosystem, name)
+}
+
+// isLocalSpec reports whether an install argument refers to a path or URL
+// rather than a registry package.
+func isLocalSpec(spec string) bool {
+	for _, prefix := range []string{".", "/", "~", "file:", "git+", "git:", "http:", "https:"} {
+		if strings.HasPrefix(spec, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeName puts a package name into the form used for matching. Python
+// package names are case-insensitive and treat -, _, and . as equivalent.
+func normalizeName(ecosystem, name string) string {
+	if ecosystem != "pypi" {
+		return name
+	}
+	name = strings.ToLower(name)
+	return strings.NewReplacer("_", "-", ".", "-").Replace(name)
+}
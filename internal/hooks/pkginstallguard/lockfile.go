@@ -0,0 +1,164 @@
+// Package pkginstallguard - lockfile lookup for existing dependencies
+package pkginstallguard
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// lockfileNames are the files, per ecosystem, that record a project's existing
+// dependencies. The first directory at or above cwd containing any of them is
+// the project root for that ecosystem.
+var lockfileNames = map[string][]string{
+	"npm":   {"package-lock.json", "yarn.lock", "package.json"},
+	"pypi":  {"poetry.lock", "uv.lock", "requirements.txt"},
+	"cargo": {"Cargo.lock"},
+	"go":    {"go.mod"},
+}
+
+// Locked holds the dependencies already recorded in a project's lockfiles.
+type Locked struct {
+	cwd      string
+	packages map[string]map[string]bool // ecosystem -> names, loaded lazily
+	found    map[string]bool            // ecosystem -> a lockfile was found
+}
+
+// NewLocked returns the lockfile view for a project containing cwd.
+func NewLocked(cwd string) *Locked {
+	return &Locked{cwd: cwd, packages: map[string]map[string]bool{}, found: map[string]bool{}}
+}
+
+// Contains reports whether the package is already a dependency, and whether a
+// lockfile for its ecosystem was found at all.
+func (l *Locked) Contains(ecosystem, name string) (contains, found bool) {
+	names, ok := l.packages[ecosystem]
+	if !ok {
+		names, l.found[ecosystem] = loadLocked(l.cwd, ecosystem)
+		l.packages[ecosystem] = names
+	}
+	if !l.found[ecosystem] {
+		return false, false
+	}
+	if ecosystem == "go" {
+		// go get installs packages; go.mod records the modules containing them
+		for module := range names {
+			if name == module || strings.HasPrefix(name, module+"/") {
+				return true, true
+			}
+		}
+		return false, true
+	}
+	return names[name], true
+}
+
+// loadLocked reads the dependency names from the nearest lockfiles.
+func loadLocked(cwd, ecosystem string) (map[string]bool, bool) {
+	if cwd == "" {
+		return nil, false
+	}
+	for dir := filepath.Clean(cwd); ; dir = filepath.Dir(dir) {
+		names := map[string]bool{}
+		found := false
+		for _, file := range lockfileNames[ecosystem] {
+			data, err := os.ReadFile(filepath.Join(dir, file)) // #nosec G304 - lockfile in the project tree
+			if err != nil {
+				continue
+			}
+			found = true
+			for _, name := range parseLockfile(file, data) {
+				names[normalizeName(ecosystem, name)] = true
+			}
+		}
+		if found {
+			return names, true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return nil, false
+		}
+	}
+}
+
+var (
+	// name = "serde" in Cargo.lock, poetry.lock, and uv.lock
+	tomlNameLine = regexp.MustCompile(`^name = "([^"]+)"`)
+	// "@scope/pkg@^1.0.0", pkg@^2.0.0: in yarn.lock entry headers
+	yarnEntry = regexp.MustCompile(`^"?(@?[^@"\s]+)@`)
+	// The module line and example.com/mod v1.2.3 require lines and blocks in go.mod
+	goRequire = regexp.MustCompile(`^(?:module\s+|require\s+)?([^\s()]+\.[^\s()]+)(?:\s+v\S+|$)`)
+)
+
+// parseLockfile extracts the package names recorded in a lockfile.
+func parseLockfile(file string, data []byte) []string {
+	switch file {
+	case "package-lock.json", "package.json":
+		return parsePackageJSON(data)
+	case "yarn.lock":
+		return matchLines(data, yarnEntry, func(line string) bool {
+			return !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "#")
+		})
+	case "requirements.txt":
+		var names []string
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+				continue
+			}
+			if name := packageName("pypi", line); name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	case "go.mod":
+		return matchLines(data, goRequire, func(line string) bool { return true })
+	default:
+		return matchLines(data, tomlNameLine, func(line string) bool { return true })
+	}
+}
+
+// matchLines returns the first submatch of re on every line accepted by keep.
+func matchLines(data []byte, re *regexp.Regexp, keep func(line string) bool) []string {
+	var names []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !keep(line) {
+			continue
+		}
+		if m := re.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// parsePackageJSON reads dependency names from package.json or package-lock.json.
+func parsePackageJSON(data []byte) []string {
+	var doc struct {
+		Dependencies         map[string]json.RawMessage `json:"dependencies"`
+		DevDependencies      map[string]json.RawMessage `json:"devDependencies"`
+		OptionalDependencies map[string]json.RawMessage `json:"optionalDependencies"`
+		PeerDependencies     map[string]json.RawMessage `json:"peerDependencies"`
+		Packages             map[string]json.RawMessage `json:"packages"` // lockfileVersion 2+
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	var names []string
+	for _, deps := range []map[string]json.RawMessage{doc.Dependencies, doc.DevDependencies, doc.OptionalDependencies, doc.PeerDependencies} {
+		for name := range deps {
+			names = append(names, name)
+		}
+	}
+	for key := range doc.Packages {
+		if i := strings.LastIndex(key, "node_modules/"); i >= 0 {
+			names = append(names, key[i+len("node_modules/"):])
+		}
+	}
+	return names
+}
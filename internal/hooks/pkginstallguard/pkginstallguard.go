@@ -0,0 +1,409 @@
+// Package pkginstallguard implements the pkg-install-guard hook, which checks npm, pip, cargo, and go installs against package allow and deny lists, and system package removals against critical packages
+package pkginstallguard
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// knownTyposquats are published typosquats of popular packages. They are
+// denied unless -builtin-deny=false.
+var knownTyposquats = []config.PackageRule{
+	{
+		Ecosystem: "npm",
+		Names:     []string{"crossenv", "cross-env.js", "babelcli", "d3.js", "jquery.js", "mongose", "mssql.js", "mysqljs", "node-fabric", "node-opencv", "node-sqlite", "nodecaffe", "nodemailer-js", "noderequest", "nodesass", "nodesqlite", "sqliter", "sqlserver"},
+		Reason:    "known typosquat",
+	},
+	{
+		Ecosystem: "pypi",
+		Names:     []string{"acqusition", "apidev-coop", "bzip", "colourama", "crypt", "django-server", "djanga", "easyinstall", "jeilyfish", "libpeshnx", "nmap-python", "python-mysql", "python-openssl", "python3-dateutil", "setup-tools", "telnet", "urlib3", "urllib"},
+		Reason:    "known typosquat",
+	},
+	{
+		Ecosystem: "cargo",
+		Names:     []string{"rustdecimal"},
+		Reason:    "known typosquat",
+	},
+}
+
+// criticalSystemPackages are system packages whose removal breaks the host
+// toolchain or remote access. Their removal is denied unless
+// -builtin-deny=false.
+var criticalSystemPackages = []config.PackageRule{{
+	Names: []string{
+		"git", "bash", "zsh", "coreutils", "sudo", "curl", "ca-certificates",
+		"openssh*", "ssh", "systemd", "libc6", "glibc", "python3", "perl",
+		"apt", "dpkg", "yum", "dnf", "rpm", "snapd", "docker*", "containerd*",
+	},
+	Reason: "critical system package",
+}}
+
+// packageFlag collects -deny and -allow values of the form "[ecosystem:]pattern".
+type packageFlag []string
+
+func (p *packageFlag) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *packageFlag) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (p *packageFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var deny, allow, critical packageFlag
+	flag.Var(&deny, "deny", "Package to deny as [ecosystem:]pattern (can be specified multiple times)")
+	flag.Var(&allow, "allow", "Package never to ask about as [ecosystem:]pattern (can be specified multiple times)")
+	flag.Var(&critical, "critical", "System package never to remove as [manager:]pattern (can be specified multiple times)")
+	askNew := flag.Bool("ask-new", false, "Ask before installing packages that are not already in the project lockfile")
+	builtinDeny := flag.Bool("builtin-deny", true, "Deny the built-in lists of known typosquats and critical system packages")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+	settings := config.RegisterFlags(flag.CommandLine, config.FailClosed)
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "pkg-install-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "pkg-install-guard", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:           "pkg-install-guard",
+		Events:         []string{hook.EventPreToolUse},
+		Tools:          []string{"Bash"},
+		PolicySections: []string{"packages"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "pkg-install-guard", "Failed to parse hook input", err)
+		return
+	}
+
+	installs, err := FindInstalls(input.ToolInput.Command)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "pkg-install-guard", "Failed to parse command", err)
+		return
+	}
+	removals, err := FindRemovals(input.ToolInput.Command)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "pkg-install-guard", "Failed to parse command", err)
+		return
+	}
+	if len(installs) == 0 && len(removals) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	// Combine -deny, -allow, -critical, and -ask-new with the packages section of every policy layer
+	layers, err := config.LoadLayers(context.Background(), settings, input.Cwd)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "pkg-install-guard", "Failed to load rules", err)
+		return
+	}
+	packages := config.Merge(layers).Packages
+	packages.Deny = append(packages.Deny, parsePackageFlags(deny)...)
+	packages.Allow = append(packages.Allow, parsePackageFlags(allow)...)
+	packages.Critical = append(packages.Critical, parseRuleFlags(critical, config.SystemPackageManagers)...)
+	packages.AskNew = packages.AskNew || *askNew
+	if *builtinDeny {
+		packages.Deny = append(packages.Deny, knownTyposquats...)
+		packages.Critical = append(packages.Critical, criticalSystemPackages...)
+	}
+
+	decision, issues := Evaluate(installs, packages, NewLocked(input.Cwd))
+	removalDecision, removalIssues := EvaluateRemovals(removals, packages.Critical)
+	decision, issues = combineDecisions(decision, issues, removalDecision, removalIssues)
+	logger.Debug("evaluated packages", "installs", len(installs), "removals", len(removals), "decision", decision, "issues", issues)
+	if decision == "" {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	auditDecision := audit.DecisionAllow
+	if decision == hook.PermissionDeny {
+		auditDecision = settings.BlockDecision()
+	}
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "pkg-install-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  auditDecision,
+		Issues:    issues,
+		Command:   input.ToolInput.Command,
+	})
+
+	if decision == hook.PermissionDeny {
+		settings.Output().BlockPreToolUse("Denied package change detected!", issues)
+		return
+	}
+	settings.Output().DecidePreToolUse(hook.PermissionAsk, "Package change needs confirmation: "+strings.Join(issues, "; "))
+}
+
+// Evaluate checks each install against the package lists. It returns
+// hook.PermissionDeny if any package is denied, hook.PermissionAsk if any
+// package needs confirmation, or "" to allow, with an issue per package that
+// was not allowed outright. A deny always wins over an allow.
+func Evaluate(installs []Install, packages config.Packages, locked *Locked) (string, []string) {
+	var denied, asked []string
+	for _, install := range installs {
+		if install.Name == "" {
+			asked = append(asked, fmt.Sprintf("%s package %q cannot be verified statically", install.Ecosystem, install.Spec))
+			continue
+		}
+		if rule, ok := matchRule(packages.Deny, install); ok {
+			issue := fmt.Sprintf("%s package %s is denied", install.Ecosystem, install.Name)
+			if rule.Reason != "" {
+				issue += ": " + rule.Reason
+			}
+			denied = append(denied, issue)
+			continue
+		}
+		if _, ok := matchRule(packages.Allow, install); ok || !packages.AskNew {
+			continue
+		}
+		switch contains, found := locked.Contains(install.Ecosystem, install.Name); {
+		case !found:
+			asked = append(asked, fmt.Sprintf("%s package %s is new (no lockfile found)", install.Ecosystem, install.Name))
+		case !contains:
+			asked = append(asked, fmt.Sprintf("%s package %s is not in the lockfile", install.Ecosystem, install.Name))
+		}
+	}
+
+	switch {
+	case len(denied) > 0:
+		return hook.PermissionDeny, denied
+	case len(asked) > 0:
+		return hook.PermissionAsk, asked
+	default:
+		return "", nil
+	}
+}
+
+// EvaluateRemovals checks each system package removal. It returns
+// hook.PermissionDeny if any removal runs without a confirmation prompt (as
+// with apt-get -y) or removes a critical package, hook.PermissionAsk if a
+// package cannot be verified statically, or "" to allow.
+func EvaluateRemovals(removals []Removal, critical []config.PackageRule) (string, []string) {
+	var denied, asked []string
+	for _, removal := range removals {
+		switch {
+		case removal.Unattended:
+			denied = append(denied, fmt.Sprintf("%s %s removal runs without confirmation", removal.Manager, removal.Spec))
+		case removal.Name == "":
+			asked = append(asked, fmt.Sprintf("%s package %q cannot be verified statically", removal.Manager, removal.Spec))
+		default:
+			if rule, ok := matchRule(critical, Install{Ecosystem: removal.Manager, Name: removal.Name}); ok {
+				issue := fmt.Sprintf("%s removal of %s is denied", removal.Manager, removal.Name)
+				if rule.Reason != "" {
+					issue += ": " + rule.Reason
+				}
+				denied = append(denied, issue)
+			}
+		}
+	}
+
+	switch {
+	case len(denied) > 0:
+		return hook.PermissionDeny, denied
+	case len(asked) > 0:
+		return hook.PermissionAsk, asked
+	default:
+		return "", nil
+	}
+}
+
+// combineDecisions merges the install and removal decisions: a deny wins
+// over an ask, which wins over an allow. Only the issues of the winning
+// decision are kept.
+func combineDecisions(decision string, issues []string, other string, otherIssues []string) (string, []string) {
+	switch {
+	case decision == other:
+		return decision, append(issues, otherIssues...)
+	case decision == hook.PermissionDeny || other == "":
+		return decision, issues
+	default:
+		return other, otherIssues
+	}
+}
+
+// matchRule returns the first rule whose ecosystem and name patterns match the install.
+func matchRule(rules []config.PackageRule, install Install) (config.PackageRule, bool) {
+	for _, rule := range rules {
+		if rule.Ecosystem != "" && rule.Ecosystem != install.Ecosystem {
+			continue
+		}
+		for _, pattern := range rule.Names {
+			if matched, err := path.Match(normalizeName(install.Ecosystem, pattern), install.Name); err == nil && matched {
+				return rule, true
+			}
+		}
+	}
+	return config.PackageRule{}, false
+}
+
+// parsePackageFlags parses -deny and -allow values into package rules. A
+// prefix naming a known ecosystem, as in "npm:@internal/*", limits the rule to
+// that ecosystem.
+func parsePackageFlags(values []string) []config.PackageRule {
+	return parseRuleFlags(values, config.PackageEcosystems)
+}
+
+// parseRuleFlags parses "[ecosystem:]pattern" values into package rules,
+// where ecosystem is one of ecosystems.
+func parseRuleFlags(values, ecosystems []string) []config.PackageRule {
+	var rules []config.PackageRule
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		rule := config.PackageRule{Names: []string{value}}
+		if ecosystem, name, ok := strings.Cut(value, ":"); ok && slices.Contains(ecosystems, ecosystem) {
+			rule = config.PackageRule{Ecosystem: ecosystem, Names: []string{name}}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `pkg-install-guard: Package install guard for Claude Code hooks
+
+Inspects npm/pnpm/yarn/bun, pip/uv/poetry, cargo, and go install commands.
+Denies installs of denied packages (known typosquats, internal-only names) and
+optionally asks before adding dependencies that are not in the project lockfile.
+
+Also inspects brew, apt/apt-get, yum/dnf, and snap removals. Denies removing
+critical system packages and any removal answered in advance with -y/--yes,
+since the host toolchain is not the agent's to change.
+
+USAGE:
+    pkg-install-guard [-deny PATTERN ...] [-allow PATTERN ...] [-critical PATTERN ...] [-ask-new] [OPTIONS]
+
+PACKAGE LISTS (from flags and the packages section of policy files; combined):
+    -deny string
+            Package to deny, as [ecosystem:]pattern (can be specified multiple times)
+            Ecosystems: npm, pypi, cargo, go. Patterns are globs, e.g. "npm:@internal/*"
+
+    -allow string
+            Package never to ask about, as [ecosystem:]pattern (can be specified
+            multiple times). A deny always wins over an allow.
+
+    -ask-new
+            Ask before installing packages missing from the nearest lockfile
+            (package-lock.json, yarn.lock, package.json, poetry.lock, uv.lock,
+            requirements.txt, Cargo.lock, go.mod)
+
+    -critical string
+            System package never to remove, as [manager:]pattern (can be specified
+            multiple times). Managers: brew, apt, yum (also dnf), snap
+
+    -builtin-deny
+            Deny the built-in lists of known typosquats and critical system
+            packages such as git, openssh*, sudo, and systemd (default: true)
+
+    -rules string
+            YAML or JSON policy file with a packages section:
+              packages:
+                deny:
+                  - ecosystem: npm
+                    names: ["@internal/*"]
+                    reason: internal packages must come from the private registry
+                allow:
+                  - names: ["left-pad"]
+                ask_new: true
+                critical:
+                  - ecosystem: brew
+                    names: ["postgresql@*"]
+
+OPTIONAL:
+    -fail-mode string
+            Behavior when input, the command, or rules cannot be parsed: closed
+            (block) or open (allow) (default: closed)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every denied or confirmed package change to this file
+
+    -discover
+            Load .claudehooks.yaml found by walking up from the payload cwd
+            (default: true; use -discover=false to disable)
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_PKG_INSTALL_GUARD_<FLAG> to target only this hook. Separate
+    multiple -deny or -allow values with semicolons.
+
+EXAMPLES:
+    # Deny internal-only npm packages and ask before any new dependency
+    pkg-install-guard -deny "npm:@internal/*" -ask-new
+
+    # Ask about new dependencies except well-known ones
+    pkg-install-guard -ask-new -allow "pypi:requests" -allow "go:golang.org/x/*"
+
+    # Also keep the agent from removing the database server
+    pkg-install-guard -critical "apt:postgresql*" -critical "brew:postgresql@*"
+
+`)
+}
@@ -0,0 +1,226 @@
+package pkginstallguard
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+func TestFindInstalls(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []Install
+	}{
+		{"npm scoped with version", "npm install --save-dev @types/node@20 left-pad", []Install{
+			{Ecosystem: "npm", Name: "@types/node", Spec: "@types/node@20"},
+			{Ecosystem: "npm", Name: "left-pad", Spec: "left-pad"},
+		}},
+		{"npm without packages", "npm ci && npm install", nil},
+		{"npm registry flag value skipped", "npm i --registry https://r.example.com lodash", []Install{
+			{Ecosystem: "npm", Name: "lodash", Spec: "lodash"},
+		}},
+		{"yarn add", "cd web && yarn add react", []Install{{Ecosystem: "npm", Name: "react", Spec: "react"}}},
+		{"pip with constraint and extras", `pip install "Requests[socks]>=2.0" Flask_Login`, []Install{
+			{Ecosystem: "pypi", Name: "requests", Spec: "Requests[socks]>=2.0"},
+			{Ecosystem: "pypi", Name: "flask-login", Spec: "Flask_Login"},
+		}},
+		{"pip requirements file", "pip install -r requirements.txt", nil},
+		{"python -m pip", "python3 -m pip install --upgrade colourama", []Install{
+			{Ecosystem: "pypi", Name: "colourama", Spec: "colourama"},
+		}},
+		{"uv add", "uv add httpx", []Install{{Ecosystem: "pypi", Name: "httpx", Spec: "httpx"}}},
+		{"cargo add", "cargo add serde@1 --features derive", []Install{{Ecosystem: "cargo", Name: "serde", Spec: "serde@1"}}},
+		{"go get", "go get -u golang.org/x/tools/cmd/stringer@latest ./...", []Install{
+			{Ecosystem: "go", Name: "golang.org/x/tools/cmd/stringer", Spec: "golang.org/x/tools/cmd/stringer@latest"},
+		}},
+		{"local paths skipped", "npm install ./vendor/pkg && pip install -e .", nil},
+		{"dynamic package", "npm install $PKG", []Install{{Ecosystem: "npm", Spec: ""}}},
+		{"other commands", "go test ./... && git push", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindInstalls(tt.command)
+			if err != nil {
+				t.Fatalf("FindInstalls() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindInstalls() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package-lock.json"), `{"packages": {"": {}, "node_modules/react": {}, "node_modules/@types/node": {}}}`)
+	writeFile(t, filepath.Join(dir, "requirements.txt"), "# pinned\nrequests==2.31.0\n-r dev.txt\n")
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/app\n\ngo 1.24\n\nrequire (\n\tgolang.org/x/tools v0.30.0 // indirect\n)\n")
+	sub := filepath.Join(dir, "web")
+	if err := os.Mkdir(sub, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	packages := config.Packages{
+		Deny:   append([]config.PackageRule{{Ecosystem: "npm", Names: []string{"@internal/*"}, Reason: "use the private registry"}}, knownTyposquats...),
+		Allow:  parsePackageFlags([]string{"npm:lodash", "crossenv"}),
+		AskNew: true,
+	}
+
+	tests := []struct {
+		name         string
+		command      string
+		wantDecision string
+		wantIssues   []string
+	}{
+		{"locked packages", "npm install react @types/node@20", "", nil},
+		{"allowed package", "npm install lodash", "", nil},
+		{"go package inside locked module", "go get golang.org/x/tools/cmd/stringer@latest", "", nil},
+		{"own module", "go install example.com/app/cmd/tool", "", nil},
+		{"denied with reason", "npm install @internal/auth", hook.PermissionDeny, []string{"npm package @internal/auth is denied: use the private registry"}},
+		{"deny wins over allow", "npm install crossenv react", hook.PermissionDeny, []string{"npm package crossenv is denied: known typosquat"}},
+		{"typosquat normalized", "pip install Python3_Dateutil", hook.PermissionDeny, []string{"pypi package python3-dateutil is denied: known typosquat"}},
+		{"new package", "pip install flask", hook.PermissionAsk, []string{"pypi package flask is not in the lockfile"}},
+		{"no lockfile", "cargo add serde", hook.PermissionAsk, []string{"cargo package serde is new (no lockfile found)"}},
+		{"dynamic package", "pip install $(cat pkgs)", hook.PermissionAsk, []string{`pypi package "" cannot be verified statically`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installs, err := FindInstalls(tt.command)
+			if err != nil {
+				t.Fatalf("FindInstalls() error: %v", err)
+			}
+			decision, issues := Evaluate(installs, packages, NewLocked(sub))
+			if decision != tt.wantDecision || !reflect.DeepEqual(issues, tt.wantIssues) {
+				t.Errorf("Evaluate() = %q, %q, want %q, %q", decision, issues, tt.wantDecision, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestEvaluate_AskNewDisabled(t *testing.T) {
+	installs, err := FindInstalls("npm install brand-new-package")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision, issues := Evaluate(installs, config.Packages{}, NewLocked(t.TempDir())); decision != "" {
+		t.Errorf("Evaluate() = %q, %q, want allow", decision, issues)
+	}
+}
+
+func TestFindRemovals(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []Removal
+	}{
+		{"brew uninstall", "brew uninstall --force git homebrew/core/wget", []Removal{
+			{Manager: "brew", Name: "git", Spec: "git"},
+			{Manager: "brew", Name: "wget", Spec: "homebrew/core/wget"},
+		}},
+		{"sudo apt-get purge with version", "sudo -u root apt-get purge openssh-server=1:9.6 libc6:amd64", []Removal{
+			{Manager: "apt", Name: "openssh-server", Spec: "openssh-server=1:9.6"},
+			{Manager: "apt", Name: "libc6", Spec: "libc6:amd64"},
+		}},
+		{"apt yes before subcommand", "apt-get -qy remove nginx", []Removal{
+			{Manager: "apt", Name: "nginx", Spec: "nginx", Unattended: true},
+		}},
+		{"dnf assumeyes", "dnf remove --assumeyes httpd", []Removal{
+			{Manager: "yum", Name: "httpd", Spec: "httpd", Unattended: true},
+		}},
+		{"option value skipped", "apt -o Dpkg::Use-Pty=0 remove vim", []Removal{{Manager: "apt", Name: "vim", Spec: "vim"}}},
+		{"autoremove -y", "apt autoremove -y", []Removal{{Manager: "apt", Spec: "autoremove", Unattended: true}}},
+		{"autoremove prompts", "apt autoremove", nil},
+		{"snap remove", "snap remove --purge lxd", []Removal{{Manager: "snap", Name: "lxd", Spec: "lxd"}}},
+		{"dynamic package", "yum erase $PKG", []Removal{{Manager: "yum", Spec: ""}}},
+		{"installs are not removals", "apt-get install -y git && brew install jq", nil},
+		{"other commands", "go test ./... && rm -rf build", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindRemovals(tt.command)
+			if err != nil {
+				t.Fatalf("FindRemovals() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindRemovals() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRemovals(t *testing.T) {
+	critical := append(parseRuleFlags([]string{"brew:postgresql@*"}, config.SystemPackageManagers), criticalSystemPackages...)
+
+	tests := []struct {
+		name         string
+		command      string
+		wantDecision string
+		wantIssues   []string
+	}{
+		{"ordinary package", "sudo apt-get remove cowsay", "", nil},
+		{"critical package", "sudo apt-get remove openssh-server", hook.PermissionDeny, []string{"apt removal of openssh-server is denied: critical system package"}},
+		{"configured for brew", "brew uninstall postgresql@16", hook.PermissionDeny, []string{"brew removal of postgresql@16 is denied"}},
+		{"configured for another manager", "snap remove postgresql@16", "", nil},
+		{"non-interactive", "apt-get remove -y cowsay", hook.PermissionDeny, []string{"apt cowsay removal runs without confirmation"}},
+		{"dynamic package", "yum remove $(cat pkgs)", hook.PermissionAsk, []string{`yum package "" cannot be verified statically`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removals, err := FindRemovals(tt.command)
+			if err != nil {
+				t.Fatalf("FindRemovals() error: %v", err)
+			}
+			decision, issues := EvaluateRemovals(removals, critical)
+			if decision != tt.wantDecision || !reflect.DeepEqual(issues, tt.wantIssues) {
+				t.Errorf("EvaluateRemovals() = %q, %q, want %q, %q", decision, issues, tt.wantDecision, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestParseLockfile(t *testing.T) {
+	yarn := `# yarn lockfile v1
+
+"@babel/core@^7.0.0", "@babel/core@^7.1.0":
+  version "7.1.0"
+
+lodash@^4.17.0:
+  version "4.17.21"
+`
+	if got, want := parseLockfile("yarn.lock", []byte(yarn)), []string{"@babel/core", "lodash"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("yarn.lock = %q, want %q", got, want)
+	}
+
+	cargo := "[[package]]\nname = \"serde\"\nversion = \"1.0.0\"\n"
+	if got, want := parseLockfile("Cargo.lock", []byte(cargo)), []string{"serde"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cargo.lock = %q, want %q", got, want)
+	}
+}
+
+func TestParsePackageFlags(t *testing.T) {
+	got := parsePackageFlags([]string{"npm:@internal/*", "left-pad", "example.com/mod", " "})
+	want := []config.PackageRule{
+		{Ecosystem: "npm", Names: []string{"@internal/*"}},
+		{Names: []string{"left-pad"}},
+		{Names: []string{"example.com/mod"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePackageFlags() = %+v, want %+v", got, want)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
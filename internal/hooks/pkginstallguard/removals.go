@@ -0,0 +1,182 @@
+// Package pkginstallguard - system package removal parsing
+package pkginstallguard
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// Removal is a system package that a command would remove from the host.
+type Removal struct {
+	Manager    string // brew, apt, yum, or snap
+	Name       string // Package name; empty when the argument is dynamic
+	Spec       string // The argument as written, e.g. "git=1:2.43.0"
+	Unattended bool   // Removed without a confirmation prompt, as with apt-get -y
+}
+
+// remover describes how a system package manager command removes packages.
+type remover struct {
+	manager     string
+	subcommands []string // Subcommands that remove packages
+	valueFlags  []string // Flags whose value is the next argument
+	yesFlags    []string // Flags that answer the confirmation prompt
+	yesShort    byte     // Short flag letter that answers the prompt, also within -qy
+}
+
+var (
+	brewRemover = remover{
+		manager:     "brew",
+		subcommands: []string{"uninstall", "remove", "rm"},
+	}
+	aptRemover = remover{
+		manager:     "apt",
+		subcommands: []string{"remove", "purge", "autoremove", "autopurge"},
+		valueFlags:  []string{"-o", "--option", "-c", "--config-file", "-t", "--target-release"},
+		yesFlags:    []string{"--yes", "--assume-yes", "--force-yes"},
+		yesShort:    'y',
+	}
+	yumRemover = remover{
+		manager:     "yum",
+		subcommands: []string{"remove", "erase", "autoremove", "rm"},
+		valueFlags: []string{
+			"-c", "--config", "-d", "--debuglevel", "-e", "--errorlevel", "-x", "--exclude",
+			"--enablerepo", "--disablerepo", "--installroot", "--releasever",
+		},
+		yesFlags: []string{"--assumeyes"},
+		yesShort: 'y',
+	}
+	snapRemover = remover{
+		manager:     "snap",
+		subcommands: []string{"remove"},
+		valueFlags:  []string{"--revision"},
+	}
+)
+
+// removers maps system package manager commands to how they remove packages.
+var removers = map[string]remover{
+	"brew":     brewRemover,
+	"apt":      aptRemover,
+	"apt-get":  aptRemover,
+	"aptitude": aptRemover,
+	"yum":      yumRemover,
+	"dnf":      yumRemover,
+	"microdnf": yumRemover,
+	"snap":     snapRemover,
+}
+
+// sudoValueFlags are the sudo and doas options that take a value.
+var sudoValueFlags = []string{"-u", "-g", "-C", "-h", "-p", "-U", "-r", "-t", "-D"}
+
+// FindRemovals returns every system package the shell expression would remove.
+func FindRemovals(shellExpr string) ([]Removal, error) {
+	ast, err := shellparse.Parse(shellExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	var removals []Removal
+	for _, call := range shellparse.CallExprs(ast) {
+		args := make([]string, 0, len(call.Args))
+		static := make([]bool, 0, len(call.Args))
+		for _, word := range call.Args {
+			arg, isStatic := shellparse.StaticWord(word)
+			args = append(args, arg)
+			static = append(static, isStatic)
+		}
+		args, static = skipSudo(args, static)
+		if len(args) == 0 || !static[0] {
+			continue
+		}
+		removals = append(removals, callRemovals(args, static)...)
+	}
+	return removals, nil
+}
+
+// skipSudo returns the command sudo or doas runs, or args unchanged.
+func skipSudo(args []string, static []bool) ([]string, []bool) {
+	if len(args) == 0 || !static[0] || !slices.Contains([]string{"sudo", "doas"}, pathmatch.Base(args[0])) {
+		return args, static
+	}
+	i := 1
+	for i < len(args) && static[i] && strings.HasPrefix(args[i], "-") {
+		if args[i] == "--" {
+			i++
+			break
+		}
+		if slices.Contains(sudoValueFlags, args[i]) {
+			i++ // Skip the option value, e.g. -u root
+		}
+		i++
+	}
+	return args[min(i, len(args)):], static[min(i, len(static)):]
+}
+
+// callRemovals returns the packages removed by a single command call.
+func callRemovals(args []string, static []bool) []Removal {
+	rem, ok := removers[pathmatch.Base(args[0])]
+	if !ok {
+		return nil
+	}
+
+	// Flags may come before or after the subcommand, as in apt-get -y remove git
+	var removals []Removal
+	verb := ""
+	unattended := false
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if static[i] && strings.HasPrefix(arg, "-") {
+			unattended = unattended || rem.isYesFlag(arg)
+			if slices.Contains(rem.valueFlags, arg) {
+				i++ // Skip the flag value, e.g. -o Dpkg::Options::=--force-all
+			}
+			continue
+		}
+		if verb == "" {
+			if !static[i] || !slices.Contains(rem.subcommands, arg) {
+				return nil
+			}
+			verb = arg
+			continue
+		}
+		removal := Removal{Manager: rem.manager, Spec: arg}
+		if static[i] {
+			removal.Name = systemPackageName(arg)
+		}
+		removals = append(removals, removal)
+	}
+	if verb == "" {
+		return nil
+	}
+	if len(removals) == 0 && strings.HasPrefix(verb, "auto") && unattended {
+		// autoremove -y removes whatever is no longer needed without asking
+		removals = append(removals, Removal{Manager: rem.manager, Spec: verb})
+	}
+	for i := range removals {
+		removals[i].Unattended = unattended
+	}
+	return removals
+}
+
+// isYesFlag reports whether a flag answers the confirmation prompt, including
+// a short flag bundled with others, as in apt-get -qy.
+func (r remover) isYesFlag(arg string) bool {
+	if slices.Contains(r.yesFlags, arg) {
+		return true
+	}
+	return r.yesShort != 0 && !strings.HasPrefix(arg, "--") && strings.IndexByte(arg[1:], r.yesShort) >= 0
+}
+
+// systemPackageName extracts the package name from a removal argument,
+// dropping apt versions and architectures (git=1:2.43.0, git:amd64) and brew
+// tap prefixes (homebrew/core/git).
+func systemPackageName(spec string) string {
+	name, _, _ := strings.Cut(spec, "=")
+	name, _, _ = strings.Cut(name, ":")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
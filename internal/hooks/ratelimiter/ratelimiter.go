@@ -0,0 +1,228 @@
+// Package ratelimiter implements the rate-limit hook, which throttles risky-but-allowed tool calls in Claude Code hooks
+package ratelimiter
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/internal/ratelimit"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+const defaultMaxRecursion = 10
+
+// cmdFlag allows multiple -cmd flags to be specified
+type cmdFlag []string
+
+func (c *cmdFlag) String() string {
+	return strings.Join(*c, ", ")
+}
+
+func (c *cmdFlag) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+// Repeatable marks -cmd as a list flag for CLAUDE_HOOKS_CMD environment binding.
+func (c *cmdFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var commands cmdFlag
+	flag.Var(&commands, "cmd", "Risky command and optional patterns to count (can be specified multiple times)")
+
+	limits := ratelimit.Limits{}
+	flag.IntVar(&limits.MaxPerSession, "max-per-session", 0, "Risky operations allowed per session (0 = unlimited)")
+	flag.IntVar(&limits.MaxPerMinute, "max-per-minute", 0, "Risky operations allowed per minute (0 = unlimited)")
+	action := flag.String("action", hook.PermissionAsk, "What to do when a limit is exceeded: ask or deny")
+	stateDir := flag.String("state-dir", ratelimit.DefaultDir(), "Directory for per-session counters")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailOpen}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input cannot be parsed or state cannot be updated: closed (block) or open (allow)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every decision to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "rate-limit"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "rate-limit", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:   "rate-limit",
+		Events: []string{hook.EventPreToolUse},
+		Tools:  []string{"Bash"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || len(commands) == 0 {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if *action != hook.PermissionAsk && *action != hook.PermissionDeny {
+		fmt.Fprintf(os.Stderr, "Error: invalid action '%s'. Must be ask or deny\n", *action)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if limits.MaxPerSession < 0 || limits.MaxPerMinute < 0 {
+		fmt.Fprintf(os.Stderr, "Error: limits must not be negative\n")
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "rate-limit", "Failed to parse hook input", err)
+		return
+	}
+
+	// Only risky operations are counted; everything else passes straight through
+	if !isRisky(parseCommandRules(commands), input.ToolInput.Command) {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	counts, err := ratelimit.NewStore(*stateDir).Record(input.SessionID)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "rate-limit", "Failed to update rate limit state", err)
+		return
+	}
+	logger.Debug("counted risky operation", "session", counts.Session, "last_minute", counts.Window)
+
+	exceeded, reason := limits.Exceeded(counts)
+	if !exceeded {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	reason = "Rate limit exceeded: " + reason
+	decision := audit.DecisionAllow
+	if *action == hook.PermissionDeny {
+		decision = settings.BlockDecision()
+	}
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "rate-limit",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  decision,
+		Reason:    reason,
+		Command:   input.ToolInput.Command,
+	})
+	settings.Output().DecidePreToolUse(*action, reason)
+}
+
+// isRisky reports whether the command matches one of the risky command rules.
+// Commands the detector cannot verify (dynamic commands, unparseable input)
+// are treated as risky too.
+func isRisky(rules []detector.CommandRule, command string) bool {
+	return detector.NewCommandDetector(rules, defaultMaxRecursion).ShouldBlockShellExpr(command)
+}
+
+// parseCommandRules parses -cmd flag values into CommandRule structs
+func parseCommandRules(commands []string) []detector.CommandRule {
+	var rules []detector.CommandRule
+	for _, cmd := range commands {
+		if rule, ok := detector.ParseCommandSpec(cmd); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `rate-limit: Throttle risky tool calls for Claude Code hooks
+
+Counts risky-but-allowed Bash commands per session and asks for confirmation
+(or denies) once a limit is exceeded, to stop runaway automation loops.
+
+USAGE:
+    rate-limit -cmd COMMAND_SPEC [-cmd COMMAND_SPEC ...] [OPTIONS]
+
+REQUIRED:
+    -cmd string
+            Risky command and optional patterns to count (can be specified multiple times)
+            Uses the same format as bash-block: "command [pattern1] [pattern2] ..."
+
+LIMITS (at least one should be set):
+    -max-per-session int
+            Risky operations allowed per session (default: 0, unlimited)
+
+    -max-per-minute int
+            Risky operations allowed in any one-minute window (default: 0, unlimited)
+
+OPTIONAL:
+    -action string
+            Decision once a limit is exceeded: ask (prompt the user) or deny
+            (default: ask)
+
+    -state-dir string
+            Directory for per-session counters (default: %s)
+
+    -fail-mode string
+            Behavior when input cannot be parsed or state cannot be updated:
+            closed (block) or open (allow) (default: open)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every throttled call to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_RATE_LIMIT_<FLAG> to target only this hook.
+
+EXAMPLES:
+    # Ask before the 21st rm in a session
+    rate-limit -cmd rm -max-per-session 20
+
+    # Deny more than 5 kubectl applies or deletes per minute
+    rate-limit -cmd "kubectl apply delete" -max-per-minute 5 -action deny
+
+`, ratelimit.DefaultDir())
+}
@@ -0,0 +1,27 @@
+package ratelimiter
+
+import "testing"
+
+func TestIsRisky(t *testing.T) {
+	rules := parseCommandRules([]string{"rm", "kubectl apply"})
+
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"rm -rf build", true},
+		{"kubectl apply -f deploy.yaml", true},
+		{"ls && rm foo", true},
+		{"kubectl get pods", false},
+		{"go test ./...", false},
+		{"$CMD foo", true}, // Dynamic commands cannot be verified
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := isRisky(rules, tt.command); got != tt.want {
+				t.Errorf("isRisky(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// Package readonlyguard - read-only command allowlists
+package readonlyguard
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// presets are the built-in allowlists. Each entry is a command, optionally
+// followed by the subcommand it is limited to.
+var presets = map[string][]string{
+	"files": {
+		"ls", "cat", "head", "tail", "less", "more", "wc", "stat", "file", "du",
+		"df", "tree", "find", "pwd", "echo", "printf", "which", "type", "realpath",
+		"readlink", "basename", "dirname", "diff", "cmp", "sort", "uniq", "cut",
+		"jq", "yq", "sha256sum", "md5sum", "date", "whoami", "true", "false",
+		"test", "[",
+	},
+	"search": {"grep", "egrep", "fgrep", "rg", "ag", "ack", "fd"},
+	"git": {
+		"git status", "git log", "git diff", "git show", "git blame",
+		"git rev-parse", "git ls-files", "git ls-tree", "git grep", "git describe",
+		"git shortlog", "git cat-file",
+	},
+	"go": {"go test", "go vet", "go list", "go env", "go version", "go doc"},
+}
+
+// presetNames returns the built-in preset names in a stable order.
+func presetNames() []string {
+	return slices.Sorted(maps.Keys(presets))
+}
+
+// writeFlags make an otherwise read-only command write or run other
+// programs, e.g. find -delete or rg --pre. Entries are keyed by command, or
+// by command and subcommand where the flag means something else elsewhere,
+// as git log -O does.
+var writeFlags = map[string][]string{
+	"find":     {"-delete", "-exec", "-execdir", "-ok", "-okdir", "-fprint", "-fprint0", "-fprintf", "-fls"},
+	"fd":       {"-x", "-X", "--exec", "--exec-batch"},
+	"rg":       {"--pre"},
+	"sort":     {"-o", "--output"},
+	"tree":     {"-o"},
+	"git":      {"--output"},
+	"git grep": {"-O", "--open-files-in-pager"},
+	"go":       {"-w", "-u"},
+	"go test":  {"-exec", "-toolexec", "-o"},
+	"go vet":   {"-vettool", "-toolexec"},
+	"yq":       {"-i", "--inplace"},
+}
+
+// readOnlyRedirectTargets may be written to without changing anything.
+var readOnlyRedirectTargets = []string{"/dev/null", "/dev/stdout", "/dev/stderr"}
+
+// writeRedirectOps are the redirection operators that write to their target.
+var writeRedirectOps = []syntax.RedirOperator{
+	syntax.RdrOut, syntax.AppOut, syntax.RdrAll, syntax.AppAll, syntax.ClbOut, syntax.RdrInOut,
+}
+
+// Allowlist decides whether shell commands only read.
+type Allowlist struct {
+	entries [][]string // Command words, e.g. {"git", "status"}
+}
+
+// NewAllowlist builds an allowlist from the named presets and extra entries.
+func NewAllowlist(presetNames, extra []string) (*Allowlist, error) {
+	list := &Allowlist{}
+	for _, name := range presetNames {
+		entries, ok := presets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset '%s'", name)
+		}
+		for _, entry := range entries {
+			list.entries = append(list.entries, strings.Fields(entry))
+		}
+	}
+	for _, entry := range extra {
+		if words := strings.Fields(entry); len(words) > 0 {
+			list.entries = append(list.entries, words)
+		}
+	}
+	return list, nil
+}
+
+// CheckCommand returns an issue for every part of the shell expression that is
+// not known to be read-only: commands missing from the allowlist, commands
+// whose name is not static, write flags, and output redirections. Nested
+// commands, such as those in pipelines and substitutions, are checked too.
+func (l *Allowlist) CheckCommand(shellExpr string) ([]string, error) {
+	ast, err := shellparse.Parse(shellExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []string
+	syntax.Walk(ast, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Redirect:
+			if issue := checkRedirect(n); issue != "" {
+				issues = append(issues, issue)
+			}
+		case *syntax.CallExpr:
+			if issue := l.checkCall(n); issue != "" {
+				issues = append(issues, issue)
+			}
+		case *syntax.FuncDecl:
+			issues = append(issues, "function definitions are not allowed in read-only mode")
+		}
+		return true
+	})
+	return issues, nil
+}
+
+// checkRedirect reports a redirection that writes to a file.
+func checkRedirect(redirect *syntax.Redirect) string {
+	if !slices.Contains(writeRedirectOps, redirect.Op) {
+		return ""
+	}
+	target, isStatic := shellparse.StaticWord(redirect.Word)
+	if isStatic && slices.Contains(readOnlyRedirectTargets, target) {
+		return ""
+	}
+	return "output redirection " + redirect.Op.String() + " " + shellparse.Print(redirect.Word) + " writes a file"
+}
+
+// checkCall reports a command call that is not allowlisted.
+func (l *Allowlist) checkCall(call *syntax.CallExpr) string {
+	if len(call.Args) == 0 {
+		return "" // Variable assignments only
+	}
+	args := make([]string, 0, len(call.Args))
+	for i, word := range call.Args {
+		value, isStatic := shellparse.StaticWord(word)
+		if !isStatic && i == 0 {
+			return "command name " + shellparse.Print(word) + " is dynamic - unable to verify it is read-only"
+		}
+		args = append(args, value)
+	}
+	// ./ls or /tmp/evil/ls is not the ls the allowlist trusts
+	if strings.ContainsAny(args[0], `/\`) {
+		return "command " + args[0] + " is run by path - only commands found in PATH are allowlisted"
+	}
+	cmd := strings.TrimSuffix(args[0], ".exe")
+	printed := shellparse.Print(call)
+
+	if !l.allows(cmd, args[1:]) {
+		return printed + " is not in the read-only allowlist"
+	}
+	// A substitution can expand to a write flag, as in find . $(printf -- -delete)
+	for _, word := range call.Args[1:] {
+		if _, isStatic := shellparse.StaticWord(word); !isStatic {
+			return "argument " + shellparse.Print(word) + " of " + cmd + " is dynamic - unable to verify it is read-only"
+		}
+	}
+	flags := slices.Concat(writeFlags[cmd], writeFlags[cmd+" "+subcommand(args[1:])])
+	for _, arg := range args[1:] {
+		if flag, ok := matchFlag(cmd, flags, arg); ok {
+			return printed + " can write with " + flag
+		}
+	}
+	return ""
+}
+
+// matchFlag returns the flag in flags that arg sets: the flag itself, the flag
+// with a value (--output=x), or a short flag with its value attached (-ox).
+// Go's flag package has no attached values, so go -o matches -o and -o=x but
+// not -outputdir.
+func matchFlag(cmd string, flags []string, arg string) (string, bool) {
+	for _, flag := range flags {
+		switch {
+		case arg == flag, strings.HasPrefix(arg, flag+"="):
+			return flag, true
+		case len(flag) == 2 && cmd != "go" && strings.HasPrefix(arg, flag):
+			return flag, true
+		}
+	}
+	return "", false
+}
+
+// allows reports whether an entry permits cmd with args. Entries with a
+// subcommand match the first argument that is not a flag.
+func (l *Allowlist) allows(cmd string, args []string) bool {
+	subcommand := subcommand(args)
+	return slices.ContainsFunc(l.entries, func(entry []string) bool {
+		if entry[0] != cmd {
+			return false
+		}
+		return len(entry) == 1 || entry[1] == subcommand
+	})
+}
+
+// subcommand returns the first argument that is not a flag, or "".
+func subcommand(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
@@ -0,0 +1,219 @@
+// Package readonlyguard implements the readonly-guard hook, which limits a session to read-only commands
+package readonlyguard
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// listFlag allows multiple -preset and -allow flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// editTools change files directly and are always blocked.
+var editTools = []string{"Edit", "MultiEdit", "Write", "NotebookEdit"}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var presetFlags, allowFlags listFlag
+	flag.Var(&presetFlags, "preset", "Read-only allowlist preset (can be specified multiple times; default: all)")
+	flag.Var(&allowFlags, "allow", "Additional read-only command, optionally with its subcommand (can be specified multiple times)")
+	enabled := flag.Bool("enabled", true, "Enforce read-only mode; false allows every tool call")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailClosed}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input or the command cannot be parsed: closed (block) or open (allow)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every blocked call to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "readonly-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "readonly-guard", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:   "readonly-guard",
+		Events: []string{hook.EventPreToolUse},
+		Tools:  []string{"Bash", "Edit", "MultiEdit", "Write", "NotebookEdit"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if !*enabled {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	if len(presetFlags) == 0 {
+		presetFlags = presetNames()
+	}
+	allowlist, err := NewAllowlist(presetFlags, allowFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v (want one of %s)\n", err, strings.Join(presetNames(), ", "))
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "readonly-guard", "Failed to parse hook input", err)
+		return
+	}
+
+	var issues []string
+	switch {
+	case input.ToolName == "Bash":
+		issues, err = allowlist.CheckCommand(input.ToolInput.Command)
+		if err != nil {
+			hookutil.FailInternal(settings, auditLog, "readonly-guard", "Failed to parse command", err)
+			return
+		}
+	case slices.Contains(editTools, input.ToolName):
+		issues = []string{input.ToolName + " is not allowed in read-only mode"}
+	}
+	logger.Debug("checked tool call", "tool", input.ToolName, "issues", issues)
+
+	if len(issues) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "readonly-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  settings.BlockDecision(),
+		Issues:    issues,
+		Command:   input.ToolInput.Command,
+		FilePath:  input.ToolInput.FilePath,
+	})
+	settings.Output().BlockPreToolUse("Read-only mode! Only read-only commands are allowed; ask the user to make changes.", issues)
+}
+
+// showUsage displays usage information
+func showUsage() {
+	var presetList strings.Builder
+	for _, name := range presetNames() {
+		fmt.Fprintf(&presetList, "    %-8s %s\n", name, strings.Join(presets[name], ", "))
+	}
+
+	fmt.Fprintf(os.Stderr, `readonly-guard: Read-only mode for Claude Code hooks
+
+A "look but don't touch" mode for review sessions: blocks every Bash command
+that is not on a read-only allowlist, and every Edit/MultiEdit/Write/
+NotebookEdit call. Every command in a pipeline, list, or substitution must be
+allowlisted by its bare name, and output redirections other than to
+/dev/null, write flags such as find -delete or rg --pre, and arguments not
+known before the command runs are blocked.
+
+USAGE:
+    readonly-guard [-preset NAME ...] [-allow COMMAND ...] [OPTIONS]
+
+PRESETS:
+%s
+OPTIONAL:
+    -preset string
+            Allowlist preset to enable (can be specified multiple times).
+            Default: all presets
+
+    -allow string
+            Additional read-only command, optionally followed by the
+            subcommand it is limited to, e.g. "make lint" (can be specified
+            multiple times)
+
+    -enabled
+            Enforce read-only mode (default: true). Set
+            CLAUDE_HOOKS_READONLY_GUARD_ENABLED=false to turn it off without
+            changing settings.json
+
+    -fail-mode string
+            Behavior when input or the command cannot be parsed: closed
+            (block) or open (allow) (default: closed)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every blocked call to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_READONLY_GUARD_<FLAG> to target only this hook.
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Bash|Edit|MultiEdit|Write|NotebookEdit",
+        "hooks": [{"type": "command", "command": "/path/to/readonly-guard"}]
+      }
+    ]
+  }
+}
+
+`, presetList.String())
+}
@@ -0,0 +1,85 @@
+package readonlyguard
+
+import "testing"
+
+func TestAllowlist_CheckCommand(t *testing.T) {
+	allowlist, err := NewAllowlist(presetNames(), []string{"make lint"})
+	if err != nil {
+		t.Fatalf("NewAllowlist() error: %v", err)
+	}
+
+	tests := []struct {
+		command    string
+		wantIssues int
+	}{
+		{"ls -la", 0},
+		{"cat go.mod | grep module", 0},
+		{"git status && git log --oneline -5", 0},
+		{"git --no-pager diff HEAD~1", 0},
+		{"go test ./...", 0},
+		{"grep -r TODO . 2>/dev/null", 0},
+		{"find . -name '*.go' | wc -l", 0},
+		{"FOO=1", 0},
+		{"make lint", 0},
+		{"git diff -Ogit.order", 0},
+		{"go test -work ./...", 0},
+		{"rm -rf build", 1},
+		{"git push", 1},
+		{"git commit -m wip", 1},
+		{"make build", 1},
+		{"ls && rm file", 1},
+		{"echo hi > out.txt", 1},
+		{"cat a >> b", 1},
+		{"echo $(rm -rf /tmp/x)", 2},
+		{"find . -name '*.tmp' -delete", 1},
+		{"find . -exec rm {} ;", 1},
+		{"sort -o sorted.txt input.txt", 1},
+		{"go env -w GOPROXY=direct", 1},
+		{"$CMD file", 1},
+		{"sh -c 'ls'", 1},
+		{"f() { ls; }", 1},
+		{"echo $(git rev-parse HEAD)", 1},
+		{"find . $(printf -- -delete)", 1},
+		{`cat "$FILE"`, 1},
+		{"fd -e go -x rm", 1},
+		{"fd --exec-batch=rm", 1},
+		{"rg --pre ./evil.sh TODO", 1},
+		{"go test -exec ./evil ./...", 1},
+		{"go test -toolexec=./evil ./...", 1},
+		{"go test -o /tmp/x.test ./pkg", 1},
+		{"go vet -vettool=./evil ./...", 1},
+		{"git grep -Orm TODO", 1},
+		{"git grep --open-files-in-pager=vim TODO", 1},
+		{"sort -o/tmp/x input.txt", 1},
+		{"./ls", 1},
+		{"/tmp/evil/ls -la", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			issues, err := allowlist.CheckCommand(tt.command)
+			if err != nil {
+				t.Fatalf("CheckCommand() error: %v", err)
+			}
+			if len(issues) != tt.wantIssues {
+				t.Errorf("CheckCommand(%q) = %q, want %d issues", tt.command, issues, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestAllowlist_Presets(t *testing.T) {
+	allowlist, err := NewAllowlist([]string{"files"}, nil)
+	if err != nil {
+		t.Fatalf("NewAllowlist() error: %v", err)
+	}
+	if issues, _ := allowlist.CheckCommand("git status"); len(issues) != 1 {
+		t.Errorf("git status without the git preset = %q, want blocked", issues)
+	}
+	if issues, _ := allowlist.CheckCommand("ls"); len(issues) != 0 {
+		t.Errorf("ls with the files preset = %q, want allowed", issues)
+	}
+
+	if _, err := NewAllowlist([]string{"nope"}, nil); err == nil {
+		t.Error("NewAllowlist() with an unknown preset should fail")
+	}
+}
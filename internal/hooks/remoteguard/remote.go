@@ -0,0 +1,314 @@
+// Package remoteguard - git remote parsing and resolution
+package remoteguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// gitTimeout bounds each git command the hook runs.
+const gitTimeout = 5 * time.Second
+
+// gitGlobalFlags are git's global options that take a value.
+var gitGlobalFlags = []string{"-C", "-c", "--git-dir", "--work-tree", "--namespace", "--config-env", "--super-prefix"}
+
+// gitValueFlags are the options of each checked subcommand that take a value,
+// so a flag's value is not mistaken for the repository.
+var gitValueFlags = map[string][]string{
+	"push":      {"--repo", "-o", "--push-option", "--receive-pack", "--exec"},
+	"fetch":     {"--depth", "--deepen", "--shallow-since", "--shallow-exclude", "-j", "--jobs", "--upload-pack", "--negotiation-tip", "-o", "--server-option", "--refmap", "--recurse-submodules-default", "--filter"},
+	"pull":      {"--depth", "--deepen", "--shallow-since", "--shallow-exclude", "-j", "--jobs", "--upload-pack", "--negotiation-tip", "-o", "--server-option", "-s", "--strategy", "-X", "--strategy-option", "--cleanup"},
+	"clone":     {"-o", "--origin", "-b", "--branch", "-u", "--upload-pack", "--reference", "--reference-if-able", "--separate-git-dir", "--depth", "--shallow-since", "--shallow-exclude", "--config", "--server-option", "-j", "--jobs", "--template", "--filter", "--bundle-uri", "--ref-format", "--revision"},
+	"ls-remote": {"--upload-pack", "-o", "--server-option", "--sort"},
+	"remote":    {"-t", "-m"},
+}
+
+// Target is a git command that talks to, or records, a remote repository.
+type Target struct {
+	Operation string // push, fetch, pull, clone, ls-remote, remote add, or remote set-url
+	Command   string // The call as shell source, for messages
+	Dir       string // Directory git runs in, after -C options
+
+	// Repositories are the remote names, URLs, or paths as given. An empty
+	// entry is one only known at run time, e.g. $REMOTE. With none and All
+	// unset, the command uses the current branch's default remote.
+	Repositories []string
+	All          bool // fetch --all: every configured remote
+}
+
+// Targets returns the git commands in a shell expression that push to,
+// fetch from, clone, or add a remote, run from dir. Aliases are not expanded.
+func Targets(command, dir string) ([]Target, error) {
+	node, err := shellparse.Parse(command)
+	if err != nil {
+		return nil, err
+	}
+	var targets []Target
+	shellparse.VisitCommands(node, func(cmd shellparse.Command) bool {
+		if path.Base(cmd.Name) != "git" {
+			return true
+		}
+		if target, ok := parseTarget(cmd, dir); ok {
+			targets = append(targets, target)
+		}
+		return true
+	})
+	return targets, nil
+}
+
+// parseTarget returns the remote a git call targets, if it targets one.
+func parseTarget(cmd shellparse.Command, dir string) (Target, bool) {
+	global := detector.ArgSpec{ValueFlags: gitGlobalFlags}.Parse(cmd.Args)
+	subcommand := global.Subcommand(0)
+	flags, ok := gitValueFlags[subcommand]
+	if !ok {
+		return Target{}, false
+	}
+	inv := detector.ArgSpec{ValueFlags: append(slices.Clone(gitGlobalFlags), flags...)}.Parse(cmd.Args)
+	for _, chdir := range inv.Flags["-C"] {
+		dir = pathmatch.Join(dir, chdir)
+	}
+	target := Target{Operation: subcommand, Command: cmd.Source, Dir: dir}
+
+	switch subcommand {
+	case "push", "pull", "ls-remote":
+		if repository := inv.Subcommand(1); len(inv.Positionals) > 1 {
+			target.Repositories = []string{repository}
+		} else if repository, ok := inv.Flag("--repo"); ok && subcommand == "push" {
+			target.Repositories = []string{repository}
+		}
+	case "fetch":
+		switch {
+		case inv.HasFlag("--all"):
+			target.All = true
+		case inv.HasFlag("--multiple"):
+			target.Repositories = inv.Positionals[1:]
+		case len(inv.Positionals) > 1:
+			target.Repositories = []string{inv.Subcommand(1)}
+		}
+	case "clone":
+		if len(inv.Positionals) < 2 {
+			return Target{}, false
+		}
+		target.Repositories = []string{inv.Subcommand(1)}
+	case "remote":
+		switch inv.Subcommand(1) {
+		case "add":
+			if len(inv.Positionals) < 4 {
+				return Target{}, false
+			}
+			target.Operation = "remote add"
+			target.Repositories = []string{inv.Subcommand(3)}
+		case "set-url":
+			if inv.HasFlag("--delete") || len(inv.Positionals) < 4 {
+				return Target{}, false
+			}
+			target.Operation = "remote set-url"
+			target.Repositories = []string{inv.Subcommand(3)}
+		default:
+			return Target{}, false
+		}
+	}
+	return target, true
+}
+
+// Push reports whether the target sends to the remote, so its push URLs
+// apply rather than its fetch URLs.
+func (t Target) Push() bool {
+	return t.Operation == "push"
+}
+
+// Resolve returns the URLs the target's repositories resolve to, keyed by the
+// repository as given (or the default remote's name). Named remotes resolve
+// to their configured URLs, with git remote get-url; other repositories to
+// themselves after url.<base>.insteadOf rewriting, as git ls-remote --get-url
+// does.
+func (t Target) Resolve() (map[string][]string, error) {
+	remotes, err := Remotes(t.Dir)
+	if err != nil {
+		return nil, err
+	}
+	repositories := t.Repositories
+	switch {
+	case t.All:
+		repositories = remotes
+	case repositories == nil:
+		remote, err := DefaultRemote(t.Dir, t.Push())
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(remotes, remote) && !strings.ContainsAny(remote, "/:") {
+			return nil, nil // No such remote, or no repository: git fails on its own
+		}
+		repositories = []string{remote}
+	}
+
+	resolved := make(map[string][]string, len(repositories))
+	for _, repository := range repositories {
+		if repository == "" || repository == "." {
+			continue // Only known at run time, which the caller reports, or the repository itself
+		}
+		if slices.Contains(remotes, repository) && t.Operation != "clone" && !strings.HasPrefix(t.Operation, "remote ") {
+			urls, err := RemoteURLs(t.Dir, repository, t.Push())
+			if err != nil {
+				return nil, err
+			}
+			resolved[repository] = urls
+			continue
+		}
+		url, err := git(t.Dir, "ls-remote", "--get-url", repository)
+		if err != nil {
+			return nil, err
+		}
+		resolved[repository] = []string{strings.TrimSpace(url)}
+	}
+	return resolved, nil
+}
+
+// Remotes returns the names of the remotes configured in the repository at
+// dir, or none if dir is not in a repository.
+func Remotes(dir string) ([]string, error) {
+	output, err := git(dir, "remote")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, nil // Not a repository
+		}
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
+
+// RemoteURLs returns the URLs of a named remote: its push URLs when push is
+// set, which default to its fetch URLs.
+func RemoteURLs(dir, remote string, push bool) ([]string, error) {
+	args := []string{"remote", "get-url", "--all"}
+	if push {
+		args = append(args, "--push")
+	}
+	output, err := git(dir, append(args, remote)...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
+
+// DefaultRemote returns the remote git push or fetch uses without one: the
+// current branch's pushRemote or remote.pushDefault for a push, then the
+// branch's remote, then origin.
+func DefaultRemote(dir string, push bool) (string, error) {
+	var keys []string
+	if branch, err := git(dir, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		branch = strings.TrimSpace(branch)
+		if push {
+			keys = append(keys, "branch."+branch+".pushRemote", "remote.pushDefault")
+		}
+		keys = append(keys, "branch."+branch+".remote")
+	} else if push {
+		keys = append(keys, "remote.pushDefault")
+	}
+	for _, key := range keys {
+		value, err := git(dir, "config", "--get", key)
+		if err == nil && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value), nil
+		}
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			return "", err
+		}
+	}
+	return "origin", nil
+}
+
+// NormalizeURL reduces a remote URL to the form allow patterns match:
+// host/path for network URLs, in https://host/path, ssh://user@host:port/path,
+// or user@host:path syntax, without a .git suffix; and the cleaned absolute
+// path for local repositories, resolved against dir.
+func NormalizeURL(url, dir string) string {
+	url = strings.TrimSpace(url)
+	if rest, ok := strings.CutPrefix(url, "file://"); ok {
+		return trimRepo(pathmatch.Join(dir, rest))
+	}
+	if scheme, rest, ok := strings.Cut(url, "://"); ok && !strings.Contains(scheme, "/") {
+		hostPart, repoPath, _ := strings.Cut(rest, "/")
+		return joinHost(hostPart, repoPath)
+	}
+	if strings.Contains(url, "::") {
+		return url // A transport helper address, e.g. ext::ssh ...
+	}
+	// scp-like syntax: a colon before the first slash, as in git@github.com:org/repo
+	if colon := strings.IndexByte(url, ':'); colon > 0 && !strings.Contains(url[:colon], "/") && !pathmatch.IsAbs(url) {
+		return joinHost(url[:colon], strings.TrimLeft(url[colon+1:], "/~"))
+	}
+	return trimRepo(pathmatch.Join(dir, url))
+}
+
+// joinHost joins a URL's host, dropping any user and port, to its path.
+func joinHost(hostPart, repoPath string) string {
+	if at := strings.LastIndexByte(hostPart, '@'); at >= 0 {
+		hostPart = hostPart[at+1:]
+	}
+	if colon := strings.LastIndexByte(hostPart, ':'); colon >= 0 && !strings.HasSuffix(hostPart, "]") {
+		hostPart = hostPart[:colon]
+	}
+	return trimRepo(strings.ToLower(hostPart) + "/" + repoPath)
+}
+
+// trimRepo drops the trailing slash and .git suffix of a repository path.
+func trimRepo(repo string) string {
+	repo = strings.TrimRight(repo, "/")
+	return strings.TrimRight(strings.TrimSuffix(repo, ".git"), "/")
+}
+
+// normalizePattern brings an allow pattern into NormalizeURL form. Patterns
+// written as URLs are normalized; others, such as github.com/myorg/* or
+// /srv/git/**, are taken as written.
+func normalizePattern(pattern string) string {
+	if strings.Contains(pattern, "://") || (strings.Contains(pattern, "@") && strings.Contains(pattern, ":")) {
+		return NormalizeURL(pattern, "/")
+	}
+	return trimRepo(pattern)
+}
+
+// Allowed reports whether a normalized URL matches one of the allow patterns,
+// which use pathmatch.Match syntax: * does not cross slashes, and a trailing
+// /** matches everything below.
+func Allowed(url string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if pathmatch.Match(normalizePattern(pattern), url) {
+			return true
+		}
+	}
+	return false
+}
+
+// git runs a git command in dir and returns its stdout. Errors from git itself
+// wrap an *exec.ExitError.
+func git(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("git %s: %w", args[0], ctx.Err())
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s: %w", args[0], strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,285 @@
+// Package remoteguard implements the remote-guard hook, which confines git pushes, fetches, clones, and remote changes to allowed remotes
+package remoteguard
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// defaultAllowedRemotes are the remotes whose URLs are allowed when neither
+// -allow nor -allow-remote is given.
+var defaultAllowedRemotes = []string{"origin"}
+
+// listFlag allows multiple -allow and -allow-remote flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var allow, allowRemotes listFlag
+	flag.Var(&allow, "allow", "Allowed remote URL glob, e.g. github.com/myorg/* (can be specified multiple times)")
+	flag.Var(&allowRemotes, "allow-remote", "Allow the URLs this remote of the current repository has, e.g. origin (can be specified multiple times; default: origin when no -allow is given)")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailClosed}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input or the command cannot be parsed, or git fails: closed (block) or open (allow)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every blocked command to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "remote-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "remote-guard", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:   "remote-guard",
+		Events: []string{hook.EventPreToolUse},
+		Tools:  []string{"Bash"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if len(allow) == 0 && len(allowRemotes) == 0 {
+		allowRemotes = defaultAllowedRemotes
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "remote-guard", "Failed to parse hook input", err)
+		return
+	}
+	if input.ToolName != "Bash" || input.ToolInput.Command == "" {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	targets, err := Targets(input.ToolInput.Command, input.Cwd)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "remote-guard", "Failed to parse command", err)
+		return
+	}
+	if len(targets) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	patterns, err := allowedPatterns(input.Cwd, allow, allowRemotes)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "remote-guard", "Failed to resolve the allowed remotes", err)
+		return
+	}
+
+	var issues []string
+	for _, target := range targets {
+		targetIssues, err := check(target, patterns)
+		if err != nil {
+			hookutil.FailInternal(settings, auditLog, "remote-guard", "Failed to resolve remotes", err)
+			return
+		}
+		issues = append(issues, targetIssues...)
+	}
+	logger.Debug("checked remotes", "targets", len(targets), "allowed", patterns, "issues", issues)
+	if len(issues) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "remote-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  settings.BlockDecision(),
+		Issues:    issues,
+		Command:   input.ToolInput.Command,
+	})
+	settings.Output().BlockPreToolUse(fmt.Sprintf("Remote not allowed! Only these remotes may be pushed to, fetched from, cloned, or added: %s. Ask the user to run the command if it is needed.", strings.Join(patterns, ", ")), issues)
+}
+
+// allowedPatterns returns the allow globs plus the current URLs of the
+// allowed remotes of the repository at dir, in NormalizeURL form.
+func allowedPatterns(dir string, allow, allowRemotes []string) ([]string, error) {
+	patterns := slices.Clone(allow)
+	remotes, err := Remotes(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, remote := range allowRemotes {
+		if !slices.Contains(remotes, remote) {
+			continue
+		}
+		for _, push := range []bool{false, true} {
+			urls, err := RemoteURLs(dir, remote, push)
+			if err != nil {
+				return nil, err
+			}
+			for _, url := range urls {
+				if normalized := NormalizeURL(url, dir); !slices.Contains(patterns, normalized) {
+					patterns = append(patterns, normalized)
+				}
+			}
+		}
+	}
+	return patterns, nil
+}
+
+// check returns why target's remotes are not allowed, if any are not.
+func check(target Target, patterns []string) ([]string, error) {
+	var issues []string
+	if slices.Contains(target.Repositories, "") {
+		issues = append(issues, fmt.Sprintf("%s: git %s to a remote only known when the command runs; name the remote or URL literally", target.Command, target.Operation))
+	}
+	resolved, err := target.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	repositories := make([]string, 0, len(resolved))
+	for repository := range resolved {
+		repositories = append(repositories, repository)
+	}
+	slices.Sort(repositories)
+	for _, repository := range repositories {
+		for _, url := range resolved[repository] {
+			normalized := NormalizeURL(url, target.Dir)
+			if Allowed(normalized, patterns) {
+				continue
+			}
+			described := repository
+			if normalized != repository {
+				described += " (" + normalized + ")"
+			}
+			issues = append(issues, fmt.Sprintf("%s: git %s to %s, which is not an allowed remote", target.Command, target.Operation, described))
+		}
+	}
+	return issues, nil
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `remote-guard: Git remote allowlist for Claude Code hooks
+
+Blocks git commands that talk to, or record, a remote that is not allowed:
+git push, fetch, pull, ls-remote, and clone, and git remote add and set-url.
+Named remotes are resolved to their URLs with git remote get-url (push URLs
+for a push), a command without a remote to the current branch's default
+remote, and URLs after url.<base>.insteadOf rewriting. A remote only known
+when the command runs, such as git push "$REMOTE", is blocked.
+
+URLs are compared as host/path, without a scheme, user, port, or .git
+suffix, so https://github.com/myorg/app.git and git@github.com:myorg/app
+are both github.com/myorg/app. Local repositories are compared as absolute
+paths.
+
+USAGE:
+    remote-guard [-allow GLOB ...] [-allow-remote NAME ...] [OPTIONS]
+
+OPTIONAL:
+    -allow string
+            Allowed remote URL glob (can be specified multiple times), e.g.
+            github.com/myorg/* or /srv/git/**. * does not cross slashes, and
+            a trailing /** matches everything below. URLs such as
+            git@github.com:myorg/app.git are normalized first.
+
+    -allow-remote string
+            Allow the URLs this remote of the current repository has when the
+            command is checked (can be specified multiple times). Defaults to
+            origin when neither -allow nor -allow-remote is given, so only the
+            repository's own origin is allowed.
+
+    -fail-mode string
+            Behavior when input or the command cannot be parsed, or git
+            fails: closed (block) or open (allow) (default: closed)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every blocked command to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_REMOTE_GUARD_<FLAG> to target only this hook. Separate
+    multiple -allow or -allow-remote values with semicolons.
+
+EXAMPLES:
+    # The repository's origin and anything in the myorg GitHub organization
+    remote-guard -allow-remote origin -allow "github.com/myorg/*"
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Bash",
+        "hooks": [{"type": "command", "command": "/path/to/remote-guard"}]
+      }
+    ]
+  }
+}
+
+`)
+}
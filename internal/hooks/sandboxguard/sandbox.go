@@ -0,0 +1,208 @@
+// Package sandboxguard - project root confinement of paths and commands
+package sandboxguard
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// devicePaths may always be used: they reach no files.
+var devicePaths = []string{
+	"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom", "/dev/tty",
+	"/dev/stdin", "/dev/stdout", "/dev/stderr", "/dev/fd/**",
+}
+
+// maxBraceWords bounds the words brace expansion may produce before a command
+// is considered too large to check.
+const maxBraceWords = 256
+
+// Sandbox decides whether tool calls stay within the project root and the
+// additional allowed roots.
+type Sandbox struct {
+	cwd   string
+	roots []string       // Resolved allowed roots, the project root first
+	env   *expand.Config // Expands $HOME and other variables in command words
+}
+
+// NewSandbox returns a Sandbox confining tool calls to projectRoot and the
+// allowed roots. Allowed roots may start with ~/ or use environment
+// variables, as in $GOPATH; relative ones are resolved against cwd.
+func NewSandbox(cwd, projectRoot string, allowed []string) *Sandbox {
+	roots := []string{utils.ResolvePath(cwd, projectRoot)}
+	for _, root := range allowed {
+		if root = os.ExpandEnv(root); root != "" {
+			roots = append(roots, utils.ResolvePath(cwd, root))
+		}
+	}
+	return &Sandbox{
+		cwd:   cwd,
+		roots: roots,
+		env:   &expand.Config{Env: expand.ListEnviron(os.Environ()...)},
+	}
+}
+
+// Contains reports whether path, absolute or relative to the working
+// directory, resolves within an allowed root. Symlinks and ../ traversal are
+// followed, so a link out of the project is not inside it.
+func (s *Sandbox) Contains(path string) bool {
+	if isDevice(path) {
+		return true
+	}
+	resolved := utils.ResolvePath(s.cwd, path)
+	return slices.ContainsFunc(s.roots, func(root string) bool {
+		return utils.WithinRoot(root, s.cwd, resolved)
+	})
+}
+
+// CheckPath returns an issue if a file tool's path is outside the sandbox.
+func (s *Sandbox) CheckPath(tool, path string) []string {
+	if path == "" || s.Contains(path) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s of %s, which is outside the project root", tool, path)}
+}
+
+// CheckCommand returns an issue for every path the shell expression uses
+// outside the sandbox: redirection targets and arguments that look like
+// paths. The check is coarse: it does not know which arguments a command
+// treats as files, and the programs it runs may use any path.
+func (s *Sandbox) CheckCommand(shellExpr string) ([]string, error) {
+	ast, err := shellparse.Parse(shellExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []string
+	if !s.Contains(s.cwd) {
+		issues = append(issues, fmt.Sprintf("working directory %s is outside the project root", s.cwd))
+	}
+	syntax.Walk(ast, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Redirect:
+			switch n.Op {
+			case syntax.Hdoc, syntax.DashHdoc, syntax.WordHdoc, syntax.DplIn, syntax.DplOut:
+				return true
+			}
+			words, ok := shellparse.ExpandBraceWords([]*syntax.Word{n.Word}, maxBraceWords)
+			if !ok {
+				issues = append(issues, "redirection uses a brace expansion that cannot be checked")
+				return true
+			}
+			for _, word := range words {
+				if target := s.wordValue(word); target != "" && !s.Contains(target) {
+					issues = append(issues, fmt.Sprintf("redirection %s %s is outside the project root", n.Op, target))
+				}
+			}
+		case *syntax.CallExpr:
+			issues = append(issues, s.checkCall(n)...)
+		}
+		return true
+	})
+	return issues, nil
+}
+
+// checkCall checks the arguments of a single command call, after brace
+// expansion, so cat {/etc/passwd,x} reads /etc/passwd. The command itself may
+// be anywhere, so /usr/bin/git status is allowed.
+func (s *Sandbox) checkCall(call *syntax.CallExpr) []string {
+	words, ok := shellparse.ExpandBraceWords(call.Args, maxBraceWords)
+	if !ok {
+		return []string{"command uses a brace expansion that cannot be checked"}
+	}
+	if len(words) < 2 {
+		return nil
+	}
+	cmd := pathmatch.Base(s.wordValue(words[0]))
+	var issues []string
+	for _, word := range words[1:] {
+		arg := s.wordValue(word)
+		if value, ok := flagValue(arg); ok && !s.Contains(value) {
+			issues = append(issues, fmt.Sprintf("%s uses %s, which is outside the project root", cmd, value))
+			continue
+		}
+		for _, candidate := range pathCandidates(arg) {
+			if looksLikePath(candidate) && !s.Contains(candidate) {
+				issues = append(issues, fmt.Sprintf("%s uses %s, which is outside the project root", cmd, candidate))
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// flagValue returns the value attached to a flag, as in --git-dir=/other/.git
+// or -o/etc/x. Flag values are checked as paths even when their top-level
+// directory does not exist, since the flag says what they are; values that
+// are URLs are not paths.
+func flagValue(arg string) (string, bool) {
+	var value string
+	switch {
+	case strings.HasPrefix(arg, "--"):
+		_, value, _ = strings.Cut(arg, "=")
+	case strings.HasPrefix(arg, "-") && len(arg) > 2:
+		value = strings.TrimPrefix(arg[2:], "=")
+	}
+	return value, value != "" && !strings.Contains(value, "://")
+}
+
+// wordValue expands a word using the hook's environment, which Claude Code
+// shares with the Bash tool, so "$HOME/.ssh" resolves as it would when run.
+// Words that cannot be expanded (command substitutions) keep their static parts.
+func (s *Sandbox) wordValue(word *syntax.Word) string {
+	if value, err := expand.Literal(s.env, word); err == nil {
+		return value
+	}
+	value, _ := shellparse.StaticWord(word)
+	return value
+}
+
+// pathCandidates returns the strings in an argument that may be paths: the
+// argument itself, the value of --flag=value, and each word of a script
+// passed to sh -c or similar.
+func pathCandidates(arg string) []string {
+	candidates := []string{arg}
+	if _, value, ok := strings.Cut(arg, "="); ok {
+		candidates = append(candidates, value)
+	}
+	if fields := strings.Fields(arg); len(fields) > 1 {
+		for _, field := range fields {
+			candidates = append(candidates, strings.Trim(field, `"';&|()<>`))
+		}
+	}
+	return candidates
+}
+
+// looksLikePath reports whether an argument should be checked as a path:
+// absolute paths whose top-level directory exists (so /api/v1 in a grep
+// pattern is not), paths in the home directory, and relative paths. Flags and
+// URLs are not paths.
+func looksLikePath(arg string) bool {
+	switch {
+	case arg == "" || strings.HasPrefix(arg, "-") || strings.Contains(arg, "://"):
+		return false
+	case arg == "~" || strings.HasPrefix(arg, "~/"):
+		return true
+	case filepath.IsAbs(arg):
+		top, _, _ := strings.Cut(strings.TrimPrefix(arg, string(filepath.Separator)), string(filepath.Separator))
+		_, err := os.Stat(string(filepath.Separator) + top)
+		return err == nil
+	}
+	return true
+}
+
+// isDevice reports whether path is a device file that reaches no files.
+func isDevice(path string) bool {
+	return slices.ContainsFunc(devicePaths, func(device string) bool {
+		return pathmatch.Match(device, path)
+	})
+}
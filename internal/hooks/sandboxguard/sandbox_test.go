@@ -0,0 +1,135 @@
+package sandboxguard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestSandbox(t *testing.T) (sandbox *Sandbox, project, outside string) {
+	t.Helper()
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", filepath.Join(base, "home"))
+	t.Setenv("GOPATH", filepath.Join(base, "go"))
+	project = filepath.Join(base, "project")
+	outside = filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(project, "src"), outside} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(project, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	return NewSandbox(project, project, []string{"$GOPATH", "~/.cache"}), project, outside
+}
+
+func TestSandbox_Contains(t *testing.T) {
+	sandbox, project, outside := newTestSandbox(t)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/main.go", true},
+		{filepath.Join(project, "new", "file.go"), true},
+		{".", true},
+		{"../outside/secret.txt", false},
+		{"src/../../outside", false},
+		{"escape/secret.txt", false},
+		{"escape/../secret.txt", false},
+		{"escape/../project/main.go", true},
+		{filepath.Join(outside, "secret.txt"), false},
+		{"~/.ssh/id_ed25519", false},
+		{"~/.cache/go-build/ab", true},
+		{filepath.Join(os.Getenv("GOPATH"), "pkg", "mod"), true},
+		{"/dev/null", true},
+		{"/dev/fd/3", true},
+		{`C:\Windows\System32\drivers\etc\hosts`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := sandbox.Contains(tt.path); got != tt.want {
+				t.Errorf("Contains(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSandbox_CheckCommand(t *testing.T) {
+	sandbox, _, outside := newTestSandbox(t)
+
+	tests := []struct {
+		name    string
+		command string
+		blocked bool
+	}{
+		{"project files", "go test ./... && cat src/main.go | grep func", false},
+		{"system binary", "/usr/bin/env git status", false},
+		{"dev null", "make build > /dev/null 2>&1", false},
+		{"url", "curl -fsSL https://example.com/install.sh -o install.sh", false},
+		{"pattern that is not a path", `grep -rn "/no-such-dir/v1" src`, false},
+		{"allowed root", "ls ~/.cache/go-build", false},
+		{"heredoc", "cat <<EOF > notes.txt\n/etc/passwd\nEOF", false},
+		{"absolute path", "cat " + filepath.Join(outside, "secret.txt"), true},
+		{"parent directory", "ls ../outside", true},
+		{"cd out", "cd .. && ls", true},
+		{"symlink", "rm -rf escape/data", true},
+		{"home directory", "cat ~/.ssh/id_ed25519", true},
+		{"home variable", `cp "$HOME/.aws/credentials" .`, true},
+		{"redirect", "echo x >> " + filepath.Join(outside, "log"), true},
+		{"read redirect", "wc -l < ../outside/data.csv", true},
+		{"flag value", "go build -o=" + filepath.Join(outside, "app") + " ./...", true},
+		{"nested shell", `bash -c "rm -rf ` + outside + `"`, true},
+		{"windows path", `type C:\Users\dev\.ssh\id_ed25519`, true},
+		{"brace expansion removes", "rm -rf {/etc/x,y}", true},
+		{"brace expansion reads", "cat {/etc/passwd,x}", true},
+		{"brace expansion redirect", "echo > {/etc/x,y}", true},
+		{"brace expansion parent", "cp x {..,.}/y", true},
+		{"brace expansion cd", "cd {/,.}; rm x", true},
+		{"brace expansion in project", "rm -f src/{a,b}.o", false},
+		{"attached short flag value", "sort -o/etc/x data.txt", true},
+		{"attached curl output", "curl -o/etc/x https://example.com", true},
+		{"long flag value outside", "git --git-dir=/other/.git log", true},
+		{"attached flag value inside", "sort -osorted.txt -rn data.txt", false},
+		{"long flag url", "git clone --template=https://example.com/t .", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := sandbox.CheckCommand(tt.command)
+			if err != nil {
+				t.Fatalf("CheckCommand() error: %v", err)
+			}
+			if blocked := len(issues) > 0; blocked != tt.blocked {
+				t.Errorf("CheckCommand(%q) issues = %q, want blocked = %v", tt.command, issues, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestSandbox_CheckPath(t *testing.T) {
+	sandbox, _, outside := newTestSandbox(t)
+
+	if issues := sandbox.CheckPath("Edit", "src/main.go"); len(issues) != 0 {
+		t.Errorf("CheckPath(src/main.go) = %q, want none", issues)
+	}
+	if issues := sandbox.CheckPath("Grep", ""); len(issues) != 0 {
+		t.Errorf("CheckPath(\"\") = %q, want none", issues)
+	}
+	want := "Write of " + filepath.Join(outside, "x") + ", which is outside the project root"
+	if issues := sandbox.CheckPath("Write", filepath.Join(outside, "x")); len(issues) != 1 || issues[0] != want {
+		t.Errorf("CheckPath(outside) = %q, want [%q]", issues, want)
+	}
+}
+
+func TestSandbox_WorkingDirectory(t *testing.T) {
+	_, project, outside := newTestSandbox(t)
+	sandbox := NewSandbox(outside, project, nil)
+	issues, err := sandbox.CheckCommand("ls")
+	if err != nil || len(issues) == 0 {
+		t.Errorf("CheckCommand() from outside the project = %q, %v, want blocked", issues, err)
+	}
+}
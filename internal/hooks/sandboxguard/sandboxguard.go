@@ -0,0 +1,203 @@
+// Package sandboxguard implements the sandbox-guard hook, which confines tool calls to the project root
+package sandboxguard
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// listFlag allows multiple -allow flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var allowFlags listFlag
+	flag.Var(&allowFlags, "allow", "Additional allowed root, e.g. /tmp or $GOPATH (can be specified multiple times)")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailClosed}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input or the command cannot be parsed: closed (block) or open (allow)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every blocked call to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "sandbox-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "sandbox-guard", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:   "sandbox-guard",
+		Events: []string{hook.EventPreToolUse},
+		Tools:  []string{"Bash", "Read", "Edit", "MultiEdit", "Write", "NotebookEdit", "Glob", "Grep"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "sandbox-guard", "Failed to parse hook input", err)
+		return
+	}
+
+	projectRoot := os.Getenv("CLAUDE_PROJECT_DIR")
+	if projectRoot == "" {
+		projectRoot = input.Cwd
+	}
+	sandbox := NewSandbox(input.Cwd, projectRoot, allowFlags)
+
+	var issues []string
+	switch input.ToolName {
+	case "Bash":
+		issues, err = sandbox.CheckCommand(input.ToolInput.Command)
+		if err != nil {
+			hookutil.FailInternal(settings, auditLog, "sandbox-guard", "Failed to parse command", err)
+			return
+		}
+	case "Read", "Edit", "MultiEdit", "Write":
+		issues = sandbox.CheckPath(input.ToolName, input.ToolInput.FilePath)
+	case "NotebookEdit":
+		issues = sandbox.CheckPath(input.ToolName, input.ToolInput.NotebookPath)
+	case "Glob", "Grep":
+		issues = sandbox.CheckPath(input.ToolName, input.ToolInput.Path)
+	}
+	logger.Debug("checked tool call", "tool", input.ToolName, "root", projectRoot, "issues", issues)
+
+	if len(issues) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "sandbox-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  settings.BlockDecision(),
+		Issues:    issues,
+		Command:   input.ToolInput.Command,
+		FilePath:  input.ToolInput.FilePath,
+	})
+	settings.Output().BlockPreToolUse("Outside the project sandbox! Work within "+projectRoot+", or ask the user to allow the path.", issues)
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `sandbox-guard: Project root confinement for Claude Code hooks
+
+A coarse containment layer: blocks Bash commands and Read/Edit/MultiEdit/
+Write/NotebookEdit/Glob/Grep calls whose target paths resolve outside the
+project root ($CLAUDE_PROJECT_DIR, or the working directory) and the allowed
+roots. Symlinks and ../ traversal are resolved first, so a link out of the
+project does not count as inside it.
+
+For Bash, redirection targets and arguments that look like paths are checked:
+absolute paths, ~/ paths, relative paths such as ../other, and values
+attached to flags, as in -o/etc/x. Braces are expanded first. The command
+itself may live anywhere, and device files such as /dev/null are allowed.
+Programs can still open paths the command line does not mention, so combine
+this hook with an OS-level sandbox where containment matters.
+
+USAGE:
+    sandbox-guard [-allow PATH ...] [OPTIONS]
+
+OPTIONAL:
+    -allow string
+            Additional allowed root, e.g. /tmp, ~/.cache/go-build, or
+            $GOPATH (can be specified multiple times). Environment
+            variables and a leading ~/ are expanded.
+
+    -fail-mode string
+            Behavior when input or the command cannot be parsed: closed
+            (block) or open (allow) (default: closed)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every blocked call to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_SANDBOX_GUARD_<FLAG> to target only this hook.
+
+EXAMPLES:
+    sandbox-guard -allow /tmp -allow '$GOPATH'
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Bash|Read|Edit|MultiEdit|Write|NotebookEdit|Glob|Grep",
+        "hooks": [{"type": "command", "command": "/path/to/sandbox-guard -allow /tmp"}]
+      }
+    ]
+  }
+}
+
+`)
+}
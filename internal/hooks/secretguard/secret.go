@@ -0,0 +1,174 @@
+// Package secretguard - secret detection and redaction
+package secretguard
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Pattern is a kind of secret and the expression that finds it. When the
+// expression has a group, only the group is the secret, e.g. the value of a
+// password assignment.
+type Pattern struct {
+	Name string
+	Re   *regexp.Regexp
+	// valid reports whether a matched value is plausibly a secret rather
+	// than a reference to one, such as password: config.Password.
+	valid func(value string) bool
+}
+
+// DefaultPatterns are the secrets looked for unless -no-defaults is given.
+var DefaultPatterns = []Pattern{
+	{Name: "private-key", Re: regexp.MustCompile(`(?s)-----BEGIN[A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----.*?(?:-----END[A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----|\z)`)},
+	{Name: "aws-access-key", Re: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+	{Name: "aws-secret-key", Re: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})(?:[^A-Za-z0-9/+]|$)`)},
+	{Name: "github-token", Re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{Name: "gitlab-token", Re: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{Name: "slack-token", Re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{Name: "stripe-key", Re: regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}`)},
+	{Name: "google-api-key", Re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{Name: "anthropic-api-key", Re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{Name: "openai-api-key", Re: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`)},
+	{Name: "jwt", Re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{Name: "url-credentials", Re: regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^/\s:@]+:([^/\s:@]{3,})@`), valid: literal},
+	{Name: "assignment", Re: regexp.MustCompile(`(?im)[\w.-]*(?:password|passwd|secret|token|api[_-]?key|private[_-]?key)[\w.-]*["']?\s*(?::=|[:=])\s*["']?([A-Za-z0-9+/=_\-.~!@#$%^&*]{8,})(?:["'\s,;]|$)`), valid: random},
+}
+
+// literal reports whether value is written out rather than a placeholder
+// such as ${DB_PASSWORD}, <password>, or ****.
+func literal(value string) bool {
+	if strings.ContainsAny(value[:1], "$<{%") {
+		return false
+	}
+	return strings.Trim(value, "*xX.") != ""
+}
+
+// random reports whether value looks generated: a literal with both letters
+// and digits, which names and expressions such as config.Password lack.
+func random(value string) bool {
+	return literal(value) &&
+		strings.ContainsAny(value, "0123456789") &&
+		strings.ContainsFunc(value, func(r rune) bool { return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' })
+}
+
+// ParsePattern parses a -pattern value, NAME=REGEXP.
+func ParsePattern(s string) (Pattern, error) {
+	name, expr, ok := strings.Cut(s, "=")
+	if !ok || name == "" || expr == "" {
+		return Pattern{}, fmt.Errorf("pattern %q is not NAME=REGEXP", s)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Pattern{}, fmt.Errorf("pattern %s: %w", name, err)
+	}
+	if re.NumSubexp() > 1 {
+		return Pattern{}, fmt.Errorf("pattern %s: has %d groups, want at most one, around the secret", name, re.NumSubexp())
+	}
+	return Pattern{Name: name, Re: re}, nil
+}
+
+// Finding is a secret in a text.
+type Finding struct {
+	Pattern    string
+	Line       int // 1-based line the secret starts on
+	Start, End int // Byte offsets of the secret
+}
+
+// Scan returns the secrets in text in order. Where matches overlap, the
+// first to start wins, and of those the earliest pattern.
+func Scan(text string, patterns []Pattern) []Finding {
+	var findings []Finding
+	for _, pattern := range patterns {
+		for _, match := range pattern.Re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := match[0], match[1]
+			if len(match) >= 4 {
+				if match[2] < 0 {
+					continue
+				}
+				start, end = match[2], match[3]
+			}
+			if start == end || pattern.valid != nil && !pattern.valid(text[start:end]) {
+				continue
+			}
+			findings = append(findings, Finding{Pattern: pattern.Name, Start: start, End: end})
+		}
+	}
+	// Stable, so patterns keep their order among findings that start together
+	slices.SortStableFunc(findings, func(a, b Finding) int { return a.Start - b.Start })
+	kept := findings[:0]
+	for _, finding := range findings {
+		if len(kept) > 0 && finding.Start < kept[len(kept)-1].End {
+			continue
+		}
+		finding.Line = strings.Count(text[:finding.Start], "\n") + 1
+		kept = append(kept, finding)
+	}
+	return kept
+}
+
+// Redact replaces each finding in text with a [REDACTED <pattern>] marker.
+// Findings must be in order, as Scan returns them.
+func Redact(text string, findings []Finding) string {
+	var b strings.Builder
+	last := 0
+	for _, finding := range findings {
+		b.WriteString(text[last:finding.Start])
+		b.WriteString("[REDACTED " + finding.Pattern + "]")
+		last = finding.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Located is a finding in one string of a JSON value.
+type Located struct {
+	Field string // Path of the string, e.g. file.content or content[0].text
+	Finding
+}
+
+// RedactValue returns a copy of v, a decoded JSON value such as a
+// tool_response, with the secrets in its strings redacted, and the secrets
+// found, field by field.
+func RedactValue(v any, patterns []Pattern) (any, []Located) {
+	var found []Located
+	var walk func(field string, v any) any
+	walk = func(field string, v any) any {
+		switch v := v.(type) {
+		case string:
+			findings := Scan(v, patterns)
+			if len(findings) == 0 {
+				return v
+			}
+			for _, finding := range findings {
+				found = append(found, Located{Field: field, Finding: finding})
+			}
+			return Redact(v, findings)
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			redacted := make(map[string]any, len(v))
+			for _, key := range keys {
+				child := key
+				if field != "" {
+					child = field + "." + key
+				}
+				redacted[key] = walk(child, v[key])
+			}
+			return redacted
+		case []any:
+			redacted := make([]any, len(v))
+			for i, element := range v {
+				redacted[i] = walk(field+"["+strconv.Itoa(i)+"]", element)
+			}
+			return redacted
+		}
+		return v
+	}
+	return walk("", v), found
+}
@@ -0,0 +1,339 @@
+// Package secretguard implements the secret-guard hook, which keeps secrets in Read, Grep, and MCP tool output from leaking into the conversation
+package secretguard
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+)
+
+// uncheckedAdvice follows the reason when fail closed reports output that
+// could not be checked.
+const uncheckedAdvice = "The output was not checked for secrets; treat any it contains as confidential."
+
+// listFlag allows multiple -pattern and -allow flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var patternFlags, allow listFlag
+	flag.Var(&patternFlags, "pattern", "Additional secret pattern NAME=REGEXP; a group marks the secret within the match (can be specified multiple times)")
+	noDefaults := flag.Bool("no-defaults", false, "Look only for the -pattern secrets, not the built-in ones")
+	flag.Var(&allow, "allow", "Glob of files whose Read output is not checked, e.g. testdata/** (can be specified multiple times)")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailOpen}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input or the tool output cannot be parsed: open (allow) or closed (block)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every blocked or redacted output to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "secret-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "secret-guard", hook.EventPostToolUse)
+	manifest := config.Manifest{
+		Hook:   "secret-guard",
+		Events: []string{hook.EventPostToolUse},
+		Tools:  []string{"Read", "Grep", "mcp__.*"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	var patterns []Pattern
+	if !*noDefaults {
+		patterns = append(patterns, DefaultPatterns...)
+	}
+	for _, value := range patternFlags {
+		pattern, err := ParsePattern(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			hook.Exit(hook.ExitNonBlockingError)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	data, err := io.ReadAll(os.Stdin)
+	var input *hook.PostToolUseInput
+	if err == nil {
+		input, err = decodeInput(data, settings.InputOptions())
+	}
+	if err != nil {
+		hookutil.FailInternalPostToolUse(settings, auditLog, "secret-guard", "Failed to parse hook input", err, uncheckedAdvice)
+		return
+	}
+	mcp := hook.IsMCPTool(input.ToolName)
+	if input.ToolName != "Read" && input.ToolName != "Grep" && !mcp {
+		hook.AllowPostToolUse()
+		return
+	}
+	if input.ToolName == "Read" && matchAny(allow, projectRoot(input.Cwd), resolve(input.Cwd, input.ToolInput.FilePath)) {
+		hook.AllowPostToolUse()
+		return
+	}
+	var response any
+	if err := json.Unmarshal(input.Raw.ToolResponse, &response); err != nil {
+		hookutil.FailInternalPostToolUse(settings, auditLog, "secret-guard", "Failed to parse tool output", hook.MarkError(err, hook.ErrInputSchema), uncheckedAdvice)
+		return
+	}
+
+	redacted, found := RedactValue(response, patterns)
+	logger.Debug("scanned tool output", "tool", input.ToolName, "secrets", len(found))
+	if len(found) == 0 {
+		hook.AllowPostToolUse()
+		return
+	}
+	issues := describeFindings(input, response, found)
+
+	record := audit.Record{
+		Hook:      "secret-guard",
+		Event:     hook.EventPostToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  settings.BlockDecision(),
+		Issues:    issues,
+		FilePath:  input.ToolInput.FilePath,
+	}
+	if mcp {
+		// Claude Code lets hooks replace an MCP tool's output, so Claude
+		// never sees the secrets
+		record.Decision = audit.DecisionRedact
+		hookutil.WriteAudit(auditLog, record)
+		hook.UpdatePostToolUseOutput(redacted)
+		return
+	}
+	hookutil.WriteAudit(auditLog, record)
+	hook.BlockPostToolUse("The " + input.ToolName + " output contains secrets: " + strings.Join(issues, "; ") +
+		". Do not repeat, copy, or use these values, and refer to them by where they are instead, e.g. \"the token in .env\". Tell the user, who may want to rotate them.")
+}
+
+// decodeInput decodes a PostToolUse payload with its raw tool_response. An
+// MCP tool's tool_response need not be an object, as DecodePostToolUseInput
+// requires, so its payload is decoded as is.
+func decodeInput(data []byte, opts hook.InputOptions) (*hook.PostToolUseInput, error) {
+	var envelope struct {
+		SessionID     string          `json:"session_id"`
+		Cwd           string          `json:"cwd"`
+		HookEventName string          `json:"hook_event_name"`
+		ToolName      string          `json:"tool_name"`
+		ToolResponse  json.RawMessage `json:"tool_response"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, hook.MarkError(err, hook.ErrInputSchema)
+	}
+	if !hook.IsMCPTool(envelope.ToolName) {
+		opts.KeepRaw = true
+		return hook.DecodePostToolUseInput(bytes.NewReader(data), opts)
+	}
+	return &hook.PostToolUseInput{
+		SessionID:     envelope.SessionID,
+		Cwd:           envelope.Cwd,
+		HookEventName: envelope.HookEventName,
+		ToolName:      envelope.ToolName,
+		Raw:           hook.Raw{Payload: data, ToolResponse: envelope.ToolResponse},
+	}, nil
+}
+
+// describeFindings says where each secret is, without the secret: by file
+// line for Read, which returns file.content from file.startLine, and by
+// output line and field otherwise.
+func describeFindings(input *hook.PostToolUseInput, response any, found []Located) []string {
+	firstLine := 1
+	if object, ok := response.(map[string]any); ok {
+		if file, ok := object["file"].(map[string]any); ok {
+			if start, ok := file["startLine"].(float64); ok && start >= 1 {
+				firstLine = int(start)
+			}
+		}
+	}
+	issues := make([]string, 0, len(found))
+	for _, f := range found {
+		var where string
+		switch {
+		case input.ToolName == "Read" && f.Field == "file.content":
+			where = input.ToolInput.FilePath + ":" + strconv.Itoa(firstLine+f.Line-1)
+		case f.Field == "":
+			where = "line " + strconv.Itoa(f.Line)
+		default:
+			where = f.Field + " line " + strconv.Itoa(f.Line)
+		}
+		issues = append(issues, f.Pattern+" at "+where)
+	}
+	return issues
+}
+
+// matchAny reports whether file matches any of the globs. A glob without a
+// slash, such as *.pem, matches the file name in any directory; others are
+// resolved against root and use pathmatch.Match syntax.
+func matchAny(patterns []string, root, file string) bool {
+	if file == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, `/\`) {
+			if pathmatch.Match(pattern, pathmatch.Base(file)) {
+				return true
+			}
+			continue
+		}
+		if pathmatch.Match(pathmatch.Join(root, pattern), pathmatch.Join(root, file)) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectRoot returns the root allow globs are relative to:
+// $CLAUDE_PROJECT_DIR, or the working directory.
+func projectRoot(cwd string) string {
+	if root := os.Getenv("CLAUDE_PROJECT_DIR"); root != "" {
+		return root
+	}
+	return cwd
+}
+
+// resolve makes path absolute against cwd.
+func resolve(cwd, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(cwd, path)
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `secret-guard: Secret redaction for tool output in Claude Code hooks
+
+Looks for secrets, such as private keys, cloud and API tokens, credentials in
+URLs, and password assignments, in the output of the tools that show Claude
+file contents, and keeps them from being repeated or used:
+
+    PostToolUse mcp__*  Replaces each secret in the MCP tool's output with
+                        [REDACTED <pattern>] before Claude sees it
+    PostToolUse Read    Claude Code cannot change a built-in tool's output,
+    PostToolUse Grep    so the hook tells Claude where the secrets are and
+                        not to repeat, copy, or use them
+
+Where reads of secret files should fail outright, deny them before they run,
+e.g. with a Claude Code permissions.deny rule for Read(./.env).
+
+USAGE:
+    secret-guard [-pattern NAME=REGEXP ...] [-allow GLOB ...] [OPTIONS]
+
+OPTIONAL:
+    -pattern string
+            Additional secret pattern NAME=REGEXP (can be specified multiple
+            times). When the expression has a group, only the group is the
+            secret, e.g. internal-token=TOKEN_([A-Z0-9]{32})
+
+    -no-defaults
+            Look only for the -pattern secrets, not the built-in ones
+
+    -allow string
+            Glob of files whose Read output is not checked (can be specified
+            multiple times). A glob without a slash, such as *.example,
+            matches the file name in any directory; others are relative to
+            the project root, e.g. testdata/**
+
+    -fail-mode string
+            Behavior when input or the tool output cannot be parsed: open
+            (allow) or closed (block) (default: open)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every blocked or redacted output to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_SECRET_GUARD_<FLAG> to target only this hook. Separate
+    multiple -pattern or -allow values with semicolons.
+
+EXAMPLES:
+    # Also catch the company's internal tokens; fixtures hold fake keys
+    secret-guard -pattern "acme-token=acme_[a-z0-9]{32}" -allow "testdata/**"
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PostToolUse": [
+      {
+        "matcher": "Read|Grep|mcp__.*",
+        "hooks": [{"type": "command", "command": "/path/to/secret-guard"}]
+      }
+    ]
+  }
+}
+
+`)
+}
@@ -0,0 +1,665 @@
+// Package selfprotect - protected hook configuration paths and command checks
+package selfprotect
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// readOnlyCommands may reference protected paths because they cannot change them.
+var readOnlyCommands = []string{
+	"cat", "less", "more", "head", "tail", "grep", "egrep", "fgrep", "rg",
+	"ls", "stat", "file", "wc", "diff", "cmp", "jq", "yq", "readlink", "realpath",
+	"sha256sum", "md5sum", "test", "[",
+}
+
+// inPlaceFlags are the flags that make a command rewrite the files it is
+// given, e.g. yq -i. yq is only read-only without them, and sed and perl
+// only change files named by their arguments with them.
+var inPlaceFlags = map[string][]string{
+	"yq":   {"-i", "--inplace"},
+	"sed":  {"-i", "--in-place"},
+	"perl": {"-i"},
+}
+
+// writeCommands change the files named by their arguments.
+var writeCommands = []string{
+	"rm", "rmdir", "mv", "cp", "ln", "install", "rsync", "chmod", "chown", "chgrp",
+	"touch", "truncate", "tee", "dd", "shred", "unlink", "trash", "trash-put",
+}
+
+// inlineCodeFlags are the flags that pass an interpreter its program as an
+// argument, e.g. python3 -c "open('x', 'w')", keyed by the interpreter name
+// without its version.
+var inlineCodeFlags = map[string][]string{
+	"python": {"-c"},
+	"node":   {"-e", "--eval", "-p", "--print"},
+	"nodejs": {"-e", "--eval", "-p", "--print"},
+	"bun":    {"-e", "--eval", "-p", "--print"},
+	"perl":   {"-e", "-E"},
+	"ruby":   {"-e"},
+	"php":    {"-r"},
+}
+
+// maxBraceWords bounds the words brace expansion may produce before a command
+// is considered too large to check.
+const maxBraceWords = 256
+
+// gitTreeSubcommands discard, remove, or move working tree files below the
+// paths they are given.
+var gitTreeSubcommands = []string{"checkout", "restore", "clean", "rm", "mv"}
+
+// protectedNames are file names protected wherever they appear.
+var protectedNames = []string{config.ProjectFileName}
+
+// presetPaths returns the paths protected by the named bash-block presets,
+// those that protect files from redirection such as shell-rc.
+func presetPaths(names []string) ([]string, error) {
+	var paths []string
+	for _, name := range names {
+		preset, ok := detector.LookupPreset(name)
+		if !ok || len(preset.Redirects) == 0 {
+			return nil, fmt.Errorf("unknown preset '%s' (want one of %s)", name, strings.Join(pathPresetNames(), ", "))
+		}
+		paths = append(paths, preset.Redirects...)
+	}
+	return paths, nil
+}
+
+// pathPresetNames returns the names of the presets that protect paths.
+func pathPresetNames() []string {
+	var names []string
+	for _, preset := range detector.Presets() {
+		if len(preset.Redirects) > 0 {
+			names = append(names, preset.Name)
+		}
+	}
+	return names
+}
+
+// Protector decides whether a tool call touches the hook configuration: the
+// Claude Code settings files, the installed hook binaries, and the
+// claudecode-hooks policy files.
+type Protector struct {
+	cwd     string
+	paths   *config.Policy    // Absolute protected path patterns
+	secrets *config.Policy    // Protected paths that must not be read either
+	env     *expand.Config    // Expands $HOME and other variables in command words
+	vars    map[string]string // The same environment, for shellparse.ResolveParams
+}
+
+// NewProtector returns a Protector for a project. projectDir is the Claude Code
+// project root ($CLAUDE_PROJECT_DIR), claudeDir the user config directory
+// (~/.claude), grantDir and grantKey the grant store and key bash-block
+// -allow-once uses, and extra additional patterns relative to projectDir.
+func NewProtector(cwd, projectDir, claudeDir, grantDir, grantKey string, extra []string) *Protector {
+	var patterns []string
+	for _, dir := range []string{projectDir, claudeDir} {
+		if dir == "" {
+			continue
+		}
+		base := dir
+		if dir == projectDir {
+			base = filepath.Join(dir, ".claude")
+		}
+		patterns = append(patterns,
+			filepath.Join(base, "settings.json"),
+			filepath.Join(base, "settings.local.json"),
+			filepath.Join(base, "hooks", "**"),
+		)
+	}
+
+	// Managed (enterprise) Claude Code settings and the policy layers
+	patterns = append(patterns,
+		filepath.Join("/etc/claude-code", "**"),
+		filepath.Join("/Library/Application Support/ClaudeCode", "**"),
+		filepath.Join(config.SystemConfigDir, "**"),
+	)
+	if userDir, err := config.UserConfigDir(); err == nil {
+		patterns = append(patterns, filepath.Join(userDir, "**"))
+	}
+
+	// Hook state: grants, cached results, backups, and per-session counters
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		patterns = append(patterns, filepath.Join(cacheDir, "claudecode-hooks", "**"))
+	}
+	if grantDir != "" {
+		patterns = append(patterns, filepath.Join(expandHome(grantDir), "**"))
+	}
+
+	// The running hook binary itself, wherever it was installed
+	if exe, err := os.Executable(); err == nil {
+		patterns = append(patterns, exe)
+	}
+
+	for _, pattern := range extra {
+		pattern = expandHome(pattern)
+		if !filepath.IsAbs(pattern) && projectDir != "" {
+			pattern = filepath.Join(projectDir, pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	// Resolved paths are matched too, so patterns need their resolved forms
+	// when the project or home directory is reached through a symlink
+	for _, pattern := range patterns {
+		dir, glob := pattern, ""
+		if strings.HasSuffix(pattern, string(filepath.Separator)+"**") {
+			dir, glob = filepath.Dir(pattern), string(filepath.Separator)+"**"
+		}
+		if resolved := utils.ResolvePath(cwd, dir) + glob; resolved != pattern && !strings.ContainsAny(dir, "*?[") {
+			patterns = append(patterns, resolved)
+		}
+	}
+
+	// The key hooks grant signs grants with: reading it is enough to forge one
+	var secrets []string
+	if grantKey != "" {
+		grantKey = expandHome(grantKey)
+		secrets = append(secrets, grantKey)
+		if resolved := utils.ResolvePath(cwd, grantKey); resolved != grantKey {
+			secrets = append(secrets, resolved)
+		}
+	}
+	patterns = append(patterns, secrets...)
+
+	vars := make(map[string]string)
+	for _, pair := range os.Environ() {
+		if name, value, ok := strings.Cut(pair, "="); ok {
+			vars[name] = value
+		}
+	}
+	return &Protector{
+		cwd:     cwd,
+		paths:   &config.Policy{ProtectedPaths: patterns},
+		secrets: &config.Policy{ProtectedPaths: secrets},
+		env:     &expand.Config{Env: expand.ListEnviron(os.Environ()...)},
+		vars:    vars,
+	}
+}
+
+// IsSecret reports whether path, absolute or relative to the working
+// directory, is a protected path Claude must not read either, such as the
+// grant key.
+func (p *Protector) IsSecret(path string) bool {
+	return p.isSecret(p.cwd, path)
+}
+
+// isSecret is IsSecret for a path relative to cwd, as for isProtected.
+func (p *Protector) isSecret(cwd, path string) bool {
+	path = expandHome(path)
+	if path == "" || cwd == "" && !pathmatch.IsAbs(path) {
+		return false
+	}
+	candidates := append([]string{path, utils.ResolvePath(cwd, path)}, globMatches(cwd, path)...)
+	return slices.ContainsFunc(candidates, func(candidate string) bool {
+		return p.secrets.IsProtectedPath(cwd, candidate)
+	})
+}
+
+// ContainsSecret reports whether path is a secret or a directory above one,
+// so searching it recursively reads the secret.
+func (p *Protector) ContainsSecret(path string) bool {
+	if p.IsSecret(path) {
+		return true
+	}
+	path = expandHome(path)
+	if path == "" {
+		return false
+	}
+	for _, dir := range []string{pathmatch.Join(p.cwd, path), utils.ResolvePath(p.cwd, path)} {
+		for _, secret := range p.secrets.ProtectedPaths {
+			if pathmatch.Within(dir, secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsProtected reports whether path, absolute or relative to the working
+// directory, is part of the hook configuration.
+func (p *Protector) IsProtected(path string) bool {
+	return p.isProtected(p.cwd, path)
+}
+
+// isProtected is IsProtected for a path relative to cwd, the directory a
+// command has changed to. An empty cwd is a directory not known before the
+// command runs, where only absolute paths and protected file names match.
+func (p *Protector) isProtected(cwd, path string) bool {
+	if path == "" {
+		return false
+	}
+	path = expandHome(path)
+	if slices.Contains(protectedNames, pathmatch.Base(path)) {
+		return true
+	}
+	if cwd == "" && !pathmatch.IsAbs(path) {
+		return false
+	}
+	if p.paths.IsProtectedPath(cwd, path) {
+		return true
+	}
+	// Resolve symlinks and ../ so a link into ~/.claude, or a new file in a
+	// linked directory, is caught too
+	resolved := utils.ResolvePath(cwd, path)
+	if slices.Contains(protectedNames, pathmatch.Base(resolved)) || p.paths.IsProtectedPath(cwd, resolved) {
+		return true
+	}
+	// A glob such as .claude/*.json names whatever it matches when it runs
+	return slices.ContainsFunc(globMatches(cwd, path), func(match string) bool {
+		return p.paths.IsProtectedPath(cwd, match)
+	})
+}
+
+// ContainsProtected reports whether path is protected or is a directory above
+// a protected path, such as ~/.claude or the project root, so removing or
+// moving it takes protected files along.
+func (p *Protector) ContainsProtected(path string) bool {
+	return p.containsProtected(p.cwd, path)
+}
+
+// containsProtected is ContainsProtected for a path relative to cwd, as for
+// isProtected.
+func (p *Protector) containsProtected(cwd, path string) bool {
+	if p.isProtected(cwd, path) {
+		return true
+	}
+	path = expandHome(path)
+	if path == "" || cwd == "" && !pathmatch.IsAbs(path) {
+		return false
+	}
+	dirs := append([]string{pathmatch.Join(cwd, path), utils.ResolvePath(cwd, path)}, globMatches(cwd, path)...)
+	for _, dir := range dirs {
+		for _, pattern := range p.paths.ProtectedPaths {
+			if pathmatch.Within(dir, staticDir(pattern)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// globMatches returns the files a path with wildcards, such as .c*, matches
+// relative to cwd, or nothing for a path without any.
+func globMatches(cwd, path string) []string {
+	if !strings.ContainsAny(path, "*?[") {
+		return nil
+	}
+	matches, _ := filepath.Glob(pathmatch.Join(cwd, path)) //nolint:errcheck // A malformed pattern matches nothing
+	return matches
+}
+
+// staticDir returns the part of a path pattern before its first wildcard:
+// the pattern itself when it has none, otherwise the directory above it.
+func staticDir(pattern string) string {
+	pattern = strings.TrimSuffix(pattern, string(filepath.Separator)+"**")
+	for strings.ContainsAny(pattern, "*?[") {
+		pattern = filepath.Dir(pattern)
+	}
+	return pattern
+}
+
+// CheckCommand returns an issue for every part of the shell expression that
+// could modify the hook configuration: writing redirections to a protected
+// path, non-read-only commands referencing one, any command or redirection
+// referencing a secret such as the grant key, commands that change files
+// named by arguments not known before they run, and hooks install or
+// self-update, which replace the hook binaries.
+//
+// Variables the command sets to a certain value, as in "d=~/.claude; rm -rf
+// $d", are resolved first, and relative paths are resolved against the
+// directory the command has changed to with cd.
+func (p *Protector) CheckCommand(shellExpr string) ([]string, error) {
+	ast, err := shellparse.Parse(shellExpr)
+	if err != nil {
+		return nil, err
+	}
+	shellparse.ResolveParams(ast, p.vars)
+
+	cwd := p.cwd
+	var issues []string
+	syntax.Walk(ast, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Redirect:
+			if n.Op == syntax.Hdoc || n.Op == syntax.DashHdoc || n.Op == syntax.WordHdoc {
+				return true
+			}
+			words, ok := shellparse.ExpandBraceWords([]*syntax.Word{n.Word}, maxBraceWords)
+			if !ok {
+				issues = append(issues, "redirection uses a brace expansion that cannot be checked")
+				return true
+			}
+			for _, word := range words {
+				issues = append(issues, p.checkRedirect(cwd, n.Op, word)...)
+			}
+		case *syntax.CallExpr:
+			var callIssues []string
+			cwd, callIssues = p.checkCall(cwd, n)
+			issues = append(issues, callIssues...)
+		}
+		return true
+	})
+	return issues, nil
+}
+
+// checkRedirect checks a redirection with operator op to or from word, run in
+// cwd.
+func (p *Protector) checkRedirect(cwd string, op syntax.RedirOperator, word *syntax.Word) []string {
+	target, static := p.wordValue(word)
+	if op == syntax.RdrIn || op == syntax.DplIn {
+		if p.isSecret(cwd, target) {
+			return []string{fmt.Sprintf("redirection reads secret %s", target)}
+		}
+		return nil
+	}
+	if op == syntax.DplOut && static && (target == "-" || strings.Trim(target, "0123456789") == "") {
+		// 2>&1 duplicates a file descriptor rather than opening a file
+		return nil
+	}
+	switch {
+	case p.isProtected(cwd, target):
+		return []string{fmt.Sprintf("redirection writes to protected path %s", target)}
+	case !static:
+		return []string{fmt.Sprintf("redirection writes to %s, which is not known before it runs", shellparse.Print(word))}
+	case cwd == "" && !pathmatch.IsAbs(expandHome(target)):
+		return []string{fmt.Sprintf("redirection writes to %s in a directory not known before it runs", target)}
+	}
+	return nil
+}
+
+// checkCall checks a single command call run in cwd, and returns the working
+// directory after it, which cd and pushd change. Brace expansion is performed
+// first, so {.claude,x} names .claude.
+func (p *Protector) checkCall(cwd string, call *syntax.CallExpr) (string, []string) {
+	words, ok := shellparse.ExpandBraceWords(call.Args, maxBraceWords)
+	if !ok {
+		return cwd, []string{"command uses a brace expansion that cannot be checked"}
+	}
+	args := make([]string, 0, len(words))
+	static := make([]bool, 0, len(words))
+	var dynamic []string // Arguments not known before the command runs
+	for i, word := range words {
+		value, isStatic := p.wordValue(word)
+		args = append(args, value)
+		static = append(static, isStatic)
+		if !isStatic && i > 0 {
+			dynamic = append(dynamic, shellparse.Print(word))
+		}
+	}
+	if len(args) == 0 || args[0] == "" {
+		return cwd, nil
+	}
+	cmd := pathmatch.Base(args[0])
+
+	switch cmd {
+	case "cd", "pushd":
+		return changeDir(cwd, args[1:], static[1:]), nil
+	case "popd":
+		return "", nil
+	}
+	if isHooksBinary(cmd) && len(args) > 1 && (args[1] == "install" || args[1] == "self-update") {
+		return cwd, []string{fmt.Sprintf("%s %s replaces the installed hook binaries", cmd, args[1])}
+	}
+	var issues []string
+	codePaths := inlineCodePaths(cmd, args[1:])
+	for _, arg := range args[1:] {
+		for _, candidate := range pathCandidates(arg) {
+			if p.isSecret(cwd, candidate) {
+				issues = append(issues, fmt.Sprintf("%s references secret %s", cmd, candidate))
+				break
+			}
+		}
+	}
+	for _, path := range codePaths {
+		if p.isSecret(cwd, path) {
+			issues = append(issues, fmt.Sprintf("%s code references secret %s", cmd, path))
+		}
+	}
+	if len(issues) > 0 || isReadOnly(cmd, args[1:]) {
+		return cwd, issues
+	}
+
+	// Inline interpreter code can change any file it names, e.g. with
+	// open(".claude/settings.json", "w") or shutil.rmtree(".claude")
+	for _, path := range codePaths {
+		if p.containsProtected(cwd, path) {
+			issues = append(issues, fmt.Sprintf("%s code references protected path %s", cmd, path))
+		}
+	}
+
+	for _, arg := range args[1:] {
+		for _, candidate := range pathCandidates(arg) {
+			if p.isProtected(cwd, candidate) {
+				issues = append(issues, fmt.Sprintf("%s references protected path %s", cmd, candidate))
+				break
+			}
+		}
+	}
+	if len(issues) > 0 {
+		return cwd, issues
+	}
+	for _, arg := range treeArgs(cmd, args) {
+		if p.containsProtected(cwd, arg) {
+			issues = append(issues, fmt.Sprintf("%s changes %s, which contains protected paths", cmd, arg))
+		}
+	}
+	if len(issues) > 0 || !writesFiles(cmd, args) {
+		return cwd, issues
+	}
+	// Whatever a file-changing command is given when it runs may be protected
+	if len(dynamic) > 0 {
+		return cwd, []string{fmt.Sprintf("%s changes files named by %s, which is not known before it runs", cmd, strings.Join(dynamic, " "))}
+	}
+	if cwd == "" && slices.ContainsFunc(args[1:], func(arg string) bool { return isOperand(arg) && !pathmatch.IsAbs(expandHome(arg)) }) {
+		return cwd, []string{fmt.Sprintf("%s changes files in a directory not known before it runs", cmd)}
+	}
+	return cwd, nil
+}
+
+// changeDir returns the working directory after cd or pushd with args in cwd,
+// or "" when it is not known before the command runs.
+func changeDir(cwd string, args []string, static []bool) string {
+	for i, arg := range args {
+		if !static[i] || arg == "-" || strings.HasPrefix(arg, "+") {
+			return ""
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue // -L, -P
+		}
+		arg = expandHome(arg)
+		if cwd == "" && !filepath.IsAbs(arg) {
+			return ""
+		}
+		return utils.ResolvePath(cwd, arg)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return home
+}
+
+// writesFiles reports whether a call changes the files its arguments name:
+// a file-changing command, find with -delete or -exec, a git subcommand that
+// discards or moves working tree files, or a command given an in-place flag.
+func writesFiles(cmd string, args []string) bool {
+	switch {
+	case slices.Contains(writeCommands, cmd):
+		return true
+	case cmd == "find", cmd == "git":
+		return treeArgs(cmd, args) != nil
+	}
+	return hasInPlaceFlag(cmd, args[1:])
+}
+
+// treeArgs returns the arguments naming directory trees the command changes
+// as a whole, such as rm's operands or cp's destination. Naming a directory
+// above a protected path there changes the protected path too; other
+// commands, such as "git add .", only count when they name a protected path
+// itself.
+func treeArgs(cmd string, args []string) []string {
+	operands := args[1:]
+	switch cmd {
+	case "rm", "rmdir", "mv", "chmod", "chown", "chgrp", "shred", "unlink", "trash", "trash-put":
+		return operands
+	case "cp", "rsync", "install", "ln":
+		// Only the destination changes, unless it is given with -t
+		if slices.ContainsFunc(operands, func(arg string) bool {
+			return arg == "-t" || strings.HasPrefix(arg, "--target-directory")
+		}) {
+			return operands
+		}
+		for i := len(operands) - 1; i >= 0; i-- {
+			if !strings.HasPrefix(operands[i], "-") {
+				return operands[i:]
+			}
+		}
+	case "find":
+		if slices.ContainsFunc(operands, func(arg string) bool {
+			return arg == "-delete" || strings.HasPrefix(arg, "-exec") || strings.HasPrefix(arg, "-ok")
+		}) {
+			return operands
+		}
+	case "git":
+		if len(operands) == 0 || !slices.Contains(gitTreeSubcommands, operands[0]) {
+			return nil
+		}
+		paths := operands[1:]
+		if operands[0] == "clean" && !slices.ContainsFunc(paths, isOperand) && slices.ContainsFunc(paths, isIgnoredFlag) {
+			// git clean -x without paths removes ignored files such as
+			// .claude/settings.local.json from the working directory
+			return append(paths, ".")
+		}
+		return paths
+	}
+	return nil
+}
+
+// wordValue expands a word using the hook's environment, which Claude Code
+// shares with the Bash tool, so "$HOME/.claude" resolves as it would when run,
+// and reports whether its value is known before it runs. Words that cannot be
+// expanded (command substitutions) keep their static parts.
+func (p *Protector) wordValue(word *syntax.Word) (string, bool) {
+	_, static := shellparse.StaticWord(word)
+	if value, err := expand.Literal(p.env, word); err == nil {
+		return value, static
+	}
+	value, _ := shellparse.StaticWord(word)
+	return value, false
+}
+
+// isReadOnly reports whether a call cannot change the files it references:
+// the command is read-only and given no in-place flag.
+func isReadOnly(cmd string, args []string) bool {
+	return slices.Contains(readOnlyCommands, cmd) && !hasInPlaceFlag(cmd, args)
+}
+
+// hasInPlaceFlag reports whether one of args is an in-place flag of cmd,
+// alone, with a value (--inplace=true), or in a short flag group (-Pi).
+func hasInPlaceFlag(cmd string, args []string) bool {
+	for _, flag := range inPlaceFlags[cmd] {
+		for _, arg := range args {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return true
+			}
+			if len(flag) == 2 && len(arg) > 1 && arg[0] == '-' && arg[1] != '-' && strings.ContainsRune(arg[1:], rune(flag[1])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isOperand reports whether a command argument is an operand rather than a flag.
+func isOperand(arg string) bool {
+	return !strings.HasPrefix(arg, "-")
+}
+
+// isIgnoredFlag reports whether a git clean flag, such as -fdx, includes -x
+// or -X, which remove ignored files.
+func isIgnoredFlag(arg string) bool {
+	return strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.ContainsAny(arg, "xX")
+}
+
+// isHooksBinary reports whether cmd is the hooks management CLI, e.g.
+// "hooks" or "krmcbride-hooks".
+func isHooksBinary(cmd string) bool {
+	cmd = strings.TrimSuffix(cmd, ".exe")
+	return cmd == "hooks" || strings.HasSuffix(cmd, "-hooks")
+}
+
+// pathCandidates returns the strings in an argument that may be paths: the
+// argument itself, the value of --flag=value, and each word of a script
+// passed to sh -c or similar.
+func pathCandidates(arg string) []string {
+	candidates := []string{arg}
+	if _, value, ok := strings.Cut(arg, "="); ok {
+		candidates = append(candidates, value)
+	}
+	if fields := strings.Fields(arg); len(fields) > 1 {
+		for _, field := range fields {
+			candidates = append(candidates, strings.Trim(field, `"';&|()<>`))
+		}
+	}
+	return candidates
+}
+
+// inlineCodePaths returns the words of the program an interpreter is passed
+// as an argument, e.g. with python3 -c or node -e, that may be paths: the code
+// is split at quotes, brackets, and operators, and words that only name the
+// working directory or the root, such as "." or "/", are left out.
+func inlineCodePaths(cmd string, args []string) []string {
+	flags := inlineCodeFlags[strings.TrimRight(cmd, "0123456789.")]
+	var paths []string
+	for i, arg := range args {
+		var code string
+		for _, flag := range flags {
+			switch {
+			case arg == flag && i+1 < len(args):
+				code = args[i+1]
+			case strings.HasPrefix(arg, flag+"="):
+				code = strings.TrimPrefix(arg, flag+"=")
+			case len(flag) == 2 && strings.HasPrefix(arg, flag):
+				code = strings.TrimPrefix(arg, flag)
+			}
+		}
+		words := strings.FieldsFunc(code, func(r rune) bool {
+			return unicode.IsSpace(r) || strings.ContainsRune("\"'`()[]{},;:+=<>|&", r)
+		})
+		for _, word := range words {
+			if strings.Trim(word, "./") != "" {
+				paths = append(paths, word)
+			}
+		}
+	}
+	return paths
+}
+
+// expandHome expands a leading ~ or $HOME, which remain in scripts passed to
+// sh -c, to the home directory.
+func expandHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	for _, prefix := range []string{"~", "$HOME", "${HOME}"} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok && (rest == "" || rest[0] == '/') {
+			return home + rest
+		}
+	}
+	return path
+}
@@ -0,0 +1,283 @@
+// Package selfprotect implements the self-protect hook, which stops Claude from editing its own hook configuration
+package selfprotect
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/grant"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// listFlag allows multiple -protect and -preset flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_<FLAG> environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var extra, presetNames listFlag
+	flag.Var(&extra, "protect", "Additional path or pattern to protect, relative to the project (can be specified multiple times)")
+	flag.Var(&presetNames, "preset", "Additional set of paths to protect, e.g. shell-rc (can be specified multiple times)")
+	projectOnly := flag.Bool("project-only", false, "Also block Edit/MultiEdit/Write/NotebookEdit of files outside the project root")
+	grantDir := flag.String("grant-dir", grant.DefaultDir(), "Directory of hooks grant approvals to protect (match bash-block -grant-dir)")
+	grantKey := flag.String("grant-key", grant.DefaultKeyPath(), "Grant key file to protect from reads and writes (match bash-block -grant-key)")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailClosed}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input or the command cannot be parsed: closed (block) or open (allow)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every blocked call to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "self-protect"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "self-protect", hook.EventPreToolUse)
+	manifest := config.Manifest{
+		Hook:   "self-protect",
+		Events: []string{hook.EventPreToolUse},
+		Tools:  []string{"Bash", "Edit", "MultiEdit", "Write", "NotebookEdit", "Read", "Grep"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || hookutil.StdinIsTerminal() {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	paths, err := presetPaths(presetNames)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(os.Stdin, settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "self-protect", "Failed to parse hook input", err)
+		return
+	}
+
+	projectDir := os.Getenv("CLAUDE_PROJECT_DIR")
+	if projectDir == "" {
+		projectDir = input.Cwd
+	}
+	protector := NewProtector(input.Cwd, projectDir, claudeConfigDir(), *grantDir, *grantKey, append(paths, extra...))
+
+	var issues []string
+	switch input.ToolName {
+	case "Bash":
+		issues, err = protector.CheckCommand(input.ToolInput.Command)
+		if err != nil {
+			hookutil.FailInternal(settings, auditLog, "self-protect", "Failed to parse command", err)
+			return
+		}
+	case "Edit", "MultiEdit", "Write", "NotebookEdit":
+		path := input.ToolInput.FilePath
+		if input.ToolName == "NotebookEdit" {
+			path = input.ToolInput.NotebookPath
+		}
+		switch {
+		case protector.IsProtected(path):
+			issues = []string{fmt.Sprintf("%s of protected path %s", input.ToolName, path)}
+		case *projectOnly && path != "" && !utils.WithinRoot(projectDir, input.Cwd, path):
+			issues = []string{fmt.Sprintf("%s of %s, which resolves outside the project root %s", input.ToolName, path, projectDir)}
+		}
+	case "Read":
+		if protector.IsSecret(input.ToolInput.FilePath) {
+			issues = []string{fmt.Sprintf("Read of secret %s", input.ToolInput.FilePath)}
+		}
+	case "Grep":
+		if protector.ContainsSecret(input.ToolInput.Path) {
+			issues = []string{fmt.Sprintf("Grep of %s, which contains secret files", input.ToolInput.Path)}
+		}
+	}
+	logger.Debug("checked tool call", "tool", input.ToolName, "issues", issues)
+
+	if len(issues) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "self-protect",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  settings.BlockDecision(),
+		Issues:    issues,
+		Command:   input.ToolInput.Command,
+		FilePath:  input.ToolInput.FilePath,
+	})
+	settings.Output().BlockPreToolUse("Configuration is protected! Ask the user to make this change.", issues)
+}
+
+// claudeConfigDir returns the Claude Code user config directory: $CLAUDE_CONFIG_DIR or ~/.claude.
+func claudeConfigDir() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".claude")
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `self-protect: Hook configuration guard for Claude Code hooks
+
+Blocks Bash commands and Edit/MultiEdit/Write/NotebookEdit calls that would
+modify Claude Code's hook configuration, so Claude cannot disable its own
+guardrails. Protected paths:
+
+    <project>/.claude/settings.json, settings.local.json, and hooks/
+    ~/.claude/settings.json, settings.local.json, and hooks/ ($CLAUDE_CONFIG_DIR)
+    Managed settings in /etc/claude-code and /Library/Application Support/ClaudeCode
+    Policy files in %s, ~/.config/claudecode-hooks, and any %s
+    Hook state in ~/.cache/claudecode-hooks: grants (-grant-dir), caches, and counters
+    This hook binary
+
+The hooks grant key (-grant-key) may not be read either, by Bash commands
+or the Read and Grep tools, since reading it is enough to forge a grant for
+bash-block -allow-once.
+
+Symlinks and ../ traversal are resolved before paths are matched. Bash
+commands may read protected paths with read-only commands such as cat,
+grep, or jq. Commands that change whole directory trees, such as rm, mv,
+or find -delete, are also blocked for directories above a protected path,
+such as ~/.claude. Variables set to a fixed value and cd within the
+command are followed; file-changing commands and redirections given paths
+not known before they run are blocked. "hooks install" and "hooks
+self-update" are blocked because they replace the hook binaries.
+
+USAGE:
+    self-protect [-protect PATH ...] [-preset NAME ...] [-project-only] [OPTIONS]
+
+OPTIONAL:
+    -protect string
+            Additional path to protect, relative to the project directory
+            or starting with ~/ (can be specified multiple times). A
+            trailing /** protects a whole directory.
+
+    -preset string
+            Additional set of paths to protect (can be specified multiple
+            times):
+%s
+    -project-only
+            Also block Edit/MultiEdit/Write/NotebookEdit of files that
+            resolve outside the project root, after following symlinks and
+            ../ traversal
+
+    -grant-dir string
+            Directory of hooks grant approvals to protect; set it, or
+            CLAUDE_HOOKS_GRANT_DIR, to match bash-block -grant-dir
+            (default: %s)
+
+    -grant-key string
+            Grant key file to protect from reads and writes; set it, or
+            CLAUDE_HOOKS_GRANT_KEY, to match bash-block -grant-key
+            (default: %s)
+
+    -fail-mode string
+            Behavior when input or the command cannot be parsed: closed
+            (block) or open (allow) (default: closed)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every blocked call to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_SELF_PROTECT_<FLAG> to target only this hook.
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Bash|Edit|MultiEdit|Write|NotebookEdit|Read|Grep",
+        "hooks": [{"type": "command", "command": "/path/to/self-protect"}]
+      }
+    ]
+  }
+}
+
+`, config.SystemConfigDir, config.ProjectFileName, presetUsage(), grant.DefaultDir(), grant.DefaultKeyPath())
+}
+
+// presetUsage lists the presets that protect paths for showUsage.
+func presetUsage() string {
+	var b strings.Builder
+	for _, name := range pathPresetNames() {
+		preset, _ := detector.LookupPreset(name)
+		fmt.Fprintf(&b, "              %s\n", name)
+		for paths := range slices.Chunk(preset.Redirects, 4) {
+			fmt.Fprintf(&b, "                  %s\n", strings.Join(paths, " "))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,251 @@
+package selfprotect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/krmcbride/claudecode-hooks/internal/grant"
+)
+
+func newTestProtector(t *testing.T) (protector *Protector, project, claudeDir string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	project = filepath.Join(home, "project")
+	claudeDir = filepath.Join(home, ".claude")
+	return NewProtector(project, project, claudeDir, grant.DefaultDir(), grant.DefaultKeyPath(), []string{"scripts/hooks/**"}), project, claudeDir
+}
+
+func TestIsProtected(t *testing.T) {
+	protector, project, claudeDir := newTestProtector(t)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{".claude/settings.json", true},
+		{filepath.Join(project, ".claude", "settings.local.json"), true},
+		{filepath.Join(project, ".claude", "hooks", "krmcbride-bash-block"), true},
+		{filepath.Join(claudeDir, "settings.json"), true},
+		{"~/.claude/hooks/bin/krmcbride-hooks", true},
+		{"~/.config/claudecode-hooks/policy.yaml", true},
+		{"sub/dir/.claudehooks.yaml", true},
+		{"scripts/hooks/pre-commit", true},
+		{".claude/commands/review.md", false},
+		{"main.go", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := protector.IsProtected(tt.path); got != tt.want {
+				t.Errorf("IsProtected(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsProtected_Symlink(t *testing.T) {
+	protector, project, claudeDir := newTestProtector(t)
+	if err := os.MkdirAll(claudeDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(project, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	settings := filepath.Join(claudeDir, "settings.json")
+	if err := os.WriteFile(settings, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(settings, filepath.Join(project, "innocent.json")); err != nil {
+		t.Fatal(err)
+	}
+	if !protector.IsProtected("innocent.json") {
+		t.Error("IsProtected() should follow symlinks into protected paths")
+	}
+
+	// A file not yet created in a linked directory, and ../ out of the project
+	if err := os.Symlink(claudeDir, filepath.Join(project, "config")); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"config/hooks/new-hook", "config/../.claude/settings.json", "src/../../.claude/settings.json"} {
+		if !protector.IsProtected(path) {
+			t.Errorf("IsProtected(%q) = false, want true", path)
+		}
+	}
+}
+
+func TestIsSecret(t *testing.T) {
+	protector, project, _ := newTestProtector(t)
+
+	if !protector.IsSecret("~/.config/claudecode-hooks/grant.key") {
+		t.Error("IsSecret(grant key) = false, want true")
+	}
+	if protector.IsSecret("~/.config/claudecode-hooks/policy.yaml") {
+		t.Error("IsSecret(policy) = true, want false")
+	}
+	for _, path := range []string{"~/.config/claudecode-hooks", "~/.config", "~"} {
+		if !protector.ContainsSecret(path) {
+			t.Errorf("ContainsSecret(%q) = false, want true", path)
+		}
+	}
+	if protector.ContainsSecret(project) {
+		t.Errorf("ContainsSecret(%q) = true, want false", project)
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	protector, project, _ := newTestProtector(t)
+	// Globs match existing files
+	if err := os.MkdirAll(filepath.Join(project, ".claude"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	keyPath := grant.DefaultKeyPath()
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		blocked bool
+	}{
+		{"read settings", "cat .claude/settings.json | jq .hooks", false},
+		{"unrelated", "go test ./... && git status", false},
+		{"remove settings", "rm .claude/settings.json", true},
+		{"redirect", `echo '{}' > ~/.claude/settings.json`, true},
+		{"home variable", `sed -i 's/bash-block/true/' "$HOME/.claude/settings.json"`, true},
+		{"append to policy", "printf 'rules: []' >> .claudehooks.yaml", true},
+		{"nested shell", `bash -c "rm -rf ~/.claude/hooks"`, true},
+		{"flag value", "cp --target-directory=.claude/hooks evil", true},
+		{"replace hook binary", "chmod -x ~/.claude/hooks/krmcbride-bash-block", true},
+		{"hooks install", "krmcbride-hooks install -merge", true},
+		{"hooks version", "krmcbride-hooks version", false},
+		{"read from protected path", "jq . < .claude/settings.json", false},
+		{"remove project config dir", "rm -rf .claude", true},
+		{"move user config dir", "mv ~/.claude /tmp/x", true},
+		{"remove policy dir", "rm -rf ~/.config/claudecode-hooks", true},
+		{"find delete above protected path", "find ~/.claude -delete", true},
+		{"git checkout protected path", "git checkout -- .claude/settings.json", true},
+		{"git clean ignored files", "git clean -fdx", true},
+		{"copy into home", "cp -r dotfiles/. ~", true},
+		{"copy from project", "cp -r . /tmp/backup", false},
+		{"git add project", "git add .", false},
+		{"find without actions", "find . -name '*.go'", false},
+		{"remove build dir", "rm -rf build", false},
+		{"yq read policy", "yq '.rules' .claudehooks.yaml", false},
+		{"yq in place", "yq -i '.rules=[]' .claudehooks.yaml", true},
+		{"yq in place long", "yq --inplace=true '.rules=[]' .claudehooks.yaml", true},
+		{"yq in place short group", "yq -Pi '.rules=[]' .claudehooks.yaml", true},
+		{"variable set in script", `d=~/.claude; rm -rf "$d"`, true},
+		{"variable set in script unrelated", `d=build; rm -rf "$d"`, false},
+		{"cd into protected dir", "cd ~/.claude && rm settings.json", true},
+		{"cd then relative path", "cd src && rm -f generated.go", false},
+		{"cd to unknown dir", `cd "$(mktemp -d)" && rm -f settings.json`, true},
+		{"loop variable", `for f in ~/.claude/*; do rm -f "$f"; done`, true},
+		{"command substitution operand", "rm -rf $(dirname ~/.claude/settings.json)", true},
+		{"glob over protected dir", "rm -rf .c*", true},
+		{"hook state", "rm -rf ~/.cache/claudecode-hooks", true},
+		{"dynamic redirection", `f=$(mktemp); echo '{}' > "$f"`, true},
+		{"stderr to stdout", "go build ./... 2>&1 | head", false},
+		{"commit message substitution", `git commit -m "$(date)"`, false},
+		{"loop over source files", `for f in *.go; do gofmt -l "$f"; done`, false},
+		{"environment variable", `rm -rf "$HOME/tmp/build"`, false},
+		{"read grant key", "cat ~/.config/claudecode-hooks/grant.key", true},
+		{"read grant key by redirection", "base64 < ~/.config/claudecode-hooks/grant.key", true},
+		{"read grant key by glob", "head ~/.config/claudecode-hooks/*.key", true},
+		{"forge grant", `echo '{"expires":"2999-01-01T00:00:00Z"}' > ~/.cache/claudecode-hooks/grants/x.json`, true},
+		{"read user policy", "cat ~/.config/claudecode-hooks/policy.yaml", false},
+		{"copy forged grant", "cp forged.json ~/.cache/claudecode-hooks/grants/x.json", true},
+		{"replace grant key", "mv my.key ~/.config/claudecode-hooks/grant.key", true},
+		{"brace expansion removes config dir", "rm -rf {.claude,x}", true},
+		{"brace expansion in file name", "rm .claude/settings.{json,x}", true},
+		{"brace expansion in directory name", "tee .claude/{settings,x}.json", true},
+		{"brace expansion of parent", "rm {.claude,x}/settings.json", true},
+		{"brace expansion move destination", "mv x .claude/{settings,}.json", true},
+		{"brace expansion redirection", "echo '{}' > .claude/settings.{json,x}", true},
+		{"brace expansion unrelated", "rm -f build/{a,b}.o", false},
+		{"python writes settings", `python3 -c "open('.claude/settings.json', 'w').write('{}')"`, true},
+		{"python removes config dir", `python3 -c "import shutil; shutil.rmtree('.claude')"`, true},
+		{"node writes settings", `node -e "require('fs').writeFileSync('.claude/settings.json', '{}')"`, true},
+		{"node eval attached", `node --eval="fs.rmSync('$HOME/.claude', {recursive: true})"`, true},
+		{"python reads grant key", `python3 -c "print(open('$HOME/.config/claudecode-hooks/grant.key').read())"`, true},
+		{"python unrelated", `python3 -c "import os; print(os.listdir('.'), '/'.join(['a', 'b']))"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := protector.CheckCommand(tt.command)
+			if err != nil {
+				t.Fatalf("CheckCommand() error: %v", err)
+			}
+			if blocked := len(issues) > 0; blocked != tt.blocked {
+				t.Errorf("CheckCommand(%q) issues = %q, want blocked = %v", tt.command, issues, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestProtector_GrantPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := filepath.Join(home, "project")
+	protector := NewProtector(project, project, filepath.Join(home, ".claude"), "~/grants", filepath.Join(home, "keys", "grant.key"), nil)
+
+	tests := []struct {
+		command string
+		blocked bool
+	}{
+		{"tee ~/grants/x.json < forged.json", true},
+		{"cp forged.json " + filepath.Join(home, "grants", "x.json"), true},
+		{"rm -rf ~/grants", true},
+		{"echo key > ~/keys/grant.key", true},
+		{"cat ~/keys/grant.key", true},
+		{"ls ~/grants", false},
+		{"cp x.json ~/other/x.json", false},
+	}
+	for _, tt := range tests {
+		issues, err := protector.CheckCommand(tt.command)
+		if err != nil {
+			t.Fatalf("CheckCommand() error: %v", err)
+		}
+		if blocked := len(issues) > 0; blocked != tt.blocked {
+			t.Errorf("CheckCommand(%q) issues = %q, want blocked = %v", tt.command, issues, tt.blocked)
+		}
+	}
+	if !protector.IsSecret("~/keys/grant.key") {
+		t.Error("IsSecret(grant key) = false, want true")
+	}
+}
+
+func TestPresetPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	paths, err := presetPaths([]string{"shell-rc"})
+	if err != nil {
+		t.Fatalf("presetPaths() error: %v", err)
+	}
+	project := filepath.Join(home, "project")
+	protector := NewProtector(project, project, filepath.Join(home, ".claude"), "", "", paths)
+
+	for _, path := range []string{"~/.bashrc", filepath.Join(home, ".zshrc"), "$HOME/.gitconfig", "~/.config/fish/config.fish"} {
+		if !protector.IsProtected(path) {
+			t.Errorf("IsProtected(%q) = false with the shell-rc preset", path)
+		}
+	}
+	if protector.IsProtected(".bashrc") {
+		t.Error("IsProtected(.bashrc) = true for a file in the project")
+	}
+	issues, err := protector.CheckCommand("echo 'alias ls=rm' >> ~/.bashrc")
+	if err != nil || len(issues) == 0 {
+		t.Errorf("CheckCommand(append to ~/.bashrc) = %q, %v, want blocked", issues, err)
+	}
+
+	if _, err := presetPaths([]string{"git-push"}); err == nil {
+		t.Error("presetPaths() with a preset that protects no paths should fail")
+	}
+}
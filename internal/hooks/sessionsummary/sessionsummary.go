@@ -0,0 +1,344 @@
+// Package sessionsummary implements the session-summary hook, which records
+// what Claude does in a session and summarizes it as markdown when Claude stops.
+package sessionsummary
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/notify"
+	"github.com/krmcbride/claudecode-hooks/internal/sessionstats"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// sessionPlaceholder in -output is replaced by the session ID.
+const sessionPlaceholder = "{session_id}"
+
+// maxCommandLength truncates long commands in the summary.
+const maxCommandLength = 120
+
+// editTools are the tools whose file path counts as an edited file.
+var editTools = []string{"Edit", "MultiEdit", "Write", "NotebookEdit"}
+
+// webhookPayload is the JSON posted to -webhook.
+type webhookPayload struct {
+	Hook      string             `json:"hook"`
+	SessionID string             `json:"session_id"`
+	Cwd       string             `json:"cwd,omitempty"`
+	Summary   string             `json:"summary"` // Markdown
+	Stats     sessionstats.Stats `json:"stats"`
+	Blocks    int                `json:"blocks"`
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	output := flag.String("output", "", "Write the markdown summary to this file when Claude stops; "+sessionPlaceholder+" is replaced by the session ID")
+	webhook := flag.String("webhook", "", "POST the summary as JSON to this URL when Claude stops")
+	stateDir := flag.String("state-dir", sessionstats.DefaultDir(), "Directory for per-session stats")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailOpen}
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Audit log written by the other hooks, read for the session's blocked tool calls")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "session-summary"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "session-summary", hook.EventPostToolUse, hook.EventStop)
+	manifest := config.Manifest{
+		Hook:   "session-summary",
+		Events: []string{hook.EventPostToolUse, hook.EventStop},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+
+	if *showHelp || (*output == "" && *webhook == "") {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	store := sessionstats.NewStore(*stateDir)
+
+	hook.Dispatch(map[string]hook.Handler{
+		hook.EventPostToolUse: func(data []byte) { recordToolCall(settings, store, data) },
+		hook.EventStop:        func(data []byte) { summarize(settings, store, data, *output, *webhook) },
+	})
+}
+
+// recordToolCall adds a PostToolUse tool call to the session's stats.
+func recordToolCall(settings *config.Settings, store *sessionstats.Store, data []byte) {
+	input, err := hook.DecodePostToolUseInput(bytes.NewReader(data), settings.InputOptions())
+	if err != nil {
+		hook.NonBlockingError(fmt.Sprintf("session-summary: failed to parse hook input: %v", err))
+	}
+	// PostToolUseInput decodes only file_path from tool_input
+	var toolInput struct {
+		ToolInput struct {
+			Command      string `json:"command"`
+			NotebookPath string `json:"notebook_path"`
+		} `json:"tool_input"`
+	}
+	_ = json.Unmarshal(data, &toolInput) //nolint:errcheck // Already decoded successfully above
+	command := ""
+	if input.ToolName == "Bash" {
+		command = toolInput.ToolInput.Command
+	}
+	file := ""
+	if slices.Contains(editTools, input.ToolName) {
+		file = cmp.Or(input.ToolInput.FilePath, toolInput.ToolInput.NotebookPath)
+	}
+	if _, err := store.Update(input.SessionID, input.Cwd, func(stats *sessionstats.Stats) {
+		stats.Add(input.ToolName, command, file)
+	}); err != nil {
+		hook.NonBlockingError(fmt.Sprintf("session-summary: %v", err))
+	}
+	hook.AllowPostToolUse()
+}
+
+// summarize writes or posts the session's summary when Claude stops.
+func summarize(settings *config.Settings, store *sessionstats.Store, data []byte, output, webhook string) {
+	logger := settings.Logger()
+	input, err := hook.DecodeStopInput(bytes.NewReader(data), settings.InputOptions())
+	if err != nil {
+		hook.NonBlockingError(fmt.Sprintf("session-summary: failed to parse hook input: %v", err))
+	}
+	stats, err := store.Load(input.SessionID)
+	if err != nil {
+		hook.NonBlockingError(fmt.Sprintf("session-summary: %v", err))
+	}
+	if stats.Cwd == "" {
+		stats.Cwd = input.Cwd
+	}
+	blocks, err := sessionBlocks(settings.AuditLog, input.SessionID)
+	if err != nil {
+		logger.Warn("reading audit log for blocks", "error", err)
+	}
+	summary := renderSummary(stats, blocks)
+
+	var failures []string
+	if output != "" {
+		path := strings.ReplaceAll(output, sessionPlaceholder, input.SessionID)
+		if err := writeSummary(path, summary); err != nil {
+			failures = append(failures, err.Error())
+		} else {
+			logger.Debug("wrote session summary", "path", path)
+		}
+	}
+	if webhook != "" {
+		payload := webhookPayload{
+			Hook:      "session-summary",
+			SessionID: input.SessionID,
+			Cwd:       stats.Cwd,
+			Summary:   summary,
+			Stats:     stats,
+			Blocks:    len(blocks),
+		}
+		if err := notify.NewWebhook(webhook).Post(context.Background(), payload); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		hook.NonBlockingError("session-summary: " + strings.Join(failures, "; "))
+	}
+	hook.Exit(hook.ExitSuccess)
+}
+
+// sessionBlocks returns the blocked tool calls of the session recorded in the
+// audit log at path. An empty path yields none.
+func sessionBlocks(path, sessionID string) ([]audit.Record, error) {
+	if path == "" {
+		return nil, nil
+	}
+	records, err := audit.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var blocks []audit.Record
+	for _, record := range records {
+		if record.SessionID == sessionID && record.Decision == audit.DecisionBlock {
+			blocks = append(blocks, record)
+		}
+	}
+	return blocks, nil
+}
+
+// renderSummary formats the session's stats and blocks as markdown.
+func renderSummary(stats sessionstats.Stats, blocks []audit.Record) string {
+	var sb strings.Builder
+	sb.WriteString("# Claude Code session summary\n\n")
+	fmt.Fprintf(&sb, "- Session: %s\n", codeSpan(stats.SessionID))
+	if stats.Cwd != "" {
+		fmt.Fprintf(&sb, "- Directory: %s\n", codeSpan(stats.Cwd))
+	}
+	if !stats.Started.IsZero() {
+		fmt.Fprintf(&sb, "- Active: %s to %s (%s)\n",
+			stats.Started.Local().Format(time.DateTime), stats.Updated.Local().Format(time.DateTime),
+			stats.Updated.Sub(stats.Started).Round(time.Second))
+	}
+	fmt.Fprintf(&sb, "- Tool calls: %d%s\n", stats.TotalToolCalls(), toolBreakdown(stats.ToolCalls))
+	fmt.Fprintf(&sb, "- Files edited: %d\n", len(stats.FilesEdited))
+	fmt.Fprintf(&sb, "- Commands run: %d\n", stats.CommandCount)
+	fmt.Fprintf(&sb, "- Blocked: %d\n", len(blocks))
+
+	if len(stats.FilesEdited) > 0 {
+		sb.WriteString("\n## Files edited\n\n")
+		for _, file := range stats.FilesEdited {
+			if rel, err := filepath.Rel(stats.Cwd, file); err == nil && stats.Cwd != "" && !strings.HasPrefix(rel, "..") {
+				file = rel
+			}
+			fmt.Fprintf(&sb, "- %s\n", codeSpan(file))
+		}
+	}
+	if len(stats.Commands) > 0 {
+		sb.WriteString("\n## Commands run\n\n")
+		for _, command := range stats.Commands {
+			fmt.Fprintf(&sb, "- %s\n", codeSpan(shorten(command)))
+		}
+		if more := stats.CommandCount - len(stats.Commands); more > 0 {
+			fmt.Fprintf(&sb, "- ... and %d more\n", more)
+		}
+	}
+	if len(blocks) > 0 {
+		sb.WriteString("\n## Blocked\n\n")
+		for _, block := range blocks {
+			target := cmp.Or(block.Command, block.FilePath)
+			reason := block.Reason
+			if reason == "" && len(block.Issues) > 0 {
+				reason = block.Issues[0]
+			}
+			line := "- " + block.Hook
+			if target != "" {
+				line += ": " + codeSpan(shorten(target))
+			}
+			if reason != "" {
+				line += " - " + reason
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// toolBreakdown formats tool call counts as " (Bash 3, Edit 2)", most used first.
+func toolBreakdown(calls map[string]int) string {
+	if len(calls) == 0 {
+		return ""
+	}
+	tools := make([]string, 0, len(calls))
+	for tool := range calls {
+		tools = append(tools, tool)
+	}
+	slices.SortFunc(tools, func(a, b string) int {
+		return cmp.Or(cmp.Compare(calls[b], calls[a]), strings.Compare(a, b))
+	})
+	parts := make([]string, len(tools))
+	for i, tool := range tools {
+		parts[i] = fmt.Sprintf("%s %d", tool, calls[tool])
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// shorten keeps the first line of text, up to maxCommandLength characters.
+func shorten(text string) string {
+	line, _, multiline := strings.Cut(text, "\n")
+	if len(line) > maxCommandLength {
+		return line[:maxCommandLength] + "..."
+	}
+	if multiline {
+		return line + " ..."
+	}
+	return line
+}
+
+// codeSpan formats text as inline markdown code, fencing with double
+// backticks when text contains one.
+func codeSpan(text string) string {
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
+
+// writeSummary writes summary to path, creating its directory.
+func writeSummary(path string, summary string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating summary directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(summary), 0o600); err != nil {
+		return fmt.Errorf("writing summary: %w", err)
+	}
+	return nil
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `session-summary: Summarize Claude Code sessions
+
+Records the tool calls of a session (PostToolUse) and, whenever Claude
+stops responding (Stop), writes a markdown summary of the files edited,
+commands run, and tool calls other hooks blocked.
+
+USAGE:
+    session-summary [-output FILE] [-webhook URL] [OPTIONS]
+
+OUTPUT (at least one is required):
+    -output string
+            Write the markdown summary to this file. %[1]s is
+            replaced by the session ID, e.g. ~/.claude/summaries/%[1]s.md
+
+    -webhook string
+            POST the summary as JSON (session_id, summary, stats, blocks)
+            to this URL
+
+OPTIONAL:
+    -audit-log string
+            Audit log written by the other hooks; its blocks for the session
+            are listed in the summary
+
+    -state-dir string
+            Directory for per-session stats (default: %[2]s)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Report payloads that do not match the expected schema as errors
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_SESSION_SUMMARY_<FLAG> to target only this hook.
+
+EXAMPLES:
+    # Configure under both PostToolUse (matcher ".*") and Stop
+    session-summary -output "$HOME/.claude/summaries/%[1]s.md"
+
+    # Post summaries to a team webhook
+    session-summary -webhook https://hooks.example.com/claude
+
+`, sessionPlaceholder, sessionstats.DefaultDir())
+}
@@ -0,0 +1,92 @@
+package sessionsummary
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/sessionstats"
+)
+
+func TestRenderSummary(t *testing.T) {
+	started := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	stats := sessionstats.Stats{
+		SessionID: "s1",
+		Cwd:       "/repo",
+		Started:   started,
+		Updated:   started.Add(90 * time.Second),
+	}
+	stats.Add("Bash", "go test ./...", "")
+	stats.Add("Bash", "echo `date`", "")
+	stats.Add("Edit", "", "/repo/main.go")
+	stats.Add("Edit", "", "/elsewhere/notes.md")
+	stats.Add("Read", "", "")
+	blocks := []audit.Record{
+		{Hook: "bash-block", Decision: audit.DecisionBlock, Command: "git push origin main", Issues: []string{"Blocked git push"}},
+		{Hook: "self-protect", Decision: audit.DecisionBlock, FilePath: "/repo/.claude/settings.json", Reason: "Protected hook configuration"},
+	}
+
+	got := renderSummary(stats, blocks)
+	for _, want := range []string{
+		"- Session: `s1`\n",
+		"(1m30s)\n",
+		"- Tool calls: 5 (Bash 2, Edit 2, Read 1)\n",
+		"- Blocked: 2\n",
+		"## Files edited\n\n- `/elsewhere/notes.md`\n- `main.go`\n",
+		"## Commands run\n\n- `go test ./...`\n- `` echo `date` ``\n",
+		"- bash-block: `git push origin main` - Blocked git push\n",
+		"- self-protect: `/repo/.claude/settings.json` - Protected hook configuration\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderSummary() missing %q:\n%s", want, got)
+		}
+	}
+
+	if empty := renderSummary(sessionstats.Stats{SessionID: "s2"}, nil); strings.Contains(empty, "##") {
+		t.Errorf("renderSummary() of an empty session has sections:\n%s", empty)
+	}
+}
+
+func TestSessionBlocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger := audit.NewLogger(path)
+	for _, record := range []audit.Record{
+		{Hook: "bash-block", SessionID: "s1", Decision: audit.DecisionBlock, Command: "git push"},
+		{Hook: "bash-block", SessionID: "s1", Decision: audit.DecisionAllow, Command: "ls"},
+		{Hook: "bash-block", SessionID: "s2", Decision: audit.DecisionBlock, Command: "rm -rf /"},
+	} {
+		if err := logger.Log(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blocks, err := sessionBlocks(path, "s1")
+	if err != nil {
+		t.Fatalf("sessionBlocks() error: %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].Command != "git push" {
+		t.Errorf("sessionBlocks() = %+v, want the one block of s1", blocks)
+	}
+
+	if blocks, err := sessionBlocks("", "s1"); err != nil || blocks != nil {
+		t.Errorf("sessionBlocks() without an audit log = %v, %v", blocks, err)
+	}
+}
+
+func TestShorten(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"ls", "ls"},
+		{"cat <<EOF\nhello\nEOF", "cat <<EOF ..."},
+		{strings.Repeat("a", maxCommandLength+1), strings.Repeat("a", maxCommandLength) + "..."},
+	}
+	for _, tt := range tests {
+		if got := shorten(tt.text); got != tt.want {
+			t.Errorf("shorten(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
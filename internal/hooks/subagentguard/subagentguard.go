@@ -0,0 +1,291 @@
+// Package subagentguard implements the subagent-guard hook, which restricts the subagent types Claude may spawn with the Task tool and caps how many run per session
+package subagentguard
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/internal/subagents"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// defaultSubagentType is the subagent a Task call without a subagent_type runs.
+const defaultSubagentType = "general-purpose"
+
+// listFlag allows multiple -allow-type and -deny-type flags to be specified
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Repeatable marks the flag as a list for CLAUDE_HOOKS_* environment binding.
+func (l *listFlag) Repeatable() bool {
+	return true
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	var allowTypes, denyTypes listFlag
+	flag.Var(&allowTypes, "allow-type", "Subagent type Claude may spawn, e.g. Explore; others are refused (can be specified multiple times)")
+	flag.Var(&denyTypes, "deny-type", "Subagent type Claude may not spawn (can be specified multiple times)")
+	limits := subagents.Limits{}
+	flag.IntVar(&limits.MaxConcurrent, "max-concurrent", 0, "Subagents allowed to run at once per session (0 = unlimited)")
+	flag.IntVar(&limits.MaxTotal, "max-total", 0, "Subagents allowed per session (0 = unlimited)")
+	action := flag.String("action", hook.PermissionDeny, "What to do with a refused Task call: ask or deny")
+	stateDir := flag.String("state-dir", subagents.DefaultDir(), "Directory for per-session subagent counts")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailOpen}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input cannot be parsed or state cannot be updated: closed (block) or open (allow)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every refused Task call to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "subagent-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "subagent-guard", hook.EventPreToolUse, hook.EventPostToolUse)
+	manifest := config.Manifest{
+		Hook:   "subagent-guard",
+		Events: []string{hook.EventPreToolUse, hook.EventPostToolUse},
+		Tools:  []string{"Task"},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	unconfigured := len(allowTypes) == 0 && len(denyTypes) == 0 && limits == (subagents.Limits{})
+	if *showHelp || unconfigured {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if *action != hook.PermissionAsk && *action != hook.PermissionDeny {
+		fmt.Fprintf(os.Stderr, "Error: invalid action '%s'. Must be ask or deny\n", *action)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if limits.MaxConcurrent < 0 || limits.MaxTotal < 0 {
+		fmt.Fprintf(os.Stderr, "Error: limits must not be negative\n")
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	store := subagents.NewStore(*stateDir)
+	hook.Dispatch(map[string]hook.Handler{
+		hook.EventPreToolUse: func(data []byte) {
+			checkTask(settings, store, data, allowTypes, denyTypes, limits, *action)
+		},
+		hook.EventPostToolUse: func(data []byte) { finishTask(settings, store, data, limits) },
+	})
+}
+
+// checkTask refuses a Task call whose subagent type is not allowed or that
+// would go over the limits, and otherwise counts the subagent as started.
+func checkTask(settings *config.Settings, store *subagents.Store, data []byte, allowTypes, denyTypes []string, limits subagents.Limits, action string) {
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	input, err := hook.DecodePreToolUseInput(bytes.NewReader(data), settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "subagent-guard", "Failed to parse hook input", err)
+		return
+	}
+	if input.ToolName != "Task" {
+		hook.AllowPreToolUse()
+		return
+	}
+	subagentType := input.ToolInput.SubagentType
+	if subagentType == "" {
+		subagentType = defaultSubagentType
+	}
+
+	reason := checkType(subagentType, allowTypes, denyTypes)
+	hint := "Use an allowed subagent type, or do the work without a subagent."
+	if reason == "" {
+		hint = "Do the work without a subagent, or wait for running subagents to finish."
+	}
+	var counts subagents.Counts
+	if limits != (subagents.Limits{}) {
+		if reason == "" {
+			counts, reason, err = store.Start(input.SessionID, limits)
+			if err != nil {
+				hookutil.FailInternal(settings, auditLog, "subagent-guard", "Failed to update subagent state", err)
+				return
+			}
+		}
+		if reason != "" && action == hook.PermissionAsk {
+			// The user may approve the call; count it in case they do
+			if counts, _, err = store.Start(input.SessionID, subagents.Limits{}); err != nil {
+				logger.Warn("counting subagent", "error", err)
+			}
+		}
+	}
+	logger.Debug("checked subagent", "type", subagentType, "running", counts.Running, "total", counts.Total, "reason", reason)
+	if reason == "" {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	reason = "Subagent not allowed: " + reason
+	decision := audit.DecisionAllow
+	if action == hook.PermissionDeny {
+		decision = settings.BlockDecision()
+		reason += ". " + hint
+	}
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "subagent-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  decision,
+		Reason:    reason,
+	})
+	settings.Output().DecidePreToolUse(action, reason)
+}
+
+// finishTask counts the subagent of a finished Task call as no longer
+// running.
+func finishTask(settings *config.Settings, store *subagents.Store, data []byte, limits subagents.Limits) {
+	input, err := hook.DecodePostToolUseInput(bytes.NewReader(data), settings.InputOptions())
+	if err != nil {
+		hook.NonBlockingError(fmt.Sprintf("subagent-guard: failed to parse hook input: %v", err))
+	}
+	if input.ToolName != "Task" || limits == (subagents.Limits{}) {
+		hook.AllowPostToolUse()
+		return
+	}
+	counts, err := store.Finish(input.SessionID)
+	if err != nil {
+		hook.NonBlockingError(fmt.Sprintf("subagent-guard: %v", err))
+	}
+	settings.Logger().Debug("subagent finished", "running", counts.Running, "total", counts.Total)
+	hook.AllowPostToolUse()
+}
+
+// checkType returns why a subagent type may not be spawned, or "" if it may:
+// it is denied, or allowed types are listed and it is not one of them.
+func checkType(subagentType string, allowTypes, denyTypes []string) string {
+	if slices.Contains(denyTypes, subagentType) {
+		return fmt.Sprintf("%s subagents are denied", subagentType)
+	}
+	if len(allowTypes) > 0 && !slices.Contains(allowTypes, subagentType) {
+		return fmt.Sprintf("%s is not an allowed subagent type (allowed: %s)", subagentType, strings.Join(allowTypes, ", "))
+	}
+	return ""
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `subagent-guard: Subagent restrictions for Claude Code hooks
+
+Restricts the subagents Claude spawns with the Task tool: which types it may
+use, and how many may run at once or in total per session. A Task call
+without a subagent_type runs a %s subagent.
+
+Running subagents are counted from their PreToolUse Task call until their
+PostToolUse one, so register the hook for both events when -max-concurrent
+is set. A subagent whose Task call never finishes, e.g. because it was
+interrupted, stops counting as running after an hour.
+
+USAGE:
+    subagent-guard [-allow-type TYPE ...] [-deny-type TYPE ...] [-max-concurrent N] [-max-total N] [OPTIONS]
+
+RESTRICTIONS (at least one must be set):
+    -allow-type string
+            Subagent type Claude may spawn, e.g. Explore; others are refused
+            (can be specified multiple times)
+
+    -deny-type string
+            Subagent type Claude may not spawn (can be specified multiple times)
+
+    -max-concurrent int
+            Subagents allowed to run at once per session (default: 0, unlimited)
+
+    -max-total int
+            Subagents allowed per session (default: 0, unlimited)
+
+OPTIONAL:
+    -action string
+            Decision for a refused Task call: ask (prompt the user) or deny
+            (default: deny)
+
+    -state-dir string
+            Directory for per-session subagent counts (default: %s)
+
+    -fail-mode string
+            Behavior when input cannot be parsed or state cannot be updated:
+            closed (block) or open (allow) (default: open)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+            Unknown fields are logged at debug level.
+
+    -audit-log string
+            Append a JSONL record of every refused Task call to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_SUBAGENT_GUARD_<FLAG> to target only this hook. Separate
+    multiple -allow-type or -deny-type values with semicolons.
+
+EXAMPLES:
+    # Only read-only exploration subagents, at most 3 at a time
+    subagent-guard -allow-type Explore -allow-type Plan -max-concurrent 3
+
+    # Ask before the 21st subagent of a session
+    subagent-guard -max-total 20 -action ask
+
+CLAUDE CODE CONFIGURATION:
+Add to your Claude Code settings.json:
+
+{
+  "hooks": {
+    "PreToolUse": [
+      {
+        "matcher": "Task",
+        "hooks": [{"type": "command", "command": "/path/to/subagent-guard -max-concurrent 3"}]
+      }
+    ],
+    "PostToolUse": [
+      {
+        "matcher": "Task",
+        "hooks": [{"type": "command", "command": "/path/to/subagent-guard -max-concurrent 3"}]
+      }
+    ]
+  }
+}
+
+`, defaultSubagentType, subagents.DefaultDir())
+}
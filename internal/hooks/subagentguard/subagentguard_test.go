@@ -0,0 +1,29 @@
+package subagentguard
+
+import "testing"
+
+func TestCheckType(t *testing.T) {
+	tests := []struct {
+		name         string
+		subagentType string
+		allow, deny  []string
+		want         bool // Allowed
+	}{
+		{"no restrictions", "general-purpose", nil, nil, true},
+		{"allowed", "Explore", []string{"Explore", "Plan"}, nil, true},
+		{"not allowed", "general-purpose", []string{"Explore", "Plan"}, nil, false},
+		{"denied", "general-purpose", nil, []string{"general-purpose"}, false},
+		{"not denied", "Explore", nil, []string{"general-purpose"}, true},
+		{"deny wins over allow", "Explore", []string{"Explore"}, []string{"Explore"}, false},
+		{"case sensitive", "explore", []string{"Explore"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := checkType(tt.subagentType, tt.allow, tt.deny)
+			if got := reason == ""; got != tt.want {
+				t.Errorf("checkType(%q) = %q, want allowed %v", tt.subagentType, reason, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Package usageguard implements the usage-guard hook, which reins in runaway
+// sessions once their token or tool call budget is spent.
+package usageguard
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/internal/hookutil"
+	"github.com/krmcbride/claudecode-hooks/internal/transcript"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// actionWarn lets the tool call proceed and shows the reason to the user.
+const actionWarn = "warn"
+
+// budget is the usage a session may reach. Zero disables a limit.
+type budget struct {
+	MaxTokens       int
+	MaxOutputTokens int
+	MaxToolCalls    int
+}
+
+// exceeded returns a reason per limit usage goes over.
+func (b budget) exceeded(usage transcript.Usage) []string {
+	var reasons []string
+	if b.MaxTokens > 0 && usage.TotalTokens() > b.MaxTokens {
+		reasons = append(reasons, fmt.Sprintf("%d tokens used (budget %d)", usage.TotalTokens(), b.MaxTokens))
+	}
+	if b.MaxOutputTokens > 0 && usage.OutputTokens > b.MaxOutputTokens {
+		reasons = append(reasons, fmt.Sprintf("%d output tokens used (budget %d)", usage.OutputTokens, b.MaxOutputTokens))
+	}
+	if b.MaxToolCalls > 0 && usage.ToolCalls > b.MaxToolCalls {
+		reasons = append(reasons, fmt.Sprintf("%d tool calls made (budget %d)", usage.ToolCalls, b.MaxToolCalls))
+	}
+	return reasons
+}
+
+// Main runs the hook with os.Args and exits the process with its decision.
+func Main() {
+	// Parse command-line flags
+	limits := budget{}
+	flag.IntVar(&limits.MaxTokens, "max-tokens", 0, "Tokens the session may use, counting input, output, and cache tokens (0 = unlimited)")
+	flag.IntVar(&limits.MaxOutputTokens, "max-output-tokens", 0, "Output tokens the session may use (0 = unlimited)")
+	flag.IntVar(&limits.MaxToolCalls, "max-tool-calls", 0, "Tool calls the session may make (0 = unlimited)")
+	action := flag.String("action", hook.PermissionAsk, "What to do with tool calls once a budget is exceeded: warn, ask, or deny")
+	showHelp := flag.Bool("help", false, "Show help message")
+	printProtocol := flag.Bool("print-protocol", false, "Print the supported hook protocol as JSON and exit")
+	describe := flag.Bool("describe", false, "Print a JSON manifest of the hook (events, tools, flags) and exit")
+	emitConfig := flag.Bool("emit-config", false, "Print the recommended settings.json entries for these flags and exit")
+
+	settings := &config.Settings{FailMode: config.FailOpen}
+	flag.Var(&settings.FailMode, "fail-mode", "Behavior when input or the transcript cannot be read: closed (block) or open (allow)")
+	flag.StringVar(&settings.LogLevel, "log-level", "warn", "Log level for diagnostics on stderr: debug, info, warn, error")
+	flag.StringVar(&settings.AuditLog, "audit-log", "", "Append a JSONL record of every decision to this file")
+	flag.BoolVar(&settings.StrictInput, "strict-input", false, "Fail on hook payloads that do not match the expected schema")
+	config.RegisterOutputFlags(flag.CommandLine, settings)
+
+	// Environment variables provide defaults; command-line flags take precedence
+	if err := config.BindEnv(flag.CommandLine, "usage-guard"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	flag.Parse()
+	hook.ExitWithProtocol(*printProtocol, "usage-guard", hook.EventPreToolUse, hook.EventStop)
+	manifest := config.Manifest{
+		Hook:   "usage-guard",
+		Events: []string{hook.EventPreToolUse, hook.EventStop},
+	}
+	config.ExitWithManifest(*describe, flag.CommandLine, manifest)
+	config.ExitWithSettings(*emitConfig, manifest)
+	if err := settings.ValidateOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	if *showHelp || limits == (budget{}) {
+		showUsage()
+		if *showHelp {
+			hook.Exit(hook.ExitSuccess)
+		}
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if *action != actionWarn && *action != hook.PermissionAsk && *action != hook.PermissionDeny {
+		fmt.Fprintf(os.Stderr, "Error: invalid action '%s'. Must be warn, ask, or deny\n", *action)
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+	if limits.MaxTokens < 0 || limits.MaxOutputTokens < 0 || limits.MaxToolCalls < 0 {
+		fmt.Fprintf(os.Stderr, "Error: budgets must not be negative\n")
+		hook.Exit(hook.ExitNonBlockingError)
+	}
+
+	logger := settings.Logger()
+	auditLog := audit.NewLogger(settings.AuditLog)
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "usage-guard", "Failed to read hook input", err)
+		return
+	}
+
+	// When Claude stops, tell the user the session is over budget
+	if hook.EventName(data) == hook.EventStop {
+		input, err := hook.DecodeStopInput(bytes.NewReader(data), settings.InputOptions())
+		if err != nil {
+			hook.NonBlockingError(fmt.Sprintf("usage-guard: failed to parse hook input: %v", err))
+		}
+		usage, err := transcript.ReadUsage(input.TranscriptPath)
+		if err != nil {
+			hook.NonBlockingError(fmt.Sprintf("usage-guard: %v", err))
+		}
+		if reasons := limits.exceeded(usage); len(reasons) > 0 {
+			hook.NonBlockingError("Session over budget: " + strings.Join(reasons, "; "))
+		}
+		hook.Exit(hook.ExitSuccess)
+	}
+
+	input, err := hook.DecodePreToolUseInput(bytes.NewReader(data), settings.InputOptions())
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "usage-guard", "Failed to parse hook input", err)
+		return
+	}
+	usage, err := transcript.ReadUsage(input.TranscriptPath)
+	if err != nil {
+		hookutil.FailInternal(settings, auditLog, "usage-guard", "Failed to read the session transcript", err)
+		return
+	}
+	logger.Debug("session usage", "tokens", usage.TotalTokens(), "output_tokens", usage.OutputTokens, "tool_calls", usage.ToolCalls)
+
+	reasons := limits.exceeded(usage)
+	if len(reasons) == 0 {
+		hook.AllowPreToolUse()
+		return
+	}
+
+	reason := "Session over budget: " + strings.Join(reasons, "; ")
+	decision := audit.DecisionAllow
+	if *action == hook.PermissionDeny {
+		decision = settings.BlockDecision()
+	}
+	hookutil.WriteAudit(auditLog, audit.Record{
+		Hook:      "usage-guard",
+		Event:     hook.EventPreToolUse,
+		SessionID: input.SessionID,
+		ToolName:  input.ToolName,
+		Decision:  decision,
+		Reason:    reason,
+		Command:   input.ToolInput.Command,
+	})
+	if *action == actionWarn {
+		hook.NonBlockingError(reason)
+	}
+	settings.Output().DecidePreToolUse(*action, reason)
+}
+
+// showUsage displays usage information
+func showUsage() {
+	fmt.Fprintf(os.Stderr, `usage-guard: Token and tool call budgets for Claude Code sessions
+
+Reads the session transcript to total the tokens used and tool calls made,
+and once a budget is exceeded asks before (or denies, or warns about) every
+further tool call, to rein in runaway agent loops. As a Stop hook it tells
+the user when a finished session went over budget.
+
+USAGE:
+    usage-guard [-max-tokens N] [-max-output-tokens N] [-max-tool-calls N] [OPTIONS]
+
+BUDGETS (at least one is required):
+    -max-tokens int
+            Tokens the session may use, counting input, output, cache write,
+            and cache read tokens (default: 0, unlimited)
+
+    -max-output-tokens int
+            Output tokens the session may use (default: 0, unlimited)
+
+    -max-tool-calls int
+            Tool calls the session may make (default: 0, unlimited)
+
+OPTIONAL:
+    -action string
+            Decision for tool calls once a budget is exceeded: warn (allow and
+            tell the user), ask (prompt the user), or deny (default: ask)
+
+    -fail-mode string
+            Behavior when input or the transcript cannot be read:
+            closed (block) or open (allow) (default: open)
+
+    -log-level string
+            Log level for diagnostics on stderr: debug, info, warn, error (default: warn)
+
+    -strict-input
+            Fail (according to -fail-mode) on payloads that do not match the
+            expected schema, e.g. a wrong hook_event_name or missing fields.
+
+    -quiet
+            Print a single-line block reason: the headline and first issue
+
+    -verbose
+            Print every issue, the matched commands, and rule descriptions
+
+    -warn-only
+            Allow what would be blocked and show the user a warning instead,
+            for trying the hook out in advisory mode
+
+    -audit-log string
+            Append a JSONL record of every over-budget tool call to this file
+
+    -help
+            Show this help message
+
+ENVIRONMENT:
+    Every flag can also be set with a CLAUDE_HOOKS_<FLAG> variable, or
+    CLAUDE_HOOKS_USAGE_GUARD_<FLAG> to target only this hook.
+
+EXAMPLES:
+    # Ask before every tool call after 500 in a session
+    usage-guard -max-tool-calls 500
+
+    # Deny tool calls once a session has produced 200k output tokens
+    usage-guard -max-output-tokens 200000 -action deny
+
+`)
+}
@@ -0,0 +1,32 @@
+package usageguard
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/krmcbride/claudecode-hooks/internal/transcript"
+)
+
+func TestBudget_Exceeded(t *testing.T) {
+	usage := transcript.Usage{ToolCalls: 50, InputTokens: 1000, OutputTokens: 4000, CacheReadTokens: 95000}
+
+	tests := []struct {
+		name   string
+		budget budget
+		want   []string
+	}{
+		{"no budget", budget{}, nil},
+		{"under budgets", budget{MaxTokens: 100000, MaxOutputTokens: 4000, MaxToolCalls: 50}, nil},
+		{"over token budget", budget{MaxTokens: 99999}, []string{"100000 tokens used (budget 99999)"}},
+		{"over output token budget", budget{MaxOutputTokens: 3000}, []string{"4000 output tokens used (budget 3000)"}},
+		{"over two budgets", budget{MaxOutputTokens: 3000, MaxToolCalls: 10}, []string{"4000 output tokens used (budget 3000)", "50 tool calls made (budget 10)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.budget.exceeded(usage)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("exceeded() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package hookutil provides the failure handling and audit helpers every hook
+// in internal/hooks shares.
+package hookutil
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+	"github.com/krmcbride/claudecode-hooks/internal/config"
+	"github.com/krmcbride/claudecode-hooks/pkg/hook"
+)
+
+// StdinIsTerminal reports whether stdin is an interactive terminal rather than
+// a hook payload pipe.
+func StdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// WriteAudit appends a decision to the audit log. Failures are reported but
+// never affect the decision.
+func WriteAudit(auditLog *audit.Logger, record audit.Record) {
+	if err := auditLog.Log(record); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+}
+
+// FailInternal handles an error that prevents the PreToolUse hook name from
+// evaluating a tool call according to the fail mode: fail closed blocks the
+// tool call, fail open allows it.
+func FailInternal(settings *config.Settings, auditLog *audit.Logger, name, message string, err error) {
+	if auditFailure(settings, auditLog, name, hook.EventPreToolUse, message, err) {
+		return
+	}
+	// Security tool must fail secure - block on internal errors
+	settings.Output().BlockPreToolUse(config.FailureMessage(message, err), []string{err.Error()})
+}
+
+// FailInternalPostToolUse is FailInternal for PostToolUse hooks, whose tool
+// call already ran: fail closed reports the failure to Claude, followed by
+// advice, if any, on how to treat the output that went unchecked.
+func FailInternalPostToolUse(settings *config.Settings, auditLog *audit.Logger, name, message string, err error, advice string) {
+	if auditFailure(settings, auditLog, name, hook.EventPostToolUse, message, err) {
+		return
+	}
+	reason := config.FailureMessage(message, err) + ": " + err.Error()
+	if advice != "" {
+		reason += ". " + advice
+	}
+	hook.BlockPostToolUse(reason)
+}
+
+// auditFailure records the failure and, when the fail mode is open, warns and
+// exits. It reports whether the failure was handled.
+func auditFailure(settings *config.Settings, auditLog *audit.Logger, name, event, message string, err error) bool {
+	decision := settings.BlockDecision()
+	if settings.FailMode == config.FailOpen {
+		decision = audit.DecisionAllow
+	}
+	WriteAudit(auditLog, audit.Record{
+		Hook:      name,
+		Event:     event,
+		Decision:  decision,
+		Reason:    message,
+		ErrorKind: config.ErrorKind(err),
+		Issues:    []string{err.Error()},
+	})
+
+	if settings.FailMode == config.FailOpen {
+		fmt.Fprintf(os.Stderr, "Warning: %s (allowing, fail mode is open): %v\n", message, err)
+		hook.Exit(config.FailOpenExitCode(err))
+		return true
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package hookutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/krmcbride/claudecode-hooks/internal/audit"
+)
+
+func TestWriteAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	WriteAudit(audit.NewLogger(path), audit.Record{Hook: "bash-block", Decision: audit.DecisionAllow})
+	// A disabled log is not an error
+	WriteAudit(audit.NewLogger(""), audit.Record{Hook: "bash-block"})
+
+	records, err := audit.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	if len(records) != 1 || records[0].Hook != "bash-block" {
+		t.Errorf("audit records = %+v, want the bash-block record", records)
+	}
+}
@@ -0,0 +1,172 @@
+// Package instances keeps the per-project registry that lets one long-lived
+// hooks serve process evaluate payloads for many Claude Code projects, each
+// under its own rules.
+//
+// An instance is a small JSON file holding a project directory and the hook
+// flags for it, named after the SHA-256 of the directory. Payloads are routed
+// by their cwd to the instance of the innermost registered project containing
+// it, so a nested project can override the repository around it.
+package instances
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+)
+
+// Instance is the configuration one registered project evaluates with.
+type Instance struct {
+	Project string   `json:"project"`         // Absolute project directory
+	Flags   []string `json:"flags,omitempty"` // Hook flags, e.g. -policy .claude/policy.yaml
+}
+
+// Registry persists instances in a directory.
+type Registry struct {
+	dir string
+}
+
+// NewRegistry creates a Registry keeping instance files in dir.
+func NewRegistry(dir string) *Registry {
+	return &Registry{dir: dir}
+}
+
+// DefaultDir returns the default registry directory: hooks/instances in
+// $CLAUDE_CONFIG_DIR, or ~/.claude.
+func DefaultDir() string {
+	base := os.Getenv("CLAUDE_CONFIG_DIR")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		base = filepath.Join(home, ".claude")
+	}
+	return filepath.Join(base, "hooks", "instances")
+}
+
+// Dir returns the registry directory.
+func (r *Registry) Dir() string {
+	return r.dir
+}
+
+// Register records the flags for a project, replacing any earlier
+// registration of the same directory. Relative directories resolve against
+// the working directory.
+func (r *Registry) Register(project string, flags []string) (Instance, error) {
+	project, err := filepath.Abs(project)
+	if err != nil {
+		return Instance{}, err
+	}
+	if info, err := os.Stat(project); err != nil {
+		return Instance{}, fmt.Errorf("project directory: %w", err)
+	} else if !info.IsDir() {
+		return Instance{}, fmt.Errorf("project directory: %s is not a directory", project)
+	}
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		return Instance{}, fmt.Errorf("creating registry directory: %w", err)
+	}
+
+	instance := Instance{Project: project, Flags: flags}
+	data, err := json.MarshalIndent(instance, "", "  ")
+	if err != nil {
+		return Instance{}, fmt.Errorf("encoding instance: %w", err)
+	}
+	path := r.path(project)
+	unlock, err := utils.LockFile(path + ".lock")
+	if err != nil {
+		return Instance{}, err
+	}
+	defer unlock()
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return Instance{}, fmt.Errorf("writing instance: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return Instance{}, fmt.Errorf("writing instance: %w", err)
+	}
+	return instance, nil
+}
+
+// Remove unregisters a project. It reports whether the project was registered.
+func (r *Registry) Remove(project string) (bool, error) {
+	project, err := filepath.Abs(project)
+	if err != nil {
+		return false, err
+	}
+	err = os.Remove(r.path(project))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("removing instance: %w", err)
+	}
+	_ = os.Remove(r.path(project) + ".lock") //nolint:errcheck // Best-effort cleanup
+	return true, nil
+}
+
+// List returns the registered instances ordered by project directory. A
+// missing registry has none; unreadable or corrupt instance files are
+// skipped, so one broken file never takes down evaluation for the others.
+func (r *Registry) List() ([]Instance, error) {
+	entries, err := os.ReadDir(r.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading registry: %w", err)
+	}
+	var instances []Instance
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.dir, entry.Name())) // #nosec G304 - path is within the registry directory
+		if err != nil {
+			continue
+		}
+		var instance Instance
+		if json.Unmarshal(data, &instance) != nil || !filepath.IsAbs(instance.Project) {
+			continue
+		}
+		instance.Project = filepath.Clean(instance.Project)
+		instances = append(instances, instance)
+	}
+	slices.SortFunc(instances, func(a, b Instance) int { return strings.Compare(a.Project, b.Project) })
+	return instances, nil
+}
+
+// Lookup returns the instance of the innermost registered project containing
+// cwd, if any.
+func (r *Registry) Lookup(cwd string) (Instance, bool, error) {
+	if !filepath.IsAbs(cwd) {
+		return Instance{}, false, nil
+	}
+	instances, err := r.List()
+	if err != nil {
+		return Instance{}, false, err
+	}
+	cwd = filepath.Clean(cwd)
+	var found Instance
+	for _, instance := range instances {
+		if pathmatch.Within(instance.Project, cwd) && len(instance.Project) > len(found.Project) {
+			found = instance
+		}
+	}
+	return found, found.Project != "", nil
+}
+
+// path maps a project directory to its instance file.
+func (r *Registry) path(project string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(project)))
+	return filepath.Join(r.dir, hex.EncodeToString(sum[:8])+".json")
+}
@@ -0,0 +1,85 @@
+package instances
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(filepath.Join(t.TempDir(), "instances"))
+	root := t.TempDir()
+	app := filepath.Join(root, "app")
+	nested := filepath.Join(app, "vendor", "lib")
+	other := filepath.Join(root, "application")
+	for _, dir := range []string{nested, other} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if list, err := registry.List(); err != nil || len(list) != 0 {
+		t.Fatalf("List() of a missing registry = %v, %v, want none", list, err)
+	}
+	for project, flags := range map[string][]string{app: {"-preset", "git-push"}, nested: {"-preset", "git-config"}, other: nil} {
+		if _, err := registry.Register(project, flags); err != nil {
+			t.Fatalf("Register(%s) error: %v", project, err)
+		}
+	}
+	// Registering again replaces the flags
+	if _, err := registry.Register(app, []string{"-policy", ".claude/policy.yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.Register(filepath.Join(root, "missing"), nil); err == nil {
+		t.Error("Register() of a missing directory succeeded")
+	}
+	// Corrupt files are skipped
+	if err := os.WriteFile(filepath.Join(registry.Dir(), "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := registry.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var projects []string
+	for _, instance := range list {
+		projects = append(projects, instance.Project)
+	}
+	if want := []string{app, nested, other}; !slices.Equal(projects, want) {
+		t.Errorf("List() projects = %q, want %q", projects, want)
+	}
+
+	tests := []struct {
+		cwd         string
+		wantProject string
+		wantFlags   []string
+	}{
+		{app, app, []string{"-policy", ".claude/policy.yaml"}},
+		{filepath.Join(app, "src"), app, []string{"-policy", ".claude/policy.yaml"}},
+		{filepath.Join(nested, "src"), nested, []string{"-preset", "git-config"}},
+		{other, other, nil},
+		{root, "", nil},
+		{"relative/path", "", nil},
+	}
+	for _, tt := range tests {
+		got, ok, err := registry.Lookup(tt.cwd)
+		if err != nil {
+			t.Fatalf("Lookup(%s) error: %v", tt.cwd, err)
+		}
+		if ok != (tt.wantProject != "") || got.Project != tt.wantProject || !slices.Equal(got.Flags, tt.wantFlags) {
+			t.Errorf("Lookup(%s) = %+v, %v, want %s %q", tt.cwd, got, ok, tt.wantProject, tt.wantFlags)
+		}
+	}
+
+	if removed, err := registry.Remove(nested); err != nil || !removed {
+		t.Fatalf("Remove() = %v, %v, want true", removed, err)
+	}
+	if removed, err := registry.Remove(nested); err != nil || removed {
+		t.Errorf("Remove() again = %v, %v, want false", removed, err)
+	}
+	if got, _, _ := registry.Lookup(filepath.Join(nested, "src")); got.Project != app { //nolint:errcheck // Checked above
+		t.Errorf("Lookup() after Remove() = %s, want %s", got.Project, app)
+	}
+}
@@ -0,0 +1,118 @@
+// Package metrics provides optional activity metrics for Claude Code hooks.
+//
+// Hooks are short-lived processes, so a Recorder accumulates the events of a
+// single invocation in memory and hands them to the configured sinks when
+// Flush is called. Two sinks are available:
+//   - A Prometheus textfile (for node_exporter's textfile collector) that is
+//     merged with the existing file so counters accumulate across invocations
+//   - StatsD over UDP
+//
+// When no sink is configured the Recorder is a cheap no-op.
+package metrics
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// Environment variables used to enable metrics emission.
+const (
+	EnvTextfile   = "CLAUDE_HOOKS_METRICS_TEXTFILE" // Path of the Prometheus textfile to update
+	EnvStatsDAddr = "CLAUDE_HOOKS_STATSD_ADDR"      // host:port of a StatsD UDP listener
+)
+
+// Snapshot is the set of events recorded during a single hook invocation.
+type Snapshot struct {
+	Hook          string
+	Evaluations   int
+	Blocks        int
+	ParseFailures int
+	ShadowMatches int // Evaluations a shadow rule would have blocked
+	Latencies     []time.Duration
+}
+
+// Sink receives the recorded events when a Recorder is flushed.
+type Sink interface {
+	Emit(snapshot Snapshot) error
+}
+
+// Recorder counts evaluations, blocks, shadow rule matches, parse failures,
+// and latencies for a hook.
+type Recorder struct {
+	snapshot Snapshot
+	sinks    []Sink
+}
+
+// NewRecorder creates a Recorder for the named hook that emits to the given sinks.
+func NewRecorder(hook string, sinks ...Sink) *Recorder {
+	return &Recorder{
+		snapshot: Snapshot{Hook: hook},
+		sinks:    sinks,
+	}
+}
+
+// FromEnv creates a Recorder for the named hook using sinks configured through
+// the CLAUDE_HOOKS_METRICS_TEXTFILE and CLAUDE_HOOKS_STATSD_ADDR environment
+// variables. If neither is set the Recorder discards everything.
+func FromEnv(hook string) *Recorder {
+	var sinks []Sink
+	if path := os.Getenv(EnvTextfile); path != "" {
+		sinks = append(sinks, NewTextfileSink(path))
+	}
+	if addr := os.Getenv(EnvStatsDAddr); addr != "" {
+		sinks = append(sinks, NewStatsDSink(addr))
+	}
+	return NewRecorder(hook, sinks...)
+}
+
+// Enabled reports whether any sink is configured.
+func (r *Recorder) Enabled() bool {
+	return len(r.sinks) > 0
+}
+
+// Evaluation records one completed evaluation, its latency, and whether it blocked.
+func (r *Recorder) Evaluation(latency time.Duration, blocked bool) {
+	r.snapshot.Evaluations++
+	r.snapshot.Latencies = append(r.snapshot.Latencies, latency)
+	if blocked {
+		r.snapshot.Blocks++
+	}
+}
+
+// ShadowMatch records an evaluation a shadow rule would have blocked.
+func (r *Recorder) ShadowMatch() {
+	r.snapshot.ShadowMatches++
+}
+
+// ParseFailure records a failure to parse hook input or a shell expression.
+func (r *Recorder) ParseFailure() {
+	r.snapshot.ParseFailures++
+}
+
+// Snapshot returns a copy of the events recorded so far.
+func (r *Recorder) Snapshot() Snapshot {
+	s := r.snapshot
+	s.Latencies = append([]time.Duration(nil), r.snapshot.Latencies...)
+	return s
+}
+
+// Flush emits the recorded events to every sink and resets the Recorder.
+// Errors from individual sinks are joined; a failing sink does not prevent
+// the others from receiving the events.
+func (r *Recorder) Flush() error {
+	if !r.Enabled() {
+		return nil
+	}
+
+	snapshot := r.Snapshot()
+	r.snapshot = Snapshot{Hook: r.snapshot.Hook}
+
+	var errs []error
+	for _, sink := range r.sinks {
+		if err := sink.Emit(snapshot); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder_NoSinks(t *testing.T) {
+	recorder := NewRecorder("bash-block")
+	recorder.Evaluation(time.Millisecond, true)
+
+	if recorder.Enabled() {
+		t.Error("Recorder without sinks should not be enabled")
+	}
+	if err := recorder.Flush(); err != nil {
+		t.Errorf("Flush() without sinks returned error: %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvTextfile, "")
+	t.Setenv(EnvStatsDAddr, "")
+	if FromEnv("bash-block").Enabled() {
+		t.Error("FromEnv() with no variables set should be disabled")
+	}
+
+	t.Setenv(EnvTextfile, filepath.Join(t.TempDir(), "hooks.prom"))
+	if !FromEnv("bash-block").Enabled() {
+		t.Error("FromEnv() with textfile set should be enabled")
+	}
+}
+
+func TestTextfileSink_Accumulates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks.prom")
+
+	// Simulate two separate hook processes
+	for _, blocked := range []bool{true, false} {
+		recorder := NewRecorder("bash-block", NewTextfileSink(path))
+		recorder.Evaluation(3*time.Millisecond, blocked)
+		recorder.ShadowMatch()
+		if err := recorder.Flush(); err != nil {
+			t.Fatalf("Flush() error: %v", err)
+		}
+	}
+
+	recorder := NewRecorder("file-format", NewTextfileSink(path))
+	recorder.ParseFailure()
+	if err := recorder.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	content, err := os.ReadFile(path) // #nosec G304 - test file
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(content)
+
+	wantLines := []string{
+		`claudecode_hooks_evaluations_total{hook="bash-block"} 2`,
+		`claudecode_hooks_blocks_total{hook="bash-block"} 1`,
+		`claudecode_hooks_parse_failures_total{hook="file-format"} 1`,
+		`claudecode_hooks_shadow_matches_total{hook="bash-block"} 2`,
+		`claudecode_hooks_evaluation_duration_seconds_bucket{hook="bash-block",le="0.001"} 0`,
+		`claudecode_hooks_evaluation_duration_seconds_bucket{hook="bash-block",le="0.005"} 2`,
+		`claudecode_hooks_evaluation_duration_seconds_bucket{hook="bash-block",le="+Inf"} 2`,
+		`claudecode_hooks_evaluation_duration_seconds_count{hook="bash-block"} 2`,
+		`# TYPE claudecode_hooks_evaluation_duration_seconds histogram`,
+	}
+	for _, want := range wantLines {
+		if !strings.Contains(text, want+"\n") {
+			t.Errorf("textfile missing line %q\n%s", want, text)
+		}
+	}
+
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Error("lock file should be removed after flush")
+	}
+}
+
+func TestFormatStatsD(t *testing.T) {
+	snapshot := Snapshot{
+		Hook:          "bash-block",
+		Evaluations:   1,
+		Blocks:        1,
+		ParseFailures: 0,
+		Latencies:     []time.Duration{1500 * time.Microsecond},
+	}
+
+	got := formatStatsD(snapshot)
+	want := "claudecode_hooks.bash_block.evaluations:1|c\n" +
+		"claudecode_hooks.bash_block.blocks:1|c\n" +
+		"claudecode_hooks.bash_block.evaluation_ms:1.500|ms"
+	if got != want {
+		t.Errorf("formatStatsD() = %q, want %q", got, want)
+	}
+}
+
+func TestStatsDSink_Emit(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP not available: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	recorder := NewRecorder("bash-block", NewStatsDSink(conn.LocalAddr().String()))
+	recorder.Evaluation(time.Millisecond, false)
+	if err := recorder.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	buf := make([]byte, 1024)
+	if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("reading statsd packet: %v", err)
+	}
+	if !strings.HasPrefix(string(buf[:n]), "claudecode_hooks.bash_block.evaluations:1|c") {
+		t.Errorf("unexpected statsd payload %q", string(buf[:n]))
+	}
+}
@@ -0,0 +1,69 @@
+// Package metrics - StatsD UDP sink
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const statsdDialTimeout = 100 * time.Millisecond
+
+// StatsDSink sends events to a StatsD server over UDP. Metric names have the
+// form claudecode_hooks.<hook>.<metric> because plain StatsD has no tags.
+type StatsDSink struct {
+	Addr string
+}
+
+// NewStatsDSink creates a sink that sends to the StatsD server at addr (host:port).
+func NewStatsDSink(addr string) *StatsDSink {
+	return &StatsDSink{Addr: addr}
+}
+
+// Emit sends the snapshot as a single UDP datagram of newline-separated metrics.
+func (s *StatsDSink) Emit(snapshot Snapshot) error {
+	payload := formatStatsD(snapshot)
+	if payload == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), statsdDialTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("connecting to statsd: %w", err)
+	}
+	defer func() { _ = conn.Close() }() //nolint:errcheck // UDP close cannot meaningfully fail
+
+	if _, err := conn.Write([]byte(payload)); err != nil {
+		return fmt.Errorf("sending statsd metrics: %w", err)
+	}
+	return nil
+}
+
+// formatStatsD renders the snapshot in StatsD line protocol.
+func formatStatsD(snapshot Snapshot) string {
+	prefix := "claudecode_hooks." + strings.ReplaceAll(snapshot.Hook, "-", "_") + "."
+
+	var lines []string
+	if snapshot.Evaluations > 0 {
+		lines = append(lines, fmt.Sprintf("%sevaluations:%d|c", prefix, snapshot.Evaluations))
+	}
+	if snapshot.Blocks > 0 {
+		lines = append(lines, fmt.Sprintf("%sblocks:%d|c", prefix, snapshot.Blocks))
+	}
+	if snapshot.ShadowMatches > 0 {
+		lines = append(lines, fmt.Sprintf("%sshadow_matches:%d|c", prefix, snapshot.ShadowMatches))
+	}
+	if snapshot.ParseFailures > 0 {
+		lines = append(lines, fmt.Sprintf("%sparse_failures:%d|c", prefix, snapshot.ParseFailures))
+	}
+	for _, latency := range snapshot.Latencies {
+		lines = append(lines, fmt.Sprintf("%sevaluation_ms:%.3f|ms", prefix, float64(latency.Microseconds())/1000))
+	}
+	return strings.Join(lines, "\n")
+}
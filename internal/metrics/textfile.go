@@ -0,0 +1,245 @@
+// Package metrics - Prometheus textfile sink
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+)
+
+const metricPrefix = "claudecode_hooks_"
+
+// latencyBuckets are the histogram upper bounds (in seconds) for evaluation latency.
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// TextfileSink merges events into a Prometheus textfile suitable for the
+// node_exporter textfile collector. Because every hook invocation is a new
+// process, existing counter values are read back and incremented rather than
+// overwritten. Writes go through a lock file and an atomic rename so concurrent
+// hooks never produce a torn file.
+type TextfileSink struct {
+	Path string
+}
+
+// NewTextfileSink creates a sink that maintains the textfile at path.
+func NewTextfileSink(path string) *TextfileSink {
+	return &TextfileSink{Path: path}
+}
+
+// hookTotals holds the accumulated series for a single hook label value.
+type hookTotals struct {
+	evaluations   float64
+	blocks        float64
+	parseFailures float64
+	shadowMatches float64
+	buckets       []float64 // cumulative counts per latencyBuckets entry, plus +Inf
+	sum           float64
+}
+
+func newHookTotals() *hookTotals {
+	return &hookTotals{buckets: make([]float64, len(latencyBuckets)+1)}
+}
+
+// Emit merges the snapshot into the textfile.
+func (s *TextfileSink) Emit(snapshot Snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o750); err != nil {
+		return fmt.Errorf("creating metrics directory: %w", err)
+	}
+
+	unlock, err := utils.LockFile(s.Path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	totals, err := readTextfile(s.Path)
+	if err != nil {
+		return err
+	}
+
+	t, ok := totals[snapshot.Hook]
+	if !ok {
+		t = newHookTotals()
+		totals[snapshot.Hook] = t
+	}
+	t.evaluations += float64(snapshot.Evaluations)
+	t.blocks += float64(snapshot.Blocks)
+	t.parseFailures += float64(snapshot.ParseFailures)
+	t.shadowMatches += float64(snapshot.ShadowMatches)
+	for _, latency := range snapshot.Latencies {
+		seconds := latency.Seconds()
+		t.sum += seconds
+		for i, bound := range latencyBuckets {
+			if seconds <= bound {
+				t.buckets[i]++
+			}
+		}
+		t.buckets[len(latencyBuckets)]++
+	}
+
+	return writeTextfile(s.Path, totals)
+}
+
+// readTextfile parses the series previously written by writeTextfile.
+// Lines that don't belong to this package's metric families are ignored.
+func readTextfile(path string) (map[string]*hookTotals, error) {
+	totals := make(map[string]*hookTotals)
+
+	f, err := os.Open(path) // #nosec G304 - path is user-configured
+	if errors.Is(err, os.ErrNotExist) {
+		return totals, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading metrics textfile: %w", err)
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck // Read-only file
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, labels, value, ok := parseSeries(line)
+		if !ok {
+			continue
+		}
+		t, exists := totals[labels["hook"]]
+		if !exists {
+			t = newHookTotals()
+			totals[labels["hook"]] = t
+		}
+		applySeries(t, name, labels["le"], value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading metrics textfile: %w", err)
+	}
+	return totals, nil
+}
+
+// applySeries stores a parsed series value in the matching hookTotals field.
+func applySeries(t *hookTotals, name, le string, value float64) {
+	switch strings.TrimPrefix(name, metricPrefix) {
+	case "evaluations_total":
+		t.evaluations = value
+	case "blocks_total":
+		t.blocks = value
+	case "parse_failures_total":
+		t.parseFailures = value
+	case "shadow_matches_total":
+		t.shadowMatches = value
+	case "evaluation_duration_seconds_sum":
+		t.sum = value
+	case "evaluation_duration_seconds_bucket":
+		if le == "+Inf" {
+			t.buckets[len(latencyBuckets)] = value
+			return
+		}
+		bound, err := strconv.ParseFloat(le, 64)
+		if err != nil {
+			return
+		}
+		if i := slices.Index(latencyBuckets, bound); i >= 0 {
+			t.buckets[i] = value
+		}
+	}
+}
+
+// parseSeries splits a line such as `name{hook="x",le="0.1"} 3` into its parts.
+func parseSeries(line string) (name string, labels map[string]string, value float64, ok bool) {
+	sep := strings.LastIndexByte(line, ' ')
+	if sep < 0 {
+		return "", nil, 0, false
+	}
+	value, err := strconv.ParseFloat(line[sep+1:], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+
+	series := line[:sep]
+	labels = make(map[string]string)
+	if open := strings.IndexByte(series, '{'); open >= 0 && strings.HasSuffix(series, "}") {
+		for _, pair := range strings.Split(series[open+1:len(series)-1], ",") {
+			key, val, found := strings.Cut(pair, "=")
+			if found {
+				labels[key] = strings.Trim(val, `"`)
+			}
+		}
+		series = series[:open]
+	}
+	if !strings.HasPrefix(series, metricPrefix) {
+		return "", nil, 0, false
+	}
+	return series, labels, value, true
+}
+
+// writeTextfile renders all totals and atomically replaces the textfile.
+func writeTextfile(path string, totals map[string]*hookTotals) error {
+	hooks := make([]string, 0, len(totals))
+	for hook := range totals {
+		hooks = append(hooks, hook)
+	}
+	slices.Sort(hooks)
+
+	var sb strings.Builder
+	writeCounter(&sb, "evaluations_total", "Number of hook evaluations.", hooks, totals,
+		func(t *hookTotals) float64 { return t.evaluations })
+	writeCounter(&sb, "blocks_total", "Number of evaluations that blocked the tool call.", hooks, totals,
+		func(t *hookTotals) float64 { return t.blocks })
+	writeCounter(&sb, "parse_failures_total", "Number of hook input or shell parse failures.", hooks, totals,
+		func(t *hookTotals) float64 { return t.parseFailures })
+	writeCounter(&sb, "shadow_matches_total", "Number of evaluations a shadow rule would have blocked.", hooks, totals,
+		func(t *hookTotals) float64 { return t.shadowMatches })
+
+	name := metricPrefix + "evaluation_duration_seconds"
+	fmt.Fprintf(&sb, "# HELP %s Hook evaluation latency.\n# TYPE %s histogram\n", name, name)
+	for _, hook := range hooks {
+		t := totals[hook]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(&sb, "%s_bucket{hook=%q,le=%q} %s\n", name, hook, formatFloat(bound), formatFloat(t.buckets[i]))
+		}
+		count := t.buckets[len(latencyBuckets)]
+		fmt.Fprintf(&sb, "%s_bucket{hook=%q,le=\"+Inf\"} %s\n", name, hook, formatFloat(count))
+		fmt.Fprintf(&sb, "%s_sum{hook=%q} %s\n", name, hook, formatFloat(t.sum))
+		fmt.Fprintf(&sb, "%s_count{hook=%q} %s\n", name, hook, formatFloat(count))
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".metrics-*.prom")
+	if err != nil {
+		return fmt.Errorf("writing metrics textfile: %w", err)
+	}
+	if _, err := tmp.WriteString(sb.String()); err != nil {
+		_ = tmp.Close()           //nolint:errcheck // Already failing
+		_ = os.Remove(tmp.Name()) //nolint:errcheck // Best-effort cleanup
+		return fmt.Errorf("writing metrics textfile: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name()) //nolint:errcheck // Best-effort cleanup
+		return fmt.Errorf("writing metrics textfile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name()) //nolint:errcheck // Best-effort cleanup
+		return fmt.Errorf("writing metrics textfile: %w", err)
+	}
+	return nil
+}
+
+// writeCounter renders one counter family with a series per hook.
+func writeCounter(sb *strings.Builder, suffix, help string, hooks []string, totals map[string]*hookTotals, value func(*hookTotals) float64) {
+	name := metricPrefix + suffix
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, hook := range hooks {
+		fmt.Fprintf(sb, "%s{hook=%q} %s\n", name, hook, formatFloat(value(totals[hook])))
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
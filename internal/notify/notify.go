@@ -0,0 +1,74 @@
+// Package notify delivers hook notifications (such as blocked tool calls) to
+// the destinations configured in a policy file.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const webhookTimeout = 2 * time.Second
+
+// Event describes something a hook wants to report.
+type Event struct {
+	Hook      string   `json:"hook"`
+	Decision  string   `json:"decision"`
+	SessionID string   `json:"session_id,omitempty"`
+	ToolName  string   `json:"tool_name,omitempty"`
+	Cwd       string   `json:"cwd,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	Issues    []string `json:"issues,omitempty"`
+}
+
+// Webhook posts events as JSON to a URL.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhook creates a Webhook notifier for url with a short timeout so
+// notifications never noticeably delay the hook.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, Client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Send posts the event. An empty URL disables the webhook.
+func (w *Webhook) Send(ctx context.Context, event Event) error {
+	return w.Post(ctx, event)
+}
+
+// Post posts payload encoded as JSON. An empty URL disables the webhook.
+func (w *Webhook) Post(ctx context.Context, payload any) error {
+	if w == nil || w.URL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding notification: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending notification: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck // Response body is not used
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sending notification: webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhook_Send(t *testing.T) {
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event := Event{Hook: "bash-block", Decision: "block", Issues: []string{"Blocked git pattern detected"}}
+	if err := NewWebhook(server.URL).Send(context.Background(), event); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if got.Hook != "bash-block" || len(got.Issues) != 1 {
+		t.Errorf("webhook received %+v", got)
+	}
+}
+
+func TestWebhook_Disabled(t *testing.T) {
+	if err := NewWebhook("").Send(context.Background(), Event{}); err != nil {
+		t.Errorf("Send() with empty URL returned error: %v", err)
+	}
+}
+
+func TestWebhook_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewWebhook(server.URL).Send(context.Background(), Event{}); err == nil {
+		t.Error("Send() should report non-2xx responses")
+	}
+}
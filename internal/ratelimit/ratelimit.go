@@ -0,0 +1,86 @@
+// Package ratelimit counts risky tool calls per Claude Code session so hooks can
+// throttle runaway automation loops.
+//
+// State lives in a sessionstore JSON file per session, since each hook
+// invocation is a separate short-lived process.
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/sessionstore"
+)
+
+const (
+	// Window is the sliding window used for per-minute limits.
+	Window = time.Minute
+
+	// sessionTTL is how long an idle session's state is kept before cleanup.
+	sessionTTL = 24 * time.Hour
+)
+
+// Counts are the number of risky operations seen for a session.
+type Counts struct {
+	Session int // Since the session started
+	Window  int // Within the last minute
+}
+
+// Limits configures when a session is throttled. Zero disables a limit.
+type Limits struct {
+	MaxPerSession int
+	MaxPerMinute  int
+}
+
+// Exceeded reports whether counts go over the limits, with a human-readable reason.
+func (l Limits) Exceeded(c Counts) (bool, string) {
+	if l.MaxPerMinute > 0 && c.Window > l.MaxPerMinute {
+		return true, fmt.Sprintf("%d risky operations in the last minute (limit %d)", c.Window, l.MaxPerMinute)
+	}
+	if l.MaxPerSession > 0 && c.Session > l.MaxPerSession {
+		return true, fmt.Sprintf("%d risky operations this session (limit %d)", c.Session, l.MaxPerSession)
+	}
+	return false, ""
+}
+
+// sessionState is the on-disk state of one session.
+type sessionState struct {
+	Total  int         `json:"total"`
+	Recent []time.Time `json:"recent"`
+}
+
+// Store persists per-session counts in a directory.
+type Store struct {
+	state *sessionstore.Store[sessionState]
+	now   func() time.Time
+}
+
+// NewStore creates a Store keeping state files in dir.
+func NewStore(dir string) *Store {
+	return &Store{state: sessionstore.New[sessionState](dir, "rate limit state", sessionTTL), now: time.Now}
+}
+
+// DefaultDir returns the default state directory under the user cache directory.
+func DefaultDir() string {
+	return sessionstore.DefaultDir("rate-limit")
+}
+
+// Record counts one risky operation for the session and returns the updated counts.
+func (s *Store) Record(sessionID string) (Counts, error) {
+	state, err := s.state.Update(sessionID, func(state *sessionState) bool {
+		now := s.now()
+		recent := state.Recent[:0]
+		for _, t := range state.Recent {
+			if now.Sub(t) < Window {
+				recent = append(recent, t)
+			}
+		}
+		state.Recent = append(recent, now)
+		state.Total++
+		return true
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+	return Counts{Session: state.Total, Window: len(state.Recent)}, nil
+}
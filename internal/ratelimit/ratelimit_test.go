@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore_Record(t *testing.T) {
+	store := NewStore(t.TempDir())
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	for i := 1; i <= 3; i++ {
+		counts, err := store.Record("session-a")
+		if err != nil {
+			t.Fatalf("Record() error: %v", err)
+		}
+		if counts.Session != i || counts.Window != i {
+			t.Errorf("Record() #%d = %+v, want Session=%d Window=%d", i, counts, i, i)
+		}
+		now = now.Add(10 * time.Second)
+	}
+
+	// Other sessions are counted separately
+	counts, err := store.Record("session-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts.Session != 1 {
+		t.Errorf("Record() for new session = %+v, want Session=1", counts)
+	}
+
+	// Old operations fall out of the window but still count for the session
+	now = now.Add(2 * time.Minute)
+	counts, err = store.Record("session-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts.Session != 4 || counts.Window != 1 {
+		t.Errorf("Record() after window = %+v, want Session=4 Window=1", counts)
+	}
+}
+
+func TestLimits_Exceeded(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		counts Counts
+		want   bool
+	}{
+		{"no limits", Limits{}, Counts{Session: 100, Window: 100}, false},
+		{"under session limit", Limits{MaxPerSession: 5}, Counts{Session: 5, Window: 5}, false},
+		{"over session limit", Limits{MaxPerSession: 5}, Counts{Session: 6, Window: 1}, true},
+		{"under minute limit", Limits{MaxPerMinute: 3}, Counts{Session: 50, Window: 3}, false},
+		{"over minute limit", Limits{MaxPerMinute: 3}, Counts{Session: 4, Window: 4}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := tt.limits.Exceeded(tt.counts)
+			if got != tt.want {
+				t.Errorf("Exceeded() = %v, want %v", got, tt.want)
+			}
+			if got && reason == "" {
+				t.Error("Exceeded() should explain why the limit was hit")
+			}
+		})
+	}
+}
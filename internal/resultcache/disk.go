@@ -0,0 +1,123 @@
+// Package resultcache - on-disk cache
+package resultcache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// DefaultDiskEntries bounds the results a Disk cache keeps by default.
+const DefaultDiskEntries = 256
+
+// Disk is a cache holding up to a fixed number of results as small JSON files
+// in a directory, evicting the least recently used. Each result is written
+// atomically, so concurrent hook processes may share a directory.
+//
+// Anything that can write the directory can plant results, so callers should
+// only cache results that are safe to replay, such as blocks.
+type Disk[V any] struct {
+	dir  string
+	size int
+	now  func() time.Time
+}
+
+// NewDisk creates a Disk cache keeping up to size results in dir.
+func NewDisk[V any](dir string, size int) *Disk[V] {
+	return &Disk[V]{dir: dir, size: max(size, 1), now: time.Now}
+}
+
+// DefaultDir returns the default cache directory under the user cache directory.
+func DefaultDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "claudecode-hooks", "results")
+}
+
+// Get returns the result stored under key and whether there was one. A
+// missing or unreadable result is a miss.
+func (c *Disk[V]) Get(key string) (V, bool) {
+	var value V
+	path := c.path(key)
+	data, err := os.ReadFile(path) // #nosec G304 - path is derived from the cache directory
+	if err != nil || json.Unmarshal(data, &value) != nil {
+		var zero V
+		return zero, false
+	}
+	// The modification time records use, for eviction
+	now := c.now()
+	_ = os.Chtimes(path, now, now) //nolint:errcheck // Only affects eviction order
+	return value, true
+}
+
+// Put stores value under key, evicting the least recently used results when
+// the cache is full.
+func (c *Disk[V]) Put(key string, value V) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding cached result: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("creating result cache directory: %w", err)
+	}
+	path := c.path(key)
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("writing cached result: %w", err)
+	}
+	_, writeErr := tmp.Write(data)
+	if err := errors.Join(writeErr, tmp.Close()); err != nil {
+		_ = os.Remove(tmp.Name()) //nolint:errcheck // Best-effort cleanup
+		return fmt.Errorf("writing cached result: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name()) //nolint:errcheck // Best-effort cleanup
+		return fmt.Errorf("writing cached result: %w", err)
+	}
+	now := c.now()
+	_ = os.Chtimes(path, now, now) //nolint:errcheck // Only affects eviction order
+	c.evict()
+	return nil
+}
+
+// path maps a key to its result file. Keys are hex hashes from Key.
+func (c *Disk[V]) path(key string) string {
+	return filepath.Join(c.dir, filepath.Base(key)+".json")
+}
+
+// evict removes the least recently used results beyond the size limit.
+// Errors are ignored; an oversized cache only costs disk space.
+func (c *Disk[V]) evict() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	type result struct {
+		name string
+		used time.Time
+	}
+	var results []result
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			results = append(results, result{entry.Name(), info.ModTime()})
+		}
+	}
+	if len(results) <= c.size {
+		return
+	}
+	slices.SortFunc(results, func(a, b result) int {
+		return b.used.Compare(a.used)
+	})
+	for _, stale := range results[c.size:] {
+		_ = os.Remove(filepath.Join(c.dir, stale.name)) //nolint:errcheck // Best-effort eviction
+	}
+}
@@ -0,0 +1,123 @@
+// Package resultcache caches the results of evaluating commands, so a command
+// Claude retries after a block is not parsed and checked again.
+//
+// Results are keyed by a hash of everything that affects the evaluation (the
+// rules and detector settings) together with the command. LRU keeps results
+// in memory for long-lived hosts; Disk keeps them in a directory for hooks,
+// which run as a new process for every tool call.
+package resultcache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+)
+
+// Key identifies the result of evaluating command under the rules and
+// settings rulesHash stands for.
+func Key(rulesHash, command string) string {
+	sum := sha256.Sum256([]byte(rulesHash + "\x00" + command))
+	return hex.EncodeToString(sum[:])
+}
+
+// ruleView is the part of a detector rule that can be hashed. ArgMatchers
+// are functions, so flag-aware rules are told apart by name, as the matchers
+// of built-in presets only change with the binary.
+type ruleView struct {
+	Name       string               `json:"name,omitempty"`
+	Command    string               `json:"command"`
+	Patterns   []string             `json:"patterns,omitempty"`
+	Suggest    string               `json:"suggest,omitempty"`
+	Message    string               `json:"message,omitempty"`
+	Desc       string               `json:"description,omitempty"`
+	AllowAfter *detector.AllowAfter `json:"allow_after,omitempty"`
+	Matcher    bool                 `json:"matcher,omitempty"`
+}
+
+// RulesHash hashes rules together with settings, values that also affect
+// evaluation such as the dialect or environment. Settings must encode as JSON.
+func RulesHash(rules []detector.CommandRule, settings ...any) (string, error) {
+	views := make([]ruleView, len(rules))
+	for i, rule := range rules {
+		views[i] = ruleView{
+			Name:       rule.Name,
+			Command:    rule.BlockedCommand,
+			Patterns:   rule.BlockedPatterns,
+			Suggest:    rule.Suggest,
+			Message:    rule.Message,
+			Desc:       rule.Description,
+			AllowAfter: rule.AllowAfter,
+			Matcher:    rule.Match != nil,
+		}
+	}
+	data, err := json.Marshal(struct {
+		Rules    []ruleView `json:"rules"`
+		Settings []any      `json:"settings"`
+	}{views, settings})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// LRU is an in-memory cache holding up to a fixed number of results, evicting
+// the least recently used. It is safe for concurrent use.
+type LRU[V any] struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Of *entry[V], most recently used first
+	entries map[string]*list.Element
+}
+
+type entry[V any] struct {
+	key   string
+	value V
+}
+
+// NewLRU creates an LRU holding up to size results.
+func NewLRU[V any](size int) *LRU[V] {
+	return &LRU[V]{size: max(size, 1), order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get returns the result stored under key and whether there was one.
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*entry[V]).value, true
+}
+
+// Put stores value under key, evicting the least recently used result when
+// the cache is full.
+func (c *LRU[V]) Put(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*entry[V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry[V]).key)
+	}
+}
+
+// Len returns the number of results held.
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
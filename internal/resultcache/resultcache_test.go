@@ -0,0 +1,96 @@
+package resultcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/detector"
+)
+
+func TestRulesHash(t *testing.T) {
+	push := []detector.CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+	pull := []detector.CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"pull"}}}
+	matcher := []detector.CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}, Match: func(detector.Invocation) string { return "" }}}
+
+	base, err := RulesHash(push, "bash")
+	if err != nil {
+		t.Fatalf("RulesHash() error: %v", err)
+	}
+	if again, _ := RulesHash(push, "bash"); again != base {
+		t.Errorf("RulesHash() = %s, then %s for the same rules", base, again)
+	}
+	for name, rules := range map[string][]detector.CommandRule{"patterns": pull, "matcher": matcher} {
+		if other, _ := RulesHash(rules, "bash"); other == base {
+			t.Errorf("RulesHash() with different %s = %s, want a different hash", name, other)
+		}
+	}
+	if other, _ := RulesHash(push, "posix"); other == base {
+		t.Errorf("RulesHash() with different settings = %s, want a different hash", other)
+	}
+	if Key(base, "git push") == Key(base, "git pull") {
+		t.Error("Key() is the same for different commands")
+	}
+}
+
+func TestLRU(t *testing.T) {
+	cache := NewLRU[string](2)
+	cache.Put("a", "1")
+	cache.Put("b", "2")
+	if got, ok := cache.Get("a"); !ok || got != "1" {
+		t.Errorf("Get(a) = %q, %v, want 1, true", got, ok)
+	}
+	cache.Put("c", "3") // Evicts b, the least recently used
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Get(b) after eviction = true, want false")
+	}
+	for key, want := range map[string]string{"a": "1", "c": "3"} {
+		if got, ok := cache.Get(key); !ok || got != want {
+			t.Errorf("Get(%s) = %q, %v, want %s, true", key, got, ok, want)
+		}
+	}
+	cache.Put("c", "4")
+	if got, _ := cache.Get("c"); got != "4" || cache.Len() != 2 {
+		t.Errorf("Get(c) after update = %q with %d results, want 4 with 2", got, cache.Len())
+	}
+}
+
+func TestDisk(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "results")
+	cache := NewDisk[[]string](dir, 2)
+	clock := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	if _, ok := cache.Get(Key("rules", "git push")); ok {
+		t.Error("Get() on an empty cache = true, want false")
+	}
+	for _, command := range []string{"git push", "git pull"} {
+		if err := cache.Put(Key("rules", command), []string{command}); err != nil {
+			t.Fatalf("Put(%q) error: %v", command, err)
+		}
+	}
+	if got, ok := cache.Get(Key("rules", "git push")); !ok || len(got) != 1 || got[0] != "git push" {
+		t.Errorf("Get(git push) = %v, %v, want [git push], true", got, ok)
+	}
+	// git pull is now the least recently used
+	if err := cache.Put(Key("rules", "rm -rf /"), []string{"rm -rf /"}); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if _, ok := cache.Get(Key("rules", "git pull")); ok {
+		t.Error("Get(git pull) after eviction = true, want false")
+	}
+	if _, ok := cache.Get(Key("rules", "git push")); !ok {
+		t.Error("Get(git push) = false, want it kept as recently used")
+	}
+
+	if err := os.WriteFile(cache.path(Key("rules", "corrupt")), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(Key("rules", "corrupt")); ok {
+		t.Error("Get() of a corrupt result = true, want a miss")
+	}
+}
@@ -0,0 +1,106 @@
+// Package sessionstats accumulates what happens in a Claude Code session (tool
+// calls, files edited, commands run) so a hook can summarize it when Claude stops.
+//
+// Like the rate limiter's counters, state lives in a sessionstore JSON file
+// per session, since each hook invocation is a separate short-lived process.
+package sessionstats
+
+import (
+	"slices"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/sessionstore"
+)
+
+const (
+	// MaxCommands bounds the commands kept per session; later ones are only counted.
+	MaxCommands = 200
+
+	// sessionTTL is how long an idle session's state is kept before cleanup.
+	sessionTTL = 7 * 24 * time.Hour
+)
+
+// Stats are the accumulated activity of one session.
+type Stats struct {
+	SessionID    string         `json:"session_id"`
+	Cwd          string         `json:"cwd,omitempty"`
+	Started      time.Time      `json:"started"`
+	Updated      time.Time      `json:"updated"`
+	ToolCalls    map[string]int `json:"tool_calls"`
+	FilesEdited  []string       `json:"files_edited"` // Sorted, without duplicates
+	Commands     []string       `json:"commands"`     // The first MaxCommands, in order
+	CommandCount int            `json:"command_count"`
+}
+
+// Add records one tool call. command is the Bash command and file the file
+// an editing tool changed; either may be empty.
+func (s *Stats) Add(tool, command, file string) {
+	if s.ToolCalls == nil {
+		s.ToolCalls = make(map[string]int)
+	}
+	s.ToolCalls[tool]++
+	if command != "" {
+		s.CommandCount++
+		if len(s.Commands) < MaxCommands {
+			s.Commands = append(s.Commands, command)
+		}
+	}
+	if file != "" {
+		if i, found := slices.BinarySearch(s.FilesEdited, file); !found {
+			s.FilesEdited = slices.Insert(s.FilesEdited, i, file)
+		}
+	}
+}
+
+// TotalToolCalls is the number of tool calls of any kind.
+func (s *Stats) TotalToolCalls() int {
+	total := 0
+	for _, n := range s.ToolCalls {
+		total += n
+	}
+	return total
+}
+
+// Store persists per-session stats in a directory.
+type Store struct {
+	stats *sessionstore.Store[Stats]
+	now   func() time.Time
+}
+
+// NewStore creates a Store keeping state files in dir.
+func NewStore(dir string) *Store {
+	return &Store{stats: sessionstore.New[Stats](dir, "session stats", sessionTTL), now: time.Now}
+}
+
+// DefaultDir returns the default state directory under the user cache directory.
+func DefaultDir() string {
+	return sessionstore.DefaultDir("session-summary")
+}
+
+// Update applies update to the session's stats under a lock and saves them.
+func (s *Store) Update(sessionID, cwd string, update func(*Stats)) (Stats, error) {
+	return s.stats.Update(sessionID, func(stats *Stats) bool {
+		now := s.now()
+		if stats.Started.IsZero() {
+			*stats = Stats{SessionID: sessionID, Started: now}
+		}
+		if cwd != "" {
+			stats.Cwd = cwd
+		}
+		stats.Updated = now
+		update(stats)
+		return true
+	})
+}
+
+// Load returns the session's stats, or empty stats for a session not seen yet.
+func (s *Store) Load(sessionID string) (Stats, error) {
+	stats, err := s.stats.Load(sessionID)
+	if err != nil {
+		return Stats{}, err
+	}
+	if stats.SessionID == "" {
+		stats.SessionID = sessionID
+	}
+	return stats, nil
+}
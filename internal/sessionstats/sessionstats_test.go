@@ -0,0 +1,67 @@
+package sessionstats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStore_Update(t *testing.T) {
+	store := NewStore(t.TempDir())
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	store.now = func() time.Time { return now }
+
+	calls := []struct{ tool, command, file string }{
+		{"Bash", "go test ./...", ""},
+		{"Edit", "", "/repo/main.go"},
+		{"Write", "", "/repo/a.go"},
+		{"Edit", "", "/repo/main.go"},
+	}
+	for _, call := range calls {
+		if _, err := store.Update("session-a", "/repo", func(s *Stats) { s.Add(call.tool, call.command, call.file) }); err != nil {
+			t.Fatalf("Update() error: %v", err)
+		}
+		now = now.Add(time.Minute)
+	}
+
+	got, err := store.Load("session-a")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want := Stats{
+		SessionID:    "session-a",
+		Cwd:          "/repo",
+		Started:      start,
+		Updated:      start.Add(3 * time.Minute),
+		ToolCalls:    map[string]int{"Bash": 1, "Edit": 2, "Write": 1},
+		FilesEdited:  []string{"/repo/a.go", "/repo/main.go"},
+		Commands:     []string{"go test ./..."},
+		CommandCount: 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+	if got.TotalToolCalls() != 4 {
+		t.Errorf("TotalToolCalls() = %d, want 4", got.TotalToolCalls())
+	}
+
+	// Sessions not seen yet have empty stats
+	other, err := store.Load("session-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.SessionID != "session-b" || other.TotalToolCalls() != 0 {
+		t.Errorf("Load() for new session = %+v", other)
+	}
+}
+
+func TestStats_AddLimitsCommands(t *testing.T) {
+	var stats Stats
+	for range MaxCommands + 5 {
+		stats.Add("Bash", "ls", "")
+	}
+	if len(stats.Commands) != MaxCommands || stats.CommandCount != MaxCommands+5 {
+		t.Errorf("kept %d of %d commands, want %d of %d", len(stats.Commands), stats.CommandCount, MaxCommands, MaxCommands+5)
+	}
+}
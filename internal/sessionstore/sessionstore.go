@@ -0,0 +1,133 @@
+// Package sessionstore keeps a small JSON state file per Claude Code session
+// under a state directory, for hooks that count or accumulate across tool
+// calls. Each hook invocation is a separate short-lived process, so updates
+// take a cross-process lock and state files of idle sessions are removed.
+package sessionstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/utils"
+)
+
+// Store persists one state of type T, which must encode as JSON, per session
+// in a directory.
+type Store[T any] struct {
+	dir  string
+	name string        // What the state is, for errors, e.g. "rate limit state"
+	ttl  time.Duration // How long an idle session's state is kept
+}
+
+// New returns a Store keeping state files in dir. name describes the state in
+// errors, and the state of sessions idle for longer than ttl is removed.
+func New[T any](dir, name string, ttl time.Duration) *Store[T] {
+	return &Store[T]{dir: dir, name: name, ttl: ttl}
+}
+
+// DefaultDir returns the state directory named name under the user cache
+// directory.
+func DefaultDir(name string) string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "claudecode-hooks", name)
+}
+
+// Update applies change to the session's state under its lock, and writes the
+// state back if change reports it changed. It returns the state after change.
+func (s *Store[T]) Update(sessionID string, change func(*T) bool) (T, error) {
+	var state T
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return state, fmt.Errorf("creating %s directory: %w", s.name, err)
+	}
+
+	path := s.sessionPath(sessionID)
+	unlock, err := utils.LockFile(path + ".lock")
+	if err != nil {
+		return state, err
+	}
+	defer unlock()
+
+	if state, err = s.read(path); err != nil {
+		return state, err
+	}
+	if !change(&state) {
+		return state, nil
+	}
+	if err := s.write(path, state); err != nil {
+		return state, err
+	}
+	s.cleanup()
+	return state, nil
+}
+
+// Load returns the session's state, or the zero state for a session not seen
+// yet, without waiting for its lock.
+func (s *Store[T]) Load(sessionID string) (T, error) {
+	return s.read(s.sessionPath(sessionID))
+}
+
+// sessionPath maps a session ID to a state file. Session IDs come from the hook
+// payload, so they are hashed rather than used as file names directly.
+func (s *Store[T]) sessionPath(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// cleanup removes state files of sessions idle for longer than the TTL.
+// Errors are ignored; stale files only cost disk space.
+func (s *Store[T]) cleanup() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err == nil && time.Since(info.ModTime()) > s.ttl {
+			_ = os.Remove(filepath.Join(s.dir, entry.Name())) //nolint:errcheck // Best-effort cleanup
+		}
+	}
+}
+
+func (s *Store[T]) read(path string) (T, error) {
+	var state T
+	data, err := os.ReadFile(path) // #nosec G304 - path is derived from the state directory
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("reading %s: %w", s.name, err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		// A corrupt state file should not wedge the session; start over
+		var zero T
+		return zero, nil
+	}
+	return state, nil
+}
+
+func (s *Store[T]) write(path string, state T) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", s.name, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing %s: %w", s.name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing %s: %w", s.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package sessionstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type counter struct {
+	N int `json:"n"`
+}
+
+func TestStore_Update(t *testing.T) {
+	dir := t.TempDir()
+	store := New[counter](dir, "test state", time.Hour)
+	increment := func(c *counter) bool { c.N++; return true }
+
+	for i := 1; i <= 2; i++ {
+		got, err := store.Update("session-a", increment)
+		if err != nil {
+			t.Fatalf("Update() error: %v", err)
+		}
+		if got.N != i {
+			t.Errorf("Update() #%d = %+v, want N=%d", i, got, i)
+		}
+	}
+	if got, err := store.Update("session-b", increment); err != nil || got.N != 1 {
+		t.Errorf("Update() for a new session = %+v, %v, want N=1", got, err)
+	}
+
+	// An unchanged state is not written
+	if got, err := store.Update("session-a", func(c *counter) bool { c.N = 100; return false }); err != nil || got.N != 100 {
+		t.Errorf("Update() without change = %+v, %v, want the changed copy", got, err)
+	}
+	if got, err := store.Load("session-a"); err != nil || got.N != 2 {
+		t.Errorf("Load() = %+v, %v, want N=2", got, err)
+	}
+	if got, err := store.Load("session-c"); err != nil || got.N != 0 {
+		t.Errorf("Load() of an unknown session = %+v, %v, want the zero state", got, err)
+	}
+}
+
+func TestStore_CorruptAndStale(t *testing.T) {
+	dir := t.TempDir()
+	store := New[counter](dir, "test state", time.Hour)
+
+	// A corrupt state file starts over
+	if err := os.WriteFile(store.sessionPath("session-a"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Load("session-a"); err != nil || got.N != 0 {
+		t.Errorf("Load() of a corrupt file = %+v, %v, want the zero state", got, err)
+	}
+
+	// Idle sessions are removed on the next update
+	stale := store.sessionPath("session-old")
+	if err := os.WriteFile(stale, []byte(`{"n":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Update("session-a", func(c *counter) bool { c.N++; return true }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale state file %s should be removed, stat error: %v", filepath.Base(stale), err)
+	}
+}
@@ -0,0 +1,123 @@
+// Package subagents tracks the subagents (Task tool calls) of each Claude Code
+// session, running and started in total, so a hook can cap them.
+//
+// Like the rate limiter's counters, state lives in a sessionstore JSON file
+// per session, since each hook invocation is a separate short-lived process.
+package subagents
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/krmcbride/claudecode-hooks/internal/sessionstore"
+)
+
+const (
+	// RunningTTL is how long a subagent counts as running without its Task
+	// call finishing. A Task call that was interrupted, or denied by another
+	// hook after it was counted, never reports finishing.
+	RunningTTL = time.Hour
+
+	// sessionTTL is how long an idle session's state is kept before cleanup.
+	sessionTTL = 24 * time.Hour
+)
+
+// Counts are the subagents of a session.
+type Counts struct {
+	Running int // Started and not yet finished
+	Total   int // Started since the session started
+}
+
+// Limits configures how many subagents a session may start. Zero disables a
+// limit.
+type Limits struct {
+	MaxConcurrent int
+	MaxTotal      int
+}
+
+// Exceeded reports whether starting another subagent would go over the
+// limits, with a human-readable reason.
+func (l Limits) Exceeded(c Counts) (bool, string) {
+	if l.MaxConcurrent > 0 && c.Running >= l.MaxConcurrent {
+		return true, fmt.Sprintf("%d subagents are already running in this session (limit %d)", c.Running, l.MaxConcurrent)
+	}
+	if l.MaxTotal > 0 && c.Total >= l.MaxTotal {
+		return true, fmt.Sprintf("%d subagents were already started this session (limit %d)", c.Total, l.MaxTotal)
+	}
+	return false, ""
+}
+
+// sessionState is the on-disk state of one session.
+type sessionState struct {
+	Total   int         `json:"total"`
+	Running []time.Time `json:"running"` // Start times, oldest first
+}
+
+// Store persists per-session subagent counts in a directory.
+type Store struct {
+	state *sessionstore.Store[sessionState]
+	now   func() time.Time
+}
+
+// NewStore creates a Store keeping state files in dir.
+func NewStore(dir string) *Store {
+	return &Store{state: sessionstore.New[sessionState](dir, "subagent state", sessionTTL), now: time.Now}
+}
+
+// DefaultDir returns the default state directory under the user cache directory.
+func DefaultDir() string {
+	return sessionstore.DefaultDir("subagents")
+}
+
+// Start counts a subagent starting in the session unless that would exceed
+// limits, in which case reason says why and nothing is counted. The check
+// and the count happen under the session's lock, so parallel Task calls
+// cannot all slip under a limit. The counts returned are those after the
+// call.
+func (s *Store) Start(sessionID string, limits Limits) (counts Counts, reason string, err error) {
+	err = s.update(sessionID, func(state *sessionState) bool {
+		counts = Counts{Running: len(state.Running), Total: state.Total}
+		var exceeded bool
+		if exceeded, reason = limits.Exceeded(counts); exceeded {
+			return false
+		}
+		state.Running = append(state.Running, s.now())
+		state.Total++
+		counts = Counts{Running: len(state.Running), Total: state.Total}
+		return true
+	})
+	return counts, reason, err
+}
+
+// Finish counts a subagent of the session finishing and returns the updated
+// counts. Task calls report no ID, so the oldest running subagent is the one
+// taken to have finished.
+func (s *Store) Finish(sessionID string) (Counts, error) {
+	var counts Counts
+	err := s.update(sessionID, func(state *sessionState) bool {
+		if len(state.Running) > 0 {
+			state.Running = state.Running[1:]
+		}
+		counts = Counts{Running: len(state.Running), Total: state.Total}
+		return true
+	})
+	return counts, err
+}
+
+// update applies change to the session's state under its lock, after
+// expiring subagents running longer than RunningTTL, and writes the state
+// back if change reports it changed.
+func (s *Store) update(sessionID string, change func(*sessionState) bool) error {
+	_, err := s.state.Update(sessionID, func(state *sessionState) bool {
+		now := s.now()
+		running := state.Running[:0]
+		for _, started := range state.Running {
+			if now.Sub(started) < RunningTTL {
+				running = append(running, started)
+			}
+		}
+		state.Running = running
+		return change(state)
+	})
+	return err
+}
@@ -0,0 +1,99 @@
+package subagents
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore_StartFinish(t *testing.T) {
+	store := NewStore(t.TempDir())
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	limits := Limits{MaxConcurrent: 2, MaxTotal: 3}
+
+	for i := 1; i <= 2; i++ {
+		counts, reason, err := store.Start("session-a", limits)
+		if err != nil {
+			t.Fatalf("Start() error: %v", err)
+		}
+		if reason != "" || counts != (Counts{Running: i, Total: i}) {
+			t.Errorf("Start() #%d = %+v (%q), want Running=%d Total=%d", i, counts, reason, i, i)
+		}
+	}
+
+	// A third concurrent subagent is refused and not counted
+	counts, reason, err := store.Start("session-a", limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason == "" || counts != (Counts{Running: 2, Total: 2}) {
+		t.Errorf("Start() over the concurrency limit = %+v (%q), want a reason and Running=2 Total=2", counts, reason)
+	}
+
+	// Other sessions are counted separately
+	if counts, reason, err = store.Start("session-b", limits); err != nil || reason != "" || counts.Total != 1 {
+		t.Errorf("Start() for a new session = %+v (%q, %v), want Total=1", counts, reason, err)
+	}
+
+	if counts, err = store.Finish("session-a"); err != nil || counts != (Counts{Running: 1, Total: 2}) {
+		t.Errorf("Finish() = %+v (%v), want Running=1 Total=2", counts, err)
+	}
+	if _, reason, _ = store.Start("session-a", limits); reason != "" {
+		t.Errorf("Start() after a subagent finished refused: %s", reason)
+	}
+	if _, reason, _ = store.Start("session-a", Limits{}); reason != "" {
+		t.Errorf("Start() without limits refused: %s", reason)
+	}
+	if _, reason, _ = store.Start("session-a", limits); reason == "" {
+		t.Error("Start() over the total limit was not refused")
+	}
+}
+
+func TestStore_RunningExpires(t *testing.T) {
+	store := NewStore(t.TempDir())
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	if _, _, err := store.Start("session-a", Limits{}); err != nil {
+		t.Fatal(err)
+	}
+	// The Task call never finished, e.g. it was interrupted
+	now = now.Add(RunningTTL + time.Minute)
+	counts, reason, err := store.Start("session-a", Limits{MaxConcurrent: 1})
+	if err != nil || reason != "" || counts != (Counts{Running: 1, Total: 2}) {
+		t.Errorf("Start() after a stale subagent = %+v (%q, %v), want Running=1 Total=2", counts, reason, err)
+	}
+	// Finishing more subagents than are running does not go negative
+	for range 2 {
+		if counts, err = store.Finish("session-a"); err != nil || counts.Running != 0 {
+			t.Errorf("Finish() = %+v (%v), want Running=0", counts, err)
+		}
+	}
+}
+
+func TestLimits_Exceeded(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		counts Counts
+		want   bool
+	}{
+		{"no limits", Limits{}, Counts{Running: 100, Total: 100}, false},
+		{"under concurrency limit", Limits{MaxConcurrent: 3}, Counts{Running: 2, Total: 10}, false},
+		{"at concurrency limit", Limits{MaxConcurrent: 3}, Counts{Running: 3, Total: 3}, true},
+		{"under total limit", Limits{MaxTotal: 5}, Counts{Running: 0, Total: 4}, false},
+		{"at total limit", Limits{MaxTotal: 5}, Counts{Running: 0, Total: 5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := tt.limits.Exceeded(tt.counts)
+			if got != tt.want {
+				t.Errorf("Exceeded() = %v, want %v", got, tt.want)
+			}
+			if got && reason == "" {
+				t.Error("Exceeded() should explain why the limit was hit")
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// Package tracing provides opt-in OpenTelemetry (OTLP/HTTP JSON) tracing for hook evaluation.
+//
+// Tracing is disabled unless CLAUDE_HOOKS_OTLP_ENDPOINT is set. Spans are
+// buffered for the lifetime of the (short-lived) hook process and exported in
+// a single request when Flush is called, so enabling tracing adds one HTTP
+// round-trip at exit and nothing on the evaluation path.
+//
+// Spans never carry full command text unless CLAUDE_HOOKS_TRACE_COMMANDS is
+// set to a true value; use Tracer.RecordCommands to decide whether to attach it.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables used to configure tracing.
+const (
+	EnvEndpoint       = "CLAUDE_HOOKS_OTLP_ENDPOINT"  // OTLP/HTTP traces URL, e.g. http://localhost:4318/v1/traces
+	EnvHeaders        = "CLAUDE_HOOKS_OTLP_HEADERS"   // Comma-separated key=value request headers
+	EnvRecordCommands = "CLAUDE_HOOKS_TRACE_COMMANDS" // Attach full command text to spans when true
+)
+
+const (
+	serviceName   = "claudecode-hooks"
+	scopeName     = "github.com/krmcbride/claudecode-hooks/internal/tracing"
+	exportTimeout = 2 * time.Second
+)
+
+// Tracer collects spans for a single hook invocation and exports them over OTLP/HTTP.
+// A nil or disabled Tracer is safe to use; all operations become no-ops.
+type Tracer struct {
+	endpoint       string
+	headers        map[string]string
+	recordCommands bool
+	hook           string
+	traceID        string
+	spans          []*Span
+	client         *http.Client
+}
+
+// Span is a single timed operation within a trace.
+type Span struct {
+	tracer     *Tracer
+	name       string
+	spanID     string
+	parentID   string
+	start      time.Time
+	end        time.Time
+	attributes map[string]any
+	isError    bool
+}
+
+// New creates an enabled Tracer for the named hook exporting to endpoint.
+func New(hook, endpoint string, headers map[string]string) *Tracer {
+	return &Tracer{
+		endpoint: endpoint,
+		headers:  headers,
+		hook:     hook,
+		traceID:  randomHex(16),
+		client:   &http.Client{Timeout: exportTimeout},
+	}
+}
+
+// FromEnv creates a Tracer for the named hook from CLAUDE_HOOKS_OTLP_* environment
+// variables. It returns a disabled Tracer when no endpoint is configured.
+func FromEnv(hook string) *Tracer {
+	endpoint := os.Getenv(EnvEndpoint)
+	if endpoint == "" {
+		return &Tracer{hook: hook}
+	}
+
+	t := New(hook, endpoint, parseHeaders(os.Getenv(EnvHeaders)))
+	t.recordCommands, _ = strconv.ParseBool(os.Getenv(EnvRecordCommands)) //nolint:errcheck // Invalid values mean disabled
+	return t
+}
+
+// Enabled reports whether spans are collected and exported.
+func (t *Tracer) Enabled() bool {
+	return t != nil && t.endpoint != ""
+}
+
+// RecordCommands reports whether full command text may be attached to spans.
+func (t *Tracer) RecordCommands() bool {
+	return t.Enabled() && t.recordCommands
+}
+
+// Start begins a span. The parent may be nil for a root span.
+func (t *Tracer) Start(name string, parent *Span) *Span {
+	if !t.Enabled() {
+		return nil
+	}
+	span := &Span{
+		tracer:     t,
+		name:       name,
+		spanID:     randomHex(8),
+		start:      time.Now(),
+		attributes: make(map[string]any),
+	}
+	if parent != nil {
+		span.parentID = parent.spanID
+	}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+// StageObserver returns a function suitable for detector.CommandDetector.SetStageObserver
+// that records each detector stage as a child span of parent.
+func (t *Tracer) StageObserver(parent *Span) func(stage string) func() {
+	return func(stage string) func() {
+		span := t.Start(stage, parent)
+		return span.End
+	}
+}
+
+// SetAttribute attaches a string, bool, int, or float attribute to the span.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.attributes[key] = value
+}
+
+// SetError marks the span status as an error.
+func (s *Span) SetError() {
+	if s == nil {
+		return
+	}
+	s.isError = true
+}
+
+// End finishes the span. Calling End more than once keeps the first end time.
+func (s *Span) End() {
+	if s == nil || !s.end.IsZero() {
+		return
+	}
+	s.end = time.Now()
+}
+
+// Flush exports all collected spans. Unfinished spans are ended first.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if !t.Enabled() || len(t.spans) == 0 {
+		return nil
+	}
+
+	for _, span := range t.spans {
+		span.End()
+	}
+
+	body, err := json.Marshal(t.payload())
+	if err != nil {
+		return fmt.Errorf("encoding spans: %w", err)
+	}
+	t.spans = nil
+
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating OTLP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("exporting spans: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck // Response body is not used
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("exporting spans: collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// payload builds the OTLP/JSON ExportTraceServiceRequest body.
+func (t *Tracer) payload() map[string]any {
+	spans := make([]map[string]any, 0, len(t.spans))
+	for _, span := range t.spans {
+		encoded := map[string]any{
+			"traceId":           t.traceID,
+			"spanId":            span.spanID,
+			"name":              span.name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": strconv.FormatInt(span.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.end.UnixNano(), 10),
+			"attributes":        encodeAttributes(span.attributes),
+		}
+		if span.parentID != "" {
+			encoded["parentSpanId"] = span.parentID
+		}
+		if span.isError {
+			encoded["status"] = map[string]any{"code": 2} // STATUS_CODE_ERROR
+		}
+		spans = append(spans, encoded)
+	}
+
+	return map[string]any{
+		"resourceSpans": []any{
+			map[string]any{
+				"resource": map[string]any{
+					"attributes": encodeAttributes(map[string]any{
+						"service.name": serviceName,
+						"hook.name":    t.hook,
+					}),
+				},
+				"scopeSpans": []any{
+					map[string]any{
+						"scope": map[string]any{"name": scopeName},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+// encodeAttributes converts attributes to OTLP KeyValue objects.
+func encodeAttributes(attributes map[string]any) []map[string]any {
+	encoded := make([]map[string]any, 0, len(attributes))
+	for key, value := range attributes {
+		var v map[string]any
+		switch typed := value.(type) {
+		case bool:
+			v = map[string]any{"boolValue": typed}
+		case int:
+			v = map[string]any{"intValue": strconv.Itoa(typed)}
+		case int64:
+			v = map[string]any{"intValue": strconv.FormatInt(typed, 10)}
+		case float64:
+			v = map[string]any{"doubleValue": typed}
+		default:
+			v = map[string]any{"stringValue": fmt.Sprint(typed)}
+		}
+		encoded = append(encoded, map[string]any{"key": key, "value": v})
+	}
+	return encoded
+}
+
+// parseHeaders parses "key=value,key2=value2" into a header map.
+func parseHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		if found && strings.TrimSpace(key) != "" {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
+
+// randomHex returns n random bytes encoded as lowercase hex.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b) //nolint:errcheck // crypto/rand.Read never returns an error
+	return hex.EncodeToString(b)
+}
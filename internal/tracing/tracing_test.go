@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromEnv_Disabled(t *testing.T) {
+	t.Setenv(EnvEndpoint, "")
+	tracer := FromEnv("bash-block")
+
+	if tracer.Enabled() {
+		t.Fatal("Tracer without endpoint should be disabled")
+	}
+
+	// All operations must be safe no-ops
+	span := tracer.Start("root", nil)
+	span.SetAttribute("key", "value")
+	span.SetError()
+	span.End()
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Errorf("Flush() on disabled tracer returned error: %v", err)
+	}
+}
+
+func TestFromEnv_RecordCommands(t *testing.T) {
+	t.Setenv(EnvEndpoint, "http://127.0.0.1:4318/v1/traces")
+	t.Setenv(EnvRecordCommands, "")
+	if FromEnv("bash-block").RecordCommands() {
+		t.Error("Command recording must be off unless explicitly enabled")
+	}
+
+	t.Setenv(EnvRecordCommands, "true")
+	if !FromEnv("bash-block").RecordCommands() {
+		t.Error("Command recording should be enabled by CLAUDE_HOOKS_TRACE_COMMANDS=true")
+	}
+}
+
+func TestTracer_Flush(t *testing.T) {
+	var received map[string]any
+	var authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading body: %v", err)
+		}
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("invalid JSON payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tracer := New("bash-block", server.URL, map[string]string{"Authorization": "Bearer token"})
+	root := tracer.Start("bash-block", nil)
+	root.SetAttribute("hook.decision", "block")
+	end := tracer.StageObserver(root)("parse")
+	end()
+	root.End()
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	if authHeader != "Bearer token" {
+		t.Errorf("Authorization header = %q, want %q", authHeader, "Bearer token")
+	}
+
+	resourceSpans := received["resourceSpans"].([]any)
+	scopeSpans := resourceSpans[0].(map[string]any)["scopeSpans"].([]any)
+	spans := scopeSpans[0].(map[string]any)["spans"].([]any)
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+
+	rootSpan := spans[0].(map[string]any)
+	child := spans[1].(map[string]any)
+	if child["name"] != "parse" {
+		t.Errorf("child span name = %v, want parse", child["name"])
+	}
+	if child["parentSpanId"] != rootSpan["spanId"] {
+		t.Error("stage span should be a child of the root span")
+	}
+	if child["traceId"] != rootSpan["traceId"] {
+		t.Error("spans should share a trace ID")
+	}
+}
+
+func TestTracer_FlushCollectorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tracer := New("bash-block", server.URL, nil)
+	tracer.Start("bash-block", nil).End()
+	if err := tracer.Flush(context.Background()); err == nil {
+		t.Error("Flush() should report collector errors")
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	got := parseHeaders("a=1, b = two,invalid,=empty")
+	if len(got) != 2 || got["a"] != "1" || got["b"] != "two" {
+		t.Errorf("parseHeaders() = %v", got)
+	}
+}
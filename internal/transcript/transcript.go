@@ -0,0 +1,225 @@
+// Package transcript reads Claude Code session transcripts so hooks can take
+// recent context and the session's API usage into account.
+//
+// A transcript is a JSONL file (the transcript_path of the hook payload) where
+// assistant messages carry tool_use content blocks and the following user
+// messages carry the matching tool_result blocks:
+//
+//	{"type":"assistant","message":{"content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"go test ./..."}}]}}
+//	{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok","is_error":false}]}}
+//
+// Assistant messages also carry the token usage of the API response that
+// produced them under message.usage.
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxLineSize bounds a single transcript entry; tool results can be large.
+const maxLineSize = 32 * 1024 * 1024
+
+// ToolCall is a tool invocation and, once it completed, its result.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Input     map[string]any
+	Completed bool   // A tool_result was recorded
+	IsError   bool   // The tool reported an error (e.g. non-zero exit for Bash)
+	Output    string // Text of the tool result
+}
+
+// Command returns the command of a Bash tool call, or "" for other tools.
+func (c ToolCall) Command() string {
+	command, _ := c.Input["command"].(string)
+	return command
+}
+
+// Succeeded reports whether the tool call completed without an error.
+func (c ToolCall) Succeeded() bool {
+	return c.Completed && !c.IsError
+}
+
+type entry struct {
+	Type    string `json:"type"`
+	Message struct {
+		ID      string          `json:"id"`
+		Usage   *messageUsage   `json:"usage"`
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+type messageUsage struct {
+	InputTokens         int `json:"input_tokens"`
+	OutputTokens        int `json:"output_tokens"`
+	CacheCreationTokens int `json:"cache_creation_input_tokens"`
+	CacheReadTokens     int `json:"cache_read_input_tokens"`
+}
+
+// Usage is the API usage recorded in a transcript.
+type Usage struct {
+	Messages            int // Assistant messages (API responses)
+	ToolCalls           int
+	InputTokens         int
+	OutputTokens        int
+	CacheCreationTokens int
+	CacheReadTokens     int
+}
+
+// TotalTokens is the sum of input, output, and cache tokens.
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationTokens + u.CacheReadTokens
+}
+
+type contentBlock struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     map[string]any  `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// ReadToolCalls reads all tool calls from the transcript at path, oldest first.
+func ReadToolCalls(path string) ([]ToolCall, error) {
+	f, err := os.Open(path) // #nosec G304 - path comes from the hook payload
+	if err != nil {
+		return nil, fmt.Errorf("opening transcript: %w", err)
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck // Read-only file
+	return ParseToolCalls(f)
+}
+
+// ParseToolCalls reads tool calls from transcript JSONL, oldest first.
+// Lines that are not valid JSON or carry no tool blocks are skipped, since the
+// transcript format is not a stable interface.
+func ParseToolCalls(r io.Reader) ([]ToolCall, error) {
+	var calls []ToolCall
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		var blocks []contentBlock
+		if err := json.Unmarshal(e.Message.Content, &blocks); err != nil {
+			continue // Plain-text message
+		}
+
+		for _, block := range blocks {
+			switch block.Type {
+			case "tool_use":
+				index[block.ID] = len(calls)
+				calls = append(calls, ToolCall{ID: block.ID, Name: block.Name, Input: block.Input})
+			case "tool_result":
+				i, ok := index[block.ToolUseID]
+				if !ok {
+					continue
+				}
+				calls[i].Completed = true
+				calls[i].IsError = block.IsError
+				calls[i].Output = resultText(block.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading transcript: %w", err)
+	}
+	return calls, nil
+}
+
+// ReadUsage reads the API usage of the transcript at path.
+func ReadUsage(path string) (Usage, error) {
+	f, err := os.Open(path) // #nosec G304 - path comes from the hook payload
+	if err != nil {
+		return Usage{}, fmt.Errorf("opening transcript: %w", err)
+	}
+	defer func() { _ = f.Close() }() //nolint:errcheck // Read-only file
+	return ParseUsage(f)
+}
+
+// ParseUsage sums the token usage of the assistant messages in transcript
+// JSONL and counts their tool calls. A message streamed over several lines
+// repeats its ID and usage, so only the last usage of each message counts.
+func ParseUsage(r io.Reader) (Usage, error) {
+	var usage Usage
+	messages := make(map[string]messageUsage)
+	toolCalls := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for lineNum := 0; scanner.Scan(); lineNum++ {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Type != "assistant" {
+			continue
+		}
+		if e.Message.Usage != nil {
+			id := e.Message.ID
+			if id == "" {
+				id = fmt.Sprintf("line-%d", lineNum)
+			}
+			messages[id] = *e.Message.Usage
+		}
+		var blocks []contentBlock
+		if err := json.Unmarshal(e.Message.Content, &blocks); err != nil {
+			continue
+		}
+		for _, block := range blocks {
+			if block.Type == "tool_use" {
+				toolCalls[block.ID] = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Usage{}, fmt.Errorf("reading transcript: %w", err)
+	}
+
+	usage.Messages = len(messages)
+	usage.ToolCalls = len(toolCalls)
+	for _, m := range messages {
+		usage.InputTokens += m.InputTokens
+		usage.OutputTokens += m.OutputTokens
+		usage.CacheCreationTokens += m.CacheCreationTokens
+		usage.CacheReadTokens += m.CacheReadTokens
+	}
+	return usage, nil
+}
+
+// Recent returns the last n tool calls.
+func Recent(calls []ToolCall, n int) []ToolCall {
+	if n <= 0 || n >= len(calls) {
+		return calls
+	}
+	return calls[len(calls)-n:]
+}
+
+// resultText extracts the text of a tool_result, which is either a string or
+// a list of content blocks.
+func resultText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return ""
+	}
+	parts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Type == "text" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,98 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleTranscript = `{"type":"user","message":{"role":"user","content":"run the tests and push"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Running tests"},{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"go test ./..."}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok  \tpkg\t0.1s","is_error":false}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_2","name":"Read","input":{"file_path":"/tmp/main.go"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_2","content":[{"type":"text","text":"package main"}]}]}}
+not json
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_3","name":"Bash","input":{"command":"make lint"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_3","content":"lint failed","is_error":true}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_4","name":"Bash","input":{"command":"git push"}}]}}
+`
+
+func TestParseToolCalls(t *testing.T) {
+	calls, err := ParseToolCalls(strings.NewReader(sampleTranscript))
+	if err != nil {
+		t.Fatalf("ParseToolCalls() error: %v", err)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("ParseToolCalls() returned %d calls, want 4", len(calls))
+	}
+
+	tests := []struct {
+		index     int
+		name      string
+		command   string
+		succeeded bool
+		completed bool
+		output    string
+	}{
+		{0, "Bash", "go test ./...", true, true, "ok  \tpkg\t0.1s"},
+		{1, "Read", "", true, true, "package main"},
+		{2, "Bash", "make lint", false, true, "lint failed"},
+		{3, "Bash", "git push", false, false, ""},
+	}
+	for _, tt := range tests {
+		call := calls[tt.index]
+		if call.Name != tt.name || call.Command() != tt.command {
+			t.Errorf("call %d = %s %q, want %s %q", tt.index, call.Name, call.Command(), tt.name, tt.command)
+		}
+		if call.Succeeded() != tt.succeeded || call.Completed != tt.completed {
+			t.Errorf("call %d Succeeded() = %v Completed = %v, want %v %v", tt.index, call.Succeeded(), call.Completed, tt.succeeded, tt.completed)
+		}
+		if call.Output != tt.output {
+			t.Errorf("call %d Output = %q, want %q", tt.index, call.Output, tt.output)
+		}
+	}
+}
+
+func TestReadToolCalls(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	if err := os.WriteFile(path, []byte(sampleTranscript), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	calls, err := ReadToolCalls(path)
+	if err != nil {
+		t.Fatalf("ReadToolCalls() error: %v", err)
+	}
+	if got := Recent(calls, 2); len(got) != 2 || got[0].ID != "toolu_3" {
+		t.Errorf("Recent(calls, 2) = %+v, want the last two calls", got)
+	}
+	if got := Recent(calls, 0); len(got) != 4 {
+		t.Errorf("Recent(calls, 0) returned %d calls, want all 4", len(got))
+	}
+
+	if _, err := ReadToolCalls(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Error("ReadToolCalls() on a missing file should return an error")
+	}
+}
+
+func TestParseUsage(t *testing.T) {
+	// msg_1 is streamed over two lines that repeat its usage
+	transcript := `{"type":"user","message":{"role":"user","content":"fix the build"}}
+{"type":"assistant","message":{"id":"msg_1","role":"assistant","usage":{"input_tokens":10,"cache_creation_input_tokens":1000,"cache_read_input_tokens":5000,"output_tokens":40},"content":[{"type":"text","text":"Running the build"}]}}
+{"type":"assistant","message":{"id":"msg_1","role":"assistant","usage":{"input_tokens":10,"cache_creation_input_tokens":1000,"cache_read_input_tokens":5000,"output_tokens":40},"content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"make"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok"}]}}
+{"type":"assistant","message":{"id":"msg_2","role":"assistant","usage":{"input_tokens":5,"cache_read_input_tokens":6000,"output_tokens":20},"content":[{"type":"tool_use","id":"toolu_2","name":"Read","input":{"file_path":"a.go"}},{"type":"tool_use","id":"toolu_3","name":"Read","input":{"file_path":"b.go"}}]}}
+not json
+`
+	got, err := ParseUsage(strings.NewReader(transcript))
+	if err != nil {
+		t.Fatalf("ParseUsage() error: %v", err)
+	}
+	want := Usage{Messages: 2, ToolCalls: 3, InputTokens: 15, OutputTokens: 60, CacheCreationTokens: 1000, CacheReadTokens: 11000}
+	if got != want {
+		t.Errorf("ParseUsage() = %+v, want %+v", got, want)
+	}
+	if got.TotalTokens() != 12075 {
+		t.Errorf("TotalTokens() = %d, want 12075", got.TotalTokens())
+	}
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	lockRetryInterval = 20 * time.Millisecond
+	lockRetries       = 100
+	staleLockAge      = 10 * time.Second
+)
+
+// LockFile takes an exclusive, cross-process lock by creating lockPath.
+// Hooks run as many short-lived concurrent processes, so this is used to
+// serialize read-modify-write updates of shared files. Locks older than
+// ten seconds are considered abandoned and removed.
+// The returned function releases the lock.
+func LockFile(lockPath string) (func(), error) {
+	return LockFileContext(context.Background(), lockPath)
+}
+
+// LockFileContext is LockFile that stops waiting for the lock, with ctx's
+// error, when ctx is done.
+func LockFileContext(ctx context.Context, lockPath string) (func(), error) {
+	for range lockRetries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) // #nosec G304 - path is caller-controlled
+		if err == nil {
+			_ = f.Close()                                  //nolint:errcheck // Lock file content is irrelevant
+			return func() { _ = os.Remove(lockPath) }, nil //nolint:errcheck // Stale locks are cleaned up by age
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("creating lock file: %w", err)
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath) //nolint:errcheck // Another process may have removed it already
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+	return nil, errors.New("timed out waiting for lock " + lockPath)
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "file.lock")
+
+	unlock, err := LockFile(lockPath)
+	if err != nil {
+		t.Fatalf("LockFile() error: %v", err)
+	}
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Errorf("lock file should exist while held: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("lock file should be removed on unlock")
+	}
+}
+
+func TestLockFile_StaleLock(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "file.lock")
+	if err := os.WriteFile(lockPath, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := LockFile(lockPath)
+	if err != nil {
+		t.Fatalf("LockFile() should take over a stale lock: %v", err)
+	}
+	unlock()
+}
+
+func TestLockFileContext_Canceled(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "file.lock")
+	unlock, err := LockFile(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := LockFileContext(ctx, lockPath); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LockFileContext() on a held lock = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed >= lockRetries*lockRetryInterval {
+		t.Errorf("LockFileContext() waited %s, want it to stop at the deadline", elapsed)
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+)
+
+// ResolvePath returns the absolute path a file operation on path really
+// touches, so ../ traversal and symlinks cannot disguise it. Relative paths
+// resolve against cwd and a leading ~ is the home directory. Each component is
+// resolved in turn, as the kernel does, so link/.. is the parent of the link's
+// target, and a file not yet created in a linked directory resolves too.
+func ResolvePath(cwd, path string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok && (rest == "" || rest[0] == '/') {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + rest
+		}
+	}
+	if filepath.Separator == '/' && pathmatch.IsWindows(path) {
+		// An absolute Windows path has no files on this host to resolve
+		if pathmatch.IsAbs(path) {
+			return pathmatch.Clean(path)
+		}
+		path = pathmatch.Clean(path)
+	}
+	if !filepath.IsAbs(path) {
+		path = cwd + string(filepath.Separator) + path
+	}
+
+	volume := filepath.VolumeName(path)
+	resolved := volume + string(filepath.Separator)
+	for _, component := range strings.Split(path[len(volume):], string(filepath.Separator)) {
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+		next := filepath.Join(resolved, component)
+		if target, err := filepath.EvalSymlinks(next); err == nil {
+			next = target
+		}
+		resolved = next
+	}
+	return resolved
+}
+
+// WithinRoot reports whether path, resolved against cwd as by ResolvePath, is
+// root or a file below it. root is resolved too, so a project reached through
+// a symlink contains its own files.
+func WithinRoot(root, cwd, path string) bool {
+	root = ResolvePath(cwd, root)
+	path = ResolvePath(cwd, path)
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePath(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", filepath.Join(base, "home"))
+	project := filepath.Join(base, "project")
+	secrets := filepath.Join(base, "secrets")
+	for _, dir := range []string{filepath.Join(project, "src"), secrets} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(secrets, filepath.Join(project, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"src/main.go", filepath.Join(project, "src", "main.go")},
+		{"./src/../README.md", filepath.Join(project, "README.md")},
+		{"../secrets/key.pem", filepath.Join(secrets, "key.pem")},
+		{"src/../../secrets", secrets},
+		{"link/key.pem", filepath.Join(secrets, "key.pem")},
+		{"link/new/dir/file.txt", filepath.Join(secrets, "new", "dir", "file.txt")},
+		{"link/../project/src", filepath.Join(project, "src")},
+		{"~/.bashrc", filepath.Join(base, "home", ".bashrc")},
+		{filepath.Join(project, "link"), secrets},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ResolvePath(project, tt.path); got != tt.want {
+				t.Errorf("ResolvePath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithinRoot(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(base, "project")
+	if err := os.MkdirAll(project, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(base, filepath.Join(project, "up")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(project, filepath.Join(base, "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", true},
+		{".", true},
+		{"src/../main.go", true},
+		{"../project-other/main.go", false},
+		{"../main.go", false},
+		{"up/secret.txt", false},
+		{"up/project/main.go", true},
+		{filepath.Join(base, "alias", "main.go"), true},
+		{"/etc/passwd", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := WithinRoot(project, project, tt.path); got != tt.want {
+				t.Errorf("WithinRoot(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
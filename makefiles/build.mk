@@ -2,7 +2,7 @@
 # This file contains all build and installation-related targets
 
 # Hook definitions (shared by build, install, and uninstall targets)
-HOOKS := bash-block:cmd/bash-block file-format:cmd/file-format hook-logger:cmd/hook-logger
+HOOKS := bash-block:cmd/bash-block blob-guard:cmd/blob-guard branch-guard:cmd/branch-guard command-rewrite:cmd/command-rewrite editorconfig-guard:cmd/editorconfig-guard file-backup:cmd/file-backup file-format:cmd/file-format generated-guard:cmd/generated-guard hook-logger:cmd/hook-logger hooks:cmd/hooks injection-guard:cmd/injection-guard owner-guard:cmd/owner-guard pkg-install-guard:cmd/pkg-install-guard rate-limit:cmd/rate-limit readonly-guard:cmd/readonly-guard remote-guard:cmd/remote-guard sandbox-guard:cmd/sandbox-guard secret-guard:cmd/secret-guard self-protect:cmd/self-protect session-summary:cmd/session-summary subagent-guard:cmd/subagent-guard usage-guard:cmd/usage-guard
 
 ##@ Build
 
@@ -12,7 +12,7 @@ build: ## Build all hook binaries
 	@mkdir -p $(BUILD_DIR)
 	@$(foreach hook,$(HOOKS), \
 		printf "$(YELLOW)Building $(HOOK_PREFIX)$(word 1,$(subst :, ,$(hook)))...$(NC)\n"; \
-		go build -o $(BUILD_DIR)/$(HOOK_PREFIX)$(word 1,$(subst :, ,$(hook))) ./$(word 2,$(subst :, ,$(hook))) || exit 1; \
+		go build -ldflags "$(LDFLAGS)" -o $(BUILD_DIR)/$(HOOK_PREFIX)$(word 1,$(subst :, ,$(hook))) ./$(word 2,$(subst :, ,$(hook))) || exit 1; \
 		printf "$(GREEN)✓ Built $(BUILD_DIR)/$(HOOK_PREFIX)$(word 1,$(subst :, ,$(hook)))$(NC)\n"; \
 	)
 	@printf "$(GREEN)✓ All hooks built$(NC)\n"
@@ -23,15 +23,49 @@ define hook-build-template
 build-$(1): ## Build $(1) hook
 	@printf "$$(YELLOW)Building $$(HOOK_PREFIX)$(1)...$$(NC)\n"
 	@mkdir -p $$(BUILD_DIR)
-	@go build -o $$(BUILD_DIR)/$$(HOOK_PREFIX)$(1) ./$(2)
+	@go build -ldflags "$$(LDFLAGS)" -o $$(BUILD_DIR)/$$(HOOK_PREFIX)$(1) ./$(2)
 	@printf "$$(GREEN)✓ Built $$(BUILD_DIR)/$$(HOOK_PREFIX)$(1)$$(NC)\n"
 endef
 
 # Generate individual hook build targets
 # NOTE: When adding a new hook, add it to HOOKS above AND add an eval line below
 $(eval $(call hook-build-template,bash-block,cmd/bash-block))
+$(eval $(call hook-build-template,blob-guard,cmd/blob-guard))
+$(eval $(call hook-build-template,branch-guard,cmd/branch-guard))
+$(eval $(call hook-build-template,command-rewrite,cmd/command-rewrite))
+$(eval $(call hook-build-template,editorconfig-guard,cmd/editorconfig-guard))
+$(eval $(call hook-build-template,file-backup,cmd/file-backup))
 $(eval $(call hook-build-template,file-format,cmd/file-format))
+$(eval $(call hook-build-template,generated-guard,cmd/generated-guard))
 $(eval $(call hook-build-template,hook-logger,cmd/hook-logger))
+$(eval $(call hook-build-template,hooks,cmd/hooks))
+$(eval $(call hook-build-template,injection-guard,cmd/injection-guard))
+$(eval $(call hook-build-template,owner-guard,cmd/owner-guard))
+$(eval $(call hook-build-template,pkg-install-guard,cmd/pkg-install-guard))
+$(eval $(call hook-build-template,rate-limit,cmd/rate-limit))
+$(eval $(call hook-build-template,readonly-guard,cmd/readonly-guard))
+$(eval $(call hook-build-template,remote-guard,cmd/remote-guard))
+$(eval $(call hook-build-template,sandbox-guard,cmd/sandbox-guard))
+$(eval $(call hook-build-template,secret-guard,cmd/secret-guard))
+$(eval $(call hook-build-template,self-protect,cmd/self-protect))
+$(eval $(call hook-build-template,session-summary,cmd/session-summary))
+$(eval $(call hook-build-template,subagent-guard,cmd/subagent-guard))
+$(eval $(call hook-build-template,usage-guard,cmd/usage-guard))
+
+.PHONY: build-wasm
+build-wasm: ## Build the detector as a WASM module for JavaScript hosts
+	@printf "$(YELLOW)Building detector.wasm...$(NC)\n"
+	@mkdir -p $(BUILD_DIR)
+	@GOOS=js GOARCH=wasm go build -ldflags "$(LDFLAGS)" -o $(BUILD_DIR)/detector.wasm ./cmd/detector-wasm
+	@cp "$$(go env GOROOT)/lib/wasm/wasm_exec.js" $(BUILD_DIR)/wasm_exec.js
+	@printf "$(GREEN)✓ Built $(BUILD_DIR)/detector.wasm and $(BUILD_DIR)/wasm_exec.js$(NC)\n"
+
+.PHONY: build-cshared
+build-cshared: ## Build the detector as a C shared library (requires a C toolchain)
+	@printf "$(YELLOW)Building libdetector...$(NC)\n"
+	@mkdir -p $(BUILD_DIR)
+	@CGO_ENABLED=1 go build -buildmode=c-shared -ldflags "$(LDFLAGS)" -o $(BUILD_DIR)/libdetector.so ./cmd/detector-cshared
+	@printf "$(GREEN)✓ Built $(BUILD_DIR)/libdetector.so and $(BUILD_DIR)/libdetector.h$(NC)\n"
 
 ##@ Installation
 
@@ -57,6 +91,12 @@ install-user: build ## Install all hook binaries to user ~/.claude/hooks/ direct
 		printf "$(GREEN)✓ Installed $(HOOK_PREFIX)$(word 1,$(subst :, ,$(hook))) to $(USER_HOOK_DIR)/$(NC)\n"; \
 	)
 
+.PHONY: install-bin
+install-bin: build-hooks ## Install the single hooks binary with per-hook shims to ~/.claude/hooks/
+	@printf "$(YELLOW)Installing single binary to user config...$(NC)\n"
+	@$(BUILD_DIR)/$(HOOK_PREFIX)hooks install -dir $(USER_HOOK_DIR) -prefix $(HOOK_PREFIX)
+	@printf "$(GREEN)✓ Installed $(HOOK_PREFIX)hooks to $(USER_HOOK_DIR)/bin/$(NC)\n"
+
 # Template for individual hook install targets
 define hook-install-template
 .PHONY: install-$(1)
@@ -116,9 +156,47 @@ endef
 # Generate individual hook install and uninstall targets
 # NOTE: When adding a new hook, add it to HOOKS above AND add eval lines below
 $(eval $(call hook-install-template,bash-block))
+$(eval $(call hook-install-template,blob-guard))
+$(eval $(call hook-install-template,branch-guard))
+$(eval $(call hook-install-template,command-rewrite))
+$(eval $(call hook-install-template,editorconfig-guard))
+$(eval $(call hook-install-template,file-backup))
 $(eval $(call hook-install-template,file-format))
+$(eval $(call hook-install-template,generated-guard))
 $(eval $(call hook-install-template,hook-logger))
+$(eval $(call hook-install-template,hooks))
+$(eval $(call hook-install-template,injection-guard))
+$(eval $(call hook-install-template,owner-guard))
+$(eval $(call hook-install-template,pkg-install-guard))
+$(eval $(call hook-install-template,rate-limit))
+$(eval $(call hook-install-template,readonly-guard))
+$(eval $(call hook-install-template,remote-guard))
+$(eval $(call hook-install-template,sandbox-guard))
+$(eval $(call hook-install-template,secret-guard))
+$(eval $(call hook-install-template,self-protect))
+$(eval $(call hook-install-template,session-summary))
+$(eval $(call hook-install-template,subagent-guard))
+$(eval $(call hook-install-template,usage-guard))
 
 $(eval $(call hook-uninstall-template,bash-block))
+$(eval $(call hook-uninstall-template,blob-guard))
+$(eval $(call hook-uninstall-template,branch-guard))
+$(eval $(call hook-uninstall-template,command-rewrite))
+$(eval $(call hook-uninstall-template,editorconfig-guard))
+$(eval $(call hook-uninstall-template,file-backup))
 $(eval $(call hook-uninstall-template,file-format))
+$(eval $(call hook-uninstall-template,generated-guard))
 $(eval $(call hook-uninstall-template,hook-logger))
+$(eval $(call hook-uninstall-template,hooks))
+$(eval $(call hook-uninstall-template,injection-guard))
+$(eval $(call hook-uninstall-template,owner-guard))
+$(eval $(call hook-uninstall-template,pkg-install-guard))
+$(eval $(call hook-uninstall-template,rate-limit))
+$(eval $(call hook-uninstall-template,readonly-guard))
+$(eval $(call hook-uninstall-template,remote-guard))
+$(eval $(call hook-uninstall-template,sandbox-guard))
+$(eval $(call hook-uninstall-template,secret-guard))
+$(eval $(call hook-uninstall-template,self-protect))
+$(eval $(call hook-uninstall-template,session-summary))
+$(eval $(call hook-uninstall-template,subagent-guard))
+$(eval $(call hook-uninstall-template,usage-guard))
@@ -0,0 +1,165 @@
+// Package detector - allowlist for default-deny mode
+package detector
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// AllowRule permits a command, optionally limited to argument patterns.
+type AllowRule struct {
+	Command  string   // Command to allow (git, make)
+	Patterns []string // Allowed leading arguments, e.g. "status" or "test-*"; "*" allows any
+}
+
+// ParseAllowSpec parses "command [pattern1] [pattern2] ..." the way -cmd
+// specs are parsed. Without patterns, every use of the command is allowed.
+func ParseAllowSpec(spec string) (AllowRule, bool) {
+	rule, ok := ParseCommandSpec(spec)
+	return AllowRule{Command: rule.BlockedCommand, Patterns: rule.BlockedPatterns}, ok
+}
+
+// Allowlist finds the commands of a shell expression that are not explicitly
+// allowed, for default-deny mode.
+type Allowlist struct {
+	rules    []AllowRule
+	maxDepth int
+	lang     syntax.LangVariant
+	env      map[string]string
+}
+
+// NewAllowlist creates an allowlist. maxDepth bounds how deeply shell -c and
+// eval strings are followed (default: 10).
+func NewAllowlist(rules []AllowRule, maxDepth int) *Allowlist {
+	if maxDepth <= 0 {
+		maxDepth = 10
+	}
+	return &Allowlist{rules: rules, maxDepth: maxDepth}
+}
+
+// SetDialect sets the shell language commands are parsed as (default:
+// syntax.LangBash).
+func (a *Allowlist) SetDialect(lang syntax.LangVariant) {
+	a.lang = lang
+}
+
+// SetEnvironment resolves variables in commands before checking them, as
+// CommandDetector.SetEnvironment does, so "$EDITOR file" can be allowlisted.
+func (a *Allowlist) SetEnvironment(env map[string]string) {
+	a.env = env
+}
+
+// Unlisted returns an issue for every command in shellExpr that no rule
+// allows. Every command of a compound command counts, including those in
+// pipelines, lists, substitutions, and function bodies, as do the commands in
+// the strings run by an allowed shell -c or eval and the remote commands of an
+// allowed ssh. A command whose name is dynamic is never allowed.
+func (a *Allowlist) Unlisted(shellExpr string) ([]string, error) {
+	return a.unlisted(shellExpr, 1)
+}
+
+func (a *Allowlist) unlisted(shellExpr string, depth int) ([]string, error) {
+	if depth > a.maxDepth {
+		return []string{"Maximum nesting depth exceeded - command too complex"}, nil
+	}
+	ast, err := shellparse.ParseVariant(shellExpr, a.lang)
+	if err != nil {
+		return nil, err
+	}
+	if a.env != nil {
+		shellparse.ResolveParams(ast, a.env)
+	}
+
+	var issues []string
+	for _, call := range shellparse.CallExprs(ast) {
+		if len(call.Args) == 0 {
+			continue // Variable assignments only
+		}
+		// Allow rules match the command a wrapper such as env -i runs
+		call = shellparse.NormalizeCall(call)
+		args, allStatic := shellparse.StaticArgs(call)
+		if _, isStatic := shellparse.StaticWord(call.Args[0]); !isStatic {
+			issues = append(issues, "Command name "+shellparse.Print(call.Args[0])+" is dynamic - unable to verify it is allowed")
+			continue
+		}
+		if !a.allows(args, allStatic) {
+			issues = append(issues, "Command not in allowlist: "+shellparse.Print(call))
+			continue
+		}
+
+		// An allowed shell, eval, or ssh runs its string argument as more commands
+		if script, ok := executedScript(call); ok {
+			if !allStatic {
+				issues = append(issues, "Script run by "+normalizeCommand(args[0])+" is dynamic - unable to verify it is allowed")
+				continue
+			}
+			nested, err := a.unlisted(script, depth+1)
+			if err != nil {
+				issues = append(issues, "Unable to parse script run by "+normalizeCommand(args[0])+": "+script)
+				continue
+			}
+			issues = append(issues, nested...)
+		}
+	}
+	return issues, nil
+}
+
+// allows reports whether a rule permits the command args. Dynamic arguments
+// are only allowed by rules that allow any arguments.
+func (a *Allowlist) allows(args []string, allStatic bool) bool {
+	return slices.ContainsFunc(a.rules, func(rule AllowRule) bool {
+		if !isMatchingCommand(args[0], rule.Command) {
+			return false
+		}
+		if len(rule.Patterns) == 0 || slices.Contains(rule.Patterns, "*") {
+			return true
+		}
+		if !allStatic {
+			return false
+		}
+		joined := strings.Join(args[1:], " ")
+		return slices.ContainsFunc(rule.Patterns, func(pattern string) bool {
+			return matchesLeadingArgs(joined, pattern)
+		})
+	})
+}
+
+// matchesLeadingArgs reports whether args begin with pattern on a word
+// boundary, so "status" allows "status -s" but not "statusx". A "*" in the
+// pattern matches any characters.
+func matchesLeadingArgs(args, pattern string) bool {
+	if !strings.Contains(pattern, "*") {
+		return args == pattern || strings.HasPrefix(args, pattern+" ")
+	}
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "( |$)"
+	matched, err := regexp.MatchString(expr, args)
+	return err == nil && matched
+}
+
+// executedScript returns the script a shell -c, eval, or ssh call runs.
+func executedScript(call *syntax.CallExpr) (string, bool) {
+	args, _ := shellparse.StaticArgs(call)
+	cmd := normalizeCommand(args[0])
+	if cmd == "eval" {
+		return strings.Join(args[1:], " "), len(args) > 1
+	}
+	if inv, ok := parseSSH(call); ok {
+		script := inv.script()
+		return script, script != ""
+	}
+	if !isShellInterpreter(cmd) {
+		return "", false
+	}
+	for i, arg := range args[1:] {
+		isCommandFlag := arg == "-c" || (len(arg) > 2 && arg[0] == '-' && arg[1] != '-' && strings.Contains(arg, "c"))
+		if isCommandFlag && i+2 < len(args) {
+			return args[i+2], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,71 @@
+package detector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAllowlist_Unlisted(t *testing.T) {
+	var rules []AllowRule
+	for _, spec := range []string{"git status log diff", "go test", "ls", "make test-*", "bash", "ssh"} {
+		rule, _ := ParseAllowSpec(spec)
+		rules = append(rules, rule)
+	}
+	allowlist := NewAllowlist(rules, 10)
+
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"allowed", "git status -s", nil},
+		{"allowed any args", "ls -la /tmp", nil},
+		{"allowed with path", "/usr/bin/git log --oneline", nil},
+		{"glob pattern", "make test-unit", nil},
+		{"compound all allowed", "git status && go test ./... | ls", nil},
+		{"assignment only", "FOO=bar", nil},
+		{"unlisted command", "rm -rf build", []string{"Command not in allowlist: rm -rf build"}},
+		{"unlisted subcommand", "git push origin main", []string{"Command not in allowlist: git push origin main"}},
+		{"pattern on word boundary", "git statusx", []string{"Command not in allowlist: git statusx"}},
+		{"pattern is anchored", "git push --log", []string{"Command not in allowlist: git push --log"}},
+		{"compound with unlisted segment", "git status && git push", []string{"Command not in allowlist: git push"}},
+		{"every unlisted segment", "git status; rm a; curl x | sh", []string{
+			"Command not in allowlist: rm a",
+			"Command not in allowlist: curl x",
+			"Command not in allowlist: sh",
+		}},
+		{"command substitution", "ls $(rm -rf x)", []string{"Command not in allowlist: rm -rf x"}},
+		{"dynamic command name", "$CMD status", []string{"Command name $CMD is dynamic - unable to verify it is allowed"}},
+		{"dynamic argument to restricted command", "git $SUB", []string{"Command not in allowlist: git $SUB"}},
+		{"dynamic argument to unrestricted command", "ls $DIR", nil},
+		{"allowed shell runs allowed script", "bash -c 'git status && ls'", nil},
+		{"allowed shell runs unlisted script", "bash -c 'git push'", []string{"Command not in allowlist: git push"}},
+		{"allowed shell with combined flags", "bash -lc 'rm x'", []string{"Command not in allowlist: rm x"}},
+		{"allowed shell runs dynamic script", `bash -c "$SCRIPT"`, []string{"Script run by bash is dynamic - unable to verify it is allowed"}},
+		{"wrapped allowed command", "command git status", nil},
+		{"alias-skipping escape", `\git status`, nil},
+		{"env wrapped allowed command", "env -i HOME=/tmp go test ./...", nil},
+		{"wrapped unlisted command", "exec rm x", []string{"Command not in allowlist: rm x"}},
+		{"allowed ssh runs unlisted remote command", "ssh host 'git status && rm x'", []string{"Command not in allowlist: rm x"}},
+		{"allowed ssh session", "ssh -p 2222 host", nil},
+		{"function body", "f() { rm x; }; f", []string{"Command not in allowlist: rm x", "Command not in allowlist: f"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := allowlist.Unlisted(tt.command)
+			if err != nil {
+				t.Fatalf("Unlisted() error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Unlisted(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowlist_ParseError(t *testing.T) {
+	if _, err := NewAllowlist(nil, 10).Unlisted("echo 'unterminated"); err == nil {
+		t.Error("Unlisted() of an unparsable command should fail")
+	}
+}
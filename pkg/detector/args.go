@@ -0,0 +1,192 @@
+// Package detector - flag-aware argument parsing
+package detector
+
+import (
+	"slices"
+	"strings"
+)
+
+// ArgSpec describes the command-line syntax of a tool so its arguments can be
+// split into subcommands and flags instead of being matched as one string.
+type ArgSpec struct {
+	// ValueFlags take a value as the next argument unless written as
+	// --flag=value, e.g. "-C" and "-c" for git or "--region" for aws.
+	ValueFlags []string
+	// AliasFlag, if set, is a flag whose "alias.NAME=VALUE" values define
+	// aliases inline, as with git -c alias.p=push.
+	AliasFlag string
+	// ShortAttached allows single-letter value flags to carry their value
+	// attached, as in kubectl -nprod.
+	ShortAttached bool
+}
+
+// Invocation is a command's arguments parsed with an ArgSpec.
+type Invocation struct {
+	// Positionals are the non-flag arguments in order, subcommands first
+	Positionals []string
+	// Flags maps each flag, with its dashes, to the values it was given.
+	// Boolean flags map to an empty value.
+	Flags map[string][]string
+}
+
+// Parse splits args into positionals and flags. Flags may appear in any
+// position; everything after "--" is positional.
+func (s ArgSpec) Parse(args []string) Invocation {
+	inv := Invocation{Flags: make(map[string][]string)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			inv.Positionals = append(inv.Positionals, args[i+1:]...)
+			return inv
+		case len(arg) < 2 || !strings.HasPrefix(arg, "-"):
+			inv.Positionals = append(inv.Positionals, arg)
+		default:
+			name, value, hasValue := s.splitFlag(arg)
+			if !hasValue && slices.Contains(s.ValueFlags, name) && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			inv.Flags[name] = append(inv.Flags[name], value)
+		}
+	}
+	return inv
+}
+
+// splitFlag splits a flag argument into its name and, when given in the
+// same argument, its value.
+func (s ArgSpec) splitFlag(arg string) (name, value string, hasValue bool) {
+	if s.ShortAttached && len(arg) > 2 && arg[1] != '-' && slices.Contains(s.ValueFlags, arg[:2]) {
+		return arg[:2], strings.TrimPrefix(arg[2:], "="), true
+	}
+	return strings.Cut(arg, "=")
+}
+
+// Subcommand returns the positional at index i, or "" if there is none.
+func (inv Invocation) Subcommand(i int) string {
+	if i < len(inv.Positionals) {
+		return inv.Positionals[i]
+	}
+	return ""
+}
+
+// Flag returns the last value given for any of the names and whether any of
+// them was set.
+func (inv Invocation) Flag(names ...string) (string, bool) {
+	var value string
+	var found bool
+	for _, name := range names {
+		if values, ok := inv.Flags[name]; ok {
+			value, found = values[len(values)-1], true
+		}
+	}
+	return value, found
+}
+
+// HasFlag reports whether any of the names was set.
+func (inv Invocation) HasFlag(names ...string) bool {
+	_, found := inv.Flag(names...)
+	return found
+}
+
+// firstPositional returns the index in args of the first positional argument,
+// or -1 if there is none.
+func (s ArgSpec) firstPositional(args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			if i+1 < len(args) {
+				return i + 1
+			}
+			return -1
+		case len(arg) < 2 || !strings.HasPrefix(arg, "-"):
+			return i
+		default:
+			if name, _, hasValue := s.splitFlag(arg); !hasValue && slices.Contains(s.ValueFlags, name) {
+				i++ // Skip the flag's value
+			}
+		}
+	}
+	return -1
+}
+
+// inlineAliases returns the aliases defined with the AliasFlag in args.
+func (s ArgSpec) inlineAliases(args []string) map[string]string {
+	if s.AliasFlag == "" {
+		return nil
+	}
+	aliases := make(map[string]string)
+	for _, value := range s.Parse(args).Flags[s.AliasFlag] {
+		key, expansion, ok := strings.Cut(value, "=")
+		name, isAlias := strings.CutPrefix(strings.ToLower(key), "alias.")
+		if ok && isAlias && name != "" {
+			aliases[name] = expansion
+		}
+	}
+	return aliases
+}
+
+// maxAliasExpansions bounds alias-of-alias chains.
+const maxAliasExpansions = 10
+
+// expandAliases replaces an aliased subcommand in args with its expansion,
+// following aliases of aliases. A shell alias (an expansion starting with "!")
+// cannot be expanded in place; it is returned as a shell expression with the
+// remaining arguments appended, for the caller to analyze.
+func (s ArgSpec) expandAliases(args []string, configured map[string]string) (expanded []string, shellAlias string) {
+	inline := s.inlineAliases(args)
+	if len(configured) == 0 && len(inline) == 0 {
+		return args, ""
+	}
+
+	seen := make(map[string]bool)
+	for range maxAliasExpansions {
+		i := s.firstPositional(args)
+		if i < 0 || seen[args[i]] {
+			return args, ""
+		}
+		expansion, ok := inline[args[i]]
+		if !ok {
+			expansion, ok = configured[args[i]]
+		}
+		if !ok {
+			return args, ""
+		}
+		seen[args[i]] = true
+
+		if shell, isShell := strings.CutPrefix(expansion, "!"); isShell {
+			return args, strings.Join(append([]string{shell}, args[i+1:]...), " ")
+		}
+		args = slices.Concat(args[:i], strings.Fields(expansion), args[i+1:])
+	}
+	return args, ""
+}
+
+// SetAliases provides the aliases configured for command, such as git's
+// alias.* settings. They are expanded before flag-aware rules are evaluated.
+func (d *CommandDetector) SetAliases(command string, aliases map[string]string) {
+	if d.aliases == nil {
+		d.aliases = make(map[string]map[string]string)
+	}
+	d.aliases[command] = aliases
+}
+
+// checkInvocation evaluates a flag-aware rule against a command's static
+// arguments, after expanding aliases.
+func (d *CommandDetector) checkInvocation(rule CommandRule, args []string) bool {
+	args, shellAlias := rule.Args.expandAliases(args, d.aliases[rule.BlockedCommand])
+	if shellAlias != "" {
+		// A shell alias runs arbitrary commands; analyze them like any other
+		if d.analyzeShellExprRecursive(shellAlias) {
+			d.addIssue(rule.BlockedCommand + " alias runs a blocked command")
+			return true
+		}
+		return false
+	}
+	if issue := rule.Match(rule.Args.Parse(args)); issue != "" {
+		d.addIssue(issue)
+		return true
+	}
+	return false
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
 )
 
 // checkArgumentsForBlockedCommands scans command arguments for blocked commands.
@@ -21,19 +23,21 @@ func (d *CommandDetector) checkArgumentsForBlockedCommands(call *syntax.CallExpr
 		arg := call.Args[i]
 
 		// Resolve the argument to a static string
-		argStr, isStatic := resolveStaticWord(arg)
+		argStr, isStatic := shellparse.StaticWord(arg)
 		if !isStatic || argStr == "" {
 			continue
 		}
 
 		// Check if this argument matches any blocked command
-		for _, rule := range d.commandRules {
-			if isMatchingCommand(argStr, rule.BlockedCommand) {
+		for _, j := range d.ruleIndex.candidates(argStr) {
+			rule := d.commandRules[j]
+			if isMatchingCommand(argStr, rule.BlockedCommand) && !d.ruleLifted(rule) {
 				// Found a blocked command as an argument
 				// Now check if the next arguments match any blocked patterns
 				remainingArgs := call.Args[i+1:]
 				if d.checkPatternInArgs(remainingArgs, rule) {
 					d.addIssue("Blocked command '" + rule.BlockedCommand + "' found as argument")
+					d.recordMatch(j)
 					return true // BLOCK
 				}
 			}
@@ -47,6 +51,17 @@ func (d *CommandDetector) checkArgumentsForBlockedCommands(call *syntax.CallExpr
 // subcommands/arguments also match the blocking criteria.
 // Returns true if the pattern matches and should be blocked.
 func (d *CommandDetector) checkPatternInArgs(args []*syntax.Word, rule CommandRule) bool {
+	// Flag-aware rules need the flags too
+	if rule.Match != nil {
+		var argStrings []string
+		for _, arg := range args {
+			if argStr, isStatic := shellparse.StaticWord(arg); isStatic {
+				argStrings = append(argStrings, argStr)
+			}
+		}
+		return d.checkInvocation(rule, argStrings)
+	}
+
 	// If no patterns specified, allow the command
 	if len(rule.BlockedPatterns) == 0 {
 		return false
@@ -60,7 +75,7 @@ func (d *CommandDetector) checkPatternInArgs(args []*syntax.Word, rule CommandRu
 	// Collect arguments as strings
 	var argStrings []string
 	for _, arg := range args {
-		argStr, isStatic := resolveStaticWord(arg)
+		argStr, isStatic := shellparse.StaticWord(arg)
 		if isStatic && argStr != "" && !strings.HasPrefix(argStr, "-") {
 			argStrings = append(argStrings, argStr)
 		}
@@ -0,0 +1,107 @@
+package detector
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// bypassCorpus lists documented detector bypasses, one JSON object per line.
+// Every bypass found, whether by fuzzing or by hand, is appended here so it
+// stays fixed; "open" marks one that is known and not yet fixed.
+//
+//go:embed testdata/bypasses.jsonl
+var bypassCorpus []byte
+
+// bypassEntry is one line of the bypass corpus.
+type bypassEntry struct {
+	// Preset names the preset the command is checked against, or "*" for
+	// every preset, with {blocked} replaced by the preset's sample command.
+	Preset  string `json:"preset"`
+	Command string `json:"command"`
+	// Decision is "block" or "allow".
+	Decision string `json:"decision"`
+	// Technique tags the evasion, e.g. ifs, quoting, wrapper, encoding.
+	Technique string `json:"technique"`
+	Note      string `json:"note,omitempty"`
+	// Open marks a bypass that still gets through.
+	Open bool `json:"open,omitempty"`
+	line int
+}
+
+func readBypassCorpus(t *testing.T) []bypassEntry {
+	t.Helper()
+	var entries []bypassEntry
+	scanner := bufio.NewScanner(bytes.NewReader(bypassCorpus))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		var entry bypassEntry
+		if err := decoder.Decode(&entry); err != nil {
+			t.Fatalf("bypasses.jsonl:%d: %v", line, err)
+		}
+		if entry.Decision != "block" && entry.Decision != "allow" {
+			t.Fatalf("bypasses.jsonl:%d: decision %q, want block or allow", line, entry.Decision)
+		}
+		if entry.Command == "" || entry.Technique == "" {
+			t.Fatalf("bypasses.jsonl:%d: command and technique are required", line)
+		}
+		entry.line = line
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestBypassCorpus(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, entry := range readBypassCorpus(t) {
+		presetNames := []string{entry.Preset}
+		if entry.Preset == "*" {
+			presetNames = nil
+			for _, preset := range Presets() {
+				presetNames = append(presetNames, preset.Name)
+			}
+		}
+		for _, name := range presetNames {
+			preset, ok := LookupPreset(name)
+			if !ok {
+				t.Errorf("bypasses.jsonl:%d: unknown preset %q", entry.line, name)
+				continue
+			}
+			command := entry.Command
+			if strings.Contains(command, "{blocked}") {
+				sample, ok := presetSamples[name]
+				if !ok {
+					t.Errorf("bypasses.jsonl:%d: preset %q has no sample command", entry.line, name)
+					continue
+				}
+				command = strings.ReplaceAll(command, "{blocked}", sample)
+			}
+			t.Run(entry.Technique+"/"+name+"/"+command, func(t *testing.T) {
+				detector := NewCommandDetector(preset.Rules, 10)
+				detector.SetProtectedRedirects(t.TempDir(), preset.Redirects)
+				got := detector.ShouldBlockShellExpr(command)
+				want := entry.Decision == "block"
+				switch {
+				case entry.Open && got == want:
+					t.Errorf("bypasses.jsonl:%d: open bypass %q is fixed; drop \"open\"", entry.line, command)
+				case entry.Open:
+					t.Skipf("bypasses.jsonl:%d: open bypass: %s", entry.line, entry.Note)
+				case got != want:
+					t.Errorf("bypasses.jsonl:%d: ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v",
+						entry.line, command, got, want, detector.GetIssues())
+				}
+			})
+		}
+	}
+}
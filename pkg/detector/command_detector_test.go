@@ -1,7 +1,16 @@
 package detector
 
 import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"slices"
+	"sync"
 	"testing"
+	"time"
+
+	"mvdan.cc/sh/v3/syntax"
 )
 
 func TestNewCommandDetector(t *testing.T) {
@@ -251,6 +260,64 @@ func TestCommandDetector_IssueReporting(t *testing.T) {
 	}
 }
 
+func TestCommandDetector_MatchedCommands(t *testing.T) {
+	detector := NewCommandDetector([]CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}, 10)
+
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"git push origin main", []string{"git push origin main"}},
+		{"echo ok && bash -c 'git push'", []string{"git push"}},
+		{"git pull", nil},
+	}
+	for _, tt := range tests {
+		detector.ShouldBlockShellExpr(tt.command)
+		if got := detector.MatchedCommands(); !slices.Equal(got, tt.want) {
+			t.Errorf("MatchedCommands() after %q = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestCommandDetector_IssueDeduplication(t *testing.T) {
+	detector := NewCommandDetector([]CommandRule{{BlockedCommand: "git"}}, 10)
+	detector.ShouldBlockShellExpr("cat a | rev; cat b | rev; cat c | rev; cat d | tac")
+	want := []string{
+		"Warning: Possible reverse string obfuscation (rev used)",
+		"Warning: Possible reverse string obfuscation (tac used)",
+	}
+	if got := detector.GetIssues(); !slices.Equal(got, want) {
+		t.Errorf("GetIssues() = %q, want %q", got, want)
+	}
+}
+
+func TestCommandDetector_MaxIssues(t *testing.T) {
+	command := "cat a | rev; cat b | tac; cat c | rev"
+	rev := "Warning: Possible reverse string obfuscation (rev used)"
+	tac := "Warning: Possible reverse string obfuscation (tac used)"
+
+	tests := []struct {
+		name      string
+		maxIssues int
+		want      []string
+	}{
+		{"unlimited", 0, []string{rev, tac}},
+		{"capped", 1, []string{rev, "...and 1 more"}},
+		{"cap not reached", 2, []string{rev, tac}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector([]CommandRule{{BlockedCommand: "git"}}, 10)
+			detector.SetMaxIssues(tt.maxIssues)
+			detector.ShouldBlockShellExpr(command)
+			if got := detector.GetIssues(); !slices.Equal(got, tt.want) {
+				t.Errorf("GetIssues() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCommandDetector_InterspersedFlags(t *testing.T) {
 	rules := []CommandRule{
 		{
@@ -331,3 +398,429 @@ func TestCommandDetector_InterspersedFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandDetector_StageObserver(t *testing.T) {
+	rules := []CommandRule{
+		{
+			BlockedCommand:  "git",
+			BlockedPatterns: []string{"push"},
+		},
+	}
+
+	var stages []string
+	detector := NewCommandDetector(rules, 10)
+	detector.SetStageObserver(func(stage string) func() {
+		stages = append(stages, stage)
+		return func() {}
+	})
+
+	// Nested analysis (sh -c) must not report additional stages
+	detector.ShouldBlockShellExpr(`sh -c "git push"`)
+
+	want := []string{"parse", "evaluate_rules"}
+	if len(stages) != len(want) || stages[0] != want[0] || stages[1] != want[1] {
+		t.Errorf("observed stages = %v, want %v", stages, want)
+	}
+}
+
+func TestCommandDetector_CommandSubstitution(t *testing.T) {
+	rules := []CommandRule{
+		{
+			BlockedCommand:  "git",
+			BlockedPatterns: []string{"push"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		{"echoed substitution", "echo $(git push)", true, "Blocked command runs inside command substitution: git push"},
+		{"quoted substitution", `echo "pushed: $(git push origin main)"`, true, "Blocked command runs inside command substitution: git push origin main"},
+		{"backquotes", "echo `git push`", true, "Blocked command runs inside command substitution: git push"},
+		{"assignment", "out=$(git push 2>&1)", true, "Blocked command runs inside command substitution: git push"},
+		{"nested", "echo $(echo $(git push))", true, "Blocked command runs inside command substitution: git push"},
+		{"subcommand from substitution", "git $(echo push)", true, "git subcommand comes from command substitution $(echo push)"},
+		{"command name from substitution", "$(echo git) push", true, "Command name comes from command substitution $(echo git) - unable to verify safety"},
+		{"harmless substitution", "echo $(git status --short)", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			gotBlock := detector.ShouldBlockShellExpr(tt.command)
+			if gotBlock != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr() = %v, want %v. Issues: %v", gotBlock, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+}
+
+func TestCommandDetector_MatchedRules(t *testing.T) {
+	rules := []CommandRule{
+		{Name: "no-push", BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+		{BlockedCommand: "aws", BlockedPatterns: []string{"s3 rm", "s3 rb"}},
+		{BlockedCommand: "kubectl"},
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"named rule", "git push", []string{"no-push"}},
+		{"unnamed rule", "aws s3 rm s3://bucket/key", []string{"aws s3 rm, s3 rb"}},
+		{"found as argument", "xargs git push", []string{"no-push"}},
+		{"allowed", "git status", nil},
+		{"decoded pipeline has no rule", "echo Z2l0IHB1c2g= | base64 -d | sh", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			detector.ShouldBlockShellExpr(tt.command)
+			var got []string
+			for _, rule := range detector.MatchedRules() {
+				got = append(got, rule.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("MatchedRules() = %q, want %q. Issues: %v", got, tt.want, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestCommandDetector_DeterministicOrder(t *testing.T) {
+	// Evaluate concurrently even on a single CPU
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	rules := append(largePolicy(200),
+		CommandRule{Name: "git-guard", BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+		CommandRule{Name: "git-guard", BlockedCommand: "git", BlockedPatterns: []string{"reset --hard"}},
+	)
+	pushRule, resetRule := len(rules)-2, len(rules)-1
+
+	tests := []struct {
+		name        string
+		command     string
+		wantIndexes []int
+		wantIssues  []string
+	}{
+		// The first call in the command decides, not the first rule
+		{"source order", "git status && git reset --hard && git push", []int{resetRule}, []string{"Blocked git pattern detected"}},
+		{"nested call", "(cd app && git push) || git reset --hard", []int{pushRule}, []string{"Blocked git pattern detected"}},
+		{"issues in detection order", "echo $(git push)", []int{pushRule}, []string{"Blocked git pattern detected", "Blocked command runs inside command substitution: git push"}},
+		{"config order", "git op0004 && git push", []int{4}, []string{"Blocked git pattern detected"}},
+		{"allowed", "git status", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			for range 20 {
+				detector.ShouldBlockShellExpr(tt.command)
+				if got := detector.MatchedRuleIndexes(); !slices.Equal(got, tt.wantIndexes) {
+					t.Fatalf("MatchedRuleIndexes() = %v, want %v", got, tt.wantIndexes)
+				}
+				if got := detector.GetIssues(); !slices.Equal(got, tt.wantIssues) {
+					t.Fatalf("GetIssues() = %q, want %q", got, tt.wantIssues)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandDetector_Evaluate(t *testing.T) {
+	detector := NewCommandDetector([]CommandRule{
+		{Name: "no-push", BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+		{Name: "no-destroy", BlockedCommand: "terraform", BlockedPatterns: []string{"destroy"}},
+	}, 10)
+
+	// Evaluate leaves the outcome of ShouldBlockShellExpr alone
+	if !detector.ShouldBlockShellExpr("git push") {
+		t.Fatal("expected git push to be blocked")
+	}
+	got := detector.Evaluate("cd infra && terraform destroy")
+	want := Result{
+		Blocked:     true,
+		Issues:      []string{"Blocked terraform pattern detected"},
+		Rules:       []CommandRule{detector.commandRules[1]},
+		RuleIndexes: []int{1},
+		Commands:    []string{"terraform destroy"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Evaluate() = %+v, want %+v", got, want)
+	}
+	if rules := detector.MatchedRuleIndexes(); !slices.Equal(rules, []int{0}) {
+		t.Errorf("MatchedRuleIndexes() after Evaluate() = %v, want [0]", rules)
+	}
+	if got := detector.Evaluate("echo 'unterminated"); !got.Blocked || !got.ParseFailed {
+		t.Errorf("Evaluate() of unparsable input = %+v, want a parse failure block", got)
+	}
+}
+
+func TestCommandDetector_EvaluateContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	detector := NewCommandDetector([]CommandRule{
+		// Stands in for a slow check that outlives the caller's deadline
+		{Name: "slow", BlockedCommand: "make", Match: func(Invocation) string {
+			cancel()
+			return ""
+		}},
+		{Name: "no-push", BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+	}, 10)
+
+	got, err := detector.EvaluateContext(context.Background(), "git push")
+	if err != nil || !got.Blocked || !slices.Equal(got.RuleIndexes, []int{1}) {
+		t.Errorf("EvaluateContext() = %+v, %v, want blocked by rule 1", got, err)
+	}
+	if got, err := detector.EvaluateContext(ctx, "git status"); err != nil || got.Blocked {
+		t.Errorf("EvaluateContext() = %+v, %v, want allowed", got, err)
+	}
+
+	// Canceled partway through: make allows, but echo is never analyzed
+	got, err = detector.EvaluateContext(ctx, "make build; echo done")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("EvaluateContext() error = %v, want context.Canceled", err)
+	}
+	want := Result{Blocked: true, Issues: []string{"Analysis canceled: context canceled"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EvaluateContext() = %+v, want %+v", got, want)
+	}
+
+	// Already done before the analysis starts
+	if got, err := detector.EvaluateContext(ctx, "git status"); !errors.Is(err, context.Canceled) || !got.Blocked {
+		t.Errorf("EvaluateContext() with a canceled context = %+v, %v, want a blocking context.Canceled", got, err)
+	}
+	if errors.Is(err, ErrTimeout) {
+		t.Errorf("EvaluateContext() error = %v, cancellation is not a timeout", err)
+	}
+
+	expired, stop := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer stop()
+	got, err = detector.EvaluateContext(expired, "git status")
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) || !got.Blocked {
+		t.Errorf("EvaluateContext() past its deadline = %+v, %v, want a blocking ErrTimeout", got, err)
+	}
+}
+
+// TestCommandDetector_ConcurrentEvaluate shares one detector between
+// goroutines; run with -race.
+func TestCommandDetector_ConcurrentEvaluate(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	rules := append(largePolicy(200), CommandRule{Name: "git-guard", BlockedCommand: "git", BlockedPatterns: []string{"push"}})
+	detector := NewCommandDetector(rules, 10)
+	detector.SetAliases("git", map[string]string{"pub": "push"})
+	detector.SetEnvironment(map[string]string{"TOOL": "git"})
+
+	commands := []string{
+		"git status",
+		"git op0004 && git push",
+		"git pub origin main",
+		"$TOOL push",
+		"bash -c 'ls; git push'",
+		"echo $(kubectl op0002)",
+		"aws op0001 | tee log",
+		"echo 'unterminated",
+	}
+	want := make([]Result, len(commands))
+	for i, command := range commands {
+		want[i] = detector.Evaluate(command)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				for i, command := range commands {
+					if got := detector.Evaluate(command); !reflect.DeepEqual(got, want[i]) {
+						t.Errorf("concurrent Evaluate(%q) = %+v, want %+v", command, got, want[i])
+					}
+					if _, err := detector.AnalyzeSegments(command); err != nil && i != len(commands)-1 {
+						t.Errorf("concurrent AnalyzeSegments(%q) error: %v", command, err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCommandDetector_AnalyzeSegments(t *testing.T) {
+	rules := []CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+
+	tests := []struct {
+		name       string
+		command    string
+		wantBlocks []bool
+		wantPrefix string
+	}{
+		{"blocked last segment", "git status && git push", []bool{false, true}, "git status"},
+		{"blocked middle segment", "make build && git push origin main; ls", []bool{false, true, false}, "make build"},
+		{"longer prefix", "git fetch; git status || true && git push", []bool{false, false, false, true}, "git fetch ; git status || true"},
+		{"blocked first segment", "git push && git status", []bool{true, false}, ""},
+		{"nothing blocked", "git status && ls", []bool{false, false}, ""},
+		{"pipeline segment", "git log | head && echo hi | git push", []bool{false, true}, "git log | head"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			results, err := detector.AnalyzeSegments(tt.command)
+			if err != nil {
+				t.Fatalf("AnalyzeSegments() error: %v", err)
+			}
+			var got []bool
+			for _, result := range results {
+				got = append(got, result.Blocked)
+				if result.Blocked && len(result.Issues) == 0 {
+					t.Errorf("segment %q is blocked without issues", result.Command)
+				}
+			}
+			if !slices.Equal(got, tt.wantBlocks) {
+				t.Errorf("AnalyzeSegments() blocked = %v, want %v", got, tt.wantBlocks)
+			}
+			if prefix := AllowedPrefix(results); prefix != tt.wantPrefix {
+				t.Errorf("AllowedPrefix() = %q, want %q", prefix, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestCommandDetector_AnalyzeSegmentsKeepsState(t *testing.T) {
+	detector := NewCommandDetector([]CommandRule{{Name: "no-push", BlockedCommand: "git", BlockedPatterns: []string{"push"}}}, 10)
+	detector.ShouldBlockShellExpr("git push")
+	issues := detector.GetIssues()
+
+	if _, err := detector.AnalyzeSegments("ls && git status"); err != nil {
+		t.Fatalf("AnalyzeSegments() error: %v", err)
+	}
+	if got := detector.GetIssues(); !slices.Equal(got, issues) {
+		t.Errorf("GetIssues() after AnalyzeSegments() = %q, want %q", got, issues)
+	}
+	if got := detector.MatchedRules(); len(got) != 1 || got[0].Name != "no-push" {
+		t.Errorf("MatchedRules() after AnalyzeSegments() = %v, want no-push", got)
+	}
+	if _, err := detector.AnalyzeSegments("ls && echo 'unterminated"); !errors.Is(err, ErrParse) {
+		t.Errorf("AnalyzeSegments() of unparsable input = %v, want ErrParse", err)
+	}
+}
+
+func TestCommandDetector_SetDialect(t *testing.T) {
+	rules := []CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+	tests := []struct {
+		name        string
+		lang        syntax.LangVariant
+		command     string
+		wantBlock   bool
+		parseFailed bool
+	}{
+		{"mksh value substitution in bash", syntax.LangBash, "echo ${|git push;}", true, true},
+		{"mksh value substitution in mksh", syntax.LangMirBSDKorn, "echo ${|git push;}", true, false},
+		{"allowed in mksh", syntax.LangMirBSDKorn, "echo ${|git status;}", false, false},
+		{"bash array in posix", syntax.LangPOSIX, "a=(1 2); echo ${a[@]}", true, true},
+		{"bats test in bats", syntax.LangBats, `@test "push" { git push; }`, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			detector.SetDialect(tt.lang)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+			if got := detector.ParseFailed(); got != tt.parseFailed {
+				t.Errorf("ParseFailed() = %v, want %v", got, tt.parseFailed)
+			}
+		})
+	}
+}
+
+func TestCommandDetector_SetEnvironment(t *testing.T) {
+	rules := []CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+	env := map[string]string{"HOME": "/home/me", "EDITOR": "vim"}
+	tests := []struct {
+		name      string
+		env       map[string]string
+		command   string
+		wantBlock bool
+	}{
+		{"dynamic without environment", nil, "$EDITOR notes.md", true},
+		{"editor resolves", env, "$EDITOR notes.md", false},
+		{"home path resolves to blocked command", env, "$HOME/bin/git push", true},
+		{"home path resolves to allowed command", env, "$HOME/bin/git status", false},
+		{"earlier assignment", map[string]string{}, "GIT=git; $GIT push", true},
+		{"earlier assignment allowed", map[string]string{}, "LS=ls; $LS -la", false},
+		{"unknown variable stays dynamic", env, "$PAGER README.md", true},
+		{"eval keeps variables dynamic", env, `eval "EDITOR=git"; $EDITOR push`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			detector.SetEnvironment(tt.env)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestCommandDetector_EnvSplitString(t *testing.T) {
+	rules := []CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+	tests := []struct {
+		command   string
+		wantBlock bool
+	}{
+		{"env -S 'git push'", true},
+		{"env --split-string='git push' --force", true},
+		{"env -S 'git status'", false},
+		{`env -S "$CMD"`, true},
+		{"env -S 'git status; git push'", true},
+		{"env -C /tmp -S '${GIT} status'", true},
+		{"env git commit -S -m msg", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestCommandDetector_NormalizedCalls(t *testing.T) {
+	rules := []CommandRule{
+		{BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+		{BlockedCommand: "env", BlockedPatterns: []string{"-S"}},
+	}
+	tests := []struct {
+		command   string
+		wantBlock bool
+	}{
+		{"command git push", true},
+		{"builtin command -p git push", true},
+		{"exec -a sh git push", true},
+		{"env -i GIT_DIR=x git push", true},
+		{"command env -u HOME -- /usr/bin/git push", true},
+		{`g"i"'t' "push"`, true},
+		{"command -v git", false},
+		{"env -i git status", false},
+		{"env -S 'git status'", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
@@ -2,8 +2,9 @@
 package detector
 
 import (
-	"path"
 	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
 )
 
 // normalizeCommand extracts the base command name from a full path.
@@ -11,11 +12,11 @@ import (
 //   - Full paths: /usr/bin/git -> git
 //   - Relative paths: ./git -> git
 //   - User paths: ~/.nix-profile/bin/aws -> aws
-//   - Windows paths with .exe: git.exe -> git
+//   - Windows paths with .exe: C:\Git\bin\git.exe -> git
 func normalizeCommand(cmd string) string {
 	// Extract just the base name from the path
-	// This handles any path like /usr/bin/git, ./git, ~/.nix-profile/bin/aws, etc.
-	base := path.Base(cmd)
+	// This handles any path like /usr/bin/git, ./git, ~/.nix-profile/bin/aws, C:\Git\git, etc.
+	base := pathmatch.Base(cmd)
 
 	// Remove .exe suffix for Windows
 	base = strings.TrimSuffix(base, ".exe")
@@ -57,3 +58,25 @@ func isMatchingCommand(cmd, ruleCmd string) bool {
 func isEchoCommand(cmd string) bool {
 	return normalizeCommand(cmd) == "echo"
 }
+
+// ParseCommandSpec parses a "command [pattern ...]" spec as used by the -cmd
+// flags into a CommandRule. A spec without patterns matches every use of the
+// command. It returns false for an empty spec.
+func ParseCommandSpec(spec string) (CommandRule, bool) {
+	parts := strings.Fields(spec)
+	if len(parts) == 0 {
+		return CommandRule{}, false
+	}
+
+	// Empty patterns means "check command only, not subcommands",
+	// so use "*" to indicate "match any subcommand"
+	patterns := []string{"*"}
+	if len(parts) > 1 {
+		patterns = parts[1:]
+	}
+
+	return CommandRule{
+		BlockedCommand:  parts[0],
+		BlockedPatterns: patterns,
+	}, true
+}
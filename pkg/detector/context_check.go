@@ -0,0 +1,82 @@
+// Package detector - context-aware rule conditions
+package detector
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// RecentCommand is a tool call from the session history, oldest first.
+// Command is empty for tools other than Bash.
+type RecentCommand struct {
+	Command   string
+	Succeeded bool
+}
+
+// AllowAfter lifts a rule when a matching command succeeded recently, e.g.
+// allowing "git push" only when "go test" passed in the last 10 tool calls.
+type AllowAfter struct {
+	Command string // Command spec ("go test", "make test") that must have succeeded
+	Within  int    // Number of most recent tool calls to consider (0 = all)
+}
+
+// SetRecentCommands provides the session history used by AllowAfter conditions.
+// Without it, rules with conditions are always enforced.
+func (d *CommandDetector) SetRecentCommands(commands []RecentCommand) {
+	d.recentCommands = commands
+}
+
+// ruleLifted reports whether the rule's AllowAfter condition is satisfied by
+// the recent commands.
+func (d *CommandDetector) ruleLifted(rule CommandRule) bool {
+	condition := rule.AllowAfter
+	if condition == nil {
+		return false
+	}
+	want := strings.Fields(condition.Command)
+	if len(want) == 0 {
+		return false
+	}
+
+	recent := d.recentCommands
+	if condition.Within > 0 && condition.Within < len(recent) {
+		recent = recent[len(recent)-condition.Within:]
+	}
+	return slices.ContainsFunc(recent, func(rc RecentCommand) bool {
+		return rc.Succeeded && commandInvokes(rc.Command, want)
+	})
+}
+
+// commandInvokes reports whether any static call in shellExpr starts with the
+// words of want, so "cd app && go test ./..." satisfies "go test".
+func commandInvokes(shellExpr string, want []string) bool {
+	if shellExpr == "" {
+		return false
+	}
+	ast, err := shellparse.Parse(shellExpr)
+	if err != nil {
+		return false
+	}
+	for _, call := range shellparse.CallExprs(ast) {
+		if len(call.Args) < len(want) {
+			continue
+		}
+		matched := true
+		for i, word := range want {
+			arg, isStatic := shellparse.StaticWord(call.Args[i])
+			if i == 0 {
+				arg = normalizeCommand(arg)
+			}
+			if !isStatic || arg != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
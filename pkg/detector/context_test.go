@@ -0,0 +1,79 @@
+package detector
+
+import (
+	"testing"
+)
+
+func TestCommandDetector_AllowAfter(t *testing.T) {
+	rules := []CommandRule{
+		{
+			BlockedCommand:  "git",
+			BlockedPatterns: []string{"push"},
+			AllowAfter:      &AllowAfter{Command: "go test", Within: 3},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		recent    []RecentCommand
+		command   string
+		wantBlock bool
+	}{
+		{
+			name:      "No context keeps rule enforced",
+			command:   "git push",
+			wantBlock: true,
+		},
+		{
+			name:      "Tests passed recently",
+			recent:    []RecentCommand{{Command: "go test ./...", Succeeded: true}},
+			command:   "git push",
+			wantBlock: false,
+		},
+		{
+			name:      "Tests passed inside a compound command",
+			recent:    []RecentCommand{{Command: "cd app && go test ./...", Succeeded: true}},
+			command:   "git push",
+			wantBlock: false,
+		},
+		{
+			name:      "Tests failed",
+			recent:    []RecentCommand{{Command: "go test ./...", Succeeded: false}},
+			command:   "git push",
+			wantBlock: true,
+		},
+		{
+			name: "Tests passed too long ago",
+			recent: []RecentCommand{
+				{Command: "go test ./...", Succeeded: true},
+				{Command: "ls", Succeeded: true},
+				{},
+				{Command: "git status", Succeeded: true},
+			},
+			command:   "git push",
+			wantBlock: true,
+		},
+		{
+			name:      "Mentioning tests is not running them",
+			recent:    []RecentCommand{{Command: "echo go test", Succeeded: true}},
+			command:   "git push",
+			wantBlock: true,
+		},
+		{
+			name:      "Lifted rule also applies to indirect execution",
+			recent:    []RecentCommand{{Command: "go test ./...", Succeeded: true}},
+			command:   "xargs git push",
+			wantBlock: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			detector.SetRecentCommands(tt.recent)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v (issues: %v)", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+// Package detector - commands started detached by session managers
+package detector
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// tmuxShellCommands maps the tmux commands that start a shell command to the
+// options of each that take a value.
+var tmuxShellCommands = map[string]string{
+	"new-session": "cefFnstxy", "new": "cefFnstxy",
+	"new-window": "ceFnt", "neww": "ceFnt",
+	"split-window": "ceFlpt", "splitw": "ceFlpt",
+	"respawn-pane": "cet", "respawnp": "cet",
+	"respawn-window": "cet", "respawnw": "cet",
+	"run-shell": "cdt", "run": "cdt",
+}
+
+// tmuxKeys are the tmux key names send-keys types as more than their name.
+// Other keys that are not plain text, such as C-c, end the typed line.
+var tmuxKeys = map[string]string{
+	"Enter": "\n", "KPEnter": "\n", "C-m": "\n", "C-j": "\n",
+	"Space": " ", "Tab": "\t",
+}
+
+// screenStuffEscapes are the escapes screen's stuff command turns into
+// newlines, submitting the typed line.
+var screenStuffEscapes = strings.NewReplacer(`\n`, "\n", `\r`, "\n", "^M", "\n", "^J", "\n", `\012`, "\n", `\015`, "\n")
+
+// checkDetachedCommands analyzes the commands a session manager starts in the
+// background or types into a session, which run after the call returns.
+// Examples:
+//   - tmux send-keys -t build 'git push' Enter
+//   - tmux new-session -d 'aws ec2 terminate-instances ...'
+//   - screen -dm bash -c "git push"
+//   - nohup sh -c 'git push' &
+func (d *CommandDetector) checkDetachedCommands(call *syntax.CallExpr) bool {
+	cmd, isStatic := shellparse.StaticWord(call.Args[0])
+	if !isStatic {
+		return false
+	}
+	var scripts []string
+	switch normalizeCommand(cmd) {
+	case "tmux":
+		scripts = tmuxScripts(call.Args[1:])
+	case "screen":
+		scripts = screenScripts(call.Args[1:])
+	case "nohup", "setsid":
+		scripts = execScript(skipShortOptions(call.Args[1:], ""))
+	default:
+		return false
+	}
+	for _, script := range scripts {
+		if d.analyzeShellExprRecursive(script) {
+			d.addIssue("Blocked command runs detached by " + normalizeCommand(cmd) + ": " + strings.TrimSpace(script))
+			return true // BLOCK
+		}
+	}
+	return false
+}
+
+// tmuxScripts returns the shell code a tmux invocation runs: shell commands
+// of commands such as new-session and run-shell, and the text send-keys
+// types. Commands may be chained with ";", written \; or ';' in the shell.
+func tmuxScripts(args []*syntax.Word) []string {
+	var scripts []string
+	args = skipShortOptionsFunc(args, "cfLST", func(opt byte, value string) {
+		if opt == 'c' {
+			scripts = append(scripts, value) // tmux -c runs a shell command
+		}
+	})
+	for len(args) > 0 {
+		end := len(args)
+		for i, arg := range args {
+			if value, _ := shellparse.StaticWord(arg); value == ";" || value == `\;` {
+				end = i
+				break
+			}
+		}
+		scripts = append(scripts, tmuxCommandScripts(args[:end])...)
+		args = args[min(end+1, len(args)):]
+	}
+	return scripts
+}
+
+// tmuxCommandScripts returns the shell code a single tmux command runs.
+func tmuxCommandScripts(args []*syntax.Word) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	name, _ := shellparse.StaticWord(args[0])
+	if withValue, ok := tmuxShellCommands[name]; ok {
+		return shellCommandScript(skipShortOptions(args[1:], withValue))
+	}
+	switch name {
+	case "send-keys", "send":
+		literal := false
+		keys := skipShortOptionsFunc(args[1:], "cNt", func(opt byte, _ string) {
+			literal = literal || opt == 'l'
+		})
+		return []string{typedKeys(keys, literal)}
+	case "if-shell", "if":
+		rest := skipShortOptions(args[1:], "t")
+		if len(rest) == 0 {
+			return nil
+		}
+		scripts := shellCommandScript(rest[:1])
+		for _, command := range rest[1:] {
+			// The other arguments are tmux commands, which may run shell commands too
+			if value, isStatic := shellparse.StaticWord(command); isStatic {
+				scripts = append(scripts, "tmux "+value)
+			}
+		}
+		return scripts
+	}
+	return nil
+}
+
+// typedKeys returns the text tmux send-keys types for keys. Key names such as
+// Enter become the characters they type, unless literal (-l) is set.
+func typedKeys(keys []*syntax.Word, literal bool) string {
+	var sb strings.Builder
+	for _, key := range keys {
+		value := wordText(key)
+		switch typed, ok := tmuxKeys[value]; {
+		case literal:
+			sb.WriteString(value)
+		case ok:
+			sb.WriteString(typed)
+		case isKeyName(value):
+			sb.WriteString("\n")
+		default:
+			sb.WriteString(value)
+		}
+	}
+	return sb.String()
+}
+
+// isKeyName reports whether a send-keys argument is a key with modifiers,
+// such as C-c or M-x, rather than text.
+func isKeyName(key string) bool {
+	return len(key) >= 3 && strings.Contains("CMS", key[:1]) && key[1] == '-'
+}
+
+// screenScripts returns the shell code a screen invocation runs: the program
+// of a new session or window, and the text the stuff command types.
+func screenScripts(args []*syntax.Word) []string {
+	for len(args) > 0 {
+		arg, isStatic := shellparse.StaticWord(args[0])
+		switch {
+		case !isStatic || arg == "" || arg[0] != '-':
+			return execScript(args) // The program and its arguments
+		case arg == "--":
+			return execScript(args[1:])
+		case arg == "-Logfile":
+			args = args[min(2, len(args)):]
+		default:
+			command := false
+			args = skipShortOption(args, "cehpSsTt", func(opt byte, _ string) {
+				command = command || opt == 'X'
+			})
+			if command {
+				return screenCommandScripts(args) // -X sends a command to a running session
+			}
+		}
+	}
+	return nil
+}
+
+// screenCommandScripts returns the shell code a screen command sent with -X
+// runs.
+func screenCommandScripts(args []*syntax.Word) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	name, _ := shellparse.StaticWord(args[0])
+	switch name {
+	case "stuff":
+		var typed []string
+		for _, word := range args[1:] {
+			typed = append(typed, screenStuffEscapes.Replace(wordText(word)))
+		}
+		return []string{strings.Join(typed, " ")}
+	case "screen", "exec":
+		return screenScripts(args[1:])
+	}
+	return nil
+}
+
+// shellCommandScript returns the shell code of a shell-command operand: a
+// single argument is run by the shell, several are run as one command.
+func shellCommandScript(args []*syntax.Word) []string {
+	if len(args) == 1 {
+		if value, isStatic := shellparse.StaticWord(args[0]); isStatic {
+			return []string{value}
+		}
+	}
+	return execScript(args)
+}
+
+// execScript returns a program and its arguments as shell code, with each
+// word kept as written so the command parses back into the same words.
+func execScript(args []*syntax.Word) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	words := make([]string, len(args))
+	for i, word := range args {
+		words[i] = shellparse.Print(word)
+	}
+	return []string{strings.Join(words, " ")}
+}
+
+// skipShortOptions returns args after their leading options, up to the first
+// operand or "--". withValue lists the option letters that take a value, as
+// in -t target or -ttarget.
+func skipShortOptions(args []*syntax.Word, withValue string) []*syntax.Word {
+	return skipShortOptionsFunc(args, withValue, func(byte, string) {})
+}
+
+// skipShortOptionsFunc is skipShortOptions calling option with each option
+// letter and, for those that take one, its value.
+func skipShortOptionsFunc(args []*syntax.Word, withValue string, option func(opt byte, value string)) []*syntax.Word {
+	for len(args) > 0 {
+		arg, isStatic := shellparse.StaticWord(args[0])
+		if !isStatic || arg == "-" || !strings.HasPrefix(arg, "-") {
+			return args
+		}
+		if arg == "--" {
+			return args[1:]
+		}
+		args = skipShortOption(args, withValue, option)
+	}
+	return args
+}
+
+// skipShortOption returns args after their first word, a group of short
+// options such as -tt or -p22, and the value of its last option when it takes
+// a separate one, calling option as skipShortOptionsFunc does.
+func skipShortOption(args []*syntax.Word, withValue string, option func(opt byte, value string)) []*syntax.Word {
+	arg, _ := shellparse.StaticWord(args[0])
+	args = args[1:]
+	for i := 1; i < len(arg); i++ {
+		if !strings.ContainsRune(withValue, rune(arg[i])) {
+			option(arg[i], "")
+			continue
+		}
+		value := arg[i+1:]
+		if value == "" && len(args) > 0 {
+			value, _ = shellparse.StaticWord(args[0])
+			args = args[1:]
+		}
+		option(arg[i], value)
+		break
+	}
+	return args
+}
@@ -0,0 +1,53 @@
+package detector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandDetector_DetachedCommands(t *testing.T) {
+	rules := []CommandRule{
+		{BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+		{BlockedCommand: "aws", BlockedPatterns: []string{"ec2 terminate-instances"}},
+	}
+
+	tests := []struct {
+		name      string
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		{"tmux send-keys", "tmux send-keys -t build 'git push' Enter", true, "Blocked command runs detached by tmux: git push"},
+		{"tmux send-keys key by key", "tmux send -t build git Space push C-m", true, "Blocked command runs detached by tmux: git push"},
+		{"tmux literal keys", "tmux send-keys -l 'git push'", true, "Blocked command runs detached by tmux: git push"},
+		{"tmux new-session", "tmux new-session -d -s ops 'aws ec2 terminate-instances --instance-ids i-0abc'", true, "Blocked command runs detached by tmux: aws ec2 terminate-instances --instance-ids i-0abc"},
+		{"tmux new-session with arguments", `tmux new -d -s ops bash -c "git push"`, true, `Blocked command runs detached by tmux: bash -c "git push"`},
+		{"tmux split-window", "tmux splitw -h -l 30 'git push'", true, "Blocked command runs detached by tmux: git push"},
+		{"tmux run-shell", "tmux run-shell -b 'git push'", true, "Blocked command runs detached by tmux: git push"},
+		{"tmux chained commands", `tmux new -d -s ops \; send-keys -t ops 'git push' Enter`, true, "Blocked command runs detached by tmux: git push"},
+		{"tmux if-shell", `tmux if-shell 'test -f go.mod' "run-shell 'git push'"`, true, ""},
+		{"tmux shell command option", "tmux -c 'git push'", true, "Blocked command runs detached by tmux: git push"},
+		{"screen session", `screen -dm bash -c "git push"`, true, `Blocked command runs detached by screen: bash -c "git push"`},
+		{"screen named session", "screen -S deploy -dm git push", true, "Blocked command runs detached by screen: git push"},
+		{"screen stuff", `screen -S deploy -X stuff 'git push\n'`, true, "Blocked command runs detached by screen: git push"},
+		{"nohup shell", "nohup sh -c 'git push' > /dev/null 2>&1 &", true, "Blocked command runs detached by nohup: sh -c 'git push'"},
+		{"setsid shell", "setsid -f bash -c 'git push'", true, "Blocked command runs detached by setsid: bash -c 'git push'"},
+		{"harmless tmux session", "tmux new-session -d -s dev 'go test ./...'", false, ""},
+		{"harmless keys", "tmux send-keys -t dev 'git status' Enter C-c", false, ""},
+		{"tmux listing", "tmux ls", false, ""},
+		{"harmless screen", "screen -ls", false, ""},
+		{"harmless nohup", "nohup make serve &", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+}
@@ -2,26 +2,101 @@
 package detector
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"slices"
+	"strings"
 
 	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
 )
 
 // CommandRule defines what commands and patterns to detect
 type CommandRule struct {
+	Name            string   // Optional label for block reasons, e.g. a policy rule or preset name
 	BlockedCommand  string   // Primary command to block (git, aws, kubectl)
 	BlockedPatterns []string // Subcommand patterns to block
+
+	// AllowAfter optionally lifts the rule based on recent session context
+	AllowAfter *AllowAfter
+
+	// Suggest is a safer alternative shown when the rule blocks, e.g. "use
+	// `git push --dry-run` or ask the human to push"
+	Suggest string
+
+	// Description says what the rule guards against, for verbose block
+	// output, e.g. "git push, including after global flags"
+	Description string
+
+	// Message is a text/template shown instead of the default block message
+	// when the rule blocks (see hook.RenderMessage). The detector only
+	// carries it.
+	Message string
+
+	// Args and Match, when Match is set, replace pattern matching: the static
+	// arguments are parsed with Args and Match decides whether to block.
+	Args  ArgSpec
+	Match ArgMatcher
 }
 
+// ArgMatcher inspects a parsed invocation of a rule's command and returns the
+// issue to report when it should be blocked, or "" to allow it.
+type ArgMatcher func(inv Invocation) string
+
+// StageObserver is notified when a top-level analysis stage ("parse" or
+// "evaluate_rules") begins. The returned function is called when the stage ends.
+// It allows callers to time or trace the detector without coupling it to a
+// specific telemetry library.
+type StageObserver func(stage string) (end func())
+
 // CommandDetector provides command detection for safety validation.
 // It analyzes shell commands to identify potentially dangerous operations
 // based on configured rules, detecting both direct and obfuscated attempts
 // to execute blocked commands.
+//
+// Analysis is deterministic: calls are checked in the order they appear in
+// the command (source order, depth-first into nested structures), each call
+// against the rules in the order given, so the same command and rules always
+// yield the same issues and matched rules, in the same order.
+//
+// Rules are indexed by command, and when a command has many candidate rules
+// they are evaluated concurrently, so ArgMatchers and ObfuscationDetectors
+// must be safe for concurrent use.
+//
+// ShouldBlockShellExpr keeps the outcome on the detector for GetIssues and
+// friends, so a detector used that way belongs to one goroutine. Evaluate
+// returns the outcome instead, and once configured with the Set methods a
+// detector can evaluate commands from any number of goroutines.
+//
+// EvaluateContext bounds an evaluation by a context: once it is done, the
+// analysis stops and the command is blocked, as an unparsable one is.
 type CommandDetector struct {
-	commandRules []CommandRule
-	issues       []string
-	maxDepth     int
-	currentDepth int
+	ctx           context.Context // Of the running EvaluateContext, nil otherwise
+	commandRules  []CommandRule
+	ruleIndex     ruleIndex
+	issues        []string
+	maxIssues     int
+	matched       []int    // Indexes into commandRules of the rules that blocked
+	matchedCalls  []string // Source of the calls that matched a rule
+	maxDepth      int
+	currentDepth  int
+	parseFailed   bool
+	lang          syntax.LangVariant
+	env           map[string]string
+	foreignSyntax bool
+	observer      StageObserver
+
+	recentCommands []RecentCommand
+	aliases        map[string]map[string]string
+
+	redirectCwd      string
+	redirectPatterns []string
+	protectedHosts   []string
+
+	obfuscationDetectors []ObfuscationDetector
+	obfuscationThreshold float64
 }
 
 // NewCommandDetector creates a new detector with safety checks.
@@ -35,24 +110,81 @@ func NewCommandDetector(rules []CommandRule, maxDepth int) *CommandDetector {
 
 	return &CommandDetector{
 		commandRules: rules,
+		ruleIndex:    newRuleIndex(rules),
 		issues:       make([]string, 0),
 		maxDepth:     maxDepth,
 		currentDepth: 0,
+
+		obfuscationDetectors: defaultObfuscationDetectors(rules),
+		obfuscationThreshold: DefaultObfuscationThreshold,
 	}
 }
 
 // GetIssues returns all detected security/safety issues found during analysis.
 // Returns a copy of the issues slice to prevent external modification.
 // Each issue describes why a command was blocked or flagged as suspicious.
+// Issues are reported once each; past the SetMaxIssues cap the rest are
+// replaced by a single "...and N more" line.
 func (d *CommandDetector) GetIssues() []string {
 	if len(d.issues) == 0 {
 		return nil
 	}
+	if d.maxIssues > 0 && len(d.issues) > d.maxIssues {
+		result := make([]string, d.maxIssues, d.maxIssues+1)
+		copy(result, d.issues)
+		return append(result, fmt.Sprintf("...and %d more", len(d.issues)-d.maxIssues))
+	}
 	result := make([]string, len(d.issues))
 	copy(result, d.issues)
 	return result
 }
 
+// SetMaxIssues caps the number of issues GetIssues returns. Zero, the
+// default, returns every issue.
+func (d *CommandDetector) SetMaxIssues(n int) {
+	d.maxIssues = n
+}
+
+// MatchedRules returns the rules that blocked the last analysis, in the order
+// they first matched. Blocks that no single rule explains, such as unparsable
+// input or obfuscation, have no matched rule.
+func (d *CommandDetector) MatchedRules() []CommandRule {
+	if len(d.matched) == 0 {
+		return nil
+	}
+	rules := make([]CommandRule, len(d.matched))
+	for i, index := range d.matched {
+		rules[i] = d.commandRules[index]
+	}
+	return rules
+}
+
+// MatchedRuleIndexes returns the positions, in the rules the detector was
+// created with, of the rules MatchedRules returns, in the same order. Unlike
+// rule names, positions tell apart rules that share a name.
+func (d *CommandDetector) MatchedRuleIndexes() []int {
+	return slices.Clone(d.matched)
+}
+
+// MatchedCommands returns the source of the simple commands that matched a
+// rule in the last analysis, as parsed, e.g. the git push inside
+// bash -c 'git push'. Blocks that no rule explains have none.
+func (d *CommandDetector) MatchedCommands() []string {
+	return slices.Clone(d.matchedCalls)
+}
+
+// String describes the rule for block reasons: its name when set, otherwise
+// its command and patterns.
+func (r CommandRule) String() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	if len(r.BlockedPatterns) == 0 {
+		return r.BlockedCommand
+	}
+	return r.BlockedCommand + " " + strings.Join(r.BlockedPatterns, ", ")
+}
+
 // ShouldBlockShellExpr is the main entry point for command analysis.
 // It parses and analyzes a shell expression to determine if it contains
 // any blocked commands or patterns.
@@ -62,15 +194,148 @@ func (d *CommandDetector) ShouldBlockShellExpr(shellExpr string) bool {
 	// Reset state for new analysis
 	d.currentDepth = 0
 	d.issues = d.issues[:0]
+	d.matched = d.matched[:0]
+	d.matchedCalls = d.matchedCalls[:0]
+	d.parseFailed = false
 	return d.analyzeShellExprRecursive(shellExpr)
 }
 
+// Result is the outcome of analyzing one command with Evaluate.
+type Result struct {
+	Blocked     bool
+	Issues      []string      // As GetIssues returns them
+	Rules       []CommandRule // As MatchedRules returns them
+	RuleIndexes []int         // As MatchedRuleIndexes returns them
+	Commands    []string      // As MatchedCommands returns them
+	ParseFailed bool
+}
+
+// Evaluate analyzes a shell expression like ShouldBlockShellExpr, but on
+// private state, and returns the outcome rather than keeping it on the
+// detector. It is safe for concurrent use, as long as no Set method is called
+// at the same time, and leaves the outcome of the last ShouldBlockShellExpr
+// call untouched.
+func (d *CommandDetector) Evaluate(shellExpr string) Result {
+	result, _ := d.EvaluateContext(context.Background(), shellExpr) //nolint:errcheck // Background is never done
+	return result
+}
+
+// EvaluateContext is Evaluate that stops analyzing when ctx is done, and then
+// returns a blocking Result with ctx's error, which also matches ErrTimeout
+// when the deadline passed. The error is nil for every evaluation that ran to
+// completion, whatever its outcome.
+func (d *CommandDetector) EvaluateContext(ctx context.Context, shellExpr string) (Result, error) {
+	scratch := d.fork()
+	scratch.ctx = ctx
+	blocked := scratch.ShouldBlockShellExpr(shellExpr)
+	err := ctx.Err()
+	if err != nil && !blocked {
+		// A check cut short may have allowed what it would have blocked
+		blocked = true
+		scratch.addIssue("Analysis canceled: " + err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return Result{
+		Blocked:     blocked,
+		Issues:      scratch.GetIssues(),
+		Rules:       scratch.MatchedRules(),
+		RuleIndexes: scratch.matched,
+		Commands:    scratch.matchedCalls,
+		ParseFailed: scratch.parseFailed,
+	}, err
+}
+
+// fork returns a detector sharing d's rules and settings, with analysis state
+// of its own.
+func (d *CommandDetector) fork() *CommandDetector {
+	scratch := *d
+	scratch.currentDepth = 0
+	scratch.issues, scratch.matched, scratch.matchedCalls = nil, nil, nil
+	scratch.parseFailed = false
+	return &scratch
+}
+
+// context returns the context of the running EvaluateContext, or
+// context.Background for analyses without one.
+func (d *CommandDetector) context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
+// checkCanceled blocks once the context of the running EvaluateContext is
+// done, so no more of the command is analyzed.
+func (d *CommandDetector) checkCanceled() bool {
+	if d.ctx == nil || d.ctx.Err() == nil {
+		return false
+	}
+	d.addIssue("Analysis canceled: " + d.ctx.Err().Error())
+	return true
+}
+
+// SetDialect sets the shell language commands are parsed as (default:
+// syntax.LangBash). A command that does not parse in it is blocked.
+func (d *CommandDetector) SetDialect(lang syntax.LangVariant) {
+	d.lang = lang
+}
+
+// SetEnvironment resolves variables in commands before analysis, so
+// "$HOME/bin/git push" is checked as a static git call rather than a dynamic
+// command. Values come from env and from assignments earlier in the command;
+// see shellparse.ResolveParams for when a value counts as certain. Pass nil,
+// the default, to treat every variable as dynamic.
+func (d *CommandDetector) SetEnvironment(env map[string]string) {
+	d.env = env
+}
+
+// SetStageObserver registers an observer for top-level analysis stages.
+// Pass nil to remove a previously registered observer.
+func (d *CommandDetector) SetStageObserver(observer StageObserver) {
+	d.observer = observer
+}
+
+// observeStage starts a stage if this is the top-level analysis and an observer
+// is registered. The returned function must be called when the stage ends.
+func (d *CommandDetector) observeStage(stage string) func() {
+	if d.observer == nil || d.currentDepth != 1 {
+		return func() {}
+	}
+	return d.observer(stage)
+}
+
+// ParseFailed reports whether the last analysis blocked because some part of
+// the expression could not be parsed.
+func (d *CommandDetector) ParseFailed() bool {
+	return d.parseFailed
+}
+
 // addIssue records a security/safety issue found during analysis.
 // These issues are returned to the user to explain why a command was blocked.
+// A repeated issue, as from the same call appearing many times, is kept once.
 func (d *CommandDetector) addIssue(issue string) {
+	if slices.Contains(d.issues, issue) {
+		return
+	}
 	d.issues = append(d.issues, issue)
 }
 
+// recordMatch notes that the rule at index blocked the command.
+func (d *CommandDetector) recordMatch(index int) {
+	if !slices.Contains(d.matched, index) {
+		d.matched = append(d.matched, index)
+	}
+}
+
+// recordMatchedCall notes the source of a call that matched a rule.
+func (d *CommandDetector) recordMatchedCall(call *syntax.CallExpr) {
+	if source := shellparse.Print(call); source != "" && !slices.Contains(d.matchedCalls, source) {
+		d.matchedCalls = append(d.matchedCalls, source)
+	}
+}
+
 // analyzeShellExprRecursive performs recursive analysis of shell expressions.
 // It parses the expression into an AST and checks each command call.
 // Tracks recursion depth to prevent stack overflow from deeply nested commands
@@ -83,20 +348,72 @@ func (d *CommandDetector) analyzeShellExprRecursive(shellExpr string) bool {
 		return true // BLOCK
 	}
 	defer func() { d.currentDepth-- }()
+	if d.checkCanceled() {
+		return true // BLOCK
+	}
 
 	// Parse shell expression into an AST
-	ast, err := parseShellExpression(shellExpr)
+	endParse := d.observeStage("parse")
+	ast, err := shellparse.ParseVariantContext(d.context(), shellExpr, d.lang)
+	if d.checkCanceled() {
+		endParse()
+		return true // BLOCK
+	}
+	if err != nil && d.foreignSyntax {
+		// zsh and fish constructs may parse once translated into bash
+		if translated, ok := translateForeignSyntax(shellExpr); ok {
+			ast, err = shellparse.ParseVariantContext(d.context(), translated, d.lang)
+		}
+		if d.checkCanceled() {
+			endParse()
+			return true // BLOCK
+		}
+		if err != nil {
+			endParse()
+			d.parseFailed = d.checkUnparsedWords(shellExpr)
+			return d.parseFailed
+		}
+	}
+	if err == nil && d.env != nil {
+		shellparse.ResolveParams(ast, d.env)
+	}
+	endParse()
 	if err != nil {
 		// Safety principle: If we can't understand it, don't run it
+		d.parseFailed = true
 		d.addIssue("Unable to parse shell expression: " + err.Error())
 		return true // BLOCK
 	}
 
-	// Extract command calls from the AST
-	calls := extractCallExprs(ast)
+	// Extract command calls from the AST, including those inside command
+	// substitutions, which execute even when their output is only echoed
+	calls := shellparse.CallExprs(ast)
+	substituted := shellparse.SubstitutedCalls(ast)
 
 	// Check if any command call should be blocked
-	return slices.ContainsFunc(calls, d.shouldBlockCallExpr)
+	endEvaluate := d.observeStage("evaluate_rules")
+	defer endEvaluate()
+	if d.checkRedirects(ast) {
+		return true // BLOCK
+	}
+	if d.checkDecodedExecution(ast) {
+		return true // BLOCK
+	}
+	if d.checkReversedStdin(ast) {
+		return true // BLOCK
+	}
+	if d.checkScheduledCommands(ast) {
+		return true // BLOCK
+	}
+	return slices.ContainsFunc(calls, func(call *syntax.CallExpr) bool {
+		if !d.shouldBlockCallExpr(call) {
+			return false
+		}
+		if kind, ok := substituted[call]; ok {
+			d.addIssue("Blocked command runs inside " + kind + ": " + shellparse.Print(call))
+		}
+		return true
+	})
 }
 
 // shouldBlockCallExpr evaluates whether a shell call expression should be blocked.
@@ -111,23 +428,62 @@ func (d *CommandDetector) shouldBlockCallExpr(call *syntax.CallExpr) bool {
 	if len(call.Args) == 0 {
 		return false // ALLOW: Empty call
 	}
+	if d.checkCanceled() {
+		return true // BLOCK
+	}
+
+	// Rules match the canonical form of the call, without wrappers such as
+	// command or env -i. The call as written is checked too, for rules on
+	// the wrappers themselves.
+	normalized := shellparse.NormalizeCall(call)
+	if normalized != call && d.shouldBlockCallExpr(normalized) {
+		return true // BLOCK
+	}
 
 	// Extract command name
-	cmd, cmdIsStatic := resolveStaticWord(call.Args[0])
+	cmd, cmdIsStatic := shellparse.StaticWord(call.Args[0])
+
+	// Check expansions that assemble a command, before they are reported
+	// as merely dynamic
+	if d.checkExpansionTricks(call) {
+		return true // BLOCK
+	}
 
 	// Check dynamic commands
-	if d.checkDynamicCommand(cmdIsStatic) {
+	if d.checkDynamicCommand(call.Args[0], cmdIsStatic) {
+		return true // BLOCK
+	}
+
+	// Check env -S strings that could not be split into a command
+	if normalized == call && d.checkEnvSplitString(call, cmd) {
+		return true // BLOCK
+	}
+
+	// Check scripts generated by process substitution
+	if d.checkSourcedProcSubst(call) {
 		return true // BLOCK
 	}
 
 	// Check direct command patterns
 	if d.checkDirectCommand(call, cmd) {
+		d.recordMatchedCall(call)
+		return true // BLOCK
+	}
+
+	// Check commands run on a remote host, as in ssh host 'git push'
+	if d.checkRemoteCommand(call) {
+		return true // BLOCK
+	}
+
+	// Check commands started in the background, as in tmux new -d 'git push'
+	if d.checkDetachedCommands(call) {
 		return true // BLOCK
 	}
 
 	// Check if any arguments are themselves blocked commands
 	// This handles cases like: xargs git push, find . -exec git push
 	if d.checkArgumentsForBlockedCommands(call) {
+		d.recordMatchedCall(call)
 		return true // BLOCK
 	}
 
@@ -147,10 +503,48 @@ func (d *CommandDetector) shouldBlockCallExpr(call *syntax.CallExpr) bool {
 // checkDynamicCommand detects attempts to use variable substitution or
 // command substitution to dynamically construct command names.
 // Example: $CMD push (where CMD="git") would be blocked.
-func (d *CommandDetector) checkDynamicCommand(cmdIsStatic bool) bool {
-	if !cmdIsStatic {
+func (d *CommandDetector) checkDynamicCommand(word *syntax.Word, cmdIsStatic bool) bool {
+	if cmdIsStatic {
+		return false
+	}
+	if hasCmdSubst(word) {
+		d.addIssue("Command name comes from command substitution " + shellparse.Print(word) + " - unable to verify safety")
+	} else {
 		d.addIssue("Command uses dynamic substitution - unable to verify safety")
-		return true
+	}
+	return true
+}
+
+// checkEnvSplitString detects env -S with a string NormalizeCall could not
+// split into words, as in env -S "$CMD" or env -S 'git push; ls', since the
+// command env runs is unknown.
+func (d *CommandDetector) checkEnvSplitString(call *syntax.CallExpr, cmd string) bool {
+	if normalizeCommand(cmd) != "env" {
+		return false
+	}
+	for i := 1; i < len(call.Args); i++ {
+		arg, _ := shellparse.StaticWord(call.Args[i])
+		switch {
+		case strings.HasPrefix(arg, "-S") || strings.HasPrefix(arg, "--split-string"):
+			d.addIssue("env -S runs a command that cannot be analyzed: " + shellparse.Print(call))
+			return true
+		case arg == "-u" || arg == "-C" || arg == "--unset" || arg == "--chdir":
+			i++
+		case arg == "--" || !strings.HasPrefix(arg, "-"):
+			return false // The command, whose own options are not env's
+		}
 	}
 	return false
 }
+
+// hasCmdSubst reports whether a word contains a command substitution.
+func hasCmdSubst(word *syntax.Word) bool {
+	found := false
+	syntax.Walk(word, func(n syntax.Node) bool {
+		if _, ok := n.(*syntax.CmdSubst); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -2,18 +2,28 @@
 package detector
 
 import (
+	"runtime"
 	"slices"
 	"strings"
 
 	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
 )
 
 // checkDirectCommand checks if the command directly matches any blocking rules.
 // This handles straightforward cases like "git push" or "aws delete-bucket"
 // where the command is explicitly stated without obfuscation.
+// Only rules indexed under the command are evaluated, concurrently when
+// there are many and more than one CPU to use.
 func (d *CommandDetector) checkDirectCommand(call *syntax.CallExpr, cmd string) bool {
-	for _, rule := range d.commandRules {
-		if blocked := d.checkRuleMatch(call, cmd, rule); blocked {
+	candidates := d.ruleIndex.candidates(cmd)
+	if len(candidates) >= concurrentRuleThreshold && runtime.GOMAXPROCS(0) > 1 {
+		return d.checkRulesConcurrently(call, cmd, candidates)
+	}
+	for _, i := range candidates {
+		if blocked := d.checkRuleMatch(call, cmd, d.commandRules[i]); blocked {
+			d.recordMatch(i)
 			return true
 		}
 	}
@@ -29,17 +39,28 @@ func (d *CommandDetector) checkRuleMatch(call *syntax.CallExpr, cmd string, rule
 		return false
 	}
 
+	// Rules can be lifted by recent context (e.g. tests passed)
+	if d.ruleLifted(rule) {
+		return false
+	}
+
 	// Extract arguments if any exist
-	var fullArgs string
+	var args []string
 	if len(call.Args) > 1 {
 		// Extract and validate arguments
-		args, hasDynamic := d.extractArguments(call.Args[1:], rule.BlockedCommand)
+		var hasDynamic bool
+		args, hasDynamic = d.extractArguments(call.Args[1:], rule.BlockedCommand)
 		if hasDynamic {
 			return true // BLOCK: Dynamic subcommand
 		}
-		fullArgs = strings.Join(args, " ")
 	}
 
+	// Flag-aware rules decide on the parsed invocation
+	if rule.Match != nil {
+		return d.checkInvocation(rule, args)
+	}
+	fullArgs := strings.Join(args, " ")
+
 	// Check blocked patterns
 	// If no arguments and pattern is "*", block the command
 	// If no arguments and specific patterns, don't block (command alone is OK)
@@ -66,11 +87,15 @@ func (d *CommandDetector) checkRuleMatch(call *syntax.CallExpr, cmd string, rule
 func (d *CommandDetector) extractArguments(args []*syntax.Word, command string) ([]string, bool) {
 	result := make([]string, 0, len(args))
 	for _, arg := range args {
-		argVal, argIsStatic := resolveStaticWord(arg)
+		argVal, argIsStatic := shellparse.StaticWord(arg)
 
 		// Check for dynamic subcommands
 		if !argIsStatic {
-			d.addIssue(command + " uses dynamic subcommand")
+			if hasCmdSubst(arg) {
+				d.addIssue(command + " subcommand comes from command substitution " + shellparse.Print(arg))
+			} else {
+				d.addIssue(command + " uses dynamic subcommand")
+			}
 			return nil, true // Has dynamic content
 		}
 
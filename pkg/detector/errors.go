@@ -0,0 +1,22 @@
+// Package detector - errors for commands that could not be analyzed
+package detector
+
+import (
+	"errors"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// Errors for commands the detector could not analyze, for errors.Is. Unlike
+// hook.ErrConfig and hook.ErrInputSchema, these concern the command itself,
+// which is blocked by default.
+var (
+	// ErrParse is matched by errors for commands that do not parse, as
+	// returned by AnalyzeSegments, Allowlist.Unlisted, and WrittenPaths. It
+	// is shellparse.ErrParse, so errors from the parser match it too.
+	ErrParse = shellparse.ErrParse
+
+	// ErrTimeout is matched by the error EvaluateContext returns when its
+	// context's deadline passed before the analysis finished.
+	ErrTimeout = errors.New("command analysis timed out")
+)
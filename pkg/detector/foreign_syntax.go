@@ -0,0 +1,90 @@
+// Package detector - best-effort handling of zsh and fish syntax
+package detector
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// foreignRewrites translate common zsh and fish constructs that the bash
+// parser rejects into bash with the same commands. They only feed analysis;
+// the command that runs is unchanged.
+var foreignRewrites = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// zsh parameter flags: ${(j:,:)arr} -> ${arr}
+	{regexp.MustCompile(`\$\{\([^)]*\)`), "${"},
+	// zsh glob qualifiers: **/*.go(.om[1,3]) -> **/*.go
+	{regexp.MustCompile(`([*?\]][^\s()|;&]*)\([^()\s]*\)`), "$1"},
+	// zsh process substitution to a temporary file: =(sort a) -> <(sort a)
+	{regexp.MustCompile(`(^|\s)=\(`), "$1<("},
+	// zsh background and disown: sleep 1 &! -> sleep 1 &
+	{regexp.MustCompile(`&[!|]`), "&"},
+	// fish command substitution as an argument: cd (dirname x) -> cd $(dirname x)
+	{regexp.MustCompile(`([^\s;|&(<>=$])(\s+)\(`), "$1$2$("},
+}
+
+// scriptRunners run their arguments, or text built from them, as commands.
+var scriptRunners = []string{"eval", "source", ".", "exec", "xargs"}
+
+// SetForeignSyntax enables best-effort handling of zsh and fish syntax, such
+// as "cd (dirname x)" or "ls *.go(.)", which the bash parser rejects. A
+// command that does not parse is translated into bash and analyzed; if it
+// still does not parse, it is only blocked when its words plausibly run a
+// blocked command or a shell. Without it, every unparsable command is blocked.
+func (d *CommandDetector) SetForeignSyntax(enabled bool) {
+	d.foreignSyntax = enabled
+}
+
+// translateForeignSyntax applies foreignRewrites to shellExpr. It reports
+// false when nothing was rewritten.
+func translateForeignSyntax(shellExpr string) (string, bool) {
+	translated := shellExpr
+	for _, rewrite := range foreignRewrites {
+		translated = rewrite.re.ReplaceAllString(translated, rewrite.repl)
+	}
+	return translated, translated != shellExpr
+}
+
+// checkUnparsedWords scans the words of an expression that could not be
+// parsed for blocked commands, for fish blocks like "if ...; end" that no
+// rewrite handles. A rule matches when its command appears as a word and
+// the words after it match its patterns. Shells and eval-like commands are
+// blocked too, since the script they run cannot be known.
+func (d *CommandDetector) checkUnparsedWords(shellExpr string) bool {
+	words := strings.FieldsFunc(shellExpr, func(r rune) bool {
+		return strings.ContainsRune(" \t\n;|&(){}<>`\"'$", r)
+	})
+	for i, word := range words {
+		if isShellInterpreter(word) || slices.Contains(scriptRunners, normalizeCommand(word)) {
+			d.addIssue("Unable to parse shell expression, and it may run a script with " + normalizeCommand(word))
+			return true // BLOCK
+		}
+		for _, j := range d.ruleIndex.candidates(word) {
+			rule := d.commandRules[j]
+			if !isMatchingCommand(word, rule.BlockedCommand) || d.ruleLifted(rule) {
+				continue
+			}
+			if d.unparsedRuleMatch(rule, words[i+1:]) {
+				d.addIssue("Unable to parse shell expression, and it may run blocked command '" + rule.BlockedCommand + "'")
+				d.recordMatch(j)
+				return true // BLOCK
+			}
+		}
+	}
+	return false
+}
+
+// unparsedRuleMatch reports whether the words following a rule's command
+// plausibly match the rule.
+func (d *CommandDetector) unparsedRuleMatch(rule CommandRule, args []string) bool {
+	if rule.Match != nil {
+		return rule.Match(rule.Args.Parse(args)) != ""
+	}
+	if len(rule.BlockedPatterns) == 0 {
+		return true
+	}
+	return hasBlockedPattern(strings.Join(args, " "), rule.BlockedPatterns)
+}
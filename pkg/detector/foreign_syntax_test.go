@@ -0,0 +1,77 @@
+package detector
+
+import "testing"
+
+func TestCommandDetector_ForeignSyntax(t *testing.T) {
+	rules := []CommandRule{
+		{BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+		{BlockedCommand: "terraform"},
+	}
+
+	tests := []struct {
+		name        string
+		command     string
+		wantBlock   bool
+		parseFailed bool
+	}{
+		// Translated into bash and analyzed
+		{"fish substitution", "cd (git rev-parse --show-toplevel)", false, false},
+		{"fish substitution runs blocked command", "echo (git push)", true, false},
+		{"fish set", "set x (pwd); and git status", false, false},
+		{"zsh glob qualifier", "ls **/*.go(.om[1,3])", false, false},
+		{"zsh parameter flags", "echo ${(j:,:)arr}", false, false},
+		{"zsh temp file substitution", "diff =(sort a) =(sort b)", false, false},
+		{"zsh temp file substitution runs blocked command", "cat =(git push)", true, false},
+		{"zsh disown", "make watch &!", false, false},
+		{"zsh disown of blocked command", "git push &!", true, false},
+
+		// Still unparsable: blocked only when a blocked command plausibly runs
+		{"fish if without blocked command", "if test -f go.mod; go test ./...; end", false, false},
+		{"fish if with blocked command", "if test -f go.mod; git push; end", true, true},
+		{"fish for with unconditional rule", "for d in a b; terraform -chdir=$d apply; end", true, true},
+		{"fish if with allowed subcommand", "if test -d .git; git status; end", false, false},
+		{"fish function with shell", "function f; bash -c $argv; end", true, true},
+		{"fish if with eval", "if true; eval $cmd; end", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			detector.SetForeignSyntax(true)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+			if got := detector.ParseFailed(); got != tt.parseFailed {
+				t.Errorf("ParseFailed() = %v, want %v", got, tt.parseFailed)
+			}
+		})
+	}
+}
+
+func TestCommandDetector_ForeignSyntaxDisabled(t *testing.T) {
+	detector := NewCommandDetector([]CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}, 10)
+	if !detector.ShouldBlockShellExpr("cd (pwd)") || !detector.ParseFailed() {
+		t.Errorf("unparsable command should be blocked without foreign syntax handling. Issues: %v", detector.GetIssues())
+	}
+}
+
+func TestTranslateForeignSyntax(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"cd (dirname x)", "cd $(dirname x)"},
+		{"set -l x (pwd)", "set -l x $(pwd)"},
+		{"(cd x && make)", "(cd x && make)"},
+		{"a && (cd x)", "a && (cd x)"},
+		{"echo $(pwd)", "echo $(pwd)"},
+		{"echo ${(f)lines}", "echo ${lines}"},
+		{"ls *.go(.)", "ls *.go"},
+		{"diff =(a) b", "diff <(a) b"},
+		{"sleep 1 &|", "sleep 1 &"},
+	}
+	for _, tt := range tests {
+		if got, _ := translateForeignSyntax(tt.in); got != tt.want {
+			t.Errorf("translateForeignSyntax(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -4,15 +4,18 @@ package detector
 import (
 	"slices"
 	"strings"
+	"unicode"
 
 	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
 )
 
 // checkObfuscation detects various obfuscation techniques used to hide commands:
 //   - Base64 decoding being piped to execution
-//   - Hex encoding
 //   - Echo with escape sequences (\x codes)
-//   - Character substitution patterns
+//   - Content patterns found by the registered ObfuscationDetectors
+//     (hex encoding, reversed strings, character substitution)
 //
 // These techniques are commonly used to bypass simple string matching.
 func (d *CommandDetector) checkObfuscation(call *syntax.CallExpr) bool {
@@ -24,9 +27,8 @@ func (d *CommandDetector) checkObfuscation(call *syntax.CallExpr) bool {
 	// Collect all static string content for other obfuscation checks
 	content := d.collectStaticContent(call)
 
-	// Check for hex encoding and other obfuscation patterns (but NOT base64)
-	if obfuscated, obfIssues := detectObfuscationExceptBase64(content); obfuscated {
-		d.issues = append(d.issues, obfIssues...)
+	// Run the registered content detectors (hex, reverse, substitution, ...)
+	if d.detectObfuscation(content) {
 		return true // BLOCK
 	}
 
@@ -40,7 +42,7 @@ func (d *CommandDetector) checkObfuscation(call *syntax.CallExpr) bool {
 func (d *CommandDetector) collectStaticContent(call *syntax.CallExpr) string {
 	var allContent strings.Builder
 	for _, arg := range call.Args {
-		val, isStatic := resolveStaticWord(arg)
+		val, isStatic := shellparse.StaticWord(arg)
 		if isStatic && val != "" {
 			allContent.WriteString(val)
 			allContent.WriteString(" ")
@@ -49,6 +51,33 @@ func (d *CommandDetector) collectStaticContent(call *syntax.CallExpr) string {
 	return allContent.String()
 }
 
+// checkReversedStdin runs the content detectors over the words of rev and
+// tac together with the heredoc or here-string they read, as in
+// rev <<< "hsup tig", which the words alone do not show. Other commands'
+// stdin is data, such as a script written with cat <<EOF > run.sh, and is
+// not scanned.
+func (d *CommandDetector) checkReversedStdin(node syntax.Node) bool {
+	blocked := false
+	syntax.Walk(node, func(n syntax.Node) bool {
+		stmt, ok := n.(*syntax.Stmt)
+		if !ok {
+			return !blocked
+		}
+		call, ok := stmt.Cmd.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		if cmd, _ := shellparse.StaticWord(call.Args[0]); !isReverseCommand(cmd) {
+			return true
+		}
+		if text, ok := stdinText(stmt); ok {
+			blocked = d.detectObfuscation(d.collectStaticContent(call) + text)
+		}
+		return !blocked
+	})
+	return blocked
+}
+
 // checkEchoEscapes detects echo commands using escape sequences to construct
 // hidden commands. Examples:
 //   - echo -e "\x67\x69\x74" (hex for "git")
@@ -56,13 +85,13 @@ func (d *CommandDetector) collectStaticContent(call *syntax.CallExpr) string {
 //
 // These can be piped to shell interpreters to execute obfuscated commands.
 func (d *CommandDetector) checkEchoEscapes(call *syntax.CallExpr) bool {
-	cmd, _ := resolveStaticWord(call.Args[0])
+	cmd, _ := shellparse.StaticWord(call.Args[0])
 	if !isEchoCommand(cmd) {
 		return false
 	}
 
 	for _, arg := range call.Args[1:] {
-		argStr, _ := resolveStaticWord(arg)
+		argStr, _ := shellparse.StaticWord(arg)
 
 		// Check for hex escapes
 		if strings.Contains(argStr, "\\x") || strings.Contains(argStr, "\\0") {
@@ -79,97 +108,16 @@ func (d *CommandDetector) checkEchoEscapes(call *syntax.CallExpr) bool {
 	return false
 }
 
-// detectObfuscationExceptBase64 performs obfuscation detection excluding base64
-// Base64 is now handled separately by checkBase64Execution to avoid false positives
-func detectObfuscationExceptBase64(s string) (bool, []string) {
-	var issues []string
-	detected := false
-
-	// Check for hex encoding patterns
-	if isLikelyHexEncoded(s) {
-		issues = append(issues, "Possible hex encoded content")
-		detected = true
-	}
-
-	// Check for reverse string patterns
-	if containsReversePattern(s) {
-		issues = append(issues, "Possible reverse string obfuscation")
-		detected = true
-	}
-
-	// Check for character substitution patterns
-	if containsSubstitutionPattern(s) {
-		issues = append(issues, "Possible character substitution obfuscation")
-		detected = true
-	}
-
-	return detected, issues
-}
-
-// isLikelyHexEncoded checks if a string looks like hex encoding
-func isLikelyHexEncoded(s string) bool {
-	// Must be at least 6 characters and even length
-	if len(s) < 6 || len(s)%2 != 0 {
-		return false
-	}
-
-	// Check if all characters are hex digits
-	for _, char := range s {
-		if (char < '0' || char > '9') && (char < 'a' || char > 'f') && (char < 'A' || char > 'F') {
-			return false
-		}
-	}
-
-	return true
-}
-
-// containsReversePattern checks for reverse string patterns
-func containsReversePattern(s string) bool {
-	// Look for common reverse patterns
-	reversePatterns := []string{
-		"rev", "tac", "hsup", "tig", // "push" reversed, "git" reversed
-	}
-
-	lowerS := strings.ToLower(s)
-	for _, pattern := range reversePatterns {
-		if strings.Contains(lowerS, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
-
-// containsSubstitutionPattern checks for character substitution obfuscation
-func containsSubstitutionPattern(s string) bool {
-	// Look for patterns with excessive variable substitutions
-	// ${} patterns that may indicate obfuscation
-	if strings.Count(s, "${") > 2 && strings.Contains(s, "}") {
-		return true
-	}
-
-	// Multiple quoted segments that could be obfuscation
-	if strings.Count(s, "\"") > 4 || strings.Count(s, "'") > 4 {
-		// Check if it contains git-related parts
-		if strings.Contains(strings.ToLower(s), "git") || strings.Contains(strings.ToLower(s), "push") {
-			return true
-		}
-	}
-
-	return false
-}
-
-// checkBase64Execution detects when base64 is being decoded AND executed.
-// This prevents false positives from file paths while catching actual threats like:
-//   - base64 -d | bash
-//   - echo <base64> | base64 --decode | sh
-//   - eval $(base64 -d ...)
+// checkBase64Execution warns about base64 decoding within a single call and
+// blocks base64 commands that name a shell interpreter. Decoded data reaching
+// an interpreter through a pipeline or substitution is blocked by
+// checkDecodedExecution.
 func (d *CommandDetector) checkBase64Execution(call *syntax.CallExpr) bool {
 	if len(call.Args) == 0 {
 		return false
 	}
 
-	cmd, _ := resolveStaticWord(call.Args[0])
+	cmd, _ := shellparse.StaticWord(call.Args[0])
 	normalizedCmd := normalizeCommand(cmd)
 
 	// Check if this is a base64 decode command
@@ -177,7 +125,7 @@ func (d *CommandDetector) checkBase64Execution(call *syntax.CallExpr) bool {
 		// Look for decode flags (-d, --decode, -D)
 		hasDecodeFlag := false
 		for i := 1; i < len(call.Args); i++ {
-			argStr, _ := resolveStaticWord(call.Args[i])
+			argStr, _ := shellparse.StaticWord(call.Args[i])
 			if argStr == "-d" || argStr == "--decode" || argStr == "-D" {
 				hasDecodeFlag = true
 				break
@@ -185,22 +133,17 @@ func (d *CommandDetector) checkBase64Execution(call *syntax.CallExpr) bool {
 		}
 
 		if hasDecodeFlag {
-			// This is a base64 decode operation
-			// In a real pipeline, we'd need to check if it's piped to a shell
-			// For now, we'll flag it with a warning but not block it
-			// The actual threat is when it's piped to execution
+			// Decoding alone is fine; decoded data reaching an interpreter
+			// is blocked by checkDecodedExecution, which sees the pipeline
 			d.addIssue("Warning: base64 decode detected - ensure output is not executed")
-			// Don't block just decoding - only block if we see execution patterns
 			return false
 		}
 	}
 
-	// Check for patterns where base64 output might be executed
-	// This would need more sophisticated pipeline analysis
-	// For now, check if command contains both base64 and shell interpreters
+	// Check for a base64 command that names a shell interpreter
 	if strings.Contains(cmd, "base64") {
 		for _, arg := range call.Args[1:] {
-			argStr, _ := resolveStaticWord(arg)
+			argStr, _ := shellparse.StaticWord(arg)
 			// Check if any argument mentions shell interpreters
 			if isShellInterpreter(argStr) || strings.Contains(argStr, "eval") {
 				d.addIssue("Base64 decode potentially being executed")
@@ -218,3 +161,140 @@ func isShellInterpreter(cmd string) bool {
 	normalizedCmd := normalizeCommand(cmd)
 	return slices.Contains(shells, normalizedCmd)
 }
+
+// maxBraceExpansion bounds the words a brace expansion may produce before the
+// command is considered too large to analyze.
+const maxBraceExpansion = 256
+
+// checkExpansionTricks detects shell expansions used to assemble a blocked
+// command that does not appear literally in the source:
+//   - Brace expansion: {git,push} runs "git push"
+//   - IFS as a separator: git${IFS}push runs "git push"
+//   - ANSI-C escapes for plain characters: $'\x67it' push runs "git push"
+func (d *CommandDetector) checkExpansionTricks(call *syntax.CallExpr) bool {
+	return d.checkBraceExpansion(call) || d.checkIFSSeparator(call) || d.checkANSICEscapes(call)
+}
+
+// checkBraceExpansion expands braces in the call's words and analyzes the
+// resulting command.
+func (d *CommandDetector) checkBraceExpansion(call *syntax.CallExpr) bool {
+	expanded := &syntax.CallExpr{Assigns: call.Assigns}
+	hasBraces := false
+	for _, arg := range call.Args {
+		words := shellparse.ExpandBraces(arg)
+		if words == nil {
+			words = []*syntax.Word{arg}
+		} else {
+			hasBraces = true
+		}
+		expanded.Args = append(expanded.Args, words...)
+		if len(expanded.Args) > maxBraceExpansion {
+			d.addIssue("Brace expansion produces too many words to analyze")
+			return true
+		}
+	}
+	if !hasBraces || !d.shouldBlockCallExpr(expanded) {
+		return false
+	}
+	d.addIssue("Brace expansion assembles a blocked command: " + shellparse.Print(expanded))
+	return true
+}
+
+// checkIFSSeparator replaces $IFS expansions with the space they usually
+// expand to and analyzes the resulting command.
+func (d *CommandDetector) checkIFSSeparator(call *syntax.CallExpr) bool {
+	usesIFS := false
+	words := make([]string, 0, len(call.Args))
+	for _, arg := range call.Args {
+		var sb strings.Builder
+		for _, part := range arg.Parts {
+			if param, ok := part.(*syntax.ParamExp); ok && param.Param != nil && param.Param.Value == "IFS" {
+				usesIFS = true
+				sb.WriteString(" ")
+				continue
+			}
+			sb.WriteString(shellparse.Print(part))
+		}
+		words = append(words, sb.String())
+	}
+	if !usesIFS {
+		return false
+	}
+	separated := strings.Join(words, " ")
+	if !d.analyzeShellExprRecursive(separated) {
+		return false
+	}
+	d.addIssue("$IFS used as a word separator to assemble a blocked command: " + separated)
+	return true
+}
+
+// checkANSICEscapes flags ANSI-C quoted words that escape plain letters or
+// digits. Escapes are needed for control characters, never for "git".
+func (d *CommandDetector) checkANSICEscapes(call *syntax.CallExpr) bool {
+	for _, arg := range call.Args {
+		for _, part := range arg.Parts {
+			quoted, ok := part.(*syntax.SglQuoted)
+			if !ok || !quoted.Dollar || !strings.Contains(quoted.Value, `\`) {
+				continue
+			}
+			decoded, ok := shellparse.DecodeANSIC(quoted)
+			if !ok || escapesPlainCharacters(quoted.Value, decoded) {
+				d.addIssue("ANSI-C quoting escapes plain characters (possible obfuscation): " + shellparse.Print(arg) + " decodes to " + decoded)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// escapesPlainCharacters reports whether decoding an ANSI-C string produced
+// letters or digits that are not in its source, i.e. they were escaped.
+func escapesPlainCharacters(source, decoded string) bool {
+	count := func(s string) int {
+		n := 0
+		for _, r := range s {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				n++
+			}
+		}
+		return n
+	}
+	// Escape sequences such as \x1b add letters and digits to the source, so
+	// plain characters were escaped only if decoding keeps more than the
+	// source's unescaped ones
+	return count(decoded) > count(stripEscapes(source))
+}
+
+// stripEscapes removes backslash escape sequences from an ANSI-C source.
+func stripEscapes(source string) string {
+	var sb strings.Builder
+	for i := 0; i < len(source); i++ {
+		if source[i] != '\\' || i+1 >= len(source) {
+			sb.WriteByte(source[i])
+			continue
+		}
+		i++ // Skip the escape letter
+		switch source[i] {
+		case 'x':
+			for j := 0; j < 2 && i+1 < len(source) && isHexDigit(source[i+1]); j++ {
+				i++
+			}
+		case 'u', 'U':
+			for j := 0; j < 8 && i+1 < len(source) && isHexDigit(source[i+1]); j++ {
+				i++
+			}
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			for j := 0; j < 2 && i+1 < len(source) && source[i+1] >= '0' && source[i+1] <= '7'; j++ {
+				i++
+			}
+		case 'c':
+			i++ // Control character
+		}
+	}
+	return sb.String()
+}
+
+// isHexDigit reports whether b is a hexadecimal digit.
+func isHexDigit(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
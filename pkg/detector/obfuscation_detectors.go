@@ -0,0 +1,195 @@
+// Package detector - pluggable obfuscation detectors
+package detector
+
+import (
+	"slices"
+	"strings"
+)
+
+// ObfuscationDetector inspects the static content of a command (its resolved
+// words joined by spaces) for one class of obfuscation.
+type ObfuscationDetector interface {
+	// Name identifies the detector, e.g. for disabling it.
+	Name() string
+	// Detect returns the confidence, from 0 (none) to 1 (certain), that
+	// content is obfuscated, and the issues describing what was found.
+	Detect(content string) (score float64, issues []string)
+}
+
+// DefaultObfuscationThreshold is the confidence at or above which an
+// obfuscation finding blocks the command. Lower scores are reported as warnings.
+const DefaultObfuscationThreshold = 0.5
+
+// DefaultObfuscationDetectors returns the built-in content detectors. The
+// reverse detector only blocks text that reverses to a rule's command, so the
+// copies NewCommandDetector registers are given its rules; these have none.
+func DefaultObfuscationDetectors() []ObfuscationDetector {
+	return defaultObfuscationDetectors(nil)
+}
+
+// defaultObfuscationDetectors returns the built-in content detectors for rules.
+func defaultObfuscationDetectors(rules []CommandRule) []ObfuscationDetector {
+	return []ObfuscationDetector{hexDetector{}, newReverseDetector(rules), substitutionDetector{}}
+}
+
+// RegisterObfuscationDetector adds a detector, replacing any registered
+// detector with the same name.
+func (d *CommandDetector) RegisterObfuscationDetector(detector ObfuscationDetector) {
+	d.DisableObfuscationDetector(detector.Name())
+	d.obfuscationDetectors = append(d.obfuscationDetectors, detector)
+}
+
+// DisableObfuscationDetector removes the detector with the given name and
+// reports whether one was registered.
+func (d *CommandDetector) DisableObfuscationDetector(name string) bool {
+	before := len(d.obfuscationDetectors)
+	d.obfuscationDetectors = slices.DeleteFunc(d.obfuscationDetectors, func(detector ObfuscationDetector) bool {
+		return detector.Name() == name
+	})
+	return len(d.obfuscationDetectors) < before
+}
+
+// SetObfuscationThreshold sets the confidence at or above which obfuscation
+// findings block. Findings below it are reported as warnings only.
+func (d *CommandDetector) SetObfuscationThreshold(threshold float64) {
+	d.obfuscationThreshold = threshold
+}
+
+// detectObfuscation runs every registered detector over content. It blocks if
+// any detector is at least as confident as the threshold.
+func (d *CommandDetector) detectObfuscation(content string) bool {
+	blocked := false
+	for _, detector := range d.obfuscationDetectors {
+		score, issues := detector.Detect(content)
+		if score <= 0 {
+			continue
+		}
+		if score >= d.obfuscationThreshold {
+			for _, issue := range issues {
+				d.addIssue(issue)
+			}
+			blocked = true
+			continue
+		}
+		for _, issue := range issues {
+			d.addIssue("Warning: " + issue)
+		}
+	}
+	return blocked
+}
+
+// hexDetector flags content that is entirely hex digits.
+type hexDetector struct{}
+
+func (hexDetector) Name() string { return "hex" }
+
+func (hexDetector) Detect(content string) (float64, []string) {
+	if isLikelyHexEncoded(content) {
+		return 0.8, []string{"Possible hex encoded content"}
+	}
+	return 0, nil
+}
+
+// reverseDetector flags text that reads as a blocked command when reversed,
+// as in echo hsup tig | rev. Using rev or tac alone is only a warning: both
+// have legitimate uses, and words such as "review" or "git rev-parse" merely
+// contain them. Reversed text piped to a shell is blocked by
+// checkDecodedExecution.
+type reverseDetector struct {
+	rules map[string][]CommandRule // By lowercased command
+}
+
+// newReverseDetector creates a reverseDetector for rules.
+func newReverseDetector(rules []CommandRule) reverseDetector {
+	byCommand := make(map[string][]CommandRule)
+	for _, rule := range rules {
+		cmd := strings.ToLower(rule.BlockedCommand)
+		byCommand[cmd] = append(byCommand[cmd], rule)
+	}
+	return reverseDetector{rules: byCommand}
+}
+
+func (reverseDetector) Name() string { return "reverse" }
+
+func (r reverseDetector) Detect(content string) (float64, []string) {
+	reversed := strings.Fields(strings.ToLower(reverseString(content)))
+	for i, word := range reversed {
+		for _, rule := range r.rules[word] {
+			if reversedArgsBlocked(reversed[i+1:], rule) {
+				return 0.9, []string{"Reversed text contains blocked command: " + strings.Join(reversed, " ")}
+			}
+		}
+	}
+
+	for _, word := range strings.Fields(content) {
+		if isReverseCommand(word) {
+			return 0.3, []string{"Possible reverse string obfuscation (" + normalizeCommand(word) + " used)"}
+		}
+	}
+	return 0, nil
+}
+
+// reversedArgsBlocked reports whether the reversed words following the rule's
+// command are arguments the rule blocks.
+func reversedArgsBlocked(args []string, rule CommandRule) bool {
+	if rule.Match != nil {
+		return rule.Match(rule.Args.Parse(args)) != ""
+	}
+	return hasBlockedPattern(strings.Join(args, " "), rule.BlockedPatterns)
+}
+
+// reverseString reverses s by rune.
+func reverseString(s string) string {
+	runes := []rune(s)
+	slices.Reverse(runes)
+	return string(runes)
+}
+
+// substitutionDetector flags content built from many expansions or quoted
+// fragments.
+type substitutionDetector struct{}
+
+func (substitutionDetector) Name() string { return "substitution" }
+
+func (substitutionDetector) Detect(content string) (float64, []string) {
+	if containsSubstitutionPattern(content) {
+		return 0.7, []string{"Possible character substitution obfuscation"}
+	}
+	return 0, nil
+}
+
+// isLikelyHexEncoded checks if a string looks like hex encoding
+func isLikelyHexEncoded(s string) bool {
+	// Must be at least 6 characters and even length
+	if len(s) < 6 || len(s)%2 != 0 {
+		return false
+	}
+
+	// Check if all characters are hex digits
+	for _, char := range s {
+		if (char < '0' || char > '9') && (char < 'a' || char > 'f') && (char < 'A' || char > 'F') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsSubstitutionPattern checks for character substitution obfuscation
+func containsSubstitutionPattern(s string) bool {
+	// Look for patterns with excessive variable substitutions
+	// ${} patterns that may indicate obfuscation
+	if strings.Count(s, "${") > 2 && strings.Contains(s, "}") {
+		return true
+	}
+
+	// Multiple quoted segments that could be obfuscation
+	if strings.Count(s, "\"") > 4 || strings.Count(s, "'") > 4 {
+		// Check if it contains git-related parts
+		if strings.Contains(strings.ToLower(s), "git") || strings.Contains(strings.ToLower(s), "push") {
+			return true
+		}
+	}
+
+	return false
+}
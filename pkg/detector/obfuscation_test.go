@@ -1,6 +1,8 @@
 package detector
 
 import (
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -79,15 +81,57 @@ func TestBase64ExecutionDetection(t *testing.T) {
 		{
 			name:        "base64 piped to bash",
 			command:     "echo SGVsbG8gV29ybGQ= | base64 -d | bash",
-			shouldBlock: false, // Current implementation doesn't handle pipes yet
-			description: "Base64 being piped to shell should eventually be blocked",
+			shouldBlock: true,
+			description: "Base64 being piped to shell should be blocked",
 		},
 		{
 			name:        "base64 in command substitution with eval",
 			command:     "eval $(base64 -d file.txt)",
-			shouldBlock: false, // Would need more sophisticated parsing
+			shouldBlock: true,
 			description: "Base64 in command substitution with eval should be blocked",
 		},
+		{
+			name:        "base64 piped through another stage to sh",
+			command:     "cat payload | base64 --decode | tr -d '\\r' | sh -s",
+			shouldBlock: true,
+			description: "Decoded data reaching a shell through later stages should be blocked",
+		},
+		{
+			name:        "base64 in sh -c substitution",
+			command:     `bash -c "$(base64 -d payload.b64)"`,
+			shouldBlock: true,
+			description: "Decoded data run with sh -c should be blocked",
+		},
+		{
+			name:        "openssl decode piped to python",
+			command:     "openssl base64 -d -in payload | python3",
+			shouldBlock: true,
+			description: "Decoded data piped to a stdin interpreter should be blocked",
+		},
+		{
+			name:        "base64 piped to source stdin",
+			command:     "base64 -d payload | source /dev/stdin",
+			shouldBlock: true,
+			description: "Sourcing decoded data should be blocked",
+		},
+		{
+			name:        "base64 decode piped to file tools",
+			command:     "base64 -d cert.b64 | openssl x509 -noout -text",
+			shouldBlock: false,
+			description: "Decoded data read by a non-interpreter is safe",
+		},
+		{
+			name:        "base64 decode piped to interpreter script",
+			command:     "base64 -d data.b64 | python3 parse.py",
+			shouldBlock: false,
+			description: "A script reading decoded data from stdin is safe",
+		},
+		{
+			name:        "base64 encode piped to shell variable",
+			command:     "echo hi | base64 | bash -c 'cat'",
+			shouldBlock: false,
+			description: "Encoding is not decoding",
+		},
 		{
 			name:        "base64 decode to file",
 			command:     "base64 -d input.txt > output.txt",
@@ -127,3 +171,235 @@ func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr ||
 		len(s) > len(substr) && contains(s[1:], substr)
 }
+
+// TestExpansionBypasses covers each documented class of expansion bypass:
+// the blocked command only exists after the shell expands the words.
+func TestExpansionBypasses(t *testing.T) {
+	rules := []CommandRule{
+		{
+			BlockedCommand:  "git",
+			BlockedPatterns: []string{"push"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		// Brace expansion
+		{"braces as whole command", "{git,push}", true, "Brace expansion assembles a blocked command: git push"},
+		{"braces with arguments", "{git,push} origin main", true, "Brace expansion assembles a blocked command: git push origin main"},
+		{"braces inside command name", "g{i,}t push", true, "Brace expansion assembles a blocked command: git gt push"},
+		{"braces inside subcommand", "git pu{s,}h", true, "Brace expansion assembles a blocked command: git push puh"},
+		{"oversized sequence", "echo {1..300}", true, "Brace expansion produces too many words to analyze"},
+		{"harmless braces", "mkdir -p src/{api,web}", false, ""},
+		{"xargs placeholder", "ls | xargs -I {} cp {} /tmp", false, ""},
+
+		// IFS as a separator
+		{"braced IFS", "git${IFS}push", true, "$IFS used as a word separator to assemble a blocked command: git push"},
+		{"bare IFS", "git$IFS'push' origin", true, "$IFS used as a word separator to assemble a blocked command: git 'push' origin"},
+		{"IFS in harmless echo", `echo "a${IFS}b"`, false, ""},
+
+		// ANSI-C quoting
+		{"hex escaped command", `$'\x67it' push`, true, `ANSI-C quoting escapes plain characters (possible obfuscation): $'\x67it' decodes to git`},
+		{"octal escaped command", `$'\147\151\164' push`, true, `ANSI-C quoting escapes plain characters (possible obfuscation): $'\147\151\164' decodes to git`},
+		{"escaped subcommand", `git $'\x70ush'`, true, `ANSI-C quoting escapes plain characters (possible obfuscation): $'\x70ush' decodes to push`},
+		{"control characters", `printf $'\e[31mred\e[0m\n'`, false, ""},
+		{"tab separator", `cut -d $'\t' -f 2 data.tsv`, false, ""},
+
+		// Arithmetic expansion only hides commands inside substitutions
+		{"substitution inside arithmetic", "echo $(( $(git push) + 1 ))", true, "Blocked command runs inside command substitution: git push"},
+		{"plain arithmetic", "sleep $((1 + 2))", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			gotBlock := detector.ShouldBlockShellExpr(tt.command)
+			if gotBlock != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, gotBlock, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+}
+
+// stubDetector reports a fixed score for content containing its marker.
+type stubDetector struct {
+	name   string
+	marker string
+	score  float64
+}
+
+func (s stubDetector) Name() string { return s.name }
+
+func (s stubDetector) Detect(content string) (float64, []string) {
+	if !strings.Contains(content, s.marker) {
+		return 0, nil
+	}
+	return s.score, []string{s.name + " found " + s.marker}
+}
+
+func TestObfuscationDetectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(d *CommandDetector)
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		{
+			name:      "default reverse detector blocks",
+			setup:     func(*CommandDetector) {},
+			command:   "echo hsup tig | rev",
+			wantBlock: true,
+			wantIssue: "Reversed text contains blocked command: git push ohce",
+		},
+		{
+			name:      "disabled reverse detector",
+			setup:     func(d *CommandDetector) { d.DisableObfuscationDetector("reverse") },
+			command:   "echo hsup tig",
+			wantBlock: false,
+		},
+		{
+			name:      "finding below threshold warns",
+			setup:     func(d *CommandDetector) { d.SetObfuscationThreshold(0.95) },
+			command:   "echo hsup tig",
+			wantBlock: false,
+			wantIssue: "Warning: Reversed text contains blocked command: git push ohce",
+		},
+		{
+			name: "confident finding blocks above raised threshold",
+			setup: func(d *CommandDetector) {
+				d.SetObfuscationThreshold(0.65)
+				d.RegisterObfuscationDetector(stubDetector{name: "marker", marker: "xyzzy", score: 0.9})
+			},
+			command:   "echo xyzzy",
+			wantBlock: true,
+			wantIssue: "marker found xyzzy",
+		},
+		{
+			name: "registered detector blocks",
+			setup: func(d *CommandDetector) {
+				d.RegisterObfuscationDetector(stubDetector{name: "marker", marker: "xyzzy", score: 1})
+			},
+			command:   "echo xyzzy",
+			wantBlock: true,
+			wantIssue: "marker found xyzzy",
+		},
+		{
+			name: "registering replaces same name",
+			setup: func(d *CommandDetector) {
+				d.RegisterObfuscationDetector(stubDetector{name: "reverse", marker: "xyzzy", score: 1})
+			},
+			command:   "echo hsup tig",
+			wantBlock: false,
+		},
+	}
+
+	rules := []CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			tt.setup(detector)
+			gotBlock := detector.ShouldBlockShellExpr(tt.command)
+			if gotBlock != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, gotBlock, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+
+	if NewCommandDetector(nil, 10).DisableObfuscationDetector("nope") {
+		t.Error("DisableObfuscationDetector(nope) = true, want false")
+	}
+}
+
+func TestReverseDetector(t *testing.T) {
+	rules := []CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+	presetRules := gitPushPreset().Rules
+
+	tests := []struct {
+		name      string
+		rules     []CommandRule
+		content   string
+		wantScore float64
+	}{
+		{"reversed blocked command", rules, "echo hsup tig", 0.9},
+		{"reversed with arguments", rules, "printf niam nigiro hsup tig", 0.9},
+		{"reversed flag-aware rule", presetRules, "echo hsup tig", 0.9},
+		{"reversed harmless command", rules, "echo sutats tig", 0},
+		{"rev tool alone", rules, "rev notes.txt", 0.3},
+		{"tac tool by path", rules, "/usr/bin/tac app.log", 0.3},
+		{"rev-parse", rules, "git rev-parse HEAD", 0},
+		{"review", rules, "gh pr review --approve", 0},
+		{"revert", rules, "git revert HEAD", 0},
+		{"stack", rules, "echo stack attack", 0},
+		{"tig tool", rules, "tig status", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, issues := newReverseDetector(tt.rules).Detect(tt.content)
+			if score != tt.wantScore {
+				t.Errorf("Detect(%q) = %v, %q, want score %v", tt.content, score, issues, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestReverseDetector_NoFalsePositives(t *testing.T) {
+	rules := []CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+	for _, command := range []string{
+		"git rev-parse --show-toplevel",
+		"git revert HEAD~1",
+		"gh pr review 42 --approve",
+		"tac build.log | head",
+		"git log | rev | cut -c1-8 | rev",
+	} {
+		detector := NewCommandDetector(rules, 10)
+		if detector.ShouldBlockShellExpr(command) {
+			t.Errorf("ShouldBlockShellExpr(%q) = true, want false. Issues: %v", command, detector.GetIssues())
+		}
+	}
+}
+
+// TestReversedExecution covers reversed text that runs: fed to rev or tac by
+// a heredoc or here-string, or piped from them to an interpreter.
+func TestReversedExecution(t *testing.T) {
+	rules := []CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+
+	tests := []struct {
+		name      string
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		{"here-string piped to bash", `rev <<< "hsup tig" | bash`, true, `Reversed data is piped to an interpreter: rev <<<"hsup tig" | bash`},
+		{"here-string alone", `rev <<< "hsup tig"`, true, "Reversed text contains blocked command: git push ver"},
+		{"heredoc", "rev <<EOF\nhsup tig\nEOF", true, "Reversed text contains blocked command: git push ver"},
+		{"file piped to sh", "cat payload | rev | sh", true, "Reversed data is piped to an interpreter: cat payload | rev | sh"},
+		{"tac piped to bash -s", "tac script.sh | bash -s", true, "Reversed data is piped to an interpreter: tac script.sh | bash -s"},
+		{"eval of reversed substitution", `eval "$(rev <<< 'hsup tig')"`, true, `eval executes reversed data: eval "$(rev <<<'hsup tig')"`},
+		{"harmless here-string", `rev <<< "olleh"`, false, "Warning: Possible reverse string obfuscation (rev used)"},
+		{"reversed into a script", "tac app.log | python3 parse.py", false, ""},
+		{"heredoc written to a file", "cat > notes.txt <<EOF\nhsup tig\nEOF", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			gotBlock := detector.ShouldBlockShellExpr(tt.command)
+			if gotBlock != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, gotBlock, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+}
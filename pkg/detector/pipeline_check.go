@@ -0,0 +1,174 @@
+// Package detector - decoded data reaching an interpreter
+package detector
+
+import (
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// stdinInterpreters run a program read from stdin when given no script.
+var stdinInterpreters = []string{"python", "python3", "perl", "ruby", "node"}
+
+// checkDecodedExecution blocks base64-decoded or reversed data that reaches
+// an interpreter, so its contents run without ever appearing in the command:
+//   - echo <base64> | base64 -d | bash
+//   - rev <<< "hsup tig" | bash
+//   - eval $(base64 -d payload.txt)
+//   - bash -c "$(base64 --decode payload.txt)"
+//
+// Decoding into a file or another command (base64 -d in > out) is allowed.
+// Scripts a shell reads from a heredoc or here-string, as in
+// bash -s <<< 'git push', are analyzed like sh -c scripts.
+func (d *CommandDetector) checkDecodedExecution(node syntax.Node) bool {
+	blocked := false
+	syntax.Walk(node, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.Stmt:
+			blocked = d.checkStdinScript(n)
+		case *syntax.BinaryCmd:
+			if n.Op == syntax.Pipe || n.Op == syntax.PipeAll {
+				blocked = d.checkDecodedPipeline(n)
+			}
+		case *syntax.CallExpr:
+			blocked = d.checkDecodedSubstitution(n)
+		}
+		return !blocked
+	})
+	return blocked
+}
+
+// checkStdinScript analyzes the script a shell run without a script argument,
+// or with -s, reads from the statement's heredoc or here-string.
+func (d *CommandDetector) checkStdinScript(stmt *syntax.Stmt) bool {
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) == 0 || !executesStdin(call) {
+		return false
+	}
+	cmd, _ := shellparse.StaticWord(call.Args[0])
+	if cmd = normalizeCommand(cmd); slices.Contains(stdinInterpreters, cmd) {
+		return false // Not shell syntax
+	}
+	script, ok := stdinText(stmt)
+	if !ok || !d.analyzeShellExprRecursive(script) {
+		return false
+	}
+	d.addIssue("Blocked command in the script " + cmd + " reads from stdin: " + strings.TrimSpace(script))
+	return true // BLOCK
+}
+
+// checkDecodedPipeline blocks a pipeline in which a decode or reverse stage
+// is followed by a stage that executes its stdin.
+func (d *CommandDetector) checkDecodedPipeline(pipeline *syntax.BinaryCmd) bool {
+	var decoded string // How earlier stages transformed the data, if they did
+	for _, stage := range shellparse.PipelineStages(pipeline) {
+		call, ok := stage.Cmd.(*syntax.CallExpr)
+		if !ok {
+			continue
+		}
+		if decoded != "" && executesStdin(call) {
+			d.addIssue(decoded + " data is piped to an interpreter: " + shellparse.Print(pipeline))
+			return true
+		}
+		if transform := decodeKind(call); transform != "" {
+			decoded = transform
+		}
+	}
+	return false
+}
+
+// checkDecodedSubstitution blocks eval, source, and sh -c given the output of
+// a command substitution that decodes base64 or reverses text.
+func (d *CommandDetector) checkDecodedSubstitution(call *syntax.CallExpr) bool {
+	args, _ := shellparse.StaticArgs(call)
+	if len(args) == 0 {
+		return false
+	}
+	cmd := normalizeCommand(args[0])
+	evaluates := cmd == "eval" || cmd == "source" || cmd == "." ||
+		(isShellInterpreter(cmd) && slices.Contains(args[1:], "-c"))
+	if !evaluates {
+		return false
+	}
+	for _, word := range call.Args[1:] {
+		substituted := shellparse.SubstitutedCalls(word)
+		for _, decodeCall := range shellparse.CallExprs(word) {
+			if _, ok := substituted[decodeCall]; ok && decodeKind(decodeCall) != "" {
+				d.addIssue(cmd + " executes " + strings.ToLower(decodeKind(decodeCall)) + " data: " + shellparse.Print(call))
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeKind describes how call transforms the data it passes on, so what
+// runs never appears in the command: "Base64-decoded" or "Reversed", for rev
+// and tac. It returns "" for other commands.
+func decodeKind(call *syntax.CallExpr) string {
+	if len(call.Args) == 0 {
+		return ""
+	}
+	if isBase64Decode(call) {
+		return "Base64-decoded"
+	}
+	if cmd, _ := shellparse.StaticWord(call.Args[0]); isReverseCommand(cmd) {
+		return "Reversed"
+	}
+	return ""
+}
+
+// isReverseCommand reports whether cmd reverses its input: rev reverses each
+// line, and tac the order of lines.
+func isReverseCommand(cmd string) bool {
+	tool := normalizeCommand(cmd)
+	return tool == "rev" || tool == "tac"
+}
+
+// isBase64Decode reports whether call decodes base64, with base64 -d or
+// openssl base64 -d.
+func isBase64Decode(call *syntax.CallExpr) bool {
+	args, _ := shellparse.StaticArgs(call)
+	if len(args) == 0 {
+		return false
+	}
+	hasDecodeFlag := slices.ContainsFunc(args[1:], func(arg string) bool {
+		return arg == "-d" || arg == "--decode" || arg == "-D"
+	})
+	switch normalizeCommand(args[0]) {
+	case "base64":
+		return hasDecodeFlag
+	case "openssl":
+		return hasDecodeFlag && (slices.Contains(args[1:], "base64") || slices.Contains(args[1:], "-base64"))
+	}
+	return false
+}
+
+// executesStdin reports whether call runs a program read from stdin: a shell
+// or scripting interpreter without a script or -c/-e program, or sourcing
+// /dev/stdin.
+func executesStdin(call *syntax.CallExpr) bool {
+	args, _ := shellparse.StaticArgs(call)
+	if len(args) == 0 {
+		return false
+	}
+	cmd := normalizeCommand(args[0])
+	if cmd == "source" || cmd == "." {
+		return slices.Contains(args[1:], "/dev/stdin")
+	}
+	if !isShellInterpreter(cmd) && !slices.Contains(stdinInterpreters, cmd) {
+		return false
+	}
+	for _, arg := range args[1:] {
+		switch {
+		case arg == "-s" || arg == "-" || arg == "/dev/stdin":
+			return true
+		case arg == "-c" || arg == "-e" || arg == "-m" || !strings.HasPrefix(arg, "-"):
+			return false // The program comes from an argument or a file
+		}
+	}
+	return true
+}
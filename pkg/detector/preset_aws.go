@@ -0,0 +1,41 @@
+// Package detector - aws presets
+package detector
+
+import "strings"
+
+// awsArgs is the aws CLI's global option syntax. Operation options that take
+// values come after the operation, so they never hide the service or
+// operation name.
+var awsArgs = ArgSpec{
+	ValueFlags: []string{
+		"--region", "--profile", "--output", "--endpoint-url", "--query",
+		"--color", "--ca-bundle", "--cli-read-timeout", "--cli-connect-timeout",
+		"--cli-binary-format",
+	},
+}
+
+// awsDestructivePreset blocks aws operations that delete or terminate
+// resources, wherever the global flags appear.
+func awsDestructivePreset() Preset {
+	return Preset{
+		Name:        "aws-destructive",
+		Description: "aws delete-* and terminate-* operations, s3 rb --force, and s3 rm --recursive",
+		Rules: []CommandRule{{
+			BlockedCommand: "aws",
+			Args:           awsArgs,
+			Suggest:        "use a describe-*, list-*, or --dryrun command to check the change, or ask the human to run it",
+			Match: func(inv Invocation) string {
+				service, operation := inv.Subcommand(0), inv.Subcommand(1)
+				switch {
+				case strings.HasPrefix(operation, "delete-") || strings.HasPrefix(operation, "terminate-"):
+					return "Blocked aws " + service + " " + operation
+				case service == "s3" && operation == "rb" && inv.HasFlag("--force"):
+					return "Blocked aws s3 rb --force"
+				case service == "s3" && operation == "rm" && inv.HasFlag("--recursive"):
+					return "Blocked aws s3 rm --recursive"
+				}
+				return ""
+			},
+		}},
+	}
+}
@@ -0,0 +1,97 @@
+// Package detector - gcloud and az presets
+package detector
+
+import (
+	"slices"
+	"strings"
+)
+
+// gcloudArgs is the gcloud CLI's global option syntax.
+var gcloudArgs = ArgSpec{
+	ValueFlags: []string{
+		"--project", "--account", "--configuration", "--format", "--verbosity",
+		"--impersonate-service-account", "--billing-project", "--flags-file",
+		"--trace-token", "--zone", "--region",
+	},
+}
+
+// gcloudDestructiveCommands are the gcloud command groups and verbs the
+// gcloud-destructive preset blocks, without release track.
+var gcloudDestructiveCommands = [][]string{
+	{"projects", "delete"},
+	{"compute", "instances", "delete"},
+	{"compute", "disks", "delete"},
+	{"sql", "instances", "delete"},
+	{"container", "clusters", "delete"},
+	{"storage", "buckets", "delete"},
+	{"storage", "rm"},
+}
+
+// azArgs is the az CLI's global option syntax.
+var azArgs = ArgSpec{
+	ValueFlags: []string{"--subscription", "-o", "--output", "--query"},
+}
+
+// azDestructiveCommands are the az command groups and verbs the
+// az-destructive preset blocks.
+var azDestructiveCommands = [][]string{
+	{"group", "delete"},
+	{"vm", "delete"},
+	{"vmss", "delete"},
+	{"keyvault", "delete"},
+	{"keyvault", "purge"},
+	{"aks", "delete"},
+	{"sql", "server", "delete"},
+	{"sql", "db", "delete"},
+	{"storage", "account", "delete"},
+}
+
+// gcloudDestructivePreset blocks gcloud commands that delete projects and
+// their core resources.
+func gcloudDestructivePreset() Preset {
+	return Preset{
+		Name:        "gcloud-destructive",
+		Description: "gcloud projects, compute instances/disks, sql instances, GKE clusters, and storage deletes",
+		Rules: []CommandRule{{
+			BlockedCommand: "gcloud",
+			Args:           gcloudArgs,
+			Suggest:        "use the matching describe or list command to inspect the resource, or ask the human to delete it",
+			Match: func(inv Invocation) string {
+				path := inv.Positionals
+				// Release tracks select an API version, not a different command
+				if slices.Contains([]string{"alpha", "beta"}, inv.Subcommand(0)) {
+					path = path[1:]
+				}
+				return matchCommandPath("gcloud", path, gcloudDestructiveCommands)
+			},
+		}},
+	}
+}
+
+// azDestructivePreset blocks az commands that delete resource groups and
+// their core resources.
+func azDestructivePreset() Preset {
+	return Preset{
+		Name:        "az-destructive",
+		Description: "az group, vm, keyvault, aks, sql, and storage account deletes",
+		Rules: []CommandRule{{
+			BlockedCommand: "az",
+			Args:           azArgs,
+			Suggest:        "use the matching show or list command to inspect the resource, or ask the human to delete it",
+			Match: func(inv Invocation) string {
+				return matchCommandPath("az", inv.Positionals, azDestructiveCommands)
+			},
+		}},
+	}
+}
+
+// matchCommandPath returns the issue for the first command path that
+// positionals start with, or "" if none matches.
+func matchCommandPath(command string, positionals []string, paths [][]string) string {
+	for _, path := range paths {
+		if len(positionals) >= len(path) && slices.Equal(positionals[:len(path)], path) {
+			return "Blocked " + command + " " + strings.Join(path, " ")
+		}
+	}
+	return ""
+}
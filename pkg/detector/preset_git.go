@@ -0,0 +1,257 @@
+// Package detector - git presets
+package detector
+
+import (
+	"slices"
+	"strings"
+)
+
+// gitArgs is git's global option syntax. Aliases may also be defined inline
+// with -c alias.NAME=VALUE.
+var gitArgs = ArgSpec{
+	ValueFlags: []string{"-C", "-c", "--git-dir", "--work-tree", "--namespace", "--config-env", "--super-prefix"},
+	AliasFlag:  "-c",
+}
+
+// gitPushPreset blocks git push however the subcommand is reached: after
+// global flags (git -C dir push, git -c key=val push) or through an alias.
+func gitPushPreset() Preset {
+	return Preset{
+		Name:        "git-push",
+		Description: "git push, including after global flags and through aliases",
+		Rules: []CommandRule{{
+			BlockedCommand: "git",
+			Args:           gitArgs,
+			Suggest:        "use `git push --dry-run` to check the push, or ask the human to push",
+			Match: func(inv Invocation) string {
+				switch {
+				case inv.Subcommand(0) == "push":
+					return "Blocked git push"
+				case inv.Subcommand(0) == "subtree" && inv.Subcommand(1) == "push":
+					return "Blocked git subtree push"
+				}
+				return ""
+			},
+		}},
+	}
+}
+
+// gitConfigArgs is git's global option syntax plus the git config options
+// that take a value, so a --file path is not mistaken for a key.
+var gitConfigArgs = ArgSpec{
+	ValueFlags: append(slices.Clone(gitArgs.ValueFlags), "-f", "--file", "--blob", "--type", "--default", "--comment", "--value"),
+	AliasFlag:  gitArgs.AliasFlag,
+}
+
+// gitConfigWriteFlags are the git config options that change a config file.
+var gitConfigWriteFlags = []string{
+	"--unset", "--unset-all", "--add", "--replace-all", "--rename-section", "--remove-section", "-e", "--edit",
+}
+
+// gitConfigReadFlags are the git config options that only read.
+var gitConfigReadFlags = []string{
+	"--get", "--get-all", "--get-regexp", "--get-urlmatch", "--get-color", "--get-colorbool", "-l", "--list",
+}
+
+// gitConfigChange is a change git config makes to a config file.
+type gitConfigChange struct {
+	key     string // Variable set or unset, or section renamed or removed; "" for --edit
+	section bool   // key is a section, as in --remove-section remote.origin
+}
+
+// parseGitConfigChange returns the change a git config invocation makes, in
+// the classic (git config --unset key) or subcommand (git config unset key)
+// syntax. Reads, such as git config user.name, make no change.
+func parseGitConfigChange(inv Invocation) (gitConfigChange, bool) {
+	if inv.Subcommand(0) != "config" {
+		return gitConfigChange{}, false
+	}
+	switch inv.Subcommand(1) {
+	case "get", "list":
+		return gitConfigChange{}, false
+	case "set", "unset":
+		return gitConfigChange{key: inv.Subcommand(2)}, true
+	case "rename-section", "remove-section":
+		return gitConfigChange{key: inv.Subcommand(2), section: true}, true
+	case "edit":
+		return gitConfigChange{}, true
+	}
+	switch {
+	case inv.HasFlag(gitConfigReadFlags...):
+		return gitConfigChange{}, false
+	case inv.HasFlag("-e", "--edit"):
+		return gitConfigChange{}, true
+	case inv.HasFlag("--rename-section", "--remove-section"):
+		return gitConfigChange{key: inv.Subcommand(1), section: true}, true
+	case inv.HasFlag(gitConfigWriteFlags...) || len(inv.Positionals) > 2:
+		return gitConfigChange{key: inv.Subcommand(1)}, true
+	}
+	return gitConfigChange{}, false // git config user.name reads the value
+}
+
+// isPushSetting reports whether a config change can redirect pushes or the
+// credentials they use: remote URLs and refspecs, url.*.insteadOf rewrites,
+// push.default, credential helpers, and core.sshCommand. Section and variable
+// names are case-insensitive; subsections such as URLs may contain dots.
+func (c gitConfigChange) isPushSetting() bool {
+	section, rest, _ := strings.Cut(strings.ToLower(c.key), ".")
+	if c.section || c.key == "" {
+		// Renaming or removing a whole section, or editing the file, can change any variable
+		return c.key == "" || slices.Contains([]string{"remote", "url", "credential"}, section)
+	}
+	name := rest[strings.LastIndex(rest, ".")+1:]
+	subsection := strings.Contains(rest, ".")
+	switch section {
+	case "remote":
+		return subsection && slices.Contains([]string{"url", "pushurl", "push"}, name) || !subsection && name == "pushdefault"
+	case "url":
+		return subsection && (name == "insteadof" || name == "pushinsteadof")
+	case "credential":
+		return name == "helper"
+	case "push":
+		return name == "default"
+	case "branch":
+		return subsection && name == "pushremote"
+	case "core":
+		return name == "sshcommand"
+	}
+	return false
+}
+
+// gitConfigPreset blocks changes to where pushes go: git config of remote
+// URLs, URL rewrites, push.default, credential helpers, and core.sshCommand,
+// and git remote set-url. A changed remote redirects every later push,
+// including ones a human approves.
+func gitConfigPreset() Preset {
+	return Preset{
+		Name:        "git-config",
+		Description: "git config changes to remote URLs, push.default, credential helpers, and core.sshCommand, and git remote set-url",
+		Rules: []CommandRule{{
+			BlockedCommand: "git",
+			Args:           gitConfigArgs,
+			Suggest:        "use `git remote -v` or `git config --get` to inspect the setting, or ask the human to change it",
+			Match: func(inv Invocation) string {
+				if inv.Subcommand(0) == "remote" && inv.Subcommand(1) == "set-url" {
+					return "Blocked git remote set-url"
+				}
+				change, ok := parseGitConfigChange(inv)
+				switch {
+				case !ok || !change.isPushSetting():
+					return ""
+				case change.key == "":
+					return "Blocked git config edit, which can change push settings"
+				case change.section:
+					return "Blocked git config change of push settings section " + change.key
+				}
+				return "Blocked git config change of push setting " + change.key
+			},
+		}},
+	}
+}
+
+// gitSigningKeys are the config variables that make git sign commits and
+// tags by default. A repository that sets one to true requires signing.
+var gitSigningKeys = []string{"commit.gpgsign", "tag.gpgsign"}
+
+// gitNoSignSubcommands are the subcommands that create commits and accept
+// --no-gpg-sign.
+var gitNoSignSubcommands = []string{"commit", "merge", "rebase", "cherry-pick", "revert", "am", "pull", "commit-tree"}
+
+// gitTagListFlags are the git tag options that list, delete, or verify tags
+// instead of creating one.
+var gitTagListFlags = []string{
+	"-l", "--list", "-d", "--delete", "-v", "--verify", "-n",
+	"--contains", "--no-contains", "--merged", "--no-merged", "--points-at", "--sort", "--format", "--column",
+}
+
+// gitSigningArgs is git's global option syntax plus the options of git
+// config and git tag that take a value, so a message or key ID is not
+// mistaken for a tag name.
+var gitSigningArgs = ArgSpec{
+	ValueFlags: append(slices.Clone(gitConfigArgs.ValueFlags),
+		"-m", "--message", "-F", "-u", "--local-user", "--cleanup",
+		"--contains", "--no-contains", "--merged", "--no-merged", "--points-at", "--sort", "--format"),
+	AliasFlag: gitArgs.AliasFlag,
+}
+
+// isFalse reports whether a git config boolean value is false.
+func isFalse(value string) bool {
+	return slices.Contains([]string{"false", "no", "off", "0", ""}, strings.ToLower(value))
+}
+
+// gitSigningChange returns the issue for a git config change that turns off,
+// or can turn off, commit or tag signing, or "" for any other change.
+func gitSigningChange(inv Invocation) string {
+	change, ok := parseGitConfigChange(inv)
+	if !ok {
+		return ""
+	}
+	key := strings.ToLower(change.key)
+	switch {
+	case change.key == "":
+		return "Blocked git config edit, which can turn off signing"
+	case change.section:
+		if key == "commit" || key == "tag" {
+			return "Blocked git config change of signing settings section " + change.key
+		}
+		return ""
+	case !slices.Contains(gitSigningKeys, key):
+		return ""
+	}
+	// The value follows the key, which is unset or the last positional
+	unset := inv.HasFlag("--unset", "--unset-all") || inv.Subcommand(1) == "unset"
+	if unset || isFalse(inv.Positionals[len(inv.Positionals)-1]) {
+		return "Blocked git config change turning off " + change.key
+	}
+	return ""
+}
+
+// isUnsignedTag reports whether a git tag invocation creates a tag without
+// -s or -u. Bundled short options such as -sm count.
+func isUnsignedTag(inv Invocation) bool {
+	if inv.Subcommand(0) != "tag" || len(inv.Positionals) < 2 || inv.HasFlag(gitTagListFlags...) {
+		return false
+	}
+	for flag := range inv.Flags {
+		switch {
+		case flag == "--sign" || flag == "--local-user":
+			return false
+		case !strings.HasPrefix(flag, "--") && strings.ContainsAny(flag[1:], "su"):
+			return false
+		}
+	}
+	return true
+}
+
+// gitSigningPreset blocks turning off commit and tag signing: --no-gpg-sign
+// on commits, merges, and rebases, git -c commit.gpgsign=false, git config
+// changes that turn signing off, and tags created without -s or -u. It only
+// applies in repositories that require signing.
+func gitSigningPreset() Preset {
+	return Preset{
+		Name:              "git-signing",
+		Description:       "unsigned commits and tags (--no-gpg-sign, commit.gpgsign=false, git tag without -s) in repositories that sign by default",
+		RequiresGitConfig: gitSigningKeys,
+		Rules: []CommandRule{{
+			BlockedCommand: "git",
+			Args:           gitSigningArgs,
+			Suggest:        "keep signing on: drop --no-gpg-sign, and create tags with `git tag -s`",
+			Match: func(inv Invocation) string {
+				for _, value := range inv.Flags["-c"] {
+					// A key without =value is true; with an empty value, false
+					key, setting, hasValue := strings.Cut(value, "=")
+					if hasValue && slices.Contains(gitSigningKeys, strings.ToLower(key)) && isFalse(setting) {
+						return "Blocked git -c " + key + "=" + setting
+					}
+				}
+				switch subcommand := inv.Subcommand(0); {
+				case slices.Contains(gitNoSignSubcommands, subcommand) && inv.HasFlag("--no-gpg-sign"):
+					return "Blocked git " + subcommand + " --no-gpg-sign"
+				case isUnsignedTag(inv):
+					return "Blocked unsigned git tag " + inv.Subcommand(1) + "; use git tag -s"
+				}
+				return gitSigningChange(inv)
+			},
+		}},
+	}
+}
@@ -0,0 +1,99 @@
+// Package detector - GitOps tooling presets
+package detector
+
+import (
+	"slices"
+	"strings"
+)
+
+// helmArgs is helm's global option syntax.
+var helmArgs = ArgSpec{
+	ValueFlags: []string{
+		"-n", "--namespace", "--kube-context", "--kubeconfig", "--kube-apiserver",
+		"--kube-as-user", "--kube-as-group", "--kube-token", "--kube-ca-file",
+		"--registry-config", "--repository-cache", "--repository-config",
+		"--burst-limit", "--qps", "--timeout", "--description", "--cascade",
+	},
+	ShortAttached: true,
+}
+
+// fluxArgs is the flux CLI's global option syntax.
+var fluxArgs = ArgSpec{
+	ValueFlags: []string{"-n", "--namespace", "--context", "--kubeconfig", "--timeout", "--cluster", "--user", "--token", "--server"},
+}
+
+// argocdArgs is the argocd CLI's global option syntax.
+var argocdArgs = ArgSpec{
+	ValueFlags: []string{
+		"--server", "--auth-token", "--config", "--grpc-web-root-path", "-H", "--header",
+		"--kube-context", "--port-forward-namespace", "--logformat", "--loglevel",
+		"--client-crt", "--client-crt-key", "--server-crt", "--http-retry-max",
+		"--cascade", "--propagation-policy", "-l", "--selector", "-N", "--app-namespace",
+	},
+}
+
+// gitopsDestructivePreset blocks commands that tear down releases and
+// applications managed by GitOps tooling, the usual path to production damage.
+func gitopsDestructivePreset() Preset {
+	return Preset{
+		Name:        "gitops-destructive",
+		Description: "helm uninstall, flux delete/uninstall, argocd app delete, and kubectl delete -f against prod contexts",
+		Rules: []CommandRule{
+			{
+				BlockedCommand: "helm",
+				Args:           helmArgs,
+				Suggest:        "use `helm status` or `helm get` to inspect the release, or ask the human to uninstall it",
+				Match: func(inv Invocation) string {
+					// delete, del, and un are aliases of uninstall
+					if slices.Contains([]string{"uninstall", "un", "delete", "del"}, inv.Subcommand(0)) {
+						return "Blocked helm " + inv.Subcommand(0)
+					}
+					return ""
+				},
+			},
+			{
+				BlockedCommand: "flux",
+				Args:           fluxArgs,
+				Suggest:        "use `flux suspend` to pause reconciliation, or ask the human to delete it",
+				Match: func(inv Invocation) string {
+					if verb := inv.Subcommand(0); verb == "delete" || verb == "uninstall" {
+						return "Blocked flux " + verb
+					}
+					return ""
+				},
+			},
+			{
+				BlockedCommand: "argocd",
+				Args:           argocdArgs,
+				Suggest:        "use `argocd app diff` to preview, or ask the human to delete the application",
+				Match: func(inv Invocation) string {
+					group, verb := inv.Subcommand(0), inv.Subcommand(1)
+					if (group == "app" || group == "appset") && (verb == "delete" || verb == "rm") {
+						return "Blocked argocd " + group + " " + verb
+					}
+					return ""
+				},
+			},
+			{
+				BlockedCommand: "kubectl",
+				Args:           kubectlArgs,
+				Suggest:        "remove the manifests from the GitOps repository instead, or ask the human to delete them",
+				Match: func(inv Invocation) string {
+					cmd := kubectlCommand(inv)
+					if cmd.Verb == "delete" && len(cmd.Files) > 0 && isProdKubectl(cmd) {
+						return "Blocked kubectl delete of manifests in a production context"
+					}
+					return ""
+				},
+			},
+		},
+	}
+}
+
+// isProdKubectl reports whether a kubectl command explicitly targets a
+// production context or namespace. Commands relying on the current context
+// cannot be judged from the command line and are not matched.
+func isProdKubectl(cmd KubectlCommand) bool {
+	return strings.Contains(strings.ToLower(cmd.Context), "prod") ||
+		cmd.Namespace == "prod" || cmd.Namespace == "production"
+}
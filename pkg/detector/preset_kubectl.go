@@ -0,0 +1,184 @@
+// Package detector - kubectl presets
+package detector
+
+import (
+	"slices"
+	"strings"
+)
+
+// kubectlArgs is kubectl's flag syntax: the global flags plus the common
+// subcommand flags that take a value, so their values are not mistaken for
+// resources or names.
+var kubectlArgs = ArgSpec{
+	ValueFlags: []string{
+		"-n", "--namespace", "--context", "--cluster", "--user", "--kubeconfig",
+		"-s", "--server", "--token", "--as", "--as-group", "--as-uid",
+		"--cache-dir", "--certificate-authority", "--client-certificate",
+		"--client-key", "--tls-server-name", "--request-timeout", "-v",
+		"-f", "--filename", "-k", "--kustomize", "-l", "--selector",
+		"--field-selector", "-o", "--output", "-c", "--container",
+		"-p", "--patch", "--type", "--replicas", "--image", "--timeout",
+		"--for", "--grace-period", "--field-manager", "--cascade",
+	},
+	ShortAttached: true,
+}
+
+// kubectlGroupVerbs are verbs whose first argument is a further verb rather
+// than a resource, as in "kubectl rollout restart deploy/web".
+var kubectlGroupVerbs = []string{"rollout", "set", "config", "auth", "certificate", "plugin"}
+
+// KubectlCommand is a kubectl invocation parsed into its verb, targets, and
+// scoping flags.
+type KubectlCommand struct {
+	Verb          string   // "delete", or "rollout restart" for grouped verbs
+	Resources     []string // Resource types, e.g. "pod", "deployment"
+	Names         []string // Resource names
+	Files         []string // -f/--filename and -k/--kustomize arguments
+	Namespace     string   // -n/--namespace; empty when not given
+	AllNamespaces bool     // -A/--all-namespaces
+	Context       string   // --context; empty when not given
+}
+
+// ParseKubectl parses kubectl arguments (without the command itself).
+// Flags are recognized in any position, in both "-n prod" and "--namespace=prod"
+// form, and targets in both "pod web" and "pod/web" form.
+func ParseKubectl(args []string) KubectlCommand {
+	return kubectlCommand(kubectlArgs.Parse(args))
+}
+
+// kubectlCommand interprets kubectl arguments parsed with kubectlArgs.
+func kubectlCommand(inv Invocation) KubectlCommand {
+	cmd := KubectlCommand{Verb: inv.Subcommand(0)}
+	targets := inv.Positionals[min(1, len(inv.Positionals)):]
+	if slices.Contains(kubectlGroupVerbs, cmd.Verb) && len(targets) > 0 {
+		cmd.Verb += " " + targets[0]
+		targets = targets[1:]
+	}
+
+	for i, target := range targets {
+		if cmd.Verb == "cp" {
+			// Pod files are [namespace/]pod:path, and their namespace wins over -n
+			if spec, _, ok := strings.Cut(target, ":"); ok && spec != "" {
+				if namespace, pod, ok := strings.Cut(spec, "/"); ok {
+					cmd.Namespace, spec = namespace, pod
+				}
+				cmd.Names = append(cmd.Names, spec)
+			}
+			continue
+		}
+		if kind, name, ok := strings.Cut(target, "/"); ok {
+			cmd.Resources = append(cmd.Resources, kind)
+			cmd.Names = append(cmd.Names, name)
+		} else if i == 0 {
+			cmd.Resources = strings.Split(target, ",")
+		} else {
+			cmd.Names = append(cmd.Names, target)
+		}
+	}
+
+	if cmd.Namespace == "" {
+		cmd.Namespace, _ = inv.Flag("-n", "--namespace")
+	}
+	cmd.Context, _ = inv.Flag("--context")
+	cmd.AllNamespaces = inv.HasFlag("-A", "--all-namespaces")
+	for _, name := range []string{"-f", "--filename", "-k", "--kustomize"} {
+		cmd.Files = append(cmd.Files, inv.Flags[name]...)
+	}
+	return cmd
+}
+
+// EffectiveNamespace returns the namespace the command runs in, assuming
+// kubectl's "default" when none is given.
+func (c KubectlCommand) EffectiveNamespace() string {
+	if c.Namespace == "" {
+		return "default"
+	}
+	return c.Namespace
+}
+
+// kubectlMutatingVerbs change namespaced resources, or run commands in or
+// reach into their pods.
+var kubectlMutatingVerbs = []string{
+	"apply", "create", "delete", "edit", "patch", "replace", "scale", "autoscale",
+	"label", "annotate", "expose", "run",
+	"exec", "cp", "debug", "attach", "port-forward",
+	"rollout restart", "rollout undo", "rollout pause", "rollout resume",
+	"set image", "set env", "set resources", "set selector", "set serviceaccount",
+	"set subject",
+}
+
+// kubectlProtectedNamespaces are the namespaces the
+// kubectl-protected-namespaces preset refuses to change.
+var kubectlProtectedNamespaces = []string{"default", "kube-system", "kube-public", "kube-node-lease", "prod", "production"}
+
+// kubectlProtectedNamespacesPreset blocks changes to protected namespaces,
+// matching the namespace flag rather than searching the command for a
+// namespace name.
+func kubectlProtectedNamespacesPreset() Preset {
+	return Preset{
+		Name:        "kubectl-protected-namespaces",
+		Description: "kubectl changes in default, kube-*, prod, or across all namespaces",
+		Rules: []CommandRule{{
+			BlockedCommand: "kubectl",
+			Args:           kubectlArgs,
+			Suggest:        "work in a dedicated namespace with -n, or ask the human to make this change",
+			Match: func(inv Invocation) string {
+				cmd := kubectlCommand(inv)
+				if !slices.Contains(kubectlMutatingVerbs, cmd.Verb) {
+					return ""
+				}
+				if cmd.AllNamespaces {
+					return "Blocked kubectl " + cmd.Verb + " across all namespaces"
+				}
+				if cmd.Verb == "delete" && slices.ContainsFunc(cmd.Resources, isKubectlNamespaceResource) {
+					for _, name := range cmd.Names {
+						if slices.Contains(kubectlProtectedNamespaces, name) {
+							return "Blocked kubectl delete of protected namespace " + name
+						}
+					}
+				}
+				if namespace := cmd.EffectiveNamespace(); slices.Contains(kubectlProtectedNamespaces, namespace) {
+					return "Blocked kubectl " + cmd.Verb + " in protected namespace " + namespace
+				}
+				return ""
+			},
+		}},
+	}
+}
+
+// isKubectlNamespaceResource reports whether a resource type names namespaces.
+func isKubectlNamespaceResource(resource string) bool {
+	switch strings.ToLower(resource) {
+	case "ns", "namespace", "namespaces":
+		return true
+	}
+	return false
+}
+
+// kubectlDestructivePreset blocks kubectl commands that remove workloads or
+// nodes outright, in any namespace.
+func kubectlDestructivePreset() Preset {
+	return Preset{
+		Name:        "kubectl-destructive",
+		Description: "kubectl delete, drain, replace --force, and scale to zero",
+		Rules: []CommandRule{{
+			BlockedCommand: "kubectl",
+			Args:           kubectlArgs,
+			Suggest:        "preview with `kubectl diff` or --dry-run=server, or ask the human to run it",
+			Match: func(inv Invocation) string {
+				cmd := kubectlCommand(inv)
+				switch {
+				case cmd.Verb == "delete" || cmd.Verb == "drain":
+					return "Blocked kubectl " + cmd.Verb
+				case cmd.Verb == "replace" && inv.HasFlag("--force"):
+					return "Blocked kubectl replace --force"
+				case cmd.Verb == "scale":
+					if replicas, _ := inv.Flag("--replicas"); replicas == "0" {
+						return "Blocked kubectl scale to zero replicas"
+					}
+				}
+				return ""
+			},
+		}},
+	}
+}
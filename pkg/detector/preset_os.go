@@ -0,0 +1,156 @@
+// Package detector - operating system presets
+package detector
+
+import (
+	"slices"
+	"strings"
+)
+
+// diskutilDestructiveVerbs erase or repartition disks. diskutil accepts its
+// verbs in any case, so they are listed lowercased.
+var diskutilDestructiveVerbs = []string{
+	"erasedisk", "erasevolume", "reformat", "partitiondisk", "splitpartition",
+	"mergepartitions", "zerodisk", "randomdisk", "secureerase",
+}
+
+// diskutilAPFSDestructiveVerbs are the diskutil apfs verbs that delete or
+// erase containers and volumes.
+var diskutilAPFSDestructiveVerbs = []string{"deletecontainer", "deletevolume", "erasevolume"}
+
+// systemctlArgs is systemctl's flag syntax, so flag values are not mistaken
+// for verbs or units.
+var systemctlArgs = ArgSpec{
+	ValueFlags: []string{
+		"-t", "--type", "-s", "--signal", "-H", "--host", "-M", "--machine",
+		"-p", "--property", "-n", "--lines", "-o", "--output", "--root",
+		"--state", "--kill-whom", "--job-mode", "--what", "--timestamp",
+	},
+	ShortAttached: true,
+}
+
+// systemctlStoppingVerbs stop a unit now or keep it from starting at boot.
+var systemctlStoppingVerbs = []string{"stop", "disable", "mask", "kill"}
+
+// criticalUnits are the system units whose loss cuts off remote access,
+// networking, or logging, without the .service suffix.
+var criticalUnits = []string{
+	"ssh", "sshd", "network", "networking", "NetworkManager", "systemd-networkd",
+	"systemd-resolved", "systemd-journald", "systemd-logind", "dbus",
+	"firewalld", "ufw", "nftables", "iptables", "auditd", "cron", "crond",
+}
+
+// iptablesCommands share iptables' flush syntax.
+var iptablesCommands = []string{"iptables", "ip6tables", "iptables-nft", "iptables-legacy", "ip6tables-nft", "ip6tables-legacy"}
+
+// osDestructivePreset blocks commands that damage the machine itself rather
+// than the project: erasing disks, disabling System Integrity Protection,
+// unloading system daemons, stopping critical services, dropping firewall
+// rules, and deleting users.
+func osDestructivePreset() Preset {
+	rules := []CommandRule{
+		{
+			BlockedCommand: "diskutil",
+			Suggest:        "use `diskutil list` or `diskutil info` to inspect disks, or ask the human to run it",
+			Match: func(inv Invocation) string {
+				verb := strings.ToLower(inv.Subcommand(0))
+				if slices.Contains(diskutilDestructiveVerbs, verb) {
+					return "Blocked diskutil " + inv.Subcommand(0)
+				}
+				if verb == "apfs" && slices.Contains(diskutilAPFSDestructiveVerbs, strings.ToLower(inv.Subcommand(1))) {
+					return "Blocked diskutil apfs " + inv.Subcommand(1)
+				}
+				return ""
+			},
+		},
+		{
+			BlockedCommand: "csrutil",
+			Suggest:        "use `csrutil status` to inspect System Integrity Protection, or ask the human to change it",
+			Match: func(inv Invocation) string {
+				if slices.Contains(inv.Positionals, "disable") {
+					return "Blocked csrutil " + strings.Join(inv.Positionals, " ")
+				}
+				return ""
+			},
+		},
+		{
+			BlockedCommand: "launchctl",
+			Suggest:        "use `launchctl list` or `launchctl print` to inspect daemons, or ask the human to run it",
+			Match: func(inv Invocation) string {
+				verb := inv.Subcommand(0)
+				if !slices.Contains([]string{"unload", "bootout", "disable", "remove"}, verb) {
+					return ""
+				}
+				for _, target := range inv.Positionals[1:] {
+					if isSystemLaunchTarget(target) {
+						return "Blocked launchctl " + verb + " of system daemon " + target
+					}
+				}
+				return ""
+			},
+		},
+		{
+			BlockedCommand: "systemctl",
+			Args:           systemctlArgs,
+			Suggest:        "use `systemctl status` to inspect the unit, or ask the human to run it",
+			Match: func(inv Invocation) string {
+				verb := inv.Subcommand(0)
+				if !slices.Contains(systemctlStoppingVerbs, verb) || inv.HasFlag("--user") {
+					return ""
+				}
+				for _, unit := range inv.Positionals[1:] {
+					if slices.Contains(criticalUnits, strings.TrimSuffix(unit, ".service")) {
+						return "Blocked systemctl " + verb + " of critical unit " + unit
+					}
+				}
+				return ""
+			},
+		},
+		{
+			BlockedCommand: "nft",
+			Suggest:        "use `nft list ruleset` to inspect the firewall, or ask the human to change it",
+			Match: func(inv Invocation) string {
+				if inv.Subcommand(0) == "flush" && inv.Subcommand(1) == "ruleset" {
+					return "Blocked nft flush ruleset"
+				}
+				return ""
+			},
+		},
+		{
+			BlockedCommand: "userdel",
+			Suggest:        "ask the human to delete the user",
+			Match:          func(Invocation) string { return "Blocked userdel" },
+		},
+		{
+			BlockedCommand: "deluser",
+			Suggest:        "ask the human to delete the user",
+			Match:          func(Invocation) string { return "Blocked deluser" },
+		},
+	}
+	for _, command := range iptablesCommands {
+		rules = append(rules, CommandRule{
+			BlockedCommand: command,
+			Args:           ArgSpec{ValueFlags: []string{"-t", "--table"}},
+			Suggest:        "use `" + command + " -L` to inspect the rules, or ask the human to change them",
+			Match: func(inv Invocation) string {
+				for flag := range inv.Flags {
+					if flag == "--flush" || !strings.HasPrefix(flag, "--") && strings.Contains(flag, "F") {
+						return "Blocked " + command + " flush"
+					}
+				}
+				return ""
+			},
+		})
+	}
+	return Preset{
+		Name:        "os-destructive",
+		Description: "diskutil erase, csrutil disable, launchctl unload of system daemons, systemctl stop/disable of critical units, iptables -F, and userdel",
+		Rules:       rules,
+	}
+}
+
+// isSystemLaunchTarget reports whether a launchctl target is a system daemon:
+// the system domain, as in system/com.apple.sshd, or a plist in a
+// LaunchDaemons directory.
+func isSystemLaunchTarget(target string) bool {
+	return target == "system" || strings.HasPrefix(target, "system/") || strings.Contains(target, "/LaunchDaemons/")
+}
@@ -0,0 +1,71 @@
+// Package detector - job scheduling presets
+package detector
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// atArgs is the option syntax of at and batch.
+var atArgs = ArgSpec{ValueFlags: []string{"-f", "-q", "-t"}}
+
+// scheduledJobsPreset blocks installing jobs that run later, outside the
+// session, where no hook sees the command when it finally runs.
+func scheduledJobsPreset() Preset {
+	atRule := func(command string) CommandRule {
+		return CommandRule{
+			BlockedCommand: command,
+			Args:           atArgs,
+			Suggest:        "run the command now instead, or ask the human to schedule it",
+			Match: func(inv Invocation) string {
+				if inv.HasFlag("-l", "-c", "-r", "-d") {
+					return "" // Listing, showing, or removing jobs
+				}
+				return "Blocked " + command + " job"
+			},
+		}
+	}
+	return Preset{
+		Name:        "scheduled-jobs",
+		Description: "crontab installs and edits, at and batch jobs, systemd-run timers, and launchctl submit/load/bootstrap",
+		Rules: []CommandRule{
+			{
+				BlockedCommand: "crontab",
+				Args:           ArgSpec{ValueFlags: []string{"-u"}},
+				Suggest:        "use `crontab -l` to inspect the schedule, or ask the human to change it",
+				Match: func(inv Invocation) string {
+					if inv.HasFlag("-l") {
+						return ""
+					}
+					return "Blocked crontab change"
+				},
+			},
+			atRule("at"),
+			atRule("batch"),
+			{
+				BlockedCommand: "systemd-run",
+				Args:           ArgSpec{ValueFlags: systemdRunValueFlags, ShortAttached: true},
+				Suggest:        "run the command now instead, or ask the human to schedule it",
+				Match: func(inv Invocation) string {
+					for _, flag := range slices.Sorted(maps.Keys(inv.Flags)) {
+						if strings.HasPrefix(flag, "--on-") {
+							return "Blocked systemd-run timer " + flag
+						}
+					}
+					return ""
+				},
+			},
+			{
+				BlockedCommand: "launchctl",
+				Suggest:        "use `launchctl list` to inspect jobs, or ask the human to load it",
+				Match: func(inv Invocation) string {
+					if verb := inv.Subcommand(0); slices.Contains([]string{"submit", "load", "bootstrap"}, verb) {
+						return "Blocked launchctl " + verb
+					}
+					return ""
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,93 @@
+// Package detector - shell startup file presets
+package detector
+
+import "strings"
+
+// shellStartupFiles are the files, relative to the home directory, that
+// shells and git read at startup. Changes to them outlive the session.
+var shellStartupFiles = []string{
+	".bashrc", ".bash_profile", ".bash_login", ".bash_logout", ".profile",
+	".zshrc", ".zshenv", ".zprofile", ".zlogin", ".zlogout",
+	".config/fish/config.fish", ".gitconfig", ".config/git/config",
+}
+
+// shellRCPreset blocks changes to shell startup files and the global git
+// config, such as an appended alias or credential helper, which persist
+// beyond the session and run in every later shell.
+func shellRCPreset() Preset {
+	suggest := "ask the human to change their shell or git configuration"
+	writeRule := func(command string, targets func(inv Invocation) []string) CommandRule {
+		return CommandRule{
+			BlockedCommand: command,
+			Suggest:        suggest,
+			Match: func(inv Invocation) string {
+				for _, target := range targets(inv) {
+					if isShellStartupFile(target) {
+						return "Blocked " + command + " of shell startup file " + target
+					}
+				}
+				return ""
+			},
+		}
+	}
+	operands := func(inv Invocation) []string { return inv.Positionals }
+	destination := func(inv Invocation) []string { return inv.Positionals[max(len(inv.Positionals)-1, 0):] }
+	inPlace := func(inv Invocation) []string {
+		if hasInPlaceFlag(inv) {
+			return inv.Positionals
+		}
+		return nil
+	}
+
+	var redirects []string
+	for _, file := range shellStartupFiles {
+		redirects = append(redirects, "~/"+file)
+	}
+	return Preset{
+		Name:        "shell-rc",
+		Description: "writes to shell startup files and ~/.gitconfig, and git config --global changes",
+		Redirects:   redirects,
+		Rules: []CommandRule{
+			writeRule("tee", operands),
+			writeRule("cp", destination),
+			writeRule("install", destination),
+			writeRule("ln", destination),
+			writeRule("mv", destination),
+			writeRule("sed", inPlace),
+			writeRule("perl", inPlace),
+			writeRule("dd", ddOutputs),
+			{
+				BlockedCommand: "git",
+				Args:           gitConfigArgs,
+				Suggest:        suggest,
+				Match: func(inv Invocation) string {
+					if _, ok := parseGitConfigChange(inv); !ok {
+						return ""
+					}
+					file, _ := inv.Flag("-f", "--file")
+					if !inv.HasFlag("--global", "--system") && !isShellStartupFile(file) {
+						return "" // Repository config
+					}
+					return "Blocked git config change of global config"
+				},
+			},
+		},
+	}
+}
+
+// isShellStartupFile reports whether path names a shell startup file in the
+// home directory, written with ~, $HOME, or the full path.
+func isShellStartupFile(path string) bool {
+	path = expandHome(path)
+	for _, prefix := range []string{"$HOME", "${HOME}"} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			path = expandHome("~" + rest)
+		}
+	}
+	for _, file := range shellStartupFiles {
+		if path == expandHome("~/"+file) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+// Package detector - curated rule presets
+package detector
+
+import "slices"
+
+// Preset is a curated, named set of rules for a tool. Unlike -cmd patterns,
+// preset rules are flag-aware, so they see through global flags and aliases.
+type Preset struct {
+	Name        string
+	Description string
+	Rules       []CommandRule
+	// Redirects are output redirection targets the preset protects, as
+	// SetProtectedRedirects patterns, e.g. "~/.bashrc" for echo ... >> ~/.bashrc.
+	Redirects []string
+	// RequiresGitConfig, if set, limits the preset to git repositories where
+	// one of these boolean config variables is true, e.g. commit.gpgsign.
+	// The detector has no repository context, so callers check it.
+	RequiresGitConfig []string
+}
+
+// presets lists the built-in presets in the order they are documented.
+var presets = namedPresets(
+	gitPushPreset(),
+	gitConfigPreset(),
+	gitSigningPreset(),
+	kubectlDestructivePreset(),
+	kubectlProtectedNamespacesPreset(),
+	awsDestructivePreset(),
+	gitopsDestructivePreset(),
+	gcloudDestructivePreset(),
+	azDestructivePreset(),
+	scheduledJobsPreset(),
+	shellRCPreset(),
+	osDestructivePreset(),
+)
+
+// namedPresets labels each preset's rules with the preset name, which block
+// reasons report as the matched rule, and its description.
+func namedPresets(presets ...Preset) []Preset {
+	for _, preset := range presets {
+		for i := range preset.Rules {
+			preset.Rules[i].Name = preset.Name
+			preset.Rules[i].Description = preset.Description
+		}
+	}
+	return presets
+}
+
+// Presets returns the built-in presets.
+func Presets() []Preset {
+	return slices.Clone(presets)
+}
+
+// LookupPreset returns the built-in preset with the given name.
+func LookupPreset(name string) (Preset, bool) {
+	i := slices.IndexFunc(presets, func(p Preset) bool { return p.Name == name })
+	if i < 0 {
+		return Preset{}, false
+	}
+	return presets[i], true
+}
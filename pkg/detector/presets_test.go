@@ -0,0 +1,567 @@
+package detector
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestArgSpec_Parse(t *testing.T) {
+	spec := ArgSpec{ValueFlags: []string{"-C", "-n", "--namespace"}}
+	tests := []struct {
+		name string
+		args []string
+		want Invocation
+	}{
+		{
+			name: "flags before and after subcommands",
+			args: []string{"-C", "/repo", "push", "--force", "origin"},
+			want: Invocation{
+				Positionals: []string{"push", "origin"},
+				Flags:       map[string][]string{"-C": {"/repo"}, "--force": {""}},
+			},
+		},
+		{
+			name: "equals form",
+			args: []string{"delete", "--namespace=prod", "pod", "web"},
+			want: Invocation{
+				Positionals: []string{"delete", "pod", "web"},
+				Flags:       map[string][]string{"--namespace": {"prod"}},
+			},
+		},
+		{
+			name: "repeated flag keeps every value",
+			args: []string{"-n", "a", "get", "-n", "b"},
+			want: Invocation{
+				Positionals: []string{"get"},
+				Flags:       map[string][]string{"-n": {"a", "b"}},
+			},
+		},
+		{
+			name: "double dash ends flags",
+			args: []string{"log", "--", "-n", "file"},
+			want: Invocation{
+				Positionals: []string{"log", "-n", "file"},
+				Flags:       map[string][]string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spec.Parse(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupPreset(t *testing.T) {
+	for _, preset := range Presets() {
+		got, ok := LookupPreset(preset.Name)
+		if !ok || got.Name != preset.Name {
+			t.Errorf("LookupPreset(%q) = %v, %v", preset.Name, got.Name, ok)
+		}
+		if len(preset.Rules) == 0 || preset.Description == "" {
+			t.Errorf("preset %q has no rules or description", preset.Name)
+		}
+	}
+	if _, ok := LookupPreset("no-such-preset"); ok {
+		t.Error("LookupPreset(no-such-preset) found a preset")
+	}
+}
+
+func TestPreset_GitPush(t *testing.T) {
+	preset, _ := LookupPreset("git-push")
+	aliases := map[string]string{
+		"p":    "push",
+		"pf":   "p --force",
+		"ship": "!git push origin HEAD",
+		"st":   "status",
+		"loop": "loop",
+	}
+
+	tests := []struct {
+		name      string
+		command   string
+		wantBlock bool
+	}{
+		{"direct push", "git push origin main", true},
+		{"work tree flag", "git -C ../other push", true},
+		{"config flag", "git -c http.sslVerify=false push", true},
+		{"git dir flag", "git --git-dir=/repo/.git --work-tree /repo push", true},
+		{"no pager", "git --no-pager push", true},
+		{"subtree push", "git subtree push --prefix dist origin gh-pages", true},
+		{"configured alias", "git p", true},
+		{"alias of alias", "git pf origin", true},
+		{"shell alias", "git ship", true},
+		{"inline alias", "git -c alias.x=push x", true},
+		{"inline alias with uppercase key", "git -c Alias.x=push x", true},
+		{"alias via xargs", "echo main | xargs git p origin", true},
+		{"harmless alias", "git st", false},
+		{"self-referencing alias", "git loop", false},
+		{"pull", "git pull", false},
+		{"push in config key", "git -c push.default=current status", false},
+		{"push as commit message", "git commit -m push", false},
+		{"help for push", "git help push", false},
+		{"dynamic subcommand", "git $CMD", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(preset.Rules, 10)
+			detector.SetAliases("git", aliases)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestParseKubectl(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want KubectlCommand
+	}{
+		{
+			name: "namespace after resource",
+			args: []string{"delete", "pod", "web", "--namespace", "prod"},
+			want: KubectlCommand{Verb: "delete", Resources: []string{"pod"}, Names: []string{"web"}, Namespace: "prod"},
+		},
+		{
+			name: "global flags before verb",
+			args: []string{"--context", "prod-eu", "-n=payments", "delete", "deploy/api"},
+			want: KubectlCommand{Verb: "delete", Resources: []string{"deploy"}, Names: []string{"api"}, Namespace: "payments", Context: "prod-eu"},
+		},
+		{
+			name: "attached short namespace",
+			args: []string{"get", "pods,svc", "-nkube-system"},
+			want: KubectlCommand{Verb: "get", Resources: []string{"pods", "svc"}, Namespace: "kube-system"},
+		},
+		{
+			name: "grouped verb and files",
+			args: []string{"rollout", "restart", "deployment", "web", "-A"},
+			want: KubectlCommand{Verb: "rollout restart", Resources: []string{"deployment"}, Names: []string{"web"}, AllNamespaces: true},
+		},
+		{
+			name: "manifest files",
+			args: []string{"apply", "-f", "base.yaml", "--filename=overlay.yaml"},
+			want: KubectlCommand{Verb: "apply", Files: []string{"base.yaml", "overlay.yaml"}},
+		},
+		{
+			name: "pod file namespace wins over the flag",
+			args: []string{"cp", "-n", "dev", "./patch.sh", "prod/web:/tmp/patch.sh"},
+			want: KubectlCommand{Verb: "cp", Names: []string{"web"}, Namespace: "prod"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseKubectl(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKubectl(%q) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreset_Kubectl(t *testing.T) {
+	tests := []struct {
+		preset    string
+		command   string
+		wantBlock bool
+	}{
+		{"kubectl-protected-namespaces", "kubectl apply -f app.yaml --namespace prod", true},
+		{"kubectl-protected-namespaces", "kubectl --namespace=kube-system delete pod coredns-1", true},
+		{"kubectl-protected-namespaces", "kubectl -n prod rollout restart deploy/web", true},
+		{"kubectl-protected-namespaces", "kubectl apply -f app.yaml", true},
+		{"kubectl-protected-namespaces", "kubectl delete pods --all -A", true},
+		{"kubectl-protected-namespaces", "kubectl delete namespace prod -n dev", true},
+		{"kubectl-protected-namespaces", "kubectl delete pod default-backend -n dev", false},
+		{"kubectl-protected-namespaces", "kubectl apply -f default.yaml -n staging", false},
+		{"kubectl-protected-namespaces", "kubectl get pods -n prod", false},
+		{"kubectl-protected-namespaces", "kubectl logs -n prod deploy/web", false},
+		{"kubectl-protected-namespaces", "kubectl exec -n prod -it web -- sh", true},
+		{"kubectl-protected-namespaces", "kubectl exec web -- cat /etc/passwd", true},
+		{"kubectl-protected-namespaces", "kubectl cp ./patch.sh prod/web:/tmp/patch.sh", true},
+		{"kubectl-protected-namespaces", "kubectl cp -n dev ./patch.sh kube-system/web:/tmp/patch.sh", true},
+		{"kubectl-protected-namespaces", "kubectl debug -n production node/worker-1 -it --image=busybox", true},
+		{"kubectl-protected-namespaces", "kubectl attach -n kube-system coredns-1 -i", true},
+		{"kubectl-protected-namespaces", "kubectl port-forward -n prod svc/db 5432:5432", true},
+		{"kubectl-protected-namespaces", "kubectl exec -n dev web -- sh", false},
+		{"kubectl-protected-namespaces", "kubectl cp dev/web:/var/log/app.log ./app.log", false},
+		{"kubectl-protected-namespaces", "kubectl port-forward -n staging svc/db 5432:5432", false},
+		{"kubectl-destructive", "kubectl --context prod delete --force namespace production", true},
+		{"kubectl-destructive", "kubectl drain node-1 --ignore-daemonsets", true},
+		{"kubectl-destructive", "kubectl replace --force -f pod.yaml", true},
+		{"kubectl-destructive", "kubectl scale deploy/web --replicas=0", true},
+		{"kubectl-destructive", "kubectl scale deploy/web --replicas 3", false},
+		{"kubectl-destructive", "kubectl get deployment delete-me", false},
+		{"kubectl-destructive", "kubectl describe pod web -n delete", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.preset+"/"+tt.command, func(t *testing.T) {
+			preset, _ := LookupPreset(tt.preset)
+			detector := NewCommandDetector(preset.Rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestPreset_AWSDestructive(t *testing.T) {
+	preset, _ := LookupPreset("aws-destructive")
+	tests := []struct {
+		command   string
+		wantBlock bool
+	}{
+		{"aws --region us-east-1 ec2 terminate-instances --instance-ids i-0abc", true},
+		{"aws --profile prod --output json ec2 terminate-instances --instance-ids i-0abc i-0def", true},
+		{"aws s3 rb s3://my-bucket --force", true},
+		{"aws --region eu-west-1 s3 rm s3://my-bucket/logs --recursive", true},
+		{"aws iam delete-access-key --user-name ci --access-key-id AKIAEXAMPLE", true},
+		{"aws ecr delete-repository --repository-name api --force", true},
+		{"aws s3 rb s3://empty-bucket", false},
+		{"aws s3 rm s3://my-bucket/one-file.txt", false},
+		{"aws --region us-east-1 ec2 describe-instances --filters Name=tag:Name,Values=delete-me", false},
+		{"aws --query Reservations ec2 describe-instances", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(preset.Rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestPreset_GitOpsDestructive(t *testing.T) {
+	preset, _ := LookupPreset("gitops-destructive")
+	tests := []struct {
+		command   string
+		wantBlock bool
+	}{
+		{"helm uninstall api -n prod", true},
+		{"helm --kube-context prod delete --purge api", true},
+		{"helm -n payments un api", true},
+		{"flux delete kustomization apps", true},
+		{"flux --context prod-eu uninstall --silent", true},
+		{"argocd app delete guestbook --cascade", true},
+		{"argocd --server argocd.example.com appset delete platform", true},
+		{"kubectl --context prod-us delete -f deploy/", true},
+		{"kubectl delete -k overlays/prod --namespace=production", true},
+		{"kubectl delete -f deploy/ --context staging", false},
+		{"kubectl delete -f deploy/", false},
+		{"helm upgrade api ./chart -n prod", false},
+		{"helm list --all-namespaces", false},
+		{"flux get kustomizations", false},
+		{"argocd app sync guestbook", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(preset.Rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestPreset_CloudDestructive(t *testing.T) {
+	tests := []struct {
+		preset    string
+		command   string
+		wantBlock bool
+	}{
+		{"gcloud-destructive", "gcloud projects delete my-project", true},
+		{"gcloud-destructive", "gcloud --project prod compute instances delete vm-1 --zone us-central1-a", true},
+		{"gcloud-destructive", "gcloud beta sql instances delete main-db", true},
+		{"gcloud-destructive", "gcloud container clusters delete prod --region us-east1 --quiet", true},
+		{"gcloud-destructive", "gcloud compute instances list --filter name:delete", false},
+		{"gcloud-destructive", "gcloud projects describe my-project", false},
+		{"gcloud-destructive", "gcloud compute instances stop vm-1", false},
+		{"az-destructive", "az group delete --name rg-prod --yes", true},
+		{"az-destructive", "az --subscription prod vm delete -g rg -n vm1", true},
+		{"az-destructive", "az keyvault purge --name kv-prod", true},
+		{"az-destructive", "az -o json sql db delete -g rg -s srv -n db", true},
+		{"az-destructive", "az group list --query [].name", false},
+		{"az-destructive", "az vm show -g rg -n delete", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.preset+"/"+tt.command, func(t *testing.T) {
+			preset, _ := LookupPreset(tt.preset)
+			detector := NewCommandDetector(preset.Rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestPreset_ScheduledJobs(t *testing.T) {
+	preset, _ := LookupPreset("scheduled-jobs")
+	tests := []struct {
+		command   string
+		wantBlock bool
+	}{
+		{"crontab jobs.txt", true},
+		{"echo '0 3 * * * make backup' | crontab -", true},
+		{"crontab -u deploy -e", true},
+		{"at now + 1 minute <<< 'make deploy'", true},
+		{"batch -f job.sh", true},
+		{"systemd-run --user --on-active=30 make deploy", true},
+		{"systemd-run --on-calendar daily make backup", true},
+		{"launchctl load ~/Library/LaunchAgents/com.example.job.plist", true},
+		{"launchctl submit -l job -- make deploy", true},
+		{"crontab -l", false},
+		{"crontab -u deploy -l", false},
+		{"at -l", false},
+		{"at -c 12", false},
+		{"systemd-run --user --scope make test", false},
+		{"launchctl list", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(preset.Rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestPreset_GitConfig(t *testing.T) {
+	preset, _ := LookupPreset("git-config")
+	tests := []struct {
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		{"git remote set-url origin git@evil.example.com:repo.git", true, "Blocked git remote set-url"},
+		{"git remote set-url --push origin https://evil.example.com/repo.git", true, "Blocked git remote set-url"},
+		{"git config remote.origin.url https://evil.example.com/repo.git", true, "Blocked git config change of push setting remote.origin.url"},
+		{"git config Remote.Origin.PushURL x", true, "Blocked git config change of push setting Remote.Origin.PushURL"},
+		{"git config --add remote.origin.push +refs/heads/*:refs/heads/*", true, "Blocked git config change of push setting remote.origin.push"},
+		{"git config url.git@evil.example.com:.insteadOf https://github.com/", true, "Blocked git config change of push setting url.git@evil.example.com:.insteadOf"},
+		{"git config --global push.default matching", true, "Blocked git config change of push setting push.default"},
+		{"git config credential.helper 'store --file /tmp/creds'", true, "Blocked git config change of push setting credential.helper"},
+		{"git config credential.https://github.com.helper store", true, "Blocked git config change of push setting credential.https://github.com.helper"},
+		{"git config core.sshCommand 'ssh -i /tmp/key'", true, "Blocked git config change of push setting core.sshCommand"},
+		{"git config set --local branch.main.pushRemote fork", true, "Blocked git config change of push setting branch.main.pushRemote"},
+		{"git config --unset remote.pushDefault", true, "Blocked git config change of push setting remote.pushDefault"},
+		{"git config --remove-section remote.origin", true, "Blocked git config change of push settings section remote.origin"},
+		{"git config --edit", true, "Blocked git config edit, which can change push settings"},
+		{"git -c alias.cfg=config cfg core.sshCommand 'ssh -i key'", true, ""},
+		{"git config remote.origin.url", false, ""},
+		{"git config --get credential.helper", false, ""},
+		{"git config get remote.origin.url", false, ""},
+		{"git config --list --show-origin", false, ""},
+		{"git config user.email dev@example.com", false, ""},
+		{"git config core.hooksPath .githooks", false, ""},
+		{"git config --remove-section alias", false, ""},
+		{"git config branch.main.remote origin", false, ""},
+		{"git remote -v", false, ""},
+		{"git remote add upstream https://github.com/example/repo.git", false, ""},
+		{"git -c push.default=current status", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(preset.Rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+}
+
+func TestPreset_GitSigning(t *testing.T) {
+	preset, _ := LookupPreset("git-signing")
+	if len(preset.RequiresGitConfig) == 0 {
+		t.Error("git-signing should only apply to repositories that require signing")
+	}
+	tests := []struct {
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		{"git commit --no-gpg-sign -m wip", true, "Blocked git commit --no-gpg-sign"},
+		{"git -C repo rebase --no-gpg-sign main", true, "Blocked git rebase --no-gpg-sign"},
+		{"git merge --no-gpg-sign feature", true, "Blocked git merge --no-gpg-sign"},
+		{"git -c commit.gpgsign=false commit -m wip", true, "Blocked git -c commit.gpgsign=false"},
+		{"git -c Commit.GPGSign=off commit -m wip", true, "Blocked git -c Commit.GPGSign=off"},
+		{"git config commit.gpgsign false", true, "Blocked git config change turning off commit.gpgsign"},
+		{"git config --global --bool tag.gpgSign no", true, "Blocked git config change turning off tag.gpgSign"},
+		{"git config set commit.gpgsign false", true, "Blocked git config change turning off commit.gpgsign"},
+		{"git config --unset commit.gpgsign", true, "Blocked git config change turning off commit.gpgsign"},
+		{"git config --remove-section commit", true, "Blocked git config change of signing settings section commit"},
+		{"git tag v1.2.0", true, "Blocked unsigned git tag v1.2.0; use git tag -s"},
+		{"git tag -a v1.2.0 -m 'Release 1.2.0'", true, "Blocked unsigned git tag v1.2.0; use git tag -s"},
+		{"git tag --no-sign -m release v1.2.0", true, "Blocked unsigned git tag v1.2.0; use git tag -s"},
+		{"git commit -S -m wip", false, ""},
+		{"git commit -m 'no gpg sign here'", false, ""},
+		{"git -c commit.gpgsign commit -m wip", false, ""},
+		{"git config commit.gpgsign true", false, ""},
+		{"git config --get commit.gpgsign", false, ""},
+		{"git config user.signingkey ABC123", false, ""},
+		{"git tag -s v1.2.0 -m 'Release 1.2.0'", false, ""},
+		{"git tag -sm 'Release 1.2.0' v1.2.0", false, ""},
+		{"git tag -u ABC123 v1.2.0", false, ""},
+		{"git tag", false, ""},
+		{"git tag -l 'v1.*'", false, ""},
+		{"git tag --contains HEAD", false, ""},
+		{"git tag -d v1.2.0", false, ""},
+		{"git tag -v v1.2.0", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(preset.Rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+}
+
+func TestPreset_ShellRC(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	preset, _ := LookupPreset("shell-rc")
+	tests := []struct {
+		command   string
+		wantBlock bool
+	}{
+		{"echo 'alias git=true' >> ~/.bashrc", true},
+		{`printf 'export PATH=/tmp/bin:$PATH\n' >> "$HOME/.zshrc"`, true},
+		{"echo 'source ~/.env' | tee -a ~/.profile", true},
+		{"cp dotfiles/bashrc " + home + "/.bashrc", true},
+		{"ln -sf ~/dotfiles/zshrc ~/.zshrc", true},
+		{"sed -i 's/^alias g=.*//' ~/.bashrc", true},
+		{"perl -pi -e 's/x/y/' ~/.gitconfig", true},
+		{"git config --global credential.helper store", true},
+		{"git config --global alias.p push", true},
+		{"git config --global --unset user.email", true},
+		{"git config --file ~/.gitconfig core.pager cat", true},
+		{"git config set --global core.editor vim", true},
+		{"cat ~/.bashrc", false},
+		{"cp ~/.bashrc bashrc.bak", false},
+		{"sed 's/x/y/' ~/.bashrc", false},
+		{"grep alias ~/.zshrc", false},
+		{"git config --global user.name", false},
+		{"git config --global --list", false},
+		{"git config --get-all --global alias.p", false},
+		{"git config core.hooksPath .githooks", false},
+		{"echo x >> project/.bashrc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(preset.Rules, 10)
+			detector.SetProtectedRedirects(t.TempDir(), preset.Redirects)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+func TestPreset_OSDestructive(t *testing.T) {
+	preset, _ := LookupPreset("os-destructive")
+	tests := []struct {
+		command   string
+		wantBlock bool
+	}{
+		{"diskutil eraseDisk APFS Blank /dev/disk2", true},
+		{"diskutil erasedisk JHFS+ Blank disk2", true},
+		{"diskutil apfs deleteContainer disk3", true},
+		{"diskutil partitionDisk disk2 GPT APFS Data 100%", true},
+		{"csrutil disable", true},
+		{"csrutil authenticated-root disable", true},
+		{"launchctl unload -w /Library/LaunchDaemons/com.example.agent.plist", true},
+		{"launchctl bootout system/com.openssh.sshd", true},
+		{"launchctl disable system/com.apple.screensharing", true},
+		{"systemctl stop sshd", true},
+		{"sudo systemctl stop sshd", true},
+		{"systemctl --now disable ssh.service", true},
+		{"systemctl -H prod mask NetworkManager", true},
+		{"iptables -F", true},
+		{"iptables -t nat --flush", true},
+		{"ip6tables -F INPUT", true},
+		{"nft flush ruleset", true},
+		{"userdel -r deploy", true},
+		{"diskutil list", false},
+		{"diskutil info disk2", false},
+		{"csrutil status", false},
+		{"launchctl unload ~/Library/LaunchAgents/com.example.job.plist", false},
+		{"launchctl print system/com.openssh.sshd", false},
+		{"systemctl status sshd", false},
+		{"systemctl restart nginx", false},
+		{"systemctl stop nginx", false},
+		{"systemctl --user stop ssh", false},
+		{"iptables -L -n -v", false},
+		{"iptables -t filter -A INPUT -p tcp --dport 22 -j ACCEPT", false},
+		{"nft list ruleset", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(preset.Rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Errorf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+		})
+	}
+}
+
+// presetSamples holds a command each preset blocks, used to check that
+// wrappers and the bypass corpus templates do not hide it.
+var presetSamples = map[string]string{
+	"git-push":                     "git push origin main",
+	"git-config":                   "git remote set-url origin git@evil.example.com:repo.git",
+	"git-signing":                  "git commit --no-gpg-sign -m wip",
+	"kubectl-destructive":          "kubectl delete namespace prod",
+	"kubectl-protected-namespaces": "kubectl delete pod api -n kube-system",
+	"aws-destructive":              "aws s3 rb s3://my-bucket --force",
+	"gitops-destructive":           "helm uninstall api -n prod",
+	"gcloud-destructive":           "gcloud projects delete my-project",
+	"az-destructive":               "az group delete --name rg-prod",
+	"scheduled-jobs":               "crontab -r",
+	"shell-rc":                     "tee -a ~/.bashrc",
+	"os-destructive":               "diskutil eraseDisk APFS Blank disk2",
+}
+
+func TestPresets_WrappedCommands(t *testing.T) {
+	wrappers := []string{
+		"command %s",
+		"command -p %s",
+		"builtin %s",
+		"exec %s",
+		"exec -a deploy %s",
+		`\%s`,
+		`\command %s`,
+		"command exec env -i %s",
+	}
+	for _, preset := range Presets() {
+		command, ok := presetSamples[preset.Name]
+		if !ok {
+			t.Errorf("preset %q has no blocked command to wrap", preset.Name)
+			continue
+		}
+		for _, wrapper := range wrappers {
+			wrapped := fmt.Sprintf(wrapper, command)
+			t.Run(preset.Name+"/"+wrapped, func(t *testing.T) {
+				detector := NewCommandDetector(preset.Rules, 10)
+				if !detector.ShouldBlockShellExpr(wrapped) {
+					t.Errorf("ShouldBlockShellExpr(%q) = false, want true. Issues: %v", wrapped, detector.GetIssues())
+				}
+				matched := detector.MatchedRules()
+				if len(matched) == 0 || matched[0].Name != preset.Name {
+					t.Errorf("MatchedRules() = %v, want the %s rule", matched, preset.Name)
+				}
+			})
+		}
+	}
+}
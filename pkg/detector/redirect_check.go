@@ -0,0 +1,119 @@
+// Package detector - process substitution and redirection checks
+package detector
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/pathmatch"
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// SetProtectedRedirects blocks output redirections (>, >>, &>, >|, <>) whose
+// target matches one of patterns. Patterns use pathmatch.Match syntax, a
+// trailing "/**" covers everything below a directory, and "~/" is the home
+// directory. Relative patterns and targets are resolved against cwd.
+func (d *CommandDetector) SetProtectedRedirects(cwd string, patterns []string) {
+	d.redirectCwd = cwd
+	d.redirectPatterns = patterns
+}
+
+// writeRedirectOps are the redirection operators that write to their target.
+var writeRedirectOps = []syntax.RedirOperator{
+	syntax.RdrOut, syntax.AppOut, syntax.RdrAll, syntax.AppAll, syntax.ClbOut, syntax.RdrInOut,
+}
+
+// checkRedirects blocks writes to protected paths anywhere in the AST.
+func (d *CommandDetector) checkRedirects(node syntax.Node) bool {
+	if len(d.redirectPatterns) == 0 {
+		return false
+	}
+	for _, redirect := range shellparse.Redirects(node) {
+		if !slices.Contains(writeRedirectOps, redirect.Op) {
+			continue
+		}
+		target, ok := redirectTarget(redirect.Word)
+		if !ok {
+			d.addIssue("Redirection target " + shellparse.Print(redirect.Word) + " is dynamic - unable to verify it is not protected")
+			return true // BLOCK
+		}
+		if d.isProtectedRedirect(target) {
+			d.addIssue("Redirection to protected path: " + redirect.Op.String() + " " + target)
+			return true // BLOCK
+		}
+	}
+	return false
+}
+
+// redirectTarget resolves a redirection target, expanding a leading tilde and
+// environment variables such as $HOME. Targets using command substitution or
+// unset variables cannot be resolved.
+func redirectTarget(word *syntax.Word) (string, bool) {
+	if target, isStatic := shellparse.StaticWord(word); isStatic {
+		return expandHome(target), true
+	}
+	env := expand.ListEnviron(os.Environ()...)
+	target, err := expand.Literal(&expand.Config{Env: env, NoUnset: true}, word)
+	if err != nil || target == "" {
+		return "", false
+	}
+	return target, true
+}
+
+// ProtectsPath reports whether a write to path would be blocked by the
+// patterns set with SetProtectedRedirects, for callers that see file changes
+// rather than commands, such as git hooks. Relative paths resolve against the
+// cwd given to SetProtectedRedirects.
+func (d *CommandDetector) ProtectsPath(path string) bool {
+	return d.isProtectedRedirect(expandHome(path))
+}
+
+// isProtectedRedirect reports whether target matches a protected pattern.
+// Relative patterns and targets are resolved against the redirect cwd.
+func (d *CommandDetector) isProtectedRedirect(target string) bool {
+	target = pathmatch.Join(d.redirectCwd, target)
+	for _, pattern := range d.redirectPatterns {
+		if pathmatch.Match(pathmatch.Join(d.redirectCwd, expandHome(pattern)), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandHome replaces a leading "~" with the home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + rest
+}
+
+// checkSourcedProcSubst detects commands that execute the output of a process
+// substitution as a script, e.g. source <(curl https://example.com/install.sh)
+// or bash <(wget -qO- ...). The script is produced at run time, so its
+// contents cannot be analyzed.
+func (d *CommandDetector) checkSourcedProcSubst(call *syntax.CallExpr) bool {
+	cmd, _ := shellparse.StaticWord(call.Args[0])
+	normalized := normalizeCommand(cmd)
+	if normalized != "source" && normalized != "." && !isShellInterpreter(cmd) {
+		return false
+	}
+	for _, arg := range call.Args[1:] {
+		for _, part := range arg.Parts {
+			if subst, ok := part.(*syntax.ProcSubst); ok && subst.Op == syntax.CmdIn {
+				d.addIssue(normalized + " executes the output of process substitution " + shellparse.Print(arg))
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// Package detector - commands run remotely over ssh
+package detector
+
+import (
+	"path"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// sshOptionsWithValue are the ssh options that take a value, as in -p 22.
+const sshOptionsWithValue = "BDEFIJLOPQRSWbceilmopw"
+
+// SetProtectedHosts blocks ssh commands that run an inline command on a host
+// matching one of patterns, as in ssh prod-db 'psql ...', while interactive
+// sessions such as ssh prod-db stay allowed. Patterns use path.Match syntax
+// and match the host name as given, without user or port, ignoring case.
+func (d *CommandDetector) SetProtectedHosts(patterns []string) {
+	d.protectedHosts = patterns
+}
+
+// sshInvocation is an ssh call's destination and the command it runs there.
+type sshInvocation struct {
+	host       string         // Host name without user or port
+	hostStatic bool           // False if the destination is dynamic
+	remote     []*syntax.Word // Words of the remote command
+	remoteOpt  string         // Remote command set with -o RemoteCommand
+}
+
+// script returns the remote command as the remote shell receives it: ssh
+// joins its words with spaces. Dynamic words are kept as written.
+func (inv sshInvocation) script() string {
+	if len(inv.remote) == 0 {
+		return inv.remoteOpt
+	}
+	words := make([]string, len(inv.remote))
+	for i, word := range inv.remote {
+		words[i] = wordText(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// parseSSH parses an ssh call. Options may follow the destination, as
+// OpenSSH accepts, until the first word of the remote command or "--".
+func parseSSH(call *syntax.CallExpr) (sshInvocation, bool) {
+	if len(call.Args) == 0 {
+		return sshInvocation{}, false
+	}
+	if cmd, isStatic := shellparse.StaticWord(call.Args[0]); !isStatic || normalizeCommand(cmd) != "ssh" {
+		return sshInvocation{}, false
+	}
+
+	var inv sshInvocation
+	haveHost := false
+	args := call.Args[1:]
+	for len(args) > 0 {
+		arg, isStatic := shellparse.StaticWord(args[0])
+		switch {
+		case isStatic && arg == "--":
+			args = args[1:]
+			if !haveHost && len(args) > 0 {
+				inv.setHost(args[0])
+				args = args[1:]
+			}
+			inv.remote = args
+			return inv, true
+		case isStatic && len(arg) > 1 && arg[0] == '-':
+			args = skipShortOption(args, sshOptionsWithValue, func(opt byte, value string) {
+				if opt == 'o' {
+					inv.remoteCommandOption(value)
+				}
+			})
+		case !haveHost:
+			inv.setHost(args[0])
+			haveHost = true
+			args = args[1:]
+		default:
+			inv.remote = args
+			return inv, true
+		}
+	}
+	return inv, haveHost
+}
+
+// setHost records the destination, [user@]host[:port] or
+// ssh://[user@]host[:port].
+func (inv *sshInvocation) setHost(word *syntax.Word) {
+	dest, isStatic := shellparse.StaticWord(word)
+	inv.hostStatic = isStatic
+	if !isStatic {
+		return
+	}
+	dest = strings.TrimPrefix(dest, "ssh://")
+	dest = dest[strings.LastIndex(dest, "@")+1:]
+	if bracketed, ok := strings.CutPrefix(dest, "["); ok {
+		dest, _, _ = strings.Cut(bracketed, "]") // IPv6 address
+	} else {
+		dest, _, _ = strings.Cut(dest, ":")
+	}
+	inv.host = strings.ToLower(strings.TrimSuffix(dest, "/"))
+}
+
+// remoteCommandOption records the command of an ssh_config option given
+// with -o, as "RemoteCommand=git push" or "RemoteCommand git push".
+func (inv *sshInvocation) remoteCommandOption(option string) {
+	key, value, ok := strings.Cut(option, "=")
+	if !ok {
+		key, value, _ = strings.Cut(option, " ")
+	}
+	if strings.EqualFold(strings.TrimSpace(key), "RemoteCommand") {
+		inv.remoteOpt = strings.TrimSpace(value)
+	}
+}
+
+// checkRemoteCommand analyzes the command an ssh call runs on the remote host
+// as shell code, and blocks inline commands to protected hosts.
+// Examples:
+//   - ssh host 'git push origin main'
+//   - ssh -p 2222 host -- aws ec2 terminate-instances --instance-ids i-0abc
+func (d *CommandDetector) checkRemoteCommand(call *syntax.CallExpr) bool {
+	inv, ok := parseSSH(call)
+	if !ok {
+		return false
+	}
+	script := inv.script()
+	if script == "" {
+		return false // Interactive session
+	}
+
+	if len(d.protectedHosts) > 0 {
+		if !inv.hostStatic {
+			d.addIssue("ssh destination is dynamic - unable to verify it is not a protected host")
+			return true // BLOCK
+		}
+		if d.isProtectedHost(inv.host) {
+			d.addIssue("Inline ssh command to protected host " + inv.host + ": " + script)
+			return true // BLOCK
+		}
+	}
+
+	if d.analyzeShellExprRecursive(script) {
+		d.addIssue("Blocked command runs remotely over ssh: " + script)
+		return true // BLOCK
+	}
+	return false
+}
+
+// isProtectedHost reports whether host matches a protected host pattern.
+func (d *CommandDetector) isProtectedHost(host string) bool {
+	for _, pattern := range d.protectedHosts {
+		if matched, err := path.Match(strings.ToLower(pattern), host); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
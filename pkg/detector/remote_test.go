@@ -0,0 +1,74 @@
+package detector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandDetector_RemoteCommand(t *testing.T) {
+	rules := []CommandRule{
+		{BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+		{BlockedCommand: "aws", BlockedPatterns: []string{"ec2 terminate-instances"}},
+	}
+
+	tests := []struct {
+		name      string
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		{"quoted remote command", "ssh host 'git push origin main'", true, "Blocked command runs remotely over ssh: git push origin main"},
+		{"after double dash", "ssh host -- aws ec2 terminate-instances --instance-ids i-0abc", true, "Blocked command runs remotely over ssh: aws ec2 terminate-instances --instance-ids i-0abc"},
+		{"compound remote command", `ssh deploy@host "cd /srv/app && git push"`, true, "Blocked command runs remotely over ssh: cd /srv/app && git push"},
+		{"options before and after host", "ssh -i key.pem -tt host -p 2222 'git push'", true, "Blocked command runs remotely over ssh: git push"},
+		{"joined option value", "ssh -p2222 host git push", true, "Blocked command runs remotely over ssh: git push"},
+		{"remote command option", "ssh -o RemoteCommand='git push' host", true, "Blocked command runs remotely over ssh: git push"},
+		{"remote shell", `ssh host bash -c "'git push'"`, true, ""},
+		{"harmless remote command", "ssh host 'git status && uptime'", false, ""},
+		{"interactive session", "ssh -A -p 2222 user@host", false, ""},
+		{"option value is not a host", "ssh -l git host uptime", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+}
+
+func TestCommandDetector_ProtectedHosts(t *testing.T) {
+	rules := []CommandRule{{BlockedCommand: "git", BlockedPatterns: []string{"push"}}}
+
+	tests := []struct {
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		{"ssh prod-db 'psql -c \"select 1\"'", true, "Inline ssh command to protected host prod-db: psql -c \"select 1\""},
+		{"ssh admin@PROD-web:22 uptime", true, "Inline ssh command to protected host prod-web: uptime"},
+		{"ssh ssh://deploy@bastion.example.com ls", true, "Inline ssh command to protected host bastion.example.com: ls"},
+		{"ssh $HOST uptime", true, "ssh destination is dynamic - unable to verify it is not a protected host"},
+		{"ssh prod-db", false, ""},
+		{"ssh staging-db uptime", false, ""},
+		{"scp prod-db:/etc/hosts .", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			detector.SetProtectedHosts([]string{"prod-*", "bastion.example.com"})
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Package detector - rule indexing and concurrent evaluation for large policies
+package detector
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const (
+	// concurrentRuleThreshold is the number of candidate rules for a command
+	// from which they are evaluated concurrently. Below it, starting goroutines
+	// costs more than evaluating the rules one after another.
+	concurrentRuleThreshold = 64
+
+	// minRuleGroupSize keeps groups large enough to be worth a goroutine.
+	minRuleGroupSize = 16
+)
+
+// ruleIndex maps the first token of a call to the rules that may match it,
+// so a command is only checked against rules for that command rather than
+// every rule of an org-wide policy.
+type ruleIndex struct {
+	byCommand map[string][]int // Rule indexes by BlockedCommand, ascending
+	unindexed []int            // Rules with path-like commands, checked for every call
+}
+
+// newRuleIndex indexes rules by their blocked command.
+func newRuleIndex(rules []CommandRule) ruleIndex {
+	index := ruleIndex{byCommand: make(map[string][]int)}
+	for i, rule := range rules {
+		ruleCmd := rule.BlockedCommand
+		if ruleCmd == "" || strings.ContainsAny(ruleCmd, `/\`) || strings.HasSuffix(ruleCmd, ".exe") {
+			// isMatchingCommand matches these by suffix, which no key captures
+			index.unindexed = append(index.unindexed, i)
+			continue
+		}
+		index.byCommand[ruleCmd] = append(index.byCommand[ruleCmd], i)
+	}
+	return index
+}
+
+// candidates returns the indexes, in rule order, of the rules whose command
+// may match cmd. Every rule isMatchingCommand would match is included. The
+// result must not be modified.
+func (idx ruleIndex) candidates(cmd string) []int {
+	keys := commandKeys(cmd)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	var lists [][]int
+	for _, key := range keys {
+		if rules, ok := idx.byCommand[key]; ok {
+			lists = append(lists, rules)
+		}
+	}
+	if len(idx.unindexed) > 0 {
+		lists = append(lists, idx.unindexed)
+	}
+	switch len(lists) {
+	case 0:
+		return nil
+	case 1:
+		return lists[0] // The common case: a plain command name
+	}
+	result := slices.Concat(lists...)
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// commandKeys returns the names a rule command without path separators must
+// equal for isMatchingCommand(cmd, ruleCmd) to hold: cmd itself, its last
+// path element, and its normalized form, for cmd with and without ".exe".
+func commandKeys(cmd string) []string {
+	keys := []string{cmd, cmd[strings.LastIndexAny(cmd, `/\`)+1:], normalizeCommand(cmd)}
+	if trimmed, ok := strings.CutSuffix(cmd, ".exe"); ok {
+		keys = append(keys, commandKeys(trimmed)...)
+	}
+	return keys
+}
+
+// ruleGroup is a run of candidate rules one goroutine evaluates on its own
+// copy of the detector, so issues and matches can be merged in rule order.
+type ruleGroup struct {
+	rules   []int
+	scratch CommandDetector
+	blocked int // The rule that blocked, or -1
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// checkRulesConcurrently evaluates candidate rules for a call in concurrent
+// groups. When a rule blocks, groups of later rules are cancelled, while
+// groups of earlier rules finish, so the result, issues, and matched rule are
+// the same as evaluating the rules in order.
+func (d *CommandDetector) checkRulesConcurrently(call *syntax.CallExpr, cmd string, candidates []int) bool {
+	parent, cancelAll := context.WithCancel(d.context())
+	defer cancelAll()
+
+	size := max(minRuleGroupSize, (len(candidates)+runtime.GOMAXPROCS(0)-1)/runtime.GOMAXPROCS(0))
+	var groups []*ruleGroup
+	for rules := range slices.Chunk(candidates, size) {
+		group := &ruleGroup{rules: rules, scratch: *d, blocked: -1}
+		group.scratch.issues = nil
+		group.scratch.matched = nil
+		group.scratch.parseFailed = false
+		group.ctx, group.cancel = context.WithCancel(parent)
+		groups = append(groups, group)
+	}
+
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, index := range group.rules {
+				if group.ctx.Err() != nil {
+					return
+				}
+				if group.scratch.checkRuleMatch(call, cmd, d.commandRules[index]) {
+					group.blocked = index
+					for _, later := range groups[i+1:] {
+						later.cancel()
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Merge up to the first block; cancelled groups all come after it
+	for _, group := range groups {
+		for _, issue := range group.scratch.issues {
+			d.addIssue(issue)
+		}
+		for _, index := range group.scratch.matched {
+			d.recordMatch(index)
+		}
+		d.parseFailed = d.parseFailed || group.scratch.parseFailed
+		if group.blocked >= 0 {
+			d.recordMatch(group.blocked)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestRuleIndex_Candidates(t *testing.T) {
+	rules := []CommandRule{
+		{BlockedCommand: "git"},
+		{BlockedCommand: "aws"},
+		{BlockedCommand: "git"},
+		{BlockedCommand: "bin/kubectl"},
+		{BlockedCommand: "terraform.exe"},
+		{BlockedCommand: ""},
+	}
+	index := newRuleIndex(rules)
+
+	commands := []string{
+		"git", "/usr/bin/git", "./git", "git.exe", `C:\bin\git.exe`, "git.exe.exe",
+		"~/.nix-profile/bin/aws", "aws/", "/usr/local/bin/kubectl", "kubectl",
+		"terraform.exe", `D:\tools\terraform.exe`, "terraform", "gitx", "xgit", "", "/",
+	}
+	for _, cmd := range commands {
+		var want []int
+		for i, rule := range rules {
+			if isMatchingCommand(cmd, rule.BlockedCommand) {
+				want = append(want, i)
+			}
+		}
+		got := index.candidates(cmd)
+		for _, i := range want {
+			if !slices.Contains(got, i) {
+				t.Errorf("candidates(%q) = %v, missing rule %d that matches", cmd, got, i)
+			}
+		}
+		if !slices.IsSorted(got) {
+			t.Errorf("candidates(%q) = %v, want rule order", cmd, got)
+		}
+	}
+}
+
+// largePolicy returns n rules spread over a few commands, like an org-wide denylist.
+func largePolicy(n int) []CommandRule {
+	commands := []string{"git", "aws", "kubectl", "gcloud"}
+	rules := make([]CommandRule, 0, n)
+	for i := range n {
+		cmd := commands[i%len(commands)]
+		rules = append(rules, CommandRule{
+			Name:            fmt.Sprintf("%s-%d", cmd, i),
+			BlockedCommand:  cmd,
+			BlockedPatterns: []string{fmt.Sprintf("op%04d", i)},
+		})
+	}
+	return rules
+}
+
+func TestCommandDetector_LargePolicy(t *testing.T) {
+	// Evaluate concurrently even on a single CPU
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	rules := largePolicy(1000)
+	// A later rule blocking the same call must not be reported instead
+	rules = append(rules, CommandRule{Name: "git-any", BlockedCommand: "git", BlockedPatterns: []string{"*"}})
+
+	tests := []struct {
+		command   string
+		wantBlock bool
+		wantRule  string
+	}{
+		{"git op0000", true, "git-0"},
+		{"git op0996", true, "git-996"},
+		{"/usr/bin/aws op0997", true, "aws-997"},
+		{"git status", true, "git-any"},
+		{"aws op0000", false, ""},
+		{"kubectl get pods", false, ""},
+		{"xargs gcloud op0999", true, "gcloud-999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			for range 20 {
+				if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+					t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+				}
+				var got []string
+				for _, rule := range detector.MatchedRules() {
+					got = append(got, rule.Name)
+				}
+				if tt.wantBlock && !slices.Equal(got, []string{tt.wantRule}) {
+					t.Fatalf("MatchedRules() = %v, want [%s]", got, tt.wantRule)
+				}
+			}
+		})
+	}
+}
+
+// TestCommandDetector_ConcurrentCancel checks that rule groups stop with the
+// context of EvaluateContext, not only when an earlier rule blocks.
+func TestCommandDetector_ConcurrentCancel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	canceled := make(chan struct{})
+	rules := []CommandRule{{Name: "cancel", BlockedCommand: "git", Match: func(Invocation) string {
+		cancel()
+		close(canceled)
+		return ""
+	}}}
+	rules = append(rules, largePolicy(400)...)
+	// The last group waits for the cancellation before its blocking rule
+	rules = append(rules,
+		CommandRule{Name: "wait", BlockedCommand: "git", Match: func(Invocation) string {
+			<-canceled
+			return ""
+		}},
+		CommandRule{Name: "git-any", BlockedCommand: "git", BlockedPatterns: []string{"*"}},
+	)
+
+	got, err := NewCommandDetector(rules, 10).EvaluateContext(ctx, "git status")
+	if !errors.Is(err, context.Canceled) || !got.Blocked {
+		t.Fatalf("EvaluateContext() = %+v, %v, want a blocking context.Canceled", got, err)
+	}
+	for _, rule := range got.Rules {
+		if rule.Name == "git-any" {
+			t.Errorf("Rules include %s, want rules after the cancellation left unevaluated", rule.Name)
+		}
+	}
+}
+
+func BenchmarkCommandDetector_LargePolicy(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			detector := NewCommandDetector(largePolicy(n), 10)
+			for b.Loop() {
+				detector.ShouldBlockShellExpr("npm test && git status && aws s3 ls")
+			}
+		})
+	}
+}
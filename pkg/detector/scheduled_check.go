@@ -0,0 +1,231 @@
+// Package detector - commands scheduled to run later
+package detector
+
+import (
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// systemdRunValueFlags are the systemd-run options that take a value as the
+// next argument.
+var systemdRunValueFlags = []string{
+	"-u", "--unit", "-p", "--property", "-E", "--setenv", "-H", "--host", "-M", "--machine",
+	"--description", "--slice", "--uid", "--gid", "--nice", "--working-directory", "--service-type",
+	"--on-active", "--on-boot", "--on-startup", "--on-unit-active", "--on-unit-inactive",
+	"--on-calendar", "--timer-property", "--path-property", "--socket-property",
+}
+
+// checkScheduledCommands blocks jobs that run blocked commands later, out of
+// sight of a check of the eventual command:
+//   - echo '0 3 * * * git push' | crontab -
+//   - at now + 1 minute <<< 'git push'
+//   - systemd-run --on-active=60 git push
+//   - launchctl submit -l push -- git push
+func (d *CommandDetector) checkScheduledCommands(node syntax.Node) bool {
+	blocked := false
+	syntax.Walk(node, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.Stmt:
+			if call, ok := n.Cmd.(*syntax.CallExpr); ok && len(call.Args) > 0 {
+				if input, ok := stdinText(n); ok {
+					blocked = d.checkScheduledInput(call, input)
+				}
+				blocked = blocked || d.checkScheduledCall(call)
+			}
+		case *syntax.BinaryCmd:
+			if n.Op == syntax.Pipe || n.Op == syntax.PipeAll {
+				blocked = d.checkScheduledPipeline(n)
+			}
+		}
+		return !blocked
+	})
+	return blocked
+}
+
+// checkScheduledPipeline checks jobs piped into crontab or at, as in
+// echo 'git push' | at now.
+func (d *CommandDetector) checkScheduledPipeline(pipeline *syntax.BinaryCmd) bool {
+	stages := shellparse.PipelineStages(pipeline)
+	for i, stage := range stages[1:] {
+		call, ok := stage.Cmd.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			continue
+		}
+		if input, ok := outputText(stages[i]); ok && d.checkScheduledInput(call, input) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkScheduledInput checks the job a crontab or at call reads from stdin.
+func (d *CommandDetector) checkScheduledInput(call *syntax.CallExpr, input string) bool {
+	args, _ := shellparse.StaticArgs(call)
+	cmd := normalizeCommand(args[0])
+	var scripts []string
+	switch {
+	case cmd == "crontab" && crontabReadsStdin(args[1:]):
+		scripts = crontabCommands(input)
+	case (cmd == "at" || cmd == "batch") && atReadsStdin(args[1:]):
+		scripts = []string{input}
+	}
+	return d.checkScheduledScripts(cmd, scripts)
+}
+
+// checkScheduledCall checks the command systemd-run or launchctl submit
+// starts, which may be delayed by a timer or run by the service manager.
+func (d *CommandDetector) checkScheduledCall(call *syntax.CallExpr) bool {
+	cmd, isStatic := shellparse.StaticWord(call.Args[0])
+	if !isStatic {
+		return false
+	}
+	var scripts []string
+	switch cmd = normalizeCommand(cmd); cmd {
+	case "systemd-run":
+		scripts = execScript(skipSystemdRunOptions(call.Args[1:]))
+	case "launchctl":
+		args, _ := shellparse.StaticArgs(call)
+		if separator := slices.Index(args, "--"); len(args) > 1 && args[1] == "submit" && separator > 0 {
+			scripts = execScript(call.Args[separator+1:])
+		}
+	}
+	return d.checkScheduledScripts(cmd, scripts)
+}
+
+// checkScheduledScripts analyzes the scripts a scheduler will run.
+func (d *CommandDetector) checkScheduledScripts(scheduler string, scripts []string) bool {
+	for _, script := range scripts {
+		if d.analyzeShellExprRecursive(script) {
+			d.addIssue("Blocked command scheduled by " + scheduler + ": " + strings.TrimSpace(script))
+			return true // BLOCK
+		}
+	}
+	return false
+}
+
+// crontabReadsStdin reports whether crontab installs a table read from stdin:
+// given no file, or "-", and not listing, editing, or removing.
+func crontabReadsStdin(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-l" || arg == "-e" || arg == "-r":
+			return false
+		case arg == "-u":
+			i++ // User name
+		case arg != "-" && !strings.HasPrefix(arg, "-"):
+			return false // Installs a file
+		}
+	}
+	return true
+}
+
+// atReadsStdin reports whether at or batch queues a job read from stdin: not
+// from a file with -f, and not listing, showing, or removing jobs.
+func atReadsStdin(args []string) bool {
+	return !slices.ContainsFunc(args, func(arg string) bool {
+		return len(arg) > 1 && arg[0] == '-' && strings.ContainsAny(arg[1:], "flrdc")
+	})
+}
+
+// crontabCommands returns the commands of a crontab: each entry without its
+// schedule. Comments and environment settings are skipped.
+func crontabCommands(table string) []string {
+	var commands []string
+	for line := range strings.Lines(table) {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(fields[0], "=") && !strings.ContainsAny(fields[0][:1], "*@0123456789") {
+			continue // NAME=value
+		}
+		scheduleFields := 5
+		if strings.HasPrefix(line, "@") {
+			scheduleFields = 1 // @reboot, @daily, ...
+		}
+		if command := dropFields(line, scheduleFields); command != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// dropFields returns line without its first n whitespace-separated fields.
+func dropFields(line string, n int) string {
+	for range n {
+		line = strings.TrimLeft(line, " \t")
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			return ""
+		}
+		line = line[end:]
+	}
+	return strings.TrimSpace(line)
+}
+
+// skipSystemdRunOptions returns the command and arguments systemd-run runs.
+func skipSystemdRunOptions(args []*syntax.Word) []*syntax.Word {
+	for i := 0; i < len(args); i++ {
+		arg, isStatic := shellparse.StaticWord(args[i])
+		switch {
+		case !isStatic || !strings.HasPrefix(arg, "-"):
+			return args[i:]
+		case arg == "--":
+			return args[i+1:]
+		case slices.Contains(systemdRunValueFlags, arg):
+			i++
+		}
+	}
+	return nil
+}
+
+// stdinText returns the text a statement's heredoc or here-string feeds to
+// its command.
+func stdinText(stmt *syntax.Stmt) (string, bool) {
+	for _, redirect := range stmt.Redirs {
+		switch redirect.Op {
+		case syntax.Hdoc, syntax.DashHdoc:
+			return wordText(redirect.Hdoc), true
+		case syntax.WordHdoc:
+			return wordText(redirect.Word), true
+		}
+	}
+	return "", false
+}
+
+// outputText returns the text a pipeline stage writes, for echo, printf, and
+// cat of a heredoc or here-string.
+func outputText(stage *syntax.Stmt) (string, bool) {
+	call, ok := stage.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return "", false
+	}
+	cmd, _ := shellparse.StaticWord(call.Args[0])
+	switch normalizeCommand(cmd) {
+	case "echo", "printf":
+		var words []string
+		for _, word := range call.Args[1:] {
+			if text := wordText(word); len(words) > 0 || !strings.HasPrefix(text, "-") {
+				words = append(words, text)
+			}
+		}
+		// Both may turn \n into a newline, which separates jobs and entries
+		return strings.ReplaceAll(strings.Join(words, " "), `\n`, "\n"), true
+	case "cat":
+		return stdinText(stage)
+	}
+	return "", false
+}
+
+// wordText returns the static value of word, or its source if it is dynamic.
+func wordText(word *syntax.Word) string {
+	if value, isStatic := shellparse.StaticWord(word); isStatic {
+		return value
+	}
+	return shellparse.Print(word)
+}
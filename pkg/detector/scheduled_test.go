@@ -0,0 +1,49 @@
+package detector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandDetector_ScheduledCommands(t *testing.T) {
+	rules := []CommandRule{
+		{BlockedCommand: "git", BlockedPatterns: []string{"push"}},
+		{BlockedCommand: "aws", BlockedPatterns: []string{"ec2 terminate-instances"}},
+	}
+
+	tests := []struct {
+		name      string
+		command   string
+		wantBlock bool
+		wantIssue string
+	}{
+		{"crontab from echo", "echo '*/5 * * * * cd /srv/app && git push' | crontab -", true, "Blocked command scheduled by crontab: cd /srv/app && git push"},
+		{"crontab special schedule", "printf '@reboot git push\\n' | crontab", true, "Blocked command scheduled by crontab: git push"},
+		{"crontab heredoc", "crontab - <<'EOF'\nMAILTO=ops@example.com\n# nightly\n0 3 * * * aws ec2 terminate-instances --instance-ids i-0abc\nEOF", true, "Blocked command scheduled by crontab: aws ec2 terminate-instances --instance-ids i-0abc"},
+		{"crontab from cat heredoc", "cat <<EOF | crontab -u deploy -\n0 * * * * git push\nEOF", true, "Blocked command scheduled by crontab: git push"},
+		{"at here-string", "at now + 1 minute <<< 'git push origin main'", true, "Blocked command scheduled by at: git push origin main"},
+		{"at from echo", "echo 'git push' | at -m 03:00", true, "Blocked command scheduled by at: git push"},
+		{"batch heredoc", "batch <<EOF\ngit push\nEOF", true, ""},
+		{"systemd-run timer", "systemd-run --user --on-active=60 git push", true, "Blocked command scheduled by systemd-run: git push"},
+		{"systemd-run with properties", `systemd-run -p User=deploy --unit push bash -c "git push"`, true, `Blocked command scheduled by systemd-run: bash -c "git push"`},
+		{"launchctl submit", "launchctl submit -l com.example.push -- git push", true, "Blocked command scheduled by launchctl: git push"},
+		{"harmless crontab", "echo '0 3 * * * make backup' | crontab -", false, ""},
+		{"crontab file", "echo '0 3 * * * git push' | crontab jobs.txt", false, ""},
+		{"listing at jobs", "at -l <<< 'git push'", false, ""},
+		{"harmless at job", "at now <<< 'make report'", false, ""},
+		{"harmless systemd-run", "systemd-run --scope make test", false, ""},
+		{"launchctl list", "launchctl list", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewCommandDetector(rules, 10)
+			if got := detector.ShouldBlockShellExpr(tt.command); got != tt.wantBlock {
+				t.Fatalf("ShouldBlockShellExpr(%q) = %v, want %v. Issues: %v", tt.command, got, tt.wantBlock, detector.GetIssues())
+			}
+			if tt.wantIssue != "" && !slices.Contains(detector.GetIssues(), tt.wantIssue) {
+				t.Errorf("GetIssues() = %q, want it to contain %q", detector.GetIssues(), tt.wantIssue)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Package detector - per-segment results for compound commands
+package detector
+
+import (
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// SegmentResult is the decision for one top-level segment of a compound
+// command, e.g. "git push" in "git status && git push".
+type SegmentResult struct {
+	shellparse.Segment
+	Blocked bool
+	Issues  []string
+}
+
+// AnalyzeSegments splits shellExpr at its top-level statement boundaries and
+// analyzes each segment on its own, to report which of them cause a block.
+// Like Evaluate, it leaves the outcome of the last ShouldBlockShellExpr call
+// untouched and is safe for concurrent use. A block that only the whole
+// expression explains, such as a variable set in one segment and run in
+// another, blocks no single segment.
+func (d *CommandDetector) AnalyzeSegments(shellExpr string) ([]SegmentResult, error) {
+	ast, err := shellparse.ParseVariant(shellExpr, d.lang)
+	if err != nil {
+		return nil, err
+	}
+	segments := shellparse.Segments(ast)
+
+	results := make([]SegmentResult, len(segments))
+	for i, segment := range segments {
+		result := d.Evaluate(segment.Command)
+		results[i] = SegmentResult{Segment: segment, Blocked: result.Blocked, Issues: result.Issues}
+	}
+	return results, nil
+}
+
+// AllowedPrefix returns the leading segments before the first blocked one,
+// joined by their operators, as a command that could run on its own. It
+// returns "" when the first segment is blocked or no segment is.
+func AllowedPrefix(results []SegmentResult) string {
+	var prefix []string
+	for i, result := range results {
+		if result.Blocked {
+			if i == 0 {
+				return ""
+			}
+			return strings.Join(prefix[:len(prefix)-1], " ")
+		}
+		prefix = append(prefix, result.Command, result.Op)
+	}
+	return ""
+}
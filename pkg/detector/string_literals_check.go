@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
 )
 
 // analyzeStringLiterals performs deep inspection of string literals that might
@@ -16,7 +18,7 @@ import (
 // Only analyzes strings for commands known to execute their string arguments.
 func (d *CommandDetector) analyzeStringLiterals(call *syntax.CallExpr) bool {
 	// First check what command we're dealing with
-	cmd, _ := resolveStaticWord(call.Args[0])
+	cmd, _ := shellparse.StaticWord(call.Args[0])
 	normalizedCmd := normalizeCommand(cmd)
 
 	// For certain commands, we should analyze their string arguments
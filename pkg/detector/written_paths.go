@@ -0,0 +1,109 @@
+// Package detector - files a shell command writes
+package detector
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/krmcbride/claudecode-hooks/pkg/shellparse"
+)
+
+// writeCommands are the commands whose written files WrittenPaths can tell
+// from their arguments, with the flags of each that take a value.
+var writeCommands = map[string]struct {
+	spec    ArgSpec
+	targets func(inv Invocation) []string
+}{
+	"tee":     {ArgSpec{}, func(inv Invocation) []string { return inv.Positionals }},
+	"cp":      {ArgSpec{ValueFlags: []string{"-S", "--suffix", "-t", "--target-directory"}}, copyDestination},
+	"mv":      {ArgSpec{ValueFlags: []string{"-S", "--suffix", "-t", "--target-directory"}}, copyDestination},
+	"install": {ArgSpec{ValueFlags: []string{"-m", "--mode", "-o", "--owner", "-g", "--group", "-S", "--suffix", "-t", "--target-directory"}}, copyDestination},
+	"sed":     {ArgSpec{ValueFlags: []string{"-e", "--expression", "-f", "--file", "-l", "--line-length"}}, func(inv Invocation) []string { return inPlaceFiles(inv, "-e", "--expression", "-f", "--file") }},
+	"perl":    {ArgSpec{ValueFlags: []string{"-e", "-E", "-M", "-I"}}, func(inv Invocation) []string { return inPlaceFiles(inv, "-e", "-E") }},
+	"dd":      {ArgSpec{}, ddOutputs},
+}
+
+// WrittenPaths returns the files command writes, as far as its source tells:
+// the targets of output redirections, the files of tee, the destination of
+// cp, mv, and install, the files edited in place by sed -i and perl -i, and
+// dd's of=. Paths are returned as written, so relative ones are relative to
+// the directory the command ran in. Paths that are only known at run time,
+// and devices such as /dev/null, are left out.
+func WrittenPaths(command string) ([]string, error) {
+	node, err := shellparse.Parse(command)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	add := func(target string) {
+		if target != "" && !strings.HasPrefix(target, "/dev/") && !slices.Contains(paths, target) {
+			paths = append(paths, target)
+		}
+	}
+	shellparse.VisitRedirects(node, func(redirect shellparse.Redirect) bool {
+		if redirect.Writes && redirect.Static {
+			add(redirect.Target)
+		}
+		return true
+	})
+	shellparse.VisitCommands(node, func(cmd shellparse.Command) bool {
+		writer, ok := writeCommands[path.Base(cmd.Name)]
+		if !ok || !cmd.Static {
+			return true
+		}
+		for _, target := range writer.targets(writer.spec.Parse(cmd.Args)) {
+			add(target)
+		}
+		return true
+	})
+	return paths, nil
+}
+
+// copyDestination returns the destination of cp, mv, or install when it is a
+// file. With -t, or several sources, the destination is a directory.
+func copyDestination(inv Invocation) []string {
+	if len(inv.Positionals) != 2 || inv.HasFlag("-t", "--target-directory", "-d", "--directory") {
+		return nil
+	}
+	destination := inv.Positionals[1]
+	if strings.HasSuffix(destination, "/") {
+		return nil
+	}
+	return []string{destination}
+}
+
+// inPlaceFiles returns the files sed -i or perl -i edits. Without one of the
+// scriptFlags, the first positional is the script rather than a file.
+func inPlaceFiles(inv Invocation, scriptFlags ...string) []string {
+	if !hasInPlaceFlag(inv) {
+		return nil
+	}
+	if !inv.HasFlag(scriptFlags...) && len(inv.Positionals) > 0 {
+		return inv.Positionals[1:]
+	}
+	return inv.Positionals
+}
+
+// hasInPlaceFlag reports whether sed or perl edits files in place: -i, a
+// suffixed -i.bak, --in-place, or i among combined short flags such as -pi.
+func hasInPlaceFlag(inv Invocation) bool {
+	for flag := range inv.Flags {
+		if strings.HasPrefix(flag, "-i") || flag == "--in-place" || (len(flag) > 2 && flag[1] != '-' && strings.Contains(flag, "i")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ddOutputs returns the of= operands of dd.
+func ddOutputs(inv Invocation) []string {
+	var targets []string
+	for _, operand := range inv.Positionals {
+		if target, ok := strings.CutPrefix(operand, "of="); ok {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,44 @@
+package detector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWrittenPaths(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"cat > main.go <<'EOF'\npackage main\nEOF", []string{"main.go"}},
+		{"echo x >> notes.md 2>/dev/null", []string{"notes.md"}},
+		{"printf '%s' x | tee a.ts b.ts", []string{"a.ts", "b.ts"}},
+		{"tee -a log.txt < input", []string{"log.txt"}},
+		{"sed -i 's/a/b/' x.go y.go", []string{"x.go", "y.go"}},
+		{"sed -i.bak -e 's/a/b/' x.go", []string{"x.go"}},
+		{"sed 's/a/b/' x.go", nil},
+		{"perl -pi -e 's/a/b/' x.py", []string{"x.py"}},
+		{"cp -f src/a.go dst/b.go", []string{"dst/b.go"}},
+		{"cp a.go b.go dir/", nil},
+		{"mv -t dir a.go", nil},
+		{"install -m 644 a.sh /usr/local/bin/a.sh", []string{"/usr/local/bin/a.sh"}},
+		{"dd if=/dev/zero of=blob.bin", []string{"blob.bin"}},
+		{"cd sub && echo x > \"$FILE\"", nil},
+		{"go build ./... > build.log && cat build.log > build.log", []string{"build.log"}},
+		{"echo x >&2", nil},
+		{"bash -c 'echo x > inner.go'", nil},
+	}
+	for _, tt := range tests {
+		got, err := WrittenPaths(tt.command)
+		if err != nil {
+			t.Errorf("WrittenPaths(%q) error: %v", tt.command, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("WrittenPaths(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+	if _, err := WrittenPaths("echo 'unterminated"); err == nil {
+		t.Error("WrittenPaths() should fail on a command that does not parse")
+	}
+}
@@ -0,0 +1,195 @@
+package hook
+
+import (
+	"bytes"
+	"embed"
+	"log/slog"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// payloads is the golden corpus: payloads captured with hook-logger, one per
+// event and tool combination. When Claude Code changes a payload, capture the
+// new one over the old file; these tests then show which decoded fields moved.
+//
+//go:embed testdata/payloads/*.json
+var payloads embed.FS
+
+const (
+	corpusSession    = "9a3c2f61-5b7e-4d0a-9f1e-2c8b7a6d5e41"
+	corpusTranscript = "/home/dev/.claude/projects/-home-dev-project/9a3c2f61-5b7e-4d0a-9f1e-2c8b7a6d5e41.jsonl"
+)
+
+func preInput(cwd, tool, command, filePath, notebookPath string) *PreToolUseInput {
+	input := &PreToolUseInput{
+		SessionID:      corpusSession,
+		TranscriptPath: corpusTranscript,
+		Cwd:            cwd,
+		HookEventName:  EventPreToolUse,
+		ToolName:       tool,
+	}
+	input.ToolInput.Command = command
+	input.ToolInput.FilePath = filePath
+	input.ToolInput.NotebookPath = notebookPath
+	return input
+}
+
+func postInput(tool, command, filePath string) *PostToolUseInput {
+	input := &PostToolUseInput{
+		SessionID:      corpusSession,
+		TranscriptPath: corpusTranscript,
+		Cwd:            "/home/dev/project",
+		HookEventName:  EventPostToolUse,
+		ToolName:       tool,
+	}
+	input.ToolInput.FilePath = filePath
+	input.ToolInput.Command = command
+	return input
+}
+
+func TestContract_PreToolUse(t *testing.T) {
+	write := preInput("/home/dev/project", "Write", "", "/home/dev/project/.claude/settings.json", "")
+	write.ToolInput.Content = "{\n  \"hooks\": {}\n}\n"
+	edit := preInput("/home/dev/project", "Edit", "", "/home/dev/project/main.go", "")
+	edit.ToolInput.OldString = `fmt.Println("hello")`
+	edit.ToolInput.NewString = `fmt.Println("hello, world")`
+	multiEdit := preInput("/home/dev/project", "MultiEdit", "", "/home/dev/project/pkg/server/server.go", "")
+	multiEdit.ToolInput.Edits = []Edit{
+		{OldString: "const port = 8080", NewString: "const port = 9090"},
+		{OldString: "log.Printf", NewString: "slog.Info", ReplaceAll: true},
+	}
+
+	task := preInput("/home/dev/project", "Task", "", "", "")
+	task.ToolInput.Prompt = "Search the test suite for tests that depend on timing and list them with file and line."
+	task.ToolInput.SubagentType = "general-purpose"
+
+	tests := []struct {
+		file string
+		want *PreToolUseInput
+	}{
+		{"pre_bash.json", preInput("/home/dev/project", "Bash", "git push origin main", "", "")},
+		{"pre_bash_background.json", preInput("/home/dev/project/web", "Bash", "npm run dev", "", "")},
+		{"pre_edit.json", edit},
+		{"pre_multiedit.json", multiEdit},
+		{"pre_write.json", write},
+		{"pre_notebookedit.json", preInput("/home/dev/project", "NotebookEdit", "", "", "/home/dev/project/analysis.ipynb")},
+		{"pre_task.json", task},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := DecodePreToolUseInput(bytes.NewReader(readPayload(t, tt.file)), strictCorpusOptions(t))
+			if err != nil {
+				t.Fatalf("DecodePreToolUseInput() error: %v", err)
+			}
+			if len(got.RawToolInput) == 0 || len(got.Raw.ToolInput) == 0 || !bytes.Equal(got.Raw.Payload, bytes.TrimSpace(readPayload(t, tt.file))) {
+				t.Error("DecodePreToolUseInput() did not keep the raw tool_input and payload")
+			}
+			got.RawToolInput = nil
+			got.Raw = Raw{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodePreToolUseInput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContract_PostToolUse(t *testing.T) {
+	tests := []struct {
+		file string
+		want *PostToolUseInput
+		keys []string // tool_response keys hooks may rely on
+	}{
+		{"post_bash.json", postInput("Bash", "go test ./...", ""), []string{"stdout", "stderr", "interrupted"}},
+		{"post_edit.json", postInput("Edit", "", "/home/dev/project/main.go"), []string{"filePath", "structuredPatch"}},
+		{"post_multiedit.json", postInput("MultiEdit", "", "/home/dev/project/pkg/server/server.go"), []string{"filePath", "edits"}},
+		{"post_write.json", postInput("Write", "", "/home/dev/project/hello.py"), []string{"type", "filePath", "content"}},
+		{"post_read.json", postInput("Read", "", "/home/dev/project/go.mod"), []string{"type", "file"}},
+		{"post_task.json", postInput("Task", "", ""), []string{"content", "totalToolUseCount"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := DecodePostToolUseInput(bytes.NewReader(readPayload(t, tt.file)), strictCorpusOptions(t))
+			if err != nil {
+				t.Fatalf("DecodePostToolUseInput() error: %v", err)
+			}
+			for _, key := range tt.keys {
+				if _, ok := got.ToolResponse[key]; !ok {
+					t.Errorf("tool_response is missing %q: %v", key, got.ToolResponse)
+				}
+			}
+			// tool_response is checked by key above; compare the typed fields
+			got.ToolResponse = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodePostToolUseInput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContract_Stop(t *testing.T) {
+	got, err := DecodeStopInput(bytes.NewReader(readPayload(t, "stop.json")), strictCorpusOptions(t))
+	if err != nil {
+		t.Fatalf("DecodeStopInput() error: %v", err)
+	}
+	want := &StopInput{
+		SessionID:      corpusSession,
+		TranscriptPath: corpusTranscript,
+		Cwd:            "/home/dev/project",
+		HookEventName:  EventStop,
+	}
+	if !bytes.Equal(got.Raw.Payload, bytes.TrimSpace(readPayload(t, "stop.json"))) {
+		t.Errorf("DecodeStopInput() Raw.Payload = %s", got.Raw.Payload)
+	}
+	got.Raw = Raw{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeStopInput() = %+v, want %+v", got, want)
+	}
+}
+
+// TestContract_CorpusCovered fails when a payload is added to the corpus
+// without an expectation above.
+func TestContract_CorpusCovered(t *testing.T) {
+	entries, err := payloads.ReadDir("testdata/payloads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	covered := map[string]bool{
+		"pre_bash.json": true, "pre_bash_background.json": true, "pre_edit.json": true,
+		"pre_multiedit.json": true, "pre_write.json": true, "pre_notebookedit.json": true,
+		"post_bash.json": true, "post_edit.json": true, "post_multiedit.json": true,
+		"post_write.json": true, "post_read.json": true, "stop.json": true,
+		"pre_task.json": true, "post_task.json": true,
+	}
+	for _, entry := range entries {
+		if !covered[entry.Name()] {
+			t.Errorf("corpus payload %s has no contract test", entry.Name())
+		}
+	}
+}
+
+func readPayload(t *testing.T, file string) []byte {
+	t.Helper()
+	data, err := payloads.ReadFile(path.Join("testdata/payloads", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// strictCorpusOptions validates strictly and fails the test on any unknown
+// field, so a corpus refresh that adds fields is noticed and the schema updated.
+func strictCorpusOptions(t *testing.T) InputOptions {
+	t.Helper()
+	var logs bytes.Buffer
+	t.Cleanup(func() {
+		if strings.Contains(logs.String(), "unknown payload field") {
+			t.Errorf("corpus payload has fields unknown to the schema:\n%s", logs.String())
+		}
+	})
+	return InputOptions{
+		Strict: true,
+		Logger: slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+}
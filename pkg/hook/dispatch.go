@@ -0,0 +1,59 @@
+// Package hook - multi-event dispatch
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Handler handles the payload of one hook event. data is the payload as read
+// from stdin, for the event's decoder, e.g. DecodeStopInput.
+type Handler func(data []byte)
+
+// Route runs the handler registered for the payload's hook_event_name. A
+// payload without one is an ErrInputSchema error, and one for an event
+// without a handler an ErrConfig error: the hook is registered under an
+// event it does not handle.
+func Route(data []byte, handlers map[string]Handler) error {
+	event := EventName(data)
+	if event == "" {
+		return MarkError(errors.New("payload has no hook_event_name"), ErrInputSchema)
+	}
+	handler, ok := handlers[event]
+	if !ok {
+		events := make([]string, 0, len(handlers))
+		for name := range handlers {
+			events = append(events, name)
+		}
+		slices.Sort(events)
+		return MarkError(fmt.Errorf("hook registered under %s handles only %s", event, strings.Join(events, ", ")), ErrConfig)
+	}
+	handler(data)
+	return nil
+}
+
+// Dispatch reads a payload from stdin and runs the handler for its event, so
+// one binary registered under several events keeps its logic and
+// configuration in one place:
+//
+//	hook.Dispatch(map[string]hook.Handler{
+//		hook.EventPreToolUse: checkToolCall,
+//		hook.EventStop:       summarize,
+//	})
+//
+// Handlers exit with their decision. A payload that cannot be read or
+// routed is a non-blocking error, so a misregistered hook does not block
+// Claude; read stdin and call Route to fail otherwise.
+func Dispatch(handlers map[string]Handler) {
+	data, err := io.ReadAll(os.Stdin)
+	if err == nil {
+		err = Route(data, handlers)
+	}
+	if err != nil {
+		NonBlockingError(fmt.Sprintf("Error dispatching hook input: %v", err))
+	}
+}
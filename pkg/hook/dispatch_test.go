@@ -0,0 +1,39 @@
+package hook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRoute(t *testing.T) {
+	var handled []string
+	handlers := map[string]Handler{
+		EventPreToolUse: func(data []byte) { handled = append(handled, EventPreToolUse+" "+string(data)) },
+		EventStop:       func([]byte) { handled = append(handled, EventStop) },
+	}
+
+	pre := `{"hook_event_name": "PreToolUse", "tool_name": "Bash"}`
+	if err := Route([]byte(pre), handlers); err != nil {
+		t.Fatalf("Route(PreToolUse) error: %v", err)
+	}
+	if err := Route([]byte(`{"hook_event_name": "Stop"}`), handlers); err != nil {
+		t.Fatalf("Route(Stop) error: %v", err)
+	}
+	if want := []string{EventPreToolUse + " " + pre, EventStop}; strings.Join(handled, "\n") != strings.Join(want, "\n") {
+		t.Errorf("handled %q, want %q", handled, want)
+	}
+
+	err := Route([]byte(`{"hook_event_name": "PostToolUse"}`), handlers)
+	if !errors.Is(err, ErrConfig) || err.Error() != "hook registered under PostToolUse handles only PreToolUse, Stop" {
+		t.Errorf("Route(PostToolUse) error = %v, want an ErrConfig naming the handled events", err)
+	}
+	for _, payload := range []string{`{"tool_name": "Bash"}`, `not json`} {
+		if err := Route([]byte(payload), handlers); !errors.Is(err, ErrInputSchema) {
+			t.Errorf("Route(%s) error = %v, want ErrInputSchema", payload, err)
+		}
+	}
+	if len(handled) != 2 {
+		t.Errorf("handlers ran for unrouted payloads: %q", handled)
+	}
+}
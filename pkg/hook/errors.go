@@ -0,0 +1,39 @@
+// Package hook - error taxonomy
+package hook
+
+import "errors"
+
+// Errors that stop a hook from judging a tool call, for errors.Is. They tell
+// a broken setup or payload apart from a suspicious tool call: with these the
+// hook is at fault, not the call. See detector.ErrParse and
+// detector.ErrTimeout for commands the detector could not analyze.
+var (
+	// ErrInputSchema is matched by errors decoding a payload that is not JSON
+	// or, with InputOptions.Strict, does not match the event's schema.
+	ErrInputSchema = errors.New("hook input does not match the expected schema")
+
+	// ErrConfig is matched by errors in a hook's configuration, such as an
+	// invalid policy file or flag value.
+	ErrConfig = errors.New("invalid hook configuration")
+)
+
+// MarkError returns err marked as kind, such as ErrConfig, so errors.Is(err,
+// kind) holds while the message stays err's. A nil err stays nil.
+func MarkError(err, kind error) error {
+	if err == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &markedError{err: err, kind: kind}
+}
+
+type markedError struct {
+	err, kind error
+}
+
+func (e *markedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *markedError) Unwrap() []error {
+	return []error{e.err, e.kind}
+}
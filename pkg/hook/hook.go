@@ -3,16 +3,56 @@ package hook
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"slices"
+	"strings"
 )
 
 // PreToolUseInput represents the JSON input from Claude Code PreToolUse hooks.
-// This is specifically for Bash tool hooks that need to inspect commands.
+// Only the fields hooks inspect are decoded from tool_input; RawToolInput
+// holds all of it.
 type PreToolUseInput struct {
-	ToolName  string `json:"tool_name"`
-	ToolInput struct {
-		Command string `json:"command"`
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	HookEventName  string `json:"hook_event_name"`
+	ToolName       string `json:"tool_name"`
+	ToolInput      struct {
+		Command      string `json:"command"`       // Bash
+		FilePath     string `json:"file_path"`     // Edit, MultiEdit, Write
+		Content      string `json:"content"`       // Write
+		OldString    string `json:"old_string"`    // Edit
+		NewString    string `json:"new_string"`    // Edit
+		ReplaceAll   bool   `json:"replace_all"`   // Edit
+		Edits        []Edit `json:"edits"`         // MultiEdit
+		NotebookPath string `json:"notebook_path"` // NotebookEdit
+		Path         string `json:"path"`          // Glob, Grep
+		Prompt       string `json:"prompt"`        // Task
+		SubagentType string `json:"subagent_type"` // Task
 	} `json:"tool_input"`
+
+	// RawToolInput is the complete tool_input as decoded by
+	// DecodePreToolUseInput, for building an updated input
+	RawToolInput map[string]any `json:"-"`
+
+	Raw Raw `json:"-"`
+}
+
+// Raw holds the JSON of a payload as read, per section, so hooks can read
+// fields the typed structs don't model yet; stdin cannot be read twice.
+// Sections the payload lacks are nil.
+type Raw struct {
+	Payload      json.RawMessage // The whole payload
+	ToolInput    json.RawMessage // tool_input
+	ToolResponse json.RawMessage // tool_response, PostToolUse only
+}
+
+// Edit is one replacement of an Edit or MultiEdit tool call.
+type Edit struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
 }
 
 // PostToolUseInput represents the JSON input from Claude Code PostToolUse hooks.
@@ -29,80 +69,369 @@ type PreToolUseInput struct {
 // Full payload structure (not all fields are decoded):
 // - session_id, transcript_path, cwd, hook_event_name
 // - tool_input varies by tool:
-//   - Edit/MultiEdit/Write: file_path (we only use this)
+//   - Edit/MultiEdit/Write: file_path (decoded)
 //   - Edit: old_string, new_string
 //   - MultiEdit: edits array with old_string, new_string
 //   - Write: content
-//   - Bash: command
+//   - Bash: command (decoded)
+//   - Task: description, prompt, subagent_type
 //
 // - tool_response varies by tool and contains the results
 //
 // See docs/tool-hook-inputs.md for documented examples.
 type PostToolUseInput struct {
-	ToolName  string `json:"tool_name"`
-	ToolInput struct {
-		FilePath string `json:"file_path"`
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	HookEventName  string `json:"hook_event_name"`
+	ToolName       string `json:"tool_name"`
+	ToolInput      struct {
+		FilePath string `json:"file_path"` // Edit, MultiEdit, Write
+		Command  string `json:"command"`   // Bash
 	} `json:"tool_input"`
 	ToolResponse map[string]any `json:"tool_response"`
+
+	// Raw is set only with InputOptions.KeepRaw, as decoding otherwise
+	// streams the payload without holding all of it
+	Raw Raw `json:"-"`
+}
+
+// StopInput represents the JSON input from Claude Code Stop hooks, sent when
+// Claude finishes responding. StopHookActive is set when Claude is already
+// continuing because of a Stop hook.
+type StopInput struct {
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	HookEventName  string `json:"hook_event_name"`
+	StopHookActive bool   `json:"stop_hook_active"`
+
+	Raw Raw `json:"-"`
 }
 
 // PostToolUseResponse represents the JSON response for PostToolUse hooks.
 // Used to block further actions after a tool has been executed.
 type PostToolUseResponse struct {
-	Decision string `json:"decision,omitempty"` // "block" or omit for allow
-	Reason   string `json:"reason,omitempty"`   // Optional explanation when blocking
+	Decision           string             `json:"decision,omitempty"` // "block" or omit for allow
+	Reason             string             `json:"reason,omitempty"`   // Optional explanation when blocking
+	HookSpecificOutput *PostToolUseOutput `json:"hookSpecificOutput,omitempty"`
+}
+
+// PostToolUseOutput carries the output Claude is given instead of an MCP
+// tool's own, or context added to the tool's output.
+type PostToolUseOutput struct {
+	HookEventName        string `json:"hookEventName"`
+	AdditionalContext    string `json:"additionalContext,omitempty"`
+	UpdatedMCPToolOutput any    `json:"updatedMCPToolOutput,omitempty"`
+}
+
+// Permission decisions a PreToolUse hook can return in its JSON output.
+const (
+	PermissionAllow = "allow" // Run the tool call without a permission prompt
+	PermissionAsk   = "ask"   // Prompt the user to confirm the tool call
+	PermissionDeny  = "deny"  // Refuse the tool call and show the reason to Claude
+)
+
+// PreToolUseResponse represents the JSON response for PreToolUse hooks that
+// return a permission decision instead of using exit codes.
+type PreToolUseResponse struct {
+	HookSpecificOutput PreToolUseOutput `json:"hookSpecificOutput"`
+}
+
+// PreToolUseOutput carries the permission decision of a PreToolUse hook and,
+// optionally, the tool input to run the tool call with instead of Claude's.
+type PreToolUseOutput struct {
+	HookEventName            string         `json:"hookEventName"`
+	PermissionDecision       string         `json:"permissionDecision"`
+	PermissionDecisionReason string         `json:"permissionDecisionReason,omitempty"`
+	UpdatedInput             map[string]any `json:"updatedInput,omitempty"`
+}
+
+// WarningResponse is the JSON response of a hook that lets the action proceed
+// but has something to tell the user.
+type WarningResponse struct {
+	SystemMessage string `json:"systemMessage"`
 }
 
 // ReadPreToolUseInput reads and parses PreToolUse hook input from stdin.
 // This is typically used by hooks that need to inspect Bash commands.
+// Schema problems are not reported; use DecodePreToolUseInput to validate.
 func ReadPreToolUseInput() (*PreToolUseInput, error) {
-	var input PreToolUseInput
-	decoder := json.NewDecoder(os.Stdin)
-	if err := decoder.Decode(&input); err != nil {
-		return nil, err
-	}
-	return &input, nil
+	return DecodePreToolUseInput(os.Stdin, InputOptions{})
 }
 
-// ReadPostToolUseInput reads and parses PostToolUse hook input from stdin
+// ReadPostToolUseInput reads and parses PostToolUse hook input from stdin.
+// Schema problems are not reported; use DecodePostToolUseInput to validate.
 func ReadPostToolUseInput() (*PostToolUseInput, error) {
-	var input PostToolUseInput
-	decoder := json.NewDecoder(os.Stdin)
-	if err := decoder.Decode(&input); err != nil {
-		return nil, err
+	return DecodePostToolUseInput(os.Stdin, InputOptions{})
+}
+
+// DefaultMaxIssues is the number of issues block output lists before
+// summarizing the rest, unless an Output says otherwise.
+const DefaultMaxIssues = 10
+
+// Verbosity is how much context block output pushes into Claude's context.
+type Verbosity int
+
+const (
+	// VerbosityNormal lists the issues up to the Output's MaxIssues, the
+	// blocked segments, and the suggested alternatives.
+	VerbosityNormal Verbosity = iota
+	// VerbosityQuiet gives a single line: the message and the first issue.
+	VerbosityQuiet
+	// VerbosityVerbose lists every issue, the matched commands, and the
+	// descriptions of the matched rules.
+	VerbosityVerbose
+)
+
+// Output controls how a hook writes blocks: how much of the reason goes into
+// Claude's context, and whether blocks are only warnings. The package-level
+// block helpers use DefaultOutput.
+type Output struct {
+	MaxIssues int // Issues listed before summarizing the rest; zero lists every issue
+	Verbosity Verbosity
+
+	// WarnOnly turns blocks into warnings: the block methods and a "deny"
+	// DecidePreToolUse let the tool call proceed and show the block reason to
+	// the user instead, for trying out a hook in advisory mode.
+	WarnOnly bool
+}
+
+// DefaultOutput returns the Output of the package-level block helpers:
+// DefaultMaxIssues issues at VerbosityNormal, blocking.
+func DefaultOutput() Output {
+	return Output{MaxIssues: DefaultMaxIssues}
+}
+
+// QuietReason is the single-line form of reason: the message, the first
+// issue, and how many more there are.
+func QuietReason(reason BlockReason) string {
+	line := reason.Message
+	issues := SummarizeIssues(reason.Issues, 0)
+	if len(issues) > 0 {
+		line += " " + issues[0]
+	}
+	if len(issues) > 1 {
+		line += fmt.Sprintf(" (+%d more)", len(issues)-1)
+	}
+	return line
+}
+
+// blockIssues returns the issues block output lists at o's verbosity.
+func (o Output) blockIssues(issues []string) []string {
+	if o.Verbosity == VerbosityVerbose {
+		return SummarizeIssues(issues, 0)
 	}
-	return &input, nil
+	return SummarizeIssues(issues, o.MaxIssues)
+}
+
+// SummarizeIssues removes repeated issues and, past limit, replaces the rest
+// with a single "...and N more" line. A limit of zero keeps every issue.
+func SummarizeIssues(issues []string, limit int) []string {
+	var unique []string
+	for _, issue := range issues {
+		if !slices.Contains(unique, issue) {
+			unique = append(unique, issue)
+		}
+	}
+	if limit <= 0 || len(unique) <= limit {
+		return unique
+	}
+	return append(unique[:limit], fmt.Sprintf("...and %d more", len(unique)-limit))
 }
 
 // BlockPreToolUse blocks the tool execution with an error message (PreToolUse hooks).
-// Exit code 2 tells Claude Code to block the tool and show stderr output.
+// ExitBlock tells Claude Code to block the tool and show stderr output to Claude.
+// Issues are listed once each, up to DefaultMaxIssues.
 func BlockPreToolUse(message string, issues []string) {
-	_, _ = os.Stderr.WriteString("🚫 BLOCKED: " + message + "\n") //nolint:errcheck // Error writing to stderr is not actionable in blocking function
-	for _, issue := range issues {
+	DefaultOutput().BlockPreToolUse(message, issues)
+}
+
+// BlockPreToolUse is the package-level BlockPreToolUse written as o says.
+func (o Output) BlockPreToolUse(message string, issues []string) {
+	o.BlockPreToolUseReason(BlockReason{Message: message, Issues: issues})
+}
+
+// BlockPreToolUseReason is BlockPreToolUse for a BlockReason. Suggested
+// alternatives follow the issues so Claude has a compliant way forward.
+func BlockPreToolUseReason(reason BlockReason) {
+	DefaultOutput().BlockPreToolUseReason(reason)
+}
+
+// BlockPreToolUseReason is the package-level BlockPreToolUseReason written as
+// o says: how much is written depends on o.Verbosity.
+func (o Output) BlockPreToolUseReason(reason BlockReason) {
+	if o.WarnOnly {
+		o.WarnPreToolUseReason(reason)
+		return
+	}
+	if o.Verbosity == VerbosityQuiet {
+		_, _ = os.Stderr.WriteString("🚫 BLOCKED: " + QuietReason(reason) + "\n") //nolint:errcheck // Error writing to stderr is not actionable in blocking function
+		Exit(ExitBlock)
+		return
+	}
+	_, _ = os.Stderr.WriteString("🚫 BLOCKED: " + reason.Message + "\n") //nolint:errcheck // Error writing to stderr is not actionable in blocking function
+	for _, segment := range reason.Segments {
+		_, _ = os.Stderr.WriteString("Blocked segment: " + segment + "\n") //nolint:errcheck // Error writing to stderr is not actionable in blocking function
+	}
+	for _, issue := range o.blockIssues(reason.Issues) {
 		_, _ = os.Stderr.WriteString("Issue: " + issue + "\n") //nolint:errcheck // Error writing to stderr is not actionable in blocking function
 	}
-	os.Exit(2) // Block execution
+	if o.Verbosity == VerbosityVerbose {
+		for _, matched := range reason.Matched {
+			_, _ = os.Stderr.WriteString("Matched: " + matched + "\n") //nolint:errcheck // Error writing to stderr is not actionable in blocking function
+		}
+		for _, description := range reason.Descriptions {
+			_, _ = os.Stderr.WriteString("Rule: " + description + "\n") //nolint:errcheck // Error writing to stderr is not actionable in blocking function
+		}
+	}
+	for _, alternative := range reason.Alternatives {
+		_, _ = os.Stderr.WriteString("Suggestion: " + alternative + "\n") //nolint:errcheck // Error writing to stderr is not actionable in blocking function
+	}
+	Exit(ExitBlock)
+}
+
+// DecidePreToolUse returns a permission decision (PermissionAsk or PermissionDeny)
+// as JSON. Unlike BlockPreToolUse, "ask" lets the user approve the call.
+func DecidePreToolUse(decision, reason string) {
+	DefaultOutput().DecidePreToolUse(decision, reason)
+}
+
+// DecidePreToolUse is the package-level DecidePreToolUse, except that with
+// o.WarnOnly "deny" becomes a warning.
+func (o Output) DecidePreToolUse(decision, reason string) {
+	if o.WarnOnly && decision == PermissionDeny {
+		WarnPreToolUse("⚠️ " + reason)
+		return
+	}
+	response := PreToolUseResponse{
+		HookSpecificOutput: PreToolUseOutput{
+			HookEventName:            EventPreToolUse,
+			PermissionDecision:       decision,
+			PermissionDecisionReason: reason,
+		},
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	if err := encoder.Encode(response); err != nil {
+		_, _ = os.Stderr.WriteString("Error encoding decision response: " + err.Error() + "\n") //nolint:errcheck
+	}
+	Exit(ExitSuccess)
+}
+
+// BlockReason is a machine-readable explanation of a block, for hooks that
+// let Claude parse why a command was refused and propose a compliant
+// alternative rather than retry variants of it.
+type BlockReason struct {
+	Message      string   `json:"message"`
+	Rules        []string `json:"rules,omitempty"`        // Rules that matched
+	RuleIndexes  []int    `json:"rule_indexes,omitempty"` // Zero-based positions of the matched rules among the rules evaluated, in match order
+	Segments     []string `json:"segments,omitempty"`     // Segments of a compound command that are blocked
+	Issues       []string `json:"issues,omitempty"`       // Same as BlockPreToolUse lists
+	Alternatives []string `json:"alternatives,omitempty"` // Suggested safe alternatives
+	Docs         string   `json:"docs,omitempty"`         // Link to the rule documentation
+
+	// Matched and Descriptions are only shown at VerbosityVerbose
+	Matched      []string `json:"matched,omitempty"`      // Source of the commands that matched a rule
+	Descriptions []string `json:"descriptions,omitempty"` // "rule: description" of the matched rules that have one
+}
+
+// DenyPreToolUse denies the tool call with reason encoded as JSON in
+// permissionDecisionReason. Issues are summarized as in BlockPreToolUse.
+func DenyPreToolUse(reason BlockReason) {
+	DefaultOutput().DenyPreToolUse(reason)
+}
+
+// DenyPreToolUse is the package-level DenyPreToolUse written as o says. At
+// VerbosityQuiet the reason is the single line of QuietReason instead.
+func (o Output) DenyPreToolUse(reason BlockReason) {
+	if o.WarnOnly {
+		o.WarnPreToolUseReason(reason)
+		return
+	}
+	switch o.Verbosity {
+	case VerbosityQuiet:
+		o.DecidePreToolUse(PermissionDeny, QuietReason(reason))
+		return
+	case VerbosityNormal:
+		reason.Matched, reason.Descriptions = nil, nil
+	}
+	reason.Issues = o.blockIssues(reason.Issues)
+	data, err := json.Marshal(reason)
+	if err != nil {
+		o.BlockPreToolUseReason(reason)
+		return
+	}
+	o.DecidePreToolUse(PermissionDeny, string(data))
+}
+
+// WarnPreToolUse allows the tool call and shows message to the user as a
+// warning. Claude is not told. The message is also written to stderr, which
+// Claude Code shows in verbose mode.
+func WarnPreToolUse(message string) {
+	_, _ = os.Stderr.WriteString(message + "\n") //nolint:errcheck // Error writing to stderr is not actionable in warning function
+	encoder := json.NewEncoder(os.Stdout)
+	if err := encoder.Encode(WarningResponse{SystemMessage: message}); err != nil {
+		_, _ = os.Stderr.WriteString("Error encoding warning response: " + err.Error() + "\n") //nolint:errcheck
+	}
+	Exit(ExitSuccess)
+}
+
+// WarnPreToolUseReason is WarnPreToolUse for a BlockReason: the message
+// followed by the issues, on one line as the user sees it in the transcript.
+func WarnPreToolUseReason(reason BlockReason) {
+	DefaultOutput().WarnPreToolUseReason(reason)
+}
+
+// WarnPreToolUseReason is the package-level WarnPreToolUseReason, listing up
+// to o.MaxIssues issues.
+func (o Output) WarnPreToolUseReason(reason BlockReason) {
+	WarnPreToolUse(warningMessage(reason, o.MaxIssues))
+}
+
+// warningMessage is the one-line warning WarnPreToolUseReason shows.
+func warningMessage(reason BlockReason, maxIssues int) string {
+	message := "⚠️ " + reason.Message
+	if issues := SummarizeIssues(reason.Issues, maxIssues); len(issues) > 0 {
+		message += " " + strings.Join(issues, "; ")
+	}
+	return message
 }
 
 // AllowPreToolUse allows the tool to proceed (PreToolUse hooks).
 func AllowPreToolUse() {
-	os.Exit(0)
+	Exit(ExitSuccess)
 }
 
 // BlockPostToolUse blocks further actions with a JSON response
 func BlockPostToolUse(reason string) {
 	response := PostToolUseResponse{
-		Decision: "block",
+		Decision: string(OutcomeBlock),
 		Reason:   reason,
 	}
 	encoder := json.NewEncoder(os.Stdout)
 	if err := encoder.Encode(response); err != nil {
 		_, _ = os.Stderr.WriteString("Error encoding block response: " + err.Error() + "\n") //nolint:errcheck
 	}
-	os.Exit(0)
+	Exit(ExitSuccess)
+}
+
+// AnnotatePostToolUse lets Claude continue with context added to the tool's
+// output, e.g. a caution about what it contains, without the block decision.
+func AnnotatePostToolUse(context string) {
+	response := PostToolUseResponse{
+		HookSpecificOutput: &PostToolUseOutput{
+			HookEventName:     EventPostToolUse,
+			AdditionalContext: context,
+		},
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	if err := encoder.Encode(response); err != nil {
+		_, _ = os.Stderr.WriteString("Error encoding context response: " + err.Error() + "\n") //nolint:errcheck
+	}
+	Exit(ExitSuccess)
 }
 
 // AllowPostToolUse allows the action to proceed (PostToolUse)
 func AllowPostToolUse() {
-	os.Exit(0)
+	Exit(ExitSuccess)
 }
@@ -0,0 +1,106 @@
+package hook
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeIssues(t *testing.T) {
+	tests := []struct {
+		name   string
+		issues []string
+		limit  int
+		want   []string
+	}{
+		{"empty", nil, 10, nil},
+		{"duplicates removed", []string{"a", "b", "a", "a"}, 10, []string{"a", "b"}},
+		{"capped", []string{"a", "b", "c", "d", "e"}, 2, []string{"a", "b", "...and 3 more"}},
+		{"capped after deduplication", []string{"a", "a", "b", "b", "c"}, 2, []string{"a", "b", "...and 1 more"}},
+		{"exactly at limit", []string{"a", "b"}, 2, []string{"a", "b"}},
+		{"no limit", []string{"a", "b", "c"}, 0, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SummarizeIssues(tt.issues, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("SummarizeIssues(%q, %d) = %q, want %q", tt.issues, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason BlockReason
+		want   string
+	}{
+		{"no issues", BlockReason{Message: "Blocked!"}, "Blocked!"},
+		{"one issue", BlockReason{Message: "Blocked!", Issues: []string{"git push"}}, "Blocked! git push"},
+		{"more issues", BlockReason{Message: "Blocked!", Issues: []string{"a", "b", "a", "c"}}, "Blocked! a (+2 more)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuietReason(tt.reason); got != tt.want {
+				t.Errorf("QuietReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutput_BlockIssues(t *testing.T) {
+	issues := []string{"a", "b", "c", "a"}
+	tests := []struct {
+		name   string
+		output Output
+		want   []string
+	}{
+		{"default", DefaultOutput(), []string{"a", "b", "c"}},
+		{"capped", Output{MaxIssues: 2}, []string{"a", "b", "...and 1 more"}},
+		{"verbose ignores the cap", Output{MaxIssues: 2, Verbosity: VerbosityVerbose}, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.output.blockIssues(issues); !slices.Equal(got, tt.want) {
+				t.Errorf("blockIssues() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarningMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason BlockReason
+		want   string
+	}{
+		{"no issues", BlockReason{Message: "Read-only mode!"}, "⚠️ Read-only mode!"},
+		{"issues", BlockReason{Message: "Blocked!", Issues: []string{"a", "b", "a"}}, "⚠️ Blocked! a; b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := warningMessage(tt.reason, DefaultMaxIssues); got != tt.want {
+				t.Errorf("warningMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdatedToolInput(t *testing.T) {
+	payload := `{"hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"pip install x","description":"Install x","timeout":60000}}`
+	input, err := DecodePreToolUseInput(strings.NewReader(payload), InputOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"command": "uv pip install x", "description": "Install x", "timeout": float64(60000)}
+	if got := input.UpdatedToolInput(map[string]any{"command": "uv pip install x"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("UpdatedToolInput() = %v, want %v", got, want)
+	}
+	if input.RawToolInput["command"] != "pip install x" {
+		t.Errorf("UpdatedToolInput() modified RawToolInput: %v", input.RawToolInput)
+	}
+	if got := (&PreToolUseInput{}).UpdatedToolInput(map[string]any{"command": "ls"}); !reflect.DeepEqual(got, map[string]any{"command": "ls"}) {
+		t.Errorf("UpdatedToolInput() without a raw input = %v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package hook
+
+import (
+	"os"
+	"strings"
+	"text/template"
+)
+
+// MessageData is what block message templates are executed with. Besides the
+// BlockReason fields ({{.Message}}, {{.Issues}}, {{.Alternatives}},
+// {{.Docs}}, ...) it holds the blocked command and the first matched rule
+// and suggestion, e.g.
+//
+//	{{.Rule}} blocked `{{.Command}}`. {{with .Suggestion}}Try: {{.}}. {{end}}Runbook: https://wiki.example.com/{{.Rule}}
+type MessageData struct {
+	BlockReason
+	Hook       string // Hook that blocked, e.g. bash-block
+	Command    string // Blocked command or tool input
+	Rule       string // First entry of Rules
+	Suggestion string // First entry of Alternatives
+}
+
+// ParseMessageTemplate parses a block message template.
+func ParseMessageTemplate(text string) (*template.Template, error) {
+	return template.New("message").Option("missingkey=error").Parse(text)
+}
+
+// RenderMessage executes the block message template text for reason.
+func RenderMessage(text, hookName, command string, reason BlockReason) (string, error) {
+	tmpl, err := ParseMessageTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	data := MessageData{BlockReason: reason, Hook: hookName, Command: command}
+	if len(reason.Rules) > 0 {
+		data.Rule = reason.Rules[0]
+	}
+	if len(reason.Alternatives) > 0 {
+		data.Suggestion = reason.Alternatives[0]
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// BlockPreToolUseMessage blocks the tool execution with message as the whole
+// stderr output, for block messages rendered from a template.
+func BlockPreToolUseMessage(message string) {
+	DefaultOutput().BlockPreToolUseMessage(message)
+}
+
+// BlockPreToolUseMessage is the package-level BlockPreToolUseMessage, except
+// that with o.WarnOnly the message is shown to the user as a warning.
+func (o Output) BlockPreToolUseMessage(message string) {
+	if o.WarnOnly {
+		WarnPreToolUse("⚠️ " + strings.TrimSuffix(message, "\n"))
+		return
+	}
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+	_, _ = os.Stderr.WriteString(message) //nolint:errcheck // Error writing to stderr is not actionable in blocking function
+	Exit(ExitBlock)
+}
@@ -0,0 +1,35 @@
+package hook
+
+import "testing"
+
+func TestRenderMessage(t *testing.T) {
+	reason := BlockReason{
+		Message:      "Blocked command detected!",
+		Rules:        []string{"no-force", "no-push"},
+		Issues:       []string{"Blocked git pattern detected"},
+		Alternatives: []string{"git push --force-with-lease"},
+	}
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{"Fields", "{{.Hook}}: {{.Rule}} blocked `{{.Command}}`, try {{.Suggestion}}", "bash-block: no-force blocked `git push --force`, try git push --force-with-lease", false},
+		{"Lists", "{{range .Issues}}- {{.}}{{end}} ({{len .Rules}} rules)", "- Blocked git pattern detected (2 rules)", false},
+		{"Default message", "{{.Message}} Runbook: https://wiki.example.com", "Blocked command detected! Runbook: https://wiki.example.com", false},
+		{"Parse error", "{{.Rule", "", true},
+		{"Unknown field", "{{.Runbook}}", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderMessage(tt.text, "bash-block", "git push --force", reason)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
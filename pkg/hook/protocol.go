@@ -0,0 +1,150 @@
+// Package hook - exit codes and the per-event hook protocol
+package hook
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+)
+
+// ProtocolVersion identifies the set of events, exit codes, and JSON responses
+// below. It changes whenever an outcome is added or its signalling changes, so
+// tooling that wraps the hooks can detect what a binary supports.
+const ProtocolVersion = 3
+
+// Hook event names as sent in hook_event_name.
+const (
+	EventPreToolUse       = "PreToolUse"
+	EventPostToolUse      = "PostToolUse"
+	EventUserPromptSubmit = "UserPromptSubmit"
+	EventNotification     = "Notification"
+	EventStop             = "Stop"
+	EventSubagentStop     = "SubagentStop"
+	EventPreCompact       = "PreCompact"
+	EventSessionStart     = "SessionStart"
+)
+
+// Events lists every hook event in the order Claude Code documents them.
+var Events = []string{
+	EventPreToolUse, EventPostToolUse, EventUserPromptSubmit, EventNotification,
+	EventStop, EventSubagentStop, EventPreCompact, EventSessionStart,
+}
+
+// ExitCode is a hook process exit status as interpreted by Claude Code.
+type ExitCode int
+
+const (
+	// ExitSuccess continues normally. Stdout is parsed as a JSON response if
+	// it holds one.
+	ExitSuccess ExitCode = 0
+	// ExitNonBlockingError reports a problem without stopping anything: stderr
+	// is shown to the user and execution continues. Claude Code treats every
+	// status other than 0 and 2 this way.
+	ExitNonBlockingError ExitCode = 1
+	// ExitBlock blocks the action where the event allows it and feeds stderr
+	// back to Claude (see Protocol for the effect per event).
+	ExitBlock ExitCode = 2
+)
+
+// Outcome is what a hook wants to happen as a result of an event.
+type Outcome string
+
+// Hook outcomes. Not every event supports every outcome.
+const (
+	OutcomeAllow   Outcome = "allow"   // Proceed normally
+	OutcomeBlock   Outcome = "block"   // Stop the action and tell Claude why
+	OutcomeAsk     Outcome = "ask"     // Prompt the user to confirm (PreToolUse only)
+	OutcomeDeny    Outcome = "deny"    // Refuse with a JSON permission decision (PreToolUse only)
+	OutcomeWarn    Outcome = "warn"    // Proceed and show the user a warning (PreToolUse only)
+	OutcomeUpdate  Outcome = "update"  // Proceed with modified tool input, or MCP tool output (PostToolUse)
+	OutcomeContext Outcome = "context" // Proceed and give Claude added context (PostToolUse only)
+	OutcomeError   Outcome = "error"   // Non-blocking error shown to the user
+)
+
+// Signal describes how a hook signals one outcome for one event.
+type Signal struct {
+	Event    string   `json:"event"`
+	Outcome  Outcome  `json:"outcome"`
+	ExitCode ExitCode `json:"exit_code"`
+	Output   string   `json:"output,omitempty"` // Where the message goes: "stderr" or the JSON field on stdout
+	Effect   string   `json:"effect"`
+}
+
+// Protocol is every supported event/outcome combination, the contract the
+// helpers in this package implement.
+var Protocol = []Signal{
+	{EventPreToolUse, OutcomeAllow, ExitSuccess, "", "tool call proceeds"},
+	{EventPreToolUse, OutcomeBlock, ExitBlock, "stderr", "tool call is blocked and stderr is shown to Claude"},
+	{EventPreToolUse, OutcomeAsk, ExitSuccess, "hookSpecificOutput.permissionDecision", "user is asked to confirm the tool call"},
+	{EventPreToolUse, OutcomeDeny, ExitSuccess, "hookSpecificOutput.permissionDecision", "tool call is refused and the reason is shown to Claude"},
+	{EventPreToolUse, OutcomeWarn, ExitSuccess, "systemMessage", "tool call proceeds and the message is shown to the user"},
+	{EventPreToolUse, OutcomeUpdate, ExitSuccess, "hookSpecificOutput.updatedInput", "tool call proceeds with the updated tool input"},
+	{EventPreToolUse, OutcomeError, ExitNonBlockingError, "stderr", "stderr is shown to the user and the tool call proceeds"},
+	{EventPostToolUse, OutcomeAllow, ExitSuccess, "", "Claude continues"},
+	{EventPostToolUse, OutcomeBlock, ExitSuccess, "decision", "reason is shown to Claude; the tool has already run"},
+	{EventPostToolUse, OutcomeUpdate, ExitSuccess, "hookSpecificOutput.updatedMCPToolOutput", "Claude is given the updated output instead of the MCP tool's"},
+	{EventPostToolUse, OutcomeContext, ExitSuccess, "hookSpecificOutput.additionalContext", "Claude continues with the context added to the tool output"},
+	{EventPostToolUse, OutcomeError, ExitNonBlockingError, "stderr", "stderr is shown to the user"},
+	{EventUserPromptSubmit, OutcomeAllow, ExitSuccess, "", "prompt is processed"},
+	{EventUserPromptSubmit, OutcomeBlock, ExitBlock, "stderr", "prompt is erased and stderr is shown to the user"},
+	{EventUserPromptSubmit, OutcomeError, ExitNonBlockingError, "stderr", "stderr is shown to the user and the prompt is processed"},
+	{EventNotification, OutcomeAllow, ExitSuccess, "", "notification is handled"},
+	{EventNotification, OutcomeError, ExitNonBlockingError, "stderr", "stderr is shown to the user"},
+	{EventStop, OutcomeAllow, ExitSuccess, "", "Claude stops"},
+	{EventStop, OutcomeBlock, ExitBlock, "stderr", "Claude keeps working with stderr as its instructions"},
+	{EventStop, OutcomeError, ExitNonBlockingError, "stderr", "stderr is shown to the user and Claude stops"},
+	{EventSubagentStop, OutcomeAllow, ExitSuccess, "", "subagent stops"},
+	{EventSubagentStop, OutcomeBlock, ExitBlock, "stderr", "subagent keeps working with stderr as its instructions"},
+	{EventSubagentStop, OutcomeError, ExitNonBlockingError, "stderr", "stderr is shown to the user and the subagent stops"},
+	{EventPreCompact, OutcomeAllow, ExitSuccess, "", "compaction proceeds"},
+	{EventPreCompact, OutcomeError, ExitNonBlockingError, "stderr", "stderr is shown to the user and compaction proceeds"},
+	{EventSessionStart, OutcomeAllow, ExitSuccess, "", "stdout is added to the session context"},
+	{EventSessionStart, OutcomeError, ExitNonBlockingError, "stderr", "stderr is shown to the user"},
+}
+
+// ProtocolInfo is the -print-protocol output of a hook binary.
+type ProtocolInfo struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Hook            string   `json:"hook"`
+	Events          []string `json:"events"`
+	Signals         []Signal `json:"signals"`
+}
+
+// PrintProtocol writes the protocol version and the signals a hook uses for
+// the events it handles as indented JSON.
+func PrintProtocol(w io.Writer, hookName string, events ...string) error {
+	info := ProtocolInfo{ProtocolVersion: ProtocolVersion, Hook: hookName, Events: events}
+	for _, signal := range Protocol {
+		if slices.Contains(events, signal.Event) {
+			info.Signals = append(info.Signals, signal)
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(info)
+}
+
+// Exit ends the hook process with the given exit code.
+func Exit(code ExitCode) {
+	os.Exit(int(code))
+}
+
+// NonBlockingError reports a problem to the user without affecting the
+// action (any event).
+func NonBlockingError(message string) {
+	_, _ = os.Stderr.WriteString(message + "\n") //nolint:errcheck // Error writing to stderr is not actionable in exiting function
+	Exit(ExitNonBlockingError)
+}
+
+// ExitWithProtocol prints the protocol and exits when -print-protocol was given.
+// Call it right after parsing flags.
+func ExitWithProtocol(printProtocol bool, hookName string, events ...string) {
+	if !printProtocol {
+		return
+	}
+	if err := PrintProtocol(os.Stdout, hookName, events...); err != nil {
+		NonBlockingError("Error encoding protocol: " + err.Error())
+	}
+	Exit(ExitSuccess)
+}
@@ -0,0 +1,46 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestPrintProtocol(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PrintProtocol(&buf, "bash-block", EventPreToolUse); err != nil {
+		t.Fatalf("PrintProtocol() error: %v", err)
+	}
+
+	var info ProtocolInfo
+	if err := json.Unmarshal(buf.Bytes(), &info); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	if info.ProtocolVersion != ProtocolVersion || info.Hook != "bash-block" {
+		t.Errorf("PrintProtocol() = %+v", info)
+	}
+	for _, signal := range info.Signals {
+		if signal.Event != EventPreToolUse {
+			t.Errorf("PrintProtocol() included %s signal for a PreToolUse hook", signal.Event)
+		}
+	}
+	if len(info.Signals) != 7 {
+		t.Errorf("PrintProtocol() returned %d PreToolUse signals, want 7", len(info.Signals))
+	}
+}
+
+func TestProtocol_EveryEventCanAllowAndError(t *testing.T) {
+	for _, event := range Events {
+		for _, outcome := range []Outcome{OutcomeAllow, OutcomeError} {
+			if !slices.ContainsFunc(Protocol, func(s Signal) bool { return s.Event == event && s.Outcome == outcome }) {
+				t.Errorf("Protocol has no %s signal for %s", outcome, event)
+			}
+		}
+	}
+	for _, signal := range Protocol {
+		if signal.ExitCode == ExitBlock && signal.Outcome != OutcomeBlock {
+			t.Errorf("%s %s uses the block exit code", signal.Event, signal.Outcome)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Package hook - streaming PostToolUse decoding
+package hook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxResponseString is the number of bytes of each tool_response string
+// DecodePostToolUseInput retains. tool_response echoes whole files, such as
+// Write content and MultiEdit originalFileContents, which hooks do not need.
+const MaxResponseString = 4 << 10
+
+// postDecoder decodes a PostToolUse payload token by token, so large values
+// hooks do not read are skipped rather than held in memory.
+type postDecoder struct {
+	dec   *json.Decoder
+	input PostToolUseInput
+	shape payloadShape
+}
+
+// decodePostToolUse decodes a PostToolUse payload from r and returns it with
+// its shape for validation. Syntax and type errors are marked ErrInputSchema.
+func decodePostToolUse(r io.Reader) (*PostToolUseInput, payloadShape, error) {
+	rr := &readRecorder{r: r}
+	d := &postDecoder{dec: json.NewDecoder(rr)}
+	d.shape.toolInputInvalid = true
+	if err := d.decode(); err != nil {
+		if rr.err != nil {
+			return nil, payloadShape{}, rr.err
+		}
+		return nil, payloadShape{}, MarkError(err, ErrInputSchema)
+	}
+	d.shape.eventName = d.input.HookEventName
+	d.shape.toolName = d.input.ToolName
+	return &d.input, d.shape, nil
+}
+
+func (d *postDecoder) decode() error {
+	if err := d.expectObject(); err != nil {
+		return err
+	}
+	for d.dec.More() {
+		field, err := d.key()
+		if err != nil {
+			return err
+		}
+		d.shape.fields = append(d.shape.fields, field)
+		switch field {
+		case "session_id":
+			err = d.dec.Decode(&d.input.SessionID)
+		case "transcript_path":
+			err = d.dec.Decode(&d.input.TranscriptPath)
+		case "cwd":
+			err = d.dec.Decode(&d.input.Cwd)
+		case "hook_event_name":
+			err = d.dec.Decode(&d.input.HookEventName)
+		case "tool_name":
+			err = d.dec.Decode(&d.input.ToolName)
+		case "tool_input":
+			err = d.toolInput()
+		case "tool_response":
+			err = d.toolResponse()
+		default:
+			err = d.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := d.dec.Token(); err != nil {
+		return err
+	}
+	if _, err := d.dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// toolInput decodes the tool_input fields PostToolUseInput holds and records
+// the names of the others, skipping their values.
+func (d *postDecoder) toolInput() error {
+	token, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		d.shape.toolInputInvalid = false
+		return nil
+	}
+	if token != json.Delim('{') {
+		return typeError("tool_input", token)
+	}
+	d.shape.toolInputInvalid = false
+	for d.dec.More() {
+		field, err := d.key()
+		if err != nil {
+			return err
+		}
+		d.shape.toolInputFields = append(d.shape.toolInputFields, field)
+		switch field {
+		case "file_path":
+			err = d.dec.Decode(&d.input.ToolInput.FilePath)
+		case "command":
+			err = d.dec.Decode(&d.input.ToolInput.Command)
+		default:
+			err = d.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = d.dec.Token()
+	return err
+}
+
+// toolResponse decodes tool_response with each string cut to
+// MaxResponseString bytes.
+func (d *postDecoder) toolResponse() error {
+	token, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('{') {
+		return typeError("tool_response", token)
+	}
+	value, err := d.value(token)
+	if err != nil {
+		return err
+	}
+	d.input.ToolResponse = value.(map[string]any)
+	return nil
+}
+
+// value decodes the JSON value starting with token as encoding/json would
+// into an any, capping strings.
+func (d *postDecoder) value(token json.Token) (any, error) {
+	switch token {
+	case json.Delim('{'):
+		object := map[string]any{}
+		for d.dec.More() {
+			key, err := d.key()
+			if err != nil {
+				return nil, err
+			}
+			next, err := d.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if object[key], err = d.value(next); err != nil {
+				return nil, err
+			}
+		}
+		_, err := d.dec.Token()
+		return object, err
+	case json.Delim('['):
+		array := []any{}
+		for d.dec.More() {
+			next, err := d.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			element, err := d.value(next)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+		}
+		_, err := d.dec.Token()
+		return array, err
+	}
+	if s, ok := token.(string); ok {
+		return truncateUTF8(s, MaxResponseString), nil
+	}
+	return token, nil
+}
+
+// skip consumes the next value without keeping it.
+func (d *postDecoder) skip() error {
+	depth := 0
+	for {
+		token, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func (d *postDecoder) expectObject() error {
+	token, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('{') {
+		return typeError("payload", token)
+	}
+	return nil
+}
+
+// key reads an object key; the decoder only returns strings in key position.
+func (d *postDecoder) key() (string, error) {
+	token, err := d.dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, _ := token.(string) //nolint:errcheck // Keys are always strings
+	return key, nil
+}
+
+func typeError(field string, token json.Token) error {
+	return fmt.Errorf("%s is %v, want a JSON object", field, token)
+}
+
+// readRecorder records the first error reading a payload, so it is told
+// apart from errors in the payload itself.
+type readRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (rr *readRecorder) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if err != nil && err != io.EOF && rr.err == nil {
+		rr.err = err
+	}
+	return n, err
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune. The cut
+// string is copied, so s itself can be freed.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return strings.Clone(s[:n])
+}
@@ -0,0 +1,104 @@
+package hook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecodePostToolUseInput_LargeMultiEdit(t *testing.T) {
+	contents := strings.Repeat("x", 4*MaxResponseString)
+	var edits []string
+	for i := range 100 {
+		edits = append(edits, fmt.Sprintf(`{"old_string": %q, "new_string": "y", "replace_all": false}`, contents[:i+1]))
+	}
+	payload := fmt.Sprintf(`{"session_id": "s1", "cwd": "/repo", "hook_event_name": "PostToolUse", "tool_name": "MultiEdit",
+		"tool_input": {"file_path": "/repo/a.go", "edits": [%s]},
+		"tool_response": {"filePath": "/repo/a.go", "originalFileContents": %q, "edits": [%s], "userModified": false}}`,
+		strings.Join(edits, ","), contents, strings.Join(edits, ","))
+
+	input, err := DecodePostToolUseInput(strings.NewReader(payload), InputOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("DecodePostToolUseInput() error: %v", err)
+	}
+	if input.ToolInput.FilePath != "/repo/a.go" || input.Cwd != "/repo" || input.ToolName != "MultiEdit" {
+		t.Errorf("DecodePostToolUseInput() = %+v", input)
+	}
+	if got := input.ToolResponse["originalFileContents"].(string); len(got) != MaxResponseString {
+		t.Errorf("originalFileContents kept %d bytes, want %d", len(got), MaxResponseString)
+	}
+	if got := input.ToolResponse["edits"].([]any); len(got) != 100 {
+		t.Errorf("tool_response edits = %d, want 100", len(got))
+	}
+	if got := input.ToolResponse["userModified"]; got != false {
+		t.Errorf("userModified = %v, want false", got)
+	}
+}
+
+func TestDecodePostToolUseInput_Malformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"empty", ""},
+		{"not an object", `["PostToolUse"]`},
+		{"truncated", `{"hook_event_name": "PostToolUse", "tool_input": {"file_path": "/a`},
+		{"wrong field type", `{"hook_event_name": "PostToolUse", "tool_name": 1}`},
+		{"tool_input not an object", `{"hook_event_name": "PostToolUse", "tool_input": "ls"}`},
+		{"tool_response not an object", `{"hook_event_name": "PostToolUse", "tool_response": []}`},
+		{"trailing data", `{"hook_event_name": "PostToolUse"} {}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodePostToolUseInput(strings.NewReader(tt.payload), InputOptions{})
+			if !errors.Is(err, ErrInputSchema) {
+				t.Errorf("DecodePostToolUseInput(%s) error = %v, want ErrInputSchema", tt.payload, err)
+			}
+		})
+	}
+
+	readErr := errors.New("stdin closed")
+	_, err := DecodePostToolUseInput(io.MultiReader(strings.NewReader(`{"cwd": `), iotest.ErrReader(readErr)), InputOptions{})
+	if !errors.Is(err, readErr) || errors.Is(err, ErrInputSchema) {
+		t.Errorf("DecodePostToolUseInput() read error = %v, want %v unmarked", err, readErr)
+	}
+}
+
+func TestDecodePostToolUseInput_SchemaProblems(t *testing.T) {
+	payload := `{"session_id": "s1", "hook_event_name": "PreToolUse", "tool_name": "Write", "tool_input": {"content": "x"}, "tool_response": null}`
+	_, err := DecodePostToolUseInput(bytes.NewReader([]byte(payload)), InputOptions{Strict: true})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("DecodePostToolUseInput() error = %v, want a *SchemaError", err)
+	}
+	want := []string{
+		`hook_event_name is "PreToolUse", but this hook reads PostToolUse (check the event it is configured under)`,
+		"missing tool_input.file_path for Write",
+	}
+	if strings.Join(schemaErr.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Problems = %q, want %q", schemaErr.Problems, want)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"héllo", 2, "h"},
+		{"héllo", 3, "hé"},
+		{"日本", 4, "日"},
+	}
+	for _, tt := range tests {
+		if got := truncateUTF8(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
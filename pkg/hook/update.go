@@ -0,0 +1,67 @@
+// Package hook - updated tool input for PreToolUse hooks
+package hook
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"strings"
+)
+
+// UpdatedToolInput returns the complete tool_input with changes applied, for
+// UpdatePreToolUseInput. Claude Code replaces the whole input, so fields the
+// hook did not change, such as a Bash command's description or timeout, are
+// kept.
+func (in *PreToolUseInput) UpdatedToolInput(changes map[string]any) map[string]any {
+	updated := maps.Clone(in.RawToolInput)
+	if updated == nil {
+		updated = make(map[string]any, len(changes))
+	}
+	maps.Copy(updated, changes)
+	return updated
+}
+
+// UpdatePreToolUseInput lets the tool call run with updatedInput, the complete
+// tool_input to use instead of Claude's (see UpdatedToolInput), e.g. a Write
+// with formatted content or a rewritten command. decision is PermissionAsk to
+// have the user confirm the call as usual, or PermissionAllow to also skip
+// the permission prompt. reason is shown to the user.
+func UpdatePreToolUseInput(decision string, updatedInput map[string]any, reason string) {
+	response := PreToolUseResponse{
+		HookSpecificOutput: PreToolUseOutput{
+			HookEventName:            EventPreToolUse,
+			PermissionDecision:       decision,
+			PermissionDecisionReason: reason,
+			UpdatedInput:             updatedInput,
+		},
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	if err := encoder.Encode(response); err != nil {
+		_, _ = os.Stderr.WriteString("Error encoding updated input response: " + err.Error() + "\n") //nolint:errcheck
+	}
+	Exit(ExitSuccess)
+}
+
+// UpdatePostToolUseOutput has Claude Code give Claude output in place of the
+// tool's own, e.g. a tool_response with secrets redacted. Claude Code only
+// replaces the output of MCP tools (see IsMCPTool); the output of built-in
+// tools such as Read is already final, so block with BlockPostToolUse there.
+func UpdatePostToolUseOutput(output any) {
+	response := PostToolUseResponse{
+		HookSpecificOutput: &PostToolUseOutput{
+			HookEventName:        EventPostToolUse,
+			UpdatedMCPToolOutput: output,
+		},
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	if err := encoder.Encode(response); err != nil {
+		_, _ = os.Stderr.WriteString("Error encoding updated output response: " + err.Error() + "\n") //nolint:errcheck
+	}
+	Exit(ExitSuccess)
+}
+
+// IsMCPTool reports whether toolName names an MCP server's tool, such as
+// mcp__github__get_file_contents, rather than a built-in tool.
+func IsMCPTool(toolName string) bool {
+	return strings.HasPrefix(toolName, "mcp__")
+}
@@ -0,0 +1,246 @@
+// Package hook - payload schema validation
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// InputOptions controls how hook payloads are validated while decoding.
+type InputOptions struct {
+	// Strict turns schema problems (wrong hook_event_name, missing required
+	// fields) into a *SchemaError instead of a warning, so payload changes in
+	// Claude Code surface as errors rather than silently-empty fields.
+	Strict bool
+	// Logger receives unknown fields at debug level and, when not strict,
+	// schema problems at warn level. Nil discards them.
+	Logger *slog.Logger
+	// KeepRaw sets PostToolUseInput.Raw, at the cost of holding the whole
+	// payload in memory. Other inputs always carry Raw.
+	KeepRaw bool
+}
+
+// SchemaError reports a payload that does not match the schema a reader expects.
+type SchemaError struct {
+	Event    string   // Event the reader expects
+	Problems []string // One entry per problem, e.g. a missing field
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid %s payload: %s", e.Event, strings.Join(e.Problems, "; "))
+}
+
+// Is makes a *SchemaError match ErrInputSchema.
+func (e *SchemaError) Is(target error) bool {
+	return target == ErrInputSchema
+}
+
+// eventFields are the top-level fields Claude Code sends per event.
+var eventFields = map[string][]string{
+	EventPreToolUse:  {"session_id", "transcript_path", "cwd", "hook_event_name", "tool_name", "tool_input"},
+	EventPostToolUse: {"session_id", "transcript_path", "cwd", "hook_event_name", "tool_name", "tool_input", "tool_response"},
+	EventStop:        {"session_id", "transcript_path", "cwd", "hook_event_name", "stop_hook_active"},
+}
+
+// toolInputFields are the known tool_input fields per tool, required ones first.
+var toolInputFields = map[string]struct{ required, optional []string }{
+	"Bash":         {[]string{"command"}, []string{"description", "timeout", "run_in_background"}},
+	"Edit":         {[]string{"file_path"}, []string{"old_string", "new_string", "replace_all"}},
+	"MultiEdit":    {[]string{"file_path"}, []string{"edits"}},
+	"Write":        {[]string{"file_path"}, []string{"content"}},
+	"NotebookEdit": {[]string{"notebook_path"}, []string{"cell_id", "new_source", "cell_type", "edit_mode"}},
+	"Read":         {[]string{"file_path"}, []string{"offset", "limit"}},
+	"Glob":         {[]string{"pattern"}, []string{"path"}},
+	"Grep":         {[]string{"pattern"}, []string{"path", "glob", "type", "output_mode", "-A", "-B", "-C", "-i", "-n", "multiline", "head_limit"}},
+	"Task":         {[]string{"description", "prompt"}, []string{"subagent_type"}},
+}
+
+// DecodePreToolUseInput decodes and validates a PreToolUse payload from r.
+func DecodePreToolUseInput(r io.Reader, opts InputOptions) (*PreToolUseInput, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var input PreToolUseInput
+	if err := decodeInput(bytes.NewReader(data), EventPreToolUse, opts, &input); err != nil {
+		return nil, err
+	}
+	input.Raw = rawSections(bytes.TrimSpace(data))
+	_ = json.Unmarshal(input.Raw.ToolInput, &input.RawToolInput) //nolint:errcheck // The payload decoded above
+	return &input, nil
+}
+
+// rawSections splits a decoded payload into its Raw sections.
+func rawSections(data []byte) Raw {
+	var sections struct {
+		ToolInput    json.RawMessage `json:"tool_input"`
+		ToolResponse json.RawMessage `json:"tool_response"`
+	}
+	_ = json.Unmarshal(data, &sections) //nolint:errcheck // Only called on decoded payloads
+	return Raw{Payload: data, ToolInput: sections.ToolInput, ToolResponse: sections.ToolResponse}
+}
+
+// DecodePostToolUseInput decodes and validates a PostToolUse payload from r.
+// The payload is streamed: tool_input fields PostToolUseInput does not hold
+// are skipped and tool_response strings are cut to MaxResponseString bytes,
+// so MultiEdit and Write payloads carrying whole files are not retained.
+func DecodePostToolUseInput(r io.Reader, opts InputOptions) (*PostToolUseInput, error) {
+	var payload bytes.Buffer
+	if opts.KeepRaw {
+		r = io.TeeReader(r, &payload)
+	}
+	input, shape, err := decodePostToolUse(r)
+	if err != nil {
+		return nil, err
+	}
+	problems, unknown := shape.validate(EventPostToolUse)
+	if err := reportSchema(EventPostToolUse, opts, problems, unknown); err != nil {
+		return nil, err
+	}
+	if opts.KeepRaw {
+		input.Raw = rawSections(bytes.TrimSpace(payload.Bytes()))
+	}
+	return input, nil
+}
+
+// DecodeStopInput decodes and validates a Stop payload from r.
+func DecodeStopInput(r io.Reader, opts InputOptions) (*StopInput, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var input StopInput
+	if err := decodeInput(bytes.NewReader(data), EventStop, opts, &input); err != nil {
+		return nil, err
+	}
+	input.Raw = Raw{Payload: bytes.TrimSpace(data)}
+	return &input, nil
+}
+
+// EventName returns the hook_event_name of a payload, or "" when it has none.
+// Hooks configured under several events use it to pick a decoder.
+func EventName(data []byte) string {
+	var payload struct {
+		HookEventName string `json:"hook_event_name"`
+	}
+	_ = json.Unmarshal(data, &payload) //nolint:errcheck // Decoding reports invalid payloads
+	return payload.HookEventName
+}
+
+// decodeInput decodes the payload into v after checking it against the schema
+// of event.
+func decodeInput(r io.Reader, event string, opts InputOptions, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return MarkError(err, ErrInputSchema)
+	}
+	problems, unknown := validatePayload(data, event)
+	return reportSchema(event, opts, problems, unknown)
+}
+
+// reportSchema logs the unknown fields of a payload and returns its schema
+// problems as a *SchemaError when strict, logging them otherwise.
+func reportSchema(event string, opts InputOptions, problems, unknown []string) error {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	for _, field := range unknown {
+		logger.Debug("unknown payload field", "event", event, "field", field)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	if len(unknown) > 0 {
+		// A required field that went missing alongside a new one was likely renamed
+		problems = append(problems, "unknown fields (renamed?): "+strings.Join(unknown, ", "))
+	}
+	if opts.Strict {
+		return &SchemaError{Event: event, Problems: problems}
+	}
+	for _, problem := range problems {
+		logger.Warn("payload does not match schema", "event", event, "problem", problem)
+	}
+	return nil
+}
+
+// validatePayload returns the schema problems of a payload for event, and the
+// fields it does not know, as "field" or "tool_input.field".
+func validatePayload(data []byte, event string) (problems, unknown []string) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return []string{"payload is not a JSON object"}, nil
+	}
+
+	var shape payloadShape
+	_ = json.Unmarshal(payload["hook_event_name"], &shape.eventName) //nolint:errcheck // A wrong type is reported as a mismatch below
+	_ = json.Unmarshal(payload["tool_name"], &shape.toolName)        //nolint:errcheck // A wrong type is reported as missing below
+	for field := range payload {
+		shape.fields = append(shape.fields, field)
+	}
+	var toolInput map[string]json.RawMessage
+	if err := json.Unmarshal(payload["tool_input"], &toolInput); err != nil {
+		shape.toolInputInvalid = true
+	}
+	for field := range toolInput {
+		shape.toolInputFields = append(shape.toolInputFields, field)
+	}
+	return shape.validate(event)
+}
+
+// payloadShape is what schema validation needs from a payload: its field
+// names and the two fields that select the schema, not their values.
+type payloadShape struct {
+	eventName, toolName string
+	fields              []string // Top-level fields
+	toolInputFields     []string
+	toolInputInvalid    bool // tool_input is missing or not an object
+}
+
+// validate returns the schema problems of the payload for event, and the
+// fields it does not know, as "field" or "tool_input.field".
+func (shape payloadShape) validate(event string) (problems, unknown []string) {
+	switch {
+	case shape.eventName == "":
+		problems = append(problems, "missing hook_event_name")
+	case shape.eventName != event:
+		problems = append(problems, fmt.Sprintf("hook_event_name is %q, but this hook reads %s (check the event it is configured under)", shape.eventName, event))
+	}
+	if shape.toolName == "" && slices.Contains(eventFields[event], "tool_name") {
+		problems = append(problems, "missing tool_name")
+	}
+	for _, field := range shape.fields {
+		if !slices.Contains(eventFields[event], field) {
+			unknown = append(unknown, field)
+		}
+	}
+
+	fields, known := toolInputFields[shape.toolName]
+	if !known {
+		slices.Sort(unknown)
+		return problems, unknown
+	}
+	if shape.toolInputInvalid {
+		problems = append(problems, "tool_input is not a JSON object")
+	}
+	for _, field := range fields.required {
+		if !slices.Contains(shape.toolInputFields, field) {
+			problems = append(problems, fmt.Sprintf("missing tool_input.%s for %s", field, shape.toolName))
+		}
+	}
+	for _, field := range shape.toolInputFields {
+		if !slices.Contains(fields.required, field) && !slices.Contains(fields.optional, field) {
+			unknown = append(unknown, "tool_input."+field)
+		}
+	}
+	slices.Sort(unknown)
+	return problems, unknown
+}
@@ -0,0 +1,168 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestDecodePreToolUseInput(t *testing.T) {
+	payload := `{"session_id": "s1", "cwd": "/repo", "hook_event_name": "PreToolUse", "tool_name": "Bash",
+		"tool_input": {"command": "ls", "description": "List files"}}`
+
+	input, err := DecodePreToolUseInput(strings.NewReader(payload), InputOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("DecodePreToolUseInput() error: %v", err)
+	}
+	if input.ToolInput.Command != "ls" || input.HookEventName != EventPreToolUse {
+		t.Errorf("DecodePreToolUseInput() = %+v", input)
+	}
+}
+
+func TestDecodePreToolUseInput_Strict(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{
+			name:    "Wrong event",
+			payload: `{"session_id": "s1", "hook_event_name": "PostToolUse", "tool_name": "Bash", "tool_input": {"command": "ls"}}`,
+			want:    `hook_event_name is "PostToolUse", but this hook reads PreToolUse`,
+		},
+		{
+			name:    "Renamed field",
+			payload: `{"session_id": "s1", "hook_event_name": "PreToolUse", "tool_name": "Bash", "tool_input": {"cmd": "ls"}}`,
+			want:    "missing tool_input.command for Bash; unknown fields (renamed?): tool_input.cmd",
+		},
+		{
+			name:    "Missing tool name",
+			payload: `{"session_id": "s1", "hook_event_name": "PreToolUse", "tool_input": {}}`,
+			want:    "missing tool_name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodePreToolUseInput(strings.NewReader(tt.payload), InputOptions{Strict: true})
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("DecodePreToolUseInput() error = %v, want *SchemaError", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+			if !errors.Is(err, ErrInputSchema) {
+				t.Errorf("error = %v, want it to match ErrInputSchema", err)
+			}
+
+			// Without -strict-input the same payload decodes with a warning
+			if _, err := DecodePreToolUseInput(strings.NewReader(tt.payload), InputOptions{}); err != nil {
+				t.Errorf("non-strict DecodePreToolUseInput() error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodePreToolUseInput_InvalidJSON(t *testing.T) {
+	_, err := DecodePreToolUseInput(strings.NewReader(`{"tool_name": "Bash"`), InputOptions{})
+	if !errors.Is(err, ErrInputSchema) || err.Error() != "unexpected end of JSON input" {
+		t.Errorf("DecodePreToolUseInput() error = %v, want the JSON error matching ErrInputSchema", err)
+	}
+}
+
+func TestMarkError(t *testing.T) {
+	if MarkError(nil, ErrConfig) != nil {
+		t.Error("MarkError(nil) should be nil")
+	}
+	cause := errors.New("bad value")
+	err := MarkError(cause, ErrConfig)
+	if !errors.Is(err, ErrConfig) || !errors.Is(err, cause) || errors.Is(err, ErrInputSchema) {
+		t.Errorf("MarkError() = %v, want it to match ErrConfig and its cause only", err)
+	}
+	if err.Error() != "bad value" {
+		t.Errorf("MarkError().Error() = %q, want the cause's message", err)
+	}
+	if again := MarkError(err, ErrConfig); again != err {
+		t.Error("MarkError() of an already marked error should return it unchanged")
+	}
+}
+
+func TestDecodePostToolUseInput_UnknownFieldsLogged(t *testing.T) {
+	payload := `{"session_id": "s1", "hook_event_name": "PostToolUse", "tool_name": "Write", "permission_mode": "default",
+		"tool_input": {"file_path": "/repo/a.go", "content": "package a"}, "tool_response": {"success": true}}`
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	input, err := DecodePostToolUseInput(strings.NewReader(payload), InputOptions{Strict: true, Logger: logger})
+	if err != nil {
+		t.Fatalf("unknown fields alone should not fail strict decoding: %v", err)
+	}
+	if input.ToolInput.FilePath != "/repo/a.go" {
+		t.Errorf("FilePath = %q", input.ToolInput.FilePath)
+	}
+	if !strings.Contains(logs.String(), "field=permission_mode") {
+		t.Errorf("unknown field not logged at debug level: %s", logs.String())
+	}
+}
+
+func TestEventName(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    string
+	}{
+		{`{"session_id": "s1", "hook_event_name": "Stop", "stop_hook_active": false}`, EventStop},
+		{`{"session_id": "s1", "tool_name": "Bash"}`, ""},
+		{`not json`, ""},
+	}
+	for _, tt := range tests {
+		if got := EventName([]byte(tt.payload)); got != tt.want {
+			t.Errorf("EventName(%s) = %q, want %q", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeInput_Raw(t *testing.T) {
+	content := strings.Repeat("x", 2*MaxResponseString)
+	post := `{"session_id": "s1", "hook_event_name": "PostToolUse", "tool_name": "Write", "permission_mode": "plan",
+		"tool_input": {"file_path": "/repo/a.go", "content": "` + content + `"}, "tool_response": {"content": "` + content + `"}}`
+
+	input, err := DecodePostToolUseInput(strings.NewReader(post), InputOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Raw.Payload != nil {
+		t.Errorf("Raw.Payload = %s without KeepRaw, want nil", input.Raw.Payload)
+	}
+
+	input, err = DecodePostToolUseInput(strings.NewReader(post+"\n"), InputOptions{KeepRaw: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(input.Raw.Payload) != post {
+		t.Errorf("Raw.Payload = %s, want the payload", input.Raw.Payload)
+	}
+	var extra struct {
+		PermissionMode string `json:"permission_mode"`
+	}
+	if err := json.Unmarshal(input.Raw.Payload, &extra); err != nil || extra.PermissionMode != "plan" {
+		t.Errorf("permission_mode from Raw.Payload = %q, %v", extra.PermissionMode, err)
+	}
+	var response struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(input.Raw.ToolResponse, &response); err != nil || response.Content != content {
+		t.Errorf("Raw.ToolResponse content = %d bytes, %v, want %d", len(response.Content), err, len(content))
+	}
+
+	pre := `{"session_id": "s1", "hook_event_name": "PreToolUse", "tool_name": "Bash", "tool_input": {"command": "ls", "run_in_background": true}}`
+	preInput, err := DecodePreToolUseInput(strings.NewReader(pre), InputOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(preInput.Raw.ToolInput) != `{"command": "ls", "run_in_background": true}` || preInput.Raw.ToolResponse != nil {
+		t.Errorf("Raw = %+v", preInput.Raw)
+	}
+}
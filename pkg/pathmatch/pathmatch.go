@@ -0,0 +1,121 @@
+// Package pathmatch matches file paths against glob patterns the same way on
+// every platform. Hooks see paths written for the machine Claude Code runs on,
+// which may not be the one running the hook (a remote evaluation server, or
+// Git Bash on Windows), so Windows forms are understood everywhere:
+//
+//   - Backslashes separate elements, as in C:\Users\dev\project
+//   - Drive letters and UNC shares (\\server\share) make a path absolute
+//   - Windows paths compare case-insensitively, as NTFS does
+//
+// Paths are compared in a cleaned, slash-separated form, so "a/b/../c",
+// "a\c", and "a/c" are the same path. Unix paths stay case-sensitive, except
+// when the hook itself runs on Windows.
+package pathmatch
+
+import (
+	"path"
+	"runtime"
+	"strings"
+)
+
+// IsWindows reports whether p is written in Windows form: with a drive
+// letter, as a UNC path, or with backslash separators.
+func IsWindows(p string) bool {
+	return hasDrive(p) || strings.Contains(p, `\`)
+}
+
+// hasDrive reports whether p starts with a drive letter, as in C: or c:\.
+func hasDrive(p string) bool {
+	return len(p) >= 2 && p[1] == ':' && ('a' <= p[0] && p[0] <= 'z' || 'A' <= p[0] && p[0] <= 'Z')
+}
+
+// Clean returns the shortest slash-separated form of p: backslashes become
+// slashes, the drive letter is lowercased, and . and .. elements are
+// resolved lexically. A UNC path keeps its leading //.
+func Clean(p string) string {
+	if p == "" {
+		return ""
+	}
+	p = strings.ReplaceAll(p, `\`, "/")
+	if hasDrive(p) {
+		drive, rest := strings.ToLower(p[:2]), p[2:]
+		if rest == "" {
+			return drive
+		}
+		if cleaned := path.Clean(rest); cleaned != "." {
+			return drive + cleaned
+		}
+		return drive
+	}
+	if strings.HasPrefix(p, "//") && !strings.HasPrefix(p, "///") {
+		return "/" + path.Clean(p[1:]) // UNC share
+	}
+	return path.Clean(p)
+}
+
+// IsAbs reports whether p is absolute on Unix or Windows: /usr/bin, C:\Go,
+// C:/Go, or \\server\share.
+func IsAbs(p string) bool {
+	p = strings.ReplaceAll(p, `\`, "/")
+	return strings.HasPrefix(p, "/") || hasDrive(p) && strings.HasPrefix(p[2:], "/")
+}
+
+// Join resolves p against base, unless p is already absolute, and returns
+// the cleaned result.
+func Join(base, p string) string {
+	if IsAbs(p) || base == "" {
+		return Clean(p)
+	}
+	return Clean(strings.TrimRight(base, `/\`) + "/" + p)
+}
+
+// Base returns the last element of p, splitting on slashes and backslashes.
+func Base(p string) string {
+	return path.Base(Clean(p))
+}
+
+// Match reports whether name matches pattern. Both are cleaned first, and
+// pattern uses path.Match syntax, where * does not cross separators. A
+// trailing /** matches a directory and everything below it.
+func Match(pattern, name string) bool {
+	pattern, name = fold(pattern, name)
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return Within(dir, name)
+	}
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// Within reports whether p is root or a path below it.
+func Within(root, p string) bool {
+	root, p = fold(root, p)
+	return p == root || strings.HasPrefix(p, strings.TrimSuffix(root, "/")+"/")
+}
+
+// HasExt reports whether p's file name ends with one of exts, such as ".go".
+// Windows paths match extensions case-insensitively.
+func HasExt(p string, exts ...string) bool {
+	ext := path.Ext(Base(p))
+	for _, want := range exts {
+		if ext == want || foldCase(p) && strings.EqualFold(ext, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// fold returns a and b cleaned, and lowercased when either is a Windows path
+// or the hook runs on Windows.
+func fold(a, b string) (string, string) {
+	fold := foldCase(a) || foldCase(b)
+	a, b = Clean(a), Clean(b)
+	if fold {
+		return strings.ToLower(a), strings.ToLower(b)
+	}
+	return a, b
+}
+
+// foldCase reports whether p compares case-insensitively.
+func foldCase(p string) bool {
+	return runtime.GOOS == "windows" || IsWindows(p)
+}
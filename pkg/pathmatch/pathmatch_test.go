@@ -0,0 +1,135 @@
+package pathmatch
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/usr/local/../bin/", "/usr/bin"},
+		{"src/./main.go", "src/main.go"},
+		{`C:\Users\dev\project\..\other`, "c:/Users/dev/other"},
+		{"C:/Go/bin", "c:/Go/bin"},
+		{`C:\`, "c:/"},
+		{"D:", "d:"},
+		{"C:.hidden", "c:.hidden"},
+		{`\\server\share\dir\..\file`, "//server/share/file"},
+		{`src\main.go`, "src/main.go"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Clean(tt.path); got != tt.want {
+			t.Errorf("Clean(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsAbsAndJoin(t *testing.T) {
+	tests := []struct {
+		base, path string
+		abs        bool
+		want       string
+	}{
+		{"/repo", "/etc/passwd", true, "/etc/passwd"},
+		{"/repo", "src/../go.mod", false, "/repo/go.mod"},
+		{`C:\repo`, `C:\Windows\System32`, true, "c:/Windows/System32"},
+		{`C:\repo\`, `src\main.go`, false, "c:/repo/src/main.go"},
+		{`C:\repo`, "c:/Temp", true, "c:/Temp"},
+		{"/repo", `\\server\share`, true, "//server/share"},
+		{"/repo", "C:relative", false, "/repo/C:relative"},
+		{"", "main.go", false, "main.go"},
+	}
+	for _, tt := range tests {
+		if got := IsAbs(tt.path); got != tt.abs {
+			t.Errorf("IsAbs(%q) = %v, want %v", tt.path, got, tt.abs)
+		}
+		if got := Join(tt.base, tt.path); got != tt.want {
+			t.Errorf("Join(%q, %q) = %q, want %q", tt.base, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBase(t *testing.T) {
+	tests := map[string]string{
+		"/usr/bin/git":                     "git",
+		`C:\Program Files\Git\bin\git.exe`: "git.exe",
+		"git":                              "git",
+		`dir\`:                             "dir",
+	}
+	for path, want := range tests {
+		if got := Base(path); got != want {
+			t.Errorf("Base(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"/etc/**", "/etc/hosts", true},
+		{"/etc/**", "/etc", true},
+		{"/etc/**", "/etcetera/hosts", false},
+		{"/home/dev/.bashrc", "/home/dev/.bashrc", true},
+		{"/repo/secrets/*.env", "/repo/secrets/prod.env", true},
+		{"/repo/secrets/*.env", "/repo/secrets/nested/prod.env", false},
+		{"/repo/secrets/*.env", "/repo/other/../secrets/prod.env", true},
+		{`C:\repo\secrets\**`, `c:\REPO\Secrets\key.pem`, true},
+		{`C:\repo\secrets\**`, "C:/repo/secrets/key.pem", true},
+		{"c:/repo/*.env", `C:\Repo\PROD.ENV`, true},
+		{`C:\repo\**`, `D:\repo\file`, false},
+		{`\\server\share\**`, `\\SERVER\share\docs\a.txt`, true},
+		{"/Repo/**", "/repo/file", runtime.GOOS == "windows"},
+		{"[", "[", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		root, path string
+		want       bool
+	}{
+		{"/repo", "/repo", true},
+		{"/repo", "/repo/src/main.go", true},
+		{"/repo", "/repo-other/main.go", false},
+		{"/repo", "/repo/../etc", false},
+		{"/", "/etc", true},
+		{`C:\Repo`, `c:\repo\src`, true},
+		{`C:\`, `C:\Windows`, true},
+		{`C:\repo`, `D:\repo`, false},
+	}
+	for _, tt := range tests {
+		if got := Within(tt.root, tt.path); got != tt.want {
+			t.Errorf("Within(%q, %q) = %v, want %v", tt.root, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestHasExt(t *testing.T) {
+	tests := []struct {
+		path string
+		exts []string
+		want bool
+	}{
+		{"main.go", []string{".go"}, true},
+		{"main.GO", []string{".go"}, runtime.GOOS == "windows"},
+		{`C:\src\Main.GO`, []string{".js", ".go"}, true},
+		{`src.go\README`, []string{".go"}, false},
+		{"Makefile", []string{".go"}, false},
+	}
+	for _, tt := range tests {
+		if got := HasExt(tt.path, tt.exts...); got != tt.want {
+			t.Errorf("HasExt(%q, %q) = %v, want %v", tt.path, tt.exts, got, tt.want)
+		}
+	}
+}
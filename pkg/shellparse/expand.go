@@ -0,0 +1,232 @@
+// Package shellparse - parameter expansion against known variable values
+package shellparse
+
+import (
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// variableSetters are commands that can set variables named by their
+// arguments, or run text that does, so no value is certain around them.
+var variableSetters = []string{
+	"eval", "source", ".", "read", "unset", "mapfile", "readarray", "getopts", "let",
+	"declare", "typeset", "local", "export", "readonly",
+}
+
+// shellManagedVariables change as the script runs, e.g. PWD after cd, so a
+// snapshot of their value is never certain.
+var shellManagedVariables = []string{
+	"PWD", "OLDPWD", "RANDOM", "SRANDOM", "SECONDS", "LINENO", "REPLY", "OPTARG", "OPTIND",
+	"BASHPID", "EPOCHSECONDS", "EPOCHREALTIME", "PIPESTATUS", "_",
+}
+
+// arithmAssignOps are the arithmetic operators that assign, as in $((n += 1)).
+var arithmAssignOps = []syntax.BinAritOperator{
+	syntax.Assgn, syntax.AddAssgn, syntax.SubAssgn, syntax.MulAssgn, syntax.QuoAssgn, syntax.RemAssgn,
+	syntax.AndAssgn, syntax.OrAssgn, syntax.XorAssgn, syntax.ShlAssgn, syntax.ShrAssgn,
+}
+
+// paramValue returns the value of a simple parameter expansion, $NAME or
+// ${NAME}, when lookup knows it. Unquoted, a value containing whitespace or
+// glob characters would be split or globbed, and an empty value would remove
+// its word, so those are not resolved.
+func paramValue(param *syntax.ParamExp, lookup func(name string) (string, bool), quoted bool) (string, bool) {
+	simple := param.Param != nil && !param.Excl && !param.Length && !param.Width &&
+		param.Index == nil && param.Slice == nil && param.Repl == nil && param.Names == 0 && param.Exp == nil
+	if !simple {
+		return "", false
+	}
+	value, ok := lookup(param.Param.Value)
+	if !ok {
+		return "", false
+	}
+	if !quoted && (value == "" || strings.ContainsAny(value, " \t\n*?[")) {
+		return "", false
+	}
+	return value, true
+}
+
+// ResolveParams replaces the simple parameter expansions in node whose value
+// is certain with literals, so StaticWord, CallExprs, and the visitors see
+// "$HOME/bin/git push" as a static call. A value is certain when it comes
+// from a top-level assignment earlier in the script with a static value, as
+// in "GIT=git; $GIT push", or from env for a variable the script never sets.
+//
+// Nothing is resolved when the script may set variables in ways that cannot
+// be followed statically: eval, source, read, declare and export, arithmetic
+// assignments, ${NAME:=value}, or a command whose name stays dynamic. It
+// reports whether any expansion was resolved.
+func ResolveParams(node syntax.Node, env map[string]string) bool {
+	if setsVariablesIndirectly(node) {
+		return false
+	}
+	known := scriptAssignments(node, env)
+	lookupAt := func(pos syntax.Pos) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			if assigned, ok := known[name]; ok {
+				if !assigned.certain || assigned.end.Offset() > pos.Offset() {
+					return "", false
+				}
+				return assigned.value, true
+			}
+			return envValue(env, name)
+		}
+	}
+
+	// A command name that stays dynamic, or resolves to eval, could set any
+	// variable when it runs
+	safe := true
+	for _, call := range CallExprs(node) {
+		if len(call.Args) == 0 {
+			continue
+		}
+		if !callSetsNoVariables(call, lookupAt(call.Pos())) {
+			safe = false
+			break
+		}
+	}
+	if !safe {
+		return false
+	}
+
+	resolved := false
+	resolve := func(parts []syntax.WordPart, quoted bool) {
+		for i, part := range parts {
+			param, ok := part.(*syntax.ParamExp)
+			if !ok {
+				continue
+			}
+			if value, ok := paramValue(param, lookupAt(param.Pos()), quoted); ok {
+				parts[i] = &syntax.Lit{ValuePos: param.Pos(), ValueEnd: param.End(), Value: value}
+				resolved = true
+			}
+		}
+	}
+	syntax.Walk(node, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.Word:
+			resolve(n.Parts, false)
+		case *syntax.DblQuoted:
+			resolve(n.Parts, true)
+		}
+		return true
+	})
+	return resolved
+}
+
+// assignment is what a script does to one variable.
+type assignment struct {
+	value   string
+	end     syntax.Pos // End of the assigning statement
+	certain bool       // Assigned once, at the top level, to a static value
+}
+
+// scriptAssignments returns every variable the script sets. Only a variable
+// assigned exactly once, by a top-level statement of assignments alone, has
+// a certain value; its value may use env and earlier certain assignments.
+func scriptAssignments(node syntax.Node, env map[string]string) map[string]assignment {
+	counts := make(map[string]int)
+	syntax.Walk(node, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.Assign:
+			if n.Name != nil {
+				counts[n.Name.Value]++
+			}
+		case *syntax.WordIter:
+			counts[n.Name.Value]++
+		case *syntax.CoprocClause:
+			if n.Name != nil {
+				if name, ok := StaticWord(n.Name); ok {
+					counts[name]++
+				}
+			}
+		}
+		return true
+	})
+
+	assigned := make(map[string]assignment, len(counts))
+	for name := range counts {
+		assigned[name] = assignment{}
+	}
+	file, ok := node.(*syntax.File)
+	if !ok {
+		return assigned
+	}
+	lookup := func(name string) (string, bool) {
+		if a, ok := assigned[name]; ok {
+			return a.value, a.certain
+		}
+		return envValue(env, name)
+	}
+	for _, stmt := range file.Stmts {
+		call, ok := stmt.Cmd.(*syntax.CallExpr)
+		if !ok || len(call.Args) > 0 || stmt.Negated || stmt.Background || len(stmt.Redirs) > 0 {
+			continue
+		}
+		for _, assign := range call.Assigns {
+			name := assign.Name.Value
+			if counts[name] != 1 || assign.Append || assign.Naked || assign.Index != nil || assign.Array != nil {
+				continue
+			}
+			if value, isStatic := expandWord(assign.Value, lookup); isStatic {
+				assigned[name] = assignment{value: value, end: stmt.End(), certain: true}
+			}
+		}
+	}
+	return assigned
+}
+
+// envValue looks name up in env, except for variables the shell manages.
+func envValue(env map[string]string, name string) (string, bool) {
+	if slices.Contains(shellManagedVariables, name) {
+		return "", false
+	}
+	value, ok := env[name]
+	return value, ok
+}
+
+// callSetsNoVariables reports whether call certainly sets no variables of
+// the script: its name is static and neither it nor the builtin it runs
+// (command eval, builtin read) sets variables by name, as printf -v does.
+func callSetsNoVariables(call *syntax.CallExpr, lookup func(string) (string, bool)) bool {
+	args := make([]string, 0, len(call.Args))
+	for _, word := range call.Args {
+		arg, isStatic := expandWord(word, lookup)
+		if !isStatic && len(args) == 0 {
+			return false
+		}
+		args = append(args, arg)
+	}
+	for len(args) > 1 && (args[0] == "command" || args[0] == "builtin") && !strings.HasPrefix(args[1], "-") {
+		args = args[1:]
+	}
+	if args[0] == "command" || args[0] == "builtin" {
+		return len(args) == 1 || args[1] == "-v" || args[1] == "-V"
+	}
+	if args[0] == "printf" && slices.Contains(args[1:], "-v") {
+		return false
+	}
+	return !slices.Contains(variableSetters, args[0])
+}
+
+// setsVariablesIndirectly reports whether the script sets variables in a way
+// scriptAssignments cannot follow.
+func setsVariablesIndirectly(node syntax.Node) bool {
+	indirect := false
+	syntax.Walk(node, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.DeclClause, *syntax.LetClause, *syntax.ArithmCmd:
+			indirect = true
+		case *syntax.BinaryArithm:
+			indirect = indirect || slices.Contains(arithmAssignOps, n.Op)
+		case *syntax.UnaryArithm:
+			indirect = indirect || n.Op == syntax.Inc || n.Op == syntax.Dec
+		case *syntax.ParamExp:
+			indirect = indirect || n.Exp != nil && (n.Exp.Op == syntax.AssignUnset || n.Exp.Op == syntax.AssignUnsetOrNull)
+		}
+		return !indirect
+	})
+	return indirect
+}
@@ -0,0 +1,96 @@
+package shellparse
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpandWord(t *testing.T) {
+	env := map[string]string{"HOME": "/home/me", "EDITOR": "vim", "EMPTY": "", "SPACED": "git push"}
+	tests := []struct {
+		word       string
+		want       string
+		wantStatic bool
+	}{
+		{"$HOME/bin/git", "/home/me/bin/git", true},
+		{"${EDITOR}", "vim", true},
+		{`"$SPACED"`, "git push", true},
+		{`"$EMPTY"`, "", true},
+		{"$SPACED", "", false},
+		{"$EMPTY", "", false},
+		{"$UNSET", "", false},
+		{"${HOME:-/tmp}", "", false},
+		{"${#HOME}", "", false},
+	}
+	for _, tt := range tests {
+		node, err := Parse("echo " + tt.word)
+		if err != nil {
+			t.Fatalf("Parse() error: %v", err)
+		}
+		got, isStatic := ExpandWord(CallExprs(node)[0].Args[1], env)
+		if isStatic != tt.wantStatic || (isStatic && got != tt.want) {
+			t.Errorf("ExpandWord(%s) = %q, %v, want %q, %v", tt.word, got, isStatic, tt.want, tt.wantStatic)
+		}
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	env := map[string]string{"HOME": "/home/me", "EDITOR": "vim", "PWD": "/src"}
+	tests := []struct {
+		name string
+		expr string
+		want []string // Printed calls after resolution
+	}{
+		{"environment", "$HOME/bin/git push", []string{"/home/me/bin/git push"}},
+		{"quoted", `$EDITOR "$HOME/notes.md"`, []string{`vim "/home/me/notes.md"`}},
+		{"earlier assignment", "GIT=git; $GIT push", []string{"GIT=git", "git push"}},
+		{"assignment from environment", "BIN=$HOME/bin; $BIN/git push", []string{"BIN=/home/me/bin", "/home/me/bin/git push"}},
+		{"use before assignment", "$GIT push; GIT=git", []string{"$GIT push", "GIT=git"}},
+		{"assigned twice", "GIT=git; GIT=ls; $GIT push", []string{"GIT=git", "GIT=ls", "$GIT push"}},
+		{"assignment in subshell", "(GIT=git); $GIT push", []string{"GIT=git", "$GIT push"}},
+		{"assignment overrides environment", "(HOME=/tmp); $HOME/bin/git", []string{"HOME=/tmp", "$HOME/bin/git"}},
+		{"loop variable", "for HOME in a b; do ls $HOME; done", []string{"ls $HOME"}},
+		{"shell-managed variable", "cd /tmp && ls $PWD", []string{"cd /tmp", "ls $PWD"}},
+		{"dynamic assignment", "GIT=$(which git); $GIT push", []string{"GIT=$(which git)", "which git", "$GIT push"}},
+		{"eval", `GIT=ls; eval "GIT=git"; $GIT push`, []string{"GIT=ls", `eval "GIT=git"`, "$GIT push"}},
+		{"read", "read GIT; $EDITOR x", []string{"read GIT", "$EDITOR x"}},
+		{"printf -v", "printf -v GIT git; $EDITOR x", []string{"printf -v GIT git", "$EDITOR x"}},
+		{"printf", "printf '%s' x; $EDITOR x", []string{"printf '%s' x", "vim x"}},
+		{"command eval", "command eval X=1; $EDITOR x", []string{"command eval X=1", "$EDITOR x"}},
+		{"command -v", "command -v git; $EDITOR x", []string{"command -v git", "vim x"}},
+		{"export", "export GIT=git; $GIT push", []string{"$GIT push"}},
+		{"arithmetic assignment", "echo $((HOME=1)); $EDITOR x", []string{"echo $((HOME = 1))", "$EDITOR x"}},
+		{"default assignment", "echo ${HOME:=x}; $EDITOR x", []string{"echo ${HOME:=x}", "$EDITOR x"}},
+		{"dynamic command name", "$CMD; $EDITOR x", []string{"$CMD", "$EDITOR x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			ResolveParams(node, env)
+			var got []string
+			for _, call := range CallExprs(node) {
+				got = append(got, Print(call))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ResolveParams(%q) calls = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveParams_Static(t *testing.T) {
+	node, err := Parse("$HOME/bin/git push")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if !ResolveParams(node, map[string]string{"HOME": "/home/me"}) {
+		t.Fatal("ResolveParams() = false, want true")
+	}
+	call := CallExprs(node)[0]
+	if args, ok := StaticArgs(call); !ok || !slices.Equal(args, []string{"/home/me/bin/git", "push"}) {
+		t.Errorf("StaticArgs() = %q, %v, want resolved static args", args, ok)
+	}
+}
@@ -0,0 +1,117 @@
+// Package shellparse - guard against mvdan.cc/sh syntax drift
+package shellparse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// SyntaxModule is the module path of the shell parser.
+const SyntaxModule = "mvdan.cc/sh/v3"
+
+// SyntaxVersion is the mvdan.cc/sh release nodeTypes was reviewed against.
+// Upgrading the parser fails TestSyntaxVersion until both are updated, after
+// checking every switch over syntax types for the new release's additions.
+const SyntaxVersion = "v3.12.0"
+
+// ErrUnsupportedSyntax is wrapped by the error Parse returns for a command
+// whose AST contains a node type this package was not written for. Callers
+// treat it like any parse failure, so such commands are handled by the fail
+// mode (blocked by default) instead of being silently misclassified.
+var ErrUnsupportedSyntax = errors.New("unsupported shell syntax")
+
+// nodeTypes are the syntax.Node implementations of SyntaxVersion. The list
+// stops compiling when an upgrade removes or renames one, and the
+// exhaustiveness test fails when one is added.
+var nodeTypes = []syntax.Node{
+	(*syntax.ArithmCmd)(nil),
+	(*syntax.ArithmExp)(nil),
+	(*syntax.ArrayElem)(nil),
+	(*syntax.ArrayExpr)(nil),
+	(*syntax.Assign)(nil),
+	(*syntax.BinaryArithm)(nil),
+	(*syntax.BinaryCmd)(nil),
+	(*syntax.BinaryTest)(nil),
+	(*syntax.Block)(nil),
+	(*syntax.BraceExp)(nil),
+	(*syntax.CStyleLoop)(nil),
+	(*syntax.CallExpr)(nil),
+	(*syntax.CaseClause)(nil),
+	(*syntax.CaseItem)(nil),
+	(*syntax.CmdSubst)(nil),
+	(*syntax.Comment)(nil),
+	(*syntax.CoprocClause)(nil),
+	(*syntax.DblQuoted)(nil),
+	(*syntax.DeclClause)(nil),
+	(*syntax.ExtGlob)(nil),
+	(*syntax.File)(nil),
+	(*syntax.ForClause)(nil),
+	(*syntax.FuncDecl)(nil),
+	(*syntax.IfClause)(nil),
+	(*syntax.LetClause)(nil),
+	(*syntax.Lit)(nil),
+	(*syntax.ParamExp)(nil),
+	(*syntax.ParenArithm)(nil),
+	(*syntax.ParenTest)(nil),
+	(*syntax.ProcSubst)(nil),
+	(*syntax.Redirect)(nil),
+	(*syntax.SglQuoted)(nil),
+	(*syntax.Stmt)(nil),
+	(*syntax.Subshell)(nil),
+	(*syntax.TestClause)(nil),
+	(*syntax.TestDecl)(nil),
+	(*syntax.TimeClause)(nil),
+	(*syntax.UnaryArithm)(nil),
+	(*syntax.UnaryTest)(nil),
+	(*syntax.WhileClause)(nil),
+	(*syntax.Word)(nil),
+	(*syntax.WordIter)(nil),
+}
+
+// knownNodeTypes indexes nodeTypes for checkNodes.
+var knownNodeTypes = func() map[reflect.Type]bool {
+	known := make(map[reflect.Type]bool, len(nodeTypes))
+	for _, node := range nodeTypes {
+		known[reflect.TypeOf(node)] = true
+	}
+	return known
+}()
+
+// checkNodes returns an error wrapping ErrUnsupportedSyntax for the first
+// node in the AST whose type is not in nodeTypes.
+func checkNodes(node syntax.Node) error {
+	var err error
+	syntax.Walk(node, func(n syntax.Node) bool {
+		if err != nil || n == nil {
+			return false
+		}
+		if !knownNodeTypes[reflect.TypeOf(n)] {
+			err = fmt.Errorf("%w: %T at %s (parser %s, reviewed for %s)", ErrUnsupportedSyntax, n, n.Pos(), LinkedSyntaxVersion(), SyntaxVersion)
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// LinkedSyntaxVersion returns the mvdan.cc/sh version linked into the running
+// binary, or "unknown" when the build carries no module information.
+func LinkedSyntaxVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == SyntaxModule {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,172 @@
+package shellparse
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// nodeTypeNames returns the names of nodeTypes, e.g. CallExpr.
+func nodeTypeNames() []string {
+	names := make([]string, 0, len(nodeTypes))
+	for _, node := range nodeTypes {
+		names = append(names, reflect.TypeOf(node).Elem().Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TestNodeTypes_Exhaustive fails when the linked mvdan.cc/sh declares a node
+// type (anything with an End() Pos method) that nodeTypes does not list, so
+// an upgrade cannot add syntax the detector silently misreads.
+func TestNodeTypes_Exhaustive(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go tool not installed")
+	}
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", SyntaxModule+"/syntax").Output()
+	if err != nil {
+		t.Skipf("locating %s/syntax source: %v", SyntaxModule, err)
+	}
+	dir := strings.TrimSpace(string(out))
+	packages, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var declared []string
+	for _, file := range packages["syntax"].Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "End" || len(fn.Recv.List) != 1 {
+				continue
+			}
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if ident, ok := recv.(*ast.Ident); ok && ident.IsExported() && !slices.Contains(declared, ident.Name) {
+				declared = append(declared, ident.Name)
+			}
+		}
+	}
+	slices.Sort(declared)
+	if known := nodeTypeNames(); !slices.Equal(declared, known) {
+		t.Errorf("syntax node types changed; review every switch over syntax types and update nodeTypes and SyntaxVersion\n declared: %v\n known:    %v", declared, known)
+	}
+}
+
+// TestNodeTypes_Probe parses a construct producing each node type, so a
+// parser that stops producing one, or produces another for the same source,
+// is noticed.
+func TestNodeTypes_Probe(t *testing.T) {
+	probes := []struct {
+		lang syntax.LangVariant
+		src  string
+	}{
+		{syntax.LangBash, "((x++)); echo $(( (1) + 2 )) \"$y\" 'z' >out"},
+		{syntax.LangBash, "a=(x [1]=y); local b=1; let c=1"},
+		{syntax.LangBash, "a && b | c; { d; }; (e); time f; coproc g"},
+		{syntax.LangBash, "[[ -n x && ( a == b ) ]]; echo @(a|b) $(h) <(i)"},
+		{syntax.LangBash, "for ((i=0; i<1; i++)); do :; done; for j in k; do :; done"},
+		{syntax.LangBash, "case x in a) b;; esac; f() { :; }; if a; then b; fi; while a; do b; done"},
+		{syntax.LangBats, "@test \"probe\" { :; }"},
+	}
+	seen := make(map[string]bool)
+	record := func(node syntax.Node) {
+		syntax.Walk(node, func(n syntax.Node) bool {
+			if n != nil {
+				seen[reflect.TypeOf(n).Elem().Name()] = true
+			}
+			return true
+		})
+	}
+	for _, probe := range probes {
+		node, err := ParseVariant(probe.src, probe.lang)
+		if err != nil {
+			t.Fatalf("ParseVariant(%q) error: %v", probe.src, err)
+		}
+		record(node)
+	}
+	// Comments are only kept on request, and brace expansions only appear
+	// after SplitBraces; Parse produces neither.
+	commented, err := syntax.NewParser(syntax.KeepComments(true)).Parse(strings.NewReader("# note"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record(commented)
+	braces := &syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{Value: "{a,b}"}}}
+	syntax.SplitBraces(braces)
+	for _, part := range braces.Parts { // Walk does not descend into BraceExp
+		seen[reflect.TypeOf(part).Elem().Name()] = true
+	}
+
+	var missing []string
+	for _, name := range nodeTypeNames() {
+		if !seen[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		t.Errorf("probes produced no %v nodes", missing)
+	}
+}
+
+func TestCheckNodes(t *testing.T) {
+	node, err := Parse("git push && echo $(date)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkNodes(node); err != nil {
+		t.Errorf("checkNodes() error: %v", err)
+	}
+
+	// A node type the table does not know, standing in for one a future
+	// parser release adds
+	defer func(known map[reflect.Type]bool) { knownNodeTypes = known }(knownNodeTypes)
+	knownNodeTypes = map[reflect.Type]bool{}
+	for _, n := range nodeTypes {
+		if _, isSubst := n.(*syntax.CmdSubst); !isSubst {
+			knownNodeTypes[reflect.TypeOf(n)] = true
+		}
+	}
+	err = checkNodes(node)
+	if !errors.Is(err, ErrUnsupportedSyntax) || !strings.Contains(err.Error(), "*syntax.CmdSubst at 1:18") {
+		t.Errorf("checkNodes() = %v, want ErrUnsupportedSyntax for *syntax.CmdSubst", err)
+	}
+	if _, err := Parse("echo $(date)"); !errors.Is(err, ErrUnsupportedSyntax) || !errors.Is(err, ErrParse) {
+		t.Errorf("Parse() = %v, want ErrUnsupportedSyntax and ErrParse", err)
+	}
+}
+
+// TestSyntaxVersion fails when go.mod moves to another mvdan.cc/sh release
+// without nodeTypes being reviewed for it.
+func TestSyntaxVersion(t *testing.T) {
+	data, err := os.ReadFile("../../go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var required string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "require "))
+		if len(fields) >= 2 && fields[0] == SyntaxModule {
+			required = fields[1]
+		}
+	}
+	if required != SyntaxVersion {
+		t.Errorf("go.mod requires %s %s, but nodeTypes was reviewed for %s", SyntaxModule, required, SyntaxVersion)
+	}
+	if linked := LinkedSyntaxVersion(); linked != "unknown" && linked != SyntaxVersion {
+		t.Errorf("LinkedSyntaxVersion() = %s, want %s", linked, SyntaxVersion)
+	}
+}
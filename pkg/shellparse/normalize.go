@@ -0,0 +1,259 @@
+// Package shellparse - canonical forms of commands for matching
+package shellparse
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// NormalizeCall returns call without the wrappers that only run the rest of
+// it as a command: command (and -p), builtin, exec with its -c, -l, and -a
+// NAME options, and env with its -i, -u NAME, and -C DIR options and NAME=VALUE
+// assignments. The string of env -S is split into the words it stands for.
+// Backslash escapes in the command name, which only skip alias expansion as
+// in \git, are removed. So "command env -i GIT_DIR=x \git push" and
+// env -S 'git push' are analyzed as "git push". Wrappers that describe a
+// command instead of running it, as in command -v git, or whose options are
+// dynamic or unknown, as in env -S "$CMD", are kept. call is not modified;
+// the result shares its words.
+func NormalizeCall(call *syntax.CallExpr) *syntax.CallExpr {
+	args := call.Args
+	for len(args) > 0 {
+		rest := stripWrapper(args)
+		if rest[0] == args[0] {
+			break
+		}
+		args = rest
+	}
+	if len(args) > 0 {
+		if unescaped, ok := unescapeCommandName(args[0]); ok {
+			args = append([]*syntax.Word{unescaped}, args[1:]...)
+		}
+	}
+	if len(args) == len(call.Args) && (len(args) == 0 || args[0] == call.Args[0]) {
+		return call
+	}
+	return &syntax.CallExpr{Assigns: call.Assigns, Args: args}
+}
+
+// commandName returns the name a command word runs, with backslash escapes
+// removed, and whether it is static.
+func commandName(word *syntax.Word) (string, bool) {
+	if unescaped, ok := unescapeCommandName(word); ok {
+		word = unescaped
+	}
+	return StaticWord(word)
+}
+
+// unescapeCommandName returns word as a plain token when it is a single
+// unquoted literal with backslash escapes, such as \git, whose value needs no
+// quoting. StaticWord keeps such escapes verbatim.
+func unescapeCommandName(word *syntax.Word) (*syntax.Word, bool) {
+	if len(word.Parts) != 1 {
+		return nil, false
+	}
+	lit, ok := word.Parts[0].(*syntax.Lit)
+	if !ok || !strings.Contains(lit.Value, `\`) {
+		return nil, false
+	}
+	var sb strings.Builder
+	for i := 0; i < len(lit.Value); i++ {
+		if lit.Value[i] == '\\' && i+1 < len(lit.Value) {
+			i++
+		}
+		sb.WriteByte(lit.Value[i])
+	}
+	value := sb.String()
+	if quoted, err := syntax.Quote(value, syntax.LangBash); err != nil || quoted != value {
+		return nil, false // Would change meaning as a plain token, as in \*
+	}
+	return &syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{ValuePos: lit.ValuePos, ValueEnd: lit.ValueEnd, Value: value}}}, true
+}
+
+// stripWrapper returns the command a wrapper runs, or args if args[0] is not
+// a wrapper NormalizeCall removes or it runs no command.
+func stripWrapper(args []*syntax.Word) []*syntax.Word {
+	name, isStatic := commandName(args[0])
+	if !isStatic {
+		return args
+	}
+
+	var next int
+	var ok bool
+	switch {
+	case name == "command":
+		next, ok = skipOptions(args, func(opt string) (int, bool) {
+			return 0, strings.Trim(opt, "-p") == "" // -v and -V describe the command
+		})
+	case name == "builtin":
+		next, ok = skipOptions(args, func(string) (int, bool) { return 0, false })
+	case name == "exec":
+		next, ok = skipOptions(args, func(opt string) (int, bool) {
+			flags := strings.TrimPrefix(opt, "-")
+			if strings.Trim(flags, "cl") == "" {
+				return 0, true
+			}
+			if strings.Trim(flags, "cl") == "a" && strings.HasSuffix(flags, "a") {
+				return 1, true // -a NAME sets argv[0]
+			}
+			return 0, false
+		})
+	case path.Base(name) == "env":
+		if split, next, ok := skipEnvOptions(args); ok && next < len(split) {
+			return split[next:]
+		}
+		return args
+	}
+	if !ok || next >= len(args) {
+		return args
+	}
+	return args[next:]
+}
+
+// skipOptions returns the index of the first operand after args[0] and its
+// options. option reports whether an option is allowed and how many values
+// follow it. It reports false for dynamic or disallowed options.
+func skipOptions(args []*syntax.Word, option func(opt string) (values int, ok bool)) (int, bool) {
+	i := 1
+	for i < len(args) {
+		arg, isStatic := StaticWord(args[i])
+		switch {
+		case !isStatic:
+			return 0, false
+		case arg == "--":
+			return i + 1, true
+		case !strings.HasPrefix(arg, "-") || arg == "-":
+			return i, true
+		}
+		values, ok := option(arg)
+		if !ok {
+			return 0, false
+		}
+		i += 1 + values
+	}
+	return i, true
+}
+
+// skipEnvOptions returns the index of the command env runs, after its options
+// and NAME=VALUE assignments, in args with the string of every -S split into
+// its words in place, as env does. It reports false for options other than
+// those that only change the environment or directory, and for -S strings
+// that are not plain words.
+func skipEnvOptions(args []*syntax.Word) ([]*syntax.Word, int, bool) {
+	i := 1
+	for i < len(args) {
+		arg, isStatic := StaticWord(args[i])
+		switch {
+		case !isStatic:
+			return nil, 0, false
+		case arg == "--":
+			return args, i + 1, true
+		case arg == "-S" || arg == "--split-string":
+			if i+1 >= len(args) {
+				return nil, 0, false
+			}
+			value, isStatic := StaticWord(args[i+1])
+			words, ok := splitString(value)
+			if !isStatic || !ok {
+				return nil, 0, false
+			}
+			args = slices.Concat(args[:i], words, args[i+2:]) // The words may be options
+		case strings.HasPrefix(arg, "-S") || strings.HasPrefix(arg, "--split-string="):
+			words, ok := splitString(strings.TrimPrefix(strings.TrimPrefix(arg, "--split-string="), "-S"))
+			if !ok {
+				return nil, 0, false
+			}
+			args = slices.Concat(args[:i], words, args[i+1:])
+		case arg == "-" || arg == "-i" || arg == "--ignore-environment" || arg == "-0" || arg == "--null":
+			i++
+		case arg == "-u" || arg == "-C" || arg == "--unset" || arg == "--chdir":
+			i += 2
+		case strings.HasPrefix(arg, "--unset=") || strings.HasPrefix(arg, "--chdir="),
+			len(arg) > 2 && (strings.HasPrefix(arg, "-u") || strings.HasPrefix(arg, "-C")):
+			i++
+		case strings.HasPrefix(arg, "-"):
+			return nil, 0, false
+		case strings.Contains(arg, "="):
+			i++ // NAME=VALUE
+		default:
+			return args, i, true
+		}
+	}
+	return args, i, true
+}
+
+// splitString returns the words env -S splits value into: a single simple
+// command of static words, read with shell quoting. Anything else, such as
+// ${VAR}, which env expands, or operators, which it passes on as words,
+// reports false.
+func splitString(value string) ([]*syntax.Word, bool) {
+	node, err := Parse(value)
+	if err != nil {
+		return nil, false
+	}
+	file, ok := node.(*syntax.File)
+	if !ok || len(file.Stmts) != 1 {
+		return nil, false
+	}
+	stmt := file.Stmts[0]
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(stmt.Redirs) > 0 || stmt.Negated || stmt.Background || stmt.Coprocess || len(call.Assigns) > 0 {
+		return nil, false
+	}
+	for _, word := range call.Args {
+		if _, isStatic := StaticWord(word); !isStatic {
+			return nil, false
+		}
+	}
+	return call.Args, true
+}
+
+// Normalize returns the canonical form of a shell expression, the form rules
+// are matched against: wrappers are removed from every call as by
+// NormalizeCall, words assembled from quoted pieces are joined into one token
+// (g"i"'t' becomes git), and whitespace is collapsed. It lets rule authors see
+// what the detector sees.
+func Normalize(shellExpr string, lang syntax.LangVariant) (string, error) {
+	node, err := ParseVariant(shellExpr, lang)
+	if err != nil {
+		return "", err
+	}
+	syntax.Walk(node, func(n syntax.Node) bool {
+		if call, ok := n.(*syntax.CallExpr); ok && len(call.Args) > 0 {
+			call.Args = NormalizeCall(call).Args
+			for i, word := range call.Args {
+				call.Args[i] = canonicalWord(word, lang)
+			}
+		}
+		return true
+	})
+	return Print(node), nil
+}
+
+// canonicalWord returns a static word assembled from quoted pieces as a
+// single token, quoted only where needed. Other words are returned as is,
+// including those with backslash escapes, which StaticWord keeps verbatim,
+// and those with unquoted glob or brace characters, which quoting would turn
+// into literals.
+func canonicalWord(word *syntax.Word, lang syntax.LangVariant) *syntax.Word {
+	value, isStatic := StaticWord(word)
+	if !isStatic || strings.Contains(Print(word), `\`) {
+		return word
+	}
+	if _, ok := word.Parts[0].(*syntax.Lit); ok && len(word.Parts) == 1 {
+		return word // Already a plain token
+	}
+	for _, part := range word.Parts {
+		if lit, ok := part.(*syntax.Lit); ok && strings.ContainsAny(lit.Value, "*?[{") {
+			return word
+		}
+	}
+	quoted, err := syntax.Quote(value, lang)
+	if err != nil {
+		return word
+	}
+	return &syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{Value: quoted}}}
+}
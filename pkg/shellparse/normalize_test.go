@@ -0,0 +1,89 @@
+package shellparse
+
+import (
+	"testing"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestNormalizeCall(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"git push", "git push"},
+		{"command git push", "git push"},
+		{"command -p git push", "git push"},
+		{"builtin cd /tmp", "cd /tmp"},
+		{"exec git push", "git push"},
+		{"exec -a deploy -cl git push", "git push"},
+		{"env -i git push", "git push"},
+		{"/usr/bin/env -u HOME -C /tmp GIT_DIR=x git push", "git push"},
+		{"env --unset=HOME --chdir=/tmp -- git push", "git push"},
+		{"command env -i exec git push", "git push"},
+		{`\git push`, "git push"},
+		{`\command \e\n\v -i git push`, "git push"},
+		{`command \git push`, "git push"},
+		{`\* push`, `\* push`},
+		{`g\ it push`, `g\ it push`},
+		{"command -v git", "command -v git"},
+		{"env -S 'git push'", "git push"},
+		{"env -S'git push' --force", "git push --force"},
+		{`env --split-string="-i GIT_DIR=x git" push`, "git push"},
+		{"env -S 'command -p git push'", "git push"},
+		{`env -S "$CMD"`, `env -S "$CMD"`},
+		{"env -S 'git push ${REMOTE}'", "env -S 'git push ${REMOTE}'"},
+		{"env -S 'git push; rm -rf /'", "env -S 'git push; rm -rf /'"},
+		{"env -S", "env -S"},
+		{"env $OPTS git push", "env $OPTS git push"},
+		{"env", "env"},
+		{"env -i", "env -i"},
+		{"exec 3>&1", "exec 3>&1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			node, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			call := CallExprs(node)[0]
+			normalized := NormalizeCall(call)
+			got := Print(normalized)
+			if normalized == call {
+				got = Print(node) // Keep redirects in the output
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeCall(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"git   push\torigin", "git push origin"},
+		{`g"i"'t' "push"`, "git push"},
+		{`gi\t pu\sh`, `git pu\sh`},
+		{`command  env -i "git" push && echo 'a b'`, "git push && echo 'a b'"},
+		{`sh -c "command git push"`, `sh -c 'command git push'`},
+		{"ls *.go '*.md'", "ls *.go '*.md'"},
+		{"echo $HOME", "echo $HOME"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Normalize(tt.expr, syntax.LangBash)
+			if err != nil {
+				t.Fatalf("Normalize() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+	if _, err := Normalize("git push (", syntax.LangBash); err == nil {
+		t.Error("Normalize() of an invalid expression should fail")
+	}
+}
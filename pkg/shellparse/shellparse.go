@@ -0,0 +1,386 @@
+// Package shellparse provides shell parsing utilities shared by the detector
+// and hooks that need to inspect command arguments.
+package shellparse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ErrParse is wrapped by every error Parse returns for a command it cannot
+// parse, whether the syntax is invalid or unsupported.
+var ErrParse = errors.New("failed to parse shell expression")
+
+// Parse parses a shell expression into an Abstract Syntax Tree.
+// The input shellExpr can be a simple command ("ls -la") or a complex expression
+// with pipes, conditionals, loops, and subshells ("cd /tmp && git pull || echo failed").
+// Returns the AST root node which can be traversed to extract various elements
+// like command calls, redirections, variables, etc. An AST containing a node
+// type this package was not reviewed for fails with ErrUnsupportedSyntax.
+func Parse(shellExpr string) (syntax.Node, error) {
+	return ParseVariant(shellExpr, syntax.LangBash)
+}
+
+// Dialects are the shell languages ParseDialect accepts, default first.
+var Dialects = []string{"bash", "posix", "mksh", "bats"}
+
+// ParseDialect returns the shell language named by name, one of Dialects;
+// "sh" is an alias for posix.
+func ParseDialect(name string) (syntax.LangVariant, error) {
+	var lang syntax.LangVariant
+	if err := lang.Set(name); err != nil || lang == syntax.LangAuto {
+		return syntax.LangBash, fmt.Errorf("unknown shell dialect '%s' (want one of %s)", name, strings.Join(Dialects, ", "))
+	}
+	return lang, nil
+}
+
+// ParseVariant is Parse for a shell language other than bash, e.g. posix
+// for scripts that use words bash reserves, or mksh for its own syntax.
+func ParseVariant(shellExpr string, lang syntax.LangVariant) (syntax.Node, error) {
+	parser := syntax.NewParser(syntax.Variant(lang))
+	node, err := parser.Parse(strings.NewReader(shellExpr), "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParse, err)
+	}
+	if err := checkNodes(node); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParse, err)
+	}
+	return node, nil
+}
+
+// ParseContext is Parse that returns ctx's error as soon as ctx is done,
+// for callers that bound how long a command may take to analyze.
+func ParseContext(ctx context.Context, shellExpr string) (syntax.Node, error) {
+	return ParseVariantContext(ctx, shellExpr, syntax.LangBash)
+}
+
+// ParseVariantContext is ParseVariant that returns ctx's error as soon as ctx
+// is done. The parser itself cannot be interrupted, so an abandoned parse
+// finishes in the background and its result is discarded.
+func ParseVariantContext(ctx context.Context, shellExpr string, lang syntax.LangVariant) (syntax.Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if ctx.Done() == nil {
+		return ParseVariant(shellExpr, lang)
+	}
+
+	type result struct {
+		node syntax.Node
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		node, err := ParseVariant(shellExpr, lang)
+		done <- result{node, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.node, r.err
+	}
+}
+
+// CallExprs walks the AST and collects all command call expressions.
+// These represent actual command invocations (e.g., "git push", "echo hello").
+// The traversal is depth-first, capturing commands in nested structures like
+// subshells, conditionals, and loops.
+func CallExprs(node syntax.Node) []*syntax.CallExpr {
+	var calls []*syntax.CallExpr
+	syntax.Walk(node, func(n syntax.Node) bool {
+		if call, ok := n.(*syntax.CallExpr); ok {
+			calls = append(calls, call)
+		}
+		return true // Continue traversing into child nodes
+	})
+	return calls
+}
+
+// StaticWord attempts to resolve a word into a static string.
+// It returns the resolved string and a boolean indicating if the resolution is complete
+// (i.e., the word contained no dynamic parts like variables or command substitutions).
+func StaticWord(word *syntax.Word) (val string, isStatic bool) {
+	return ExpandWord(word, nil)
+}
+
+// ExpandWord is StaticWord with simple parameter expansions ($NAME and
+// ${NAME}) of the variables in env resolved. An unquoted value that would be
+// split into several words or globbed, or that is empty and would remove the
+// word, keeps the word dynamic.
+func ExpandWord(word *syntax.Word, env map[string]string) (val string, isStatic bool) {
+	return expandWord(word, func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+}
+
+// expandWord resolves word, looking up the values of parameter expansions
+// with lookup.
+func expandWord(word *syntax.Word, lookup func(name string) (string, bool)) (val string, isStatic bool) {
+	if word == nil {
+		return "", true
+	}
+
+	var sb strings.Builder
+	isStatic = true
+
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			if !p.Dollar {
+				sb.WriteString(p.Value)
+				break
+			}
+			// ANSI-C quoting ($'\x67it') is static once its escapes are decoded
+			decoded, ok := DecodeANSIC(p)
+			if !ok {
+				isStatic = false
+			}
+			sb.WriteString(decoded)
+		case *syntax.DblQuoted:
+			// Handle parts inside double quotes
+			for _, subPart := range p.Parts {
+				switch sp := subPart.(type) {
+				case *syntax.Lit:
+					sb.WriteString(sp.Value)
+				case *syntax.ParamExp:
+					if value, ok := paramValue(sp, lookup, true); ok {
+						sb.WriteString(value)
+						break
+					}
+					// Variable expansion makes it dynamic
+					isStatic = false
+					// For partial resolution, we could try to handle simple cases
+					// but for safety, we'll mark it as dynamic
+				case *syntax.CmdSubst:
+					// Command substitution makes it dynamic
+					isStatic = false
+				case *syntax.ArithmExp:
+					// Arithmetic expansion makes it dynamic
+					isStatic = false
+				default:
+					// Any other dynamic element
+					isStatic = false
+				}
+			}
+		case *syntax.ParamExp:
+			if value, ok := paramValue(p, lookup, false); ok {
+				sb.WriteString(value)
+				break
+			}
+			// Variable expansion outside quotes
+			isStatic = false
+		case *syntax.CmdSubst:
+			// Command substitution outside quotes
+			isStatic = false
+		case *syntax.ArithmExp:
+			// Arithmetic expansion outside quotes
+			isStatic = false
+		case *syntax.ProcSubst:
+			// Process substitution
+			isStatic = false
+		default:
+			// Any other dynamic element
+			isStatic = false
+		}
+	}
+
+	return sb.String(), isStatic
+}
+
+// StaticArgs resolves every word of a call. The boolean is false if any word
+// is dynamic; dynamic words are returned with their static parts only.
+func StaticArgs(call *syntax.CallExpr) ([]string, bool) {
+	args := make([]string, 0, len(call.Args))
+	allStatic := true
+	for _, word := range call.Args {
+		arg, isStatic := StaticWord(word)
+		allStatic = allStatic && isStatic
+		args = append(args, arg)
+	}
+	return args, allStatic
+}
+
+// Substitution kinds reported by SubstitutedCalls.
+const (
+	CommandSubstitution = "command substitution" // $(...) or `...`
+	ProcessSubstitution = "process substitution" // <(...) or >(...)
+)
+
+// SubstitutedCalls maps the command calls that run inside a command or
+// process substitution anywhere in the AST to the kind of the innermost
+// substitution. These execute while the enclosing command's arguments are
+// expanded, whatever the enclosing command does with their output.
+func SubstitutedCalls(node syntax.Node) map[*syntax.CallExpr]string {
+	calls := make(map[*syntax.CallExpr]string)
+	mark := func(stmts []*syntax.Stmt, kind string) {
+		for _, stmt := range stmts {
+			for _, call := range CallExprs(stmt) {
+				calls[call] = kind
+			}
+		}
+	}
+	// Walk visits outer substitutions first, so inner ones overwrite the kind
+	syntax.Walk(node, func(n syntax.Node) bool {
+		switch subst := n.(type) {
+		case *syntax.CmdSubst:
+			mark(subst.Stmts, CommandSubstitution)
+		case *syntax.ProcSubst:
+			mark(subst.Stmts, ProcessSubstitution)
+		}
+		return true
+	})
+	return calls
+}
+
+// Redirects returns every redirection in the AST, including those inside
+// substitutions and nested statements.
+func Redirects(node syntax.Node) []*syntax.Redirect {
+	var redirects []*syntax.Redirect
+	syntax.Walk(node, func(n syntax.Node) bool {
+		if redirect, ok := n.(*syntax.Redirect); ok {
+			redirects = append(redirects, redirect)
+		}
+		return true
+	})
+	return redirects
+}
+
+// Segment is one top-level command of a list such as "a && b || c; d",
+// together with the operator that joins it to the next segment ("&&", "||",
+// or ";"; empty for the last). A pipeline is a single segment.
+type Segment struct {
+	Command string
+	Op      string
+}
+
+// Segments splits a parsed shell expression at its top-level statement
+// boundaries: newlines, ";", "&&", and "||". Lists nested inside subshells,
+// blocks, or substitutions are part of the segment that contains them.
+func Segments(node syntax.Node) []Segment {
+	file, ok := node.(*syntax.File)
+	if !ok {
+		return nil
+	}
+	var segments []Segment
+	var split func(stmt *syntax.Stmt, op string)
+	split = func(stmt *syntax.Stmt, op string) {
+		binary, ok := stmt.Cmd.(*syntax.BinaryCmd)
+		isList := ok && (binary.Op == syntax.AndStmt || binary.Op == syntax.OrStmt)
+		if !isList || stmt.Negated || stmt.Background || len(stmt.Redirs) > 0 {
+			segments = append(segments, Segment{Command: Print(stmt), Op: op})
+			return
+		}
+		split(binary.X, binary.Op.String())
+		split(binary.Y, op)
+	}
+	for i, stmt := range file.Stmts {
+		op := ";"
+		if i == len(file.Stmts)-1 {
+			op = ""
+		}
+		split(stmt, op)
+	}
+	return segments
+}
+
+// Print renders a node back to shell source, e.g. a call or a word containing
+// a substitution, for use in messages.
+func Print(node syntax.Node) string {
+	var sb strings.Builder
+	if err := syntax.NewPrinter(syntax.SingleLine(true)).Print(&sb, node); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// DecodeANSIC decodes the escape sequences of an ANSI-C quoted string
+// ($'...'). It reports false if the string cannot be decoded.
+func DecodeANSIC(quoted *syntax.SglQuoted) (string, bool) {
+	decoded, err := expand.Literal(nil, &syntax.Word{Parts: []syntax.WordPart{quoted}})
+	if err != nil {
+		return "", false
+	}
+	return decoded, true
+}
+
+// ExpandBraces performs brace expansion ({a,b}, {1..3}) on a word without
+// modifying it. It returns nil if the word has no brace expansion; braces
+// that expand to a single word, such as xargs' {}, are literal.
+func ExpandBraces(word *syntax.Word) []*syntax.Word {
+	split := &syntax.Word{Parts: slices.Clone(word.Parts)}
+	if !syntax.SplitBraces(split) {
+		return nil
+	}
+	if words := expand.Braces(split); len(words) > 1 {
+		return words
+	}
+	return nil
+}
+
+// ExpandBraceWords performs brace expansion on each of words, as the shell
+// does for the arguments of a command, e.g. rm {a,b} removes a and b. Words
+// without brace expansion are kept as they are. It reports false if the words
+// expand to more than limit words, or keep a brace list the parser cannot
+// expand, such as {..,.}, which the shell expands to .. and .
+func ExpandBraceWords(words []*syntax.Word, limit int) ([]*syntax.Word, bool) {
+	expanded := make([]*syntax.Word, 0, len(words))
+	for _, word := range words {
+		if braced := ExpandBraces(word); braced != nil {
+			expanded = append(expanded, braced...)
+		} else {
+			expanded = append(expanded, word)
+		}
+		if len(expanded) > limit {
+			return nil, false
+		}
+	}
+	if slices.ContainsFunc(expanded, hasBraceList) {
+		return nil, false
+	}
+	return expanded, true
+}
+
+// hasBraceList reports whether the unquoted text of a word has a brace list,
+// a { followed by a comma and a matching }, which the shell would expand.
+func hasBraceList(word *syntax.Word) bool {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		if lit, ok := part.(*syntax.Lit); ok {
+			sb.WriteString(lit.Value)
+		} else {
+			sb.WriteByte('_') // Quoted text and expansions are not brace syntax
+		}
+	}
+	text := sb.String()
+	var commas []bool // For each open brace, whether a comma follows it
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '{':
+			commas = append(commas, false)
+		case ',':
+			if len(commas) > 0 {
+				commas[len(commas)-1] = true
+			}
+		case '}':
+			if len(commas) > 0 {
+				if commas[len(commas)-1] {
+					return true
+				}
+				commas = commas[:len(commas)-1]
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+package shellparse
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+func TestStaticArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		expr       string
+		want       [][]string
+		wantStatic []bool
+	}{
+		{"simple", "npm install left-pad", [][]string{{"npm", "install", "left-pad"}}, []bool{true}},
+		{"quoted", `pip install "requests==2.0" 'flask'`, [][]string{{"pip", "install", "requests==2.0", "flask"}}, []bool{true}},
+		{"compound", "cd app && go get example.com/mod@v1", [][]string{{"cd", "app"}, {"go", "get", "example.com/mod@v1"}}, []bool{true, true}},
+		{"dynamic", "cargo add $CRATE", [][]string{{"cargo", "add", ""}}, []bool{false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			calls := CallExprs(node)
+			if len(calls) != len(tt.want) {
+				t.Fatalf("CallExprs() returned %d calls, want %d", len(calls), len(tt.want))
+			}
+			for i, call := range calls {
+				args, static := StaticArgs(call)
+				if !reflect.DeepEqual(args, tt.want[i]) || static != tt.wantStatic[i] {
+					t.Errorf("StaticArgs() = %q, %v, want %q, %v", args, static, tt.want[i], tt.wantStatic[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse("echo 'unterminated")
+	if !errors.Is(err, ErrParse) {
+		t.Errorf("Parse() of unterminated quotes = %v, want ErrParse", err)
+	}
+}
+
+func TestSubstitutedCalls(t *testing.T) {
+	node, err := Parse("echo $(git rev-parse HEAD) `date` && diff <(sort a) <(echo $(cat b)) && make build")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	substituted := SubstitutedCalls(node)
+	var got []string
+	for _, call := range CallExprs(node) {
+		if kind, ok := substituted[call]; ok {
+			got = append(got, kind+": "+Print(call))
+		}
+	}
+	want := []string{
+		"command substitution: git rev-parse HEAD",
+		"command substitution: date",
+		"process substitution: sort a",
+		"process substitution: echo $(cat b)",
+		"command substitution: cat b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SubstitutedCalls() = %q, want %q", got, want)
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	node, err := Parse("echo hi > out.txt 2>&1 && (cat <in >> log) | tee copy")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	var got []string
+	for _, redirect := range Redirects(node) {
+		got = append(got, redirect.Op.String()+" "+Print(redirect.Word))
+	}
+	want := []string{"> out.txt", ">& 1", "< in", ">> log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Redirects() = %q, want %q", got, want)
+	}
+}
+
+func TestStaticWord_ANSIC(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`$'\x67it'`, "git"},
+		{`$'\147it'`, "git"},
+		{`$'line\n'`, "line\n"},
+		{`'\x67it'`, `\x67it`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			node, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			got, isStatic := StaticWord(CallExprs(node)[0].Args[0])
+			if got != tt.want || !isStatic {
+				t.Errorf("StaticWord(%s) = %q, %v, want %q, true", tt.expr, got, isStatic, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"{git,push}", []string{"git", "push"}},
+		{"g{i,}t", []string{"git", "gt"}},
+		{"file{1..3}", []string{"file1", "file2", "file3"}},
+		{"plain", nil},
+		{"{}", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			node, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			word := CallExprs(node)[0].Args[0]
+			var got []string
+			for _, expanded := range ExpandBraces(word) {
+				got = append(got, Print(expanded))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandBraces(%s) = %q, want %q", tt.expr, got, tt.want)
+			}
+			if Print(word) != tt.expr {
+				t.Errorf("ExpandBraces() modified the word to %s", Print(word))
+			}
+		})
+	}
+}
+
+func TestExpandBraceWords(t *testing.T) {
+	node, err := Parse("rm -rf {.claude,x}/settings.{json,y} plain")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	words, ok := ExpandBraceWords(CallExprs(node)[0].Args, 10)
+	var got []string
+	for _, word := range words {
+		got = append(got, Print(word))
+	}
+	want := []string{"rm", "-rf", ".claude/settings.json", ".claude/settings.y", "x/settings.json", "x/settings.y", "plain"}
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandBraceWords() = %q, %v, want %q, true", got, ok, want)
+	}
+	if _, ok := ExpandBraceWords(CallExprs(node)[0].Args, 4); ok {
+		t.Error("ExpandBraceWords() over the limit reported true")
+	}
+
+	// The parser leaves lists with .. unexpanded, and literal braces are fine
+	for expr, want := range map[string]bool{"cp x {..,.}/y": false, "rm {a,..}": false, `find . -exec rm {} \;`: true, "echo '{a,b}' \\{a,b\\}": true} {
+		node, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse() error: %v", err)
+		}
+		if _, ok := ExpandBraceWords(CallExprs(node)[0].Args, 10); ok != want {
+			t.Errorf("ExpandBraceWords(%s) reported %v, want %v", expr, ok, want)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []Segment
+	}{
+		{"single", "git status", []Segment{{"git status", ""}}},
+		{"and", "git status && git push", []Segment{{"git status", "&&"}, {"git push", ""}}},
+		{"mixed operators", "make build && make test || echo failed; ls\npwd", []Segment{
+			{"make build", "&&"}, {"make test", "||"}, {"echo failed", ";"}, {"ls", ";"}, {"pwd", ""},
+		}},
+		{"pipeline is one segment", "git log | head -5 && git push", []Segment{{"git log | head -5", "&&"}, {"git push", ""}}},
+		{"nested list stays whole", "(cd x && make) && ls", []Segment{{"(cd x && make)", "&&"}, {"ls", ""}}},
+		{"negation binds to its command", "! a && b", []Segment{{"! a", "&&"}, {"b", ""}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			got := Segments(node)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Segments(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVariant(t *testing.T) {
+	tests := []struct {
+		expr    string
+		accepts []string // Dialects the expression parses in; it fails in the rest
+	}{
+		{"git status && git push", []string{"bash", "posix", "mksh", "bats"}},
+		{"a=(1 2); echo ${a[@]}", []string{"bash", "mksh", "bats"}},
+		{"diff <(sort a) b", []string{"bash", "bats"}},
+		{"function f { git push; }", []string{"bash", "mksh", "bats"}},
+		{"echo ${x/a/b}", []string{"bash", "mksh", "bats"}},
+		{"echo ${|git push;}", []string{"mksh"}},
+		{`@test "push" { git push; }`, []string{"bats"}},
+	}
+	for _, tt := range tests {
+		for _, name := range Dialects {
+			t.Run(name+"/"+tt.expr, func(t *testing.T) {
+				lang, err := ParseDialect(name)
+				if err != nil {
+					t.Fatalf("ParseDialect() error: %v", err)
+				}
+				_, err = ParseVariant(tt.expr, lang)
+				if want := slices.Contains(tt.accepts, name); (err == nil) != want {
+					t.Errorf("ParseVariant(%q, %s) error = %v, want parsed %v", tt.expr, name, err, want)
+				}
+			})
+		}
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	node, err := ParseContext(ctx, "git status && git push")
+	if err != nil || len(CallExprs(node)) != 2 {
+		t.Fatalf("ParseContext() = %v, %v, want two calls", node, err)
+	}
+	if _, err := ParseContext(ctx, "git push &&"); err == nil || errors.Is(err, context.Canceled) {
+		t.Errorf("ParseContext() of invalid syntax = %v, want a parse error", err)
+	}
+	cancel()
+	if _, err := ParseContext(ctx, "git status"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseContext() with a canceled context = %v, want context.Canceled", err)
+	}
+	if _, err := ParseVariantContext(context.Background(), "echo ${|git push;}", syntax.LangMirBSDKorn); err != nil {
+		t.Errorf("ParseVariantContext() error: %v", err)
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	for name, want := range map[string]syntax.LangVariant{
+		"bash": syntax.LangBash, "posix": syntax.LangPOSIX, "sh": syntax.LangPOSIX,
+		"mksh": syntax.LangMirBSDKorn, "bats": syntax.LangBats,
+	} {
+		if got, err := ParseDialect(name); err != nil || got != want {
+			t.Errorf("ParseDialect(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"auto", "zsh", ""} {
+		if _, err := ParseDialect(name); err == nil {
+			t.Errorf("ParseDialect(%q) should fail", name)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package shellparse - visitors over commands, pipelines, and redirections
+package shellparse
+
+import (
+	"slices"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Command is a command call with its words resolved, for hooks that inspect
+// commands without handling mvdan.cc/sh nodes themselves.
+type Command struct {
+	Name   string   // Command name as written, e.g. "git" or "/usr/bin/git"; "" for assignments only
+	Args   []string // Arguments after the name
+	Static bool     // Every word resolved completely; dynamic words hold only their static parts
+
+	// Substitution is CommandSubstitution or ProcessSubstitution when the
+	// command runs inside one, and "" otherwise.
+	Substitution string
+
+	Source string           // The call as shell source, for messages
+	Node   *syntax.CallExpr // The underlying node
+}
+
+// Pipeline is a pipeline of two or more stages, such as "git log | head".
+type Pipeline struct {
+	Stages []PipelineStage
+	Source string
+	Node   *syntax.BinaryCmd
+}
+
+// PipelineStage is one stage of a pipeline with the commands it runs, which
+// may be several for a stage like "(cd x && make)".
+type PipelineStage struct {
+	Commands []Command
+	Source   string
+}
+
+// Redirect is a redirection with its target resolved.
+type Redirect struct {
+	Op     string // Operator, e.g. ">", ">>", "<", "&>", or "<<"
+	Fd     string // Explicit file descriptor, e.g. "2" in 2>err.log; "" if none
+	Target string // Target file, descriptor, or heredoc delimiter
+	Static bool   // Target resolved completely
+	Writes bool   // Operator writes to the target, as > and >> do
+	Source string // The redirection as shell source, e.g. 2>/dev/null
+	Node   *syntax.Redirect
+}
+
+// writeOps are the redirection operators that write to their target.
+var writeOps = []syntax.RedirOperator{
+	syntax.RdrOut, syntax.AppOut, syntax.RdrAll, syntax.AppAll, syntax.ClbOut, syntax.RdrInOut,
+}
+
+// VisitCommands calls visit for every command call in the AST, depth-first,
+// including those in pipelines, lists, functions, and substitutions.
+// Visiting stops when visit returns false.
+func VisitCommands(node syntax.Node, visit func(Command) bool) {
+	substituted := SubstitutedCalls(node)
+	for _, call := range CallExprs(node) {
+		if !visit(newCommand(call, substituted[call])) {
+			return
+		}
+	}
+}
+
+// VisitPipelines calls visit for every pipeline in the AST, outermost first.
+// A pipeline's stages are flattened, so "a | b | c" is visited once with
+// three stages. Visiting stops when visit returns false.
+func VisitPipelines(node syntax.Node, visit func(Pipeline) bool) {
+	nested := make(map[*syntax.BinaryCmd]bool)
+	stopped := false
+	syntax.Walk(node, func(n syntax.Node) bool {
+		pipe, ok := n.(*syntax.BinaryCmd)
+		if stopped || !ok || !isPipe(pipe) || nested[pipe] {
+			return !stopped
+		}
+		pipeline := Pipeline{Source: Print(pipe), Node: pipe}
+		for _, stage := range PipelineStages(pipe) {
+			var commands []Command
+			VisitCommands(stage, func(command Command) bool {
+				commands = append(commands, command)
+				return true
+			})
+			pipeline.Stages = append(pipeline.Stages, PipelineStage{Commands: commands, Source: Print(stage)})
+		}
+		markNestedPipes(pipe, nested)
+		stopped = !visit(pipeline)
+		return !stopped
+	})
+}
+
+// VisitRedirects calls visit for every redirection in the AST, including
+// those inside substitutions and nested statements. Visiting stops when
+// visit returns false.
+func VisitRedirects(node syntax.Node, visit func(Redirect) bool) {
+	for _, redirect := range Redirects(node) {
+		target, isStatic := StaticWord(redirect.Word)
+		fd := ""
+		if redirect.N != nil {
+			fd = redirect.N.Value
+		}
+		if !visit(Redirect{
+			Op:     redirect.Op.String(),
+			Fd:     fd,
+			Target: target,
+			Static: isStatic,
+			Writes: slices.Contains(writeOps, redirect.Op),
+			Source: fd + redirect.Op.String() + Print(redirect.Word),
+			Node:   redirect,
+		}) {
+			return
+		}
+	}
+}
+
+// PipelineStages flattens a pipeline into its stages, left to right.
+func PipelineStages(pipeline *syntax.BinaryCmd) []*syntax.Stmt {
+	var stages []*syntax.Stmt
+	for _, side := range []*syntax.Stmt{pipeline.X, pipeline.Y} {
+		if inner, ok := side.Cmd.(*syntax.BinaryCmd); ok && isPipe(inner) {
+			stages = append(stages, PipelineStages(inner)...)
+		} else {
+			stages = append(stages, side)
+		}
+	}
+	return stages
+}
+
+// isPipe reports whether cmd is a pipe, | or |&.
+func isPipe(cmd *syntax.BinaryCmd) bool {
+	return cmd.Op == syntax.Pipe || cmd.Op == syntax.PipeAll
+}
+
+// markNestedPipes records the pipes flattened into pipeline, so they are not
+// visited as pipelines of their own.
+func markNestedPipes(pipeline *syntax.BinaryCmd, nested map[*syntax.BinaryCmd]bool) {
+	for _, side := range []*syntax.Stmt{pipeline.X, pipeline.Y} {
+		if inner, ok := side.Cmd.(*syntax.BinaryCmd); ok && isPipe(inner) {
+			nested[inner] = true
+			markNestedPipes(inner, nested)
+		}
+	}
+}
+
+// newCommand resolves the words of call.
+func newCommand(call *syntax.CallExpr, substitution string) Command {
+	command := Command{Static: true, Substitution: substitution, Source: Print(call), Node: call}
+	if len(call.Args) == 0 {
+		return command
+	}
+	args, allStatic := StaticArgs(call)
+	command.Name, command.Args, command.Static = args[0], args[1:], allStatic
+	return command
+}
@@ -0,0 +1,89 @@
+package shellparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVisitCommands(t *testing.T) {
+	node, err := Parse(`FOO=1; git -C "$dir" push && echo $(git rev-parse HEAD) | diff <(sort a) -`)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	var got []Command
+	VisitCommands(node, func(command Command) bool {
+		command.Node = nil
+		got = append(got, command)
+		return true
+	})
+	want := []Command{
+		{Static: true, Source: "FOO=1"},
+		{Name: "git", Args: []string{"-C", "", "push"}, Source: `git -C "$dir" push`},
+		{Name: "echo", Args: []string{""}, Source: "echo $(git rev-parse HEAD)"},
+		{Name: "git", Args: []string{"rev-parse", "HEAD"}, Static: true, Substitution: CommandSubstitution, Source: "git rev-parse HEAD"},
+		{Name: "diff", Args: []string{"", "-"}, Source: "diff <(sort a) -"},
+		{Name: "sort", Args: []string{"a"}, Static: true, Substitution: ProcessSubstitution, Source: "sort a"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VisitCommands() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	var names []string
+	VisitCommands(node, func(command Command) bool {
+		names = append(names, command.Name)
+		return command.Name != "git"
+	})
+	if want := []string{"", "git"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("VisitCommands() stopped after %q, want %q", names, want)
+	}
+}
+
+func TestVisitPipelines(t *testing.T) {
+	node, err := Parse("git log | grep fix | head -5 && (cd x && make) |& tee log; ls")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	var got [][]string
+	VisitPipelines(node, func(pipeline Pipeline) bool {
+		var stages []string
+		for _, stage := range pipeline.Stages {
+			var names []string
+			for _, command := range stage.Commands {
+				names = append(names, command.Name)
+			}
+			stages = append(stages, stage.Source+": "+strings.Join(names, ","))
+		}
+		got = append(got, stages)
+		return true
+	})
+	want := [][]string{
+		{"git log: git", "grep fix: grep", "head -5: head"},
+		{"(cd x && make): cd,make", "tee log: tee"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VisitPipelines() = %q, want %q", got, want)
+	}
+}
+
+func TestVisitRedirects(t *testing.T) {
+	node, err := Parse(`make 2>/dev/null >> "$log" < in.txt && echo $(cat x > out)`)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	var got []Redirect
+	VisitRedirects(node, func(redirect Redirect) bool {
+		redirect.Node = nil
+		got = append(got, redirect)
+		return true
+	})
+	want := []Redirect{
+		{Op: ">", Fd: "2", Target: "/dev/null", Static: true, Writes: true, Source: "2>/dev/null"},
+		{Op: ">>", Target: "", Writes: true, Source: `>>"$log"`},
+		{Op: "<", Target: "in.txt", Static: true, Source: "<in.txt"},
+		{Op: ">", Target: "out", Static: true, Writes: true, Source: ">out"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VisitRedirects() =\n%+v\nwant\n%+v", got, want)
+	}
+}